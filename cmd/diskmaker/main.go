@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/diskmaker"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 )
 
 var rootCmd = &cobra.Command{
@@ -28,6 +31,23 @@ var discoveryDaemonCmd = &cobra.Command{
 }
 
 func main() {
+	for _, cmd := range []*cobra.Command{managerCmd, lvDaemonCmd} {
+		cmd.Flags().Duration("device-cache-ttl", diskmaker.DefaultDeviceCacheTTL,
+			"how long a listing of the node's block devices is reused, unless udev reports a device change. 0 disables caching")
+		cmd.Flags().Duration(common.ResyncPeriodFlag, 0,
+			fmt.Sprintf("interval of the periodic reconciles of the controllers, at least %v. 0 keeps the default of each controller", common.MinResyncPeriod))
+		cmd.Flags().Bool(common.DiscoveryOnlyFlag, false,
+			"discover and match devices, but never format, symlink or clean them, nor create or delete PVs")
+		cmd.Flags().String(common.PVNodeAffinityKeyFlag, corev1.LabelHostname,
+			"node label key selected by the node affinity of new PVs")
+		cmd.Flags().String(common.MinFilesystemDeviceSizeFlag, "",
+			"size below which devices are not provisioned with volumeMode Filesystem. Defaults to "+common.DefaultMinFilesystemDeviceSize.String())
+		cmd.Flags().String(common.PVBackupAnnotationsFlag, "",
+			"comma separated key=value annotations of the PVs for backup tools, or "+common.PVBackupAnnotationsVelero+" for the Velero exclude-from-backup label. Empty adds none")
+		cmd.Flags().String(common.HostExcludeFileFlag, "",
+			"path of a file listing the serial numbers and paths of devices that are never touched. Empty disables it")
+		cmd.Flags().Int32(common.LogLevelFlag, 0, "verbosity of the logs, 0 being the least verbose")
+	}
 	rootCmd.AddCommand(lvDaemonCmd)
 	rootCmd.AddCommand(managerCmd)
 	rootCmd.AddCommand(discoveryDaemonCmd)
@@ -2,9 +2,13 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/openshift/local-storage-operator/pkg/apis"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/diskmaker"
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	"github.com/operator-framework/operator-sdk/pkg/log/zap"
 	"github.com/prometheus/common/log"
@@ -24,6 +28,23 @@ func startManager(cmd *cobra.Command, args []string) error {
 	klog.InitFlags(klogFlags)
 	flag.Set("alsologtostderr", "true")
 	flag.Parse()
+	opts := common.DefaultOptions()
+	var err error
+	opts.LogLevel, err = cmd.Flags().GetInt32(common.LogLevelFlag)
+	if err != nil {
+		return err
+	}
+	// both the zap logger of the controllers and klog, used by the static provisioner, log at the level
+	if opts.LogLevel > 0 {
+		err = klogFlags.Set("v", strconv.Itoa(int(opts.LogLevel)))
+		if err != nil {
+			return err
+		}
+		err = zap.FlagSet().Set("zap-level", strconv.Itoa(int(opts.LogLevel)))
+		if err != nil {
+			return err
+		}
+	}
 	// Use a zap logr.Logger implementation. If none of the zap
 	// flags are configured (or if the zap flag set is not being
 	// used), this defaults to a production zap logger.
@@ -36,6 +57,69 @@ func startManager(cmd *cobra.Command, args []string) error {
 
 	printVersion()
 
+	deviceCacheTTL, err := cmd.Flags().GetDuration("device-cache-ttl")
+	if err != nil {
+		return err
+	}
+	diskmaker.Devices.SetTTL(deviceCacheTTL)
+
+	opts.ResyncPeriod, err = cmd.Flags().GetDuration(common.ResyncPeriodFlag)
+	if err != nil {
+		return err
+	}
+
+	opts.DiscoveryOnly, err = cmd.Flags().GetBool(common.DiscoveryOnlyFlag)
+	if err != nil {
+		return err
+	}
+
+	opts.PVNodeAffinityKey, err = cmd.Flags().GetString(common.PVNodeAffinityKeyFlag)
+	if err != nil {
+		return err
+	}
+
+	minFilesystemDeviceSize, err := cmd.Flags().GetString(common.MinFilesystemDeviceSizeFlag)
+	if err != nil {
+		return err
+	}
+	opts.MinFilesystemDeviceSize, err = common.ParseMinFilesystemDeviceSize(minFilesystemDeviceSize)
+	if err != nil {
+		return err
+	}
+
+	pvBackupAnnotations, err := cmd.Flags().GetString(common.PVBackupAnnotationsFlag)
+	if err != nil {
+		return err
+	}
+	opts.PVBackupAnnotations, opts.PVBackupLabels, err = common.ParsePVBackupMetadata(pvBackupAnnotations)
+	if err != nil {
+		return err
+	}
+
+	opts.HostExcludeFile, err = cmd.Flags().GetString(common.HostExcludeFileFlag)
+	if err != nil {
+		return err
+	}
+
+	err = opts.Validate()
+	if err != nil {
+		return err
+	}
+	if opts.DiscoveryOnly {
+		log.Info("running in discovery-only mode, devices are not provisioned nor cleaned")
+	}
+
+	// a missing or unreadable file excludes nothing until it can be read
+	if err := diskmaker.LoadHostExcludes(opts.HostExcludeFile); err != nil {
+		log.Errorf("failed to load the host exclude file: %v", err)
+	}
+
+	// don't exit when the host paths are unavailable: the diskmaker reports NotReady with the reason
+	// and the reconciles check them again later, instead of the pod crash-looping
+	if err := diskmaker.CheckHostPaths(opts.DiscoveryOnly); err != nil {
+		log.Errorf("%s: %v, devices are not provisioned until the host paths are available", diskmaker.HostPathUnavailable, err)
+	}
+
 	namespace, err := k8sutil.GetWatchNamespace()
 	if err != nil {
 		log.Error(err, "Failed to get watch namespace")
@@ -51,9 +135,12 @@ func startManager(cmd *cobra.Command, args []string) error {
 
 	// Set default manager options
 	options := manager.Options{
-		Namespace:          namespace,
-		MetricsBindAddress: "0",
-		LeaderElection:     false,
+		Namespace:              namespace,
+		MetricsBindAddress:     fmt.Sprintf(":%d", common.DiskMakerMetricsPort),
+		LeaderElection:         false,
+		HealthProbeBindAddress: fmt.Sprintf(":%d", common.DiskMakerHealthProbePort),
+		ReadinessEndpointName:  common.DiskMakerReadinessPath,
+		LivenessEndpointName:   common.DiskMakerLivenessPath,
 	}
 
 	// Add support for MultiNamespace set in WATCH_NAMESPACE (e.g ns1,ns2)
@@ -78,16 +165,39 @@ func startManager(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	err = diskmakerController.AddToManager(mgr)
+	err = diskmakerController.AddToManager(mgr, opts)
 	if err != nil {
 		log.Error(err, "failed to add controllers to manager")
 	}
 
+	if err := mgr.AddReadyzCheck("discovery", diskmaker.ReadyzCheck); err != nil {
+		log.Error(err, "failed to add readiness check")
+		return err
+	}
+	if err := mgr.AddHealthzCheck("reconcile", diskmaker.HealthzCheck); err != nil {
+		log.Error(err, "failed to add liveness check")
+		return err
+	}
+
 	// Start the Cmd
 	stopChan := signals.SetupSignalHandler()
+	// reconcile as soon as devices are hot-plugged, and keep the device cache fresh
+	go diskmaker.WatchDevices(stopChan)
+	// reconcile when the node admins change the host exclude file
+	go diskmaker.WatchHostExcludes(opts.HostExcludeFile, stopChan)
+	// ready after the first successful device listing, whether or not a controller lists the devices
+	go diskmaker.RunInitialDiscovery(diskmaker.Devices, stopChan)
 	if err := mgr.Start(stopChan); err != nil {
 		log.Error(err, "manager exited non-zero")
 		return err
 	}
+
+	// the manager returns as soon as SIGTERM is received, give device operations
+	// that are halfway through (symlink created, PV not yet) a chance to finish.
+	// An interrupted operation is picked up again by the next diskmaker run,
+	// which creates the PV for an existing symlink.
+	if !diskmaker.WaitForDeviceOperations(diskmaker.ShutdownGracePeriod) {
+		log.Info("timed out waiting for in-flight device operations to finish")
+	}
 	return nil
 }
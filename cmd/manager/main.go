@@ -5,8 +5,11 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -14,7 +17,10 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/openshift/local-storage-operator/pkg/apis"
+	"github.com/openshift/local-storage-operator/pkg/common"
 	"github.com/openshift/local-storage-operator/pkg/controller"
+	"github.com/openshift/local-storage-operator/pkg/webhook"
+	"github.com/openshift/local-storage-operator/version"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	kubemetrics "github.com/operator-framework/operator-sdk/pkg/kube-metrics"
@@ -27,17 +33,24 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 )
 
 // Change below variables to serve metrics on different host or port.
+// An empty host listens on all addresses of both IP families, so that the endpoints
+// are reachable on IPv4-only, IPv6-only and dual-stack clusters.
 var (
-	metricsHost               = "0.0.0.0"
+	metricsHost               = ""
+	webhookHost               = ""
+	healthProbeHost           = ""
 	metricsPort         int32 = 8383
 	operatorMetricsPort int32 = 8686
-	version                   = "unknown"
+	webhookPort               = 9443
+	healthProbePort           = 8081
+	webhookCertDir            = "/tmp/k8s-webhook-server/serving-certs"
 )
 var log = logf.Log.WithName("cmd")
 
@@ -45,7 +58,7 @@ func printVersion() {
 	log.Info(fmt.Sprintf("Go Version: %s", runtime.Version()))
 	log.Info(fmt.Sprintf("Go OS/Arch: %s/%s", runtime.GOOS, runtime.GOARCH))
 	log.Info(fmt.Sprintf("Version of operator-sdk: %v", sdkVersion.Version))
-	log.Info(fmt.Sprintf("local-storage-operator Version: %s", version))
+	log.Info(fmt.Sprintf("local-storage-operator Version: %s", version.Version))
 }
 
 func main() {
@@ -57,6 +70,46 @@ func main() {
 	// controller-runtime)
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 
+	pflag.StringVar(&metricsHost, "metrics-bind-host", metricsHost, "address the metrics servers listen on, all addresses if empty")
+	pflag.StringVar(&webhookHost, "webhook-bind-host", webhookHost, "address the webhook server listens on, all addresses if empty")
+	pflag.StringVar(&healthProbeHost, "health-probe-bind-host", healthProbeHost, "address the health probes are served on, all addresses if empty")
+	// the options of the diskmaker are passed on to the diskmaker DaemonSet
+	opts := common.DefaultOptions()
+	pflag.DurationVar(&opts.ResyncPeriod, common.ResyncPeriodFlag, opts.ResyncPeriod,
+		fmt.Sprintf("interval of the periodic reconciles of the operator and the diskmaker, at least %v. 0 keeps the defaults", common.MinResyncPeriod))
+	pflag.BoolVar(&opts.DiscoveryOnly, common.DiscoveryOnlyFlag, opts.DiscoveryOnly,
+		"run the diskmaker in discovery-only mode: devices are discovered and matched, but never formatted, symlinked or cleaned, and no PVs are created or deleted")
+	pflag.StringVar(&opts.PVNodeAffinityKey, common.PVNodeAffinityKeyFlag, opts.PVNodeAffinityKey,
+		"node label key selected by the node affinity of new PVs, for example the topology key of a CSI driver")
+
+	minFilesystemDeviceSize := pflag.String(common.MinFilesystemDeviceSizeFlag, "",
+		fmt.Sprintf("size below which the diskmaker doesn't provision devices with volumeMode Filesystem, as mkfs would fail on them. Defaults to %s", common.DefaultMinFilesystemDeviceSize.String()))
+
+	pvBackupAnnotations := pflag.String(common.PVBackupAnnotationsFlag, "",
+		fmt.Sprintf("comma separated key=value annotations the diskmaker adds to the PVs for backup tools, or %q for the Velero exclude-from-backup label. Empty adds none", common.PVBackupAnnotationsVelero))
+
+	pflag.StringVar(&opts.DiskMakerSecurityContext, common.DiskMakerSecurityContextFlag, opts.DiskMakerSecurityContext,
+		fmt.Sprintf("%q runs the diskmaker as a privileged container, %q with the capabilities it needs only, unless devices are bind-mounted",
+			common.DiskMakerSecurityContextPrivileged, common.DiskMakerSecurityContextCapabilities))
+
+	pflag.DurationVar(&opts.NodeNotReadyGracePeriod, common.NodeNotReadyGracePeriodFlag, opts.NodeNotReadyGracePeriod,
+		"how long a node must be NotReady before the operator leaves its PVs and taints alone and marks its PVs, until it is ready again")
+
+	pflag.StringVar(&opts.HostExcludeFile, common.HostExcludeFileFlag, opts.HostExcludeFile,
+		"path of a file on the nodes listing the serial numbers and paths of devices the diskmaker never touches, managed outside Kubernetes. Empty disables it")
+
+	pflag.BoolVar(&opts.DisallowDefaultStorageClass, common.DisallowDefaultStorageClassFlag, opts.DisallowDefaultStorageClass,
+		"never set or unset the default StorageClass annotation, the setAsDefault of LocalVolumes is ignored")
+
+	pflag.StringVar(&opts.DiscoveryResultConfigMapNamespace, common.DiscoveryResultConfigMapNamespaceFlag, opts.DiscoveryResultConfigMapNamespace,
+		"namespace the LocalVolumeDiscoveryResults are mirrored to as one ConfigMap per node, for tools that can't read custom resources. Empty disables it")
+
+	reconcileEndpoint := pflag.Bool(common.ReconcileEndpointFlag, false,
+		"serve "+webhook.ReconcilePath+" on the webhook server, a POST with the bearer token of a user that can update LocalVolumes and LocalVolumeSets reconciles all objects")
+
+	storageClassNamePattern := pflag.String(common.StorageClassNamePatternFlag, "",
+		"regular expression the StorageClass names of new LocalVolumes and LocalVolumeSets must match, enforced by the admission webhooks. Empty allows every name")
+
 	pflag.Parse()
 
 	// Use a zap logr.Logger implementation. If none of the zap
@@ -94,8 +147,36 @@ func main() {
 
 	// Set default manager options
 	options := manager.Options{
-		Namespace:          namespace,
-		MetricsBindAddress: fmt.Sprintf("%s:%d", metricsHost, metricsPort),
+		Namespace:              namespace,
+		MetricsBindAddress:     net.JoinHostPort(metricsHost, strconv.Itoa(int(metricsPort))),
+		Host:                   webhookHost,
+		Port:                   webhookPort,
+		CertDir:                webhookCertDir,
+		HealthProbeBindAddress: net.JoinHostPort(healthProbeHost, strconv.Itoa(healthProbePort)),
+	}
+	opts.MinFilesystemDeviceSize, err = common.ParseMinFilesystemDeviceSize(*minFilesystemDeviceSize)
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	opts.PVBackupAnnotations, opts.PVBackupLabels, err = common.ParsePVBackupMetadata(*pvBackupAnnotations)
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	opts.StorageClassNamePattern, err = common.ParseStorageClassNamePattern(*storageClassNamePattern)
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	// the diskmaker logs as verbosely as the operator, unless LocalVolumeSets set their logLevel
+	opts.LogLevel = common.LogLevelFromZapLevel(zap.FlagSet().Lookup("zap-level").Value.String())
+	if err := opts.Validate(); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	if opts.ResyncPeriod != 0 {
+		options.SyncPeriod = &opts.ResyncPeriod
 	}
 
 	// Add support for MultiNamespace set in WATCH_NAMESPACE (e.g ns1,ns2)
@@ -123,7 +204,37 @@ func main() {
 	}
 
 	// Setup all Controllers
-	if err := controller.AddToManager(mgr); err != nil {
+	if err := controller.AddToManager(mgr, opts); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
+	// Setup all admission webhooks, the webhook server can only be started
+	// when serving certificates have been mounted (e.g. by OLM)
+	if _, err := os.Stat(filepath.Join(webhookCertDir, "tls.crt")); err == nil {
+		if err := webhook.AddToManager(mgr, opts); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+		if *reconcileEndpoint {
+			if err := webhook.AddReconcileEndpoint(mgr, namespace); err != nil {
+				log.Error(err, "")
+				os.Exit(1)
+			}
+		}
+	} else {
+		log.Info("Skipping admission webhooks; no serving certificate found.", "certDir", webhookCertDir)
+		if *reconcileEndpoint {
+			log.Info("Skipping the reconcile endpoint; no serving certificate found.", "certDir", webhookCertDir)
+		}
+	}
+
+	// Serve /healthz and /readyz for the deployment's probes
+	if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
 		log.Error(err, "")
 		os.Exit(1)
 	}
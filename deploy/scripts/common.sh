@@ -13,7 +13,7 @@
 # limitations under the License.
 
 function errorExit {
-    echo $@
+    echo "$@"
     exit 1
 }
 
@@ -18,7 +18,7 @@
 # $ quick_reset.sh
 
 # Import common functions.
-. $(dirname "$0")/common.sh
+. "$(dirname "$0")"/common.sh
 
 if [ "$1" == "-h" ]; then
   echo "Usage: $(basename $0) "
@@ -32,9 +32,9 @@ fi
 validateBlockDevice
 
 echo "Calling mkfs"
-ionice -c 3 mkfs -F $LOCAL_PV_BLKDEVICE
+ionice -c 3 mkfs -F "$LOCAL_PV_BLKDEVICE"
 
 echo "Calling wipefs"
-ionice -c 3 wipefs -a $LOCAL_PV_BLKDEVICE
+ionice -c 3 wipefs -a "$LOCAL_PV_BLKDEVICE"
 
 echo "Quick reset completed"
@@ -30,6 +30,8 @@ sed -i "s,quay.io/openshift/origin-local-storage-operator,${IMAGE_LOCAL_STORAGE_
 sed -i "s,quay.io/openshift/origin-local-storage-diskmaker,${IMAGE_LOCAL_DISKMAKER}," ${manifest}
 export TEST_NAMESPACE=${TEST_NAMESPACE:-default}
 export TEST_LOCAL_DISK=${TEST_LOCAL_DISK:-""}
+# image of the jobs that consume the PVs, set it to a mirror of busybox in disconnected clusters
+export TEST_CONSUMER_IMAGE=${TEST_CONSUMER_IMAGE:-gcr.io/google_containers/busybox}
 
 export \
     IMAGE_LOCAL_STORAGE_OPERATOR \
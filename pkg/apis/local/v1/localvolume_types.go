@@ -1,9 +1,17 @@
 package v1
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	operatorv1 "github.com/openshift/api/operator/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // LocalVolumeSpec defines the desired state of LocalVolume
@@ -18,13 +26,73 @@ type LocalVolumeSpec struct {
 	// Nodes on which the provisoner must run
 	// +optional
 	NodeSelector *corev1.NodeSelector `json:"nodeSelector,omitempty"`
+	// PVNamePrefix is prepended to the names of the PVs created for this object instead of "local-pv-",
+	// followed by a hash that keeps the names unique. Existing PVs keep their name when it is changed.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9][-a-z0-9]*$`
+	// +optional
+	PVNamePrefix string `json:"pvNamePrefix,omitempty"`
+	// PVLabels are added to the PVs created for this object. They never replace the labels the operator sets,
+	// and labels removed from the list are left on the existing PVs.
+	// Keys with the storage.openshift.com/, local.storage.openshift.io/, kubernetes.io/ and k8s.io/ prefixes are reserved.
+	// +optional
+	PVLabels map[string]string `json:"pvLabels,omitempty"`
+	// PVAnnotations are added to the PVs created for this object. They never replace the annotations the operator
+	// sets, and annotations removed from the list are left on the existing PVs.
+	// Keys with the storage.openshift.com/, local.storage.openshift.io/, kubernetes.io/ and k8s.io/ prefixes are reserved.
+	// +optional
+	PVAnnotations map[string]string `json:"pvAnnotations,omitempty"`
+	// CleanupTimeout bounds how long the cleanup of a released PV may run, for example "6h".
+	// A PV whose cleanup runs longer is annotated with storage.openshift.com/cleanup-timed-out,
+	// reported with a CleanupTimedOut event and quarantined: it is neither deleted nor recreated
+	// until the annotation is removed. Block cleanups are killed shortly after the timeout.
+	// Cleanups are not bounded when it is unset.
+	// +optional
+	CleanupTimeout *metav1.Duration `json:"cleanupTimeout,omitempty"`
+	// ProvisionerConfig sets tunables of the static provisioner, which are written to the provisioner ConfigMap.
+	// Only the minResyncPeriod and deletionBackoff keys are accepted, as durations like "1m".
+	// The ConfigMap is shared by all LocalVolumes and LocalVolumeSets, the shortest value of each key is used.
+	// +optional
+	ProvisionerConfig map[string]string `json:"provisionerConfig,omitempty"`
+	// DeviceSettleGracePeriod is how long the diskmaker waits after it first sees a device before it symlinks it,
+	// for udev to create the /dev/disk/by-id links of hot-plugged devices. Defaults to 5s, 0s disables the wait.
+	// Devices that are already symlinked are not delayed.
+	// +optional
+	DeviceSettleGracePeriod *metav1.Duration `json:"deviceSettleGracePeriod,omitempty"`
+	// MinimumProvisionedCount is the number of PVs that need to exist for this object before its
+	// Available condition is set to true. Defaults to 1, 0 only waits for the diskmaker DaemonSet.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinimumProvisionedCount *int32 `json:"minimumProvisionedCount,omitempty"`
 	// List of storage class and devices they can match
 	StorageClassDevices []StorageClassDevice `json:"storageClassDevices,omitempty"`
 	// If specified, a list of tolerations to pass to the diskmaker and provisioner DaemonSets.
 	// +optional
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// DeletionPolicy sets what the operator does while a deleted LocalVolume waits for its bound PVs to be released.
+	// WaitForUnbound, the default, only waits. DrainThenDelete also unsets the default annotation of its StorageClasses
+	// and marks them deprecated with the local.storage.openshift.io/deprecated annotation, to discourage new PVCs.
+	// The LocalVolume is deleted once no PV is bound with both policies.
+	// +kubebuilder:validation:Enum=WaitForUnbound;DrainThenDelete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// ManagePersistentVolumes, when false, leaves the PVs of the devices to another tool: the diskmaker discovers,
+	// formats and symlinks the devices in the storageclass directories, but never creates or deletes PVs.
+	// The symlinks are removed from the nodes when the object is deleted. Defaults to true.
+	// +optional
+	ManagePersistentVolumes *bool `json:"managePersistentVolumes,omitempty"`
 }
 
+// DeletionPolicy is what the operator does while a deleted LocalVolume has bound PVs
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyWaitForUnbound keeps a deleted LocalVolume until its PVs are released
+	DeletionPolicyWaitForUnbound DeletionPolicy = "WaitForUnbound"
+	// DeletionPolicyDrainThenDelete also deprecates its StorageClasses while its PVs are released
+	DeletionPolicyDrainThenDelete DeletionPolicy = "DrainThenDelete"
+)
+
 // PersistentVolumeMode describes how a volume is intended to be consumed, either Block or Filesystem.
 type PersistentVolumeMode string
 
@@ -33,21 +101,86 @@ const (
 	PersistentVolumeBlock PersistentVolumeMode = "Block"
 	// PersistentVolumeFilesystem means the volume will be or is formatted with a filesystem.
 	PersistentVolumeFilesystem PersistentVolumeMode = "Filesystem"
+
+	// Ext4FSType is the fsType of Filesystem volumes that don't set one
+	Ext4FSType = "ext4"
+
+	// DefaultMinimumProvisionedCount is the number of PVs needed for a LocalVolume to be Available
+	// when spec.minimumProvisionedCount is unset
+	DefaultMinimumProvisionedCount int32 = 1
+
+	// MinPeriodicTrimInterval is the shortest periodicTrim interval, trimming more often only wears the devices
+	MinPeriodicTrimInterval = time.Hour
 )
 
 // StorageClassDevice returns device configuration
 type StorageClassDevice struct {
 	// StorageClass name to use for set of matched devices
 	StorageClassName string `json:"storageClassName"`
-	// Volume mode. Raw or with file system
-	// + optional
+	// Volume mode. Raw or with file system. Defaults to Filesystem.
+	// +optional
 	VolumeMode PersistentVolumeMode `json:"volumeMode,omitempty"`
 	// File system type
 	// +optional
 	FSType string `json:"fsType,omitempty"`
+	// AccessModes are the access modes of the PVs created for the devices, ReadWriteOnce by default.
+	// Local volumes are only reachable from their node, so only ReadWriteOnce and ReadOnlyMany are allowed.
+	// Existing PVs keep their access modes when it is changed.
+	// +optional
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	// UseBindMount makes the diskmaker bind-mount the devices into the local-storage
+	// directory instead of symlinking them, for container runtimes that can't resolve
+	// symlinked volume paths. Defaults to false (symlink).
+	// The devices are bind-mounted again after a reboot, and unmounted when their PVs are deleted.
+	// +optional
+	UseBindMount bool `json:"useBindMount,omitempty"`
+	// SetAsDefault makes the StorageClass the cluster default, and unsets the default
+	// annotation from the other StorageClasses of this LocalVolume.
+	// Setting it back to false removes the default annotation the operator set.
+	// +optional
+	SetAsDefault bool `json:"setAsDefault,omitempty"`
+	// DisableLazyInit makes the diskmaker format blank devices with ext4 before creating their PVs,
+	// initializing the inode tables and the journal at format time instead of in the background
+	// after the first mount. Formatting takes longer, minutes on large disks, but the first writes
+	// to the volume don't compete with the background initialization.
+	// Only applies to Filesystem volumes with the ext4 fsType.
+	// +optional
+	DisableLazyInit bool `json:"disableLazyInit,omitempty"`
+	// PeriodicTrim is the interval at which the diskmaker runs fstrim on the mounted filesystems of the PVs,
+	// to discard their unused blocks on SSDs. It is at least 1h, the PVs are not trimmed when it is unset.
+	// Only applies to Filesystem volumes on devices that support discard, the last trim of each PV
+	// is recorded in its local.storage.openshift.io/last-trim annotation.
+	// +optional
+	PeriodicTrim *metav1.Duration `json:"periodicTrim,omitempty"`
+	// ManageStorageClass makes the operator create and own the StorageClass. When set to false,
+	// the StorageClass must already exist and the operator only provisions PVs for it,
+	// leaving the StorageClass as it is. Defaults to true.
+	// +optional
+	ManageStorageClass *bool `json:"manageStorageClass,omitempty"`
 	// A list of device paths which would be chosen for local storage.
 	// For example - ["/dev/sda", "/dev/sdb", "/dev/disk/by-id/ata-crucial"]
 	DevicePaths []string `json:"devicePaths,omitempty"`
+	// SourceDir provisions Filesystem PVs from subdirectories of a directory on the node
+	// instead of from devices, for nodes without spare block devices.
+	// devicePaths are ignored when it is set.
+	// +optional
+	SourceDir *SourceDir `json:"sourceDir,omitempty"`
+}
+
+// SourceDir describes the subdirectories the diskmaker creates in a directory on the node,
+// each of which is bind-mounted into the local-storage directory and provisioned as a PV.
+type SourceDir struct {
+	// Path is the absolute path of the directory on the node
+	// +kubebuilder:validation:Pattern=`^/`
+	Path string `json:"path"`
+	// Count is the number of subdirectories, and so PVs, to create.
+	// Lowering it doesn't remove subdirectories or PVs that already exist.
+	// +kubebuilder:validation:Minimum=1
+	Count int32 `json:"count"`
+	// Capacity is the capacity advertised by each PV. It is not enforced on the filesystem,
+	// the PVs share the free space of the directory's filesystem. The subdirectories are not
+	// provisioned if count * capacity exceeds the size of the filesystem.
+	Capacity resource.Quantity `json:"capacity"`
 }
 
 // LocalVolumeStatus defines the observed state of LocalVolume
@@ -68,6 +201,40 @@ type LocalVolumeStatus struct {
 	// generations are used to determine when an item needs to be reconciled or has changed in a way that needs a reaction.
 	// +optional
 	Generations []operatorv1.GenerationStatus `json:"generations,omitempty"`
+
+	// ObservedOperatorVersion is the version of the operator that last reconciled this object
+	// +optional
+	ObservedOperatorVersion string `json:"observedOperatorVersion,omitempty"`
+
+	// ObservedProvisionerVersion is the image tag of the diskmaker that provisions the PVs of this object
+	// +optional
+	ObservedProvisionerVersion string `json:"observedProvisionerVersion,omitempty"`
+
+	// ObservedRescan is the value of the local.storage.openshift.io/rescan annotation that was last reconciled
+	// +optional
+	ObservedRescan string `json:"observedRescan,omitempty"`
+
+	// SymlinkedNodes are the nodes where the diskmaker symlinked devices while managePersistentVolumes is false,
+	// or bind-mounted devices with useBindMount. A deleted object is kept until the diskmakers of these nodes
+	// have removed the symlinks and the bind mounts.
+	// +optional
+	SymlinkedNodes []string `json:"symlinkedNodes,omitempty"`
+
+	// NodesWithErrors are the nodes matching the node selector whose diskmaker pod is failing, with its latest error
+	// +optional
+	NodesWithErrors []NodeError `json:"nodesWithErrors,omitempty"`
+}
+
+// NodeError is the latest error of the diskmaker pod of a node
+type NodeError struct {
+	// NodeName is the name of the node
+	NodeName string `json:"nodeName"`
+	// Reason is a machine-readable reason for the error, like CrashLoopBackOff or ImagePullBackOff
+	Reason string `json:"reason"`
+	// Message sums up the error
+	Message string `json:"message"`
+	// LastTransitionTime is when the diskmaker pod of the node started to fail with this reason
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -92,7 +259,7 @@ type LocalVolumeList struct {
 	Items           []LocalVolume `json:"items"`
 }
 
-// SetDefaults sets values of log level and manage levels
+// SetDefaults sets values of log level, manage levels and volume modes
 func (local *LocalVolume) SetDefaults() {
 	if len(local.Spec.LogLevel) == 0 {
 		local.Spec.LogLevel = operatorv1.Normal
@@ -101,6 +268,159 @@ func (local *LocalVolume) SetDefaults() {
 	if len(local.Spec.ManagementState) == 0 {
 		local.Spec.ManagementState = operatorv1.Managed
 	}
+
+	for i := range local.Spec.StorageClassDevices {
+		if len(local.Spec.StorageClassDevices[i].VolumeMode) == 0 {
+			local.Spec.StorageClassDevices[i].VolumeMode = PersistentVolumeFilesystem
+		}
+	}
+}
+
+// GetMinimumProvisionedCount returns the number of PVs needed for the LocalVolume to be Available
+func (local *LocalVolume) GetMinimumProvisionedCount() int32 {
+	if local.Spec.MinimumProvisionedCount == nil {
+		return DefaultMinimumProvisionedCount
+	}
+	return *local.Spec.MinimumProvisionedCount
+}
+
+// ManagesPersistentVolumes returns true if the diskmaker creates and deletes the PVs of the LocalVolume
+func (local *LocalVolume) ManagesPersistentVolumes() bool {
+	return local.Spec.ManagePersistentVolumes == nil || *local.Spec.ManagePersistentVolumes
+}
+
+// ManagesStorageClass returns true if the operator creates and owns the StorageClass of the devices
+func (d StorageClassDevice) ManagesStorageClass() bool {
+	return d.ManageStorageClass == nil || *d.ManageStorageClass
+}
+
+// Validate returns an error if fields of the devices contradict each other
+func (d StorageClassDevice) Validate() error {
+	if d.VolumeMode == PersistentVolumeBlock && d.FSType != "" {
+		return fmt.Errorf("storageClass %s sets fsType %s with volumeMode Block, Block volumes are not formatted", d.StorageClassName, d.FSType)
+	}
+	err := d.ValidateAccessModes()
+	if err != nil {
+		return err
+	}
+	err = d.ValidatePeriodicTrim()
+	if err != nil {
+		return err
+	}
+	return d.ValidateDisableLazyInit()
+}
+
+// ValidatePeriodicTrim returns an error if PeriodicTrim is shorter than MinPeriodicTrimInterval
+func (d StorageClassDevice) ValidatePeriodicTrim() error {
+	if d.PeriodicTrim != nil && d.PeriodicTrim.Duration < MinPeriodicTrimInterval {
+		return fmt.Errorf("storageClass %s sets periodicTrim %s, it must be at least %s", d.StorageClassName, d.PeriodicTrim.Duration, MinPeriodicTrimInterval)
+	}
+	return nil
+}
+
+// ValidateAccessModes returns an error if AccessModes has modes that a volume attached to a single node can't offer
+func (d StorageClassDevice) ValidateAccessModes() error {
+	for _, mode := range d.AccessModes {
+		switch mode {
+		case corev1.ReadWriteOnce, corev1.ReadOnlyMany:
+		case corev1.ReadWriteMany:
+			return fmt.Errorf("storageClass %s sets accessMode %s, local volumes are only reachable from their node and can't be written from several nodes", d.StorageClassName, mode)
+		default:
+			return fmt.Errorf("storageClass %s sets unsupported accessMode %s, only %s and %s are allowed", d.StorageClassName, mode, corev1.ReadWriteOnce, corev1.ReadOnlyMany)
+		}
+	}
+	return nil
+}
+
+// ValidateDisableLazyInit returns an error if DisableLazyInit is set on devices that are not formatted with ext4
+func (d StorageClassDevice) ValidateDisableLazyInit() error {
+	if !d.DisableLazyInit {
+		return nil
+	}
+	if d.VolumeMode == PersistentVolumeBlock {
+		return fmt.Errorf("storageClass %s sets disableLazyInit, which does not apply to Block volumes", d.StorageClassName)
+	}
+	if d.FSType != "" && d.FSType != Ext4FSType {
+		return fmt.Errorf("storageClass %s sets disableLazyInit, which only applies to the %s fsType, not %s", d.StorageClassName, Ext4FSType, d.FSType)
+	}
+	return nil
+}
+
+// reservedPVMetadataPrefixes are the prefixes of the label and annotation keys that the operator and Kubernetes
+// manage on PVs, which pvLabels and pvAnnotations cannot set
+var reservedPVMetadataPrefixes = []string{"storage.openshift.com/", "local.storage.openshift.io/", "kubernetes.io/", "k8s.io/"}
+
+// ValidatePVMetadata returns an error if the keys of pvLabels or pvAnnotations, or the values of pvLabels, are not legal
+func ValidatePVMetadata(pvLabels, pvAnnotations map[string]string) error {
+	for key, value := range pvLabels {
+		err := validatePVMetadataKey("pvLabels", key)
+		if err != nil {
+			return err
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("invalid pvLabels value %q of key %q: %s", value, key, strings.Join(errs, "; "))
+		}
+	}
+	for key := range pvAnnotations {
+		err := validatePVMetadataKey("pvAnnotations", key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validatePVMetadataKey(field, key string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Errorf("invalid %s key %q: %s", field, key, strings.Join(errs, "; "))
+	}
+	for _, prefix := range reservedPVMetadataPrefixes {
+		// kubernetes.io/ and k8s.io/ also reserve their subdomains, like pv.kubernetes.io/
+		if strings.HasPrefix(key, prefix) || strings.Contains(key, "."+prefix) {
+			return fmt.Errorf("invalid %s key %q: the %s prefix is reserved", field, key, prefix)
+		}
+	}
+	return nil
+}
+
+// Default is called by the mutating admission webhook so that the defaults
+// are recorded on the stored object.
+func (local *LocalVolume) Default() {
+	local.SetDefaults()
+}
+
+// ValidateCreate is called by the validating admission webhook
+func (local *LocalVolume) ValidateCreate() error {
+	err := ValidatePVMetadata(local.Spec.PVLabels, local.Spec.PVAnnotations)
+	if err != nil {
+		return err
+	}
+	err = ValidateProvisionerConfig(local.Spec.ProvisionerConfig)
+	if err != nil {
+		return err
+	}
+	for _, device := range local.Spec.StorageClassDevices {
+		err := device.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateUpdate is called by the validating admission webhook. Updates that leave the spec
+// unchanged are allowed, so that the finalizer and status of existing LocalVolumes can be updated.
+func (local *LocalVolume) ValidateUpdate(old runtime.Object) error {
+	oldLocal, ok := old.(*LocalVolume)
+	if ok && equality.Semantic.DeepEqual(oldLocal.Spec, local.Spec) {
+		return nil
+	}
+	return local.ValidateCreate()
+}
+
+// ValidateDelete is called by the validating admission webhook
+func (local *LocalVolume) ValidateDelete() error {
+	return nil
 }
 
 func init() {
@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// ProvisionerConfigMinResyncPeriod is the provisionerConfig key of the minResyncPeriod of the static provisioner,
+	// the interval at which the diskmaker checks the released PVs
+	ProvisionerConfigMinResyncPeriod = "minResyncPeriod"
+	// ProvisionerConfigDeletionBackoff is the provisionerConfig key of how long the diskmaker waits
+	// before it retries the failed cleanup of a released PV
+	ProvisionerConfigDeletionBackoff = "deletionBackoff"
+)
+
+// provisionerConfigRanges are the accepted provisionerConfig keys with the bounds of their durations
+var provisionerConfigRanges = map[string]struct{ min, max time.Duration }{
+	// shorter periods would list the PVs in a hot loop
+	ProvisionerConfigMinResyncPeriod: {min: 5 * time.Second, max: time.Hour},
+	ProvisionerConfigDeletionBackoff: {min: 0, max: 24 * time.Hour},
+}
+
+// ValidateProvisionerConfig returns an error if provisionerConfig has a key that is not a tunable of the
+// static provisioner exposed by the operator, or a value that is not a duration within the bounds of its key
+func ValidateProvisionerConfig(provisionerConfig map[string]string) error {
+	for key, value := range provisionerConfig {
+		bounds, found := provisionerConfigRanges[key]
+		if !found {
+			keys := make([]string, 0, len(provisionerConfigRanges))
+			for key := range provisionerConfigRanges {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			return fmt.Errorf("unknown provisionerConfig key %q, the supported keys are %s", key, strings.Join(keys, ", "))
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid provisionerConfig %s %q: %v", key, value, err)
+		}
+		if duration < bounds.min || duration > bounds.max {
+			return fmt.Errorf("invalid provisionerConfig %s %q: it must be between %v and %v", key, value, bounds.min, bounds.max)
+		}
+	}
+	return nil
+}
+
+// MergeProvisionerConfigs returns the provisionerConfig of the ConfigMap shared by the objects of the configs:
+// the shortest duration of each key. The configs that fail ValidateProvisionerConfig are ignored.
+func MergeProvisionerConfigs(configs ...map[string]string) map[string]string {
+	shortest := map[string]time.Duration{}
+	for _, config := range configs {
+		if ValidateProvisionerConfig(config) != nil {
+			continue
+		}
+		for key, value := range config {
+			duration, _ := time.ParseDuration(value)
+			if current, found := shortest[key]; !found || duration < current {
+				shortest[key] = duration
+			}
+		}
+	}
+	merged := map[string]string{}
+	for key, duration := range shortest {
+		merged[key] = duration.String()
+	}
+	return merged
+}
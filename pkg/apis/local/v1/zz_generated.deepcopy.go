@@ -7,6 +7,7 @@ package v1
 import (
 	operatorv1 "github.com/openshift/api/operator/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -74,11 +75,47 @@ func (in *LocalVolumeList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocalVolumeSpec) DeepCopyInto(out *LocalVolumeSpec) {
 	*out = *in
+	if in.PVLabels != nil {
+		in, out := &in.PVLabels, &out.PVLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PVAnnotations != nil {
+		in, out := &in.PVAnnotations, &out.PVAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
 		*out = new(corev1.NodeSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CleanupTimeout != nil {
+		in, out := &in.CleanupTimeout, &out.CleanupTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ProvisionerConfig != nil {
+		in, out := &in.ProvisionerConfig, &out.ProvisionerConfig
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DeviceSettleGracePeriod != nil {
+		in, out := &in.DeviceSettleGracePeriod, &out.DeviceSettleGracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MinimumProvisionedCount != nil {
+		in, out := &in.MinimumProvisionedCount, &out.MinimumProvisionedCount
+		*out = new(int32)
+		**out = **in
+	}
 	if in.StorageClassDevices != nil {
 		in, out := &in.StorageClassDevices, &out.StorageClassDevices
 		*out = make([]StorageClassDevice, len(*in))
@@ -93,6 +130,11 @@ func (in *LocalVolumeSpec) DeepCopyInto(out *LocalVolumeSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ManagePersistentVolumes != nil {
+		in, out := &in.ManagePersistentVolumes, &out.ManagePersistentVolumes
+		*out = new(bool)
+		**out = **in
+	}
 	return
 }
 
@@ -126,6 +168,18 @@ func (in *LocalVolumeStatus) DeepCopyInto(out *LocalVolumeStatus) {
 		*out = make([]operatorv1.GenerationStatus, len(*in))
 		copy(*out, *in)
 	}
+	if in.SymlinkedNodes != nil {
+		in, out := &in.SymlinkedNodes, &out.SymlinkedNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodesWithErrors != nil {
+		in, out := &in.NodesWithErrors, &out.NodesWithErrors
+		*out = make([]NodeError, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -139,14 +193,68 @@ func (in *LocalVolumeStatus) DeepCopy() *LocalVolumeStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeError) DeepCopyInto(out *NodeError) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeError.
+func (in *NodeError) DeepCopy() *NodeError {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeError)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SourceDir) DeepCopyInto(out *SourceDir) {
+	*out = *in
+	out.Capacity = in.Capacity.DeepCopy()
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SourceDir.
+func (in *SourceDir) DeepCopy() *SourceDir {
+	if in == nil {
+		return nil
+	}
+	out := new(SourceDir)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageClassDevice) DeepCopyInto(out *StorageClassDevice) {
 	*out = *in
+	if in.AccessModes != nil {
+		in, out := &in.AccessModes, &out.AccessModes
+		*out = make([]corev1.PersistentVolumeAccessMode, len(*in))
+		copy(*out, *in)
+	}
 	if in.DevicePaths != nil {
 		in, out := &in.DevicePaths, &out.DevicePaths
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.SourceDir != nil {
+		in, out := &in.SourceDir, &out.SourceDir
+		*out = new(SourceDir)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ManageStorageClass != nil {
+		in, out := &in.ManageStorageClass, &out.ManageStorageClass
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PeriodicTrim != nil {
+		in, out := &in.PeriodicTrim, &out.PeriodicTrim
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	return
 }
 
@@ -38,6 +38,11 @@ type LocalVolumeDiscoverySpec struct {
 	// LocalVolumeDiscovery Daemon
 	// +optional
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// SelfTest runs a provisioning self-test on each node when the discovery starts on it: a loop device
+	// backed by a scratch file is formatted, mounted, written and read back, then removed. The disks of the node
+	// are never touched. The result is the SelfTestPassed condition of the LocalVolumeDiscoveryResult of the node.
+	// +optional
+	SelfTest bool `json:"selfTest,omitempty"`
 }
 
 // LocalVolumeDiscoveryStatus defines the observed state of LocalVolumeDiscovery
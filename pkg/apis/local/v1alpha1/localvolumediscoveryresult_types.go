@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	operatorv1 "github.com/openshift/api/operator/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -46,6 +47,51 @@ type DiscoveredDevice struct {
 	Status DeviceStatus `json:"status"`
 }
 
+// DeviceRejectionReason is a machine-readable reason why a device is not provisioned.
+// The reasons shared with LocalVolumeSets are also the reasons of the events they record for the device.
+type DeviceRejectionReason string
+
+const (
+	// RejectedReadOnly devices are read-only
+	RejectedReadOnly DeviceRejectionReason = "ReadOnly"
+	// RejectedHasChildren devices have partitions or other child devices
+	RejectedHasChildren DeviceRejectionReason = "HasChildren"
+	// RejectedRAIDMember devices are members of a software RAID array
+	RejectedRAIDMember DeviceRejectionReason = "RAIDMember"
+	// RejectedSuspended devices are suspended device-mapper devices
+	RejectedSuspended DeviceRejectionReason = "Suspended"
+	// RejectedUnsupportedType devices are neither disks, partitions nor LVM logical volumes
+	RejectedUnsupportedType DeviceRejectionReason = "UnsupportedType"
+	// RejectedHasFilesystem devices have a filesystem signature
+	RejectedHasFilesystem DeviceRejectionReason = "HasFilesystem"
+	// RejectedBiosBootPartLabel devices have "bios" or "boot" in their partition label
+	RejectedBiosBootPartLabel DeviceRejectionReason = "BiosBootPartLabel"
+	// RejectedBindMounted devices are bind-mounted on the node
+	RejectedBindMounted DeviceRejectionReason = "BindMounted"
+	// RejectedDeviceInUse devices can't be opened exclusively or are held by other devices
+	RejectedDeviceInUse DeviceRejectionReason = "DeviceInUse"
+	// RejectedDeviceQuarantined devices failed to be provisioned too often
+	RejectedDeviceQuarantined DeviceRejectionReason = "DeviceQuarantined"
+	// RejectedDeviceTooSmallForFilesystem devices are too small for mkfs
+	RejectedDeviceTooSmallForFilesystem DeviceRejectionReason = "DeviceTooSmallForFilesystem"
+	// RejectedCheckFailed devices could not be checked, the message has the error
+	RejectedCheckFailed DeviceRejectionReason = "CheckFailed"
+)
+
+// RejectedDevice is a device of the node that will not be provisioned
+type RejectedDevice struct {
+	// Path represents the device path. For eg, /dev/sdb
+	Path string `json:"path"`
+	// DeviceID represents the persistent name of the device, if it has one. For eg, /dev/disk/by-id/...
+	// +optional
+	DeviceID string `json:"deviceID,omitempty"`
+	// Reason is a machine-readable code for why the device is rejected
+	Reason DeviceRejectionReason `json:"reason"`
+	// Message explains the reason
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // LocalVolumeDiscoveryResultSpec defines the desired state of LocalVolumeDiscoveryResult
 type LocalVolumeDiscoveryResultSpec struct {
 	// Node on which the devices are discovered
@@ -66,8 +112,19 @@ type LocalVolumeDiscoveryResultStatus struct {
 	// - it should not have child partitions
 	// +optional
 	DiscoveredDevices []DiscoveredDevice `json:"discoveredDevices"`
+	// RejectedDevices lists the devices of the node that are ignored by the discovery or not available,
+	// with the reason why they won't be provisioned
+	// +optional
+	RejectedDevices []RejectedDevice `json:"rejectedDevices,omitempty"`
+	// Conditions of the node, the SelfTestPassed condition is set when the LocalVolumeDiscovery runs a self-test
+	// +optional
+	Conditions []operatorv1.OperatorCondition `json:"conditions,omitempty"`
 }
 
+// SelfTestPassedCondition is the condition of a LocalVolumeDiscoveryResult with the result of the provisioning
+// self-test of the node, false with the step that failed in the message
+const SelfTestPassedCondition = "SelfTestPassed"
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // LocalVolumeDiscoveryResult is the Schema for the localvolumediscoveryresults API
@@ -6,6 +6,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeviceMechanicalProperty holds the device's mechanical spec. It can be rotational or nonRotational
@@ -31,6 +32,29 @@ const (
 	Loop DeviceType = "loop"
 )
 
+// DeviceSelectionStrategy determines the order in which matched devices are
+// provisioned when the devices are capped by MaxDeviceCount or MaxCapacityPerNode.
+type DeviceSelectionStrategy string
+
+const (
+	// SmallestFirst provisions the smallest matching devices first
+	SmallestFirst DeviceSelectionStrategy = "smallestFirst"
+	// LargestFirst provisions the largest matching devices first
+	LargestFirst DeviceSelectionStrategy = "largestFirst"
+	// PathOrder provisions matching devices in lexical order of their kernel name (e.g. sda before sdb)
+	PathOrder DeviceSelectionStrategy = "pathOrder"
+)
+
+// CapacityRounding determines how the capacity of the PVs is derived from the size of their device
+type CapacityRounding string
+
+const (
+	// CapacityRoundingExact sets the capacity of the PVs to the exact size of their device in bytes
+	CapacityRoundingExact CapacityRounding = "exact"
+	// CapacityRoundingFloorGiB rounds the capacity of the PVs down to a whole number of GiB
+	CapacityRoundingFloorGiB CapacityRounding = "floorGiB"
+)
+
 // DeviceInclusionSpec holds the inclusion filter spec
 type DeviceInclusionSpec struct {
 	// Devices is the list of devices that should be used for automatic detection.
@@ -56,6 +80,112 @@ type DeviceInclusionSpec struct {
 	// to contain at least one of these strings.
 	// +optional
 	Vendors []string `json:"vendors,omitempty"`
+	// ExcludeBySerial is a list of device serial numbers, as outputted by lsblk, that are never provisioned.
+	// An unbound PV of an excluded device is removed, a bound one is left in place.
+	// +optional
+	ExcludeBySerial []string `json:"excludeBySerial,omitempty"`
+	// ReserveBySerial is a list of device serial numbers, as outputted by lsblk, that are reserved for future use:
+	// a matching device is listed in status.reservedDevices but neither formatted nor provisioned.
+	// Removing its serial from the list provisions it. A device that is already provisioned is left in place.
+	// +optional
+	ReserveBySerial []string `json:"reserveBySerial,omitempty"`
+	// PartLabels is a list of GPT partition names. If not empty, the device's partition label as outputted
+	// by lsblk needs to be one of these strings. Only partitions have a label, so DeviceTypes needs to include `part`.
+	// +optional
+	PartLabels []string `json:"partLabels,omitempty"`
+	// FSLabels is a list of filesystem labels, as read by blkid. If not empty, the device needs to have a filesystem
+	// with one of these labels, and the device is provisioned with its filesystem and data in place.
+	// It requires reattachExisting, the filesystem must also match fsType.
+	// +optional
+	FSLabels []string `json:"fsLabels,omitempty"`
+	// Transports is a list of device transports, like nvme, sata, sas, iscsi or fc. If not empty, the device's
+	// transport as outputted by lsblk needs to be one of these strings, partitions use the transport of their disk.
+	// +optional
+	Transports []string `json:"transports,omitempty"`
+	// MinIOPS is the minimum provisioned IOPS of the devices to include. The IOPS of the devices of a node are read
+	// by serial number from the local.storage.openshift.io/device-iops annotation of the node, a device that is not
+	// listed there is not included. On nodes without the annotation, MinIOPS is ignored and a warning event is recorded.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinIOPS *int64 `json:"minIOPS,omitempty"`
+	// MinStableDuration is how long the diskmaker must have continuously seen a device, with the same serial number
+	// and not read-only, before it provisions it. A device that disappears, changes serial number or turns read-only,
+	// like during controller firmware updates, starts over. Devices that are already provisioned are not affected.
+	// +optional
+	MinStableDuration *metav1.Duration `json:"minStableDuration,omitempty"`
+}
+
+// DeviceRoute provisions the devices that carry a tag in a StorageClass.
+// Exactly one of PartLabel and UdevTag is set.
+type DeviceRoute struct {
+	// PartLabel matches the partitions with this GPT partition label
+	// +optional
+	PartLabel string `json:"partLabel,omitempty"`
+	// UdevTag matches the devices with this udev tag, as set by a TAG+= udev rule
+	// +optional
+	UdevTag string `json:"udevTag,omitempty"`
+	// StorageClassName is the StorageClass of the PVs of the matching devices
+	StorageClassName string `json:"storageClassName"`
+}
+
+// UnroutedDevicePolicy determines what happens to the devices that match no DeviceRoute
+type UnroutedDevicePolicy string
+
+const (
+	// UnroutedDevicesDefault provisions the devices that match no route in StorageClassName
+	UnroutedDevicesDefault UnroutedDevicePolicy = "Default"
+	// UnroutedDevicesReject doesn't provision the devices that match no route
+	UnroutedDevicesReject UnroutedDevicePolicy = "Reject"
+)
+
+// DeviceWriteProbeMode determines which regions of a device the write probe tests
+type DeviceWriteProbeMode string
+
+const (
+	// DeviceWriteProbeSample tests the unused regions, that only hold zeros, among the first, middle and last MiB
+	// of the device and zeroes them again
+	DeviceWriteProbeSample DeviceWriteProbeMode = "Sample"
+	// DeviceWriteProbeFull tests the whole device, which destroys its content
+	DeviceWriteProbeFull DeviceWriteProbeMode = "Full"
+)
+
+// DeviceWriteProbe makes the diskmaker write a test pattern to a device and read it back before it provisions the device
+type DeviceWriteProbe struct {
+	// Mode is the region of the device that is tested, Sample by default
+	// +kubebuilder:validation:Enum=Sample;Full
+	// +optional
+	Mode DeviceWriteProbeMode `json:"mode,omitempty"`
+}
+
+// ProvisioningWindow is a time window that recurs on some or all days of the week
+type ProvisioningWindow struct {
+	// Start is the time of day the window opens, as HH:MM in 24-hour format
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+	// End is the time of day the window closes, as HH:MM in 24-hour format.
+	// A window whose end is before its start spans midnight, and one whose end is its start lasts the whole day.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	End string `json:"end"`
+	// Days are the days of the week on which the window opens, like in a crontab: Sun, Mon, Tue, Wed, Thu, Fri or Sat.
+	// The window opens every day if it is empty.
+	// +optional
+	Days []string `json:"days,omitempty"`
+	// TimeZone is the IANA name of the time zone of Start and End, like Europe/Paris. Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// NodeOverride overrides fields of the DeviceInclusionSpec on the nodes it selects
+type NodeOverride struct {
+	// NodeName selects a node by name
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+	// NodeSelector selects nodes by their labels
+	// +optional
+	NodeSelector *corev1.NodeSelector `json:"nodeSelector,omitempty"`
+	// DeviceInclusionSpec fields that are set replace the corresponding fields of
+	// spec.deviceInclusionSpec on the selected nodes
+	DeviceInclusionSpec DeviceInclusionSpec `json:"deviceInclusionSpec"`
 }
 
 // LocalVolumeSetSpec defines the desired state of LocalVolumeSet
@@ -69,6 +199,46 @@ type LocalVolumeSetSpec struct {
 	// If it is not specified, there will be no limit to the number of provisioned devices.
 	// +optional
 	MaxDeviceCount *int32 `json:"maxDeviceCount,omitempty"`
+	// MaxCapacityPerNode is the maximum total size of the devices provisioned on each node.
+	// Devices are considered in the order of DeviceSelectionStrategy, a device that would exceed it
+	// is skipped and the next devices may still fit. If it is not specified, the capacity is not limited.
+	// +optional
+	MaxCapacityPerNode *resource.Quantity `json:"maxCapacityPerNode,omitempty"`
+	// MaxNodeCount limits how many of the nodes matching NodeSelector provision devices,
+	// for example to roll a LocalVolumeSet out to a few nodes first. The nodes are listed in
+	// status.selectedNodes: nodes that were selected before stay selected while they match,
+	// further nodes are added in name order. Lowering it doesn't remove the PVs of deselected nodes.
+	// If it is not specified, all matching nodes provision devices.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxNodeCount *int32 `json:"maxNodeCount,omitempty"`
+	// CleanupOnNodeRemoval releases the devices of nodes that no longer match NodeSelector:
+	// their unbound PVs are deleted and, on nodes that still run the diskmaker, their symlinks are removed.
+	// Bound PVs are left in place and reported in the BoundVolumesOnRemovedNodes condition.
+	// +optional
+	CleanupOnNodeRemoval bool `json:"cleanupOnNodeRemoval,omitempty"`
+	// DeviceSelectionStrategy determines which of the matched devices are provisioned
+	// first when MaxDeviceCount or MaxCapacityPerNode limit the devices. One of smallestFirst, largestFirst or pathOrder.
+	// Devices of equal size are ordered by their kernel name. Defaults to pathOrder.
+	// +kubebuilder:validation:Enum=smallestFirst;largestFirst;pathOrder
+	// +optional
+	DeviceSelectionStrategy DeviceSelectionStrategy `json:"deviceSelectionStrategy,omitempty"`
+	// CapacityRounding determines the capacity of new PVs: exact is the size of the device in bytes,
+	// floorGiB rounds it down to a whole number of GiB, devices smaller than 1GiB are not provisioned.
+	// By default, the size is rounded down to a whole number of GiB if that is at least 10GiB,
+	// else to a whole number of MiB if that is at least 10MiB, else it is exact.
+	// Existing PVs keep their capacity when it is changed.
+	// +kubebuilder:validation:Enum=exact;floorGiB
+	// +optional
+	CapacityRounding CapacityRounding `json:"capacityRounding,omitempty"`
+	// SymlinkTargetPreference is the ordered list of the links of a device that the diskmaker symlinks
+	// in the storageclass directory, which becomes the path of its PV: by-id, by-path or by-uuid for the links
+	// in /dev/disk/by-id/, /dev/disk/by-path/ and /dev/disk/by-uuid/. The first type of link the device has is used,
+	// the lexicographically first link of that type if it has several, /dev/<KNAME> if it has none of them.
+	// Defaults to by-id. Devices that are already symlinked keep their symlink, and devices with a PV keep the
+	// link recorded in its local.storage.openshift.io/device-alias annotation.
+	// +optional
+	SymlinkTargetPreference []string `json:"symlinkTargetPreference,omitempty"`
 	// VolumeMode determines whether the PV created is Block or Filesystem.
 	// It will default to Filesystem.
 	// +optional
@@ -76,12 +246,106 @@ type LocalVolumeSetSpec struct {
 	// FSType type to create when volumeMode is Filesystem
 	// +optional
 	FSType string `json:"fsType,omitempty"`
+	// ReattachExisting provisions devices that already have an ext4 or xfs filesystem matching
+	// fsType (ext4 if fsType is empty), keeping the data on them, instead of skipping them.
+	// It only applies when volumeMode is Filesystem. Use it to move disks with data between nodes,
+	// together with a storageclass whose reclaimPolicy is Retain.
+	// +optional
+	ReattachExisting bool `json:"reattachExisting,omitempty"`
+	// AllowDevicesWithHolders provisions devices that other devices are built on, such as LVM physical volumes
+	// or device-mapper targets, as listed in /sys/class/block/<device>/holders. These are skipped by default.
+	// +optional
+	AllowDevicesWithHolders bool `json:"allowDevicesWithHolders,omitempty"`
+	// PVNamePrefix is prepended to the names of the PVs created for this object instead of "local-pv-",
+	// followed by a hash that keeps the names unique. Existing PVs keep their name when it is changed.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9][-a-z0-9]*$`
+	// +optional
+	PVNamePrefix string `json:"pvNamePrefix,omitempty"`
+	// PVLabels are added to the PVs created for this object. They never replace the labels the operator sets,
+	// and labels removed from the list are left on the existing PVs.
+	// Keys with the storage.openshift.com/, local.storage.openshift.io/, kubernetes.io/ and k8s.io/ prefixes are reserved.
+	// +optional
+	PVLabels map[string]string `json:"pvLabels,omitempty"`
+	// PVAnnotations are added to the PVs created for this object. They never replace the annotations the operator
+	// sets, and annotations removed from the list are left on the existing PVs.
+	// Keys with the storage.openshift.com/, local.storage.openshift.io/, kubernetes.io/ and k8s.io/ prefixes are reserved.
+	// +optional
+	PVAnnotations map[string]string `json:"pvAnnotations,omitempty"`
+	// CleanupTimeout bounds how long the cleanup of a released PV may run, for example "6h".
+	// A PV whose cleanup runs longer is annotated with storage.openshift.com/cleanup-timed-out,
+	// reported with a CleanupTimedOut event and quarantined: it is neither deleted nor recreated
+	// until the annotation is removed. Block cleanups are killed shortly after the timeout.
+	// Cleanups are not bounded when it is unset.
+	// +optional
+	CleanupTimeout *metav1.Duration `json:"cleanupTimeout,omitempty"`
+	// ProvisionerConfig sets tunables of the static provisioner, which are written to the provisioner ConfigMap.
+	// Only the minResyncPeriod and deletionBackoff keys are accepted, as durations like "1m".
+	// The ConfigMap is shared by all LocalVolumes and LocalVolumeSets, the shortest value of each key is used.
+	// +optional
+	ProvisionerConfig map[string]string `json:"provisionerConfig,omitempty"`
+	// QuarantineThreshold is the number of consecutive provisioning failures after which a device
+	// is quarantined: it is no longer retried until it is replaced by a device with another serial number.
+	// Defaults to 5, 0 disables quarantining.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	QuarantineThreshold *int32 `json:"quarantineThreshold,omitempty"`
+	// DeviceWriteProbe makes the diskmaker write a test pattern to every new device and read it back before it
+	// provisions the device. Devices that fail the probe are reported with a DeviceWriteProbeFailed event and count
+	// as provisioning failures towards the quarantineThreshold. Devices with a filesystem signature are never
+	// probed. Only set it for pristine disks: the Full mode destroys the content of the devices.
+	// +optional
+	DeviceWriteProbe *DeviceWriteProbe `json:"deviceWriteProbe,omitempty"`
+	// ProvisioningWindow confines the provisioning of new devices to a recurring time window, like off-hours:
+	// outside of it, the diskmaker neither formats nor provisions the devices it didn't provision yet, and the
+	// ProvisioningDeferred condition is true. The existing PVs, their cleanup and their recreation are not affected.
+	// New devices are provisioned at any time if it is unset.
+	// +optional
+	ProvisioningWindow *ProvisioningWindow `json:"provisioningWindow,omitempty"`
+	// DiskMakerMinAvailable opts into a PodDisruptionBudget for the pods of the diskmaker daemonset, which provisions
+	// and cleans the devices, with this minAvailable: a number or a percentage of the scheduled pods.
+	// The daemonset is shared by all LocalVolumeSets and LocalVolumes, the strictest value of the LocalVolumeSets is used.
+	// Evictions that ignore daemonsets, like oc adm drain --ignore-daemonsets, are not limited by it.
+	// +optional
+	DiskMakerMinAvailable *intstr.IntOrString `json:"diskMakerMinAvailable,omitempty"`
+	// LogLevel is the verbosity of the diskmaker logs, like klog's -v. The diskmaker daemonset is shared by all
+	// LocalVolumeSets and LocalVolumes, it logs with the highest logLevel of the LocalVolumeSets.
+	// Defaults to the level of the operator.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	LogLevel *int32 `json:"logLevel,omitempty"`
+	// NodeTaint, if set, is applied by the operator to the nodes that provision devices for this object,
+	// so that only workloads tolerating it are scheduled there. It is removed from nodes that no longer match
+	// and from all nodes when the object is deleted. A taint with the same key and effect that a node already
+	// has from another source is left as it is. The diskmaker tolerates it.
+	// +optional
+	NodeTaint *corev1.Taint `json:"nodeTaint,omitempty"`
 	// If specified, a list of tolerations to pass to the discovery daemons.
 	// +optional
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
 	// DeviceInclusionSpec is the filtration rule for including a device in the device discovery
 	// +optional
 	DeviceInclusionSpec *DeviceInclusionSpec `json:"deviceInclusionSpec,omitempty"`
+	// NodeOverrides override deviceInclusionSpec fields on the nodes they select.
+	// A node selected by several overrides gets all of them applied in list order,
+	// so a field set by a later override takes precedence over an earlier one.
+	// +optional
+	NodeOverrides []NodeOverride `json:"nodeOverrides,omitempty"`
+	// DeviceRouting provisions each matched device in the StorageClass of the first route whose tag it carries,
+	// instead of StorageClassName. The operator creates the StorageClasses of the routes like the one of StorageClassName.
+	// +optional
+	DeviceRouting []DeviceRoute `json:"deviceRouting,omitempty"`
+	// UnroutedDevicePolicy determines what happens to the matched devices that carry the tag of no route of
+	// DeviceRouting: Default provisions them in StorageClassName, Reject leaves them alone. Defaults to Default.
+	// +kubebuilder:validation:Enum=Default;Reject
+	// +optional
+	UnroutedDevicePolicy UnroutedDevicePolicy `json:"unroutedDevicePolicy,omitempty"`
+	// ManagePersistentVolumes, when false, leaves the PVs of the devices to another tool: the diskmaker discovers,
+	// formats and symlinks the devices in the storageclass directories, but never creates or deletes PVs.
+	// The symlinks are removed from the nodes when the object is deleted. Defaults to true.
+	// +optional
+	ManagePersistentVolumes *bool `json:"managePersistentVolumes,omitempty"`
 }
 
 // LocalVolumeSetStatus defines the observed state of LocalVolumeSet
@@ -90,9 +354,108 @@ type LocalVolumeSetStatus struct {
 	Conditions []operatorv1.OperatorCondition `json:"conditions,omitempty"`
 	// TotalProvisionedDeviceCount is the count of the total devices over which the PVs has been provisioned
 	TotalProvisionedDeviceCount *int32 `json:"totalProvisionedDeviceCount,omitempty"`
+	// NodeCount is the number of nodes that provision devices: the nodes matching NodeSelector,
+	// or the selected nodes when maxNodeCount is set
+	// +optional
+	NodeCount *int32 `json:"nodeCount,omitempty"`
+	// RejectedDeviceCount is the number of quarantined devices
+	// +optional
+	RejectedDeviceCount *int32 `json:"rejectedDeviceCount,omitempty"`
+	// Summary sums up the counts of the status in one line, such as "12 nodes, 144 devices, 140 PVs, 4 rejected".
+	// The devices are the provisioned and the rejected devices. It is updated together with the counts.
+	// +optional
+	Summary string `json:"summary,omitempty"`
 	// observedGeneration is the last generation change the operator has dealt with
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// ObservedOperatorVersion is the version of the operator that last reconciled this object
+	// +optional
+	ObservedOperatorVersion string `json:"observedOperatorVersion,omitempty"`
+	// ObservedProvisionerVersion is the image tag of the diskmaker that provisions the PVs of this object
+	// +optional
+	ObservedProvisionerVersion string `json:"observedProvisionerVersion,omitempty"`
+	// QuarantinedDevices are the devices that failed provisioning quarantineThreshold times in a row
+	// and are no longer retried
+	// +optional
+	QuarantinedDevices []QuarantinedDevice `json:"quarantinedDevices,omitempty"`
+	// ReservedDevices are the devices that match the LocalVolumeSet but are not provisioned
+	// because their serial is listed in reserveBySerial
+	// +optional
+	ReservedDevices []ReservedDevice `json:"reservedDevices,omitempty"`
+	// SelectedNodes are the names of the nodes that provision devices when maxNodeCount is set
+	// +optional
+	SelectedNodes []string `json:"selectedNodes,omitempty"`
+	// ObservedRescan is the value of the local.storage.openshift.io/rescan annotation that was last reconciled
+	// +optional
+	ObservedRescan string `json:"observedRescan,omitempty"`
+	// NodeCapacities are the total sizes of the devices provisioned on each node
+	// +optional
+	NodeCapacities []NodeCapacity `json:"nodeCapacities,omitempty"`
+	// ManagedDaemonSets is the rollout status of the DaemonSets that provision the devices of this object
+	// +optional
+	ManagedDaemonSets []ManagedDaemonSet `json:"managedDaemonSets,omitempty"`
+	// SymlinkedNodes are the nodes where the diskmaker symlinked devices while managePersistentVolumes is false.
+	// A deleted object is kept until the diskmakers of these nodes have removed the symlinks.
+	// +optional
+	SymlinkedNodes []string `json:"symlinkedNodes,omitempty"`
+	// NodesWithErrors are the nodes matching the node selector whose diskmaker pod is failing, with its latest error
+	// +optional
+	NodesWithErrors []localv1.NodeError `json:"nodesWithErrors,omitempty"`
+}
+
+// ManagedDaemonSet is the rollout status of a DaemonSet managed by the operator
+type ManagedDaemonSet struct {
+	// Name is the name of the DaemonSet
+	Name string `json:"name"`
+	// DesiredNumberScheduled is the number of nodes that should be running the daemon pod
+	DesiredNumberScheduled int32 `json:"desiredNumberScheduled"`
+	// NumberReady is the number of nodes running a ready daemon pod
+	NumberReady int32 `json:"numberReady"`
+	// Ready is true when the DaemonSet observed its latest generation and all its daemon pods are ready
+	Ready bool `json:"ready"`
+	// Generation is the generation of the DaemonSet
+	Generation int64 `json:"generation"`
+	// ObservedGeneration is the generation of the DaemonSet observed by its controller,
+	// it is stale while it is lower than generation
+	ObservedGeneration int64 `json:"observedGeneration"`
+}
+
+// NodeCapacity is the total size of the devices provisioned on a node
+type NodeCapacity struct {
+	// NodeName is the name of the node
+	NodeName string `json:"nodeName"`
+	// ProvisionedCapacity is the total size of the devices of the node that have a PV
+	ProvisionedCapacity resource.Quantity `json:"provisionedCapacity"`
+}
+
+// QuarantinedDevice is a device that is no longer provisioned because it failed repeatedly
+type QuarantinedDevice struct {
+	// NodeName is the name of the node the device is attached to
+	NodeName string `json:"nodeName"`
+	// DevicePath is the /dev path of the device
+	DevicePath string `json:"devicePath"`
+	// Serial is the serial number or partition UUID of the device.
+	// The quarantine is lifted when a device with another serial number takes its place.
+	// +optional
+	Serial string `json:"serial,omitempty"`
+	// FailureCount is the number of consecutive provisioning failures of the device
+	FailureCount int32 `json:"failureCount"`
+	// Message is the last provisioning error of the device
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ReservedDevice is a matching device that is not provisioned because it is reserved for future use
+type ReservedDevice struct {
+	// NodeName is the name of the node the device is attached to
+	NodeName string `json:"nodeName"`
+	// DevicePath is the /dev path of the device
+	DevicePath string `json:"devicePath"`
+	// Serial is the serial number of the device, as listed in reserveBySerial
+	Serial string `json:"serial"`
+	// Size is the size of the device in bytes
+	// +optional
+	Size string `json:"size,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -117,6 +480,24 @@ type LocalVolumeSetList struct {
 	Items           []LocalVolumeSet `json:"items"`
 }
 
+// ManagesPersistentVolumes returns true if the diskmaker creates and deletes the PVs of the LocalVolumeSet
+func (lvset *LocalVolumeSet) ManagesPersistentVolumes() bool {
+	return lvset.Spec.ManagePersistentVolumes == nil || *lvset.Spec.ManagePersistentVolumes
+}
+
+// StorageClassNames returns StorageClassName followed by the other StorageClasses of DeviceRouting, without duplicates
+func (lvset *LocalVolumeSet) StorageClassNames() []string {
+	names := []string{lvset.Spec.StorageClassName}
+	seen := map[string]bool{lvset.Spec.StorageClassName: true}
+	for _, route := range lvset.Spec.DeviceRouting {
+		if !seen[route.StorageClassName] {
+			seen[route.StorageClassName] = true
+			names = append(names, route.StorageClassName)
+		}
+	}
+	return names
+}
+
 func init() {
 	SchemeBuilder.Register(&LocalVolumeSet{}, &LocalVolumeSetList{})
 }
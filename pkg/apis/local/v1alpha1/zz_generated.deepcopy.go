@@ -6,8 +6,11 @@ package v1alpha1
 
 import (
 	operatorv1 "github.com/openshift/api/operator/v1"
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	intstr "k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -43,6 +46,41 @@ func (in *DeviceInclusionSpec) DeepCopyInto(out *DeviceInclusionSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ExcludeBySerial != nil {
+		in, out := &in.ExcludeBySerial, &out.ExcludeBySerial
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReserveBySerial != nil {
+		in, out := &in.ReserveBySerial, &out.ReserveBySerial
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PartLabels != nil {
+		in, out := &in.PartLabels, &out.PartLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FSLabels != nil {
+		in, out := &in.FSLabels, &out.FSLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Transports != nil {
+		in, out := &in.Transports, &out.Transports
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MinIOPS != nil {
+		in, out := &in.MinIOPS, &out.MinIOPS
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MinStableDuration != nil {
+		in, out := &in.MinStableDuration, &out.MinStableDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	return
 }
 
@@ -56,6 +94,22 @@ func (in *DeviceInclusionSpec) DeepCopy() *DeviceInclusionSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeviceRoute) DeepCopyInto(out *DeviceRoute) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceRoute.
+func (in *DeviceRoute) DeepCopy() *DeviceRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeviceStatus) DeepCopyInto(out *DeviceStatus) {
 	*out = *in
@@ -72,6 +126,22 @@ func (in *DeviceStatus) DeepCopy() *DeviceStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeviceWriteProbe) DeepCopyInto(out *DeviceWriteProbe) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceWriteProbe.
+func (in *DeviceWriteProbe) DeepCopy() *DeviceWriteProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceWriteProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DiscoveredDevice) DeepCopyInto(out *DiscoveredDevice) {
 	*out = *in
@@ -235,6 +305,18 @@ func (in *LocalVolumeDiscoveryResultStatus) DeepCopyInto(out *LocalVolumeDiscove
 		*out = make([]DiscoveredDevice, len(*in))
 		copy(*out, *in)
 	}
+	if in.RejectedDevices != nil {
+		in, out := &in.RejectedDevices, &out.RejectedDevices
+		*out = make([]RejectedDevice, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]operatorv1.OperatorCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -363,6 +445,20 @@ func (in *LocalVolumeSetList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocalVolumeSetSpec) DeepCopyInto(out *LocalVolumeSetSpec) {
 	*out = *in
+	if in.PVLabels != nil {
+		in, out := &in.PVLabels, &out.PVLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PVAnnotations != nil {
+		in, out := &in.PVAnnotations, &out.PVAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
 		*out = new(v1.NodeSelector)
@@ -373,6 +469,63 @@ func (in *LocalVolumeSetSpec) DeepCopyInto(out *LocalVolumeSetSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.MaxCapacityPerNode != nil {
+		in, out := &in.MaxCapacityPerNode, &out.MaxCapacityPerNode
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxNodeCount != nil {
+		in, out := &in.MaxNodeCount, &out.MaxNodeCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CleanupTimeout != nil {
+		in, out := &in.CleanupTimeout, &out.CleanupTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ProvisionerConfig != nil {
+		in, out := &in.ProvisionerConfig, &out.ProvisionerConfig
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.QuarantineThreshold != nil {
+		in, out := &in.QuarantineThreshold, &out.QuarantineThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DeviceWriteProbe != nil {
+		in, out := &in.DeviceWriteProbe, &out.DeviceWriteProbe
+		*out = new(DeviceWriteProbe)
+		**out = **in
+	}
+	if in.ProvisioningWindow != nil {
+		in, out := &in.ProvisioningWindow, &out.ProvisioningWindow
+		*out = new(ProvisioningWindow)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DiskMakerMinAvailable != nil {
+		in, out := &in.DiskMakerMinAvailable, &out.DiskMakerMinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.LogLevel != nil {
+		in, out := &in.LogLevel, &out.LogLevel
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SymlinkTargetPreference != nil {
+		in, out := &in.SymlinkTargetPreference, &out.SymlinkTargetPreference
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeTaint != nil {
+		in, out := &in.NodeTaint, &out.NodeTaint
+		*out = new(v1.Taint)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Tolerations != nil {
 		in, out := &in.Tolerations, &out.Tolerations
 		*out = make([]v1.Toleration, len(*in))
@@ -385,6 +538,23 @@ func (in *LocalVolumeSetSpec) DeepCopyInto(out *LocalVolumeSetSpec) {
 		*out = new(DeviceInclusionSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.NodeOverrides != nil {
+		in, out := &in.NodeOverrides, &out.NodeOverrides
+		*out = make([]NodeOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ManagePersistentVolumes != nil {
+		in, out := &in.ManagePersistentVolumes, &out.ManagePersistentVolumes
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DeviceRouting != nil {
+		in, out := &in.DeviceRouting, &out.DeviceRouting
+		*out = make([]DeviceRoute, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -413,6 +583,55 @@ func (in *LocalVolumeSetStatus) DeepCopyInto(out *LocalVolumeSetStatus) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.NodeCount != nil {
+		in, out := &in.NodeCount, &out.NodeCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RejectedDeviceCount != nil {
+		in, out := &in.RejectedDeviceCount, &out.RejectedDeviceCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.QuarantinedDevices != nil {
+		in, out := &in.QuarantinedDevices, &out.QuarantinedDevices
+		*out = make([]QuarantinedDevice, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReservedDevices != nil {
+		in, out := &in.ReservedDevices, &out.ReservedDevices
+		*out = make([]ReservedDevice, len(*in))
+		copy(*out, *in)
+	}
+	if in.SelectedNodes != nil {
+		in, out := &in.SelectedNodes, &out.SelectedNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeCapacities != nil {
+		in, out := &in.NodeCapacities, &out.NodeCapacities
+		*out = make([]NodeCapacity, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ManagedDaemonSets != nil {
+		in, out := &in.ManagedDaemonSets, &out.ManagedDaemonSets
+		*out = make([]ManagedDaemonSet, len(*in))
+		copy(*out, *in)
+	}
+	if in.SymlinkedNodes != nil {
+		in, out := &in.SymlinkedNodes, &out.SymlinkedNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodesWithErrors != nil {
+		in, out := &in.NodesWithErrors, &out.NodesWithErrors
+		*out = make([]localv1.NodeError, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -425,3 +644,127 @@ func (in *LocalVolumeSetStatus) DeepCopy() *LocalVolumeSetStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeOverride) DeepCopyInto(out *NodeOverride) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(v1.NodeSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	in.DeviceInclusionSpec.DeepCopyInto(&out.DeviceInclusionSpec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeOverride.
+func (in *NodeOverride) DeepCopy() *NodeOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedDaemonSet) DeepCopyInto(out *ManagedDaemonSet) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDaemonSet.
+func (in *ManagedDaemonSet) DeepCopy() *ManagedDaemonSet {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedDaemonSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeCapacity) DeepCopyInto(out *NodeCapacity) {
+	*out = *in
+	out.ProvisionedCapacity = in.ProvisionedCapacity.DeepCopy()
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeCapacity.
+func (in *NodeCapacity) DeepCopy() *NodeCapacity {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeCapacity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvisioningWindow) DeepCopyInto(out *ProvisioningWindow) {
+	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisioningWindow.
+func (in *ProvisioningWindow) DeepCopy() *ProvisioningWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvisioningWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuarantinedDevice) DeepCopyInto(out *QuarantinedDevice) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuarantinedDevice.
+func (in *QuarantinedDevice) DeepCopy() *QuarantinedDevice {
+	if in == nil {
+		return nil
+	}
+	out := new(QuarantinedDevice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RejectedDevice) DeepCopyInto(out *RejectedDevice) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RejectedDevice.
+func (in *RejectedDevice) DeepCopy() *RejectedDevice {
+	if in == nil {
+		return nil
+	}
+	out := new(RejectedDevice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReservedDevice) DeepCopyInto(out *ReservedDevice) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservedDevice.
+func (in *ReservedDevice) DeepCopy() *ReservedDevice {
+	if in == nil {
+		return nil
+	}
+	out := new(ReservedDevice)
+	in.DeepCopyInto(out)
+	return out
+}
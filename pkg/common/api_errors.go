@@ -0,0 +1,50 @@
+package common
+
+import (
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// IsRetryableAPIError returns true if err is a transient error of the API server or of the connection to it,
+// that a later retry of the same request may not hit
+func IsRetryableAPIError(err error) bool {
+	if apierrors.IsInternalError(err) || apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err) ||
+		apierrors.IsTooManyRequests(err) || utilnet.IsProbableEOF(err) || utilnet.IsConnectionReset(err) {
+		return true
+	}
+	// the Retry-After header is an explicit confirmation that the request should be retried
+	if _, shouldRetry := apierrors.SuggestsClientDelay(err); shouldRetry {
+		return true
+	}
+	return false
+}
+
+// IsFatalAPIError returns true if the API server rejected the request itself, as for an object that fails validation.
+// Retrying the same request fails the same way until the object it is built from changes.
+func IsFatalAPIError(err error) bool {
+	if IsRetryableAPIError(err) {
+		return false
+	}
+	return apierrors.IsInvalid(err) || apierrors.IsBadRequest(err) || apierrors.IsRequestEntityTooLargeError(err) ||
+		apierrors.IsMethodNotSupported(err) || apierrors.IsNotAcceptable(err) || apierrors.IsUnsupportedMediaType(err)
+}
+
+// ReconcileErrorResult returns what a reconciler returns for the result and error of its reconcile:
+// fatal API errors are not requeued, as the reconcile is triggered again once the object changes,
+// transient API errors that carry a Retry-After are requeued after it,
+// and the other errors are returned to be requeued with the exponential backoff of the controller.
+func ReconcileErrorResult(result reconcile.Result, err error) (reconcile.Result, error) {
+	if err == nil {
+		return result, nil
+	}
+	if IsFatalAPIError(err) {
+		return reconcile.Result{}, nil
+	}
+	if seconds, shouldRetry := apierrors.SuggestsClientDelay(err); shouldRetry && seconds > 0 {
+		return reconcile.Result{RequeueAfter: time.Duration(seconds) * time.Second}, nil
+	}
+	return result, err
+}
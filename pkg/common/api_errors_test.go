@@ -0,0 +1,87 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileErrorResult(t *testing.T) {
+	resource := schema.GroupResource{Group: "storage.k8s.io", Resource: "storageclasses"}
+	invalid := apierrors.NewInvalid(schema.GroupKind{Group: "storage.k8s.io", Kind: "StorageClass"}, "local-sc",
+		field.ErrorList{field.Invalid(field.NewPath("parameters"), "x", "invalid")})
+	throttled := apierrors.NewTooManyRequests("slow down", 7)
+	timeout := apierrors.NewServerTimeout(resource, "get", 0)
+	other := fmt.Errorf("failed to symlink")
+
+	testTable := []struct {
+		desc           string
+		err            error
+		retryable      bool
+		fatal          bool
+		expectedResult reconcile.Result
+		expectedErr    error
+	}{
+		{
+			desc:           "no error keeps the result",
+			expectedResult: reconcile.Result{RequeueAfter: time.Minute},
+		},
+		{
+			desc:           "validation errors are fatal and not requeued",
+			err:            invalid,
+			fatal:          true,
+			expectedResult: reconcile.Result{},
+		},
+		{
+			desc:           "bad requests are fatal",
+			err:            apierrors.NewBadRequest("bad"),
+			fatal:          true,
+			expectedResult: reconcile.Result{},
+		},
+		{
+			desc:           "throttling is requeued after its Retry-After",
+			err:            throttled,
+			retryable:      true,
+			expectedResult: reconcile.Result{RequeueAfter: 7 * time.Second},
+		},
+		{
+			desc:           "server timeouts are returned for the backoff of the controller",
+			err:            timeout,
+			retryable:      true,
+			expectedResult: reconcile.Result{RequeueAfter: time.Minute},
+			expectedErr:    timeout,
+		},
+		{
+			desc:           "internal errors are retryable",
+			err:            apierrors.NewInternalError(other),
+			retryable:      true,
+			expectedResult: reconcile.Result{RequeueAfter: time.Minute},
+			expectedErr:    apierrors.NewInternalError(other),
+		},
+		{
+			desc:           "conflicts are returned for the backoff of the controller",
+			err:            apierrors.NewConflict(resource, "local-sc", other),
+			expectedResult: reconcile.Result{RequeueAfter: time.Minute},
+			expectedErr:    apierrors.NewConflict(resource, "local-sc", other),
+		},
+		{
+			desc:           "other errors are returned for the backoff of the controller",
+			err:            other,
+			expectedResult: reconcile.Result{RequeueAfter: time.Minute},
+			expectedErr:    other,
+		},
+	}
+	for _, tc := range testTable {
+		assert.Equalf(t, tc.retryable, IsRetryableAPIError(tc.err), "[%s] IsRetryableAPIError", tc.desc)
+		assert.Equalf(t, tc.fatal, IsFatalAPIError(tc.err), "[%s] IsFatalAPIError", tc.desc)
+		result, err := ReconcileErrorResult(reconcile.Result{RequeueAfter: time.Minute}, tc.err)
+		assert.Equalf(t, tc.expectedResult, result, "[%s] result", tc.desc)
+		assert.Equalf(t, tc.expectedErr, err, "[%s] error", tc.desc)
+	}
+}
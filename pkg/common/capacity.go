@@ -27,3 +27,8 @@ func RoundDownCapacityPretty(capacityBytes int64) int64 {
 	}
 	return capacityBytes
 }
+
+// RoundDownCapacityGiB rounds down to the closest GiB, capacities below 1 GiB are rounded down to 0
+func RoundDownCapacityGiB(capacityBytes int64) int64 {
+	return capacityBytes / GiB * GiB
+}
@@ -31,3 +31,21 @@ func TestRoundDownCapacityPretty(t *testing.T) {
 		}
 	}
 }
+
+func TestRoundDownCapacityGiB(t *testing.T) {
+	var capTests = []struct {
+		n        int64 // input
+		expected int64 // expected result
+	}{
+		{100 * MiB, 0},
+		{GiB, GiB},
+		{5*GiB + 999*MiB, 5 * GiB},
+		{3*TiB + 2*GiB + 1*MiB, 3*TiB + 2*GiB},
+	}
+	for _, tt := range capTests {
+		actual := RoundDownCapacityGiB(tt.n)
+		if actual != tt.expected {
+			t.Errorf("RoundDownCapacityGiB(%d): expected %d, actual %d", tt.n, tt.expected, actual)
+		}
+	}
+}
@@ -23,6 +23,19 @@ func EnqueueOnlyLabeledSubcomponents(components ...string) predicate.Predicate {
 	})
 }
 
+// GenerationOrRescanChanged returns a predicate that filters out the updates of objects
+// that change neither their generation nor their RescanAnnotation
+func GenerationOrRescanChanged() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.MetaOld == nil || e.MetaNew == nil {
+				return false
+			}
+			return e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration() || GetRescan(e.MetaOld) != GetRescan(e.MetaNew)
+		},
+	}
+}
+
 func appLabelIn(meta metav1.Object, components []string) bool {
 	labels := meta.GetLabels()
 	appName, found := labels["app"]
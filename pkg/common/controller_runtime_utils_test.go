@@ -0,0 +1,50 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestGenerationOrRescanChanged(t *testing.T) {
+	old := &metav1.ObjectMeta{Generation: 1, Annotations: map[string]string{RescanAnnotation: "2021-03-01T10:00:00Z"}}
+	testcases := []struct {
+		label       string
+		new         *metav1.ObjectMeta
+		expectMatch bool
+	}{
+		{
+			label:       "unchanged",
+			new:         old.DeepCopy(),
+			expectMatch: false,
+		},
+		{
+			label:       "generation changed",
+			new:         &metav1.ObjectMeta{Generation: 2, Annotations: old.Annotations},
+			expectMatch: true,
+		},
+		{
+			label:       "rescan changed",
+			new:         &metav1.ObjectMeta{Generation: 1, Annotations: map[string]string{RescanAnnotation: "2021-03-01T11:00:00Z"}},
+			expectMatch: true,
+		},
+		{
+			label:       "rescan removed",
+			new:         &metav1.ObjectMeta{Generation: 1},
+			expectMatch: true,
+		},
+		{
+			label:       "other annotation changed",
+			new:         &metav1.ObjectMeta{Generation: 1, Annotations: map[string]string{RescanAnnotation: "2021-03-01T10:00:00Z", "foo": "bar"}},
+			expectMatch: false,
+		},
+	}
+	predicate := GenerationOrRescanChanged()
+	for _, tc := range testcases {
+		match := predicate.Update(event.UpdateEvent{MetaOld: old, MetaNew: tc.new})
+		assert.Equalf(t, tc.expectMatch, match, "[%s]", tc.label)
+	}
+	assert.True(t, predicate.Create(event.CreateEvent{Meta: old}))
+}
@@ -2,9 +2,12 @@ package common
 
 import (
 	"fmt"
+	"os"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 	corev1helper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
 )
 
@@ -24,3 +27,69 @@ func NodeSelectorMatchesNodeLabels(node *corev1.Node, nodeSelector *corev1.NodeS
 	})
 	return matches, nil
 }
+
+// nodeSelectorOperators maps the operators of label selectors to the operators of node selectors
+var nodeSelectorOperators = map[selection.Operator]corev1.NodeSelectorOperator{
+	selection.Equals:       corev1.NodeSelectorOpIn,
+	selection.DoubleEquals: corev1.NodeSelectorOpIn,
+	selection.In:           corev1.NodeSelectorOpIn,
+	selection.NotEquals:    corev1.NodeSelectorOpNotIn,
+	selection.NotIn:        corev1.NodeSelectorOpNotIn,
+	selection.Exists:       corev1.NodeSelectorOpExists,
+	selection.DoesNotExist: corev1.NodeSelectorOpDoesNotExist,
+	selection.GreaterThan:  corev1.NodeSelectorOpGt,
+	selection.LessThan:     corev1.NodeSelectorOpLt,
+}
+
+// GetDefaultNodeSelector returns the requirements of the label selector in DefaultNodeSelectorEnv,
+// which every node running local storage must match. It returns nil if the variable is not set.
+func GetDefaultNodeSelector() ([]corev1.NodeSelectorRequirement, error) {
+	value := os.Getenv(DefaultNodeSelectorEnv)
+	if value == "" {
+		return nil, nil
+	}
+	selector, err := labels.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", DefaultNodeSelectorEnv, value, err)
+	}
+	labelRequirements, _ := selector.Requirements()
+	requirements := make([]corev1.NodeSelectorRequirement, 0, len(labelRequirements))
+	for _, labelRequirement := range labelRequirements {
+		operator, found := nodeSelectorOperators[labelRequirement.Operator()]
+		if !found {
+			return nil, fmt.Errorf("invalid %s %q: unsupported operator %q", DefaultNodeSelectorEnv, value, labelRequirement.Operator())
+		}
+		requirements = append(requirements, corev1.NodeSelectorRequirement{
+			Key:      labelRequirement.Key(),
+			Operator: operator,
+			Values:   labelRequirement.Values().List(),
+		})
+	}
+	return requirements, nil
+}
+
+// EffectiveNodeSelector returns the nodeSelector of a LocalVolume or LocalVolumeSet ANDed with the default nodeSelector
+// of the operator: a node must match both. A nil or empty nodeSelector, which selects all nodes, is replaced
+// by the default nodeSelector. The nodeSelector is returned as is when no default is set.
+func EffectiveNodeSelector(nodeSelector *corev1.NodeSelector) (*corev1.NodeSelector, error) {
+	requirements, err := GetDefaultNodeSelector()
+	if err != nil || len(requirements) == 0 {
+		return nodeSelector, err
+	}
+	if nodeSelector == nil || len(nodeSelector.NodeSelectorTerms) == 0 {
+		return &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: requirements}},
+		}, nil
+	}
+	// the terms are ORed, so the default requirements are added to each of them.
+	// empty terms match no node and are left empty.
+	effective := nodeSelector.DeepCopy()
+	for i := range effective.NodeSelectorTerms {
+		term := &effective.NodeSelectorTerms[i]
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		term.MatchExpressions = append(term.MatchExpressions, requirements...)
+	}
+	return effective, nil
+}
@@ -0,0 +1,76 @@
+package common
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEffectiveNodeSelector(t *testing.T) {
+	defer os.Unsetenv(DefaultNodeSelectorEnv)
+	diskSelector := &corev1.NodeSelector{
+		NodeSelectorTerms: []corev1.NodeSelectorTerm{
+			{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "disks", Operator: corev1.NodeSelectorOpIn, Values: []string{"ssd"}}}},
+			{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "disks", Operator: corev1.NodeSelectorOpIn, Values: []string{"hdd"}}}},
+		},
+	}
+	nodes := map[string]*corev1.Node{
+		"storage-ssd": {ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: map[string]string{"storage-role": "", "disks": "ssd"}}},
+		"storage-hdd": {ObjectMeta: metav1.ObjectMeta{Name: "b", Labels: map[string]string{"storage-role": "", "disks": "hdd"}}},
+		"storage":     {ObjectMeta: metav1.ObjectMeta{Name: "c", Labels: map[string]string{"storage-role": ""}}},
+		"ssd":         {ObjectMeta: metav1.ObjectMeta{Name: "d", Labels: map[string]string{"disks": "ssd"}}},
+	}
+
+	testcases := []struct {
+		label           string
+		defaultSelector string
+		nodeSelector    *corev1.NodeSelector
+		expectedMatches []string
+	}{
+		{
+			label:           "no default",
+			nodeSelector:    diskSelector,
+			expectedMatches: []string{"storage-ssd", "storage-hdd", "ssd"},
+		},
+		{
+			label:           "default ANDed with each term",
+			defaultSelector: "storage-role",
+			nodeSelector:    diskSelector,
+			expectedMatches: []string{"storage-ssd", "storage-hdd"},
+		},
+		{
+			label:           "default replaces a nil selector",
+			defaultSelector: "storage-role",
+			expectedMatches: []string{"storage-ssd", "storage-hdd", "storage"},
+		},
+		{
+			label:           "default with several requirements",
+			defaultSelector: "storage-role,disks!=hdd",
+			expectedMatches: []string{"storage-ssd", "storage"},
+		},
+	}
+	for _, tc := range testcases {
+		os.Setenv(DefaultNodeSelectorEnv, tc.defaultSelector)
+		effective, err := EffectiveNodeSelector(tc.nodeSelector)
+		assert.NoErrorf(t, err, "[%s]", tc.label)
+		matches := []string{}
+		for _, name := range []string{"storage-ssd", "storage-hdd", "storage", "ssd"} {
+			match, err := NodeSelectorMatchesNodeLabels(nodes[name], effective)
+			assert.NoError(t, err)
+			if match {
+				matches = append(matches, name)
+			}
+		}
+		assert.Equalf(t, tc.expectedMatches, matches, "[%s] unexpected matching nodes", tc.label)
+	}
+
+	// the selector of the CR isn't modified
+	assert.Len(t, diskSelector.NodeSelectorTerms[0].MatchExpressions, 1)
+
+	os.Setenv(DefaultNodeSelectorEnv, "storage-role=(")
+	_, err := EffectiveNodeSelector(diskSelector)
+	assert.Error(t, err)
+}
@@ -0,0 +1,5 @@
+package common
+
+// DisallowDefaultStorageClassFlag is the flag of the operator that keeps it from ever changing
+// the default StorageClass annotation
+const DisallowDefaultStorageClassFlag = "disallow-default-storageclass"
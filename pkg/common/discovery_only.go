@@ -0,0 +1,4 @@
+package common
+
+// DiscoveryOnlyFlag is the flag of the operator and the diskmaker that enables the discovery-only mode
+const DiscoveryOnlyFlag = "discovery-only"
@@ -0,0 +1,10 @@
+package common
+
+const (
+	// DiscoveryResultConfigMapNamespaceFlag is the flag of the operator that sets the namespace
+	// the LocalVolumeDiscoveryResults are mirrored to as ConfigMaps
+	DiscoveryResultConfigMapNamespaceFlag = "discovery-result-configmap-namespace"
+	// DiscoveryResultExportLabel marks the ConfigMaps that mirror a LocalVolumeDiscoveryResult,
+	// the value is the namespace of the LocalVolumeDiscoveryResult
+	DiscoveryResultExportLabel = "local.storage.openshift.io/discovery-result-namespace"
+)
@@ -0,0 +1,12 @@
+package common
+
+const (
+	// DiskMakerHealthProbePort is the port the diskmaker serves its readiness and liveness probes on
+	DiskMakerHealthProbePort = 8081
+	// DiskMakerReadinessPath is the path of the diskmaker readiness probe
+	DiskMakerReadinessPath = "/readyz"
+	// DiskMakerLivenessPath is the path of the diskmaker liveness probe
+	DiskMakerLivenessPath = "/healthz"
+	// DiskMakerMetricsPort is the port the diskmaker serves its prometheus metrics on
+	DiskMakerMetricsPort = 8383
+)
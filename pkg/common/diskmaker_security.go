@@ -0,0 +1,32 @@
+package common
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DiskMakerSecurityContextFlag is the flag of the operator that sets how the diskmaker container is privileged
+const DiskMakerSecurityContextFlag = "diskmaker-security-context"
+
+const (
+	// DiskMakerSecurityContextPrivileged runs the diskmaker as a privileged container, the default
+	DiskMakerSecurityContextPrivileged = "privileged"
+	// DiskMakerSecurityContextCapabilities runs the diskmaker unprivileged, with DiskMakerCapabilities only
+	DiskMakerSecurityContextCapabilities = "capabilities"
+)
+
+// DiskMakerCapabilities are the capabilities the diskmaker needs when it is not privileged:
+// SYS_ADMIN to mount and bind-mount devices and directories, SYS_PTRACE to read the host's /proc/1/mountinfo,
+// DAC_OVERRIDE, FOWNER and CHOWN to manage the symlinks and directories of the host's local disk location.
+var DiskMakerCapabilities = []corev1.Capability{"SYS_ADMIN", "SYS_PTRACE", "DAC_OVERRIDE", "FOWNER", "CHOWN"}
+
+func validateDiskMakerSecurityContext(securityContext string) error {
+	switch securityContext {
+	case DiskMakerSecurityContextPrivileged, DiskMakerSecurityContextCapabilities:
+		return nil
+	default:
+		return fmt.Errorf("--%s %q must be %q or %q", DiskMakerSecurityContextFlag, securityContext,
+			DiskMakerSecurityContextPrivileged, DiskMakerSecurityContextCapabilities)
+	}
+}
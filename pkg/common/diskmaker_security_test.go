@@ -0,0 +1,18 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDiskMakerSecurityContext(t *testing.T) {
+	options := DefaultOptions()
+	assert.Equal(t, DiskMakerSecurityContextPrivileged, options.DiskMakerSecurityContext, "the diskmaker is privileged by default")
+
+	options.DiskMakerSecurityContext = DiskMakerSecurityContextCapabilities
+	assert.NoError(t, options.Validate())
+
+	options.DiskMakerSecurityContext = "rootless"
+	assert.Error(t, options.Validate(), "unknown values are rejected")
+}
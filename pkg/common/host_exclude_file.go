@@ -0,0 +1,31 @@
+package common
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+const (
+	// HostExcludeFileFlag is the flag of the operator and the diskmaker that sets the path of the host file
+	// listing the devices the diskmaker never touches. The operator takes the path on the host,
+	// the diskmaker the path in its container.
+	HostExcludeFileFlag = "host-exclude-file"
+	// HostExcludeDir is where the diskmaker container mounts the host directory of the host exclude file.
+	// The directory is mounted instead of the file, so that a file replaced by a rename is seen too.
+	HostExcludeDir = "/host-exclude"
+)
+
+// validateHostExcludeFile accepts an empty path, which disables the host exclude file. Other paths need to be
+// absolute and not in the root directory, whose mount would expose the whole host.
+func validateHostExcludeFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+		return fmt.Errorf("--%s %q is not a clean absolute path", HostExcludeFileFlag, path)
+	}
+	if filepath.Dir(path) == "/" {
+		return fmt.Errorf("--%s %q must not be in the root directory", HostExcludeFileFlag, path)
+	}
+	return nil
+}
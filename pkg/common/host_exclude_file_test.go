@@ -0,0 +1,20 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateHostExcludeFile(t *testing.T) {
+	options := DefaultOptions()
+	assert.Equal(t, "", options.HostExcludeFile, "the host exclude file is disabled by default")
+
+	options.HostExcludeFile = "/etc/lso/exclude"
+	assert.NoError(t, options.Validate())
+
+	for _, path := range []string{"etc/lso/exclude", "/etc/lso/../exclude", "/lso-exclude"} {
+		options.HostExcludeFile = path
+		assert.Errorf(t, options.Validate(), "%q is rejected", path)
+	}
+}
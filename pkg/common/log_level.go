@@ -0,0 +1,29 @@
+package common
+
+import (
+	"fmt"
+)
+
+// LogLevelFlag is the flag of the diskmaker that sets the verbosity of its logs, like klog's -v
+const LogLevelFlag = "v"
+
+func validateLogLevel(level int32) error {
+	if level < 0 {
+		return fmt.Errorf("--%s %d must not be negative", LogLevelFlag, level)
+	}
+	return nil
+}
+
+// LogLevelFromZapLevel converts the value of the --zap-level flag to a verbosity:
+// "debug" is 1 and an integer level N is printed as "Level(-N)". Less verbose levels are 0.
+func LogLevelFromZapLevel(zapLevel string) int32 {
+	if zapLevel == "debug" {
+		return 1
+	}
+	var level int32
+	_, err := fmt.Sscanf(zapLevel, "Level(%d)", &level)
+	if err != nil || level > 0 {
+		return 0
+	}
+	return -level
+}
@@ -0,0 +1,30 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogLevelFromZapLevel(t *testing.T) {
+	testCases := map[string]int32{
+		zapcore.InfoLevel.String():  0,
+		zapcore.ErrorLevel.String(): 0,
+		zapcore.DebugLevel.String(): 1,
+		zapcore.Level(-2).String():  2,
+		zapcore.Level(-5).String():  5,
+		"not a level":               0,
+	}
+	for zapLevel, expected := range testCases {
+		assert.Equalf(t, expected, LogLevelFromZapLevel(zapLevel), "zap level %q", zapLevel)
+	}
+}
+
+func TestValidateLogLevel(t *testing.T) {
+	options := DefaultOptions()
+	options.LogLevel = 4
+	assert.NoError(t, options.Validate())
+	options.LogLevel = -1
+	assert.Error(t, options.Validate(), "negative levels are rejected")
+}
@@ -0,0 +1,42 @@
+package common
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// DurationBuckets are the histogram buckets of the provisioning latency metrics, from half a second to about 4 minutes
+var DurationBuckets = prometheus.ExponentialBuckets(0.5, 2, 10)
+
+// reconcileDuration is labeled by controller only, to keep its cardinality bounded
+var reconcileDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "lso_reconcile_duration_seconds",
+		Help:    "Time taken by a reconcile of the local storage operator and diskmaker controllers",
+		Buckets: DurationBuckets,
+	},
+	[]string{"controller"},
+)
+
+// PVCreateDuration is the time taken by CreateLocalPV for the PVs it creates.
+// It is only registered by the diskmaker, which creates the PVs.
+var PVCreateDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "lso_pv_create_duration_seconds",
+	Help:    "Time taken to create the PV of a matching device",
+	Buckets: DurationBuckets,
+})
+
+func init() {
+	metrics.Registry.MustRegister(reconcileDuration)
+}
+
+// ObserveReconcileDuration starts timing a reconcile of the controller.
+// The returned function must be called when the reconcile returns.
+func ObserveReconcileDuration(controller string) func() {
+	start := time.Now()
+	return func() {
+		reconcileDuration.WithLabelValues(controller).Observe(time.Since(start).Seconds())
+	}
+}
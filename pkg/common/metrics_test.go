@@ -0,0 +1,20 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveReconcileDuration(t *testing.T) {
+	ObserveReconcileDuration("test-controller")()
+	ObserveReconcileDuration("test-controller")()
+
+	metric := &dto.Metric{}
+	err := reconcileDuration.WithLabelValues("test-controller").(prometheus.Histogram).Write(metric)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), metric.GetHistogram().GetSampleCount())
+	assert.Len(t, metric.GetLabel(), 1, "reconcile durations are only labeled by controller")
+}
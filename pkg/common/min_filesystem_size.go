@@ -0,0 +1,34 @@
+package common
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// MinFilesystemDeviceSizeFlag is the flag of the operator and the diskmaker that sets the size below which
+// devices are not provisioned with volumeMode Filesystem, whatever the minSize of the CR
+const MinFilesystemDeviceSizeFlag = "min-filesystem-device-size"
+
+// DefaultMinFilesystemDeviceSize is large enough for mkfs.ext4 and mkfs.xfs to succeed
+var DefaultMinFilesystemDeviceSize = resource.MustParse("10Mi")
+
+// ParseMinFilesystemDeviceSize parses the value of MinFilesystemDeviceSizeFlag, an empty size is
+// DefaultMinFilesystemDeviceSize
+func ParseMinFilesystemDeviceSize(size string) (resource.Quantity, error) {
+	if size == "" {
+		return DefaultMinFilesystemDeviceSize, nil
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("--%s %q is not a valid size: %w", MinFilesystemDeviceSizeFlag, size, err)
+	}
+	return quantity, nil
+}
+
+func validateMinFilesystemDeviceSize(size resource.Quantity) error {
+	if size.Sign() < 0 {
+		return fmt.Errorf("--%s %q must not be negative", MinFilesystemDeviceSizeFlag, size.String())
+	}
+	return nil
+}
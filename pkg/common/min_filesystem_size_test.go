@@ -0,0 +1,26 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestParseMinFilesystemDeviceSize(t *testing.T) {
+	size, err := ParseMinFilesystemDeviceSize("")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, size.Cmp(resource.MustParse("10Mi")), "10Mi is the default")
+
+	size, err = ParseMinFilesystemDeviceSize("300Mi")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, size.Cmp(resource.MustParse("300Mi")))
+
+	_, err = ParseMinFilesystemDeviceSize("ten megabytes")
+	assert.Error(t, err, "invalid sizes are rejected")
+
+	options := DefaultOptions()
+	options.MinFilesystemDeviceSize, err = ParseMinFilesystemDeviceSize("-1Mi")
+	assert.NoError(t, err)
+	assert.Error(t, options.Validate(), "negative sizes are rejected")
+}
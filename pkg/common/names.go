@@ -3,9 +3,11 @@ package common
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -20,6 +22,8 @@ const (
 	OwnerNamespaceLabel = "local.storage.openshift.io/owner-namespace"
 	// OwnerNameLabel references the owning object
 	OwnerNameLabel = "local.storage.openshift.io/owner-name"
+	// OwnerKindLabel references the owning object's kind
+	OwnerKindLabel = "local.storage.openshift.io/owner-kind"
 
 	// DiskMakerImageEnv is used by the operator to read the DISKMAKER_IMAGE from the environment
 	DiskMakerImageEnv = "DISKMAKER_IMAGE"
@@ -27,10 +31,27 @@ const (
 	ProvisionerImageEnv = "PROVISIONER_IMAGE"
 	// LocalDiskLocationEnv is passed to the operator to override the LOCAL_DISK_LOCATION host directory
 	LocalDiskLocationEnv = "LOCAL_DISK_LOCATION"
+	// DefaultNodeSelectorEnv is passed to the operator to restrict all LocalVolumes and LocalVolumeSets to the nodes
+	// matching a label selector, such as "node-role.kubernetes.io/storage="
+	DefaultNodeSelectorEnv = "DEFAULT_NODE_SELECTOR"
+	// DiscoverySelfTestEnv is set to "true" on the discovery daemon when its LocalVolumeDiscovery asks for a self-test
+	DiscoverySelfTestEnv = "SELF_TEST"
 
 	// ProvisionerConfigMapName is the name of the local-static-provisioner configmap
 	ProvisionerConfigMapName = "local-provisioner"
 
+	// PausedAnnotation is set to "true" on a LocalVolume or LocalVolumeSet to stop the controllers
+	// from reconciling it, existing daemonsets and PVs are left in place
+	PausedAnnotation = "local.storage.openshift.io/paused"
+
+	// LockedAnnotation is set to "true" on a LocalVolumeSet to reject the changes to the fields that select its devices,
+	// enforced by the admission webhook
+	LockedAnnotation = "local.storage.openshift.io/locked"
+
+	// RescanAnnotation is set to a new value, such as a timestamp, on a LocalVolume or LocalVolumeSet
+	// to make the diskmakers discover the devices of their node again right away
+	RescanAnnotation = "local.storage.openshift.io/rescan"
+
 	// DiscoveryNodeLabelKey is the label key on the discovery result CR used to identify the node it belongs to.
 	// the value is the node's name
 	DiscoveryNodeLabel = "discovery-result-node"
@@ -52,6 +73,24 @@ func GetDiskMakerImage() string {
 	return defaultDiskMakerImageVersion
 }
 
+// GetProvisionerVersion returns the version of the diskmaker image, which provisions the PVs
+func GetProvisionerVersion() string {
+	return ImageVersion(GetDiskMakerImage())
+}
+
+// ImageVersion returns the digest or tag of an image reference, or "latest" if it has neither
+func ImageVersion(image string) string {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		return image[i+1:]
+	}
+	// a colon before the last slash separates the registry port
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return "latest"
+}
+
 // GetLocalDiskLocationPath return the local disk path
 func GetLocalDiskLocationPath() string {
 	if localDiskLocationEnvImage := os.Getenv(LocalDiskLocationEnv); localDiskLocationEnvImage != "" {
@@ -70,3 +109,18 @@ func LocalVolumeKey(lv *localv1.LocalVolume) string {
 func GetProvisionedByValue(node corev1.Node) string {
 	return fmt.Sprintf("local-volume-provisioner-%v-%v", node.Name, node.UID)
 }
+
+// IsPaused returns true if reconciliation of the object is paused with the PausedAnnotation
+func IsPaused(obj metav1.Object) bool {
+	return obj.GetAnnotations()[PausedAnnotation] == "true"
+}
+
+// IsLocked returns true if the fields that select the devices of the object are locked with the LockedAnnotation
+func IsLocked(obj metav1.Object) bool {
+	return obj.GetAnnotations()[LockedAnnotation] == "true"
+}
+
+// GetRescan returns the value of the RescanAnnotation of the object
+func GetRescan(obj metav1.Object) string {
+	return obj.GetAnnotations()[RescanAnnotation]
+}
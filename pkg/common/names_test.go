@@ -0,0 +1,25 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestImageVersion(t *testing.T) {
+	var imageTests = []struct {
+		image    string
+		expected string
+	}{
+		{"quay.io/openshift/origin-local-storage-diskmaker:4.8", "4.8"},
+		{"quay.io/openshift/origin-local-storage-diskmaker", "latest"},
+		{"registry:5000/local-storage-diskmaker", "latest"},
+		{"registry:5000/local-storage-diskmaker:v4.8.0", "v4.8.0"},
+		{"quay.io/openshift/origin-local-storage-diskmaker@sha256:abcdef", "sha256:abcdef"},
+		{"diskmaker:dev", "dev"},
+	}
+	for _, tt := range imageTests {
+		actual := ImageVersion(tt.image)
+		if actual != tt.expected {
+			t.Errorf("ImageVersion(%q): expected %q, actual %q", tt.image, tt.expected, actual)
+		}
+	}
+}
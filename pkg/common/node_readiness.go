@@ -0,0 +1,77 @@
+package common
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// NodeNotReadyGracePeriodFlag is the flag of the operator that sets how long a node must be NotReady
+	// before the operator stops changing it
+	NodeNotReadyGracePeriodFlag = "node-not-ready-grace-period"
+	// DefaultNodeNotReadyGracePeriod is the grace period used when the flag is not set
+	DefaultNodeNotReadyGracePeriod = 5 * time.Minute
+)
+
+func validateNodeNotReadyGracePeriod(period time.Duration) error {
+	if period <= 0 {
+		return fmt.Errorf("--%s %v must be positive", NodeNotReadyGracePeriodFlag, period)
+	}
+	return nil
+}
+
+// NodeNotReady returns true and the time the node stopped being ready if its Ready condition has been False or Unknown
+// for longer than gracePeriod. A node that stopped being ready more recently is not NotReady yet,
+// the returned duration is how long until its grace period expires. Nodes without a Ready condition are ready.
+func NodeNotReady(node *corev1.Node, now time.Time, gracePeriod time.Duration) (bool, time.Time, time.Duration) {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type != corev1.NodeReady {
+			continue
+		}
+		if condition.Status == corev1.ConditionTrue {
+			return false, time.Time{}, 0
+		}
+		since := condition.LastTransitionTime.Time
+		notReadyFor := now.Sub(since)
+		if notReadyFor < gracePeriod {
+			return false, time.Time{}, gracePeriod - notReadyFor
+		}
+		return true, since, 0
+	}
+	return false, time.Time{}, 0
+}
+
+// NodeLabelsOrReadinessChanged returns a predicate that filters node events which change the labels of a node,
+// like NodeLabelsChanged, or the status of its Ready condition
+func NodeLabelsOrReadinessChanged() predicate.Predicate {
+	labelsChanged := NodeLabelsChanged()
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if labelsChanged.Update(e) {
+				return true
+			}
+			oldNode, ok := e.ObjectOld.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			newNode, ok := e.ObjectNew.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			return nodeReadyStatus(oldNode) != nodeReadyStatus(newNode)
+		},
+	}
+}
+
+func nodeReadyStatus(node *corev1.Node) corev1.ConditionStatus {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status
+		}
+	}
+	return ""
+}
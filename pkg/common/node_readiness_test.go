@@ -0,0 +1,68 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func newNodeWithReadiness(status corev1.ConditionStatus, since time.Time) *corev1.Node {
+	return &corev1.Node{Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+		{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse},
+		{Type: corev1.NodeReady, Status: status, LastTransitionTime: metav1.NewTime(since)},
+	}}}
+}
+
+func TestNodeNotReady(t *testing.T) {
+	now := time.Now()
+	tenMinutesAgo := now.Add(-10 * time.Minute)
+
+	notReady, _, remaining := NodeNotReady(newNodeWithReadiness(corev1.ConditionTrue, tenMinutesAgo), now, DefaultNodeNotReadyGracePeriod)
+	assert.False(t, notReady, "a ready node")
+	assert.Zero(t, remaining)
+
+	notReady, since, _ := NodeNotReady(newNodeWithReadiness(corev1.ConditionUnknown, tenMinutesAgo), now, DefaultNodeNotReadyGracePeriod)
+	assert.True(t, notReady, "a node that stopped reporting past the grace period")
+	assert.Equal(t, tenMinutesAgo.Unix(), since.Unix())
+
+	notReady, _, remaining = NodeNotReady(newNodeWithReadiness(corev1.ConditionFalse, now.Add(-time.Minute)), now, DefaultNodeNotReadyGracePeriod)
+	assert.False(t, notReady, "a node within the grace period")
+	assert.Equal(t, DefaultNodeNotReadyGracePeriod-time.Minute, remaining)
+
+	notReady, _, remaining = NodeNotReady(&corev1.Node{}, now, DefaultNodeNotReadyGracePeriod)
+	assert.False(t, notReady, "a node without a Ready condition")
+	assert.Zero(t, remaining)
+
+	notReady, _, _ = NodeNotReady(newNodeWithReadiness(corev1.ConditionFalse, now.Add(-time.Minute)), now, 30*time.Second)
+	assert.True(t, notReady, "the grace period is configurable")
+}
+
+func TestValidateNodeNotReadyGracePeriod(t *testing.T) {
+	options := DefaultOptions()
+	options.NodeNotReadyGracePeriod = 30 * time.Second
+	assert.NoError(t, options.Validate())
+	options.NodeNotReadyGracePeriod = -time.Second
+	assert.Error(t, options.Validate())
+}
+
+func TestNodeLabelsOrReadinessChanged(t *testing.T) {
+	now := time.Now()
+	ready := newNodeWithReadiness(corev1.ConditionTrue, now)
+	notReady := newNodeWithReadiness(corev1.ConditionUnknown, now)
+	heartbeat := newNodeWithReadiness(corev1.ConditionTrue, now)
+	heartbeat.Status.Conditions[1].LastHeartbeatTime = metav1.NewTime(now.Add(time.Minute))
+	relabeled := newNodeWithReadiness(corev1.ConditionTrue, now)
+	relabeled.Labels = map[string]string{"disks": "ssd"}
+
+	p := NodeLabelsOrReadinessChanged()
+	update := func(oldNode, newNode *corev1.Node) bool {
+		return p.Update(event.UpdateEvent{MetaOld: oldNode, ObjectOld: oldNode, MetaNew: newNode, ObjectNew: newNode})
+	}
+	assert.True(t, update(ready, notReady), "readiness changed")
+	assert.True(t, update(ready, relabeled), "labels changed")
+	assert.False(t, update(ready, heartbeat), "status update")
+}
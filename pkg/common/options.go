@@ -0,0 +1,81 @@
+package common
+
+import (
+	"regexp"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Options are the command line options of the operator and the diskmaker, passed to their controllers.
+// The operator passes the options of the diskmaker on to the diskmaker DaemonSet as flags.
+type Options struct {
+	// ResyncPeriod overrides the interval of the periodic reconciles, 0 keeps the default interval of each controller
+	ResyncPeriod time.Duration
+	// DiscoveryOnly enables the discovery-only mode, in which the diskmaker discovers and matches devices
+	// but never formats, symlinks or cleans them, nor creates or deletes PVs
+	DiscoveryOnly bool
+	// LogLevel is the default verbosity of the diskmaker logs, 0 being the least verbose
+	LogLevel int32
+	// MinFilesystemDeviceSize is the size below which devices are rejected for volumeMode Filesystem,
+	// because mkfs would fail on them
+	MinFilesystemDeviceSize resource.Quantity
+	// PVNodeAffinityKey is the node label key that the node affinity of new PVs selects, for example to
+	// match the topology key of a CSI driver. The label needs to identify the node, like kubernetes.io/hostname.
+	PVNodeAffinityKey string
+	// DiskMakerSecurityContext is how the diskmaker container is privileged
+	DiskMakerSecurityContext string
+	// NodeNotReadyGracePeriod is how long the Ready condition of a node must not be True before it is considered NotReady
+	NodeNotReadyGracePeriod time.Duration
+	// HostExcludeFile is the path of the host exclude file, empty if it is disabled
+	HostExcludeFile string
+	// DisallowDefaultStorageClass keeps the operator from setting or removing the default StorageClass annotation
+	// of any StorageClass, the setAsDefault of LocalVolumes is ignored
+	DisallowDefaultStorageClass bool
+	// StorageClassNamePattern is the regular expression the admission webhooks require the StorageClass names of
+	// new LocalVolumes and LocalVolumeSets to match, nil allows every name
+	StorageClassNamePattern *regexp.Regexp
+	// PVBackupAnnotations and PVBackupLabels are added to the PVs for backup tools
+	PVBackupAnnotations map[string]string
+	PVBackupLabels      map[string]string
+	// DiscoveryResultConfigMapNamespace is the namespace of the ConfigMaps that mirror the LocalVolumeDiscoveryResults,
+	// empty if they are disabled
+	DiscoveryResultConfigMapNamespace string
+}
+
+// DefaultOptions returns the options used when no flag is set
+func DefaultOptions() Options {
+	return Options{
+		MinFilesystemDeviceSize:  DefaultMinFilesystemDeviceSize,
+		PVNodeAffinityKey:        corev1.LabelHostname,
+		DiskMakerSecurityContext: DiskMakerSecurityContextPrivileged,
+		NodeNotReadyGracePeriod:  DefaultNodeNotReadyGracePeriod,
+	}
+}
+
+// Validate returns an error naming the flag of the first invalid option
+func (o Options) Validate() error {
+	for _, err := range []error{
+		validateResyncPeriod(o.ResyncPeriod),
+		validateLogLevel(o.LogLevel),
+		validateMinFilesystemDeviceSize(o.MinFilesystemDeviceSize),
+		validatePVNodeAffinityKey(o.PVNodeAffinityKey),
+		validateDiskMakerSecurityContext(o.DiskMakerSecurityContext),
+		validateNodeNotReadyGracePeriod(o.NodeNotReadyGracePeriod),
+		validateHostExcludeFile(o.HostExcludeFile),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResyncPeriodOrDefault returns the resync period if it is set, defaultPeriod otherwise
+func (o Options) ResyncPeriodOrDefault(defaultPeriod time.Duration) time.Duration {
+	if o.ResyncPeriod == 0 {
+		return defaultPeriod
+	}
+	return o.ResyncPeriod
+}
@@ -0,0 +1,12 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultOptions(t *testing.T) {
+	assert.NoError(t, DefaultOptions().Validate(), "the defaults are valid")
+	assert.Error(t, Options{}.Validate(), "the zero value lacks the node affinity key")
+}
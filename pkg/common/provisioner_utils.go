@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"hash/fnv"
 	"path/filepath"
+	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -37,21 +39,83 @@ func GenerateMountMap(runtimeConfig *provCommon.RuntimeConfig) (sets.String, err
 	return mountPointMap, nil
 }
 
+// CreateLocalPVArgs holds the arguments for CreateLocalPV
+type CreateLocalPVArgs struct {
+	// LocalVolumeLikeObject is the LocalVolume or LocalVolumeSet the PV is created for
+	LocalVolumeLikeObject runtime.Object
+	RuntimeConfig         *provCommon.RuntimeConfig
+	CleanupTracker        *provDeleter.CleanupStatusTracker
+	StorageClass          storagev1.StorageClass
+	MountPointMap         sets.String
+	Client                client.Client
+	// SymLinkPath is the path of the symlink to the device under the storageclass directory
+	SymLinkPath string
+	// DeviceName is the KNAME of the device
+	DeviceName string
+	// DeviceIdentity identifies the physical device, see internal.BlockDevice.StableIdentity.
+	// It is stored on the PV and must not change for the lifetime of the PV.
+	DeviceIdentity string
+	// DeviceByID and DeviceByPath are the /dev/disk/by-id/ and /dev/disk/by-path/ links of the device,
+	// they are recorded on the PV when it is created and never updated
+	DeviceByID   string
+	DeviceByPath string
+	// DeviceAlias is the link of the device the symlink was created from, empty if it is the /dev/KNAME path.
+	// It is recorded on the PV, see PVDeviceAliasAnnotation.
+	DeviceAlias      string
+	IDExists         bool
+	ExtraLabelsForPV map[string]string
+	// PVLabels and PVAnnotations are the spec.pvLabels and spec.pvAnnotations of the LocalVolumeLikeObject,
+	// they are added to the PV unless the operator sets the same key
+	PVLabels      map[string]string
+	PVAnnotations map[string]string
+	// BackupLabels and BackupAnnotations are added to the PV for backup tools,
+	// unless the operator, PVLabels or PVAnnotations set the same key
+	BackupLabels      map[string]string
+	BackupAnnotations map[string]string
+	// AccessModes, if set, replace ReadWriteOnce as the access modes of a new PV
+	AccessModes []corev1.PersistentVolumeAccessMode
+	// PVNamePrefix replaces DefaultPVNamePrefix in the name of a new PV if set
+	PVNamePrefix string
+	// NodeAffinityKey is the node label key that the node affinity of a new PV selects, kubernetes.io/hostname if empty
+	NodeAffinityKey string
+	// RecreationLimiter, if set, delays creating PVs that were recreated too often
+	RecreationLimiter *PVRecreationLimiter
+	// CapacityBytes, if set, is advertised as the PV's capacity instead of the measured capacity.
+	// It is used for directories, whose filesystem is shared by several PVs.
+	CapacityBytes int64
+	// RoundCapacity, if set, rounds the capacity of new PVs instead of RoundDownCapacityPretty
+	RoundCapacity func(capacityBytes int64) int64
+	// PrepareDevice, if set, is called before creating a new PV for the device,
+	// once the cleanup of any previous PV of the device finished
+	PrepareDevice func() error
+	// UnmanagedPV, if set, only prepares the device: its PV is left to another tool,
+	// for objects whose managePersistentVolumes is false
+	UnmanagedPV bool
+}
+
+// DeviceIdentityMismatchError is returned by CreateLocalPV when the device behind the symlink
+// is not the device the PV was originally created for
+type DeviceIdentityMismatchError struct {
+	PVName   string
+	Expected string
+	Actual   string
+}
+
+func (e DeviceIdentityMismatchError) Error() string {
+	return fmt.Sprintf("device behind PV %q changed: PV was created for device %q but it now points to %q", e.PVName, e.Expected, e.Actual)
+}
+
 // CreateLocalPV is used to create a local PV against a symlink
 // after passing the same validations against that symlink that local-static-provisioner uses
-func CreateLocalPV(
-	obj runtime.Object,
-	runtimeConfig *provCommon.RuntimeConfig,
-	cleanupTracker *provDeleter.CleanupStatusTracker,
-	devLogger logr.Logger,
-	storageClass storagev1.StorageClass,
-	mountPointMap sets.String,
-	client client.Client,
-	symLinkPath string,
-	deviceName string,
-	idExists bool,
-	extraLabelsForPV map[string]string,
-) error {
+func CreateLocalPV(args CreateLocalPVArgs, devLogger logr.Logger) error {
+	start := time.Now()
+	obj := args.LocalVolumeLikeObject
+	runtimeConfig := args.RuntimeConfig
+	cleanupTracker := args.CleanupTracker
+	storageClass := args.StorageClass
+	mountPointMap := args.MountPointMap
+	symLinkPath := args.SymLinkPath
+
 	useJob := false
 	nodeLabels := runtimeConfig.Node.GetLabels()
 	hostname, found := nodeLabels[corev1.LabelHostname]
@@ -59,19 +123,44 @@ func CreateLocalPV(
 		return fmt.Errorf("could node find label %q for node %q", corev1.LabelHostname, runtimeConfig.Node.GetName())
 	}
 
-	pvName := GeneratePVName(filepath.Base(symLinkPath), runtimeConfig.Node.Name, storageClass.Name)
+	// the node affinity selects the node by kubernetes.io/hostname, unless another key is configured
+	affinityKey := args.NodeAffinityKey
+	if affinityKey == "" {
+		affinityKey = corev1.LabelHostname
+	}
+	affinityValue, found := nodeLabels[affinityKey]
+	if !found {
+		return fmt.Errorf("could not find the node affinity label %q for node %q", affinityKey, runtimeConfig.Node.GetName())
+	}
+
+	pvName, err := getExistingPVName(args.Client, hostname, storageClass.Name, symLinkPath)
+	if err != nil {
+		return err
+	}
+	creating := pvName == ""
+	if creating {
+		pvName = GeneratePVNameWithPrefix(args.PVNamePrefix, filepath.Base(symLinkPath), runtimeConfig.Node.Name, storageClass.Name)
+	}
 
 	pvLogger := devLogger.WithValues("pv.Name", pvName)
 
+	if creating && args.RecreationLimiter != nil && !args.UnmanagedPV {
+		allowed, wait := args.RecreationLimiter.Allow(pvName)
+		if !allowed {
+			pvLogger.Info("PV was recreated too often, delaying its recreation", "wait", wait.Round(time.Second))
+			return nil
+		}
+	}
+
 	nodeAffinity := &corev1.VolumeNodeAffinity{
 		Required: &corev1.NodeSelector{
 			NodeSelectorTerms: []corev1.NodeSelectorTerm{
 				{
 					MatchExpressions: []corev1.NodeSelectorRequirement{
 						{
-							Key:      corev1.LabelHostname,
+							Key:      affinityKey,
 							Operator: corev1.NodeSelectorOpIn,
-							Values:   []string{hostname},
+							Values:   []string{affinityValue},
 						},
 					},
 				},
@@ -102,6 +191,17 @@ func CreateLocalPV(
 		return err
 	}
 
+	if creating && args.PrepareDevice != nil {
+		err = args.PrepareDevice()
+		if err != nil {
+			return fmt.Errorf("failed to prepare the device: %w", err)
+		}
+	}
+	if args.UnmanagedPV {
+		pvLogger.Info("not creating the PV, managePersistentVolumes is false")
+		return nil
+	}
+
 	var capacityBytes int64
 	switch actualVolumeMode {
 	case corev1.PersistentVolumeBlock:
@@ -129,6 +229,17 @@ func CreateLocalPV(
 	default:
 		return fmt.Errorf("path %q has unexpected volume type %q", symLinkPath, actualVolumeMode)
 	}
+	if args.CapacityBytes > 0 {
+		capacityBytes = args.CapacityBytes
+	}
+	roundCapacity := RoundDownCapacityPretty
+	if args.RoundCapacity != nil {
+		roundCapacity = args.RoundCapacity
+	}
+	pvCapacityBytes := roundCapacity(capacityBytes)
+	if creating && pvCapacityBytes <= 0 {
+		return fmt.Errorf("capacity %d of %q rounds down to %d bytes", capacityBytes, symLinkPath, pvCapacityBytes)
+	}
 
 	accessor, err := meta.Accessor(obj)
 	if err != nil {
@@ -148,17 +259,48 @@ func CreateLocalPV(
 		PVOwnerKindLabel:      kind,
 		PVOwnerNamespaceLabel: namespace,
 		PVOwnerNameLabel:      name,
+		OwnerKindLabel:        kind,
+		OwnerNamespaceLabel:   namespace,
+		OwnerNameLabel:        name,
 	}
-	for key, value := range extraLabelsForPV {
+	for key, value := range args.ExtraLabelsForPV {
 		labels[key] = value
 	}
 	annotations := map[string]string{
-		PVDeviceNameLabel:           deviceName,
+		PVDeviceNameLabel:           args.DeviceName,
 		provCommon.AnnProvisionedBy: runtimeConfig.Name,
 	}
-	if idExists {
+	if args.IDExists {
 		annotations[PVDeviceIDLabel] = filepath.Base(symLinkPath)
 	}
+	if args.DeviceIdentity != "" {
+		annotations[PVDeviceIdentityAnnotation] = args.DeviceIdentity
+	}
+	// a symlink that was kept while the device is now found by another link isn't named after the alias
+	if args.DeviceAlias != "" && filepath.Base(args.DeviceAlias) == filepath.Base(symLinkPath) {
+		annotations[PVDeviceAliasAnnotation] = args.DeviceAlias
+	}
+	for key, value := range args.PVLabels {
+		if _, found := labels[key]; !found {
+			labels[key] = value
+		}
+	}
+	for key, value := range args.PVAnnotations {
+		if _, found := annotations[key]; !found {
+			annotations[key] = value
+		}
+	}
+	// the labels and annotations for backup tools come last, spec.pvLabels and spec.pvAnnotations of the object replace them
+	for key, value := range args.BackupLabels {
+		if _, found := labels[key]; !found {
+			labels[key] = value
+		}
+	}
+	for key, value := range args.BackupAnnotations {
+		if _, found := annotations[key]; !found {
+			annotations[key] = value
+		}
+	}
 
 	var reclaimPolicy corev1.PersistentVolumeReclaimPolicy
 	if storageClass.ReclaimPolicy == nil {
@@ -171,7 +313,7 @@ func CreateLocalPV(
 	localPVConfig := &provCommon.LocalPVConfig{
 		Name:            pvName,
 		HostPath:        symLinkPath,
-		Capacity:        RoundDownCapacityPretty(capacityBytes), // d.VolUtil.GetBlockCapacityByte(filePath)
+		Capacity:        pvCapacityBytes, // d.VolUtil.GetBlockCapacityByte(filePath)
 		StorageClass:    storageClass.GetName(),
 		ReclaimPolicy:   reclaimPolicy,      // fetch from storageClass (created by LocalVolumeSet operator controller)
 		ProvisionerName: runtimeConfig.Name, // populate in runtimeconfig earlier
@@ -191,14 +333,31 @@ func CreateLocalPV(
 		localPVConfig.FsType = &fsType
 	}
 	newPV := provCommon.CreateLocalPVSpec(localPVConfig)
+	if len(args.AccessModes) > 0 {
+		newPV.Spec.AccessModes = args.AccessModes
+	}
 
 	existingPV := &corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: pvName}}
 
 	pvLogger.Info("creating")
-	opRes, err := controllerutil.CreateOrUpdate(context.TODO(), client, existingPV, func() error {
+	opRes, err := controllerutil.CreateOrUpdate(context.TODO(), args.Client, existingPV, func() error {
+		// refuse to touch a PV whose symlink now resolves to a different device
+		err := checkDeviceIdentity(existingPV, args.DeviceIdentity)
+		if err != nil {
+			pvLogger.Error(err, "not updating PV", "filePath", symLinkPath)
+			runtimeConfig.Recorder.Eventf(existingPV, corev1.EventTypeWarning, DeviceIdentityMismatch, err.Error())
+			return err
+		}
 		if existingPV.CreationTimestamp.IsZero() {
 			// operations for create
 			newPV.DeepCopyInto(existingPV)
+			InitMapIfNil(&existingPV.ObjectMeta.Annotations)
+			if args.DeviceByID != "" {
+				existingPV.ObjectMeta.Annotations[PVDeviceByIDAnnotation] = args.DeviceByID
+			}
+			if args.DeviceByPath != "" {
+				existingPV.ObjectMeta.Annotations[PVDeviceByPathAnnotation] = args.DeviceByPath
+			}
 		}
 		// operations for update only
 
@@ -232,21 +391,106 @@ func CreateLocalPV(
 
 		return nil
 	})
+	if kerrors.IsAlreadyExists(err) {
+		// the PV was created by an earlier reconcile and the cache didn't see it yet,
+		// it is updated by the next reconcile instead of failing this one
+		pvLogger.Info("PV already exists but is not in the cache yet")
+		return nil
+	}
 	if opRes != controllerutil.OperationResultNone {
 		pvLogger.Info("pv changed", "operation", opRes)
 	}
+	if opRes == controllerutil.OperationResultCreated {
+		PVCreateDuration.Observe(time.Since(start).Seconds())
+		if args.RecreationLimiter != nil {
+			args.RecreationLimiter.RecordCreation(pvName)
+		}
+	}
 
 	return err
 }
 
+// checkDeviceIdentity returns a DeviceIdentityMismatchError if the PV records a device identity
+// that differs from the identity of the device currently behind its symlink.
+// PVs created before the identity was recorded, or devices without one, are not checked.
+func checkDeviceIdentity(pv *corev1.PersistentVolume, deviceIdentity string) error {
+	recorded, found := pv.GetAnnotations()[PVDeviceIdentityAnnotation]
+	if !found || recorded == "" || deviceIdentity == "" || recorded == deviceIdentity {
+		return nil
+	}
+	return DeviceIdentityMismatchError{PVName: pv.Name, Expected: recorded, Actual: deviceIdentity}
+}
+
+// getExistingPVName returns the name of the PV already created for symLinkPath on the node, or "" if there is none.
+// A PV keeps its name when spec.pvNamePrefix changes, so that the device does not get a second PV.
+func getExistingPVName(c client.Client, hostname, storageClassName, symLinkPath string) (string, error) {
+	pv, err := FindPVForSymLink(c, hostname, storageClassName, symLinkPath)
+	if err != nil || pv == nil {
+		return "", err
+	}
+	return pv.Name, nil
+}
+
+// FindPVForSymLink returns the local PV of the storageclass on the node with the hostname
+// whose path is the symlink, or nil if there is none
+func FindPVForSymLink(c client.Client, hostname, storageClassName, symLinkPath string) (*corev1.PersistentVolume, error) {
+	pvs := &corev1.PersistentVolumeList{}
+	err := c.List(context.TODO(), pvs, client.MatchingLabels{corev1.LabelHostname: hostname})
+	if err != nil {
+		return nil, fmt.Errorf("could not list PVs on node %q: %w", hostname, err)
+	}
+	for i, pv := range pvs.Items {
+		if pv.Spec.StorageClassName == storageClassName && pv.Spec.Local != nil && pv.Spec.Local.Path == symLinkPath {
+			return &pvs.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// FindPVDeviceAlias returns the PVDeviceAliasAnnotation of the local PV of the storageclass on the node with the hostname
+// that was created for the device with deviceIdentity, or an empty string if there is none.
+// The PVs that are duplicates of another PV of the device are ignored.
+func FindPVDeviceAlias(c client.Client, hostname, storageClassName, deviceIdentity string) (string, error) {
+	if deviceIdentity == "" {
+		return "", nil
+	}
+	pvs := &corev1.PersistentVolumeList{}
+	err := c.List(context.TODO(), pvs, client.MatchingLabels{corev1.LabelHostname: hostname})
+	if err != nil {
+		return "", fmt.Errorf("could not list PVs on node %q: %w", hostname, err)
+	}
+	for _, pv := range pvs.Items {
+		if pv.Spec.StorageClassName != storageClassName || pv.Spec.Local == nil {
+			continue
+		}
+		annotations := pv.GetAnnotations()
+		if annotations[PVDeviceIdentityAnnotation] != deviceIdentity || annotations[PVDuplicateOfAnnotation] != "" {
+			continue
+		}
+		if alias := annotations[PVDeviceAliasAnnotation]; alias != "" {
+			return alias, nil
+		}
+	}
+	return "", nil
+}
+
 // GeneratePVName is used to generate a PV name based on the filename, node, and storageclass
 // Important, this hash value should remain consistent, so this function should not be changed
 // in a way that would change its output.
 func GeneratePVName(file, node, class string) string {
+	return GeneratePVNameWithPrefix(DefaultPVNamePrefix, file, node, class)
+}
+
+// GeneratePVNameWithPrefix is GeneratePVName with a custom prefix in place of DefaultPVNamePrefix.
+// An empty prefix means DefaultPVNamePrefix.
+func GeneratePVNameWithPrefix(prefix, file, node, class string) string {
+	if prefix == "" {
+		prefix = DefaultPVNamePrefix
+	}
 	h := fnv.New32a()
 	h.Write([]byte(file))
 	h.Write([]byte(node))
 	h.Write([]byte(class))
 	// This is the FNV-1a 32-bit hash
-	return fmt.Sprintf("local-pv-%x", h.Sum32())
+	return fmt.Sprintf("%s%x", prefix, h.Sum32())
 }
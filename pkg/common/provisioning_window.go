@@ -0,0 +1,158 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+)
+
+// ProvisioningDeferred is the type of the condition of LocalVolumeSets whose provisioningWindow is closed,
+// and the event reason used when the diskmaker defers a new device until the window opens
+const ProvisioningDeferred = "ProvisioningDeferred"
+
+// weekdays are the days of spec.provisioningWindow.days, indexed by time.Weekday
+var weekdays = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+var timeOfDayPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):([0-5][0-9])$`)
+
+// provisioningWindow is a parsed localv1alpha1.ProvisioningWindow
+type provisioningWindow struct {
+	// startHour, startMinute, endHour and endMinute are the times of day in location
+	startHour, startMinute int
+	endHour, endMinute     int
+	// days are the days the window opens on, all days if it is empty
+	days     map[time.Weekday]bool
+	location *time.Location
+}
+
+// parseTimeOfDay parses a HH:MM time of day
+func parseTimeOfDay(value string) (int, int, error) {
+	match := timeOfDayPattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, 0, fmt.Errorf("%q is not a time of day in the HH:MM format", value)
+	}
+	// the pattern only matches valid numbers
+	hour, _ := strconv.Atoi(match[1])
+	minute, _ := strconv.Atoi(match[2])
+	return hour, minute, nil
+}
+
+func parseProvisioningWindow(window *localv1alpha1.ProvisioningWindow) (*provisioningWindow, error) {
+	parsed := &provisioningWindow{days: map[time.Weekday]bool{}, location: time.UTC}
+	var err error
+	parsed.startHour, parsed.startMinute, err = parseTimeOfDay(window.Start)
+	if err != nil {
+		return nil, fmt.Errorf("start: %w", err)
+	}
+	parsed.endHour, parsed.endMinute, err = parseTimeOfDay(window.End)
+	if err != nil {
+		return nil, fmt.Errorf("end: %w", err)
+	}
+	for _, day := range window.Days {
+		found := false
+		for weekday, name := range weekdays {
+			if day == name {
+				parsed.days[time.Weekday(weekday)] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("days: %q is not one of %v", day, weekdays)
+		}
+	}
+	if window.TimeZone != "" {
+		// Local is the time zone of the operator or diskmaker container, which is not what was meant
+		if window.TimeZone == "Local" {
+			return nil, fmt.Errorf("timeZone: %q is not an IANA time zone name", window.TimeZone)
+		}
+		parsed.location, err = time.LoadLocation(window.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("timeZone: %w", err)
+		}
+	}
+	return parsed, nil
+}
+
+// ValidateProvisioningWindow checks the times of day, the days and the time zone of spec.provisioningWindow
+func ValidateProvisioningWindow(window *localv1alpha1.ProvisioningWindow) error {
+	if window == nil {
+		return nil
+	}
+	_, err := parseProvisioningWindow(window)
+	if err != nil {
+		return fmt.Errorf("spec.provisioningWindow.%w", err)
+	}
+	return nil
+}
+
+// timeRange is the half-open interval [start, end)
+type timeRange struct {
+	start, end time.Time
+}
+
+// occurrences returns the openings of the window that start from the day before the day of now until a week after it,
+// merged when one opens when or before the previous closes
+func (w *provisioningWindow) occurrences(now time.Time) []timeRange {
+	local := now.In(w.location)
+	ranges := []timeRange{}
+	for offset := -1; offset <= 8; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, w.location)
+		if len(w.days) > 0 && !w.days[day.Weekday()] {
+			continue
+		}
+		start := time.Date(day.Year(), day.Month(), day.Day(), w.startHour, w.startMinute, 0, 0, w.location)
+		end := time.Date(day.Year(), day.Month(), day.Day(), w.endHour, w.endMinute, 0, 0, w.location)
+		if !end.After(start) {
+			// the window spans midnight, or the whole day when it ends when it starts
+			end = time.Date(day.Year(), day.Month(), day.Day()+1, w.endHour, w.endMinute, 0, 0, w.location)
+		}
+		ranges = append(ranges, timeRange{start: start, end: end})
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start.Before(ranges[j].start) })
+	merged := []timeRange{}
+	for _, r := range ranges {
+		if last := len(merged) - 1; last >= 0 && !r.start.After(merged[last].end) {
+			if r.end.After(merged[last].end) {
+				merged[last].end = r.end
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// ProvisioningWindowState returns whether the provisioning window is open at now, and when it next closes if it is open
+// or opens if it is closed. A nil window is always open, with a zero next time.
+func ProvisioningWindowState(window *localv1alpha1.ProvisioningWindow, now time.Time) (bool, time.Time, error) {
+	if window == nil {
+		return true, time.Time{}, nil
+	}
+	parsed, err := parseProvisioningWindow(window)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid provisioningWindow: %w", err)
+	}
+	for _, r := range parsed.occurrences(now) {
+		if now.Before(r.start) {
+			return false, r.start, nil
+		}
+		if now.Before(r.end) {
+			return true, r.end, nil
+		}
+	}
+	// not reached, the occurrences span more than a week and the window opens at least once a week
+	return false, time.Time{}, fmt.Errorf("provisioningWindow %+v never opens", *window)
+}
+
+// ProvisioningWindowRequeueAfter returns how long until the provisioning window opens or closes, to reconcile then:
+// the time until next plus a second, so that the state already changed, and 0 for a zero next time
+func ProvisioningWindowRequeueAfter(next, now time.Time) time.Duration {
+	if next.IsZero() {
+		return 0
+	}
+	return next.Sub(now) + time.Second
+}
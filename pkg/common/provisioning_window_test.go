@@ -0,0 +1,103 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvisioningWindowState(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.NoError(t, err)
+	// 2021-03-03 is a Wednesday
+	utc := func(day, hour, minute int) time.Time { return time.Date(2021, 3, day, hour, minute, 0, 0, time.UTC) }
+
+	testTable := []struct {
+		desc         string
+		window       *localv1alpha1.ProvisioningWindow
+		now          time.Time
+		expectedOpen bool
+		expectedNext time.Time
+	}{
+		{
+			desc:         "no window is always open",
+			now:          utc(3, 12, 0),
+			expectedOpen: true,
+		},
+		{
+			desc:         "before the window",
+			window:       &localv1alpha1.ProvisioningWindow{Start: "22:00", End: "23:30"},
+			now:          utc(3, 12, 0),
+			expectedNext: utc(3, 22, 0),
+		},
+		{
+			desc:         "in the window",
+			window:       &localv1alpha1.ProvisioningWindow{Start: "22:00", End: "23:30"},
+			now:          utc(3, 22, 0),
+			expectedOpen: true,
+			expectedNext: utc(3, 23, 30),
+		},
+		{
+			desc:         "a window spanning midnight is open after midnight",
+			window:       &localv1alpha1.ProvisioningWindow{Start: "22:00", End: "04:00"},
+			now:          utc(3, 1, 0),
+			expectedOpen: true,
+			expectedNext: utc(3, 4, 0),
+		},
+		{
+			desc:         "a window spanning midnight opens again in the evening",
+			window:       &localv1alpha1.ProvisioningWindow{Start: "22:00", End: "04:00"},
+			now:          utc(3, 4, 0),
+			expectedNext: utc(3, 22, 0),
+		},
+		{
+			desc:         "the window only opens on its days",
+			window:       &localv1alpha1.ProvisioningWindow{Start: "00:00", End: "00:00", Days: []string{"Sat", "Sun"}},
+			now:          utc(3, 12, 0),
+			expectedNext: utc(6, 0, 0),
+		},
+		{
+			desc:         "consecutive whole days are one window",
+			window:       &localv1alpha1.ProvisioningWindow{Start: "00:00", End: "00:00", Days: []string{"Sat", "Sun"}},
+			now:          utc(6, 12, 0),
+			expectedOpen: true,
+			expectedNext: utc(8, 0, 0),
+		},
+		{
+			desc:         "a window spanning midnight belongs to the day it opens",
+			window:       &localv1alpha1.ProvisioningWindow{Start: "22:00", End: "04:00", Days: []string{"Tue"}},
+			now:          utc(3, 3, 0),
+			expectedOpen: true,
+			expectedNext: utc(3, 4, 0),
+		},
+		{
+			desc:         "the times are in the time zone",
+			window:       &localv1alpha1.ProvisioningWindow{Start: "22:00", End: "23:00", TimeZone: "Europe/Paris"},
+			now:          utc(3, 21, 30),
+			expectedOpen: true,
+			expectedNext: time.Date(2021, 3, 3, 23, 0, 0, 0, paris),
+		},
+	}
+	for _, tc := range testTable {
+		open, next, err := ProvisioningWindowState(tc.window, tc.now)
+		assert.NoErrorf(t, err, "[%s]", tc.desc)
+		assert.Equalf(t, tc.expectedOpen, open, "[%s] open", tc.desc)
+		assert.Truef(t, tc.expectedNext.Equal(next), "[%s] expected next %v, got %v", tc.desc, tc.expectedNext, next)
+	}
+}
+
+func TestValidateProvisioningWindow(t *testing.T) {
+	assert.NoError(t, ValidateProvisioningWindow(nil))
+	assert.NoError(t, ValidateProvisioningWindow(&localv1alpha1.ProvisioningWindow{Start: "22:00", End: "06:00", Days: []string{"Sat"}, TimeZone: "America/New_York"}))
+	for _, window := range []localv1alpha1.ProvisioningWindow{
+		{Start: "24:00", End: "06:00"},
+		{Start: "22:00", End: "6:00"},
+		{Start: "22:00", End: "06:00", Days: []string{"Saturday"}},
+		{Start: "22:00", End: "06:00", TimeZone: "Mars/Olympus_Mons"},
+		{Start: "22:00", End: "06:00", TimeZone: "Local"},
+	} {
+		assert.Errorf(t, ValidateProvisioningWindow(&window), "%+v should be invalid", window)
+	}
+}
@@ -0,0 +1,87 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+)
+
+const (
+	// PVBackupAnnotationsFlag is the flag of the operator and the diskmaker that sets the annotations of new PVs
+	// for backup tools, as a comma separated list of key=value pairs or PVBackupAnnotationsVelero
+	PVBackupAnnotationsFlag = "pv-backup-annotations"
+	// PVBackupAnnotationsVelero selects VeleroPVBackupLabels
+	PVBackupAnnotationsVelero = "velero"
+)
+
+// VeleroPVBackupLabels are the labels of PVBackupAnnotationsVelero. Velero skips the objects with the
+// velero.io/exclude-from-backup label, the data of a local PV can't be restored on another node.
+// Velero ignores the key as an annotation, so the preset sets a label.
+var VeleroPVBackupLabels = map[string]string{
+	"velero.io/exclude-from-backup": "true",
+}
+
+// ParsePVBackupMetadata returns the annotations and the labels added to the PVs for backup tools from the value
+// of PVBackupAnnotationsFlag. An empty value adds none. The keys follow the rules of spec.pvAnnotations,
+// the prefixes of the operator and of Kubernetes are rejected. PVBackupAnnotationsVelero adds the
+// VeleroPVBackupLabels instead.
+func ParsePVBackupMetadata(value string) (map[string]string, map[string]string, error) {
+	labels := map[string]string{}
+	if strings.TrimSpace(value) == PVBackupAnnotationsVelero {
+		for key, value := range VeleroPVBackupLabels {
+			labels[key] = value
+		}
+		return map[string]string{}, labels, nil
+	}
+	annotations, err := ParsePVBackupAnnotations(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return annotations, labels, nil
+}
+
+// PVBackupAnnotationsFlagValue returns the value of PVBackupAnnotationsFlag that sets the annotations and labels
+// of the options, empty if there are none
+func (o Options) PVBackupAnnotationsFlagValue() string {
+	if len(o.PVBackupLabels) > 0 {
+		return PVBackupAnnotationsVelero
+	}
+	return FormatPVBackupAnnotations(o.PVBackupAnnotations)
+}
+
+// ParsePVBackupAnnotations parses a list of key=value annotations of PVBackupAnnotationsFlag
+func ParsePVBackupAnnotations(value string) (map[string]string, error) {
+	annotations := map[string]string{}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return annotations, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("--%s %q is not a key=value pair", PVBackupAnnotationsFlag, pair)
+		}
+		key := strings.TrimSpace(parts[0])
+		if _, found := annotations[key]; found {
+			return nil, fmt.Errorf("--%s sets %q twice", PVBackupAnnotationsFlag, key)
+		}
+		annotations[key] = strings.TrimSpace(parts[1])
+	}
+	if err := localv1.ValidatePVMetadata(nil, annotations); err != nil {
+		return nil, fmt.Errorf("--%s: %w", PVBackupAnnotationsFlag, err)
+	}
+	return annotations, nil
+}
+
+// FormatPVBackupAnnotations returns the value of PVBackupAnnotationsFlag that sets the annotations,
+// with the keys sorted so that it doesn't change between calls
+func FormatPVBackupAnnotations(annotations map[string]string) string {
+	pairs := make([]string, 0, len(annotations))
+	for key, value := range annotations {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,45 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePVBackupMetadata(t *testing.T) {
+	options := DefaultOptions()
+	assert.Empty(t, options.PVBackupAnnotations, "no annotations by default")
+	assert.Empty(t, options.PVBackupAnnotationsFlagValue())
+
+	var err error
+	options.PVBackupAnnotations, options.PVBackupLabels, err = ParsePVBackupMetadata(PVBackupAnnotationsVelero)
+	assert.NoError(t, err)
+	assert.Empty(t, options.PVBackupAnnotations, "velero only reads the label")
+	assert.Equal(t, map[string]string{"velero.io/exclude-from-backup": "true"}, options.PVBackupLabels)
+	assert.Equal(t, PVBackupAnnotationsVelero, options.PVBackupAnnotationsFlagValue())
+
+	options.PVBackupAnnotations, options.PVBackupLabels, err = ParsePVBackupMetadata("backup.example.com/skip=true, backup.example.com/reason = node-local")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"backup.example.com/skip":   "true",
+		"backup.example.com/reason": "node-local",
+	}, options.PVBackupAnnotations)
+	assert.Empty(t, options.PVBackupLabels)
+	assert.Equal(t, "backup.example.com/reason=node-local,backup.example.com/skip=true", options.PVBackupAnnotationsFlagValue())
+
+	for _, value := range []string{
+		"backup.example.com/skip",
+		"backup.example.com/skip=true,backup.example.com/skip=false",
+		"not a/valid/key=true",
+		"local.storage.openshift.io/backup=false",
+		"pv.kubernetes.io/backup=false",
+	} {
+		_, _, err = ParsePVBackupMetadata(value)
+		assert.Errorf(t, err, "%q is rejected", value)
+	}
+
+	annotations, labels, err := ParsePVBackupMetadata("")
+	assert.NoError(t, err)
+	assert.Empty(t, annotations)
+	assert.Empty(t, labels)
+}
@@ -0,0 +1,18 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// PVNodeAffinityKeyFlag is the flag of the operator and the diskmaker that sets the node label key of the PVs' node affinity
+const PVNodeAffinityKeyFlag = "pv-node-affinity-key"
+
+func validatePVNodeAffinityKey(key string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Errorf("--%s %q is not a valid label key: %s", PVNodeAffinityKeyFlag, key, strings.Join(errs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestValidatePVNodeAffinityKey(t *testing.T) {
+	options := DefaultOptions()
+	assert.Equal(t, corev1.LabelHostname, options.PVNodeAffinityKey, "kubernetes.io/hostname is the default")
+
+	options.PVNodeAffinityKey = "topology.local.csi.example.com/node"
+	assert.NoError(t, options.Validate())
+
+	options.PVNodeAffinityKey = "not a/valid/key"
+	assert.Error(t, options.Validate(), "invalid label keys are rejected")
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// MaxPVRecreationsPerMinute is how many times the PV for a device can be created
+	// in quick succession before recreating it is delayed.
+	MaxPVRecreationsPerMinute = 3
+	// maxPVRecreationBackoff caps the delay between recreations of a churning PV,
+	// it is also how long creations are remembered.
+	maxPVRecreationBackoff = 10 * time.Minute
+)
+
+// PVRecreationLimiter limits how often the PV for a device is created again after it was
+// released, cleaned and deleted. Every cleanup wipes the device, so a consumer that keeps
+// releasing its PVC would otherwise wear out SSDs.
+// Once a PV was created MaxPVRecreationsPerMinute times, each further recreation has to wait
+// twice as long as the previous one, starting at a minute and up to maxPVRecreationBackoff.
+// The history of a PV is forgotten after maxPVRecreationBackoff without a recreation.
+type PVRecreationLimiter struct {
+	lock      sync.Mutex
+	creations map[string][]time.Time
+	now       func() time.Time
+}
+
+// NewPVRecreationLimiter returns an empty PVRecreationLimiter
+func NewPVRecreationLimiter() *PVRecreationLimiter {
+	return &PVRecreationLimiter{
+		creations: map[string][]time.Time{},
+		now:       time.Now,
+	}
+}
+
+// Allow returns whether the PV can be created now, and if not, how long to wait until it can be.
+func (l *PVRecreationLimiter) Allow(pvName string) (bool, time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := l.now()
+	creations := l.recentCreations(pvName, now)
+	if len(creations) < MaxPVRecreationsPerMinute {
+		return true, 0
+	}
+	backoff := time.Minute << uint(len(creations)-MaxPVRecreationsPerMinute)
+	if backoff > maxPVRecreationBackoff || backoff <= 0 {
+		backoff = maxPVRecreationBackoff
+	}
+	wait := creations[len(creations)-1].Add(backoff).Sub(now)
+	if wait <= 0 {
+		return true, 0
+	}
+	return false, wait
+}
+
+// RecordCreation records that the PV was created
+func (l *PVRecreationLimiter) RecordCreation(pvName string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := l.now()
+	l.creations[pvName] = append(l.recentCreations(pvName, now), now)
+}
+
+// recentCreations drops and returns the creations of the PV within maxPVRecreationBackoff
+func (l *PVRecreationLimiter) recentCreations(pvName string, now time.Time) []time.Time {
+	creations := l.creations[pvName]
+	i := 0
+	for i < len(creations) && now.Sub(creations[i]) > maxPVRecreationBackoff {
+		i++
+	}
+	creations = creations[i:]
+	if len(creations) == 0 {
+		delete(l.creations, pvName)
+		return nil
+	}
+	l.creations[pvName] = creations
+	return creations
+}
@@ -0,0 +1,45 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPVRecreationLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := NewPVRecreationLimiter()
+	limiter.now = func() time.Time { return now }
+
+	// the first creations are not delayed
+	for i := 0; i < MaxPVRecreationsPerMinute; i++ {
+		allowed, _ := limiter.Allow("pv-a")
+		assert.Truef(t, allowed, "creation %d", i)
+		limiter.RecordCreation("pv-a")
+		now = now.Add(time.Second)
+	}
+
+	// rapid churn backs off exponentially
+	for _, backoff := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		allowed, wait := limiter.Allow("pv-a")
+		assert.False(t, allowed)
+		assert.Equal(t, backoff-time.Second, wait)
+
+		now = now.Add(wait)
+		allowed, _ = limiter.Allow("pv-a")
+		assert.True(t, allowed)
+		limiter.RecordCreation("pv-a")
+		now = now.Add(time.Second)
+	}
+
+	// other PVs are not affected
+	allowed, _ := limiter.Allow("pv-b")
+	assert.True(t, allowed)
+
+	// history is forgotten after a quiet period
+	now = now.Add(maxPVRecreationBackoff + time.Second)
+	allowed, _ = limiter.Allow("pv-a")
+	assert.True(t, allowed)
+	assert.Empty(t, limiter.creations)
+}
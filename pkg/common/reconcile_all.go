@@ -0,0 +1,42 @@
+package common
+
+import (
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// ReconcileEndpointFlag is the flag of the operator that enables the HTTP endpoint that reconciles all objects
+const ReconcileEndpointFlag = "enable-reconcile-endpoint"
+
+// reconcileAllBroadcaster notifies the subscribed controllers that all their objects are to be reconciled
+type reconcileAllBroadcaster struct {
+	lock        sync.Mutex
+	subscribers []chan event.GenericEvent
+}
+
+var reconcileAll = &reconcileAllBroadcaster{}
+
+// SubscribeReconcileAll returns a channel that receives an event when all objects are to be reconciled,
+// to be watched by a controller with a source.Channel that enqueues all of its objects.
+// The events carry no object, notifications that are not consumed yet are coalesced.
+func SubscribeReconcileAll() <-chan event.GenericEvent {
+	reconcileAll.lock.Lock()
+	defer reconcileAll.lock.Unlock()
+	c := make(chan event.GenericEvent, 1)
+	reconcileAll.subscribers = append(reconcileAll.subscribers, c)
+	return c
+}
+
+// ReconcileAll makes the subscribed controllers reconcile all their objects
+func ReconcileAll() {
+	reconcileAll.lock.Lock()
+	defer reconcileAll.lock.Unlock()
+	for _, c := range reconcileAll.subscribers {
+		select {
+		case c <- event.GenericEvent{}:
+		default:
+			// a notification is already pending
+		}
+	}
+}
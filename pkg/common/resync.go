@@ -0,0 +1,20 @@
+package common
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// ResyncPeriodFlag is the flag of the operator and the diskmaker that sets the resync period
+	ResyncPeriodFlag = "resync-period"
+	// MinResyncPeriod is the shortest resync period accepted, shorter ones would reconcile in a hot loop
+	MinResyncPeriod = 5 * time.Second
+)
+
+func validateResyncPeriod(period time.Duration) error {
+	if period != 0 && period < MinResyncPeriod {
+		return fmt.Errorf("--%s %v is shorter than the minimum of %v", ResyncPeriodFlag, period, MinResyncPeriod)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResyncPeriodOrDefault(t *testing.T) {
+	options := DefaultOptions()
+	assert.Equal(t, time.Minute, options.ResyncPeriodOrDefault(time.Minute), "the default is used when no period is set")
+
+	options.ResyncPeriod = time.Second
+	assert.Error(t, options.Validate(), "periods below the minimum are rejected")
+
+	options.ResyncPeriod = MinResyncPeriod
+	assert.NoError(t, options.Validate())
+	assert.Equal(t, MinResyncPeriod, options.ResyncPeriodOrDefault(time.Minute))
+}
@@ -0,0 +1,32 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// StorageClassNamePatternFlag is the flag of the operator that sets the regular expression
+// the StorageClass names of LocalVolumes and LocalVolumeSets must match
+const StorageClassNamePatternFlag = "storage-class-name-pattern"
+
+// ParseStorageClassNamePattern compiles the regular expression the admission webhooks require the StorageClass names
+// of new LocalVolumes and LocalVolumeSets to match, for example ^local- to enforce a prefix.
+// An empty pattern returns nil, which allows every name.
+func ParseStorageClassNamePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("--%s %q is not a valid regular expression: %w", StorageClassNamePatternFlag, pattern, err)
+	}
+	return re, nil
+}
+
+// ValidateStorageClassName returns an error if the StorageClass name doesn't match the pattern, a nil pattern allows every name
+func ValidateStorageClassName(pattern *regexp.Regexp, name string) error {
+	if pattern == nil || pattern.MatchString(name) {
+		return nil
+	}
+	return fmt.Errorf("storageClassName %q doesn't match the required pattern %q", name, pattern.String())
+}
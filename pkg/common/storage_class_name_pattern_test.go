@@ -0,0 +1,25 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStorageClassNamePattern(t *testing.T) {
+	options := DefaultOptions()
+	assert.Nil(t, options.StorageClassNamePattern)
+	assert.NoError(t, ValidateStorageClassName(options.StorageClassNamePattern, "fast-disks"), "every name is allowed by default")
+
+	pattern, err := ParseStorageClassNamePattern("^local-")
+	assert.NoError(t, err)
+	assert.NoError(t, ValidateStorageClassName(pattern, "local-fast-disks"))
+	assert.Error(t, ValidateStorageClassName(pattern, "fast-disks"))
+
+	_, err = ParseStorageClassNamePattern("^local-(")
+	assert.Error(t, err, "invalid regular expressions are rejected")
+
+	pattern, err = ParseStorageClassNamePattern("")
+	assert.NoError(t, err)
+	assert.Nil(t, pattern)
+}
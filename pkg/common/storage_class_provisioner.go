@@ -0,0 +1,50 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	storagev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// NoProvisioner is the provisioner of the StorageClasses of local PVs. The diskmakers create the PVs statically,
+	// a StorageClass with another provisioner makes them look dynamically provisioned.
+	NoProvisioner = "kubernetes.io/no-provisioner"
+	// StorageClassProvisionerMismatch is the type of the condition of LocalVolumes and LocalVolumeSets
+	// that is true when one of their StorageClasses has another provisioner than NoProvisioner
+	StorageClassProvisionerMismatch = "StorageClassProvisionerMismatch"
+)
+
+// MismatchedStorageClasses returns the StorageClasses among names whose provisioner is not NoProvisioner,
+// as "name (provisioner)". StorageClasses that don't exist are skipped.
+func MismatchedStorageClasses(c client.Client, names []string) ([]string, error) {
+	mismatched := []string{}
+	for _, name := range names {
+		sc := &storagev1.StorageClass{}
+		err := c.Get(context.TODO(), types.NamespacedName{Name: name}, sc)
+		if kerrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get storageclass %s: %w", name, err)
+		}
+		if sc.Provisioner != NoProvisioner {
+			mismatched = append(mismatched, fmt.Sprintf("%s (%s)", sc.Name, sc.Provisioner))
+		}
+	}
+	return mismatched, nil
+}
+
+// StorageClassProvisionerMismatchMessage returns the message of the StorageClassProvisionerMismatch condition
+// for the mismatched StorageClasses
+func StorageClassProvisionerMismatchMessage(mismatched []string) string {
+	if len(mismatched) == 0 {
+		return fmt.Sprintf("All storageclasses use the %s provisioner", NoProvisioner)
+	}
+	return fmt.Sprintf("Storageclasses don't use the %s provisioner, their local PVs look dynamically provisioned: %s",
+		NoProvisioner, strings.Join(mismatched, ", "))
+}
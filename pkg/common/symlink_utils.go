@@ -2,38 +2,115 @@ package common
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
+	"github.com/go-logr/logr"
 	"github.com/openshift/local-storage-operator/pkg/internal"
+	corev1 "k8s.io/api/core/v1"
 )
 
+const (
+	// SymlinkTargetByID selects the /dev/disk/by-id/ link of a device as the source of its symlink
+	SymlinkTargetByID = "by-id"
+	// SymlinkTargetByPath selects the /dev/disk/by-path/ link of a device
+	SymlinkTargetByPath = "by-path"
+	// SymlinkTargetByUUID selects the /dev/disk/by-uuid/ link of a device, which only devices with a filesystem have
+	SymlinkTargetByUUID = "by-uuid"
+)
+
+// DefaultSymlinkTargetPreference is used when no preference is set
+var DefaultSymlinkTargetPreference = []string{SymlinkTargetByID}
+
 // GetSymLinkSourceAndTarget returns
-// `source`: the /dev/disk/by-id path of the device if it exists, /dev/KNAME if it doesn't
-// `target`: the path in the symlinkdir to symlink to. device-id if it exists, KNAME if it doesn't
-// `idExists`: is set if the device-id exists
+// `source`: the first link of the device in the order of preference, /dev/KNAME if it has none of them.
+// `target`: the path in the symlinkdir to symlink to. the name of the link if it exists, KNAME if it doesn't
+// `idExists`: is set if a link of the device exists
 // `err`
-func GetSymLinkSourceAndTarget(dev internal.BlockDevice, symlinkDir string) (string, string, bool, error) {
-	var source string
-	var target string
-	var idExists = true
-	var err error
-
+// An empty preference is DefaultSymlinkTargetPreference, the /dev/disk/by-id/ link.
+func GetSymLinkSourceAndTarget(dev internal.BlockDevice, symlinkDir string, preference []string) (string, string, bool, error) {
 	// get /dev/KNAME path
 	devLabelPath, err := dev.GetDevPath()
 	if err != nil {
-		return source, target, false, err
+		return "", "", false, err
+	}
+	if len(preference) == 0 {
+		preference = DefaultSymlinkTargetPreference
 	}
 	// determine symlink source
-	source, err = dev.GetPathByID()
-	if errors.As(err, &internal.IDPathNotFoundError{}) {
-		// no disk-by-id
-		idExists = false
-		source = devLabelPath
-	} else if err != nil {
-		return source, target, false, err
+	for _, linkType := range preference {
+		var source string
+		switch linkType {
+		case SymlinkTargetByID:
+			source, err = dev.GetPathByID()
+			if errors.As(err, &internal.IDPathNotFoundError{}) {
+				// no disk-by-id
+				continue
+			}
+		case SymlinkTargetByPath:
+			source, err = dev.GetPathByPath()
+		case SymlinkTargetByUUID:
+			source, err = dev.GetPathByUUID()
+		default:
+			return "", "", false, fmt.Errorf("unknown symlink target type %q", linkType)
+		}
+		if err != nil {
+			return "", "", false, err
+		}
+		if source != "" {
+			return source, path.Join(symlinkDir, filepath.Base(source)), true, nil
+		}
+	}
+	return devLabelPath, path.Join(symlinkDir, filepath.Base(devLabelPath)), false, nil
+}
+
+// DeviceLinks returns the /dev/disk/by-id/ and /dev/disk/by-path/ links of the device that are recorded on its PV.
+// symlinkSource and idExists are returned by GetSymLinkSourceAndTarget.
+// A link that can't be found is empty, failing to look it up doesn't prevent provisioning.
+func DeviceLinks(dev internal.BlockDevice, symlinkSource string, idExists bool, devLogger logr.Logger) (string, string) {
+	byID := ""
+	if idExists && strings.HasPrefix(symlinkSource, internal.DiskByIDDir) {
+		byID = symlinkSource
+	} else if idExists {
+		// the device is symlinked by another link
+		link, err := dev.GetPathByID()
+		if err == nil {
+			byID = link
+		} else if !errors.As(err, &internal.IDPathNotFoundError{}) {
+			devLogger.Error(err, "could not find the by-id link of the device")
+		}
+	}
+	byPath, err := dev.GetPathByPath()
+	if err != nil {
+		devLogger.Error(err, "could not find the by-path link of the device")
 	}
-	target = path.Join(symlinkDir, filepath.Base(source))
-	return source, target, idExists, nil
+	return byID, byPath
+}
 
+// RemoveSymlinksOfPVs removes the symlinks in symlinkDir that are the local path of one of pvs on the node
+// with hostname, and returns their names. Symlinks no such PV points to, like those of another object with the same
+// StorageClass, and other entries, like the targets of bind mounts, are left in place.
+func RemoveSymlinksOfPVs(symlinkDir, hostname string, pvs []corev1.PersistentVolume) ([]string, error) {
+	removed := []string{}
+	for _, pv := range pvs {
+		if pv.Labels[corev1.LabelHostname] != hostname || pv.Spec.Local == nil || filepath.Dir(pv.Spec.Local.Path) != filepath.Clean(symlinkDir) {
+			continue
+		}
+		symlinkPath := pv.Spec.Local.Path
+		info, err := os.Lstat(symlinkPath)
+		if os.IsNotExist(err) || (err == nil && info.Mode()&os.ModeSymlink == 0) {
+			continue
+		} else if err != nil {
+			return removed, fmt.Errorf("could not check symlink %q: %w", symlinkPath, err)
+		}
+		err = os.Remove(symlinkPath)
+		if err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("could not remove symlink %q: %w", symlinkPath, err)
+		}
+		removed = append(removed, filepath.Base(symlinkPath))
+	}
+	return removed, nil
 }
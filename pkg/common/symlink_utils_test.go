@@ -0,0 +1,120 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetSymLinkSourceAndTarget(t *testing.T) {
+	defer func() {
+		internal.FilePathGlob = filepath.Glob
+		internal.FilePathEvalSymLinks = filepath.EvalSymlinks
+	}()
+	// sdb has all the links, sdc has a by-path link only
+	links := map[string][]string{
+		internal.DiskByIDDir:   {"/dev/disk/by-id/wwn-0x5000c500a0b1c2d3"},
+		internal.DiskByPathDir: {"/dev/disk/by-path/pci-0000:00:1f.2-ata-2", "/dev/disk/by-path/pci-0000:00:1f.2-ata-3"},
+		internal.DiskByUUIDDir: {"/dev/disk/by-uuid/0b4cbd2b-3b4e-4f4c-9d0a-7c1e6f1f2a3b"},
+	}
+	internal.FilePathGlob = func(pattern string) ([]string, error) {
+		return links[filepath.Dir(pattern)+"/"], nil
+	}
+	internal.FilePathEvalSymLinks = func(path string) (string, error) {
+		if strings.HasSuffix(path, "ata-3") {
+			return "/dev/sdc", nil
+		}
+		return "/dev/sdb", nil
+	}
+	sdb := internal.BlockDevice{Name: "sdb", KName: "sdb"}
+	sdc := internal.BlockDevice{Name: "sdc", KName: "sdc"}
+
+	testcases := []struct {
+		label          string
+		device         internal.BlockDevice
+		preference     []string
+		expectedSource string
+		expectedTarget string
+		expectedLink   bool
+	}{
+		{
+			label:          "by-id by default",
+			device:         sdb,
+			expectedSource: "/dev/disk/by-id/wwn-0x5000c500a0b1c2d3",
+			expectedTarget: "/mnt/local-storage/sc/wwn-0x5000c500a0b1c2d3",
+			expectedLink:   true,
+		},
+		{
+			label:          "the first preferred link",
+			device:         sdb,
+			preference:     []string{SymlinkTargetByUUID, SymlinkTargetByID},
+			expectedSource: "/dev/disk/by-uuid/0b4cbd2b-3b4e-4f4c-9d0a-7c1e6f1f2a3b",
+			expectedTarget: "/mnt/local-storage/sc/0b4cbd2b-3b4e-4f4c-9d0a-7c1e6f1f2a3b",
+			expectedLink:   true,
+		},
+		{
+			label:          "falls back to the next link",
+			device:         sdc,
+			preference:     []string{SymlinkTargetByID, SymlinkTargetByUUID, SymlinkTargetByPath},
+			expectedSource: "/dev/disk/by-path/pci-0000:00:1f.2-ata-3",
+			expectedTarget: "/mnt/local-storage/sc/pci-0000:00:1f.2-ata-3",
+			expectedLink:   true,
+		},
+		{
+			label:          "falls back to the kernel name",
+			device:         sdc,
+			expectedSource: "/dev/sdc",
+			expectedTarget: "/mnt/local-storage/sc/sdc",
+			expectedLink:   false,
+		},
+	}
+	for _, tc := range testcases {
+		source, target, idExists, err := GetSymLinkSourceAndTarget(tc.device, "/mnt/local-storage/sc", tc.preference)
+		assert.NoErrorf(t, err, "[%s]", tc.label)
+		assert.Equalf(t, tc.expectedSource, source, "[%s] source", tc.label)
+		assert.Equalf(t, tc.expectedTarget, target, "[%s] target", tc.label)
+		assert.Equalf(t, tc.expectedLink, idExists, "[%s] link exists", tc.label)
+	}
+
+	_, _, _, err := GetSymLinkSourceAndTarget(sdb, "/mnt/local-storage/sc", []string{"by-label"})
+	assert.Error(t, err, "unknown link type")
+}
+
+func TestRemoveSymlinksOfPVs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "symlinks")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"owned", "other-owner", "other-node"} {
+		assert.NoError(t, os.Symlink("/dev/sdb", filepath.Join(dir, name)))
+	}
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "mount"), 0755))
+	newPV := func(name, hostname string) corev1.PersistentVolume {
+		return corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelHostname: hostname}},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{Local: &corev1.LocalVolumeSource{Path: filepath.Join(dir, name)}},
+			},
+		}
+	}
+	pvs := []corev1.PersistentVolume{
+		newPV("owned", "node-a"),
+		newPV("other-node", "node-b"),
+		// bind mounts are not removed
+		newPV("mount", "node-a"),
+		newPV("missing", "node-a"),
+	}
+
+	removed, err := RemoveSymlinksOfPVs(dir, "node-a", pvs)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"owned"}, removed)
+	entries, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3, "only the symlinks of the PVs of the node are removed")
+}
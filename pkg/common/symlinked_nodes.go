@@ -0,0 +1,60 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SyncSymlinkedNode returns the status.symlinkedNodes of a LocalVolume or LocalVolumeSet with nodeName
+// added when the diskmakers don't manage its PVs, or removed when they do: the symlinks then belong to the PVs.
+// A LocalVolume that bind-mounts devices passes false, the diskmakers remove the bind mounts when it is deleted.
+// changed is false if the list is already up to date.
+func SyncSymlinkedNode(symlinkedNodes []string, nodeName string, managesPersistentVolumes bool) (updated []string, changed bool) {
+	nodes := sets.NewString(symlinkedNodes...)
+	if nodes.Has(nodeName) == !managesPersistentVolumes {
+		return symlinkedNodes, false
+	}
+	if managesPersistentVolumes {
+		nodes.Delete(nodeName)
+	} else {
+		nodes.Insert(nodeName)
+	}
+	return nodes.List(), true
+}
+
+// NodesWithSymlinks returns the symlinkedNodes of a deleted LocalVolume or LocalVolumeSet whose diskmaker
+// still has to remove its symlinks: the nodes that exist and match nodeSelector.
+// The diskmaker doesn't run for the object on the other nodes, their symlinks are left in place.
+func NodesWithSymlinks(c client.Client, symlinkedNodes []string, nodeSelector *corev1.NodeSelector) ([]string, error) {
+	if len(symlinkedNodes) == 0 {
+		return nil, nil
+	}
+	nodes := &corev1.NodeList{}
+	err := c.List(context.TODO(), nodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	effectiveSelector, err := EffectiveNodeSelector(nodeSelector)
+	if err != nil {
+		return nil, err
+	}
+	symlinked := sets.NewString(symlinkedNodes...)
+	remaining := []string{}
+	for i := range nodes.Items {
+		if !symlinked.Has(nodes.Items[i].Name) {
+			continue
+		}
+		matches, err := NodeSelectorMatchesNodeLabels(&nodes.Items[i], effectiveSelector)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			remaining = append(remaining, nodes.Items[i].Name)
+		}
+	}
+	return remaining, nil
+}
@@ -0,0 +1,49 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	crFake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncSymlinkedNode(t *testing.T) {
+	nodes, changed := SyncSymlinkedNode(nil, "node-b", false)
+	assert.True(t, changed)
+	assert.Equal(t, []string{"node-b"}, nodes)
+	nodes, changed = SyncSymlinkedNode([]string{"node-b"}, "node-b", false)
+	assert.False(t, changed, "the node is already listed")
+	assert.Equal(t, []string{"node-b"}, nodes)
+	nodes, changed = SyncSymlinkedNode([]string{"node-a", "node-b"}, "node-b", true)
+	assert.True(t, changed, "the symlinks belong to the PVs once they are managed")
+	assert.Equal(t, []string{"node-a"}, nodes)
+	_, changed = SyncSymlinkedNode(nil, "node-b", true)
+	assert.False(t, changed)
+}
+
+func TestNodesWithSymlinks(t *testing.T) {
+	objects := []runtime.Object{
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"disks": "ssd"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-c", Labels: map[string]string{"disks": "ssd"}}},
+	}
+	fakeClient := crFake.NewFakeClientWithScheme(scheme.Scheme, objects...)
+	nodeSelector := &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{
+		{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "disks", Operator: corev1.NodeSelectorOpIn, Values: []string{"ssd"}}}},
+	}}
+
+	nodes, err := NodesWithSymlinks(fakeClient, nil, nodeSelector)
+	assert.NoError(t, err)
+	assert.Empty(t, nodes)
+	// node-b no longer matches and node-d no longer exists
+	nodes, err = NodesWithSymlinks(fakeClient, []string{"node-a", "node-b", "node-d"}, nodeSelector)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"node-a"}, nodes)
+	nodes, err = NodesWithSymlinks(fakeClient, []string{"node-a", "node-b", "node-d"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"node-a", "node-b"}, nodes)
+}
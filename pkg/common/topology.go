@@ -0,0 +1,56 @@
+package common
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// LabelTopologyZone is the well-known zone label of nodes, not yet part of the vendored k8s.io/api
+const LabelTopologyZone = "topology.kubernetes.io/zone"
+
+// AllowedTopologies returns the allowedTopologies of a StorageClass whose PVs are created on the nodes matching nodeSelector:
+// the zones of these nodes. It returns nil when no node matches or a matching node has no zone label,
+// as restricting the StorageClass would then make some of its PVs unusable.
+func AllowedTopologies(nodes []corev1.Node, nodeSelector *corev1.NodeSelector) ([]corev1.TopologySelectorTerm, error) {
+	zones := sets.NewString()
+	for i := range nodes {
+		matches, err := NodeSelectorMatchesNodeLabels(&nodes[i], nodeSelector)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			continue
+		}
+		zone, found := nodes[i].Labels[LabelTopologyZone]
+		if !found || zone == "" {
+			return nil, nil
+		}
+		zones.Insert(zone)
+	}
+	if zones.Len() == 0 {
+		return nil, nil
+	}
+	return []corev1.TopologySelectorTerm{
+		{
+			MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{
+				{
+					Key:    LabelTopologyZone,
+					Values: zones.List(),
+				},
+			},
+		},
+	}, nil
+}
+
+// NodeLabelsChanged returns a predicate that filters node events which can change the result of AllowedTopologies:
+// nodes being added, removed or relabeled, but not the frequent status updates
+func NodeLabelsChanged() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !equality.Semantic.DeepEqual(e.MetaOld.GetLabels(), e.MetaNew.GetLabels())
+		},
+	}
+}
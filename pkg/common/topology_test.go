@@ -0,0 +1,77 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAllowedTopologies(t *testing.T) {
+	node := func(name, zone string) corev1.Node {
+		labels := map[string]string{"role": "storage"}
+		if zone != "" {
+			labels[LabelTopologyZone] = zone
+		}
+		return corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	zoneA := node("a", "zone-a")
+	zoneB := node("b", "zone-b")
+	zoneA2 := node("a2", "zone-a")
+	noZone := node("c", "")
+	noZone.Labels["role"] = "worker"
+	storageNodes := &corev1.NodeSelector{
+		NodeSelectorTerms: []corev1.NodeSelectorTerm{
+			{
+				MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "role", Operator: corev1.NodeSelectorOpIn, Values: []string{"storage"}},
+				},
+			},
+		},
+	}
+
+	testTable := []struct {
+		label         string
+		nodes         []corev1.Node
+		nodeSelector  *corev1.NodeSelector
+		expectedZones []string
+	}{
+		{
+			label:         "zones of all nodes",
+			nodes:         []corev1.Node{zoneB, zoneA, zoneA2},
+			expectedZones: []string{"zone-a", "zone-b"},
+		},
+		{
+			label:        "a node without zone",
+			nodes:        []corev1.Node{zoneA, noZone},
+			nodeSelector: nil,
+		},
+		{
+			label:         "unmatched node without zone",
+			nodes:         []corev1.Node{zoneA, noZone},
+			nodeSelector:  storageNodes,
+			expectedZones: []string{"zone-a"},
+		},
+		{
+			label:        "no matching nodes",
+			nodes:        []corev1.Node{noZone},
+			nodeSelector: storageNodes,
+		},
+	}
+	for _, tc := range testTable {
+		terms, err := AllowedTopologies(tc.nodes, tc.nodeSelector)
+		assert.NoErrorf(t, err, "[%s] AllowedTopologies", tc.label)
+		if tc.expectedZones == nil {
+			assert.Nilf(t, terms, "[%s] allowedTopologies", tc.label)
+			continue
+		}
+		assert.Equalf(t, []corev1.TopologySelectorTerm{
+			{
+				MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{
+					{Key: LabelTopologyZone, Values: tc.expectedZones},
+				},
+			},
+		}, terms, "[%s] allowedTopologies", tc.label)
+	}
+}
@@ -1,8 +1,14 @@
 package common
 
 import (
+	"context"
+	"fmt"
+	"sort"
+
 	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
@@ -21,6 +27,58 @@ const (
 	PVDeviceNameLabel = "storage.openshift.com/device-name"
 	// PVDeviceIDLabel is the id of the device
 	PVDeviceIDLabel = "storage.openshift.com/device-id"
+	// PVDeviceIdentityAnnotation is the serial number or partition UUID of the device the PV was created for
+	PVDeviceIdentityAnnotation = "storage.openshift.com/device-identity"
+	// PVDeviceByIDAnnotation is the /dev/disk/by-id/ link of the device when the PV was created
+	PVDeviceByIDAnnotation = "local.storage.openshift.io/device-by-id"
+	// PVDeviceByPathAnnotation is the /dev/disk/by-path/ link of the device when the PV was created
+	PVDeviceByPathAnnotation = "local.storage.openshift.io/device-by-path"
+	// PVDeviceAliasAnnotation is the link of the device, like a /dev/disk/by-id/ link, that the symlink of the PV
+	// was created from. The diskmaker of a LocalVolumeSet symlinks the device from the same link again,
+	// so that the path of the PV doesn't change when the device has several links.
+	PVDeviceAliasAnnotation = "local.storage.openshift.io/device-alias"
+	// PVCleanupTimedOutAnnotation is set to the time the cleanup of a released PV exceeded the cleanupTimeout,
+	// the PV is quarantined until it is removed
+	PVCleanupTimedOutAnnotation = "storage.openshift.com/cleanup-timed-out"
+	// PVDuplicateOfAnnotation is set by the diskmaker to the name of the oldest PV of the node that uses the same device,
+	// on the other PVs of the device. The PVs are left in place, only one of them should ever be bound.
+	PVDuplicateOfAnnotation = "storage.openshift.com/duplicate-of"
+	// PVHostnameChangedAnnotation is set by the diskmaker to the new hostname of the node of a bound PV,
+	// when the device of the PV is found on a node whose hostname changed, like after the node was re-imaged.
+	// The node affinity of the PV can't be changed, bound PVs are left in place.
+	PVHostnameChangedAnnotation = "storage.openshift.com/hostname-changed"
+	// PVNodeNotReadyAnnotation is set to the time the node of the PV stopped being ready, while the node is NotReady
+	PVNodeNotReadyAnnotation = "storage.openshift.com/node-not-ready"
+	// PVLastTrimAnnotation is set by the diskmaker to the time it last trimmed the mounted filesystem of the PV,
+	// for LocalVolumes with periodicTrim
+	PVLastTrimAnnotation = "local.storage.openshift.io/last-trim"
+
+	// NodeDeviceIOPSAnnotation holds the provisioned IOPS of the devices of a node, set by the cloud provider integration
+	// or an administrator. Its value is a JSON object of device serial numbers, as outputted by lsblk, to IOPS,
+	// like {"vol0a1b2c3d4e5f60789": 16000}. It is used by deviceInclusionSpec.minIOPS of LocalVolumeSets.
+	NodeDeviceIOPSAnnotation = "local.storage.openshift.io/device-iops"
+	// NodeTotalCapacityAnnotation is set by the operator on the nodes matched by LocalVolumes and LocalVolumeSets
+	// to the total capacity of their local PVs, like 3Ti
+	NodeTotalCapacityAnnotation = "local.storage.openshift.io/total-capacity"
+	// NodeAvailableCapacityAnnotation is set by the operator next to NodeTotalCapacityAnnotation
+	// to the capacity of the local PVs of the node that are Available
+	NodeAvailableCapacityAnnotation = "local.storage.openshift.io/available-capacity"
+
+	// DefaultPVNamePrefix is the prefix of the names of PVs created by the diskmaker
+	DefaultPVNamePrefix = "local-pv-"
+
+	// DeviceIdentityMismatch is the event reason used when a PV's symlink resolves to a different device
+	DeviceIdentityMismatch = "DeviceIdentityMismatch"
+	// CleanupTimedOut is the event reason used when the cleanup of a released PV exceeded the cleanupTimeout
+	CleanupTimedOut = "CleanupTimedOut"
+	// DuplicatePVForDevice is the event reason used when a PV uses the device of an older PV of the node,
+	// and the type of the condition of LocalVolumes and LocalVolumeSets that have such PVs
+	DuplicatePVForDevice = "DuplicatePVForDevice"
+	// PVHostnameChanged is the event reason used when the device of a PV is found on a node with a new hostname,
+	// and the type of the condition of LocalVolumes and LocalVolumeSets that have bound PVs on such devices
+	PVHostnameChanged = "PVHostnameChanged"
+	// TrimFailed is the event reason used when fstrim fails on the filesystem of a PV
+	TrimFailed = "TrimFailed"
 )
 
 // DeprecatedLabels: these labels were deprecated because the potential values weren't all compatible label values
@@ -35,3 +93,38 @@ func GetPVOwnerSelector(lv *localv1.LocalVolume) labels.Selector {
 	}
 	return labels.SelectorFromSet(pvOwnerLabels)
 }
+
+// DuplicatePVs describes the PVs that the diskmaker flagged with PVDuplicateOfAnnotation, sorted by name
+func DuplicatePVs(pvs []corev1.PersistentVolume) []string {
+	duplicates := []string{}
+	for _, pv := range pvs {
+		if original, found := pv.Annotations[PVDuplicateOfAnnotation]; found {
+			duplicates = append(duplicates, fmt.Sprintf("%s (duplicate of %s)", pv.Name, original))
+		}
+	}
+	sort.Strings(duplicates)
+	return duplicates
+}
+
+// HostnameChangedPVs describes the PVs that the diskmaker flagged with PVHostnameChangedAnnotation, sorted by name
+func HostnameChangedPVs(pvs []corev1.PersistentVolume) []string {
+	changed := []string{}
+	for _, pv := range pvs {
+		if hostname, found := pv.Annotations[PVHostnameChangedAnnotation]; found {
+			changed = append(changed, fmt.Sprintf("%s (moved from %s to %s)", pv.Name, pv.Labels[corev1.LabelHostname], hostname))
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// ListOwnedPVs returns the PVs created for the given LocalVolume, selected with GetPVOwnerSelector.
+// PVs are cluster scoped, so c must be able to list PVs across the cluster.
+func ListOwnedPVs(ctx context.Context, c client.Reader, lv *localv1.LocalVolume) (*corev1.PersistentVolumeList, error) {
+	pvs := &corev1.PersistentVolumeList{}
+	err := c.List(ctx, pvs, client.MatchingLabelsSelector{Selector: GetPVOwnerSelector(lv)})
+	if err != nil {
+		return nil, err
+	}
+	return pvs, nil
+}
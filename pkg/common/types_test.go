@@ -0,0 +1,41 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestListOwnedPVs(t *testing.T) {
+	lv := &localv1.LocalVolume{ObjectMeta: metav1.ObjectMeta{Name: "local-disks", Namespace: "local-storage"}}
+	ownedPV := func(name, owner, namespace string) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				LocalVolumeOwnerNameForPV:      owner,
+				LocalVolumeOwnerNamespaceForPV: namespace,
+			},
+		}}
+	}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme,
+		ownedPV("pv-a", "local-disks", "local-storage"),
+		ownedPV("pv-b", "local-disks", "local-storage"),
+		ownedPV("pv-other-lv", "other-disks", "local-storage"),
+		ownedPV("pv-other-namespace", "local-disks", "default"),
+		&corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "pv-unowned"}},
+	)
+
+	pvs, err := ListOwnedPVs(context.TODO(), c, lv)
+	assert.NoError(t, err)
+	names := []string{}
+	for _, pv := range pvs.Items {
+		names = append(names, pv.Name)
+	}
+	assert.ElementsMatch(t, []string{"pv-a", "pv-b"}, names)
+}
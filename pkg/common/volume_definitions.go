@@ -18,6 +18,7 @@ const (
 
 var (
 	hostContainerPropagation = corev1.MountPropagationHostToContainer
+	bidirectionalPropagation = corev1.MountPropagationBidirectional
 	directoryHostPath        = corev1.HostPathDirectory
 
 	// SymlinkHostDirVolume is the corev1.Volume definition for the lso symlink host directory.
@@ -38,6 +39,14 @@ var (
 		MountPropagation: &hostContainerPropagation,
 	}
 
+	// SymlinkBidirectionalMount is used instead of SymlinkMount when the diskmaker bind-mounts devices
+	// into the symlink directory, so that the mounts propagate to the host
+	SymlinkBidirectionalMount = corev1.VolumeMount{
+		Name:             "local-disks",
+		MountPath:        GetLocalDiskLocationPath(),
+		MountPropagation: &bidirectionalPropagation,
+	}
+
 	// DevHostDirVolume  is the corev1.Volume definition for the "/dev" bind mount used to
 	// list block devices.
 	// DevMount is the corresponding mount
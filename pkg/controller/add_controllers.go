@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"github.com/openshift/local-storage-operator/pkg/common"
 	localv1 "github.com/openshift/local-storage-operator/pkg/controller/localvolume"
 	"github.com/openshift/local-storage-operator/pkg/controller/localvolumediscovery"
 	"github.com/openshift/local-storage-operator/pkg/controller/localvolumeset"
@@ -9,17 +10,18 @@ import (
 )
 
 // AddToManagerFuncs is a list of functions to add all Controllers to the Manager
-var AddToManagerFuncs = []func(manager.Manager) error{
+var AddToManagerFuncs = []func(manager.Manager, common.Options) error{
 	localv1.Add,
 	localvolumeset.AddLocalVolumeSetReconciler,
 	nodedaemon.AddDaemonReconciler,
 	localvolumediscovery.Add,
+	localvolumediscovery.AddResultExporter,
 }
 
 // AddToManager adds all Controllers to the Manager
-func AddToManager(m manager.Manager) error {
+func AddToManager(m manager.Manager, options common.Options) error {
 	for _, f := range AddToManagerFuncs {
-		if err := f(m); err != nil {
+		if err := f(m, options); err != nil {
 			return err
 		}
 	}
@@ -1,12 +1,16 @@
 package localvolume
 
 import (
+	"context"
+
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
 
 	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
 	"github.com/openshift/local-storage-operator/pkg/common"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -22,6 +26,14 @@ const (
 	ownerNamespaceLabel = "local.storage.openshift.io/owner-namespace"
 	ownerNameLabel      = "local.storage.openshift.io/owner-name"
 
+	defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+	// setAsDefaultAnnotation marks the StorageClasses the operator made the default because of setAsDefault,
+	// their default annotation is removed when setAsDefault is turned off. A default set by an admin is kept.
+	setAsDefaultAnnotation = "local.storage.openshift.io/set-as-default"
+	// deprecatedStorageClassAnnotation marks the StorageClasses of a LocalVolume with the DrainThenDelete deletionPolicy
+	// while it is deleted, the value says why
+	deprecatedStorageClassAnnotation = "local.storage.openshift.io/deprecated"
+
 	localVolumeFinalizer = "storage.openshift.com/local-volume-protection"
 )
 
@@ -33,16 +45,18 @@ type ReconcileLocalVolume struct {
 	apiClient         apiUpdater
 	lvMap             *common.StorageClassOwnerMap
 	controllerVersion string
+	options           common.Options
 }
 
 // Add creates a LocalVolume Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
+func Add(mgr manager.Manager, options common.Options) error {
 
 	r := &ReconcileLocalVolume{
 		client:    mgr.GetClient(),
 		apiClient: newAPIUpdater(mgr),
 		lvMap:     &common.StorageClassOwnerMap{},
+		options:   options,
 	}
 
 	// create a new controller
@@ -60,7 +74,50 @@ func Add(mgr manager.Manager) error {
 		return err
 	}
 
-	//  watch for storageclass, enqueue owner
+	enqueueAllLocalVolumes := &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			lvs := &localv1.LocalVolumeList{}
+			err := r.client.List(context.TODO(), lvs)
+			if err != nil {
+				klog.Errorf("failed to list LocalVolumes: %v", err)
+				return []reconcile.Request{}
+			}
+			reqs := make([]reconcile.Request, 0, len(lvs.Items))
+			for _, lv := range lvs.Items {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: lv.Name, Namespace: lv.Namespace}})
+			}
+			return reqs
+		}),
+	}
+
+	// watch nodes and enqueue all LocalVolumes, the zones of the matching nodes are the allowedTopologies of their storageclasses
+	err = c.Watch(&source.Kind{Type: &corev1.Node{}}, enqueueAllLocalVolumes, common.NodeLabelsChanged())
+	if err != nil {
+		return err
+	}
+
+	// enqueue all LocalVolumes when the reconcile endpoint is hit
+	err = c.Watch(&source.Channel{Source: common.SubscribeReconcileAll()}, enqueueAllLocalVolumes)
+	if err != nil {
+		return err
+	}
+
+	// watch storageclasses by name: a deleted StorageClass managed by the operator is recreated,
+	// and the StorageClasses that are not managed by the operator must exist
+	err = c.Watch(&source.Kind{Type: &storagev1.StorageClass{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			names := r.lvMap.GetStorageClassOwners(obj.Meta.GetName())
+			reqs := make([]reconcile.Request, 0)
+			for _, name := range names {
+				reqs = append(reqs, reconcile.Request{NamespacedName: name})
+			}
+			return reqs
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
 	err = c.Watch(&source.Kind{Type: &corev1.PersistentVolume{}}, &handler.EnqueueRequestsFromMapFunc{
 		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
 			pv, ok := obj.Object.(*corev1.PersistentVolume)
@@ -38,7 +38,7 @@ type apiUpdater interface {
 	applyClusterRoleBinding(roleBinding *rbacv1.ClusterRoleBinding) (*rbacv1.ClusterRoleBinding, bool, error)
 	applyRole(role *rbacv1.Role) (*rbacv1.Role, bool, error)
 	applyRoleBinding(roleBinding *rbacv1.RoleBinding) (*rbacv1.RoleBinding, bool, error)
-	applyStorageClass(required *storagev1.StorageClass) (*storagev1.StorageClass, bool, error)
+	applyStorageClass(required *storagev1.StorageClass, keepDefault bool) (*storagev1.StorageClass, bool, error)
 	applyDaemonSet(ds *appsv1.DaemonSet, expectedGeneration int64, forceRollout bool) (*appsv1.DaemonSet, bool, error)
 	getDaemonSet(namespace, dsName string) (*appsv1.DaemonSet, error)
 	listStorageClasses(listOptions metav1.ListOptions) (*storagev1.StorageClassList, error)
@@ -121,8 +121,8 @@ func (s *sdkAPIUpdater) applyClusterRoleBinding(roleBinding *rbacv1.ClusterRoleB
 	return resourceapply.ApplyClusterRoleBinding(s.clientset.RbacV1(), events.NewInMemoryRecorder(componentName), roleBinding)
 }
 
-func (s *sdkAPIUpdater) applyStorageClass(sc *storagev1.StorageClass) (*storagev1.StorageClass, bool, error) {
-	return applyStorageClass(s.clientset.StorageV1(), sc)
+func (s *sdkAPIUpdater) applyStorageClass(sc *storagev1.StorageClass, keepDefault bool) (*storagev1.StorageClass, bool, error) {
+	return applyStorageClass(s.clientset.StorageV1(), sc, keepDefault)
 }
 
 func (s *sdkAPIUpdater) applyDaemonSet(ds *appsv1.DaemonSet, expectedGeneration int64, forceRollout bool) (*appsv1.DaemonSet, bool, error) {
@@ -5,4 +5,9 @@ const (
 	listingPersistentVolumesFailed = "ListingPersistentVolumeFailed"
 	deletingStorageClassFailed     = "DeletingStorageClassFailed"
 	localVolumeDeletionFailed      = "LocalVolumeDeletionFailed"
+	multipleDefaultStorageClasses  = "MultipleDefaultStorageClasses"
+	defaultStorageClassDisallowed  = "DefaultStorageClassDisallowed"
+	deprecatingStorageClassFailed  = "DeprecatingStorageClassFailed"
+	drainingPersistentVolumes      = "DrainingPersistentVolumes"
+	removingSymlinks               = "RemovingSymlinks"
 )
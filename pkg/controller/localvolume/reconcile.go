@@ -3,6 +3,7 @@ package localvolume
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
@@ -11,9 +12,11 @@ import (
 	"k8s.io/klog"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
 	commontypes "github.com/openshift/local-storage-operator/pkg/common"
 	"github.com/openshift/local-storage-operator/pkg/controller/nodedaemon"
+	"github.com/openshift/local-storage-operator/version"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
@@ -31,6 +34,7 @@ func (r *ReconcileLocalVolume) deregisterLVFromStorageClass(lv localv1.LocalVolu
 }
 
 func (r *ReconcileLocalVolume) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	defer commontypes.ObserveReconcileDuration("localvolume-controller")()
 	klog.Info("Reconciling LocalVolume")
 	localStorageProvider := &localv1.LocalVolume{}
 
@@ -49,8 +53,8 @@ func (r *ReconcileLocalVolume) Reconcile(request reconcile.Request) (reconcile.R
 		r.lvMap.RegisterStorageClassOwner(storageClassDeviceSet.StorageClassName, request.NamespacedName)
 	}
 
-	r.syncLocalVolumeProvider(localStorageProvider)
-	return reconcile.Result{}, nil
+	err = r.syncLocalVolumeProvider(localStorageProvider)
+	return commontypes.ReconcileErrorResult(reconcile.Result{}, err)
 }
 
 func (r *ReconcileLocalVolume) syncLocalVolumeProvider(instance *localv1.LocalVolume) error {
@@ -65,6 +69,11 @@ func (r *ReconcileLocalVolume) syncLocalVolumeProvider(instance *localv1.LocalVo
 		return r.cleanupLocalVolumeDeployment(o)
 	}
 
+	if commontypes.IsPaused(o) {
+		klog.Infof("reconciliation of LocalVolume %s is paused", commontypes.LocalVolumeKey(o))
+		return nil
+	}
+
 	// Lets add a finalizer to the LocalVolume object first
 	o, modified := addFinalizer(o)
 	if modified {
@@ -107,10 +116,39 @@ func (r *ReconcileLocalVolume) syncLocalVolumeProvider(instance *localv1.LocalVo
 		})
 	}
 
+	provisioned, err := r.provisionedPVCount(o)
+	if err != nil {
+		klog.Errorf("failed to count provisioned persistentvolumes: %v", err)
+		return r.addFailureCondition(instance, o, err)
+	}
+
 	o.Status.Generations = children
 	o.Status.State = operatorv1.Managed
-	o = r.addSuccessCondition(o)
+	if minimum := o.GetMinimumProvisionedCount(); provisioned < minimum && !r.options.DiscoveryOnly && o.ManagesPersistentVolumes() {
+		o = addProvisioningCondition(o, fmt.Sprintf("%d of the minimum %d persistentvolumes are provisioned", provisioned, minimum))
+	} else {
+		o = r.addSuccessCondition(o)
+	}
+	err = r.setDuplicatePVCondition(o)
+	if err != nil {
+		klog.Errorf("failed to look for duplicate persistentvolumes: %v", err)
+		return r.addFailureCondition(instance, o, err)
+	}
+	err = r.setHostnameChangedPVCondition(o)
+	if err != nil {
+		klog.Errorf("failed to look for persistentvolumes whose device moved to a new hostname: %v", err)
+		return r.addFailureCondition(instance, o, err)
+	}
+	err = r.setStorageClassProvisionerCondition(o)
+	if err != nil {
+		klog.Errorf("failed to check the provisioner of the storageclasses: %v", err)
+		return r.addFailureCondition(instance, o, err)
+	}
+	setDegradedCondition(o, nil)
 	o.Status.ObservedGeneration = &o.Generation
+	o.Status.ObservedOperatorVersion = version.Version
+	o.Status.ObservedProvisionerVersion = commontypes.GetProvisionerVersion()
+	o.Status.ObservedRescan = commontypes.GetRescan(o)
 	err = r.apiClient.syncStatus(instance, o)
 	if err != nil {
 		klog.Errorf("error syncing status: %v", err)
@@ -127,8 +165,8 @@ func (r *ReconcileLocalVolume) addFailureCondition(oldLv *localv1.LocalVolume, l
 		Message:            message,
 		LastTransitionTime: metav1.Now(),
 	}
-	newConditions := []operatorv1.OperatorCondition{condition}
-	lv.Status.Conditions = newConditions
+	setAvailableCondition(lv, condition)
+	setDegradedCondition(lv, err)
 	syncErr := r.apiClient.syncStatus(oldLv, lv)
 	if syncErr != nil {
 		klog.Errorf("error syncing condition: %v", syncErr)
@@ -136,6 +174,35 @@ func (r *ReconcileLocalVolume) addFailureCondition(oldLv *localv1.LocalVolume, l
 	return err
 }
 
+// addProvisioningCondition marks the LocalVolume as not Available until enough PVs are provisioned.
+// The PV watch reconciles the LocalVolume again as its PVs are created.
+func addProvisioningCondition(lv *localv1.LocalVolume, message string) *localv1.LocalVolume {
+	for _, c := range lv.Status.Conditions {
+		// keep the transition time while the count is unchanged
+		if c.Type == operatorv1.OperatorStatusTypeAvailable &&
+			c.Status == operatorv1.ConditionFalse &&
+			c.Message == message {
+			return lv
+		}
+	}
+	setAvailableCondition(lv, operatorv1.OperatorCondition{
+		Type:               operatorv1.OperatorStatusTypeAvailable,
+		Status:             operatorv1.ConditionFalse,
+		Message:            message,
+		LastTransitionTime: metav1.Now(),
+	})
+	return lv
+}
+
+// provisionedPVCount returns the number of PVs that exist for the LocalVolume
+func (r *ReconcileLocalVolume) provisionedPVCount(lv *localv1.LocalVolume) (int32, error) {
+	pvs, err := commontypes.ListOwnedPVs(context.TODO(), r.client, lv)
+	if err != nil {
+		return 0, fmt.Errorf("error listing persistentvolumes for localvolume %s: %v", commontypes.LocalVolumeKey(lv), err)
+	}
+	return int32(len(pvs.Items)), nil
+}
+
 func (r *ReconcileLocalVolume) addSuccessCondition(lv *localv1.LocalVolume) *localv1.LocalVolume {
 	condition := operatorv1.OperatorCondition{
 		Type:               operatorv1.OperatorStatusTypeAvailable,
@@ -143,7 +210,6 @@ func (r *ReconcileLocalVolume) addSuccessCondition(lv *localv1.LocalVolume) *loc
 		Message:            "Ready",
 		LastTransitionTime: metav1.Now(),
 	}
-	newConditions := []operatorv1.OperatorCondition{condition}
 	oldConditions := lv.Status.Conditions
 	for _, c := range oldConditions {
 		// if operator already has success condition - don't add again
@@ -153,10 +219,99 @@ func (r *ReconcileLocalVolume) addSuccessCondition(lv *localv1.LocalVolume) *loc
 			return lv
 		}
 	}
-	lv.Status.Conditions = newConditions
+	setAvailableCondition(lv, condition)
 	return lv
 }
 
+// setAvailableCondition replaces the Available condition of the LocalVolume and keeps its other conditions.
+// The Available condition is kept first.
+func setAvailableCondition(lv *localv1.LocalVolume, condition operatorv1.OperatorCondition) {
+	conditions := []operatorv1.OperatorCondition{condition}
+	for _, c := range lv.Status.Conditions {
+		if c.Type != operatorv1.OperatorStatusTypeAvailable {
+			conditions = append(conditions, c)
+		}
+	}
+	lv.Status.Conditions = conditions
+}
+
+// setDegradedCondition sets the Degraded condition of the LocalVolume when its reconcile failed with a fatal API error,
+// which is not retried until the LocalVolume changes, and removes it otherwise
+func setDegradedCondition(lv *localv1.LocalVolume, err error) {
+	if err == nil || !commontypes.IsFatalAPIError(err) {
+		v1helpers.RemoveOperatorCondition(&lv.Status.Conditions, operatorv1.OperatorStatusTypeDegraded)
+		return
+	}
+	v1helpers.SetOperatorCondition(&lv.Status.Conditions, operatorv1.OperatorCondition{
+		Type:    operatorv1.OperatorStatusTypeDegraded,
+		Status:  operatorv1.ConditionTrue,
+		Message: fmt.Sprintf("error syncing local storage, not retried until the LocalVolume changes: %+v", err),
+	})
+}
+
+// setStorageClassProvisionerCondition reports the StorageClasses of the LocalVolume that don't use the local provisioner:
+// those that the operator doesn't manage
+func (r *ReconcileLocalVolume) setStorageClassProvisionerCondition(lv *localv1.LocalVolume) error {
+	names := []string{}
+	for _, storageClassDevice := range lv.Spec.StorageClassDevices {
+		names = append(names, storageClassDevice.StorageClassName)
+	}
+	mismatched, err := commontypes.MismatchedStorageClasses(r.client, names)
+	if err != nil {
+		return err
+	}
+	condition := operatorv1.OperatorCondition{
+		Type:    commontypes.StorageClassProvisionerMismatch,
+		Status:  operatorv1.ConditionFalse,
+		Message: commontypes.StorageClassProvisionerMismatchMessage(mismatched),
+	}
+	if len(mismatched) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+	}
+	v1helpers.SetOperatorCondition(&lv.Status.Conditions, condition)
+	return nil
+}
+
+// setDuplicatePVCondition reports the PVs of the LocalVolume that the diskmakers flagged because they use
+// the device of an older PV of their node
+func (r *ReconcileLocalVolume) setDuplicatePVCondition(lv *localv1.LocalVolume) error {
+	pvs, err := commontypes.ListOwnedPVs(context.TODO(), r.client, lv)
+	if err != nil {
+		return fmt.Errorf("error listing persistentvolumes for localvolume %s: %v", commontypes.LocalVolumeKey(lv), err)
+	}
+	condition := operatorv1.OperatorCondition{
+		Type:    commontypes.DuplicatePVForDevice,
+		Status:  operatorv1.ConditionFalse,
+		Message: "No persistentvolume shares its device",
+	}
+	if duplicates := commontypes.DuplicatePVs(pvs.Items); len(duplicates) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Message = fmt.Sprintf("Persistentvolumes use the device of an older persistentvolume of their node: %s", strings.Join(duplicates, ", "))
+	}
+	v1helpers.SetOperatorCondition(&lv.Status.Conditions, condition)
+	return nil
+}
+
+// setHostnameChangedPVCondition reports the bound PVs of the LocalVolume that the diskmakers flagged because
+// their device is now on a node with another hostname
+func (r *ReconcileLocalVolume) setHostnameChangedPVCondition(lv *localv1.LocalVolume) error {
+	pvs, err := commontypes.ListOwnedPVs(context.TODO(), r.client, lv)
+	if err != nil {
+		return fmt.Errorf("error listing persistentvolumes for localvolume %s: %v", commontypes.LocalVolumeKey(lv), err)
+	}
+	condition := operatorv1.OperatorCondition{
+		Type:    commontypes.PVHostnameChanged,
+		Status:  operatorv1.ConditionFalse,
+		Message: "No persistentvolume has a device that moved to a new hostname",
+	}
+	if changed := commontypes.HostnameChangedPVs(pvs.Items); len(changed) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Message = fmt.Sprintf("Bound persistentvolumes have a device on a node with a new hostname and keep their node affinity: %s", strings.Join(changed, ", "))
+	}
+	v1helpers.SetOperatorCondition(&lv.Status.Conditions, condition)
+	return nil
+}
+
 func (r *ReconcileLocalVolume) cleanupLocalVolumeDeployment(lv *localv1.LocalVolume) error {
 	klog.Infof("Deleting localvolume: %s", commontypes.LocalVolumeKey(lv))
 	childPersistentVolumes, err := r.apiClient.listPersistentVolumes(metav1.ListOptions{LabelSelector: commontypes.GetPVOwnerSelector(lv).String()})
@@ -166,9 +321,21 @@ func (r *ReconcileLocalVolume) cleanupLocalVolumeDeployment(lv *localv1.LocalVol
 		return fmt.Errorf(msg)
 	}
 	boundPVs := []corev1.PersistentVolume{}
+	availablePVs := []corev1.PersistentVolume{}
 	for _, pv := range childPersistentVolumes.Items {
-		if pv.Status.Phase == corev1.VolumeBound {
+		switch pv.Status.Phase {
+		case corev1.VolumeBound:
 			boundPVs = append(boundPVs, pv)
+		case corev1.VolumeAvailable, corev1.VolumePending:
+			availablePVs = append(availablePVs, pv)
+		}
+	}
+	if len(boundPVs) > 0 && lv.Spec.DeletionPolicy == localv1.DeletionPolicyDrainThenDelete {
+		err = r.deprecateStorageClasses(lv)
+		if err != nil {
+			msg := fmt.Sprintf("error deprecating storageclasses of localvolume %s: %v", commontypes.LocalVolumeKey(lv), err)
+			r.apiClient.recordEvent(lv, corev1.EventTypeWarning, deprecatingStorageClassFailed, msg)
+			return fmt.Errorf(msg)
 		}
 	}
 	if len(boundPVs) > 0 {
@@ -177,6 +344,15 @@ func (r *ReconcileLocalVolume) cleanupLocalVolumeDeployment(lv *localv1.LocalVol
 		return fmt.Errorf(msg)
 	}
 
+	// the diskmaker doesn't provision for deleted localvolumes, so the unbound PVs are not recreated.
+	// Released PVs are left to the deleter, which wipes their devices before deleting them.
+	err = r.removeUnboundPersistentVolumes(availablePVs)
+	if err != nil {
+		msg := fmt.Sprintf("error deleting unbound persistentvolumes of localvolume %s: %v", commontypes.LocalVolumeKey(lv), err)
+		r.apiClient.recordEvent(lv, corev1.EventTypeWarning, localVolumeDeletionFailed, msg)
+		return fmt.Errorf(msg)
+	}
+
 	err = r.removeUnExpectedStorageClasses(lv, sets.NewString())
 	if err != nil {
 		msg := err.Error()
@@ -184,22 +360,82 @@ func (r *ReconcileLocalVolume) cleanupLocalVolumeDeployment(lv *localv1.LocalVol
 		return err
 	}
 
+	// the diskmakers remove the symlinks of a localvolume that leaves its PVs to another tool
+	nodes, err := commontypes.NodesWithSymlinks(r.client, lv.Status.SymlinkedNodes, lv.Spec.NodeSelector)
+	if err != nil {
+		return err
+	}
+	if len(nodes) > 0 {
+		msg := fmt.Sprintf("localvolume %s waits for its symlinks to be removed from nodes %s", commontypes.LocalVolumeKey(lv), strings.Join(nodes, ", "))
+		r.apiClient.recordEvent(lv, corev1.EventTypeNormal, removingSymlinks, msg)
+		return fmt.Errorf(msg)
+	}
+
 	lv = removeFinalizer(lv)
 	return r.apiClient.updateLocalVolume(lv)
 }
 
 func (r *ReconcileLocalVolume) syncStorageClass(cr *localv1.LocalVolume) error {
 	storageClassDevices := cr.Spec.StorageClassDevices
+	nodes := &corev1.NodeList{}
+	err := r.client.List(context.TODO(), nodes)
+	if err != nil {
+		return fmt.Errorf("error listing nodes: %v", err)
+	}
+	nodeSelector, err := commontypes.EffectiveNodeSelector(cr.Spec.NodeSelector)
+	if err != nil {
+		return err
+	}
+	allowedTopologies, err := commontypes.AllowedTopologies(nodes.Items, nodeSelector)
+	if err != nil {
+		return fmt.Errorf("error computing allowedTopologies: %v", err)
+	}
+	defaultStorageClass := ""
+	for _, storageClassDevice := range storageClassDevices {
+		err = storageClassDevice.ValidateDisableLazyInit()
+		if err != nil {
+			return err
+		}
+		if !storageClassDevice.SetAsDefault {
+			continue
+		}
+		if r.options.DisallowDefaultStorageClass {
+			msg := fmt.Sprintf("ignoring setAsDefault of storageClass %s, the operator runs with --%s", storageClassDevice.StorageClassName, commontypes.DisallowDefaultStorageClassFlag)
+			klog.Warning(msg)
+			r.apiClient.recordEvent(cr, corev1.EventTypeWarning, defaultStorageClassDisallowed, msg)
+			continue
+		}
+		if !storageClassDevice.ManagesStorageClass() {
+			return fmt.Errorf("storageClass %s sets setAsDefault but not manageStorageClass, the operator can't change a StorageClass it doesn't manage", storageClassDevice.StorageClassName)
+		}
+		if defaultStorageClass != "" && defaultStorageClass != storageClassDevice.StorageClassName {
+			return fmt.Errorf("storageClasses %s and %s both set setAsDefault, only one StorageClass can be the default", defaultStorageClass, storageClassDevice.StorageClassName)
+		}
+		defaultStorageClass = storageClassDevice.StorageClassName
+	}
 	expectedStorageClasses := sets.NewString()
 	for _, storageClassDevice := range storageClassDevices {
 		storageClassName := storageClassDevice.StorageClassName
+		if !storageClassDevice.ManagesStorageClass() {
+			err = r.checkUnmanagedStorageClass(cr, storageClassName)
+			if err != nil {
+				return err
+			}
+			continue
+		}
 		expectedStorageClasses.Insert(storageClassName)
-		storageClass := generateStorageClass(cr, storageClassName)
-		_, _, err := r.apiClient.applyStorageClass(storageClass)
+		storageClass := generateStorageClass(cr, storageClassName, allowedTopologies, storageClassName == defaultStorageClass)
+		_, _, err := r.apiClient.applyStorageClass(storageClass, r.options.DisallowDefaultStorageClass)
 		if err != nil {
 			return fmt.Errorf("error creating storageClass %s: %v", storageClassName, err)
 		}
 	}
+	if defaultStorageClass != "" {
+		err = r.unsetOtherDefaultStorageClasses(cr, defaultStorageClass)
+		if err != nil {
+			return err
+		}
+	}
 	removeErrors := r.removeUnExpectedStorageClasses(cr, expectedStorageClasses)
 	// For now we will ignore errors while removing unexpected storageClasses
 	if removeErrors != nil {
@@ -208,6 +444,99 @@ func (r *ReconcileLocalVolume) syncStorageClass(cr *localv1.LocalVolume) error {
 	return nil
 }
 
+// checkUnmanagedStorageClass checks that a StorageClass not managed by the operator exists.
+// A StorageClass created by the operator before it stopped managing it is released:
+// its owner labels are removed, so that it is neither deleted nor changed by the operator anymore.
+func (r *ReconcileLocalVolume) checkUnmanagedStorageClass(cr *localv1.LocalVolume, storageClassName string) error {
+	sc := &storagev1.StorageClass{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: storageClassName}, sc)
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("storageClass %s does not exist, it must be created when manageStorageClass is false", storageClassName)
+	} else if err != nil {
+		return fmt.Errorf("error getting storageClass %s: %v", storageClassName, err)
+	}
+	if !getOwnerLabelSelector(cr).Matches(labels.Set(sc.Labels)) {
+		return nil
+	}
+	klog.Infof("releasing storageClass %s, it is not managed anymore", storageClassName)
+	delete(sc.Labels, ownerNamespaceLabel)
+	delete(sc.Labels, ownerNameLabel)
+	err = r.client.Update(context.TODO(), sc)
+	if err != nil {
+		return fmt.Errorf("error releasing storageClass %s: %v", storageClassName, err)
+	}
+	return nil
+}
+
+// unsetOtherDefaultStorageClasses removes the default annotation from the StorageClasses of the LocalVolume
+// other than defaultStorageClass, so that the cluster doesn't end up with two defaults.
+// The default annotation of StorageClasses owned by anything else is never removed, they are only reported.
+func (r *ReconcileLocalVolume) unsetOtherDefaultStorageClasses(cr *localv1.LocalVolume, defaultStorageClass string) error {
+	list, err := r.apiClient.listStorageClasses(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing storageclasses: %v", err)
+	}
+	ownerSelector := getOwnerLabelSelector(cr)
+	for i := range list.Items {
+		sc := &list.Items[i]
+		if sc.Name == defaultStorageClass || sc.Annotations[defaultStorageClassAnnotation] != "true" {
+			continue
+		}
+		if !ownerSelector.Matches(labels.Set(sc.Labels)) {
+			msg := fmt.Sprintf("storageClass %s is also a default StorageClass, it is not owned by this LocalVolume and won't be changed", sc.Name)
+			klog.Warning(msg)
+			r.apiClient.recordEvent(cr, corev1.EventTypeWarning, multipleDefaultStorageClasses, msg)
+			continue
+		}
+		klog.Infof("unsetting default annotation of storageClass %s", sc.Name)
+		delete(sc.Annotations, defaultStorageClassAnnotation)
+		delete(sc.Annotations, setAsDefaultAnnotation)
+		err = r.client.Update(context.TODO(), sc)
+		if err != nil {
+			return fmt.Errorf("error unsetting default annotation of storageClass %s: %v", sc.Name, err)
+		}
+	}
+	return nil
+}
+
+// deprecateStorageClasses unsets the default annotation of the StorageClasses of a deleted LocalVolume
+// and marks them deprecated, to discourage new PVCs while its bound PVs are released.
+// StorageClasses the operator doesn't manage are left as they are, and so is the default annotation
+// when the operator must not change it.
+func (r *ReconcileLocalVolume) deprecateStorageClasses(lv *localv1.LocalVolume) error {
+	list, err := r.apiClient.listStorageClasses(metav1.ListOptions{LabelSelector: getOwnerLabelSelector(lv).String()})
+	if err != nil {
+		return fmt.Errorf("error listing storageclasses for CR %s: %v", lv.Name, err)
+	}
+	deprecated := []string{}
+	for i := range list.Items {
+		sc := &list.Items[i]
+		_, found := sc.Annotations[deprecatedStorageClassAnnotation]
+		if found && (sc.Annotations[defaultStorageClassAnnotation] != "true" || r.options.DisallowDefaultStorageClass) {
+			continue
+		}
+		klog.Infof("deprecating storageClass %s until the persistentvolumes of localvolume %s are released", sc.Name, commontypes.LocalVolumeKey(lv))
+		if sc.Annotations == nil {
+			sc.Annotations = map[string]string{}
+		}
+		if !r.options.DisallowDefaultStorageClass {
+			delete(sc.Annotations, defaultStorageClassAnnotation)
+			delete(sc.Annotations, setAsDefaultAnnotation)
+		}
+		sc.Annotations[deprecatedStorageClassAnnotation] = fmt.Sprintf("LocalVolume %s is deleted once its persistentvolumes are released", commontypes.LocalVolumeKey(lv))
+		err = r.client.Update(context.TODO(), sc)
+		if err != nil {
+			return fmt.Errorf("error deprecating storageClass %s: %v", sc.Name, err)
+		}
+		deprecated = append(deprecated, sc.Name)
+	}
+	if len(deprecated) > 0 {
+		msg := fmt.Sprintf("storageClasses %s are deprecated until the bound persistentvolumes are released", strings.Join(deprecated, ", "))
+		r.apiClient.recordEvent(lv, corev1.EventTypeNormal, drainingPersistentVolumes, msg)
+	}
+	return nil
+}
+
 func (r *ReconcileLocalVolume) removeUnExpectedStorageClasses(cr *localv1.LocalVolume, expectedStorageClasses sets.String) error {
 	list, err := r.apiClient.listStorageClasses(metav1.ListOptions{LabelSelector: getOwnerLabelSelector(cr).String()})
 	if err != nil {
@@ -226,6 +555,18 @@ func (r *ReconcileLocalVolume) removeUnExpectedStorageClasses(cr *localv1.LocalV
 	return utilerrors.NewAggregate(removeErrors)
 }
 
+func (r *ReconcileLocalVolume) removeUnboundPersistentVolumes(pvs []corev1.PersistentVolume) error {
+	removeErrors := []error{}
+	for i := range pvs {
+		klog.Infof("removing unbound persistentvolume %s", pvs[i].Name)
+		err := r.client.Delete(context.TODO(), &pvs[i])
+		if err != nil && !errors.IsNotFound(err) {
+			removeErrors = append(removeErrors, fmt.Errorf("error deleting persistentvolume %s: %v", pvs[i].Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(removeErrors)
+}
+
 func addFinalizer(lv *localv1.LocalVolume) (*localv1.LocalVolume, bool) {
 	currentFinalizers := lv.GetFinalizers()
 	if contains(currentFinalizers, localVolumeFinalizer) {
@@ -276,7 +617,7 @@ func addOwnerLabels(meta *metav1.ObjectMeta, cr *localv1.LocalVolume) bool {
 	return changed
 }
 
-func generateStorageClass(cr *localv1.LocalVolume, scName string) *storagev1.StorageClass {
+func generateStorageClass(cr *localv1.LocalVolume, scName string, allowedTopologies []corev1.TopologySelectorTerm, setAsDefault bool) *storagev1.StorageClass {
 	deleteReclaimPolicy := corev1.PersistentVolumeReclaimDelete
 	firstConsumerBinding := storagev1.VolumeBindingWaitForFirstConsumer
 	sc := &storagev1.StorageClass{
@@ -287,9 +628,13 @@ func generateStorageClass(cr *localv1.LocalVolume, scName string) *storagev1.Sto
 		ObjectMeta: metav1.ObjectMeta{
 			Name: scName,
 		},
-		Provisioner:       "kubernetes.io/no-provisioner",
+		Provisioner:       commontypes.NoProvisioner,
 		ReclaimPolicy:     &deleteReclaimPolicy,
 		VolumeBindingMode: &firstConsumerBinding,
+		AllowedTopologies: allowedTopologies,
+	}
+	if setAsDefault {
+		sc.Annotations = map[string]string{defaultStorageClassAnnotation: "true", setAsDefaultAnnotation: "true"}
 	}
 	addOwnerLabels(&sc.ObjectMeta, cr)
 	return sc
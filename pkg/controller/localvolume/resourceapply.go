@@ -7,10 +7,11 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	storageclientv1 "k8s.io/client-go/kubernetes/typed/storage/v1"
+	"k8s.io/klog"
 )
 
-// ApplyStorageclass
-func applyStorageClass(client storageclientv1.StorageClassesGetter, required *storagev1.StorageClass) (*storagev1.StorageClass, bool, error) {
+// ApplyStorageclass, keepDefault keeps the default annotation of existing StorageClasses as it is
+func applyStorageClass(client storageclientv1.StorageClassesGetter, required *storagev1.StorageClass, keepDefault bool) (*storagev1.StorageClass, bool, error) {
 	existing, err := client.StorageClasses().Get(required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		actual, err := client.StorageClasses().Create(required)
@@ -20,7 +21,32 @@ func applyStorageClass(client storageclientv1.StorageClassesGetter, required *st
 		return nil, false, err
 	}
 
-	changed := false
+	// the provisioner is immutable, a StorageClass owned by the LocalVolume is recreated and its PVs keep their
+	// storageClassName. StorageClasses owned by anything else are left alone, the StorageClassProvisionerMismatch
+	// condition of the LocalVolume reports them.
+	if existing.Provisioner != required.Provisioner {
+		if !isOwnedBy(existing, required) {
+			klog.Warningf("storageClass %s uses provisioner %s and is not owned by the operator, leaving it as it is", existing.Name, existing.Provisioner)
+			return existing, false, nil
+		}
+		recreated := required.DeepCopy()
+		for _, annotation := range []string{defaultStorageClassAnnotation, setAsDefaultAnnotation} {
+			if value, found := existing.Annotations[annotation]; found {
+				if _, set := recreated.Annotations[annotation]; !set {
+					metav1.SetMetaDataAnnotation(&recreated.ObjectMeta, annotation, value)
+				}
+			}
+		}
+		unsetOperatorDefault(&recreated.ObjectMeta, required, keepDefault)
+		err = client.StorageClasses().Delete(existing.Name, &metav1.DeleteOptions{Preconditions: metav1.NewUIDPreconditions(string(existing.UID))})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, false, err
+		}
+		actual, err := client.StorageClasses().Create(recreated)
+		return actual, true, err
+	}
+
+	changed := unsetOperatorDefault(&existing.ObjectMeta, required, keepDefault)
 	resourcemerge.EnsureObjectMeta(&changed, &existing.ObjectMeta, required.ObjectMeta)
 
 	if !equality.Semantic.DeepEqual(required.MountOptions, existing.MountOptions) {
@@ -39,3 +65,30 @@ func applyStorageClass(client storageclientv1.StorageClassesGetter, required *st
 	actual, err := client.StorageClasses().Update(existing)
 	return actual, true, err
 }
+
+// isOwnedBy returns true when existing carries the owner labels of the LocalVolume that generated required
+func isOwnedBy(existing, required *storagev1.StorageClass) bool {
+	for _, label := range []string{ownerNamespaceLabel, ownerNameLabel} {
+		value, found := existing.Labels[label]
+		if !found || value != required.Labels[label] {
+			return false
+		}
+	}
+	return true
+}
+
+// unsetOperatorDefault removes the default annotation from a StorageClass that the operator made the default
+// when required doesn't set it anymore, because setAsDefault was turned off. It returns true when it changed meta.
+// A default set by an admin, without setAsDefaultAnnotation, is kept, and so is any default with keepDefault,
+// when the operator must not change the default annotation.
+func unsetOperatorDefault(meta *metav1.ObjectMeta, required *storagev1.StorageClass, keepDefault bool) bool {
+	if _, set := meta.Annotations[setAsDefaultAnnotation]; !set || keepDefault {
+		return false
+	}
+	if _, stillSet := required.Annotations[setAsDefaultAnnotation]; stillSet {
+		return false
+	}
+	delete(meta.Annotations, defaultStorageClassAnnotation)
+	delete(meta.Annotations, setAsDefaultAnnotation)
+	return true
+}
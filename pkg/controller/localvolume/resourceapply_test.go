@@ -0,0 +1,127 @@
+package localvolume
+
+import (
+	"testing"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	"github.com/stretchr/testify/assert"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	storageclientv1 "k8s.io/client-go/kubernetes/typed/storage/v1"
+)
+
+// fakeStorageClasses keeps StorageClasses in a map, it implements the calls applyStorageClass makes
+type fakeStorageClasses struct {
+	storageclientv1.StorageClassInterface
+	storageClasses map[string]*storagev1.StorageClass
+	deleted        []string
+}
+
+func newFakeStorageClasses(objs ...*storagev1.StorageClass) *fakeStorageClasses {
+	f := &fakeStorageClasses{storageClasses: map[string]*storagev1.StorageClass{}}
+	for _, sc := range objs {
+		f.storageClasses[sc.Name] = sc.DeepCopy()
+	}
+	return f
+}
+
+func (f *fakeStorageClasses) StorageClasses() storageclientv1.StorageClassInterface {
+	return f
+}
+
+func (f *fakeStorageClasses) Get(name string, options metav1.GetOptions) (*storagev1.StorageClass, error) {
+	sc, found := f.storageClasses[name]
+	if !found {
+		return nil, apierrors.NewNotFound(storagev1.Resource("storageclasses"), name)
+	}
+	return sc.DeepCopy(), nil
+}
+
+func (f *fakeStorageClasses) Create(sc *storagev1.StorageClass) (*storagev1.StorageClass, error) {
+	if _, found := f.storageClasses[sc.Name]; found {
+		return nil, apierrors.NewAlreadyExists(storagev1.Resource("storageclasses"), sc.Name)
+	}
+	f.storageClasses[sc.Name] = sc.DeepCopy()
+	return sc, nil
+}
+
+func (f *fakeStorageClasses) Update(sc *storagev1.StorageClass) (*storagev1.StorageClass, error) {
+	f.storageClasses[sc.Name] = sc.DeepCopy()
+	return sc, nil
+}
+
+func (f *fakeStorageClasses) Delete(name string, options *metav1.DeleteOptions) error {
+	delete(f.storageClasses, name)
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+func newTestLocalVolume() *localv1.LocalVolume {
+	return &localv1.LocalVolume{ObjectMeta: metav1.ObjectMeta{Name: "local-disks", Namespace: "local-storage"}}
+}
+
+func TestApplyStorageClassProvisionerMismatch(t *testing.T) {
+	lv := newTestLocalVolume()
+	required := generateStorageClass(lv, "local-sc", nil, false)
+
+	// a StorageClass owned by the LocalVolume is recreated, an admin's default annotation is kept
+	owned := generateStorageClass(lv, "local-sc", nil, false)
+	owned.Provisioner = "example.com/dynamic"
+	owned.Annotations = map[string]string{defaultStorageClassAnnotation: "true"}
+	client := newFakeStorageClasses(owned)
+	_, changed, err := applyStorageClass(client, required, false)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, []string{"local-sc"}, client.deleted)
+	sc := client.storageClasses["local-sc"]
+	assert.Equal(t, required.Provisioner, sc.Provisioner)
+	assert.Equal(t, "true", sc.Annotations[defaultStorageClassAnnotation])
+
+	// a StorageClass the LocalVolume doesn't own is left alone
+	for _, labels := range []map[string]string{
+		nil,
+		{ownerNamespaceLabel: lv.Namespace, ownerNameLabel: "other"},
+	} {
+		unowned := &storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "local-sc", Labels: labels},
+			Provisioner: "example.com/dynamic",
+		}
+		client = newFakeStorageClasses(unowned)
+		_, changed, err = applyStorageClass(client, required, false)
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		assert.Empty(t, client.deleted)
+		assert.Equal(t, unowned, client.storageClasses["local-sc"])
+	}
+}
+
+func TestApplyStorageClassSetAsDefault(t *testing.T) {
+	lv := newTestLocalVolume()
+	client := newFakeStorageClasses()
+
+	_, _, err := applyStorageClass(client, generateStorageClass(lv, "local-sc", nil, true), false)
+	assert.NoError(t, err)
+	assert.Equal(t, "true", client.storageClasses["local-sc"].Annotations[defaultStorageClassAnnotation])
+
+	// turning setAsDefault off removes the default annotation the operator set
+	_, changed, err := applyStorageClass(client, generateStorageClass(lv, "local-sc", nil, false), false)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.NotContains(t, client.storageClasses["local-sc"].Annotations, defaultStorageClassAnnotation)
+	assert.NotContains(t, client.storageClasses["local-sc"].Annotations, setAsDefaultAnnotation)
+
+	// a default set by an admin is kept
+	client.storageClasses["local-sc"].Annotations = map[string]string{defaultStorageClassAnnotation: "true"}
+	_, changed, err = applyStorageClass(client, generateStorageClass(lv, "local-sc", nil, false), false)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, "true", client.storageClasses["local-sc"].Annotations[defaultStorageClassAnnotation])
+
+	// with --disallow-default-storageclass the operator never changes the annotation
+	client.storageClasses["local-sc"].Annotations = map[string]string{defaultStorageClassAnnotation: "true", setAsDefaultAnnotation: "true"}
+	_, changed, err = applyStorageClass(client, generateStorageClass(lv, "local-sc", nil, false), true)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, "true", client.storageClasses["local-sc"].Annotations[defaultStorageClassAnnotation])
+}
@@ -39,8 +39,8 @@ const (
 )
 
 // Add creates a new LocalVolumeDiscovery Controller and adds it to the Manager. The Manager will set fields on the Controller
-// and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
+// and Start it when the Manager is Started. The controller doesn't use any of the options.
+func Add(mgr manager.Manager, _ common.Options) error {
 	return add(mgr, newReconciler(mgr))
 }
 
@@ -73,6 +73,26 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// enqueue all LocalVolumeDiscoveries when the reconcile endpoint is hit
+	err = c.Watch(&source.Channel{Source: common.SubscribeReconcileAll()}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			discoveries := &localv1alpha1.LocalVolumeDiscoveryList{}
+			err := mgr.GetClient().List(context.TODO(), discoveries)
+			if err != nil {
+				log.Error(err, "failed to list LocalVolumeDiscoveries")
+				return []reconcile.Request{}
+			}
+			reqs := make([]reconcile.Request, 0, len(discoveries.Items))
+			for _, discovery := range discoveries.Items {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: discovery.Name, Namespace: discovery.Namespace}})
+			}
+			return reqs
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -93,6 +113,11 @@ type ReconcileLocalVolumeDiscovery struct {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileLocalVolumeDiscovery) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	defer common.ObserveReconcileDuration("localvolumediscovery-controller")()
+	return common.ReconcileErrorResult(r.reconcile(request))
+}
+
+func (r *ReconcileLocalVolumeDiscovery) reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := r.reqLogger.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling LocalVolumeDiscovery")
 
@@ -111,7 +136,7 @@ func (r *ReconcileLocalVolumeDiscovery) Reconcile(request reconcile.Request) (re
 	}
 
 	diskMakerDSMutateFn := getDiskMakerDiscoveryDSMutateFn(request, instance.Spec.Tolerations,
-		getEnvVars(instance.Name, string(instance.UID)),
+		getEnvVars(instance.Name, string(instance.UID), instance.Spec.SelfTest),
 		getOwnerRefs(instance),
 		instance.Spec.NodeSelector)
 	ds, opResult, err := nodedaemon.CreateOrUpdateDaemonset(r.client, diskMakerDSMutateFn)
@@ -314,8 +339,10 @@ func getOwnerRefs(cr *localv1alpha1.LocalVolumeDiscovery) []metav1.OwnerReferenc
 	}
 }
 
-func getEnvVars(objName, uid string) []corev1.EnvVar {
-	return []corev1.EnvVar{
+// getEnvVars returns the env of the discovery daemon. The self-test env is only set when it is enabled,
+// changing it restarts the daemons, which run the self-test when they start.
+func getEnvVars(objName, uid string, selfTest bool) []corev1.EnvVar {
+	envVars := []corev1.EnvVar{
 		{
 			Name:  "DISCOVERY_OBJECT_UID",
 			Value: uid,
@@ -325,4 +352,8 @@ func getEnvVars(objName, uid string) []corev1.EnvVar {
 			Value: objName,
 		},
 	}
+	if selfTest {
+		envVars = append(envVars, corev1.EnvVar{Name: common.DiscoverySelfTestEnv, Value: "true"})
+	}
+	return envVars
 }
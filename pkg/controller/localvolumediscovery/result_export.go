@@ -0,0 +1,227 @@
+package localvolumediscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	resultExportControllerName = "discoveryresultexport-controller"
+	// resultExportResyncPeriod is how often the ConfigMaps are checked, they are not watched
+	// as their namespace may not be watched by the operator
+	resultExportResyncPeriod = 5 * time.Minute
+)
+
+// resultExportRequest is the only request of the export controller, which mirrors all LocalVolumeDiscoveryResults at once
+var resultExportRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: resultExportControllerName}}
+
+// AddResultExporter adds the controller that mirrors each LocalVolumeDiscoveryResult into a ConfigMap
+// when the operator runs with a discovery result ConfigMap namespace
+func AddResultExporter(mgr manager.Manager, options common.Options) error {
+	if options.DiscoveryResultConfigMapNamespace == "" {
+		return nil
+	}
+	r := &ResultExporter{
+		client:       mgr.GetClient(),
+		apiReader:    mgr.GetAPIReader(),
+		namespace:    options.DiscoveryResultConfigMapNamespace,
+		resyncPeriod: options.ResyncPeriodOrDefault(resultExportResyncPeriod),
+		reqLogger:    logf.Log.WithName(resultExportControllerName),
+	}
+	c, err := controller.New(resultExportControllerName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	enqueueExport := &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			return []reconcile.Request{resultExportRequest}
+		}),
+	}
+
+	err = c.Watch(&source.Kind{Type: &localv1alpha1.LocalVolumeDiscoveryResult{}}, enqueueExport)
+	if err != nil {
+		return err
+	}
+
+	// watch nodes being added and removed, the ConfigMaps of the removed nodes are deleted
+	err = c.Watch(&source.Kind{Type: &corev1.Node{}}, enqueueExport, predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool { return false },
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ResultExporter mirrors the LocalVolumeDiscoveryResults of the existing nodes into ConfigMaps in namespace,
+// for the tools that can't read custom resources. The LocalVolumeDiscoveryResults stay the source of truth:
+// changes to the ConfigMaps are overwritten, and the ConfigMaps of removed results and nodes are deleted.
+type ResultExporter struct {
+	client client.Client
+	// apiReader reads the ConfigMaps, whose namespace may not be in the cache of the operator
+	apiReader    client.Reader
+	namespace    string
+	resyncPeriod time.Duration
+	reqLogger    logr.Logger
+}
+
+var _ reconcile.Reconciler = &ResultExporter{}
+
+// Reconcile creates, updates and deletes the ConfigMaps of the LocalVolumeDiscoveryResults
+func (r *ResultExporter) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	defer common.ObserveReconcileDuration(resultExportControllerName)()
+	return common.ReconcileErrorResult(r.reconcile(request))
+}
+
+func (r *ResultExporter) reconcile(request reconcile.Request) (reconcile.Result, error) {
+	results := &localv1alpha1.LocalVolumeDiscoveryResultList{}
+	err := r.client.List(context.TODO(), results)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list LocalVolumeDiscoveryResults: %w", err)
+	}
+	nodes := &corev1.NodeList{}
+	err = r.client.List(context.TODO(), nodes)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	nodeNames := map[string]bool{}
+	for _, node := range nodes.Items {
+		nodeNames[node.Name] = true
+	}
+
+	exported := map[string]bool{}
+	for i := range results.Items {
+		result := &results.Items[i]
+		if !nodeNames[result.Spec.NodeName] || result.DeletionTimestamp != nil {
+			continue
+		}
+		configMap, err := newResultConfigMap(result, r.namespace)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		exported[configMap.Name] = true
+		err = r.syncConfigMap(configMap)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	configMaps := &corev1.ConfigMapList{}
+	err = r.apiReader.List(context.TODO(), configMaps, client.InNamespace(r.namespace), client.HasLabels{common.DiscoveryResultExportLabel})
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list the discovery result ConfigMaps in namespace %q: %w", r.namespace, err)
+	}
+	for i := range configMaps.Items {
+		configMap := &configMaps.Items[i]
+		if exported[configMap.Name] {
+			continue
+		}
+		r.reqLogger.Info("deleting the ConfigMap of a removed discovery result", "configMap", configMap.Name)
+		err = r.client.Delete(context.TODO(), configMap)
+		if err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("failed to delete ConfigMap %q: %w", configMap.Name, err)
+		}
+	}
+
+	return reconcile.Result{Requeue: true, RequeueAfter: r.resyncPeriod}, nil
+}
+
+// syncConfigMap creates the ConfigMap, or updates the data and labels of the existing one
+func (r *ResultExporter) syncConfigMap(configMap *corev1.ConfigMap) error {
+	existing := &corev1.ConfigMap{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, existing)
+	if errors.IsNotFound(err) {
+		r.reqLogger.Info("creating the ConfigMap of a discovery result", "configMap", configMap.Name)
+		err = r.client.Create(context.TODO(), configMap)
+		if err != nil {
+			return fmt.Errorf("failed to create ConfigMap %q: %w", configMap.Name, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get ConfigMap %q: %w", configMap.Name, err)
+	}
+
+	source, found := existing.Labels[common.DiscoveryResultExportLabel]
+	if !found {
+		// a ConfigMap that the operator didn't create is left alone
+		r.reqLogger.Info("skipping the ConfigMap of a discovery result, a ConfigMap of the same name exists", "configMap", configMap.Name)
+		return nil
+	}
+	if source != configMap.Labels[common.DiscoveryResultExportLabel] {
+		// the ConfigMap of the node is exported from the LocalVolumeDiscoveryResult of another namespace
+		r.reqLogger.Info("skipping the ConfigMap of a discovery result, it is exported from another namespace",
+			"configMap", configMap.Name, "namespace", source)
+		return nil
+	}
+	if reflect.DeepEqual(existing.Data, configMap.Data) && existing.Labels[common.DiscoveryNodeLabel] == configMap.Labels[common.DiscoveryNodeLabel] {
+		return nil
+	}
+	for key, value := range configMap.Labels {
+		existing.Labels[key] = value
+	}
+	existing.Data = configMap.Data
+	err = r.client.Update(context.TODO(), existing)
+	if err != nil {
+		return fmt.Errorf("failed to update ConfigMap %q: %w", configMap.Name, err)
+	}
+	return nil
+}
+
+// newResultConfigMap returns the ConfigMap that mirrors result in namespace, it has the name of the result
+func newResultConfigMap(result *localv1alpha1.LocalVolumeDiscoveryResult, namespace string) (*corev1.ConfigMap, error) {
+	discoveredDevices := result.Status.DiscoveredDevices
+	if discoveredDevices == nil {
+		discoveredDevices = []localv1alpha1.DiscoveredDevice{}
+	}
+	rejectedDevices := result.Status.RejectedDevices
+	if rejectedDevices == nil {
+		rejectedDevices = []localv1alpha1.RejectedDevice{}
+	}
+	discovered, err := json.Marshal(discoveredDevices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the discovered devices of %q: %w", result.Name, err)
+	}
+	rejected, err := json.Marshal(rejectedDevices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the rejected devices of %q: %w", result.Name, err)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      result.Name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				common.DiscoveryResultExportLabel: result.Namespace,
+				common.DiscoveryNodeLabel:         result.Spec.NodeName,
+			},
+		},
+		Data: map[string]string{
+			"nodeName":            result.Spec.NodeName,
+			"discoveredTimeStamp": result.Status.DiscoveredTimeStamp,
+			"discoveredDevices":   string(discovered),
+			"rejectedDevices":     string(rejected),
+		},
+	}, nil
+}
@@ -0,0 +1,93 @@
+package localvolumediscovery
+
+import (
+	"context"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestResultExporter(t *testing.T) {
+	const exportNamespace = "inventory"
+	newResult := func(nodeName string, devices ...string) *localv1alpha1.LocalVolumeDiscoveryResult {
+		result := &localv1alpha1.LocalVolumeDiscoveryResult{
+			ObjectMeta: metav1.ObjectMeta{Name: "discovery-result-" + nodeName, Namespace: namespace},
+			Spec:       localv1alpha1.LocalVolumeDiscoveryResultSpec{NodeName: nodeName},
+			Status:     localv1alpha1.LocalVolumeDiscoveryResultStatus{DiscoveredTimeStamp: "2021-03-01T10:00:00Z"},
+		}
+		for _, device := range devices {
+			result.Status.DiscoveredDevices = append(result.Status.DiscoveredDevices, localv1alpha1.DiscoveredDevice{Path: device})
+		}
+		return result
+	}
+	newConfigMap := func(name string, labels map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: exportNamespace, Labels: labels},
+			Data:       map[string]string{"nodeName": "edited"},
+		}
+	}
+	objects := []runtime.Object{
+		&mockNodeList.Items[0],
+		&mockNodeList.Items[1],
+		newResult("Node1", "/dev/sdb"),
+		newResult("Node2"),
+		// the node of the result was removed
+		newResult("Node3", "/dev/sdc"),
+		// an edited ConfigMap is overwritten
+		newConfigMap("discovery-result-Node2", map[string]string{common.DiscoveryResultExportLabel: namespace}),
+		newConfigMap("discovery-result-Node3", map[string]string{common.DiscoveryResultExportLabel: namespace}),
+		// the ConfigMap of a result that is gone
+		newConfigMap("discovery-result-Node4", map[string]string{common.DiscoveryResultExportLabel: namespace}),
+		// a ConfigMap that the operator didn't create
+		newConfigMap("unrelated", nil),
+	}
+
+	scheme, err := localv1alpha1.SchemeBuilder.Build()
+	assert.NoError(t, err)
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	fakeClient := fake.NewFakeClientWithScheme(scheme, objects...)
+	r := &ResultExporter{
+		client:    fakeClient,
+		apiReader: fakeClient,
+		namespace: exportNamespace,
+		reqLogger: logf.Log.WithName(resultExportControllerName),
+	}
+
+	_, err = r.reconcile(resultExportRequest)
+	assert.NoError(t, err)
+
+	expected := map[string]map[string]string{
+		"discovery-result-Node1": {
+			"nodeName":            "Node1",
+			"discoveredTimeStamp": "2021-03-01T10:00:00Z",
+			"discoveredDevices":   `[{"deviceID":"","path":"/dev/sdb","model":"","type":"","vendor":"","serial":"","size":0,"property":"","fstype":"","status":{"state":""}}]`,
+			"rejectedDevices":     "[]",
+		},
+		"discovery-result-Node2": {
+			"nodeName":            "Node2",
+			"discoveredTimeStamp": "2021-03-01T10:00:00Z",
+			"discoveredDevices":   "[]",
+			"rejectedDevices":     "[]",
+		},
+		"unrelated": {"nodeName": "edited"},
+	}
+	for name, data := range expected {
+		configMap := &corev1.ConfigMap{}
+		err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: exportNamespace}, configMap)
+		assert.NoErrorf(t, err, "getting ConfigMap %s", name)
+		assert.Equalf(t, data, configMap.Data, "data of ConfigMap %s", name)
+	}
+	for _, name := range []string{"discovery-result-Node3", "discovery-result-Node4"} {
+		err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: exportNamespace}, &corev1.ConfigMap{})
+		assert.Truef(t, kerrors.IsNotFound(err), "ConfigMap %s should be deleted", name)
+	}
+}
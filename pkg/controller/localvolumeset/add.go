@@ -3,16 +3,20 @@
 package localvolumeset
 
 import (
+	"context"
+
 	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
 	"github.com/openshift/local-storage-operator/pkg/common"
 	"github.com/openshift/local-storage-operator/pkg/controller/nodedaemon"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
@@ -25,12 +29,18 @@ const (
 
 // AddLocalVolumeSetReconciler adds a new Controller to mgr with r as the reconcile.Reconciler
 // this controller creates the child objects for the localvolumset CR
-func AddLocalVolumeSetReconciler(mgr manager.Manager) error {
+func AddLocalVolumeSetReconciler(mgr manager.Manager, options common.Options) error {
 
 	// an association from storageclass to localvolumesets
 	lvSetMap := &common.StorageClassOwnerMap{}
 
-	r := &LocalVolumeSetReconciler{client: mgr.GetClient(), scheme: mgr.GetScheme(), lvSetMap: lvSetMap}
+	r := &LocalVolumeSetReconciler{
+		client:   mgr.GetClient(),
+		scheme:   mgr.GetScheme(),
+		lvSetMap: lvSetMap,
+		recorder: mgr.GetEventRecorderFor(ComponentName),
+		options:  options,
+	}
 	// Create a new controller
 	c, err := controller.New(ComponentName, mgr, controller.Options{Reconciler: r})
 	if err != nil {
@@ -62,7 +72,35 @@ func AddLocalVolumeSetReconciler(mgr manager.Manager) error {
 		return err
 	}
 
-	//  watch for storageclass, enqueue owner
+	enqueueAllLocalVolumeSets := &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			lvSets := &localv1alpha1.LocalVolumeSetList{}
+			err := r.client.List(context.TODO(), lvSets)
+			if err != nil {
+				logf.Log.WithName(ComponentName).Error(err, "failed to list LocalVolumeSets")
+				return []reconcile.Request{}
+			}
+			reqs := make([]reconcile.Request, 0, len(lvSets.Items))
+			for _, lvSet := range lvSets.Items {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: lvSet.Name, Namespace: lvSet.Namespace}})
+			}
+			return reqs
+		}),
+	}
+
+	// watch nodes and enqueue all LocalVolumeSets, the zones of the matching nodes are the allowedTopologies of their storageclasses
+	// and the PVs and taints of NotReady nodes are left alone until they are ready again
+	err = c.Watch(&source.Kind{Type: &corev1.Node{}}, enqueueAllLocalVolumeSets, common.NodeLabelsOrReadinessChanged())
+	if err != nil {
+		return err
+	}
+
+	// enqueue all LocalVolumeSets when the reconcile endpoint is hit
+	err = c.Watch(&source.Channel{Source: common.SubscribeReconcileAll()}, enqueueAllLocalVolumeSets)
+	if err != nil {
+		return err
+	}
+
 	err = c.Watch(&source.Kind{Type: &corev1.PersistentVolume{}}, &handler.EnqueueRequestsFromMapFunc{
 		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
 			pv, ok := obj.Object.(*corev1.PersistentVolume)
@@ -82,6 +120,21 @@ func AddLocalVolumeSetReconciler(mgr manager.Manager) error {
 		return err
 	}
 
+	// watch storageclasses by name, so that a deleted storageclass is recreated while its LocalVolumeSet exists
+	err = c.Watch(&source.Kind{Type: &storagev1.StorageClass{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			names := lvSetMap.GetStorageClassOwners(obj.Meta.GetName())
+			reqs := make([]reconcile.Request, 0)
+			for _, name := range names {
+				reqs = append(reqs, reconcile.Request{NamespacedName: name})
+			}
+			return reqs
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
 	// Watch for changes to owned resource PersistentVolume and enqueue the LocalVolumeSet
 	// so that the controller can update the status and finalizer(TODO) based on the owned PVs
 	err = c.Watch(&source.Kind{Type: &corev1.PersistentVolume{}}, &handler.EnqueueRequestsFromMapFunc{
@@ -1,13 +1,23 @@
 package localvolumeset
 
 import (
+	"fmt"
+
 	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/local-storage-operator/pkg/common"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	// DaemonSetsAvailable
 	DaemonSetsAvailableAndConfigured = "DaemonSetsAvailable"
+	// DaemonSetUnschedulable is true when no node matches the nodeSelector and tolerations of the LocalVolumeSet,
+	// so its diskmaker is not scheduled on any node
+	DaemonSetUnschedulable = "DaemonSetUnschedulable"
+	// ExcludedDevicesInUse is true when devices listed in excludeBySerial still back bound PVs
+	ExcludedDevicesInUse = "ExcludedDevicesInUse"
+	// BoundVolumesOnRemovedNodes is true when cleanupOnNodeRemoval leaves bound PVs on nodes that no longer match the nodeSelector
+	BoundVolumesOnRemovedNodes = "BoundVolumesOnRemovedNodes"
 )
 
 // SetCondition creates or updates a condition of type conditionType in conditions and returns changed
@@ -25,7 +35,6 @@ func SetCondition(conditions *[]operatorv1.OperatorCondition, conditionType, con
 	for i, condition := range *conditions {
 		if condition.Type == conditionType {
 			changed := false
-			newCondition.LastTransitionTime = condition.LastTransitionTime
 			if condition.Status != conditionStatus || condition.LastTransitionTime.IsZero() {
 				changed = true
 			} else {
@@ -42,3 +51,28 @@ func SetCondition(conditions *[]operatorv1.OperatorCondition, conditionType, con
 	return true
 
 }
+
+// setDegradedCondition sets the Degraded condition when the reconcile failed with a fatal API error, which is not
+// retried until the LocalVolumeSet changes, and clears it once the reconcile gets past it. It returns changed.
+func setDegradedCondition(conditions *[]operatorv1.OperatorCondition, reconcileError error) bool {
+	if reconcileError != nil && common.IsFatalAPIError(reconcileError) {
+		message := fmt.Sprintf("Operator error, not retried until the LocalVolumeSet changes: %+v", reconcileError)
+		return SetCondition(conditions, operatorv1.OperatorStatusTypeDegraded, message, operatorv1.ConditionTrue)
+	}
+	for _, condition := range *conditions {
+		if condition.Type == operatorv1.OperatorStatusTypeDegraded {
+			return SetCondition(conditions, operatorv1.OperatorStatusTypeDegraded, "", operatorv1.ConditionFalse)
+		}
+	}
+	return false
+}
+
+// findCondition returns the condition of type conditionType, or nil if conditions don't have it
+func findCondition(conditions []operatorv1.OperatorCondition, conditionType string) *operatorv1.OperatorCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package localvolumeset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// syncNodeNotReadyPVs sets the PVNodeNotReadyAnnotation on the PVs of the LocalVolumeSet whose node is NotReady,
+// and removes it when the node is ready again.
+// It returns how long to wait before checking again while a node that stopped being ready is within its grace period.
+func (r *LocalVolumeSetReconciler) syncNodeNotReadyPVs(request reconcile.Request) (time.Duration, error) {
+	lvSet := &localv1alpha1.LocalVolumeSet{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, lvSet)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get localvolumeset: %w", err)
+	}
+
+	nodes := &corev1.NodeList{}
+	err = r.client.List(context.TODO(), nodes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	now := time.Now()
+	var requeueAfter time.Duration
+	// the time each NotReady node stopped being ready, by hostname
+	notReadySince := map[string]string{}
+	for i := range nodes.Items {
+		notReady, since, remaining := common.NodeNotReady(&nodes.Items[i], now, r.options.NodeNotReadyGracePeriod)
+		if remaining > 0 && (requeueAfter == 0 || remaining < requeueAfter) {
+			requeueAfter = remaining
+		}
+		hostname, found := nodes.Items[i].Labels[corev1.LabelHostname]
+		if notReady && found {
+			notReadySince[hostname] = since.UTC().Format(time.RFC3339)
+		}
+	}
+
+	pvs := &corev1.PersistentVolumeList{}
+	err = r.client.List(context.TODO(), pvs, client.MatchingLabels{
+		common.PVOwnerKindLabel:      localv1alpha1.LocalVolumeSetKind,
+		common.PVOwnerNameLabel:      lvSet.Name,
+		common.PVOwnerNamespaceLabel: lvSet.Namespace,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list persistent volumes: %w", err)
+	}
+	for i := range pvs.Items {
+		pv := &pvs.Items[i]
+		since, notReady := notReadySince[pv.Labels[corev1.LabelHostname]]
+		current, marked := pv.Annotations[common.PVNodeNotReadyAnnotation]
+		if notReady == marked && current == since {
+			continue
+		}
+		if notReady {
+			if pv.Annotations == nil {
+				pv.Annotations = map[string]string{}
+			}
+			pv.Annotations[common.PVNodeNotReadyAnnotation] = since
+		} else {
+			delete(pv.Annotations, common.PVNodeNotReadyAnnotation)
+		}
+		r.reqLogger.Info("updating node readiness of PV", "pv", pv.Name, "nodeNotReadySince", since)
+		err = r.client.Update(context.TODO(), pv)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return 0, fmt.Errorf("failed to update PV %q: %w", pv.Name, err)
+		}
+	}
+	return requeueAfter, nil
+}
@@ -0,0 +1,69 @@
+package localvolumeset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestSyncNodeNotReadyPVs(t *testing.T) {
+	notReadySince := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	newNode := func(name string, ready corev1.ConditionStatus, since metav1.Time) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelHostname: name}},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: ready, LastTransitionTime: since},
+			}},
+		}
+	}
+	newPV := func(name, hostname string, annotations map[string]string) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: annotations,
+				Labels: map[string]string{
+					corev1.LabelHostname:         hostname,
+					common.PVOwnerKindLabel:      localv1alpha1.LocalVolumeSetKind,
+					common.PVOwnerNameLabel:      "lvset",
+					common.PVOwnerNamespaceLabel: testNamespace,
+				},
+			},
+		}
+	}
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "sc"},
+	}
+	fakeReconciler := newFakeLocalVolumeSetReconciler(t, lvset,
+		newNode("node-ready", corev1.ConditionTrue, notReadySince),
+		newNode("node-down", corev1.ConditionUnknown, notReadySince),
+		newNode("node-flapping", corev1.ConditionFalse, metav1.NewTime(time.Now().Add(-time.Minute))),
+		newPV("pv-ready", "node-ready", map[string]string{common.PVNodeNotReadyAnnotation: notReadySince.UTC().Format(time.RFC3339)}),
+		newPV("pv-down", "node-down", nil),
+		newPV("pv-flapping", "node-flapping", nil),
+	)
+	fakeReconciler.reqLogger = logf.Log
+
+	requeueAfter, err := fakeReconciler.syncNodeNotReadyPVs(reconcile.Request{NamespacedName: types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}})
+	assert.NoError(t, err)
+	assert.True(t, requeueAfter > 0 && requeueAfter <= common.DefaultNodeNotReadyGracePeriod, "requeued when the grace period of node-flapping expires")
+
+	getPV := func(name string) *corev1.PersistentVolume {
+		pv := &corev1.PersistentVolume{}
+		err := fakeReconciler.client.Get(context.TODO(), types.NamespacedName{Name: name}, pv)
+		assert.NoError(t, err)
+		return pv
+	}
+	assert.NotContains(t, getPV("pv-ready").Annotations, common.PVNodeNotReadyAnnotation, "the mark is removed when the node is ready again")
+	assert.Equal(t, notReadySince.UTC().Format(time.RFC3339), getPV("pv-down").Annotations[common.PVNodeNotReadyAnnotation])
+	assert.NotContains(t, getPV("pv-flapping").Annotations, common.PVNodeNotReadyAnnotation, "nodes within the grace period are not marked")
+}
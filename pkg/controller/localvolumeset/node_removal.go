@@ -0,0 +1,108 @@
+package localvolumeset
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// releaseRemovedNodes deletes the unbound PVs of the LocalVolumeSet on nodes that no longer match its nodeSelector,
+// when spec.cleanupOnNodeRemoval is set. The diskmaker removes their symlinks on the nodes it still runs on.
+// PVs that are bound are left in place and reported in the BoundVolumesOnRemovedNodes condition.
+func (r *LocalVolumeSetReconciler) releaseRemovedNodes(request reconcile.Request) error {
+	lvSet := &localv1alpha1.LocalVolumeSet{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, lvSet)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			r.lvSetMap.DeregisterStorageClassOwner(lvSet.Spec.StorageClassName, request.NamespacedName)
+			return nil
+		}
+		return fmt.Errorf("failed to get localvolumeset: %w", err)
+	}
+	if !lvSet.Spec.CleanupOnNodeRemoval {
+		return nil
+	}
+
+	nodeSelector, err := common.EffectiveNodeSelector(lvSet.Spec.NodeSelector)
+	if err != nil {
+		return err
+	}
+	nodes := &corev1.NodeList{}
+	err = r.client.List(context.TODO(), nodes)
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	removedNodes := map[string]bool{}
+	now := time.Now()
+	for i := range nodes.Items {
+		matches, err := common.NodeSelectorMatchesNodeLabels(&nodes.Items[i], nodeSelector)
+		if err != nil {
+			return err
+		}
+		hostname, found := nodes.Items[i].Labels[corev1.LabelHostname]
+		if found {
+			// the PVs of NotReady nodes are left alone until the node is ready again
+			notReady, _, _ := common.NodeNotReady(&nodes.Items[i], now, r.options.NodeNotReadyGracePeriod)
+			removedNodes[hostname] = !matches && !notReady
+		}
+	}
+
+	pvs := &corev1.PersistentVolumeList{}
+	err = r.client.List(context.TODO(), pvs, client.MatchingLabels{
+		common.PVOwnerKindLabel:      localv1alpha1.LocalVolumeSetKind,
+		common.PVOwnerNameLabel:      lvSet.Name,
+		common.PVOwnerNamespaceLabel: lvSet.Namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list persistent volumes: %w", err)
+	}
+
+	bound := []string{}
+	for i, pv := range pvs.Items {
+		// PVs of nodes that were deleted are left alone, the node may come back
+		if !removedNodes[pv.Labels[corev1.LabelHostname]] {
+			continue
+		}
+		if pv.Spec.ClaimRef != nil {
+			bound = append(bound, pv.Name)
+			continue
+		}
+		if pv.Status.Phase != corev1.VolumeAvailable {
+			continue
+		}
+		r.reqLogger.Info("deleting unbound PV of a node that no longer matches the nodeSelector", "pv", pv.Name)
+		// the precondition fails if the PV was bound meanwhile
+		err = r.client.Delete(context.TODO(), &pvs.Items[i], client.Preconditions{ResourceVersion: &pvs.Items[i].ResourceVersion})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete PV %q: %w", pv.Name, err)
+		}
+	}
+	sort.Strings(bound)
+
+	conditionStatus := operatorv1.ConditionFalse
+	conditionMessage := "No bound PV is left on removed nodes"
+	if len(bound) > 0 {
+		conditionStatus = operatorv1.ConditionTrue
+		conditionMessage = fmt.Sprintf("PVs of nodes that no longer match the nodeSelector are still bound and left in place: %s", strings.Join(bound, ", "))
+	}
+
+	changed := SetCondition(&lvSet.Status.Conditions, BoundVolumesOnRemovedNodes, conditionMessage, conditionStatus)
+	if changed {
+		err := r.client.Status().Update(context.TODO(), lvSet)
+		if err != nil {
+			r.reqLogger.Error(err, "failed to update localvolumeset condition", BoundVolumesOnRemovedNodes, conditionStatus, "message", conditionMessage)
+			return err
+		}
+	}
+	return nil
+}
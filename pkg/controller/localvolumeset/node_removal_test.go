@@ -0,0 +1,130 @@
+package localvolumeset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReleaseRemovedNodes(t *testing.T) {
+	newNode := func(name string, labels map[string]string) *corev1.Node {
+		labels[corev1.LabelHostname] = name
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	newPV := func(name, hostname string, bound bool, phase corev1.PersistentVolumePhase) *corev1.PersistentVolume {
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					corev1.LabelHostname:         hostname,
+					common.PVOwnerKindLabel:      localv1alpha1.LocalVolumeSetKind,
+					common.PVOwnerNameLabel:      "lvset",
+					common.PVOwnerNamespaceLabel: testNamespace,
+				},
+			},
+			Spec:   corev1.PersistentVolumeSpec{StorageClassName: "sc"},
+			Status: corev1.PersistentVolumeStatus{Phase: phase},
+		}
+		if bound {
+			pv.Spec.ClaimRef = &corev1.ObjectReference{Name: "claim", Namespace: testNamespace}
+		}
+		return pv
+	}
+	// PVs of NotReady nodes are left alone until they are ready again
+	notReadyNode := newNode("node-d", map[string]string{})
+	notReadyNode.Status.Conditions = []corev1.NodeCondition{
+		{Type: corev1.NodeReady, Status: corev1.ConditionUnknown, LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour))},
+	}
+	objects := []runtime.Object{
+		newNode("node-a", map[string]string{"disks": "ssd"}),
+		newNode("node-b", map[string]string{}),
+		notReadyNode,
+		newPV("pv-matching", "node-a", false, corev1.VolumeAvailable),
+		newPV("pv-available", "node-b", false, corev1.VolumeAvailable),
+		newPV("pv-bound", "node-b", true, corev1.VolumeBound),
+		newPV("pv-released", "node-b", true, corev1.VolumeReleased),
+		newPV("pv-deleted-node", "node-c", false, corev1.VolumeAvailable),
+		newPV("pv-not-ready-node", "node-d", false, corev1.VolumeAvailable),
+	}
+
+	testTable := []struct {
+		label           string
+		cleanup         bool
+		expectedPVs     []string
+		expectedStatus  operatorv1.ConditionStatus
+		conditionExists bool
+	}{
+		{
+			label:       "cleanup disabled",
+			expectedPVs: []string{"pv-matching", "pv-available", "pv-bound", "pv-released", "pv-deleted-node", "pv-not-ready-node"},
+		},
+		{
+			label:           "cleanup enabled",
+			cleanup:         true,
+			expectedPVs:     []string{"pv-matching", "pv-bound", "pv-released", "pv-deleted-node", "pv-not-ready-node"},
+			expectedStatus:  operatorv1.ConditionTrue,
+			conditionExists: true,
+		},
+	}
+
+	for _, tc := range testTable {
+		lvset := &localv1alpha1.LocalVolumeSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+			Spec: localv1alpha1.LocalVolumeSetSpec{
+				StorageClassName:     "sc",
+				CleanupOnNodeRemoval: tc.cleanup,
+				NodeSelector: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "disks", Operator: corev1.NodeSelectorOpIn, Values: []string{"ssd"}}}},
+				}},
+			},
+		}
+		objs := []runtime.Object{lvset}
+		for _, obj := range objects {
+			objs = append(objs, obj.DeepCopyObject())
+		}
+		fakeReconciler := newFakeLocalVolumeSetReconciler(t, objs...)
+		fakeReconciler.reqLogger = logf.Log
+		lvsetKey := types.NamespacedName{Name: lvset.GetName(), Namespace: lvset.GetNamespace()}
+		err := fakeReconciler.releaseRemovedNodes(reconcile.Request{NamespacedName: lvsetKey})
+		assert.NoErrorf(t, err, "[%s] releaseRemovedNodes", tc.label)
+
+		for _, name := range []string{"pv-matching", "pv-available", "pv-bound", "pv-released", "pv-deleted-node", "pv-not-ready-node"} {
+			err = fakeReconciler.client.Get(context.TODO(), types.NamespacedName{Name: name}, &corev1.PersistentVolume{})
+			expected := false
+			for _, expectedName := range tc.expectedPVs {
+				expected = expected || expectedName == name
+			}
+			if expected {
+				assert.NoErrorf(t, err, "[%s] PV %q should be left in place", tc.label, name)
+			} else {
+				assert.Truef(t, kerrors.IsNotFound(err), "[%s] PV %q should be deleted", tc.label, name)
+			}
+		}
+
+		reconciledLVSet := &localv1alpha1.LocalVolumeSet{}
+		err = fakeReconciler.client.Get(context.TODO(), lvsetKey, reconciledLVSet)
+		assert.NoErrorf(t, err, "get lvset from fake client")
+		conditionFound := false
+		for _, condition := range reconciledLVSet.Status.Conditions {
+			if condition.Type == BoundVolumesOnRemovedNodes {
+				conditionFound = true
+				assert.Equalf(t, tc.expectedStatus, condition.Status, "[%s] condition status", tc.label)
+				assert.Containsf(t, condition.Message, "pv-bound", "[%s] message lists the bound PVs", tc.label)
+				assert.NotContainsf(t, condition.Message, "pv-matching", "[%s] message lists only PVs of removed nodes", tc.label)
+			}
+		}
+		assert.Equalf(t, tc.conditionExists, conditionFound, "[%s] condition presence", tc.label)
+	}
+}
@@ -0,0 +1,220 @@
+package localvolumeset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// nodeTaintOwnersAnnotation records on a node the taints applied by LocalVolumeSets, by namespace/name,
+	// so that taints the node got from other sources are never removed
+	nodeTaintOwnersAnnotation = "local.storage.openshift.io/node-taint-owners"
+	// nodeTaintFinalizer keeps a LocalVolumeSet with a nodeTaint until its taint is removed from the nodes
+	nodeTaintFinalizer = "storage.openshift.com/local-volume-set-node-taint"
+)
+
+// syncNodeTaints applies spec.nodeTaint to the nodes that provision devices for the LocalVolumeSet
+// and removes it from the other nodes, or from all nodes when the LocalVolumeSet is deleted.
+// The finalizer is kept while the LocalVolumeSet has a nodeTaint.
+func (r *LocalVolumeSetReconciler) syncNodeTaints(request reconcile.Request) error {
+	lvSet := &localv1alpha1.LocalVolumeSet{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, lvSet)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get localvolumeset: %w", err)
+	}
+	deleting := lvSet.DeletionTimestamp != nil
+
+	tainted := sets.NewString()
+	if lvSet.Spec.NodeTaint != nil && !deleting {
+		if lvSet.Spec.MaxNodeCount != nil {
+			tainted.Insert(lvSet.Status.SelectedNodes...)
+		} else {
+			matching, err := r.matchingNodes(lvSet)
+			if err != nil {
+				return err
+			}
+			tainted.Insert(matching...)
+		}
+	}
+
+	nodes := &corev1.NodeList{}
+	err = r.client.List(context.TODO(), nodes)
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	owner := request.NamespacedName.String()
+	now := time.Now()
+	for i := range nodes.Items {
+		// the taints of NotReady nodes are left alone until the node is ready again, unless the LocalVolumeSet is deleted
+		if notReady, _, _ := common.NodeNotReady(&nodes.Items[i], now, r.options.NodeNotReadyGracePeriod); notReady && !deleting {
+			continue
+		}
+		owners, err := nodeTaintOwners(&nodes.Items[i])
+		if err != nil {
+			return err
+		}
+		_, owned := owners[owner]
+		if !owned && !tainted.Has(nodes.Items[i].Name) {
+			continue
+		}
+		var taint *corev1.Taint
+		if tainted.Has(nodes.Items[i].Name) {
+			taint = lvSet.Spec.NodeTaint
+		}
+		err = r.updateNodeTaint(nodes.Items[i].Name, owner, taint)
+		if err != nil {
+			return fmt.Errorf("failed to update the taints of node %q: %w", nodes.Items[i].Name, err)
+		}
+	}
+
+	keepFinalizer := lvSet.Spec.NodeTaint != nil && !deleting
+	if hasFinalizer(lvSet) == keepFinalizer {
+		return nil
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		err := r.client.Get(context.TODO(), request.NamespacedName, lvSet)
+		if err != nil {
+			return err
+		}
+		finalizers := []string{}
+		for _, finalizer := range lvSet.Finalizers {
+			if finalizer != nodeTaintFinalizer {
+				finalizers = append(finalizers, finalizer)
+			}
+		}
+		if keepFinalizer {
+			finalizers = append(finalizers, nodeTaintFinalizer)
+		}
+		lvSet.Finalizers = finalizers
+		return r.client.Update(context.TODO(), lvSet)
+	})
+}
+
+func hasFinalizer(lvSet *localv1alpha1.LocalVolumeSet) bool {
+	for _, finalizer := range lvSet.Finalizers {
+		if finalizer == nodeTaintFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// updateNodeTaint applies the taint to the node on behalf of owner, or removes the taint owner applied if it is nil
+func (r *LocalVolumeSetReconciler) updateNodeTaint(nodeName, owner string, taint *corev1.Taint) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node := &corev1.Node{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: nodeName}, node)
+		if err != nil {
+			return err
+		}
+		owners, err := nodeTaintOwners(node)
+		if err != nil {
+			return err
+		}
+		if !applyNodeTaint(node, owners, owner, taint) {
+			return nil
+		}
+		err = setNodeTaintOwners(node, owners)
+		if err != nil {
+			return err
+		}
+		r.reqLogger.Info("updating node taints", "node", nodeName, "taints", node.Spec.Taints)
+		return r.client.Update(context.TODO(), node)
+	})
+}
+
+// applyNodeTaint updates the taints of the node and their owners for the taint of owner, nil to remove it,
+// and returns true if the node changed. A taint that the node got from another source is neither duplicated nor removed,
+// a taint that several LocalVolumeSets applied is removed with the last of them.
+func applyNodeTaint(node *corev1.Node, owners map[string]corev1.Taint, owner string, taint *corev1.Taint) bool {
+	previous, owned := owners[owner]
+	if owned && taint != nil && previous.MatchTaint(taint) && previous.Value == taint.Value {
+		return false
+	}
+	changed := false
+	if owned {
+		delete(owners, owner)
+		changed = true
+		if !ownedByOthers(owners, &previous) {
+			taints := []corev1.Taint{}
+			for _, existing := range node.Spec.Taints {
+				if !existing.MatchTaint(&previous) {
+					taints = append(taints, existing)
+				}
+			}
+			node.Spec.Taints = taints
+		}
+	}
+	if taint == nil {
+		return changed
+	}
+
+	for _, existing := range node.Spec.Taints {
+		if !existing.MatchTaint(taint) {
+			continue
+		}
+		// share the taint with the LocalVolumeSets that applied it, leave it alone if it comes from elsewhere
+		if ownedByOthers(owners, taint) {
+			owners[owner] = *taint
+			return true
+		}
+		return changed
+	}
+	node.Spec.Taints = append(node.Spec.Taints, corev1.Taint{Key: taint.Key, Value: taint.Value, Effect: taint.Effect})
+	owners[owner] = *taint
+	return true
+}
+
+// ownedByOthers returns true if one of the owners applied a taint with the key and effect of taint
+func ownedByOthers(owners map[string]corev1.Taint, taint *corev1.Taint) bool {
+	for _, owned := range owners {
+		if owned.MatchTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeTaintOwners returns the taints applied to the node by LocalVolumeSets, by namespace/name
+func nodeTaintOwners(node *corev1.Node) (map[string]corev1.Taint, error) {
+	owners := map[string]corev1.Taint{}
+	value, found := node.Annotations[nodeTaintOwnersAnnotation]
+	if !found {
+		return owners, nil
+	}
+	err := json.Unmarshal([]byte(value), &owners)
+	if err != nil {
+		return nil, fmt.Errorf("invalid annotation %q on node %q: %w", nodeTaintOwnersAnnotation, node.Name, err)
+	}
+	return owners, nil
+}
+
+func setNodeTaintOwners(node *corev1.Node, owners map[string]corev1.Taint) error {
+	if len(owners) == 0 {
+		delete(node.Annotations, nodeTaintOwnersAnnotation)
+		return nil
+	}
+	value, err := json.Marshal(owners)
+	if err != nil {
+		return err
+	}
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[nodeTaintOwnersAnnotation] = string(value)
+	return nil
+}
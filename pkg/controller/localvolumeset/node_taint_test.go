@@ -0,0 +1,116 @@
+package localvolumeset
+
+import (
+	"context"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestApplyNodeTaint(t *testing.T) {
+	taint := corev1.Taint{Key: "storage", Value: "local", Effect: corev1.TaintEffectNoSchedule}
+	otherValue := corev1.Taint{Key: "storage", Value: "other", Effect: corev1.TaintEffectNoSchedule}
+
+	// a new taint is added and recorded
+	node := &corev1.Node{}
+	owners := map[string]corev1.Taint{}
+	assert.True(t, applyNodeTaint(node, owners, "ns/a", &taint))
+	assert.Equal(t, []corev1.Taint{taint}, node.Spec.Taints)
+	assert.Equal(t, map[string]corev1.Taint{"ns/a": taint}, owners)
+	assert.False(t, applyNodeTaint(node, owners, "ns/a", &taint), "applying the taint again doesn't change the node")
+
+	// a second LocalVolumeSet shares the taint, which is removed with the last of them
+	assert.True(t, applyNodeTaint(node, owners, "ns/b", &taint))
+	assert.Len(t, node.Spec.Taints, 1, "the taint is not duplicated")
+	assert.True(t, applyNodeTaint(node, owners, "ns/a", nil))
+	assert.Len(t, node.Spec.Taints, 1, "the taint is kept for the other LocalVolumeSet")
+	assert.True(t, applyNodeTaint(node, owners, "ns/b", nil))
+	assert.Empty(t, node.Spec.Taints)
+	assert.Empty(t, owners)
+
+	// a taint from another source is neither duplicated nor recorded, so it is never removed
+	node = &corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{otherValue}}}
+	assert.False(t, applyNodeTaint(node, owners, "ns/a", &taint))
+	assert.Equal(t, []corev1.Taint{otherValue}, node.Spec.Taints)
+	assert.Empty(t, owners)
+	assert.False(t, applyNodeTaint(node, owners, "ns/a", nil))
+	assert.Equal(t, []corev1.Taint{otherValue}, node.Spec.Taints)
+
+	// changing the value of the taint replaces it
+	node = &corev1.Node{}
+	assert.True(t, applyNodeTaint(node, owners, "ns/a", &taint))
+	assert.True(t, applyNodeTaint(node, owners, "ns/a", &otherValue))
+	assert.Equal(t, []corev1.Taint{otherValue}, node.Spec.Taints)
+	assert.Equal(t, map[string]corev1.Taint{"ns/a": otherValue}, owners)
+}
+
+func TestSyncNodeTaints(t *testing.T) {
+	taint := corev1.Taint{Key: "storage", Value: "local", Effect: corev1.TaintEffectNoSchedule}
+	foreignTaint := corev1.Taint{Key: "storage", Value: "reserved", Effect: corev1.TaintEffectNoSchedule}
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec: localv1alpha1.LocalVolumeSetSpec{
+			StorageClassName: "sc",
+			NodeTaint:        &taint,
+			NodeSelector: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "disks", Operator: corev1.NodeSelectorOpIn, Values: []string{"ssd"}}}},
+			}},
+		},
+	}
+	matching := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"disks": "ssd"}}}
+	alreadyTainted := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{"disks": "ssd"}},
+		Spec:       corev1.NodeSpec{Taints: []corev1.Taint{foreignTaint}},
+	}
+	removed := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "node-c",
+			Annotations: map[string]string{nodeTaintOwnersAnnotation: `{"` + testNamespace + `/lvset":{"key":"storage","value":"local","effect":"NoSchedule"}}`},
+		},
+		Spec: corev1.NodeSpec{Taints: []corev1.Taint{taint}},
+	}
+	fakeReconciler := newFakeLocalVolumeSetReconciler(t, lvset, matching, alreadyTainted, removed)
+	fakeReconciler.reqLogger = logf.Log
+	lvsetKey := types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}
+	getNode := func(name string) *corev1.Node {
+		node := &corev1.Node{}
+		err := fakeReconciler.client.Get(context.TODO(), types.NamespacedName{Name: name}, node)
+		assert.NoError(t, err)
+		return node
+	}
+
+	err := fakeReconciler.syncNodeTaints(reconcile.Request{NamespacedName: lvsetKey})
+	assert.NoError(t, err)
+	node := getNode("node-a")
+	assert.Equal(t, []corev1.Taint{taint}, node.Spec.Taints, "matching nodes are tainted")
+	assert.Contains(t, node.Annotations, nodeTaintOwnersAnnotation)
+	node = getNode("node-b")
+	assert.Equal(t, []corev1.Taint{foreignTaint}, node.Spec.Taints, "a taint from another source is not duplicated")
+	assert.NotContains(t, node.Annotations, nodeTaintOwnersAnnotation)
+	node = getNode("node-c")
+	assert.Empty(t, node.Spec.Taints, "the taint is removed from nodes that no longer match")
+	assert.NotContains(t, node.Annotations, nodeTaintOwnersAnnotation)
+	err = fakeReconciler.client.Get(context.TODO(), lvsetKey, lvset)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{nodeTaintFinalizer}, lvset.Finalizers)
+
+	// deleting the LocalVolumeSet removes its taints and its finalizer
+	now := metav1.Now()
+	lvset.DeletionTimestamp = &now
+	err = fakeReconciler.client.Update(context.TODO(), lvset)
+	assert.NoError(t, err)
+	err = fakeReconciler.syncNodeTaints(reconcile.Request{NamespacedName: lvsetKey})
+	assert.NoError(t, err)
+	assert.Empty(t, getNode("node-a").Spec.Taints)
+	assert.Equal(t, []corev1.Taint{foreignTaint}, getNode("node-b").Spec.Taints, "a taint from another source is not removed")
+	lvset = &localv1alpha1.LocalVolumeSet{}
+	err = fakeReconciler.client.Get(context.TODO(), lvsetKey, lvset)
+	assert.NoError(t, err)
+	assert.Empty(t, lvset.Finalizers)
+}
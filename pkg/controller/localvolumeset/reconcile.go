@@ -2,15 +2,19 @@ package localvolumeset
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/go-logr/logr"
 	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
 	"github.com/openshift/local-storage-operator/pkg/common"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -33,6 +37,8 @@ type LocalVolumeSetReconciler struct {
 	scheme    *runtime.Scheme
 	reqLogger logr.Logger
 	lvSetMap  *common.StorageClassOwnerMap
+	recorder  record.EventRecorder
+	options   common.Options
 }
 
 // Reconcile reads that state of the cluster for a LocalVolumeSet object and makes changes based on the state read
@@ -41,6 +47,7 @@ type LocalVolumeSetReconciler struct {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *LocalVolumeSetReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	defer common.ObserveReconcileDuration(ComponentName)()
 	result, err := r.reconcile(request)
 	// sets conditions based on the exit status of reconcile
 	return r.addAvailabilityConditions(request, result, err)
@@ -65,7 +72,29 @@ func (r *LocalVolumeSetReconciler) reconcile(request reconcile.Request) (reconci
 	}
 
 	// store a one to many association from storageClass to LocalVolumeSet
-	r.lvSetMap.RegisterStorageClassOwner(lvSet.Spec.StorageClassName, request.NamespacedName)
+	for _, storageClassName := range lvSet.StorageClassNames() {
+		r.lvSetMap.RegisterStorageClassOwner(storageClassName, request.NamespacedName)
+	}
+
+	// remove the nodeTaint of a deleted LocalVolumeSet and wait for its symlinks to be removed,
+	// even if reconciliation is paused
+	if lvSet.DeletionTimestamp != nil {
+		err = r.syncNodeTaints(request)
+		if err != nil {
+			r.reqLogger.Error(err, "failed to remove node taints")
+			return reconcile.Result{}, err
+		}
+		requeueAfter, err := r.syncSymlinkCleanupFinalizer(request)
+		if err != nil {
+			r.reqLogger.Error(err, "failed to sync the symlink cleanup finalizer")
+		}
+		return reconcile.Result{RequeueAfter: requeueAfter}, err
+	}
+
+	if common.IsPaused(lvSet) {
+		r.reqLogger.Info("reconciliation is paused", "annotation", common.PausedAnnotation)
+		return reconcile.Result{}, nil
+	}
 
 	// The diskmaker daemonset, local-staic-provisioner daemonset and configmap are created in pkg/daemon
 	// this way, there can be one daemonset for all LocalVolumeSets
@@ -75,6 +104,12 @@ func (r *LocalVolumeSetReconciler) reconcile(request reconcile.Request) (reconci
 		r.reqLogger.Error(err, "failed to sync storageclass")
 		return reconcile.Result{}, err
 	}
+
+	err = r.updateSelectedNodesStatus(request)
+	if err != nil {
+		r.reqLogger.Error(err, "failed to update selected nodes")
+		return reconcile.Result{}, err
+	}
 	r.reqLogger.Info("updating status")
 
 	err = r.updateDaemonSetsCondition(request)
@@ -83,35 +118,148 @@ func (r *LocalVolumeSetReconciler) reconcile(request reconcile.Request) (reconci
 		return reconcile.Result{}, err
 	}
 
+	requeueAfter, err := r.updateDaemonSetUnschedulableCondition(request)
+	if err != nil {
+		r.reqLogger.Error(err, "failed to update status")
+		return reconcile.Result{}, err
+	}
+
 	err = r.updateTotalProvisionedDeviceCountStatus(request)
 	if err != nil {
 		r.reqLogger.Error(err, "failed to update status")
 		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, nil
+	err = r.updateExcludedDevicesCondition(request)
+	if err != nil {
+		r.reqLogger.Error(err, "failed to update status")
+		return reconcile.Result{}, err
+	}
+
+	err = r.updateDuplicatePVCondition(request)
+	if err != nil {
+		r.reqLogger.Error(err, "failed to update status")
+		return reconcile.Result{}, err
+	}
+
+	err = r.updateHostnameChangedPVCondition(request)
+	if err != nil {
+		r.reqLogger.Error(err, "failed to update status")
+		return reconcile.Result{}, err
+	}
+
+	err = r.updateStorageClassProvisionerCondition(request)
+	if err != nil {
+		r.reqLogger.Error(err, "failed to update status")
+		return reconcile.Result{}, err
+	}
+
+	windowRequeueAfter, err := r.updateProvisioningWindowCondition(request)
+	if err != nil {
+		r.reqLogger.Error(err, "failed to update status")
+		return reconcile.Result{}, err
+	}
+
+	err = r.releaseRemovedNodes(request)
+	if err != nil {
+		r.reqLogger.Error(err, "failed to release the PVs of removed nodes")
+		return reconcile.Result{}, err
+	}
+
+	err = r.syncNodeTaints(request)
+	if err != nil {
+		r.reqLogger.Error(err, "failed to sync node taints")
+		return reconcile.Result{}, err
+	}
+
+	_, err = r.syncSymlinkCleanupFinalizer(request)
+	if err != nil {
+		r.reqLogger.Error(err, "failed to sync the symlink cleanup finalizer")
+		return reconcile.Result{}, err
+	}
+
+	notReadyRequeueAfter, err := r.syncNodeNotReadyPVs(request)
+	if err != nil {
+		r.reqLogger.Error(err, "failed to mark the PVs of NotReady nodes")
+		return reconcile.Result{}, err
+	}
+	if notReadyRequeueAfter > 0 && (requeueAfter == 0 || notReadyRequeueAfter < requeueAfter) {
+		requeueAfter = notReadyRequeueAfter
+	}
+	if windowRequeueAfter > 0 && (requeueAfter == 0 || windowRequeueAfter < requeueAfter) {
+		requeueAfter = windowRequeueAfter
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
 }
 
+// syncStorageClass creates the storageclasses of the LocalVolumeSet: spec.storageClassName and those of spec.deviceRouting
 func (r *LocalVolumeSetReconciler) syncStorageClass(lvs *localv1alpha1.LocalVolumeSet) error {
+	for _, storageClassName := range lvs.StorageClassNames() {
+		err := r.syncStorageClassNamed(lvs, storageClassName)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *LocalVolumeSetReconciler) syncStorageClassNamed(lvs *localv1alpha1.LocalVolumeSet, storageClassName string) error {
 	deleteReclaimPolicy := corev1.PersistentVolumeReclaimDelete
 	firstConsumerBinding := storagev1.VolumeBindingWaitForFirstConsumer
 	storageClass := &storagev1.StorageClass{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      lvs.Spec.StorageClassName,
+			Name:      storageClassName,
 			Namespace: lvs.GetNamespace(),
 			Labels: map[string]string{
 				common.OwnerNameLabel:      lvs.GetName(),
 				common.OwnerNamespaceLabel: lvs.GetNamespace(),
 			},
 		},
-		Provisioner:       "kubernetes.io/no-provisioner",
+		Provisioner:       common.NoProvisioner,
 		ReclaimPolicy:     &deleteReclaimPolicy,
 		VolumeBindingMode: &firstConsumerBinding,
 	}
 
-	err := r.client.Create(context.TODO(), storageClass)
-	if err != nil && !kerrors.IsAlreadyExists(err) {
+	nodes := &corev1.NodeList{}
+	err := r.client.List(context.TODO(), nodes)
+	if err != nil {
 		return err
 	}
-	return nil
+	nodeSelector, err := common.EffectiveNodeSelector(lvs.Spec.NodeSelector)
+	if err != nil {
+		return err
+	}
+	storageClass.AllowedTopologies, err = common.AllowedTopologies(nodes.Items, nodeSelector)
+	if err != nil {
+		return err
+	}
+
+	existing := &storagev1.StorageClass{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: storageClass.Name}, existing)
+	if kerrors.IsNotFound(err) {
+		return r.client.Create(context.TODO(), storageClass)
+	} else if err != nil {
+		return err
+	}
+
+	// only keep the storageclasses created for this LocalVolumeSet up to date
+	if existing.Labels[common.OwnerNameLabel] != lvs.GetName() || existing.Labels[common.OwnerNamespaceLabel] != lvs.GetNamespace() {
+		return nil
+	}
+	if existing.Provisioner != storageClass.Provisioner {
+		// the provisioner is immutable, the storageclass is recreated and its PVs keep their storageClassName
+		r.reqLogger.Info("recreating storageclass with the local provisioner", "storageClass", existing.Name, "provisioner", existing.Provisioner)
+		err = r.client.Delete(context.TODO(), existing, client.Preconditions{UID: &existing.UID})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete storageclass %s with provisioner %s: %w", existing.Name, existing.Provisioner, err)
+		}
+		return r.client.Create(context.TODO(), storageClass)
+	}
+	if equality.Semantic.DeepEqual(existing.AllowedTopologies, storageClass.AllowedTopologies) {
+		return nil
+	}
+	r.reqLogger.Info("updating allowedTopologies of storageclass", "storageClass", existing.Name)
+	existing.AllowedTopologies = storageClass.AllowedTopologies
+	return r.client.Update(context.TODO(), existing)
 }
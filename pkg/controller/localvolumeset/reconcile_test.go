@@ -0,0 +1,126 @@
+package localvolumeset
+
+import (
+	"context"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestSyncStorageClassAllowedTopologies(t *testing.T) {
+	lvSet := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "sc"},
+	}
+	nodeA := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: map[string]string{common.LabelTopologyZone: "zone-a"}},
+	}
+	r := newFakeLocalVolumeSetReconciler(t, lvSet, nodeA)
+	r.reqLogger = logf.Log.WithName(ComponentName)
+
+	zones := func() []string {
+		sc := &storagev1.StorageClass{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: "sc"}, sc)
+		assert.NoError(t, err)
+		zones := []string{}
+		for _, term := range sc.AllowedTopologies {
+			for _, requirement := range term.MatchLabelExpressions {
+				zones = append(zones, requirement.Values...)
+			}
+		}
+		return zones
+	}
+
+	err := r.syncStorageClass(lvSet)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"zone-a"}, zones(), "allowedTopologies of the created storageclass")
+
+	nodeB := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "b", Labels: map[string]string{common.LabelTopologyZone: "zone-b"}},
+	}
+	err = r.client.Create(context.TODO(), nodeB)
+	assert.NoError(t, err)
+	err = r.syncStorageClass(lvSet)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"zone-a", "zone-b"}, zones(), "allowedTopologies after a node was added")
+
+	// a node without a zone label lifts the restriction
+	nodeC := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "c"}}
+	err = r.client.Create(context.TODO(), nodeC)
+	assert.NoError(t, err)
+	err = r.syncStorageClass(lvSet)
+	assert.NoError(t, err)
+	assert.Empty(t, zones(), "allowedTopologies after a node without zone was added")
+}
+
+func TestSyncStorageClassProvisioner(t *testing.T) {
+	lvSet := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec: localv1alpha1.LocalVolumeSetSpec{
+			StorageClassName: "sc",
+			DeviceRouting:    []localv1alpha1.DeviceRoute{{PartLabel: "fast", StorageClassName: "sc-fast"}},
+		},
+	}
+	// a storageclass of the LocalVolumeSet that was edited, and one that someone else created
+	edited := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "sc-fast", Labels: map[string]string{
+			common.OwnerNameLabel:      lvSet.Name,
+			common.OwnerNamespaceLabel: lvSet.Namespace,
+		}},
+		Provisioner: "example.com/dynamic",
+	}
+	r := newFakeLocalVolumeSetReconciler(t, lvSet, edited)
+	r.reqLogger = logf.Log.WithName(ComponentName)
+	provisioner := func(name string) string {
+		sc := &storagev1.StorageClass{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: name}, sc)
+		assert.NoError(t, err)
+		return sc.Provisioner
+	}
+	lvsetKey := types.NamespacedName{Name: lvSet.Name, Namespace: lvSet.Namespace}
+	getCondition := func() *operatorv1.OperatorCondition {
+		reconciledLVSet := &localv1alpha1.LocalVolumeSet{}
+		err := r.client.Get(context.TODO(), lvsetKey, reconciledLVSet)
+		assert.NoError(t, err)
+		return v1helpers.FindOperatorCondition(reconciledLVSet.Status.Conditions, common.StorageClassProvisionerMismatch)
+	}
+
+	err := r.syncStorageClass(lvSet)
+	assert.NoError(t, err)
+	assert.Equal(t, common.NoProvisioner, provisioner("sc"), "provisioner of the created storageclass")
+	assert.Equal(t, common.NoProvisioner, provisioner("sc-fast"), "the edited storageclass is recreated")
+	err = r.updateStorageClassProvisionerCondition(reconcile.Request{NamespacedName: lvsetKey})
+	assert.NoError(t, err)
+	condition := getCondition()
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, operatorv1.ConditionFalse, condition.Status)
+	}
+
+	// a storageclass that the LocalVolumeSet didn't create is only reported
+	foreign := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "sc-slow"}, Provisioner: "example.com/dynamic"}
+	err = r.client.Create(context.TODO(), foreign)
+	assert.NoError(t, err)
+	lvSet.Spec.DeviceRouting = append(lvSet.Spec.DeviceRouting, localv1alpha1.DeviceRoute{PartLabel: "slow", StorageClassName: "sc-slow"})
+	err = r.client.Update(context.TODO(), lvSet)
+	assert.NoError(t, err)
+	err = r.syncStorageClass(lvSet)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/dynamic", provisioner("sc-slow"))
+	err = r.updateStorageClassProvisionerCondition(reconcile.Request{NamespacedName: lvsetKey})
+	assert.NoError(t, err)
+	condition = getCondition()
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, operatorv1.ConditionTrue, condition.Status)
+		assert.Contains(t, condition.Message, "sc-slow (example.com/dynamic)")
+	}
+}
@@ -3,19 +3,30 @@ package localvolumeset
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
 	"github.com/openshift/local-storage-operator/pkg/controller/nodedaemon"
+	"github.com/openshift/local-storage-operator/version"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+// how long no node may match the nodeSelector and tolerations of a LocalVolumeSet before it is reported as unschedulable,
+// this gives new nodes and taint changes time to settle
+const daemonSetUnschedulableGracePeriod = 2 * time.Minute
+
 func (r *LocalVolumeSetReconciler) updateDaemonSetsCondition(request reconcile.Request) error {
 	var diskMakerMessage string
 	diskMakerFound := true
@@ -62,6 +73,155 @@ func (r *LocalVolumeSetReconciler) updateDaemonSetsCondition(request reconcile.R
 	return nil
 }
 
+// updateDaemonSetUnschedulableCondition reports the DaemonSetUnschedulable condition and a warning event when
+// no node has matched the nodeSelector and tolerations of the LocalVolumeSet for longer than daemonSetUnschedulableGracePeriod.
+// The condition is Unknown during the grace period, which is timed from its last transition.
+// It returns how long to wait before checking again while the grace period has not expired.
+func (r *LocalVolumeSetReconciler) updateDaemonSetUnschedulableCondition(request reconcile.Request) (time.Duration, error) {
+	lvSet := &localv1alpha1.LocalVolumeSet{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, lvSet)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			r.lvSetMap.DeregisterStorageClassOwner(lvSet.Spec.StorageClassName, request.NamespacedName)
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get localvolumeset: %w", err)
+	}
+
+	schedulable, err := r.schedulableNodes(lvSet)
+	if err != nil {
+		return 0, err
+	}
+
+	var requeueAfter time.Duration
+	conditionStatus := operatorv1.ConditionFalse
+	conditionMessage := fmt.Sprintf("%d nodes scheduled", len(schedulable))
+	if len(schedulable) == 0 {
+		nodeSelector, err := common.EffectiveNodeSelector(lvSet.Spec.NodeSelector)
+		if err != nil {
+			return 0, err
+		}
+		conditionStatus = operatorv1.ConditionTrue
+		conditionMessage = fmt.Sprintf("%s is not scheduled on any node, no node matches nodeSelector %s with the given tolerations",
+			nodedaemon.DiskMakerName, formatNodeSelector(nodeSelector))
+
+		previous := findCondition(lvSet.Status.Conditions, DaemonSetUnschedulable)
+		unschedulableFor := time.Duration(0)
+		if previous != nil && previous.Status != operatorv1.ConditionFalse {
+			unschedulableFor = time.Since(previous.LastTransitionTime.Time)
+		}
+		if previous == nil || previous.Status != operatorv1.ConditionTrue {
+			if unschedulableFor < daemonSetUnschedulableGracePeriod {
+				conditionStatus = operatorv1.ConditionUnknown
+				requeueAfter = daemonSetUnschedulableGracePeriod - unschedulableFor
+			}
+		}
+	}
+
+	changed := SetCondition(&lvSet.Status.Conditions, DaemonSetUnschedulable, conditionMessage, conditionStatus)
+	if changed {
+		err := r.client.Status().Update(context.TODO(), lvSet)
+		if err != nil {
+			r.reqLogger.Error(err, "failed to update localvolumeset condition", DaemonSetUnschedulable, conditionStatus, "message", conditionMessage)
+			return 0, err
+		}
+		if conditionStatus == operatorv1.ConditionTrue && r.recorder != nil {
+			r.recorder.Event(lvSet, corev1.EventTypeWarning, DaemonSetUnschedulable, conditionMessage)
+		}
+	}
+	return requeueAfter, nil
+}
+
+// schedulableNodes returns the names of the nodes where the diskmaker runs for the LocalVolumeSet: the nodes that match
+// its effective nodeSelector, or its selected nodes with maxNodeCount, and whose taints it tolerates
+func (r *LocalVolumeSetReconciler) schedulableNodes(lvSet *localv1alpha1.LocalVolumeSet) ([]string, error) {
+	matching, err := r.matchingNodes(lvSet)
+	if err != nil {
+		return nil, err
+	}
+	candidates := sets.NewString(matching...)
+	if lvSet.Spec.MaxNodeCount != nil {
+		candidates = candidates.Intersection(sets.NewString(lvSet.Status.SelectedNodes...))
+	}
+
+	tolerations := append([]corev1.Toleration{}, lvSet.Spec.Tolerations...)
+	// the diskmaker runs on the nodes the localvolumeset taints
+	if taint := lvSet.Spec.NodeTaint; taint != nil {
+		tolerations = append(tolerations, corev1.Toleration{
+			Key:      taint.Key,
+			Operator: corev1.TolerationOpEqual,
+			Value:    taint.Value,
+			Effect:   taint.Effect,
+		})
+	}
+
+	nodes := &corev1.NodeList{}
+	err = r.client.List(context.TODO(), nodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	schedulable := []string{}
+	for i := range nodes.Items {
+		if candidates.Has(nodes.Items[i].Name) && toleratesNodeTaints(&nodes.Items[i], tolerations) {
+			schedulable = append(schedulable, nodes.Items[i].Name)
+		}
+	}
+	sort.Strings(schedulable)
+	return schedulable, nil
+}
+
+// toleratesNodeTaints returns true if the tolerations tolerate the NoSchedule and NoExecute taints of the node.
+// The node.kubernetes.io/ taints are ignored, the daemonset controller tolerates them for all daemonsets.
+func toleratesNodeTaints(node *corev1.Node, tolerations []corev1.Toleration) bool {
+TaintLoop:
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule || strings.HasPrefix(taint.Key, "node.kubernetes.io/") {
+			continue
+		}
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(taint) {
+				continue TaintLoop
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// formatNodeSelector returns a readable representation of the node selector for messages
+func formatNodeSelector(selector *corev1.NodeSelector) string {
+	if selector == nil || len(selector.NodeSelectorTerms) == 0 {
+		return "<all nodes>"
+	}
+	terms := make([]string, 0, len(selector.NodeSelectorTerms))
+	for _, term := range selector.NodeSelectorTerms {
+		requirements := make([]string, 0, len(term.MatchExpressions)+len(term.MatchFields))
+		for _, expr := range term.MatchExpressions {
+			requirements = append(requirements, fmt.Sprintf("%s %s %v", expr.Key, expr.Operator, expr.Values))
+		}
+		for _, field := range term.MatchFields {
+			requirements = append(requirements, fmt.Sprintf("%s %s %v", field.Key, field.Operator, field.Values))
+		}
+		terms = append(terms, "("+strings.Join(requirements, " && ")+")")
+	}
+	return strings.Join(terms, " || ")
+}
+
+// listStorageClassPVs returns the PVs of the storageclasses of the LocalVolumeSet
+func (r *LocalVolumeSetReconciler) listStorageClassPVs(lvSet *localv1alpha1.LocalVolumeSet) (*corev1.PersistentVolumeList, error) {
+	pvs := &corev1.PersistentVolumeList{}
+	for _, storageClassName := range lvSet.StorageClassNames() {
+		storageClassPVs := &corev1.PersistentVolumeList{}
+		err := r.client.List(context.TODO(), storageClassPVs, client.MatchingFields{pvStorageClassField: storageClassName})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list persistent volumes: %w", err)
+		}
+		pvs.Items = append(pvs.Items, storageClassPVs.Items...)
+	}
+	return pvs, nil
+}
+
 func (r *LocalVolumeSetReconciler) updateTotalProvisionedDeviceCountStatus(request reconcile.Request) error {
 
 	lvSet := &localv1alpha1.LocalVolumeSet{}
@@ -75,20 +235,324 @@ func (r *LocalVolumeSetReconciler) updateTotalProvisionedDeviceCountStatus(reque
 	}
 
 	// fetch PVs that match the storageclass
-	pvs := &corev1.PersistentVolumeList{}
-	err = r.client.List(context.TODO(), pvs, client.MatchingFields{pvStorageClassField: lvSet.Spec.StorageClassName})
+	pvs, err := r.listStorageClassPVs(lvSet)
 	if err != nil {
-		return fmt.Errorf("failed to list persistent volumes: %w", err)
+		return err
+	}
+
+	// with maxNodeCount, only the selected nodes provision devices
+	var nodeCount int32
+	if lvSet.Spec.MaxNodeCount != nil {
+		nodeCount = int32(len(lvSet.Status.SelectedNodes))
+	} else {
+		matching, err := r.matchingNodes(lvSet)
+		if err != nil {
+			return err
+		}
+		nodeCount = int32(len(matching))
 	}
 
+	// the counts and the summary are written by the same update, so that they never disagree
 	totalPVCount := int32(len(pvs.Items))
+	rejectedCount := int32(len(lvSet.Status.QuarantinedDevices))
 	lvSet.Status.TotalProvisionedDeviceCount = &totalPVCount
+	lvSet.Status.NodeCount = &nodeCount
+	lvSet.Status.RejectedDeviceCount = &rejectedCount
+	lvSet.Status.Summary = statusSummary(nodeCount, totalPVCount, rejectedCount)
 	lvSet.Status.ObservedGeneration = lvSet.Generation
+	lvSet.Status.ObservedOperatorVersion = version.Version
+	lvSet.Status.ObservedProvisionerVersion = common.GetProvisionerVersion()
+	lvSet.Status.ObservedRescan = common.GetRescan(lvSet)
+	err = r.client.Status().Update(context.TODO(), lvSet)
+	if err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+
+	return nil
+}
+
+// statusSummary formats the counts of the status as a single line, for example "12 nodes, 144 devices, 140 PVs, 4 rejected".
+// The devices are the provisioned and the rejected devices.
+func statusSummary(nodeCount, pvCount, rejectedCount int32) string {
+	return fmt.Sprintf("%d nodes, %d devices, %d PVs, %d rejected", nodeCount, pvCount+rejectedCount, pvCount, rejectedCount)
+}
+
+// updateSelectedNodesStatus chooses the nodes that provision devices when maxNodeCount is set,
+// the nodedaemon schedules the diskmaker on them and the diskmaker skips all other nodes.
+func (r *LocalVolumeSetReconciler) updateSelectedNodesStatus(request reconcile.Request) error {
+	lvSet := &localv1alpha1.LocalVolumeSet{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, lvSet)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			r.lvSetMap.DeregisterStorageClassOwner(lvSet.Spec.StorageClassName, request.NamespacedName)
+			return nil
+		}
+		return fmt.Errorf("failed to get localvolumeset: %w", err)
+	}
+
+	var selected []string
+	if lvSet.Spec.MaxNodeCount != nil {
+		matching, err := r.matchingNodes(lvSet)
+		if err != nil {
+			return err
+		}
+		selected = selectNodes(lvSet.Status.SelectedNodes, matching, int(*lvSet.Spec.MaxNodeCount))
+	}
+
+	if reflect.DeepEqual(selected, lvSet.Status.SelectedNodes) {
+		return nil
+	}
+	r.reqLogger.Info("updating selected nodes", "selectedNodes", selected)
+	lvSet.Status.SelectedNodes = selected
 	err = r.client.Status().Update(context.TODO(), lvSet)
 	if err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
 	}
+	return nil
+}
+
+// matchingNodes returns the names of the nodes that match the effective nodeSelector of the LocalVolumeSet
+func (r *LocalVolumeSetReconciler) matchingNodes(lvSet *localv1alpha1.LocalVolumeSet) ([]string, error) {
+	nodes := &corev1.NodeList{}
+	err := r.client.List(context.TODO(), nodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	nodeSelector, err := common.EffectiveNodeSelector(lvSet.Spec.NodeSelector)
+	if err != nil {
+		return nil, err
+	}
+	matching := []string{}
+	for i := range nodes.Items {
+		matches, err := common.NodeSelectorMatchesNodeLabels(&nodes.Items[i], nodeSelector)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			matching = append(matching, nodes.Items[i].Name)
+		}
+	}
+	return matching, nil
+}
+
+// selectNodes returns up to maxNodeCount of the matching nodes ordered by name.
+// The previously selected nodes that still match are kept, so that raising maxNodeCount only adds nodes.
+func selectNodes(previous, matching []string, maxNodeCount int) []string {
+	matchingSet := sets.NewString(matching...)
+	kept := []string{}
+	for _, name := range previous {
+		if matchingSet.Has(name) {
+			kept = append(kept, name)
+		}
+	}
+	sort.Strings(kept)
+	if len(kept) > maxNodeCount {
+		kept = kept[:maxNodeCount]
+	}
+	selected := sets.NewString(kept...)
+	for _, name := range sets.NewString(matching...).List() {
+		if selected.Len() >= maxNodeCount {
+			break
+		}
+		selected.Insert(name)
+	}
+	if selected.Len() == 0 {
+		return nil
+	}
+	return selected.List()
+}
+
+// updateExcludedDevicesCondition reports the bound PVs of devices that are excluded by serial,
+// the diskmaker leaves them in place until they are released.
+func (r *LocalVolumeSetReconciler) updateExcludedDevicesCondition(request reconcile.Request) error {
+	lvSet := &localv1alpha1.LocalVolumeSet{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, lvSet)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			r.lvSetMap.DeregisterStorageClassOwner(lvSet.Spec.StorageClassName, request.NamespacedName)
+			return nil
+		}
+		return fmt.Errorf("failed to get localvolumeset: %w", err)
+	}
+
+	excluded := sets.NewString()
+	if lvSet.Spec.DeviceInclusionSpec != nil {
+		excluded.Insert(lvSet.Spec.DeviceInclusionSpec.ExcludeBySerial...)
+	}
+	for _, override := range lvSet.Spec.NodeOverrides {
+		excluded.Insert(override.DeviceInclusionSpec.ExcludeBySerial...)
+	}
+
+	inUse := []string{}
+	if excluded.Len() > 0 {
+		pvs, err := r.listStorageClassPVs(lvSet)
+		if err != nil {
+			return err
+		}
+		for _, pv := range pvs.Items {
+			if pv.Spec.ClaimRef != nil && excluded.Has(pv.GetAnnotations()[common.PVDeviceIdentityAnnotation]) {
+				inUse = append(inUse, pv.Name)
+			}
+		}
+	}
+	sort.Strings(inUse)
+
+	conditionStatus := operatorv1.ConditionFalse
+	conditionMessage := "No excluded device is in use"
+	if len(inUse) > 0 {
+		conditionStatus = operatorv1.ConditionTrue
+		conditionMessage = fmt.Sprintf("Excluded devices are still bound and left in place: %s", strings.Join(inUse, ", "))
+	}
 
+	changed := SetCondition(&lvSet.Status.Conditions, ExcludedDevicesInUse, conditionMessage, conditionStatus)
+	if changed {
+		err := r.client.Status().Update(context.TODO(), lvSet)
+		if err != nil {
+			r.reqLogger.Error(err, "failed to update localvolumeset condition", ExcludedDevicesInUse, conditionStatus, "message", conditionMessage)
+			return err
+		}
+	}
+	return nil
+}
+
+// updateDuplicatePVCondition reports the PVs of the LocalVolumeSet that the diskmakers flagged
+// because they use the device of an older PV of their node
+func (r *LocalVolumeSetReconciler) updateDuplicatePVCondition(request reconcile.Request) error {
+	lvSet := &localv1alpha1.LocalVolumeSet{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, lvSet)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get localvolumeset: %w", err)
+	}
+
+	pvs, err := r.listStorageClassPVs(lvSet)
+	if err != nil {
+		return err
+	}
+	duplicates := common.DuplicatePVs(pvs.Items)
+
+	conditionStatus := operatorv1.ConditionFalse
+	conditionMessage := "No persistentvolume shares its device"
+	if len(duplicates) > 0 {
+		conditionStatus = operatorv1.ConditionTrue
+		conditionMessage = fmt.Sprintf("Persistentvolumes use the device of an older persistentvolume of their node: %s", strings.Join(duplicates, ", "))
+	}
+
+	changed := SetCondition(&lvSet.Status.Conditions, common.DuplicatePVForDevice, conditionMessage, conditionStatus)
+	if changed {
+		err := r.client.Status().Update(context.TODO(), lvSet)
+		if err != nil {
+			r.reqLogger.Error(err, "failed to update localvolumeset condition", common.DuplicatePVForDevice, conditionStatus, "message", conditionMessage)
+			return err
+		}
+	}
+	return nil
+}
+
+// updateHostnameChangedPVCondition reports the bound PVs of the LocalVolumeSet that the diskmakers flagged
+// because their device is now on a node with another hostname
+func (r *LocalVolumeSetReconciler) updateHostnameChangedPVCondition(request reconcile.Request) error {
+	lvSet := &localv1alpha1.LocalVolumeSet{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, lvSet)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get localvolumeset: %w", err)
+	}
+
+	pvs, err := r.listStorageClassPVs(lvSet)
+	if err != nil {
+		return err
+	}
+	changed := common.HostnameChangedPVs(pvs.Items)
+
+	conditionStatus := operatorv1.ConditionFalse
+	conditionMessage := "No persistentvolume has a device that moved to a new hostname"
+	if len(changed) > 0 {
+		conditionStatus = operatorv1.ConditionTrue
+		conditionMessage = fmt.Sprintf("Bound persistentvolumes have a device on a node with a new hostname and keep their node affinity: %s", strings.Join(changed, ", "))
+	}
+
+	if SetCondition(&lvSet.Status.Conditions, common.PVHostnameChanged, conditionMessage, conditionStatus) {
+		err := r.client.Status().Update(context.TODO(), lvSet)
+		if err != nil {
+			r.reqLogger.Error(err, "failed to update localvolumeset condition", common.PVHostnameChanged, conditionStatus, "message", conditionMessage)
+			return err
+		}
+	}
+	return nil
+}
+
+// updateProvisioningWindowCondition reports whether the diskmakers defer new devices because the provisioningWindow
+// of the LocalVolumeSet is closed, and returns when to reconcile again for the window to open or close
+func (r *LocalVolumeSetReconciler) updateProvisioningWindowCondition(request reconcile.Request) (time.Duration, error) {
+	lvSet := &localv1alpha1.LocalVolumeSet{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, lvSet)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get localvolumeset: %w", err)
+	}
+
+	now := time.Now()
+	open, next, err := common.ProvisioningWindowState(lvSet.Spec.ProvisioningWindow, now)
+	conditionStatus := operatorv1.ConditionFalse
+	conditionMessage := "New devices are provisioned at any time"
+	switch {
+	case err != nil:
+		conditionStatus = operatorv1.ConditionTrue
+		conditionMessage = fmt.Sprintf("New devices are not provisioned: %v", err)
+	case !open:
+		conditionStatus = operatorv1.ConditionTrue
+		conditionMessage = fmt.Sprintf("New devices are deferred until the provisioning window opens at %s", next.Format(time.RFC3339))
+	case !next.IsZero():
+		conditionMessage = fmt.Sprintf("The provisioning window is open until %s", next.Format(time.RFC3339))
+	}
+
+	if SetCondition(&lvSet.Status.Conditions, common.ProvisioningDeferred, conditionMessage, conditionStatus) {
+		err := r.client.Status().Update(context.TODO(), lvSet)
+		if err != nil {
+			r.reqLogger.Error(err, "failed to update localvolumeset condition", common.ProvisioningDeferred, conditionStatus, "message", conditionMessage)
+			return 0, err
+		}
+	}
+	return common.ProvisioningWindowRequeueAfter(next, now), nil
+}
+
+// updateStorageClassProvisionerCondition reports the storageclasses of the LocalVolumeSet that don't use the local
+// provisioner: those that the LocalVolumeSet didn't create or that syncStorageClass failed to recreate
+func (r *LocalVolumeSetReconciler) updateStorageClassProvisionerCondition(request reconcile.Request) error {
+	lvSet := &localv1alpha1.LocalVolumeSet{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, lvSet)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get localvolumeset: %w", err)
+	}
+
+	mismatched, err := common.MismatchedStorageClasses(r.client, lvSet.StorageClassNames())
+	if err != nil {
+		return err
+	}
+	conditionStatus := operatorv1.ConditionFalse
+	if len(mismatched) > 0 {
+		conditionStatus = operatorv1.ConditionTrue
+	}
+	conditionMessage := common.StorageClassProvisionerMismatchMessage(mismatched)
+
+	changed := SetCondition(&lvSet.Status.Conditions, common.StorageClassProvisionerMismatch, conditionMessage, conditionStatus)
+	if changed {
+		err := r.client.Status().Update(context.TODO(), lvSet)
+		if err != nil {
+			r.reqLogger.Error(err, "failed to update localvolumeset condition", common.StorageClassProvisionerMismatch, conditionStatus, "message", conditionMessage)
+			return err
+		}
+	}
 	return nil
 }
 
@@ -116,6 +580,7 @@ func (r *LocalVolumeSetReconciler) addAvailabilityConditions(request reconcile.R
 		conditionMessage = fmt.Sprintf("Operator error: %+v", reconcileError)
 	}
 	changed := SetCondition(&lvSet.Status.Conditions, conditionType, conditionMessage, conditionStatus)
+	changed = setDegradedCondition(&lvSet.Status.Conditions, reconcileError) || changed
 	if changed {
 		err := r.client.Status().Update(context.TODO(), lvSet)
 		if err != nil {
@@ -123,5 +588,5 @@ func (r *LocalVolumeSetReconciler) addAvailabilityConditions(request reconcile.R
 			return result, err
 		}
 	}
-	return result, reconcileError
+	return common.ReconcileErrorResult(result, reconcileError)
 }
@@ -4,18 +4,25 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
 	"github.com/openshift/local-storage-operator/pkg/common"
 	"github.com/openshift/local-storage-operator/pkg/controller/nodedaemon"
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -33,12 +40,17 @@ func newFakeLocalVolumeSetReconciler(t *testing.T, objs ...runtime.Object) *Loca
 	err = appsv1.AddToScheme(scheme)
 	assert.NoErrorf(t, err, "adding appsv1 to scheme")
 
+	err = storagev1.AddToScheme(scheme)
+	assert.NoErrorf(t, err, "adding storagev1 to scheme")
+
 	client := fake.NewFakeClientWithScheme(scheme, objs...)
 
 	return &LocalVolumeSetReconciler{
 		client:   client,
 		scheme:   scheme,
 		lvSetMap: &common.StorageClassOwnerMap{},
+		recorder: record.NewFakeRecorder(20),
+		options:  common.DefaultOptions(),
 	}
 }
 
@@ -127,3 +139,462 @@ func TestDaemonSetCondition(t *testing.T) {
 
 	}
 }
+
+func TestDaemonSetUnschedulableCondition(t *testing.T) {
+	hostnameSelector := func(hostname string) *corev1.NodeSelector {
+		return &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key:      "kubernetes.io/hostname",
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{hostname},
+				}},
+			}},
+		}
+	}
+	unschedulableCondition := func(status operatorv1.ConditionStatus, age time.Duration) []operatorv1.OperatorCondition {
+		return []operatorv1.OperatorCondition{{
+			Type:               DaemonSetUnschedulable,
+			Status:             status,
+			LastTransitionTime: metav1.NewTime(time.Now().Add(-age)),
+		}}
+	}
+	taint := corev1.Taint{Key: "dedicated", Value: "storage", Effect: corev1.TaintEffectNoSchedule}
+
+	testTable := []struct {
+		label             string
+		nodeSelector      *corev1.NodeSelector
+		tolerations       []corev1.Toleration
+		conditions        []operatorv1.OperatorCondition
+		expectedStatus    operatorv1.ConditionStatus
+		expectRequeue     bool
+		expectedEventSent bool
+	}{
+		{
+			label:          "scheduled on nodes",
+			nodeSelector:   hostnameSelector("node-a"),
+			conditions:     unschedulableCondition(operatorv1.ConditionTrue, time.Hour),
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			label:          "no nodes within grace period",
+			nodeSelector:   hostnameSelector("missing-node"),
+			conditions:     unschedulableCondition(operatorv1.ConditionFalse, time.Hour),
+			expectedStatus: operatorv1.ConditionUnknown,
+			expectRequeue:  true,
+		},
+		{
+			label:          "no nodes for less than the grace period",
+			nodeSelector:   hostnameSelector("missing-node"),
+			conditions:     unschedulableCondition(operatorv1.ConditionUnknown, time.Second),
+			expectedStatus: operatorv1.ConditionUnknown,
+			expectRequeue:  true,
+		},
+		{
+			label:             "no nodes after grace period",
+			nodeSelector:      hostnameSelector("missing-node"),
+			conditions:        unschedulableCondition(operatorv1.ConditionUnknown, time.Hour),
+			expectedStatus:    operatorv1.ConditionTrue,
+			expectedEventSent: true,
+		},
+		{
+			label:          "tainted node not tolerated",
+			nodeSelector:   hostnameSelector("node-b"),
+			conditions:     unschedulableCondition(operatorv1.ConditionFalse, time.Hour),
+			expectedStatus: operatorv1.ConditionUnknown,
+			expectRequeue:  true,
+		},
+		{
+			label:          "tainted node tolerated",
+			nodeSelector:   hostnameSelector("node-b"),
+			tolerations:    []corev1.Toleration{{Key: taint.Key, Operator: corev1.TolerationOpEqual, Value: taint.Value, Effect: taint.Effect}},
+			conditions:     unschedulableCondition(operatorv1.ConditionTrue, time.Hour),
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+	}
+
+	for _, tc := range testTable {
+		// the shared diskmaker daemonset runs for other objects, it doesn't hide an unschedulable LocalVolumeSet
+		diskmaker := &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              nodedaemon.DiskMakerName,
+				Namespace:         testNamespace,
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+			},
+			Status: appsv1.DaemonSetStatus{
+				DesiredNumberScheduled: 2,
+			},
+		}
+		nodeA := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"kubernetes.io/hostname": "node-a"}}}
+		nodeB := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{"kubernetes.io/hostname": "node-b"}},
+			Spec:       corev1.NodeSpec{Taints: []corev1.Taint{taint}},
+		}
+		lvset := &localv1alpha1.LocalVolumeSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "lvset",
+				Namespace: testNamespace,
+			},
+			Spec: localv1alpha1.LocalVolumeSetSpec{
+				NodeSelector: tc.nodeSelector,
+				Tolerations:  tc.tolerations,
+			},
+			Status: localv1alpha1.LocalVolumeSetStatus{
+				Conditions: tc.conditions,
+			},
+		}
+
+		fakeReconciler := newFakeLocalVolumeSetReconciler(t, diskmaker, nodeA, nodeB, lvset)
+		lvsetKey := types.NamespacedName{Name: lvset.GetName(), Namespace: lvset.GetNamespace()}
+		requeueAfter, err := fakeReconciler.updateDaemonSetUnschedulableCondition(reconcile.Request{NamespacedName: lvsetKey})
+		assert.NoErrorf(t, err, "[%s] updateDaemonSetUnschedulableCondition", tc.label)
+		assert.Equalf(t, tc.expectRequeue, requeueAfter > 0, "[%s] requeue", tc.label)
+
+		reconciledLVSet := &localv1alpha1.LocalVolumeSet{}
+		err = fakeReconciler.client.Get(context.TODO(), lvsetKey, reconciledLVSet)
+		assert.NoErrorf(t, err, "get lvset from fake client")
+
+		condition := findCondition(reconciledLVSet.Status.Conditions, DaemonSetUnschedulable)
+		if assert.NotNilf(t, condition, "[%s] condition should be set", tc.label) {
+			assert.Equalf(t, tc.expectedStatus, condition.Status, "[%s] condition status", tc.label)
+			if tc.expectedStatus != operatorv1.ConditionFalse {
+				assert.Containsf(t, condition.Message, tc.nodeSelector.NodeSelectorTerms[0].MatchExpressions[0].Values[0],
+					"[%s] message includes the selector", tc.label)
+			}
+		}
+
+		events := fakeReconciler.recorder.(*record.FakeRecorder).Events
+		assert.Equalf(t, tc.expectedEventSent, len(events) > 0, "[%s] event", tc.label)
+	}
+}
+
+func TestExcludedDevicesInUseCondition(t *testing.T) {
+	newPV := func(name, identity string, bound bool) *corev1.PersistentVolume {
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{common.PVDeviceIdentityAnnotation: identity},
+			},
+			Spec: corev1.PersistentVolumeSpec{StorageClassName: "sc"},
+		}
+		if bound {
+			pv.Spec.ClaimRef = &corev1.ObjectReference{Name: "claim", Namespace: testNamespace}
+		}
+		return pv
+	}
+
+	testTable := []struct {
+		label           string
+		excludeBySerial []string
+		overrideSerials []string
+		pvs             []runtime.Object
+		expectedStatus  operatorv1.ConditionStatus
+		expectedPVs     []string
+	}{
+		{
+			label:          "nothing excluded",
+			pvs:            []runtime.Object{newPV("pv-a", "S1", true)},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			label:           "excluded device is unbound",
+			excludeBySerial: []string{"S1"},
+			pvs:             []runtime.Object{newPV("pv-a", "S1", false)},
+			expectedStatus:  operatorv1.ConditionFalse,
+		},
+		{
+			label:           "excluded devices are bound",
+			excludeBySerial: []string{"S1"},
+			overrideSerials: []string{"S2"},
+			pvs:             []runtime.Object{newPV("pv-a", "S1", true), newPV("pv-b", "S2", true), newPV("pv-c", "S3", true)},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedPVs:     []string{"pv-a", "pv-b"},
+		},
+	}
+
+	for _, tc := range testTable {
+		lvset := &localv1alpha1.LocalVolumeSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "lvset",
+				Namespace: testNamespace,
+			},
+			Spec: localv1alpha1.LocalVolumeSetSpec{
+				StorageClassName:    "sc",
+				DeviceInclusionSpec: &localv1alpha1.DeviceInclusionSpec{ExcludeBySerial: tc.excludeBySerial},
+				NodeOverrides: []localv1alpha1.NodeOverride{{
+					NodeName:            "node-a",
+					DeviceInclusionSpec: localv1alpha1.DeviceInclusionSpec{ExcludeBySerial: tc.overrideSerials},
+				}},
+			},
+		}
+
+		fakeReconciler := newFakeLocalVolumeSetReconciler(t, append(tc.pvs, lvset)...)
+		lvsetKey := types.NamespacedName{Name: lvset.GetName(), Namespace: lvset.GetNamespace()}
+		err := fakeReconciler.updateExcludedDevicesCondition(reconcile.Request{NamespacedName: lvsetKey})
+		assert.NoErrorf(t, err, "[%s] updateExcludedDevicesCondition", tc.label)
+
+		reconciledLVSet := &localv1alpha1.LocalVolumeSet{}
+		err = fakeReconciler.client.Get(context.TODO(), lvsetKey, reconciledLVSet)
+		assert.NoErrorf(t, err, "get lvset from fake client")
+
+		conditionFound := false
+		for _, condition := range reconciledLVSet.Status.Conditions {
+			if condition.Type == ExcludedDevicesInUse {
+				conditionFound = true
+				assert.Equalf(t, tc.expectedStatus, condition.Status, "[%s] condition status", tc.label)
+				for _, pvName := range tc.expectedPVs {
+					assert.Containsf(t, condition.Message, pvName, "[%s] message lists the bound PVs", tc.label)
+				}
+				assert.NotContainsf(t, condition.Message, "pv-c", "[%s] message lists only excluded devices", tc.label)
+			}
+		}
+		assert.Truef(t, conditionFound, "[%s] condition should be set", tc.label)
+	}
+}
+
+func TestDuplicatePVForDeviceCondition(t *testing.T) {
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "sc"},
+	}
+	original := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-a"},
+		Spec:       corev1.PersistentVolumeSpec{StorageClassName: "sc"},
+	}
+	duplicate := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-b", Annotations: map[string]string{common.PVDuplicateOfAnnotation: "pv-a"}},
+		Spec:       corev1.PersistentVolumeSpec{StorageClassName: "sc"},
+	}
+	fakeReconciler := newFakeLocalVolumeSetReconciler(t, lvset, original, duplicate)
+	lvsetKey := types.NamespacedName{Name: lvset.GetName(), Namespace: lvset.GetNamespace()}
+	getCondition := func() *operatorv1.OperatorCondition {
+		reconciledLVSet := &localv1alpha1.LocalVolumeSet{}
+		err := fakeReconciler.client.Get(context.TODO(), lvsetKey, reconciledLVSet)
+		assert.NoError(t, err)
+		return v1helpers.FindOperatorCondition(reconciledLVSet.Status.Conditions, common.DuplicatePVForDevice)
+	}
+
+	err := fakeReconciler.updateDuplicatePVCondition(reconcile.Request{NamespacedName: lvsetKey})
+	assert.NoError(t, err)
+	condition := getCondition()
+	assert.NotNil(t, condition)
+	assert.Equal(t, operatorv1.ConditionTrue, condition.Status)
+	assert.Contains(t, condition.Message, "pv-b (duplicate of pv-a)")
+
+	// the condition is cleared once the diskmaker removes the annotation
+	delete(duplicate.Annotations, common.PVDuplicateOfAnnotation)
+	err = fakeReconciler.client.Update(context.TODO(), duplicate)
+	assert.NoError(t, err)
+	err = fakeReconciler.updateDuplicatePVCondition(reconcile.Request{NamespacedName: lvsetKey})
+	assert.NoError(t, err)
+	condition = getCondition()
+	assert.NotNil(t, condition)
+	assert.Equal(t, operatorv1.ConditionFalse, condition.Status)
+}
+
+func TestProvisioningWindowCondition(t *testing.T) {
+	now := time.Now().UTC()
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec: localv1alpha1.LocalVolumeSetSpec{
+			StorageClassName: "sc",
+			ProvisioningWindow: &localv1alpha1.ProvisioningWindow{
+				Start: now.Add(2 * time.Hour).Format("15:04"),
+				End:   now.Add(3 * time.Hour).Format("15:04"),
+			},
+		},
+	}
+	fakeReconciler := newFakeLocalVolumeSetReconciler(t, lvset)
+	lvsetKey := types.NamespacedName{Name: lvset.GetName(), Namespace: lvset.GetNamespace()}
+	getCondition := func() *operatorv1.OperatorCondition {
+		reconciledLVSet := &localv1alpha1.LocalVolumeSet{}
+		err := fakeReconciler.client.Get(context.TODO(), lvsetKey, reconciledLVSet)
+		assert.NoError(t, err)
+		return v1helpers.FindOperatorCondition(reconciledLVSet.Status.Conditions, common.ProvisioningDeferred)
+	}
+
+	requeueAfter, err := fakeReconciler.updateProvisioningWindowCondition(reconcile.Request{NamespacedName: lvsetKey})
+	assert.NoError(t, err)
+	condition := getCondition()
+	assert.NotNil(t, condition)
+	assert.Equal(t, operatorv1.ConditionTrue, condition.Status, "new devices are deferred outside of the window")
+	assert.True(t, requeueAfter > time.Hour && requeueAfter <= 2*time.Hour+time.Second, "the reconcile is requeued when the window opens, got %v", requeueAfter)
+
+	// in the window
+	lvset.Spec.ProvisioningWindow.Start = now.Add(-time.Hour).Format("15:04")
+	fakeReconciler = newFakeLocalVolumeSetReconciler(t, lvset)
+	requeueAfter, err = fakeReconciler.updateProvisioningWindowCondition(reconcile.Request{NamespacedName: lvsetKey})
+	assert.NoError(t, err)
+	condition = getCondition()
+	assert.NotNil(t, condition)
+	assert.Equal(t, operatorv1.ConditionFalse, condition.Status)
+	assert.True(t, requeueAfter > 2*time.Hour && requeueAfter <= 3*time.Hour+time.Second, "the reconcile is requeued when the window closes, got %v", requeueAfter)
+}
+
+func TestAvailabilityConditionsFatalError(t *testing.T) {
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "sc"},
+	}
+	fakeReconciler := newFakeLocalVolumeSetReconciler(t, lvset)
+	fakeReconciler.reqLogger = logf.Log.WithName(ComponentName)
+	lvsetKey := types.NamespacedName{Name: lvset.GetName(), Namespace: lvset.GetNamespace()}
+	request := reconcile.Request{NamespacedName: lvsetKey}
+	getCondition := func(conditionType string) *operatorv1.OperatorCondition {
+		reconciledLVSet := &localv1alpha1.LocalVolumeSet{}
+		err := fakeReconciler.client.Get(context.TODO(), lvsetKey, reconciledLVSet)
+		assert.NoError(t, err)
+		return v1helpers.FindOperatorCondition(reconciledLVSet.Status.Conditions, conditionType)
+	}
+
+	// a transient error is returned for the backoff of the controller
+	transient := kerrors.NewServerTimeout(schema.GroupResource{Resource: "daemonsets"}, "get", 0)
+	_, err := fakeReconciler.addAvailabilityConditions(request, reconcile.Result{}, transient)
+	assert.Equal(t, transient, err)
+	assert.Nil(t, getCondition(operatorv1.OperatorStatusTypeDegraded))
+
+	// a fatal error is not requeued and degrades the LocalVolumeSet
+	fatal := kerrors.NewBadRequest("invalid storageclass")
+	result, err := fakeReconciler.addAvailabilityConditions(request, reconcile.Result{}, fatal)
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, result)
+	condition := getCondition(operatorv1.OperatorStatusTypeDegraded)
+	assert.NotNil(t, condition)
+	assert.Equal(t, operatorv1.ConditionTrue, condition.Status)
+	assert.Contains(t, condition.Message, "invalid storageclass")
+	assert.Equal(t, operatorv1.ConditionFalse, getCondition(operatorv1.OperatorStatusTypeAvailable).Status)
+
+	// the condition is cleared by the next successful reconcile
+	_, err = fakeReconciler.addAvailabilityConditions(request, reconcile.Result{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, operatorv1.ConditionFalse, getCondition(operatorv1.OperatorStatusTypeDegraded).Status)
+	assert.Equal(t, operatorv1.ConditionTrue, getCondition(operatorv1.OperatorStatusTypeAvailable).Status)
+}
+
+func TestSelectNodes(t *testing.T) {
+	testTable := []struct {
+		label        string
+		previous     []string
+		matching     []string
+		maxNodeCount int
+		expected     []string
+	}{
+		{
+			label:        "first selection in name order",
+			matching:     []string{"node-c", "node-a", "node-d", "node-b"},
+			maxNodeCount: 2,
+			expected:     []string{"node-a", "node-b"},
+		},
+		{
+			label:        "raising maxNodeCount keeps the selected nodes",
+			previous:     []string{"node-c", "node-d"},
+			matching:     []string{"node-a", "node-b", "node-c", "node-d"},
+			maxNodeCount: 3,
+			expected:     []string{"node-a", "node-c", "node-d"},
+		},
+		{
+			label:        "nodes that no longer match are replaced",
+			previous:     []string{"node-c", "node-d"},
+			matching:     []string{"node-a", "node-b", "node-c"},
+			maxNodeCount: 2,
+			expected:     []string{"node-a", "node-c"},
+		},
+		{
+			label:        "lowering maxNodeCount",
+			previous:     []string{"node-b", "node-c", "node-d"},
+			matching:     []string{"node-a", "node-b", "node-c", "node-d"},
+			maxNodeCount: 1,
+			expected:     []string{"node-b"},
+		},
+		{
+			label:        "fewer matching nodes than maxNodeCount",
+			matching:     []string{"node-a"},
+			maxNodeCount: 3,
+			expected:     []string{"node-a"},
+		},
+		{
+			label:        "no matching nodes",
+			maxNodeCount: 3,
+		},
+	}
+	for _, tc := range testTable {
+		assert.Equalf(t, tc.expected, selectNodes(tc.previous, tc.matching, tc.maxNodeCount), "[%s] selected nodes", tc.label)
+	}
+}
+
+func TestSelectedNodesStatus(t *testing.T) {
+	maxNodeCount := int32(1)
+	lvSet := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec: localv1alpha1.LocalVolumeSetSpec{
+			StorageClassName: "sc",
+			MaxNodeCount:     &maxNodeCount,
+		},
+	}
+	nodes := []runtime.Object{
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}},
+	}
+	r := newFakeLocalVolumeSetReconciler(t, append(nodes, lvSet)...)
+	r.reqLogger = logf.Log.WithName(ComponentName)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: lvSet.Name, Namespace: lvSet.Namespace}}
+
+	err := r.updateSelectedNodesStatus(request)
+	assert.NoError(t, err)
+	updated := &localv1alpha1.LocalVolumeSet{}
+	err = r.client.Get(context.TODO(), request.NamespacedName, updated)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"node-a"}, updated.Status.SelectedNodes)
+
+	// without maxNodeCount no nodes are listed
+	updated.Spec.MaxNodeCount = nil
+	err = r.client.Update(context.TODO(), updated)
+	assert.NoError(t, err)
+	err = r.updateSelectedNodesStatus(request)
+	assert.NoError(t, err)
+	updated = &localv1alpha1.LocalVolumeSet{}
+	err = r.client.Get(context.TODO(), request.NamespacedName, updated)
+	assert.NoError(t, err)
+	assert.Empty(t, updated.Status.SelectedNodes)
+}
+
+func TestStatusSummary(t *testing.T) {
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec: localv1alpha1.LocalVolumeSetSpec{
+			StorageClassName: "sc",
+			NodeSelector: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "disks", Operator: corev1.NodeSelectorOpExists}}},
+			}},
+		},
+		Status: localv1alpha1.LocalVolumeSetStatus{
+			QuarantinedDevices: []localv1alpha1.QuarantinedDevice{{NodeName: "node-a", DevicePath: "/dev/sdb"}},
+		},
+	}
+	objects := []runtime.Object{
+		lvset,
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"disks": "ssd"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{"disks": "hdd"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-c"}},
+	}
+	for _, name := range []string{"pv-a", "pv-b", "pv-c"} {
+		objects = append(objects, &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       corev1.PersistentVolumeSpec{StorageClassName: "sc"},
+		})
+	}
+	fakeReconciler := newFakeLocalVolumeSetReconciler(t, objects...)
+	lvsetKey := types.NamespacedName{Name: lvset.GetName(), Namespace: lvset.GetNamespace()}
+
+	err := fakeReconciler.updateTotalProvisionedDeviceCountStatus(reconcile.Request{NamespacedName: lvsetKey})
+	assert.NoError(t, err)
+
+	reconciledLVSet := &localv1alpha1.LocalVolumeSet{}
+	err = fakeReconciler.client.Get(context.TODO(), lvsetKey, reconciledLVSet)
+	assert.NoError(t, err)
+	status := reconciledLVSet.Status
+	assert.Equal(t, int32(3), *status.TotalProvisionedDeviceCount)
+	assert.Equal(t, int32(2), *status.NodeCount)
+	assert.Equal(t, int32(1), *status.RejectedDeviceCount)
+	assert.Equal(t, "2 nodes, 4 devices, 3 PVs, 1 rejected", status.Summary)
+}
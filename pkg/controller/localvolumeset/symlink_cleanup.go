@@ -0,0 +1,86 @@
+package localvolumeset
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// symlinkCleanupFinalizer keeps a LocalVolumeSet that leaves its PVs to another tool
+	// until the diskmakers removed its symlinks from the nodes
+	symlinkCleanupFinalizer = "storage.openshift.com/local-volume-set-symlink-cleanup"
+	// symlinkCleanupRequeueAfter is how often a deleted LocalVolumeSet checks for nodes that still have its symlinks,
+	// which may be removed from the cluster in the meantime
+	symlinkCleanupRequeueAfter = time.Minute
+)
+
+// syncSymlinkCleanupFinalizer keeps the symlinkCleanupFinalizer while the LocalVolumeSet leaves its PVs to another tool
+// and, once it is deleted, until status.symlinkedNodes lists no existing node that matches the LocalVolumeSet.
+// It returns when to check again for a deleted LocalVolumeSet that waits for its symlinks to be removed.
+func (r *LocalVolumeSetReconciler) syncSymlinkCleanupFinalizer(request reconcile.Request) (time.Duration, error) {
+	lvSet := &localv1alpha1.LocalVolumeSet{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, lvSet)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get localvolumeset: %w", err)
+	}
+
+	var keepFinalizer bool
+	var requeueAfter time.Duration
+	if lvSet.DeletionTimestamp == nil {
+		keepFinalizer = !lvSet.ManagesPersistentVolumes() || len(lvSet.Status.SymlinkedNodes) > 0
+	} else {
+		if !hasSymlinkCleanupFinalizer(lvSet) {
+			return 0, nil
+		}
+		nodes, err := common.NodesWithSymlinks(r.client, lvSet.Status.SymlinkedNodes, lvSet.Spec.NodeSelector)
+		if err != nil {
+			return 0, err
+		}
+		if len(nodes) > 0 {
+			r.reqLogger.Info("waiting for the symlinks to be removed", "nodes", strings.Join(nodes, ", "))
+			keepFinalizer = true
+			requeueAfter = symlinkCleanupRequeueAfter
+		}
+	}
+	if hasSymlinkCleanupFinalizer(lvSet) == keepFinalizer {
+		return requeueAfter, nil
+	}
+
+	return requeueAfter, retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		err := r.client.Get(context.TODO(), request.NamespacedName, lvSet)
+		if err != nil {
+			return err
+		}
+		finalizers := []string{}
+		for _, finalizer := range lvSet.Finalizers {
+			if finalizer != symlinkCleanupFinalizer {
+				finalizers = append(finalizers, finalizer)
+			}
+		}
+		if keepFinalizer {
+			finalizers = append(finalizers, symlinkCleanupFinalizer)
+		}
+		lvSet.Finalizers = finalizers
+		return r.client.Update(context.TODO(), lvSet)
+	})
+}
+
+func hasSymlinkCleanupFinalizer(lvSet *localv1alpha1.LocalVolumeSet) bool {
+	for _, finalizer := range lvSet.Finalizers {
+		if finalizer == symlinkCleanupFinalizer {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package localvolumeset
+
+import (
+	"context"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestSyncSymlinkCleanupFinalizer(t *testing.T) {
+	managePersistentVolumes := false
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec: localv1alpha1.LocalVolumeSetSpec{
+			StorageClassName:        "sc",
+			ManagePersistentVolumes: &managePersistentVolumes,
+		},
+		Status: localv1alpha1.LocalVolumeSetStatus{SymlinkedNodes: []string{"node-a", "node-b"}},
+	}
+	nodeA := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}}
+	fakeReconciler := newFakeLocalVolumeSetReconciler(t, lvset, nodeA)
+	fakeReconciler.reqLogger = logf.Log
+	lvsetKey := types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}
+	request := reconcile.Request{NamespacedName: lvsetKey}
+	getLVSet := func() *localv1alpha1.LocalVolumeSet {
+		lvset := &localv1alpha1.LocalVolumeSet{}
+		err := fakeReconciler.client.Get(context.TODO(), lvsetKey, lvset)
+		assert.NoError(t, err)
+		return lvset
+	}
+
+	requeueAfter, err := fakeReconciler.syncSymlinkCleanupFinalizer(request)
+	assert.NoError(t, err)
+	assert.Zero(t, requeueAfter)
+	assert.Equal(t, []string{symlinkCleanupFinalizer}, getLVSet().Finalizers)
+
+	// the deleted LocalVolumeSet waits for node-a, node-b no longer exists
+	lvset = getLVSet()
+	now := metav1.Now()
+	lvset.DeletionTimestamp = &now
+	err = fakeReconciler.client.Update(context.TODO(), lvset)
+	assert.NoError(t, err)
+	requeueAfter, err = fakeReconciler.syncSymlinkCleanupFinalizer(request)
+	assert.NoError(t, err)
+	assert.Equal(t, symlinkCleanupRequeueAfter, requeueAfter)
+	assert.Equal(t, []string{symlinkCleanupFinalizer}, getLVSet().Finalizers)
+
+	// the diskmaker of node-a removed the symlinks
+	lvset = getLVSet()
+	lvset.Status.SymlinkedNodes = []string{"node-b"}
+	err = fakeReconciler.client.Status().Update(context.TODO(), lvset)
+	assert.NoError(t, err)
+	requeueAfter, err = fakeReconciler.syncSymlinkCleanupFinalizer(request)
+	assert.NoError(t, err)
+	assert.Zero(t, requeueAfter)
+	assert.Empty(t, getLVSet().Finalizers)
+}
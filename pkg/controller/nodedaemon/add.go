@@ -5,6 +5,8 @@
 package nodedaemon
 
 import (
+	"context"
+
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -17,6 +19,7 @@ import (
 	"github.com/openshift/local-storage-operator/pkg/common"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 )
 
 const (
@@ -25,8 +28,8 @@ const (
 
 // AddDaemonReconciler adds a new Controller to mgr with r as the reconcile.Reconciler
 // this controller manages creation and scheduling of the diskmaker manager and provisioner daemonsets
-func AddDaemonReconciler(mgr manager.Manager) error {
-	r := &DaemonReconciler{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+func AddDaemonReconciler(mgr manager.Manager, options common.Options) error {
+	r := &DaemonReconciler{client: mgr.GetClient(), scheme: mgr.GetScheme(), options: options}
 	// Create a new controller
 	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
 	if err != nil {
@@ -56,6 +59,32 @@ func AddDaemonReconciler(mgr manager.Manager) error {
 		return err
 	}
 
+	// watch the diskmaker poddisruptionbudget
+	err = c.Watch(&source.Kind{Type: &policyv1beta1.PodDisruptionBudget{}}, enqueueOnlyNamespace, common.EnqueueOnlyLabeledSubcomponents(DiskMakerName))
+	if err != nil {
+		return err
+	}
+
+	// watch the diskmaker pods, their errors are reported in the status of the LocalVolumes and LocalVolumeSets
+	err = c.Watch(&source.Kind{Type: &corev1.Pod{}}, enqueueOnlyNamespace, common.EnqueueOnlyLabeledSubcomponents(DiskMakerName))
+	if err != nil {
+		return err
+	}
+
+	// watch the local PVs, their capacity is summarized in the annotations of their nodes
+	err = c.Watch(&source.Kind{Type: &corev1.PersistentVolume{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			namespace, found := obj.Meta.GetLabels()[common.PVOwnerNamespaceLabel]
+			if !found {
+				return []reconcile.Request{}
+			}
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace}}}
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
 	// watch provisioner configmap
 	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, enqueueOnlyNamespace, common.EnqueueOnlyLabeledSubcomponents(common.ProvisionerConfigMapName))
 	if err != nil {
@@ -72,5 +101,36 @@ func AddDaemonReconciler(mgr manager.Manager) error {
 		return err
 	}
 
+	// enqueue the namespaces of all LocalVolumeSets and LocalVolumes when the reconcile endpoint is hit
+	err = c.Watch(&source.Channel{Source: common.SubscribeReconcileAll()}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			namespaces := map[string]bool{}
+			lvSets := &localv1alpha1.LocalVolumeSetList{}
+			err := r.client.List(context.TODO(), lvSets)
+			if err != nil {
+				log.Error(err, "failed to list LocalVolumeSets")
+			}
+			for _, lvSet := range lvSets.Items {
+				namespaces[lvSet.Namespace] = true
+			}
+			lvs := &v1.LocalVolumeList{}
+			err = r.client.List(context.TODO(), lvs)
+			if err != nil {
+				log.Error(err, "failed to list LocalVolumes")
+			}
+			for _, lv := range lvs.Items {
+				namespaces[lv.Namespace] = true
+			}
+			reqs := make([]reconcile.Request, 0, len(namespaces))
+			for namespace := range namespaces {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace}})
+			}
+			return reqs
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -11,8 +11,10 @@ import (
 
 	v1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
 	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func (r *DaemonReconciler) aggregateDeamonInfo(request reconcile.Request) (localv1alpha1.LocalVolumeSetList, v1.LocalVolumeList, []corev1.Toleration, []metav1.OwnerReference, *corev1.NodeSelector, error) {
@@ -23,6 +25,7 @@ func (r *DaemonReconciler) aggregateDeamonInfo(request reconcile.Request) (local
 		return localv1alpha1.LocalVolumeSetList{}, v1.LocalVolumeList{}, []corev1.Toleration{}, []metav1.OwnerReference{}, nil, fmt.Errorf("could not fetch localvolumeset link: %w", err)
 	}
 
+	lvSetList.Items = r.renderLVSets(request.Namespace, lvSetList.Items)
 	lvSets := lvSetList.Items
 	tolerations, ownerRefs, terms := extractLVSetInfo(lvSets)
 	lvList := v1.LocalVolumeList{}
@@ -31,6 +34,7 @@ func (r *DaemonReconciler) aggregateDeamonInfo(request reconcile.Request) (local
 		return localv1alpha1.LocalVolumeSetList{}, v1.LocalVolumeList{}, []corev1.Toleration{}, []metav1.OwnerReference{}, nil, fmt.Errorf("could not fetch localvolume link: %w", err)
 	}
 
+	lvList.Items = r.renderLVs(request.Namespace, lvList.Items)
 	lvs := lvList.Items
 	lvTolerations, lvOwnerRefs, lvTerms := extractLVInfo(lvs)
 
@@ -42,10 +46,51 @@ func (r *DaemonReconciler) aggregateDeamonInfo(request reconcile.Request) (local
 	if len(terms) > 0 {
 		nodeSelector = &corev1.NodeSelector{NodeSelectorTerms: terms}
 	}
+	nodeSelector, err = common.EffectiveNodeSelector(nodeSelector)
+	if err != nil {
+		return localv1alpha1.LocalVolumeSetList{}, v1.LocalVolumeList{}, []corev1.Toleration{}, []metav1.OwnerReference{}, nil, err
+	}
 
 	return lvSetList, lvList, tolerations, ownerRefs, nodeSelector, err
 }
 
+// renderLVSets returns the LocalVolumeSets of the namespace with the spec they contribute to the configmap and daemonsets:
+// a paused LocalVolumeSet keeps the spec it was last rendered with, so that editing it doesn't roll the daemonsets.
+// A LocalVolumeSet that is paused the first time the operator sees it contributes its current spec.
+func (r *DaemonReconciler) renderLVSets(namespace string, lvSets []localv1alpha1.LocalVolumeSet) []localv1alpha1.LocalVolumeSet {
+	previous := r.renderedLVSets[namespace]
+	rendered := map[types.UID]localv1alpha1.LocalVolumeSetSpec{}
+	for i := range lvSets {
+		if spec, found := previous[lvSets[i].UID]; found && common.IsPaused(&lvSets[i]) {
+			lvSets[i].Spec = spec
+		}
+		rendered[lvSets[i].UID] = *lvSets[i].Spec.DeepCopy()
+	}
+	if r.renderedLVSets == nil {
+		r.renderedLVSets = map[string]map[types.UID]localv1alpha1.LocalVolumeSetSpec{}
+	}
+	r.renderedLVSets[namespace] = rendered
+	return lvSets
+}
+
+// renderLVs returns the LocalVolumes of the namespace with the spec they contribute to the configmap and daemonsets,
+// a paused LocalVolume keeps the spec it was last rendered with like in renderLVSets
+func (r *DaemonReconciler) renderLVs(namespace string, lvs []v1.LocalVolume) []v1.LocalVolume {
+	previous := r.renderedLVs[namespace]
+	rendered := map[types.UID]v1.LocalVolumeSpec{}
+	for i := range lvs {
+		if spec, found := previous[lvs[i].UID]; found && common.IsPaused(&lvs[i]) {
+			lvs[i].Spec = spec
+		}
+		rendered[lvs[i].UID] = *lvs[i].Spec.DeepCopy()
+	}
+	if r.renderedLVs == nil {
+		r.renderedLVs = map[string]map[types.UID]v1.LocalVolumeSpec{}
+	}
+	r.renderedLVs[namespace] = rendered
+	return lvs
+}
+
 func extractLVSetInfo(lvsets []localv1alpha1.LocalVolumeSet) ([]corev1.Toleration, []metav1.OwnerReference, []corev1.NodeSelectorTerm) {
 	tolerations := make([]corev1.Toleration, 0)
 	ownerRefs := make([]metav1.OwnerReference, 0)
@@ -61,6 +106,15 @@ func extractLVSetInfo(lvsets []localv1alpha1.LocalVolumeSet) ([]corev1.Toleratio
 	})
 	for _, lvset := range lvsets {
 		tolerations = append(tolerations, lvset.Spec.Tolerations...)
+		// the diskmaker runs on the nodes the localvolumeset taints
+		if taint := lvset.Spec.NodeTaint; taint != nil {
+			tolerations = append(tolerations, corev1.Toleration{
+				Key:      taint.Key,
+				Operator: corev1.TolerationOpEqual,
+				Value:    taint.Value,
+				Effect:   taint.Effect,
+			})
+		}
 
 		falseVar := false
 		ownerRefs = append(ownerRefs, metav1.OwnerReference{
@@ -71,7 +125,15 @@ func extractLVSetInfo(lvsets []localv1alpha1.LocalVolumeSet) ([]corev1.Toleratio
 			Controller:         &falseVar,
 			BlockOwnerDeletion: &falseVar,
 		})
-		if lvset.Spec.NodeSelector != nil {
+		if lvset.Spec.MaxNodeCount != nil {
+			// only the nodes selected by the localvolumeset controller provision devices,
+			// none until the controller selected them
+			if len(lvset.Status.SelectedNodes) > 0 {
+				terms = append(terms, selectedNodesTerms(lvset.Status.SelectedNodes)...)
+			} else {
+				terms = append(terms, noNodesTerm())
+			}
+		} else if lvset.Spec.NodeSelector != nil {
 			terms = append(terms, lvset.Spec.NodeSelector.NodeSelectorTerms...)
 		} else {
 			matchAllNodes = true
@@ -85,6 +147,34 @@ func extractLVSetInfo(lvsets []localv1alpha1.LocalVolumeSet) ([]corev1.Toleratio
 	return tolerations, ownerRefs, terms
 }
 
+// selectedNodesTerms returns NodeSelectorTerms that match the named nodes, one per node
+// as a metadata.name field selector takes a single value
+func selectedNodesTerms(nodeNames []string) []corev1.NodeSelectorTerm {
+	terms := make([]corev1.NodeSelectorTerm, 0, len(nodeNames))
+	for _, nodeName := range nodeNames {
+		terms = append(terms, corev1.NodeSelectorTerm{
+			MatchFields: []corev1.NodeSelectorRequirement{
+				{
+					Key:      "metadata.name",
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{nodeName},
+				},
+			},
+		})
+	}
+	return terms
+}
+
+// noNodesTerm returns a NodeSelectorTerm that matches no node: its requirements contradict each other
+func noNodesTerm() corev1.NodeSelectorTerm {
+	return corev1.NodeSelectorTerm{
+		MatchExpressions: []corev1.NodeSelectorRequirement{
+			{Key: corev1.LabelHostname, Operator: corev1.NodeSelectorOpExists},
+			{Key: corev1.LabelHostname, Operator: corev1.NodeSelectorOpDoesNotExist},
+		},
+	}
+}
+
 func extractLVInfo(lvs []v1.LocalVolume) ([]corev1.Toleration, []metav1.OwnerReference, []corev1.NodeSelectorTerm) {
 	tolerations := make([]corev1.Toleration, 0)
 	ownerRefs := make([]metav1.OwnerReference, 0)
@@ -119,3 +209,48 @@ func extractLVInfo(lvs []v1.LocalVolume) ([]corev1.Toleration, []metav1.OwnerRef
 	return tolerations, ownerRefs, terms
 
 }
+
+// usesBindMount returns true if any of the LocalVolumes has devices or directories that are bind-mounted instead of symlinked
+func usesBindMount(lvs []v1.LocalVolume) bool {
+	for _, lv := range lvs {
+		for _, devices := range lv.Spec.StorageClassDevices {
+			if devices.UseBindMount || devices.SourceDir != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sourceDirPaths returns the sorted, distinct sourceDir paths of the LocalVolumes
+func sourceDirPaths(lvs []v1.LocalVolume) []string {
+	paths := map[string]bool{}
+	for _, lv := range lvs {
+		for _, devices := range lv.Spec.StorageClassDevices {
+			if devices.SourceDir != nil {
+				paths[devices.SourceDir.Path] = true
+			}
+		}
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// diskMakerLogLevel returns the verbosity of the diskmaker: the highest logLevel of the LocalVolumeSets,
+// as they share the daemonset, or defaultLevel if none of them sets it
+func diskMakerLogLevel(lvSets []localv1alpha1.LocalVolumeSet, defaultLevel int32) int32 {
+	var level *int32
+	for _, lvSet := range lvSets {
+		if lvSet.Spec.LogLevel != nil && (level == nil || *lvSet.Spec.LogLevel > *level) {
+			level = lvSet.Spec.LogLevel
+		}
+	}
+	if level == nil {
+		return defaultLevel
+	}
+	return *level
+}
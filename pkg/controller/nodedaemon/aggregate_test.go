@@ -1,13 +1,20 @@
 package nodedaemon
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
+	v1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
 	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 func TestExtractLVSetInfo(t *testing.T) {
@@ -194,3 +201,174 @@ func TestExtractLVSetInfoWithNilNodeSelector(t *testing.T) {
 	}
 
 }
+
+func TestExtractLVSetInfoWithMaxNodeCount(t *testing.T) {
+	maxNodeCount := int32(2)
+	limited := localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "limited"},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{MaxNodeCount: &maxNodeCount},
+		Status:     localv1alpha1.LocalVolumeSetStatus{SelectedNodes: []string{"node-a", "node-b"}},
+	}
+	_, _, terms := extractLVSetInfo([]localv1alpha1.LocalVolumeSet{limited})
+	assert.Equal(t, []corev1.NodeSelectorTerm{
+		{
+			MatchFields: []corev1.NodeSelectorRequirement{
+				{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-a"}},
+			},
+		},
+		{
+			MatchFields: []corev1.NodeSelectorRequirement{
+				{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-b"}},
+			},
+		},
+	}, terms, "a nil nodeSelector with maxNodeCount only matches the selected nodes")
+
+	// no nodes selected yet
+	limited.Status.SelectedNodes = nil
+	_, _, terms = extractLVSetInfo([]localv1alpha1.LocalVolumeSet{limited})
+	assert.Equal(t, []corev1.NodeSelectorTerm{noNodesTerm()}, terms, "no node matches until nodes are selected")
+	nodes := []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{corev1.LabelHostname: "node-a"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-b"}},
+	}
+	for i := range nodes {
+		matches, err := common.NodeSelectorMatchesNodeLabels(&nodes[i], &corev1.NodeSelector{NodeSelectorTerms: terms})
+		assert.NoError(t, err)
+		assert.Falsef(t, matches, "node %s should not match", nodes[i].Name)
+	}
+
+	other := localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		Spec: localv1alpha1.LocalVolumeSetSpec{
+			NodeSelector: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{}}},
+			},
+		},
+	}
+	_, _, terms = extractLVSetInfo([]localv1alpha1.LocalVolumeSet{limited, other})
+	assert.Equal(t, append([]corev1.NodeSelectorTerm{noNodesTerm()}, other.Spec.NodeSelector.NodeSelectorTerms...), terms)
+}
+
+func TestAggregatedNodeAffinityWithORedTerms(t *testing.T) {
+	lvs := []v1.LocalVolume{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "zone-a-ssd"},
+			Spec: v1.LocalVolumeSpec{
+				NodeSelector: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{
+						{
+							// nodes in zone a with SSDs that are not under maintenance
+							MatchExpressions: []corev1.NodeSelectorRequirement{
+								{Key: corev1.LabelZoneFailureDomain, Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}},
+								{Key: "disk", Operator: corev1.NodeSelectorOpIn, Values: []string{"ssd"}},
+								{Key: "maintenance", Operator: corev1.NodeSelectorOpDoesNotExist},
+							},
+						},
+						{
+							MatchFields: []corev1.NodeSelectorRequirement{
+								{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-x"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "zone-b"},
+			Spec: v1.LocalVolumeSpec{
+				NodeSelector: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{
+						{
+							MatchExpressions: []corev1.NodeSelectorRequirement{
+								{Key: corev1.LabelZoneFailureDomain, Operator: corev1.NodeSelectorOpIn, Values: []string{"b"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	_, _, terms := extractLVInfo(lvs)
+	assert.Len(t, terms, 3)
+
+	ds := &appsv1.DaemonSet{}
+	MutateAggregatedSpec(ds, reconcile.Request{}, nil, nil, &corev1.NodeSelector{NodeSelectorTerms: terms}, DiskMakerName)
+	nodeSelector := ds.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	assert.Equal(t, terms, nodeSelector.NodeSelectorTerms, "every term is passed to the DaemonSet")
+
+	zone := corev1.LabelZoneFailureDomain
+	nodes := []struct {
+		node     corev1.Node
+		expected bool
+	}{
+		{corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "a-ssd", Labels: map[string]string{zone: "a", "disk": "ssd"}}}, true},
+		{corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "a-ssd-maintenance", Labels: map[string]string{zone: "a", "disk": "ssd", "maintenance": ""}}}, false},
+		{corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "a-hdd", Labels: map[string]string{zone: "a", "disk": "hdd"}}}, false},
+		{corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-x", Labels: map[string]string{zone: "c"}}}, true},
+		{corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "b-hdd", Labels: map[string]string{zone: "b", "disk": "hdd"}}}, true},
+		{corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "c-ssd", Labels: map[string]string{zone: "c", "disk": "ssd"}}}, false},
+	}
+	for _, n := range nodes {
+		matches, err := common.NodeSelectorMatchesNodeLabels(&n.node, nodeSelector)
+		assert.NoError(t, err)
+		assert.Equalf(t, n.expected, matches, "node %q", n.node.Name)
+	}
+}
+
+func TestAggregateDaemonInfoKeepsPausedContribution(t *testing.T) {
+	scheme, err := localv1alpha1.SchemeBuilder.Build()
+	assert.NoError(t, err)
+	assert.NoError(t, v1.SchemeBuilder.AddToScheme(scheme))
+
+	namespace := "default"
+	selector := func(zone string) *corev1.NodeSelector {
+		return &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{zone}}},
+		}}}
+	}
+	lvSet := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: namespace, UID: "lvset-uid"},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{NodeSelector: selector("a")},
+	}
+	lv := &v1.LocalVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "lv", Namespace: namespace, UID: "lv-uid"},
+		Spec: v1.LocalVolumeSpec{
+			NodeSelector: selector("b"),
+			Tolerations:  []corev1.Toleration{{Key: "storage", Operator: corev1.TolerationOpExists}},
+		},
+	}
+	r := &DaemonReconciler{client: fake.NewFakeClientWithScheme(scheme, lvSet, lv), scheme: scheme}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace}}
+
+	_, _, _, _, rendered, err := r.aggregateDeamonInfo(request)
+	assert.NoError(t, err)
+
+	// pause both objects and change what they contribute
+	update := func(paused string) {
+		current := &localv1alpha1.LocalVolumeSet{}
+		assert.NoError(t, r.client.Get(context.TODO(), types.NamespacedName{Name: lvSet.Name, Namespace: namespace}, current))
+		current.Annotations = map[string]string{common.PausedAnnotation: paused}
+		current.Spec.NodeSelector = selector("c")
+		assert.NoError(t, r.client.Update(context.TODO(), current))
+		currentLV := &v1.LocalVolume{}
+		assert.NoError(t, r.client.Get(context.TODO(), types.NamespacedName{Name: lv.Name, Namespace: namespace}, currentLV))
+		currentLV.Annotations = map[string]string{common.PausedAnnotation: paused}
+		currentLV.Spec.NodeSelector = selector("d")
+		currentLV.Spec.Tolerations = nil
+		assert.NoError(t, r.client.Update(context.TODO(), currentLV))
+	}
+	update("true")
+	lvSets, lvs, tolerations, _, nodeSelector, err := r.aggregateDeamonInfo(request)
+	assert.NoError(t, err)
+	assert.Equal(t, rendered, nodeSelector, "paused objects keep their last rendered nodeSelector")
+	assert.Equal(t, lv.Spec.Tolerations, tolerations, "paused objects keep their last rendered tolerations")
+	assert.Equal(t, selector("a"), lvSets.Items[0].Spec.NodeSelector)
+	assert.Equal(t, selector("b"), lvs.Items[0].Spec.NodeSelector)
+
+	// resuming renders the current spec
+	update("false")
+	_, _, tolerations, _, nodeSelector, err = r.aggregateDeamonInfo(request)
+	assert.NoError(t, err)
+	assert.Equal(t, &corev1.NodeSelector{NodeSelectorTerms: append(selector("c").NodeSelectorTerms, selector("d").NodeSelectorTerms...)}, nodeSelector)
+	assert.Empty(t, tolerations)
+}
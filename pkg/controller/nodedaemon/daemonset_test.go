@@ -2,10 +2,15 @@ package nodedaemon
 
 import (
 	"testing"
+	"time"
 
+	v1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -40,3 +45,214 @@ func TestMutateAggregatedSpecWithNilNodeSelector(t *testing.T) {
 	assert.NotNilf(t, ds.Spec.Template.Spec.Affinity, "DaemonSet affinity should not be nil if nodeSelector is not nil")
 
 }
+
+func TestDiskMakerDaemonSetBindMountPropagation(t *testing.T) {
+	symlinkPropagation := func(ds *appsv1.DaemonSet) corev1.MountPropagationMode {
+		for _, mount := range ds.Spec.Template.Spec.Containers[0].VolumeMounts {
+			if mount.Name == common.SymlinkMount.Name {
+				return *mount.MountPropagation
+			}
+		}
+		return ""
+	}
+
+	ds := &appsv1.DaemonSet{}
+	err := getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", false, nil, 0, common.DefaultOptions())(ds)
+	assert.NoError(t, err)
+	assert.Equal(t, corev1.MountPropagationHostToContainer, symlinkPropagation(ds))
+
+	ds = &appsv1.DaemonSet{}
+	err = getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", true, nil, 0, common.DefaultOptions())(ds)
+	assert.NoError(t, err)
+	assert.Equal(t, corev1.MountPropagationBidirectional, symlinkPropagation(ds))
+}
+
+func TestDiskMakerDaemonSetProbes(t *testing.T) {
+	ds := &appsv1.DaemonSet{}
+	err := getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", false, nil, 0, common.DefaultOptions())(ds)
+	assert.NoError(t, err)
+
+	container := ds.Spec.Template.Spec.Containers[0]
+	if assert.NotNil(t, container.ReadinessProbe) {
+		assert.Equal(t, common.DiskMakerReadinessPath, container.ReadinessProbe.HTTPGet.Path)
+	}
+	if assert.NotNil(t, container.LivenessProbe) {
+		assert.Equal(t, common.DiskMakerLivenessPath, container.LivenessProbe.HTTPGet.Path)
+	}
+	if assert.Len(t, container.Ports, 2) {
+		assert.Equal(t, int32(common.DiskMakerHealthProbePort), container.Ports[0].ContainerPort)
+		assert.Equal(t, int32(common.DiskMakerMetricsPort), container.Ports[1].ContainerPort)
+	}
+}
+
+func TestDiskMakerDaemonSetSourceDirs(t *testing.T) {
+	lvs := []v1.LocalVolume{
+		{
+			Spec: v1.LocalVolumeSpec{
+				StorageClassDevices: []v1.StorageClassDevice{
+					{StorageClassName: "dirs", SourceDir: &v1.SourceDir{Path: "/var/lib/dirs", Count: 2}},
+					{StorageClassName: "more-dirs", SourceDir: &v1.SourceDir{Path: "/srv/dirs", Count: 1}},
+					{StorageClassName: "devices", DevicePaths: []string{"/dev/sdb"}},
+				},
+			},
+		},
+		{
+			Spec: v1.LocalVolumeSpec{
+				StorageClassDevices: []v1.StorageClassDevice{
+					{StorageClassName: "same-dirs", SourceDir: &v1.SourceDir{Path: "/var/lib/dirs", Count: 1}},
+				},
+			},
+		},
+	}
+	assert.True(t, usesBindMount(lvs))
+	sourceDirs := sourceDirPaths(lvs)
+	assert.Equal(t, []string{"/srv/dirs", "/var/lib/dirs"}, sourceDirs)
+
+	ds := &appsv1.DaemonSet{}
+	err := getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", usesBindMount(lvs), sourceDirs, 0, common.DefaultOptions())(ds)
+	assert.NoError(t, err)
+
+	mountPaths := map[string]string{}
+	for _, mount := range ds.Spec.Template.Spec.Containers[0].VolumeMounts {
+		mountPaths[mount.Name] = mount.MountPath
+	}
+	hostPaths := map[string]string{}
+	for _, volume := range ds.Spec.Template.Spec.Volumes {
+		if volume.HostPath != nil {
+			hostPaths[volume.Name] = volume.HostPath.Path
+		}
+	}
+	for _, name := range []string{"source-dir-0", "source-dir-1"} {
+		assert.Equal(t, hostPaths[name], mountPaths[name], "volume %s should be mounted at its host path", name)
+	}
+	assert.Equal(t, "/srv/dirs", hostPaths["source-dir-0"])
+	assert.Equal(t, "/var/lib/dirs", hostPaths["source-dir-1"])
+}
+
+func TestDiskMakerDaemonSetResyncPeriod(t *testing.T) {
+	options := common.DefaultOptions()
+	ds := &appsv1.DaemonSet{}
+	err := getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", false, nil, 0, options)(ds)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lv-manager"}, ds.Spec.Template.Spec.Containers[0].Args)
+
+	options.ResyncPeriod = 2 * time.Minute
+	ds = &appsv1.DaemonSet{}
+	err = getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", false, nil, 0, options)(ds)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lv-manager", "--resync-period=2m0s"}, ds.Spec.Template.Spec.Containers[0].Args)
+}
+
+func TestDiskMakerDaemonSetDiscoveryOnly(t *testing.T) {
+	options := common.DefaultOptions()
+	options.DiscoveryOnly = true
+	ds := &appsv1.DaemonSet{}
+	err := getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", false, nil, 0, options)(ds)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lv-manager", "--discovery-only"}, ds.Spec.Template.Spec.Containers[0].Args)
+}
+
+func TestDiskMakerDaemonSetPVNodeAffinityKey(t *testing.T) {
+	options := common.DefaultOptions()
+	options.PVNodeAffinityKey = "topology.local.csi.example.com/node"
+	ds := &appsv1.DaemonSet{}
+	err := getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", false, nil, 0, options)(ds)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lv-manager", "--pv-node-affinity-key=topology.local.csi.example.com/node"}, ds.Spec.Template.Spec.Containers[0].Args)
+}
+
+func TestDiskMakerDaemonSetPVBackupAnnotations(t *testing.T) {
+	options := common.DefaultOptions()
+	var err error
+	options.PVBackupAnnotations, options.PVBackupLabels, err = common.ParsePVBackupMetadata("backup.example.com/skip=true,backup.example.com/reason=node-local")
+	assert.NoError(t, err)
+	ds := &appsv1.DaemonSet{}
+	err = getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", false, nil, 0, options)(ds)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lv-manager", "--pv-backup-annotations=backup.example.com/reason=node-local,backup.example.com/skip=true"},
+		ds.Spec.Template.Spec.Containers[0].Args)
+
+	options.PVBackupAnnotations, options.PVBackupLabels, err = common.ParsePVBackupMetadata(common.PVBackupAnnotationsVelero)
+	assert.NoError(t, err)
+	ds = &appsv1.DaemonSet{}
+	err = getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", false, nil, 0, options)(ds)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lv-manager", "--pv-backup-annotations=velero"}, ds.Spec.Template.Spec.Containers[0].Args)
+}
+
+func TestDiskMakerDaemonSetLogLevel(t *testing.T) {
+	level := func(l int32) *int32 { return &l }
+	lvSets := []localv1alpha1.LocalVolumeSet{
+		{Spec: localv1alpha1.LocalVolumeSetSpec{LogLevel: level(2)}},
+		{Spec: localv1alpha1.LocalVolumeSetSpec{LogLevel: level(5)}},
+		{Spec: localv1alpha1.LocalVolumeSetSpec{}},
+	}
+	assert.Equal(t, int32(5), diskMakerLogLevel(lvSets, 3), "the most verbose level of the LocalVolumeSets is used")
+	assert.Equal(t, int32(3), diskMakerLogLevel(lvSets[2:], 3), "the operator's level is the default")
+	assert.Equal(t, int32(0), diskMakerLogLevel([]localv1alpha1.LocalVolumeSet{{Spec: localv1alpha1.LocalVolumeSetSpec{LogLevel: level(0)}}}, 3),
+		"a LocalVolumeSet can lower the level below the operator's")
+
+	ds := &appsv1.DaemonSet{}
+	err := getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", false, nil, diskMakerLogLevel(lvSets, 0), common.DefaultOptions())(ds)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lv-manager", "--v=5"}, ds.Spec.Template.Spec.Containers[0].Args)
+}
+
+func TestDiskMakerDaemonSetMinFilesystemDeviceSize(t *testing.T) {
+	options := common.DefaultOptions()
+	ds := &appsv1.DaemonSet{}
+	err := getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", false, nil, 0, options)(ds)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lv-manager"}, ds.Spec.Template.Spec.Containers[0].Args, "the default isn't passed")
+
+	options.MinFilesystemDeviceSize = resource.MustParse("300Mi")
+	ds = &appsv1.DaemonSet{}
+	err = getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", false, nil, 0, options)(ds)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lv-manager", "--min-filesystem-device-size=300Mi"}, ds.Spec.Template.Spec.Containers[0].Args)
+}
+
+func TestDiskMakerDaemonSetSecurityContext(t *testing.T) {
+	options := common.DefaultOptions()
+	ds := &appsv1.DaemonSet{}
+	err := getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", false, nil, 0, options)(ds)
+	assert.NoError(t, err)
+	assert.True(t, *ds.Spec.Template.Spec.Containers[0].SecurityContext.Privileged, "the diskmaker is privileged by default")
+
+	options.DiskMakerSecurityContext = common.DiskMakerSecurityContextCapabilities
+	ds = &appsv1.DaemonSet{}
+	err = getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", false, nil, 0, options)(ds)
+	assert.NoError(t, err)
+	securityContext := ds.Spec.Template.Spec.Containers[0].SecurityContext
+	assert.False(t, *securityContext.Privileged)
+	assert.Equal(t, common.DiskMakerCapabilities, securityContext.Capabilities.Add)
+	assert.Equal(t, []corev1.Capability{"ALL"}, securityContext.Capabilities.Drop)
+
+	ds = &appsv1.DaemonSet{}
+	err = getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", true, nil, 0, options)(ds)
+	assert.NoError(t, err)
+	assert.True(t, *ds.Spec.Template.Spec.Containers[0].SecurityContext.Privileged, "bidirectional mount propagation needs a privileged container")
+}
+
+func TestDiskMakerDaemonSetHostExcludeFile(t *testing.T) {
+	options := common.DefaultOptions()
+	options.HostExcludeFile = "/etc/local-storage/exclude"
+	ds := &appsv1.DaemonSet{}
+	err := getDiskMakerDSMutateFn(reconcile.Request{}, nil, nil, nil, "", false, nil, 0, options)(ds)
+	assert.NoError(t, err)
+	podSpec := ds.Spec.Template.Spec
+	assert.Equal(t, []string{"lv-manager", "--host-exclude-file=/host-exclude/exclude"}, podSpec.Containers[0].Args)
+	found := false
+	for _, volume := range podSpec.Volumes {
+		if volume.HostPath != nil && volume.HostPath.Path == "/etc/local-storage" {
+			found = true
+			for _, mount := range podSpec.Containers[0].VolumeMounts {
+				if mount.Name == volume.Name {
+					assert.Equal(t, common.HostExcludeDir, mount.MountPath)
+					assert.True(t, mount.ReadOnly, "the host exclude directory should be mounted read-only")
+				}
+			}
+		}
+	}
+	assert.True(t, found, "the directory of the host exclude file should be mounted")
+}
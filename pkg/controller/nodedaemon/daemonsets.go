@@ -3,6 +3,7 @@ package nodedaemon
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 
 	"github.com/openshift/local-storage-operator/pkg/common"
 	appsv1 "k8s.io/api/apps/v1"
@@ -61,6 +62,10 @@ func getDiskMakerDSMutateFn(
 	ownerRefs []metav1.OwnerReference,
 	nodeSelector *corev1.NodeSelector,
 	dataHash string,
+	bindMountDevices bool,
+	sourceDirs []string,
+	logLevel int32,
+	options common.Options,
 ) func(*appsv1.DaemonSet) error {
 	maxUnavailable := intstr.FromString("10%")
 
@@ -83,12 +88,102 @@ func getDiskMakerDSMutateFn(
 			return fmt.Errorf("can't add volumeMount to container, the daemonset has not specified any containers: %+v", ds)
 		}
 		ds.Spec.Template.Spec.Containers[0].VolumeMounts = append(ds.Spec.Template.Spec.Containers[0].VolumeMounts, common.UDevMount)
+		// devices bind-mounted into the symlink dir have to propagate back to the host
+		if bindMountDevices {
+			for i, mount := range ds.Spec.Template.Spec.Containers[0].VolumeMounts {
+				if mount.Name == common.SymlinkMount.Name {
+					ds.Spec.Template.Spec.Containers[0].VolumeMounts[i] = common.SymlinkBidirectionalMount
+				}
+			}
+		}
+		// directories provisioned from sourceDirs are mounted at the same path as on the host,
+		// so that the diskmaker can create their subdirectories and bind-mount them
+		for i, sourceDir := range sourceDirs {
+			volume, mount := sourceDirVolumeAndMount(i, sourceDir)
+			ds.Spec.Template.Spec.Volumes = append(ds.Spec.Template.Spec.Volumes, volume)
+			ds.Spec.Template.Spec.Containers[0].VolumeMounts = append(ds.Spec.Template.Spec.Containers[0].VolumeMounts, mount)
+		}
+		// bidirectional mount propagation is only allowed for privileged containers
+		if options.DiskMakerSecurityContext == common.DiskMakerSecurityContextCapabilities && !bindMountDevices {
+			ds.Spec.Template.Spec.Containers[0].SecurityContext = diskMakerCapabilitiesSecurityContext()
+		}
 		// add provisioner configmap hash
 		initMapIfNil(&ds.ObjectMeta.Annotations)
 		ds.ObjectMeta.Annotations[dataHashAnnotationKey] = dataHash
 		ds.Spec.Template.Spec.Containers[0].Image = common.GetDiskMakerImage()
 		ds.Spec.Template.Spec.Containers[0].ImagePullPolicy = corev1.PullIfNotPresent
 		ds.Spec.Template.Spec.Containers[0].Args = []string{"lv-manager"}
+		if options.ResyncPeriod != 0 {
+			ds.Spec.Template.Spec.Containers[0].Args = append(ds.Spec.Template.Spec.Containers[0].Args,
+				fmt.Sprintf("--%s=%v", common.ResyncPeriodFlag, options.ResyncPeriod))
+		}
+		if options.DiscoveryOnly {
+			ds.Spec.Template.Spec.Containers[0].Args = append(ds.Spec.Template.Spec.Containers[0].Args, "--"+common.DiscoveryOnlyFlag)
+		}
+		if options.PVNodeAffinityKey != corev1.LabelHostname {
+			ds.Spec.Template.Spec.Containers[0].Args = append(ds.Spec.Template.Spec.Containers[0].Args,
+				fmt.Sprintf("--%s=%s", common.PVNodeAffinityKeyFlag, options.PVNodeAffinityKey))
+		}
+		if options.MinFilesystemDeviceSize.Cmp(common.DefaultMinFilesystemDeviceSize) != 0 {
+			ds.Spec.Template.Spec.Containers[0].Args = append(ds.Spec.Template.Spec.Containers[0].Args,
+				fmt.Sprintf("--%s=%s", common.MinFilesystemDeviceSizeFlag, options.MinFilesystemDeviceSize.String()))
+		}
+		if backupAnnotations := options.PVBackupAnnotationsFlagValue(); backupAnnotations != "" {
+			ds.Spec.Template.Spec.Containers[0].Args = append(ds.Spec.Template.Spec.Containers[0].Args,
+				fmt.Sprintf("--%s=%s", common.PVBackupAnnotationsFlag, backupAnnotations))
+		}
+		if options.HostExcludeFile != "" {
+			volume, mount := hostExcludeDirVolumeAndMount(options.HostExcludeFile)
+			ds.Spec.Template.Spec.Volumes = append(ds.Spec.Template.Spec.Volumes, volume)
+			ds.Spec.Template.Spec.Containers[0].VolumeMounts = append(ds.Spec.Template.Spec.Containers[0].VolumeMounts, mount)
+			ds.Spec.Template.Spec.Containers[0].Args = append(ds.Spec.Template.Spec.Containers[0].Args,
+				fmt.Sprintf("--%s=%s", common.HostExcludeFileFlag, filepath.Join(common.HostExcludeDir, filepath.Base(options.HostExcludeFile))))
+		}
+		if logLevel > 0 {
+			ds.Spec.Template.Spec.Containers[0].Args = append(ds.Spec.Template.Spec.Containers[0].Args,
+				fmt.Sprintf("--%s=%d", common.LogLevelFlag, logLevel))
+		}
+		ds.Spec.Template.Spec.Containers[0].Ports = []corev1.ContainerPort{
+			{
+				Name:          "healthz",
+				ContainerPort: common.DiskMakerHealthProbePort,
+				Protocol:      corev1.ProtocolTCP,
+			},
+			{
+				Name:          "metrics",
+				ContainerPort: common.DiskMakerMetricsPort,
+				Protocol:      corev1.ProtocolTCP,
+			},
+		}
+		// ready once the node's devices were listed, restarted if a reconcile hangs.
+		// all fields are set so that API defaulting doesn't cause an update on every reconcile
+		ds.Spec.Template.Spec.Containers[0].ReadinessProbe = &corev1.Probe{
+			Handler: corev1.Handler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Path:   common.DiskMakerReadinessPath,
+					Port:   intstr.FromString("healthz"),
+					Scheme: corev1.URISchemeHTTP,
+				},
+			},
+			PeriodSeconds:    10,
+			TimeoutSeconds:   1,
+			SuccessThreshold: 1,
+			FailureThreshold: 3,
+		}
+		ds.Spec.Template.Spec.Containers[0].LivenessProbe = &corev1.Probe{
+			Handler: corev1.Handler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Path:   common.DiskMakerLivenessPath,
+					Port:   intstr.FromString("healthz"),
+					Scheme: corev1.URISchemeHTTP,
+				},
+			},
+			InitialDelaySeconds: 30,
+			PeriodSeconds:       30,
+			TimeoutSeconds:      1,
+			SuccessThreshold:    1,
+			FailureThreshold:    3,
+		}
 
 		// setting maxUnavailable as a percentage
 		ds.Spec.UpdateStrategy = appsv1.DaemonSetUpdateStrategy{
@@ -104,6 +199,65 @@ func getDiskMakerDSMutateFn(
 	}
 }
 
+// diskMakerCapabilitiesSecurityContext returns the securityContext of an unprivileged diskmaker container.
+// It still runs as root with the spc_t SELinux type to access the host's devices and directories.
+func diskMakerCapabilitiesSecurityContext() *corev1.SecurityContext {
+	privileged := false
+	rootUser := int64(0)
+	return &corev1.SecurityContext{
+		Privileged: &privileged,
+		RunAsUser:  &rootUser,
+		Capabilities: &corev1.Capabilities{
+			Add:  common.DiskMakerCapabilities,
+			Drop: []corev1.Capability{"ALL"},
+		},
+		SELinuxOptions: &corev1.SELinuxOptions{
+			Type: "spc_t",
+		},
+	}
+}
+
+// sourceDirVolumeAndMount returns the hostPath volume and mount of the i-th sourceDir of the diskmaker daemonset
+func sourceDirVolumeAndMount(i int, path string) (corev1.Volume, corev1.VolumeMount) {
+	name := fmt.Sprintf("source-dir-%d", i)
+	hostPathType := corev1.HostPathDirectoryOrCreate
+	volume := corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: path,
+				Type: &hostPathType,
+			},
+		},
+	}
+	mount := corev1.VolumeMount{
+		Name:      name,
+		MountPath: path,
+	}
+	return volume, mount
+}
+
+// hostExcludeDirVolumeAndMount returns the read-only volume and mount of the host directory of the host exclude file
+func hostExcludeDirVolumeAndMount(hostExcludeFile string) (corev1.Volume, corev1.VolumeMount) {
+	name := "host-exclude-dir"
+	hostPathType := corev1.HostPathDirectoryOrCreate
+	volume := corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: filepath.Dir(hostExcludeFile),
+				Type: &hostPathType,
+			},
+		},
+	}
+	mount := corev1.VolumeMount{
+		Name:      name,
+		MountPath: common.HostExcludeDir,
+		ReadOnly:  true,
+	}
+	return volume, mount
+}
+
 // Local Provisioner Daemonset
 // to be consumed by createOrUpdateDaemonset
 func getLocalProvisionerDSMutateFn(
@@ -0,0 +1,138 @@
+package nodedaemon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift/local-storage-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// nodeCapacity is the capacity of the local PVs of a node
+type nodeCapacity struct {
+	total     resource.Quantity
+	available resource.Quantity
+}
+
+func newNodeCapacity() *nodeCapacity {
+	return &nodeCapacity{total: *resource.NewQuantity(0, resource.BinarySI), available: *resource.NewQuantity(0, resource.BinarySI)}
+}
+
+// annotations returns the capacity annotations of the node
+func (c nodeCapacity) annotations() map[string]string {
+	return map[string]string{
+		common.NodeTotalCapacityAnnotation:     c.total.String(),
+		common.NodeAvailableCapacityAnnotation: c.available.String(),
+	}
+}
+
+// nodeCapacities returns the capacity of the PVs by the hostname label of their node.
+// PVs that are being deleted are not counted.
+func nodeCapacities(pvs []corev1.PersistentVolume) map[string]*nodeCapacity {
+	capacities := map[string]*nodeCapacity{}
+	for _, pv := range pvs {
+		hostname := pv.Labels[corev1.LabelHostname]
+		if hostname == "" || pv.DeletionTimestamp != nil {
+			continue
+		}
+		capacity, found := capacities[hostname]
+		if !found {
+			capacity = newNodeCapacity()
+			capacities[hostname] = capacity
+		}
+		storage := pv.Spec.Capacity[corev1.ResourceStorage]
+		capacity.total.Add(storage)
+		if pv.Status.Phase == corev1.VolumeAvailable {
+			capacity.available.Add(storage)
+		}
+	}
+	return capacities
+}
+
+// updateNodeCapacities annotates the nodes that match nodeSelector with the total and available capacity of the local PVs
+// of the LocalVolumes and LocalVolumeSets of the namespace, and removes the annotations from the other nodes.
+// A nil nodeSelector matches no node, once the last LocalVolume or LocalVolumeSet is removed.
+func (r *DaemonReconciler) updateNodeCapacities(namespace string, nodeSelector *corev1.NodeSelector) error {
+	pvs := &corev1.PersistentVolumeList{}
+	err := r.client.List(context.TODO(), pvs, client.MatchingLabels{common.PVOwnerNamespaceLabel: namespace})
+	if err != nil {
+		return fmt.Errorf("failed to list the local PVs: %w", err)
+	}
+	capacities := nodeCapacities(pvs.Items)
+
+	nodes := &corev1.NodeList{}
+	err = r.client.List(context.TODO(), nodes)
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		matches := false
+		if nodeSelector != nil {
+			matches, err = common.NodeSelectorMatchesNodeLabels(node, nodeSelector)
+			if err != nil {
+				return err
+			}
+		}
+		var annotations map[string]string
+		if matches {
+			capacity, found := capacities[node.Labels[corev1.LabelHostname]]
+			if !found {
+				capacity = newNodeCapacity()
+			}
+			annotations = capacity.annotations()
+		}
+		if !nodeCapacityChanged(node, annotations) {
+			continue
+		}
+		err = r.updateNodeCapacityAnnotations(node.Name, annotations)
+		if err != nil {
+			return fmt.Errorf("failed to update the capacity annotations of node %q: %w", node.Name, err)
+		}
+	}
+	return nil
+}
+
+// nodeCapacityChanged returns true if the capacity annotations of the node differ from annotations,
+// nil meaning that the node has none
+func nodeCapacityChanged(node *corev1.Node, annotations map[string]string) bool {
+	for _, key := range []string{common.NodeTotalCapacityAnnotation, common.NodeAvailableCapacityAnnotation} {
+		value, found := node.Annotations[key]
+		expected, expectedFound := annotations[key]
+		if found != expectedFound || value != expected {
+			return true
+		}
+	}
+	return false
+}
+
+// updateNodeCapacityAnnotations sets the capacity annotations of the node, or removes them if annotations is nil
+func (r *DaemonReconciler) updateNodeCapacityAnnotations(nodeName string, annotations map[string]string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node := &corev1.Node{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: nodeName}, node)
+		if err != nil {
+			return err
+		}
+		if !nodeCapacityChanged(node, annotations) {
+			return nil
+		}
+		if annotations == nil {
+			delete(node.Annotations, common.NodeTotalCapacityAnnotation)
+			delete(node.Annotations, common.NodeAvailableCapacityAnnotation)
+		} else {
+			if node.Annotations == nil {
+				node.Annotations = map[string]string{}
+			}
+			for key, value := range annotations {
+				node.Annotations[key] = value
+			}
+		}
+		r.reqLogger.Info("updating node capacity annotations", "node", nodeName, "capacity", annotations)
+		return r.client.Update(context.TODO(), node)
+	})
+}
@@ -0,0 +1,81 @@
+package nodedaemon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestUpdateNodeCapacities(t *testing.T) {
+	namespace := "local-storage"
+	newNode := func(name, zone string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"zone": zone, corev1.LabelHostname: name},
+		}}
+	}
+	newPV := func(name, hostname, ownerNamespace, size string, phase corev1.PersistentVolumePhase) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{corev1.LabelHostname: hostname, common.PVOwnerNamespaceLabel: ownerNamespace},
+			},
+			Spec:   corev1.PersistentVolumeSpec{Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}},
+			Status: corev1.PersistentVolumeStatus{Phase: phase},
+		}
+	}
+	objects := []runtime.Object{
+		newNode("node-a", "a"), newNode("node-b", "a"), newNode("node-c", "b"),
+		newPV("pv-1", "node-a", namespace, "1Ti", corev1.VolumeAvailable),
+		newPV("pv-2", "node-a", namespace, "512Gi", corev1.VolumeBound),
+		newPV("pv-3", "node-a", namespace, "512Gi", corev1.VolumeReleased),
+		// PVs of another namespace and of unmatched nodes are not counted
+		newPV("pv-4", "node-a", "other", "1Ti", corev1.VolumeAvailable),
+		newPV("pv-5", "node-c", namespace, "1Ti", corev1.VolumeAvailable),
+	}
+	r := &DaemonReconciler{client: fake.NewFakeClient(objects...), reqLogger: logf.Log}
+	zoneA := &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+		MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}},
+	}}}
+
+	nodeAnnotations := func(name string) map[string]string {
+		node := &corev1.Node{}
+		assert.NoError(t, r.client.Get(context.TODO(), types.NamespacedName{Name: name}, node))
+		return node.Annotations
+	}
+
+	assert.NoError(t, r.updateNodeCapacities(namespace, zoneA))
+	assert.Equal(t, map[string]string{
+		common.NodeTotalCapacityAnnotation:     "2Ti",
+		common.NodeAvailableCapacityAnnotation: "1Ti",
+	}, nodeAnnotations("node-a"))
+	assert.Equal(t, map[string]string{
+		common.NodeTotalCapacityAnnotation:     "0",
+		common.NodeAvailableCapacityAnnotation: "0",
+	}, nodeAnnotations("node-b"), "a matched node without PVs has no capacity")
+	assert.Empty(t, nodeAnnotations("node-c"))
+
+	// the annotations follow the PVs
+	pv := &corev1.PersistentVolume{}
+	assert.NoError(t, r.client.Get(context.TODO(), types.NamespacedName{Name: "pv-1"}, pv))
+	pv.Status.Phase = corev1.VolumeBound
+	assert.NoError(t, r.client.Update(context.TODO(), pv))
+	assert.NoError(t, r.client.Delete(context.TODO(), newPV("pv-3", "node-a", namespace, "512Gi", corev1.VolumeReleased)))
+	assert.NoError(t, r.updateNodeCapacities(namespace, zoneA))
+	assert.Equal(t, "1536Gi", nodeAnnotations("node-a")[common.NodeTotalCapacityAnnotation])
+	assert.Equal(t, "0", nodeAnnotations("node-a")[common.NodeAvailableCapacityAnnotation])
+
+	// the annotations are removed once no LocalVolume or LocalVolumeSet matches the nodes
+	assert.NoError(t, r.updateNodeCapacities(namespace, nil))
+	assert.Empty(t, nodeAnnotations("node-a"))
+	assert.Empty(t, nodeAnnotations("node-b"))
+}
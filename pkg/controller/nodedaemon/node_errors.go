@@ -0,0 +1,181 @@
+package nodedaemon
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxNodeErrorMessageLength keeps the status of objects with many failing nodes small
+const maxNodeErrorMessageLength = 1024
+
+// startingReasons are the waiting reasons of containers that are starting normally
+var startingReasons = map[string]bool{
+	"ContainerCreating": true,
+	"PodInitializing":   true,
+}
+
+// containerError returns the error of a container of a diskmaker pod, failed is false if it's running or starting.
+// A crashing container is waiting in CrashLoopBackOff, the message of its last termination is the latest error.
+func containerError(status corev1.ContainerStatus) (reason, message string, failed bool) {
+	if waiting := status.State.Waiting; waiting != nil && !startingReasons[waiting.Reason] {
+		reason = waiting.Reason
+		message = fmt.Sprintf("container %s is waiting", status.Name)
+		if waiting.Message != "" {
+			message = fmt.Sprintf("%s: %s", message, waiting.Message)
+		}
+		if terminated := status.LastTerminationState.Terminated; terminated != nil {
+			message = fmt.Sprintf("%s; last exit code %d", message, terminated.ExitCode)
+			if terminated.Message != "" {
+				message = fmt.Sprintf("%s: %s", message, strings.TrimSpace(terminated.Message))
+			}
+		}
+		return reason, message, true
+	}
+	if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+		message = fmt.Sprintf("container %s terminated with exit code %d", status.Name, terminated.ExitCode)
+		if terminated.Message != "" {
+			message = fmt.Sprintf("%s: %s", message, strings.TrimSpace(terminated.Message))
+		}
+		return terminated.Reason, message, true
+	}
+	return "", "", false
+}
+
+// diskMakerNodeErrors returns the latest error of the failing diskmaker pods of the namespace, by node name
+func (r *DaemonReconciler) diskMakerNodeErrors(namespace string) (map[string]localv1.NodeError, error) {
+	pods := &corev1.PodList{}
+	err := r.client.List(context.TODO(), pods, client.InNamespace(namespace), client.MatchingLabels{appLabelKey: DiskMakerName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the %s pods: %w", DiskMakerName, err)
+	}
+	nodeErrors := map[string]localv1.NodeError{}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			reason, message, failed := containerError(status)
+			if !failed {
+				continue
+			}
+			if len(message) > maxNodeErrorMessageLength {
+				message = message[:maxNodeErrorMessageLength]
+			}
+			nodeErrors[pod.Spec.NodeName] = localv1.NodeError{
+				NodeName: pod.Spec.NodeName,
+				Reason:   reason,
+				Message:  fmt.Sprintf("pod %s: %s", pod.Name, message),
+			}
+			break
+		}
+	}
+	return nodeErrors, nil
+}
+
+// nodesWithErrors returns the nodeErrors of the nodes matching nodeSelector, sorted by node name.
+// The lastTransitionTime of a node whose reason didn't change is kept from current.
+func nodesWithErrors(current []localv1.NodeError, nodeErrors map[string]localv1.NodeError, nodes []corev1.Node, nodeSelector *corev1.NodeSelector) ([]localv1.NodeError, error) {
+	effectiveSelector, err := common.EffectiveNodeSelector(nodeSelector)
+	if err != nil {
+		return nil, err
+	}
+	previous := map[string]localv1.NodeError{}
+	for _, nodeError := range current {
+		previous[nodeError.NodeName] = nodeError
+	}
+	var updated []localv1.NodeError
+	for i := range nodes {
+		nodeError, found := nodeErrors[nodes[i].Name]
+		if !found {
+			continue
+		}
+		matches, err := common.NodeSelectorMatchesNodeLabels(&nodes[i], effectiveSelector)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			continue
+		}
+		if old, found := previous[nodeError.NodeName]; found && old.Reason == nodeError.Reason {
+			nodeError.LastTransitionTime = old.LastTransitionTime
+		} else {
+			nodeError.LastTransitionTime = metav1.Now()
+		}
+		updated = append(updated, nodeError)
+	}
+	sort.Slice(updated, func(i, j int) bool { return updated[i].NodeName < updated[j].NodeName })
+	return updated, nil
+}
+
+// updateNodesWithErrors writes the errors of the failing diskmaker pods to the status.nodesWithErrors
+// of every LocalVolume and LocalVolumeSet, for the nodes they select
+func (r *DaemonReconciler) updateNodesWithErrors(namespace string, lvSets []localv1alpha1.LocalVolumeSet, lvs []localv1.LocalVolume) error {
+	nodeErrors, err := r.diskMakerNodeErrors(namespace)
+	if err != nil {
+		return err
+	}
+	nodes := &corev1.NodeList{}
+	err = r.client.List(context.TODO(), nodes)
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	for _, lvSet := range lvSets {
+		key := types.NamespacedName{Name: lvSet.Name, Namespace: lvSet.Namespace}
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			current := &localv1alpha1.LocalVolumeSet{}
+			err := r.client.Get(context.TODO(), key, current)
+			if err != nil {
+				return err
+			}
+			updated, err := nodesWithErrors(current.Status.NodesWithErrors, nodeErrors, nodes.Items, current.Spec.NodeSelector)
+			if err != nil || reflect.DeepEqual(current.Status.NodesWithErrors, updated) {
+				return err
+			}
+			current.Status.NodesWithErrors = updated
+			return r.client.Status().Update(context.TODO(), current)
+		})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to update the nodes with errors of localvolumeset %q: %w", key, err)
+		}
+	}
+
+	for _, lv := range lvs {
+		key := types.NamespacedName{Name: lv.Name, Namespace: lv.Namespace}
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			current := &localv1.LocalVolume{}
+			err := r.client.Get(context.TODO(), key, current)
+			if err != nil {
+				return err
+			}
+			updated, err := nodesWithErrors(current.Status.NodesWithErrors, nodeErrors, nodes.Items, current.Spec.NodeSelector)
+			if err != nil || reflect.DeepEqual(current.Status.NodesWithErrors, updated) {
+				return err
+			}
+			current.Status.NodesWithErrors = updated
+			return r.client.Status().Update(context.TODO(), current)
+		})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to update the nodes with errors of localvolume %q: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package nodedaemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUpdateNodesWithErrors(t *testing.T) {
+	scheme, err := localv1alpha1.SchemeBuilder.Build()
+	assert.NoError(t, err)
+	assert.NoError(t, localv1.SchemeBuilder.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+
+	namespace := "default"
+	newNode := func(name, zone string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"zone": zone}}}
+	}
+	newPod := func(name, nodeName string, status corev1.ContainerStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{appLabelKey: DiskMakerName}},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+			Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{status}},
+		}
+	}
+	zoneA := &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+		MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}},
+	}}}
+	lvSet := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: namespace},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{NodeSelector: zoneA},
+	}
+	lv := &localv1.LocalVolume{ObjectMeta: metav1.ObjectMeta{Name: "lv", Namespace: namespace}}
+	crashing := corev1.ContainerStatus{
+		Name:                 DiskMakerName,
+		State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off 5m0s restarting failed container"}},
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "failed to list block devices\n"}},
+	}
+	pullFailing := corev1.ContainerStatus{
+		Name:  DiskMakerName,
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+	}
+	starting := corev1.ContainerStatus{
+		Name:  DiskMakerName,
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+	}
+	running := corev1.ContainerStatus{
+		Name:  DiskMakerName,
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}
+	objects := []runtime.Object{
+		lvSet, lv,
+		newNode("node-a", "a"), newNode("node-b", "b"), newNode("node-c", "a"), newNode("node-d", "a"),
+		newPod("diskmaker-a", "node-a", crashing),
+		newPod("diskmaker-b", "node-b", pullFailing),
+		newPod("diskmaker-c", "node-c", starting),
+		newPod("diskmaker-d", "node-d", running),
+	}
+	r := &DaemonReconciler{client: fake.NewFakeClientWithScheme(scheme, objects...), scheme: scheme}
+
+	err = r.updateNodesWithErrors(namespace, []localv1alpha1.LocalVolumeSet{*lvSet}, []localv1.LocalVolume{*lv})
+	assert.NoError(t, err)
+	currentLVSet := &localv1alpha1.LocalVolumeSet{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: lvSet.Name, Namespace: namespace}, currentLVSet)
+	assert.NoError(t, err)
+	if assert.Len(t, currentLVSet.Status.NodesWithErrors, 1, "node-b doesn't match the node selector") {
+		nodeError := currentLVSet.Status.NodesWithErrors[0]
+		assert.Equal(t, "node-a", nodeError.NodeName)
+		assert.Equal(t, "CrashLoopBackOff", nodeError.Reason)
+		assert.Equal(t, "pod diskmaker-a: container diskmaker-manager is waiting: back-off 5m0s restarting failed container; last exit code 1: failed to list block devices", nodeError.Message)
+		assert.False(t, nodeError.LastTransitionTime.IsZero())
+	}
+	currentLV := &localv1.LocalVolume{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: lv.Name, Namespace: namespace}, currentLV)
+	assert.NoError(t, err)
+	nodes := []string{}
+	for _, nodeError := range currentLV.Status.NodesWithErrors {
+		nodes = append(nodes, nodeError.NodeName)
+	}
+	assert.Equal(t, []string{"node-a", "node-b"}, nodes)
+
+	// the error of node-a is kept with its lastTransitionTime until its pod recovers
+	lastTransitionTime := metav1.NewTime(currentLVSet.Status.NodesWithErrors[0].LastTransitionTime.Add(-time.Hour))
+	currentLVSet.Status.NodesWithErrors[0].LastTransitionTime = lastTransitionTime
+	assert.NoError(t, r.client.Status().Update(context.TODO(), currentLVSet))
+	err = r.updateNodesWithErrors(namespace, []localv1alpha1.LocalVolumeSet{*lvSet}, nil)
+	assert.NoError(t, err)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: lvSet.Name, Namespace: namespace}, currentLVSet)
+	assert.NoError(t, err)
+	assert.True(t, lastTransitionTime.Equal(&currentLVSet.Status.NodesWithErrors[0].LastTransitionTime))
+
+	pod := &corev1.Pod{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: "diskmaker-a", Namespace: namespace}, pod)
+	assert.NoError(t, err)
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{running}
+	assert.NoError(t, r.client.Update(context.TODO(), pod))
+	err = r.updateNodesWithErrors(namespace, []localv1alpha1.LocalVolumeSet{*lvSet}, nil)
+	assert.NoError(t, err)
+	currentLVSet = &localv1alpha1.LocalVolumeSet{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: lvSet.Name, Namespace: namespace}, currentLVSet)
+	assert.NoError(t, err)
+	assert.Empty(t, currentLVSet.Status.NodesWithErrors)
+}
@@ -0,0 +1,79 @@
+package nodedaemon
+
+import (
+	"context"
+	"fmt"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// diskMakerMinAvailable returns the strictest diskMakerMinAvailable of the LocalVolumeSets,
+// comparing percentages by the number of pods they keep out of the desired pods of the daemonset.
+// It returns nil if none of them sets it.
+func diskMakerMinAvailable(lvSets []localv1alpha1.LocalVolumeSet, desiredPods int32) (*intstr.IntOrString, error) {
+	var strictest *intstr.IntOrString
+	strictestPods := -1
+	for _, lvSet := range lvSets {
+		minAvailable := lvSet.Spec.DiskMakerMinAvailable
+		if minAvailable == nil {
+			continue
+		}
+		pods, err := intstr.GetValueFromIntOrPercent(minAvailable, int(desiredPods), true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid diskMakerMinAvailable %q of localvolumeset %q: %w", minAvailable.String(), lvSet.Name, err)
+		}
+		if pods > strictestPods {
+			strictest = minAvailable
+			strictestPods = pods
+		}
+	}
+	return strictest, nil
+}
+
+// reconcileDiskMakerPDB creates or updates the PodDisruptionBudget of the diskmaker pods
+// when a LocalVolumeSet sets diskMakerMinAvailable, and deletes it otherwise
+func (r *DaemonReconciler) reconcileDiskMakerPDB(
+	ds *appsv1.DaemonSet,
+	lvSets []localv1alpha1.LocalVolumeSet,
+	ownerRefs []metav1.OwnerReference,
+) (controllerutil.OperationResult, error) {
+	minAvailable, err := diskMakerMinAvailable(lvSets, ds.Status.DesiredNumberScheduled)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: DiskMakerName, Namespace: ds.Namespace},
+	}
+	if minAvailable == nil {
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: pdb.Name, Namespace: pdb.Namespace}, pdb)
+		if errors.IsNotFound(err) {
+			return controllerutil.OperationResultNone, nil
+		} else if err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+		err = r.client.Delete(context.TODO(), pdb)
+		if err != nil && !errors.IsNotFound(err) {
+			return controllerutil.OperationResultNone, err
+		}
+		return controllerutil.OperationResultUpdated, nil
+	}
+
+	return controllerutil.CreateOrUpdate(context.TODO(), r.client, pdb, func() error {
+		initMapIfNil(&pdb.ObjectMeta.Labels)
+		pdb.ObjectMeta.Labels[appLabelKey] = DiskMakerName
+		pdb.ObjectMeta.OwnerReferences = ownerRefs
+		pdb.Spec.MinAvailable = minAvailable
+		pdb.Spec.Selector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{appLabelKey: DiskMakerName},
+		}
+		return nil
+	})
+}
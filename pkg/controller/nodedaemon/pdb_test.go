@@ -0,0 +1,73 @@
+package nodedaemon
+
+import (
+	"context"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDiskMakerMinAvailable(t *testing.T) {
+	minAvailable := func(value intstr.IntOrString) localv1alpha1.LocalVolumeSet {
+		return localv1alpha1.LocalVolumeSet{Spec: localv1alpha1.LocalVolumeSetSpec{DiskMakerMinAvailable: &value}}
+	}
+
+	strictest, err := diskMakerMinAvailable([]localv1alpha1.LocalVolumeSet{{}}, 10)
+	assert.NoError(t, err)
+	assert.Nil(t, strictest, "no PodDisruptionBudget unless a LocalVolumeSet opts in")
+
+	lvSets := []localv1alpha1.LocalVolumeSet{{}, minAvailable(intstr.FromInt(6)), minAvailable(intstr.FromString("75%"))}
+	strictest, err = diskMakerMinAvailable(lvSets, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, intstr.FromString("75%"), *strictest, "75% of 10 pods keeps 8 pods")
+	strictest, err = diskMakerMinAvailable(lvSets, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, intstr.FromInt(6), *strictest, "75% of 4 pods keeps 3 pods")
+
+	_, err = diskMakerMinAvailable([]localv1alpha1.LocalVolumeSet{minAvailable(intstr.FromString("many"))}, 10)
+	assert.Error(t, err)
+}
+
+func TestReconcileDiskMakerPDB(t *testing.T) {
+	scheme, err := localv1alpha1.SchemeBuilder.Build()
+	assert.NoError(t, err)
+	assert.NoError(t, appsv1.AddToScheme(scheme))
+	assert.NoError(t, policyv1beta1.AddToScheme(scheme))
+
+	namespace := "default"
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: DiskMakerName, Namespace: namespace},
+		Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 3},
+	}
+	minAvailable := intstr.FromInt(2)
+	lvSets := []localv1alpha1.LocalVolumeSet{{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: namespace},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{DiskMakerMinAvailable: &minAvailable},
+	}}
+	ownerRefs := []metav1.OwnerReference{{Kind: localv1alpha1.LocalVolumeSetKind, Name: "lvset"}}
+	r := &DaemonReconciler{client: fake.NewFakeClientWithScheme(scheme, ds), scheme: scheme}
+	key := types.NamespacedName{Name: DiskMakerName, Namespace: namespace}
+
+	_, err = r.reconcileDiskMakerPDB(ds, lvSets, ownerRefs)
+	assert.NoError(t, err)
+	pdb := &policyv1beta1.PodDisruptionBudget{}
+	err = r.client.Get(context.TODO(), key, pdb)
+	assert.NoError(t, err)
+	assert.Equal(t, minAvailable, *pdb.Spec.MinAvailable)
+	assert.Equal(t, map[string]string{appLabelKey: DiskMakerName}, pdb.Spec.Selector.MatchLabels, "the PodDisruptionBudget selects the diskmaker pods")
+	assert.Equal(t, ownerRefs, pdb.OwnerReferences)
+
+	lvSets[0].Spec.DiskMakerMinAvailable = nil
+	_, err = r.reconcileDiskMakerPDB(ds, lvSets, ownerRefs)
+	assert.NoError(t, err)
+	err = r.client.Get(context.TODO(), key, pdb)
+	assert.True(t, errors.IsNotFound(err), "the PodDisruptionBudget is deleted when no LocalVolumeSet sets diskMakerMinAvailable")
+}
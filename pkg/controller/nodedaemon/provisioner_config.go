@@ -6,14 +6,20 @@ import (
 	"encoding/hex"
 	"fmt"
 	"path"
+	"reflect"
 	"sort"
 	"strings"
+	"time"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	localStaticProvisioner "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/common"
 
 	v1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
@@ -21,65 +27,210 @@ import (
 	"github.com/openshift/local-storage-operator/pkg/common"
 )
 
+// cleanupKillGracePeriod is how long a block cleanup may overrun the cleanupTimeout before it is killed
+const cleanupKillGracePeriod = 2 * time.Minute
+
+// provisionerConfigFieldManager is the field manager of the server-side applies of the provisioner ConfigMap.
+// The operator owns only the labels, owner references and data keys it applies, so the keys and labels that other
+// tools add to the ConfigMap are kept.
+const provisionerConfigFieldManager = "local-storage-operator"
+
 func (r *DaemonReconciler) reconcileProvisionerConfigMap(
 	request reconcile.Request,
 	lvSets []localv1alpha1.LocalVolumeSet,
 	lvs []v1.LocalVolume,
 	ownerRefs []metav1.OwnerReference,
 ) (*corev1.ConfigMap, controllerutil.OperationResult, error) {
-	// object meta
-	objectMeta := metav1.ObjectMeta{
-		Name:      common.ProvisionerConfigMapName,
-		Namespace: request.Namespace,
-		Labels:    map[string]string{"app": common.ProvisionerConfigMapName},
+	configMap, err := provisionerConfigMap(request.Namespace, lvSets, lvs, ownerRefs)
+	if err != nil {
+		return nil, controllerutil.OperationResultNone, err
+	}
+
+	existing := &corev1.ConfigMap{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, existing)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return nil, controllerutil.OperationResultNone, err
+	}
+	found := err == nil
+
+	// server-side apply, forcing the ownership of the applied fields: the operator is the only source of the
+	// storageClass configs, a storageClass removed from the applied config is removed from the ConfigMap
+	err = r.client.Patch(context.TODO(), configMap, client.Apply, client.FieldOwner(provisionerConfigFieldManager), client.ForceOwnership)
+	if err != nil {
+		return nil, controllerutil.OperationResultNone, fmt.Errorf("failed to apply the provisioner configmap: %w", err)
 	}
-	configMap := &corev1.ConfigMap{ObjectMeta: objectMeta}
 
-	// config data
+	opResult := controllerutil.OperationResultNone
+	if !found {
+		opResult = controllerutil.OperationResultCreated
+	} else if existing.ResourceVersion != configMap.ResourceVersion {
+		opResult = controllerutil.OperationResultUpdated
+	}
+	if opResult != controllerutil.OperationResultNone {
+		r.logProvisionerConfigChanges(existing.Data, configMap.Data)
+	}
+	return configMap, opResult, nil
+}
+
+// provisionerConfigMap returns the provisioner ConfigMap to apply, with the configs of the storageClasses
+// of the LocalVolumeSets and LocalVolumes
+func provisionerConfigMap(
+	namespace string,
+	lvSets []localv1alpha1.LocalVolumeSet,
+	lvs []v1.LocalVolume,
+	ownerRefs []metav1.OwnerReference,
+) (*corev1.ConfigMap, error) {
 	storageClassConfig := make(map[string]localStaticProvisioner.MountConfig)
+	provisionerConfigs := []map[string]string{}
 	for _, lvSet := range lvSets {
-		storageClassName := lvSet.Spec.StorageClassName
-		symlinkDir := path.Join(common.GetLocalDiskLocationPath(), storageClassName)
-		mountConfig := localStaticProvisioner.MountConfig{
-			FsType:     lvSet.Spec.FSType,
-			HostDir:    symlinkDir,
-			MountDir:   symlinkDir,
-			VolumeMode: string(lvSet.Spec.VolumeMode),
+		provisionerConfigs = append(provisionerConfigs, lvSet.Spec.ProvisionerConfig)
+		for _, storageClassName := range lvSet.StorageClassNames() {
+			symlinkDir := path.Join(common.GetLocalDiskLocationPath(), storageClassName)
+			mountConfig := localStaticProvisioner.MountConfig{
+				FsType:              lvSet.Spec.FSType,
+				HostDir:             symlinkDir,
+				MountDir:            symlinkDir,
+				VolumeMode:          string(lvSet.Spec.VolumeMode),
+				BlockCleanerCommand: blockCleanerCommand(lvSet.Spec.CleanupTimeout),
+			}
+			storageClassConfig[storageClassName] = mountConfig
 		}
-		storageClassConfig[storageClassName] = mountConfig
 	}
 	for _, lv := range lvs {
+		provisionerConfigs = append(provisionerConfigs, lv.Spec.ProvisionerConfig)
 		for _, devices := range lv.Spec.StorageClassDevices {
 			storageClassName := devices.StorageClassName
 			symlinkDir := path.Join(common.GetLocalDiskLocationPath(), storageClassName)
 			mountConfig := localStaticProvisioner.MountConfig{
-				FsType:     devices.FSType,
-				HostDir:    symlinkDir,
-				MountDir:   symlinkDir,
-				VolumeMode: string(devices.VolumeMode),
+				FsType:              devices.FSType,
+				HostDir:             symlinkDir,
+				MountDir:            symlinkDir,
+				VolumeMode:          string(devices.VolumeMode),
+				BlockCleanerCommand: blockCleanerCommand(lv.Spec.CleanupTimeout),
 			}
 			storageClassConfig[storageClassName] = mountConfig
 		}
 	}
-	// create or update
-	opResult, err := controllerutil.CreateOrUpdate(context.TODO(), r.client, configMap, func() error {
-		if configMap.CreationTimestamp.IsZero() {
-			configMap.ObjectMeta = objectMeta
+	data, err := localStaticProvisioner.VolumeConfigToConfigMapData(&localStaticProvisioner.ProvisionerConfiguration{
+		StorageClassConfig: storageClassConfig,
+		NodeLabelsForPV:    []string{"kubernetes.io/hostname"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the tunables of spec.provisionerConfig are top-level keys, like minResyncPeriod in the static provisioner's ConfigMap
+	for key, value := range v1.MergeProvisionerConfigs(provisionerConfigs...) {
+		data[key] = value
+	}
+
+	return &corev1.ConfigMap{
+		// apply patches need the type of the object
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            common.ProvisionerConfigMapName,
+			Namespace:       namespace,
+			Labels:          map[string]string{"app": common.ProvisionerConfigMapName},
+			OwnerReferences: ownerRefs,
+		},
+		Data: data,
+	}, nil
+}
+
+// provisionerConfigChange is a changed entry of the provisioner ConfigMap:
+// the config of a storageClass or another key of the ConfigMap.
+// Old is nil for added entries, New is nil for removed ones.
+type provisionerConfigChange struct {
+	Entry string
+	Old   interface{}
+	New   interface{}
+}
+
+// diffProvisionerConfig returns the entries that differ between the old and new data of the provisioner ConfigMap,
+// sorted by entry. The storageClass configs are compared one by one.
+func diffProvisionerConfig(oldData, newData map[string]string) ([]provisionerConfigChange, error) {
+	oldConfig := localStaticProvisioner.ProvisionerConfiguration{}
+	err := localStaticProvisioner.ConfigMapDataToVolumeConfig(oldData, &oldConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the old provisioner config: %w", err)
+	}
+	newConfig := localStaticProvisioner.ProvisionerConfiguration{}
+	err = localStaticProvisioner.ConfigMapDataToVolumeConfig(newData, &newConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the new provisioner config: %w", err)
+	}
+
+	changes := []provisionerConfigChange{}
+	storageClasses := sets.NewString()
+	for storageClass := range oldConfig.StorageClassConfig {
+		storageClasses.Insert(storageClass)
+	}
+	for storageClass := range newConfig.StorageClassConfig {
+		storageClasses.Insert(storageClass)
+	}
+	for _, storageClass := range storageClasses.List() {
+		oldMountConfig, oldFound := oldConfig.StorageClassConfig[storageClass]
+		newMountConfig, newFound := newConfig.StorageClassConfig[storageClass]
+		if oldFound && newFound && reflect.DeepEqual(oldMountConfig, newMountConfig) {
+			continue
+		}
+		change := provisionerConfigChange{Entry: localStaticProvisioner.ProvisonerStorageClassConfig + "." + storageClass}
+		if oldFound {
+			change.Old = oldMountConfig
+		}
+		if newFound {
+			change.New = newMountConfig
+		}
+		changes = append(changes, change)
+	}
+
+	keys := sets.NewString()
+	for key := range oldData {
+		keys.Insert(key)
+	}
+	for key := range newData {
+		keys.Insert(key)
+	}
+	keys.Delete(localStaticProvisioner.ProvisonerStorageClassConfig)
+	for _, key := range keys.List() {
+		oldValue, oldFound := oldData[key]
+		newValue, newFound := newData[key]
+		if oldFound && newFound && oldValue == newValue {
+			continue
+		}
+		change := provisionerConfigChange{Entry: key}
+		if oldFound {
+			change.Old = oldValue
 		}
-		configMap.ObjectMeta.Labels = objectMeta.Labels
-		configMap.ObjectMeta.OwnerReferences = ownerRefs
-		data, err := localStaticProvisioner.VolumeConfigToConfigMapData(&localStaticProvisioner.ProvisionerConfiguration{
-			StorageClassConfig: storageClassConfig,
-			NodeLabelsForPV:    []string{"kubernetes.io/hostname"},
-		})
-		if err != nil {
-			return err
+		if newFound {
+			change.New = newValue
 		}
-		configMap.Data = data
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
 
+// logProvisionerConfigChanges logs the entries of the provisioner ConfigMap changed by an update,
+// to show what the provisioner acts on after a change of the LocalVolumes and LocalVolumeSets
+func (r *DaemonReconciler) logProvisionerConfigChanges(oldData, newData map[string]string) {
+	changes, err := diffProvisionerConfig(oldData, newData)
+	if err != nil {
+		r.reqLogger.Error(err, "failed to diff the provisioner configmap")
+		return
+	}
+	for _, change := range changes {
+		r.reqLogger.Info("provisioner configmap entry changed", "entry", change.Entry, "old", change.Old, "new", change.New)
+	}
+}
+
+// blockCleanerCommand returns the command that cleans released block PVs, which is killed
+// cleanupKillGracePeriod after cleanupTimeout. The grace period lets the diskmaker quarantine
+// the PV before the cleanup fails and would be retried. It returns nil, the default command, without a timeout.
+func blockCleanerCommand(cleanupTimeout *metav1.Duration) []string {
+	if cleanupTimeout == nil || cleanupTimeout.Duration <= 0 {
 		return nil
-	})
-	return configMap, opResult, err
+	}
+	killAfter := cleanupTimeout.Duration + cleanupKillGracePeriod
+	return []string{"timeout", fmt.Sprintf("%ds", int64(killAfter.Seconds())), localStaticProvisioner.DefaultBlockCleanerCommand}
 }
 
 func dataHash(data map[string]string) string {
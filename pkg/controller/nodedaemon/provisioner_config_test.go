@@ -0,0 +1,132 @@
+package nodedaemon
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	localStaticProvisioner "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/common"
+)
+
+func TestDiffProvisionerConfig(t *testing.T) {
+	configData := func(storageClassConfig map[string]localStaticProvisioner.MountConfig) map[string]string {
+		data, err := localStaticProvisioner.VolumeConfigToConfigMapData(&localStaticProvisioner.ProvisionerConfiguration{
+			StorageClassConfig: storageClassConfig,
+			NodeLabelsForPV:    []string{"kubernetes.io/hostname"},
+		})
+		assert.NoError(t, err)
+		return data
+	}
+	fast := localStaticProvisioner.MountConfig{HostDir: "/mnt/local-storage/fast", MountDir: "/mnt/local-storage/fast", VolumeMode: "Block"}
+	slow := localStaticProvisioner.MountConfig{HostDir: "/mnt/local-storage/slow", MountDir: "/mnt/local-storage/slow", VolumeMode: "Filesystem", FsType: "xfs"}
+	slowExt4 := slow
+	slowExt4.FsType = "ext4"
+
+	oldData := configData(map[string]localStaticProvisioner.MountConfig{"fast": fast, "slow": slow})
+
+	changes, err := diffProvisionerConfig(oldData, oldData)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	changes, err = diffProvisionerConfig(oldData, configData(map[string]localStaticProvisioner.MountConfig{"slow": slowExt4, "archive": slow}))
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 3) {
+		assert.Equal(t, "storageClassMap.archive", changes[0].Entry)
+		assert.Nil(t, changes[0].Old, "archive was added")
+		assert.Equal(t, "storageClassMap.fast", changes[1].Entry)
+		assert.Nil(t, changes[1].New, "fast was removed")
+		assert.Equal(t, "storageClassMap.slow", changes[2].Entry)
+		assert.Equal(t, "xfs", changes[2].Old.(localStaticProvisioner.MountConfig).FsType)
+		assert.Equal(t, "ext4", changes[2].New.(localStaticProvisioner.MountConfig).FsType)
+	}
+
+	// every entry of a new ConfigMap is reported as added
+	changes, err = diffProvisionerConfig(nil, oldData)
+	assert.NoError(t, err)
+	entries := []string{}
+	for _, change := range changes {
+		assert.Nil(t, change.Old)
+		entries = append(entries, change.Entry)
+	}
+	assert.Equal(t, []string{"storageClassMap.fast", "storageClassMap.slow", "nodeLabelsForPV", "useAlphaAPI"}, entries)
+}
+
+func TestProvisionerConfigMap(t *testing.T) {
+	lvSets := []localv1alpha1.LocalVolumeSet{
+		{Spec: localv1alpha1.LocalVolumeSetSpec{
+			StorageClassName: "fast",
+			VolumeMode:       v1.PersistentVolumeBlock,
+			DeviceRouting:    []localv1alpha1.DeviceRoute{{PartLabel: "nvme", StorageClassName: "fastest"}},
+		}},
+	}
+	lvs := []v1.LocalVolume{
+		{Spec: v1.LocalVolumeSpec{StorageClassDevices: []v1.StorageClassDevice{
+			{StorageClassName: "slow", VolumeMode: v1.PersistentVolumeFilesystem, FSType: "xfs"},
+		}}},
+	}
+	ownerRefs := []metav1.OwnerReference{{APIVersion: "local.storage.openshift.io/v1", Kind: "LocalVolume", Name: "lv", UID: "uid"}}
+
+	configMap, err := provisionerConfigMap("ns", lvSets, lvs, ownerRefs)
+	assert.NoError(t, err)
+	assert.Equal(t, "ConfigMap", configMap.Kind, "apply patches need the type of the object")
+	assert.Equal(t, "v1", configMap.APIVersion)
+	assert.Equal(t, common.ProvisionerConfigMapName, configMap.Name)
+	assert.Equal(t, "ns", configMap.Namespace)
+	assert.Equal(t, ownerRefs, configMap.OwnerReferences)
+	config := localStaticProvisioner.ProvisionerConfiguration{}
+	err = localStaticProvisioner.ConfigMapDataToVolumeConfig(configMap.Data, &config)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"fast", "fastest", "slow"}, mapKeys(config.StorageClassConfig))
+	assert.Equal(t, "/mnt/local-storage/fastest", config.StorageClassConfig["fastest"].HostDir, "the routed storageclass has its own directory")
+	assert.Equal(t, "xfs", config.StorageClassConfig["slow"].FsType)
+
+	// a storageClass that is no longer used is left out of the whole storageClassMap key
+	// that the operator applies, so it is removed from the ConfigMap
+	configMap, err = provisionerConfigMap("ns", nil, lvs, ownerRefs)
+	assert.NoError(t, err)
+	config = localStaticProvisioner.ProvisionerConfiguration{}
+	err = localStaticProvisioner.ConfigMapDataToVolumeConfig(configMap.Data, &config)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"slow"}, mapKeys(config.StorageClassConfig))
+	assert.NotContains(t, configMap.Data, v1.ProvisionerConfigMinResyncPeriod)
+}
+
+func TestProvisionerConfigMapTunables(t *testing.T) {
+	lvSets := []localv1alpha1.LocalVolumeSet{
+		{Spec: localv1alpha1.LocalVolumeSetSpec{StorageClassName: "fast", ProvisionerConfig: map[string]string{
+			v1.ProvisionerConfigMinResyncPeriod: "2m",
+			v1.ProvisionerConfigDeletionBackoff: "90s",
+		}}},
+		// invalid configs are ignored
+		{Spec: localv1alpha1.LocalVolumeSetSpec{StorageClassName: "faster", ProvisionerConfig: map[string]string{
+			v1.ProvisionerConfigMinResyncPeriod: "1s",
+		}}},
+	}
+	lvs := []v1.LocalVolume{
+		{Spec: v1.LocalVolumeSpec{
+			ProvisionerConfig:   map[string]string{v1.ProvisionerConfigMinResyncPeriod: "1m"},
+			StorageClassDevices: []v1.StorageClassDevice{{StorageClassName: "slow", VolumeMode: v1.PersistentVolumeFilesystem}},
+		}},
+	}
+
+	configMap, err := provisionerConfigMap("ns", lvSets, lvs, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1m0s", configMap.Data[v1.ProvisionerConfigMinResyncPeriod], "the shortest value is used")
+	assert.Equal(t, "1m30s", configMap.Data[v1.ProvisionerConfigDeletionBackoff])
+	config := localStaticProvisioner.ProvisionerConfiguration{}
+	err = localStaticProvisioner.ConfigMapDataToVolumeConfig(configMap.Data, &config)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, config.MinResyncPeriod.Duration, "the static provisioner reads minResyncPeriod")
+}
+
+func mapKeys(storageClassConfig map[string]localStaticProvisioner.MountConfig) []string {
+	keys := []string{}
+	for key := range storageClassConfig {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -7,6 +7,9 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	v1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -48,6 +51,11 @@ type DaemonReconciler struct {
 	scheme                   *runtime.Scheme
 	reqLogger                logr.Logger
 	deletedStaticProvisioner bool
+	options                  common.Options
+	// renderedLVSets and renderedLVs are the specs of the LocalVolumeSets and LocalVolumes, by namespace and UID,
+	// as they were last rendered into the configmap and daemonsets, a paused object keeps contributing them
+	renderedLVSets map[string]map[types.UID]localv1alpha1.LocalVolumeSetSpec
+	renderedLVs    map[string]map[types.UID]v1.LocalVolumeSpec
 }
 
 // Reconcile reads that state of the cluster for a LocalVolumeSet object and makes changes based on the state read
@@ -56,6 +64,12 @@ type DaemonReconciler struct {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *DaemonReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	defer common.ObserveReconcileDuration(controllerName)()
+	// fatal API errors, as for an invalid daemonset, are not retried until a LocalVolume or LocalVolumeSet changes
+	return common.ReconcileErrorResult(r.reconcile(request))
+}
+
+func (r *DaemonReconciler) reconcile(request reconcile.Request) (reconcile.Result, error) {
 	r.reqLogger = logf.Log.WithName(controllerName).WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 
 	// do a one-time delete of the old static-provisioner daemonset
@@ -69,7 +83,8 @@ func (r *DaemonReconciler) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 	if len(lvSets.Items) < 1 && len(lvs.Items) < 1 {
-		return reconcile.Result{}, nil
+		// no node is matched anymore, remove the capacity annotations
+		return reconcile.Result{}, r.updateNodeCapacities(request.Namespace, nil)
 	}
 
 	configMap, opResult, err := r.reconcileProvisionerConfigMap(request, lvSets.Items, lvs.Items, ownerRefs)
@@ -81,7 +96,7 @@ func (r *DaemonReconciler) Reconcile(request reconcile.Request) (reconcile.Resul
 
 	configMapDataHash := dataHash(configMap.Data)
 
-	diskMakerDSMutateFn := getDiskMakerDSMutateFn(request, tolerations, ownerRefs, nodeSelector, configMapDataHash)
+	diskMakerDSMutateFn := getDiskMakerDSMutateFn(request, tolerations, ownerRefs, nodeSelector, configMapDataHash, usesBindMount(lvs.Items), sourceDirPaths(lvs.Items), diskMakerLogLevel(lvSets.Items, r.options.LogLevel), r.options)
 	ds, opResult, err := CreateOrUpdateDaemonset(r.client, diskMakerDSMutateFn)
 	if err != nil {
 		return reconcile.Result{}, err
@@ -89,7 +104,29 @@ func (r *DaemonReconciler) Reconcile(request reconcile.Request) (reconcile.Resul
 		r.reqLogger.Info("daemonset changed", "daemonset.Name", ds.GetName(), "op.Result", opResult)
 	}
 
-	return reconcile.Result{}, err
+	opResult, err = r.reconcileDiskMakerPDB(ds, lvSets.Items, ownerRefs)
+	if err != nil {
+		return reconcile.Result{}, err
+	} else if opResult == controllerutil.OperationResultUpdated || opResult == controllerutil.OperationResultCreated {
+		r.reqLogger.Info("poddisruptionbudget changed", "poddisruptionbudget.Name", DiskMakerName, "op.Result", opResult)
+	}
+
+	err = r.updateManagedDaemonSetsStatus(request.Namespace, lvSets.Items)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	err = r.updateNodesWithErrors(request.Namespace, lvSets.Items, lvs.Items)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	err = r.updateNodeCapacities(request.Namespace, nodeSelector)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
 }
 
 // do a one-time delete of the old static-provisioner daemonset
@@ -0,0 +1,75 @@
+package nodedaemon
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// managedDaemonSetStatus summarizes the rollout of a DaemonSet.
+// It is ready the same way the e2e tests wait for it: every scheduled daemon pod is ready,
+// and the DaemonSet controller observed the latest generation.
+func managedDaemonSetStatus(ds *appsv1.DaemonSet) localv1alpha1.ManagedDaemonSet {
+	return localv1alpha1.ManagedDaemonSet{
+		Name:                   ds.Name,
+		DesiredNumberScheduled: ds.Status.DesiredNumberScheduled,
+		NumberReady:            ds.Status.NumberReady,
+		Ready:                  ds.Status.ObservedGeneration >= ds.Generation && ds.Status.NumberReady == ds.Status.DesiredNumberScheduled,
+		Generation:             ds.Generation,
+		ObservedGeneration:     ds.Status.ObservedGeneration,
+	}
+}
+
+// getManagedDaemonSets returns the status of the diskmaker and provisioner daemonsets that exist in the namespace
+func (r *DaemonReconciler) getManagedDaemonSets(namespace string) ([]localv1alpha1.ManagedDaemonSet, error) {
+	managed := []localv1alpha1.ManagedDaemonSet{}
+	for _, name := range []string{DiskMakerName, ProvisionerName} {
+		ds := &appsv1.DaemonSet{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, ds)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get daemonset %q: %w", name, err)
+		}
+		managed = append(managed, managedDaemonSetStatus(ds))
+	}
+	if len(managed) == 0 {
+		return nil, nil
+	}
+	return managed, nil
+}
+
+// updateManagedDaemonSetsStatus writes the status of the managed daemonsets to every LocalVolumeSet
+func (r *DaemonReconciler) updateManagedDaemonSetsStatus(namespace string, lvSets []localv1alpha1.LocalVolumeSet) error {
+	managed, err := r.getManagedDaemonSets(namespace)
+	if err != nil {
+		return err
+	}
+	for _, lvSet := range lvSets {
+		key := types.NamespacedName{Name: lvSet.Name, Namespace: lvSet.Namespace}
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			current := &localv1alpha1.LocalVolumeSet{}
+			err := r.client.Get(context.TODO(), key, current)
+			if err != nil {
+				return err
+			}
+			if reflect.DeepEqual(current.Status.ManagedDaemonSets, managed) {
+				return nil
+			}
+			current.Status.ManagedDaemonSets = managed
+			return r.client.Status().Update(context.TODO(), current)
+		})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to update the managed daemonsets of localvolumeset %q: %w", key, err)
+		}
+	}
+	return nil
+}
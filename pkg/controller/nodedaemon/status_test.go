@@ -0,0 +1,55 @@
+package nodedaemon
+
+import (
+	"context"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUpdateManagedDaemonSetsStatus(t *testing.T) {
+	scheme, err := localv1alpha1.SchemeBuilder.Build()
+	assert.NoError(t, err)
+	err = appsv1.AddToScheme(scheme)
+	assert.NoError(t, err)
+
+	namespace := "default"
+	lvSet := &localv1alpha1.LocalVolumeSet{ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: namespace}}
+	diskMaker := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: DiskMakerName, Namespace: namespace, Generation: 3},
+		Status: appsv1.DaemonSetStatus{
+			DesiredNumberScheduled: 3,
+			NumberReady:            2,
+			ObservedGeneration:     3,
+		},
+	}
+	r := &DaemonReconciler{client: fake.NewFakeClientWithScheme(scheme, lvSet, diskMaker), scheme: scheme}
+
+	err = r.updateManagedDaemonSetsStatus(namespace, []localv1alpha1.LocalVolumeSet{*lvSet})
+	assert.NoError(t, err)
+	current := &localv1alpha1.LocalVolumeSet{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: lvSet.Name, Namespace: namespace}, current)
+	assert.NoError(t, err)
+	assert.Equal(t, []localv1alpha1.ManagedDaemonSet{
+		{Name: DiskMakerName, DesiredNumberScheduled: 3, NumberReady: 2, Ready: false, Generation: 3, ObservedGeneration: 3},
+	}, current.Status.ManagedDaemonSets, "the provisioner daemonset doesn't exist")
+
+	testCases := []struct {
+		label  string
+		status appsv1.DaemonSetStatus
+		ready  bool
+	}{
+		{label: "all pods ready", status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, NumberReady: 3, ObservedGeneration: 3}, ready: true},
+		{label: "stale generation", status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, NumberReady: 3, ObservedGeneration: 2}, ready: false},
+		{label: "not scheduled", status: appsv1.DaemonSetStatus{ObservedGeneration: 3}, ready: true},
+	}
+	for _, tc := range testCases {
+		diskMaker.Status = tc.status
+		assert.Equalf(t, tc.ready, managedDaemonSetStatus(diskMaker).Ready, "[%s]", tc.label)
+	}
+}
@@ -1,6 +1,7 @@
 package diskmaker
 
 import (
+	"github.com/openshift/local-storage-operator/pkg/common"
 	"github.com/openshift/local-storage-operator/pkg/diskmaker/controllers/deleter"
 	"github.com/openshift/local-storage-operator/pkg/diskmaker/controllers/lv"
 	"github.com/openshift/local-storage-operator/pkg/diskmaker/controllers/lvset"
@@ -20,12 +21,12 @@ func init() {
 
 // AddToManagerFuncs is a list of functions to add all Controllers to the Manager and pass shared resources for the static provisioner library
 // The cache populated by LVS will also be read by LV
-var AddToManagerFuncs []func(manager.Manager, *provDeleter.CleanupStatusTracker, *provCache.VolumeCache) error
+var AddToManagerFuncs []func(manager.Manager, *provDeleter.CleanupStatusTracker, *provCache.VolumeCache, common.Options) error
 
 // AddToManager adds all Controllers to the Manager
-func AddToManager(m manager.Manager) error {
+func AddToManager(m manager.Manager, options common.Options) error {
 	for _, f := range AddToManagerFuncs {
-		if err := f(m, &provDeleter.CleanupStatusTracker{ProcTable: provDeleter.NewProcTable()}, provCache.NewVolumeCache()); err != nil {
+		if err := f(m, &provDeleter.CleanupStatusTracker{ProcTable: provDeleter.NewProcTable()}, provCache.NewVolumeCache(), options); err != nil {
 			logf.Log.Error(err, "failed to add controller")
 			return err
 		}
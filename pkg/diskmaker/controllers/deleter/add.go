@@ -43,12 +43,18 @@ type ReconcileDeleter struct {
 	client         client.Client
 	scheme         *runtime.Scheme
 	cleanupTracker *provDeleter.CleanupStatusTracker
+	procTable      *timedProcTable
 	runtimeConfig  *provCommon.RuntimeConfig
 	deleter        *provDeleter.Deleter
 	firstRunOver   bool
+	options        common.Options
 }
 
-func Add(mgr manager.Manager, cleanupTracker *provDeleter.CleanupStatusTracker, pvCache *provCache.VolumeCache) error {
+func Add(mgr manager.Manager, cleanupTracker *provDeleter.CleanupStatusTracker, pvCache *provCache.VolumeCache, options common.Options) error {
+	// the deleter wipes the devices of released PVs
+	if options.DiscoveryOnly {
+		return nil
+	}
 	// populate the pv cache
 	clientSet := provCommon.SetupClient()
 	runtimeConfig := &provCommon.RuntimeConfig{
@@ -65,10 +71,18 @@ func Add(mgr manager.Manager, cleanupTracker *provDeleter.CleanupStatusTracker,
 
 	}
 
+	runtimeConfig.APIUtil = &bindMountAPIUtil{APIUtil: runtimeConfig.APIUtil, cache: pvCache}
+
+	// record the start of cleanups to enforce the cleanupTimeout
+	procTable := newTimedProcTable(cleanupTracker.ProcTable)
+	cleanupTracker.ProcTable = procTable
+
 	r := &ReconcileDeleter{
 		client:        mgr.GetClient(),
 		scheme:        mgr.GetScheme(),
+		procTable:     procTable,
 		runtimeConfig: runtimeConfig,
+		options:       options,
 		deleter: &provDeleter.Deleter{
 			RuntimeConfig: runtimeConfig,
 			CleanupStatus: cleanupTracker,
@@ -164,6 +178,11 @@ func handlePVChange(runtimeConfig *provCommon.RuntimeConfig, pv *corev1.Persiste
 }
 
 func addOrUpdatePV(r *provCommon.RuntimeConfig, pv corev1.PersistentVolume) {
+	// quarantined PVs are kept out of the cache, so that the deleter leaves them alone
+	if isQuarantined(&pv) {
+		removePV(r, pv)
+		return
+	}
 	_, exists := r.Cache.GetPV(pv.GetName())
 	if exists {
 		r.Cache.UpdatePV(&pv)
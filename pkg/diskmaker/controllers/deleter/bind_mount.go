@@ -0,0 +1,33 @@
+package deleter
+
+import (
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	provCache "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/cache"
+	provUtil "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/util"
+)
+
+// bindMountAPIUtil unmounts and removes the bind mount target of a device once the wrapped APIUtil deleted its PV.
+// The diskmaker skips bind-mounted devices, the target is removed so that it bind-mounts the wiped device again
+// and creates a new PV for it. The PV is deleted first, so that the diskmaker doesn't recreate it in between.
+type bindMountAPIUtil struct {
+	provUtil.APIUtil
+	cache *provCache.VolumeCache
+}
+
+var _ provUtil.APIUtil = &bindMountAPIUtil{}
+
+func (a *bindMountAPIUtil) DeletePV(pvName string) error {
+	pv, found := a.cache.GetPV(pvName)
+	err := a.APIUtil.DeletePV(pvName)
+	if (err != nil && !kerrors.IsNotFound(err)) || !found || pv.Spec.Local == nil {
+		return err
+	}
+	removed, removeErr := internal.RemoveBindMountTarget(pv.Spec.Local.Path)
+	if removeErr != nil {
+		log.Error(removeErr, "could not remove the bind mount target of the deleted PV", "pvName", pvName)
+	} else if removed {
+		log.Info("removed the bind mount target of the deleted PV", "pvName", pvName, "path", pv.Spec.Local.Path)
+	}
+	return err
+}
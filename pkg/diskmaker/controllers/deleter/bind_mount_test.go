@@ -0,0 +1,57 @@
+package deleter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	provCache "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/cache"
+	provUtil "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/util"
+)
+
+type fakeAPIUtil struct {
+	provUtil.APIUtil
+	deleted []string
+}
+
+func (f *fakeAPIUtil) DeletePV(pvName string) error {
+	f.deleted = append(f.deleted, pvName)
+	return nil
+}
+
+func TestBindMountAPIUtilDeletePV(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "bind-mounts")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	// a target left unmounted by a reboot, and the symlink of another PV
+	target := filepath.Join(tmpDir, "local-sc", "wwn-0x5000c500a0b1c2d3")
+	symlink := filepath.Join(tmpDir, "local-sc", "wwn-0x5000c500a0b1c2d4")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(target), 0755))
+	assert.NoError(t, ioutil.WriteFile(target, []byte{}, 0600))
+	assert.NoError(t, os.Symlink("/dev/sdc", symlink))
+
+	cache := provCache.NewVolumeCache()
+	for name, path := range map[string]string{"bind-mounted": target, "symlinked": symlink} {
+		cache.AddPV(&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{Local: &corev1.LocalVolumeSource{Path: path}},
+			},
+		})
+	}
+	apiUtil := &fakeAPIUtil{}
+	wrapped := &bindMountAPIUtil{APIUtil: apiUtil, cache: cache}
+
+	assert.NoError(t, wrapped.DeletePV("bind-mounted"))
+	assert.NoError(t, wrapped.DeletePV("symlinked"))
+	assert.Equal(t, []string{"bind-mounted", "symlinked"}, apiUtil.deleted)
+	_, err = os.Lstat(target)
+	assert.True(t, os.IsNotExist(err), "the bind mount target should be removed")
+	_, err = os.Lstat(symlink)
+	assert.NoError(t, err, "the symlink should be kept")
+}
@@ -0,0 +1,163 @@
+package deleter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	provDeleter "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/deleter"
+)
+
+// timedProcTable records when the cleanup processes of the wrapped ProcTable were started,
+// which the static provisioner's ProcTable doesn't expose while they are running,
+// and when they failed, to delay their retry by the deletionBackoff
+type timedProcTable struct {
+	provDeleter.ProcTable
+	lock      sync.Mutex
+	startTime map[string]time.Time
+	failTime  map[string]time.Time
+	// deletionBackoff is set by every reconcile from the provisioner ConfigMap
+	deletionBackoff time.Duration
+	now             func() time.Time
+}
+
+var _ provDeleter.ProcTable = &timedProcTable{}
+
+func newTimedProcTable(procTable provDeleter.ProcTable) *timedProcTable {
+	return &timedProcTable{
+		ProcTable: procTable,
+		startTime: map[string]time.Time{},
+		failTime:  map[string]time.Time{},
+		now:       time.Now,
+	}
+}
+
+func (t *timedProcTable) MarkRunning(pvName string) error {
+	err := t.ProcTable.MarkRunning(pvName)
+	if err != nil {
+		return err
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.startTime[pvName] = t.now()
+	return nil
+}
+
+func (t *timedProcTable) RemoveEntry(pvName string) (provDeleter.CleanupState, *time.Time, error) {
+	state, startTime, err := t.ProcTable.RemoveEntry(pvName)
+	if err == nil {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		delete(t.startTime, pvName)
+		delete(t.failTime, pvName)
+	}
+	return state, startTime, err
+}
+
+// runningFor returns how long the cleanup of the PV has been running, and false if it isn't running
+func (t *timedProcTable) runningFor(pvName string) (time.Duration, bool) {
+	if !t.ProcTable.IsRunning(pvName) {
+		return 0, false
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	startTime, found := t.startTime[pvName]
+	if !found {
+		return 0, false
+	}
+	return t.now().Sub(startTime), true
+}
+
+// isQuarantined returns true if the cleanup of the PV timed out and it must be left alone
+func isQuarantined(pv *corev1.PersistentVolume) bool {
+	_, found := pv.GetAnnotations()[common.PVCleanupTimedOutAnnotation]
+	return found
+}
+
+// quarantineTimedOutCleanups annotates released PVs whose cleanup runs longer than the cleanupTimeout of their owner,
+// and removes them from the cache so that the deleter neither deletes them nor restarts their cleanup.
+func (r *ReconcileDeleter) quarantineTimedOutCleanups(reqLogger logr.Logger) error {
+	for _, pv := range r.runtimeConfig.Cache.ListPVs() {
+		if pv.Status.Phase != corev1.VolumeReleased {
+			continue
+		}
+		runningFor, running := r.procTable.runningFor(pv.Name)
+		if !running {
+			continue
+		}
+		timeout, err := r.cleanupTimeout(pv)
+		if err != nil {
+			return err
+		}
+		if timeout <= 0 || runningFor < timeout {
+			continue
+		}
+
+		reqLogger.Info("cleanup timed out, quarantining PV", "pvName", pv.Name, "cleanupTimeout", timeout)
+		current := &corev1.PersistentVolume{}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: pv.Name}, current)
+		if kerrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("could not get PV %q: %w", pv.Name, err)
+		}
+		if current.Annotations == nil {
+			current.Annotations = map[string]string{}
+		}
+		current.Annotations[common.PVCleanupTimedOutAnnotation] = time.Now().Format(time.RFC3339)
+		err = r.client.Update(context.TODO(), current)
+		if err != nil {
+			return fmt.Errorf("could not quarantine PV %q: %w", pv.Name, err)
+		}
+		removePV(r.runtimeConfig, *pv)
+		r.runtimeConfig.Recorder.Eventf(current, corev1.EventTypeWarning, common.CleanupTimedOut,
+			"cleanup did not finish within %v, the PV is quarantined until the %s annotation is removed",
+			timeout, common.PVCleanupTimedOutAnnotation)
+	}
+	return nil
+}
+
+// cleanupTimeout returns the cleanupTimeout of the LocalVolume or LocalVolumeSet that owns the PV, 0 if it has none
+func (r *ReconcileDeleter) cleanupTimeout(pv *corev1.PersistentVolume) (time.Duration, error) {
+	key := types.NamespacedName{
+		Name:      pv.Labels[common.PVOwnerNameLabel],
+		Namespace: pv.Labels[common.PVOwnerNamespaceLabel],
+	}
+	if key.Name == "" || key.Namespace == "" {
+		return 0, nil
+	}
+	var cleanupTimeout *metav1.Duration
+	switch pv.Labels[common.PVOwnerKindLabel] {
+	case localv1.LocalVolumeKind:
+		lv := &localv1.LocalVolume{}
+		err := r.client.Get(context.TODO(), key, lv)
+		if kerrors.IsNotFound(err) {
+			return 0, nil
+		} else if err != nil {
+			return 0, fmt.Errorf("could not get owner of PV %q: %w", pv.Name, err)
+		}
+		cleanupTimeout = lv.Spec.CleanupTimeout
+	case localv1alpha1.LocalVolumeSetKind:
+		lvset := &localv1alpha1.LocalVolumeSet{}
+		err := r.client.Get(context.TODO(), key, lvset)
+		if kerrors.IsNotFound(err) {
+			return 0, nil
+		} else if err != nil {
+			return 0, fmt.Errorf("could not get owner of PV %q: %w", pv.Name, err)
+		}
+		cleanupTimeout = lvset.Spec.CleanupTimeout
+	}
+	if cleanupTimeout == nil {
+		return 0, nil
+	}
+	return cleanupTimeout.Duration, nil
+}
@@ -0,0 +1,110 @@
+package deleter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	crFake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	provCache "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/cache"
+	provCommon "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/common"
+	provDeleter "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/deleter"
+)
+
+func TestQuarantineTimedOutCleanups(t *testing.T) {
+	testTable := []struct {
+		label             string
+		cleanupTimeout    *metav1.Duration
+		runningFor        time.Duration
+		expectQuarantined bool
+	}{
+		{
+			label:      "no cleanupTimeout",
+			runningFor: 24 * time.Hour,
+		},
+		{
+			label:          "within cleanupTimeout",
+			cleanupTimeout: &metav1.Duration{Duration: time.Hour},
+			runningFor:     time.Minute,
+		},
+		{
+			label:             "cleanupTimeout exceeded",
+			cleanupTimeout:    &metav1.Duration{Duration: time.Hour},
+			runningFor:        2 * time.Hour,
+			expectQuarantined: true,
+		},
+	}
+
+	for _, tc := range testTable {
+		lvset := &localv1alpha1.LocalVolumeSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: "default"},
+			Spec: localv1alpha1.LocalVolumeSetSpec{
+				StorageClassName: "sc",
+				CleanupTimeout:   tc.cleanupTimeout,
+			},
+		}
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "local-pv-1",
+				Labels: map[string]string{
+					common.PVOwnerKindLabel:      localv1alpha1.LocalVolumeSetKind,
+					common.PVOwnerNameLabel:      lvset.Name,
+					common.PVOwnerNamespaceLabel: lvset.Namespace,
+				},
+			},
+			Spec:   corev1.PersistentVolumeSpec{StorageClassName: "sc"},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeReleased},
+		}
+
+		scheme, err := localv1alpha1.SchemeBuilder.Build()
+		assert.NoErrorf(t, err, "creating scheme")
+		err = corev1.AddToScheme(scheme)
+		assert.NoErrorf(t, err, "adding corev1 to scheme")
+		fakeClient := crFake.NewFakeClientWithScheme(scheme, lvset, pv)
+
+		runtimeConfig := &provCommon.RuntimeConfig{
+			UserConfig: &provCommon.UserConfig{Node: &corev1.Node{}},
+			Cache:      provCache.NewVolumeCache(),
+			Recorder:   record.NewFakeRecorder(10),
+		}
+		addOrUpdatePV(runtimeConfig, *pv)
+
+		now := time.Now()
+		procTable := newTimedProcTable(provDeleter.NewProcTable())
+		procTable.now = func() time.Time { return now.Add(-tc.runningFor) }
+		err = procTable.MarkRunning(pv.Name)
+		assert.NoErrorf(t, err, "[%s] MarkRunning", tc.label)
+		procTable.now = func() time.Time { return now }
+
+		r := &ReconcileDeleter{
+			client:        fakeClient,
+			scheme:        scheme,
+			procTable:     procTable,
+			runtimeConfig: runtimeConfig,
+		}
+		err = r.quarantineTimedOutCleanups(logf.Log)
+		assert.NoErrorf(t, err, "[%s] quarantineTimedOutCleanups", tc.label)
+
+		updatedPV := &corev1.PersistentVolume{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: pv.Name}, updatedPV)
+		assert.NoErrorf(t, err, "[%s] get PV", tc.label)
+		assert.Equalf(t, tc.expectQuarantined, isQuarantined(updatedPV), "[%s] PV annotated", tc.label)
+		_, cached := runtimeConfig.Cache.GetPV(pv.Name)
+		assert.Equalf(t, !tc.expectQuarantined, cached, "[%s] PV kept in the deleter's cache", tc.label)
+		events := runtimeConfig.Recorder.(*record.FakeRecorder).Events
+		assert.Equalf(t, tc.expectQuarantined, len(events) > 0, "[%s] CleanupTimedOut event", tc.label)
+
+		// a quarantined PV is not added back to the cache by PV events
+		addOrUpdatePV(runtimeConfig, *updatedPV)
+		_, cached = runtimeConfig.Cache.GetPV(pv.Name)
+		assert.Equalf(t, !tc.expectQuarantined, cached, "[%s] PV cached after update", tc.label)
+	}
+}
@@ -0,0 +1,35 @@
+package deleter
+
+import (
+	"time"
+)
+
+func (t *timedProcTable) MarkFailed(pvName string) error {
+	err := t.ProcTable.MarkFailed(pvName)
+	if err != nil {
+		return err
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.failTime[pvName] = t.now()
+	return nil
+}
+
+// IsRunning also reports the failed cleanups as running until the deletionBackoff has passed,
+// so that the deleter waits before it restarts them
+func (t *timedProcTable) IsRunning(pvName string) bool {
+	if t.ProcTable.IsRunning(pvName) {
+		return true
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	failTime, found := t.failTime[pvName]
+	return found && t.now().Sub(failTime) < t.deletionBackoff
+}
+
+// setDeletionBackoff sets how long the failed cleanups wait before they are retried
+func (t *timedProcTable) setDeletionBackoff(deletionBackoff time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.deletionBackoff = deletionBackoff
+}
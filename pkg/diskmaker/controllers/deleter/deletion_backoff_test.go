@@ -0,0 +1,33 @@
+package deleter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	provDeleter "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/deleter"
+)
+
+func TestDeletionBackoff(t *testing.T) {
+	now := time.Now()
+	procTable := newTimedProcTable(provDeleter.NewProcTable())
+	procTable.now = func() time.Time { return now }
+	procTable.setDeletionBackoff(5 * time.Minute)
+
+	assert.NoError(t, procTable.MarkRunning("local-pv-1"))
+	assert.True(t, procTable.IsRunning("local-pv-1"))
+	assert.NoError(t, procTable.MarkFailed("local-pv-1"))
+	assert.True(t, procTable.IsRunning("local-pv-1"), "a failed cleanup isn't retried within the deletionBackoff")
+
+	now = now.Add(5 * time.Minute)
+	assert.False(t, procTable.IsRunning("local-pv-1"), "a failed cleanup is retried after the deletionBackoff")
+	state, _, err := procTable.RemoveEntry("local-pv-1")
+	assert.NoError(t, err)
+	assert.Equal(t, provDeleter.CSFailed, state)
+
+	// without a deletionBackoff, failed cleanups are retried right away
+	procTable.setDeletionBackoff(0)
+	assert.NoError(t, procTable.MarkRunning("local-pv-1"))
+	assert.NoError(t, procTable.MarkFailed("local-pv-1"))
+	assert.False(t, procTable.IsRunning("local-pv-1"))
+}
@@ -0,0 +1,127 @@
+package deleter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	provCommon "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/common"
+)
+
+// duplicatePV is a PV that uses the device of an older PV of the node
+type duplicatePV struct {
+	original string
+	device   string
+}
+
+// flagDuplicatePVs annotates the PVs of the node whose local path resolves to the same device, or that were created
+// for the same serial number, as an older PV of the node, and reports them with a DuplicatePVForDevice event.
+// The oldest PV of each device is kept as is. The PVs are never deleted, bound or not,
+// the annotation is removed once the PVs no longer share a device.
+func (r *ReconcileDeleter) flagDuplicatePVs(reqLogger logr.Logger) error {
+	pvList := &corev1.PersistentVolumeList{}
+	err := r.client.List(context.TODO(), pvList)
+	if err != nil {
+		return fmt.Errorf("could not list PVs: %w", err)
+	}
+	pvs := []corev1.PersistentVolume{}
+	for _, pv := range pvList.Items {
+		if pv.Spec.Local != nil && pv.Annotations[provCommon.AnnProvisionedBy] == r.runtimeConfig.Name {
+			pvs = append(pvs, pv)
+		}
+	}
+
+	duplicates := findDuplicatePVs(pvs)
+	for _, pv := range pvs {
+		duplicate, isDuplicate := duplicates[pv.Name]
+		current, flagged := pv.Annotations[common.PVDuplicateOfAnnotation]
+		if isDuplicate == flagged && current == duplicate.original {
+			continue
+		}
+		err := r.setDuplicateOf(pv.Name, duplicate.original)
+		if err != nil {
+			return fmt.Errorf("could not update the %s annotation of PV %q: %w", common.PVDuplicateOfAnnotation, pv.Name, err)
+		}
+		if !isDuplicate {
+			reqLogger.Info("PV no longer shares its device", "pvName", pv.Name)
+			continue
+		}
+		reqLogger.Info("PV uses the device of an older PV", "pvName", pv.Name, "original", duplicate.original, "device", duplicate.device)
+		r.runtimeConfig.Recorder.Eventf(&pv, corev1.EventTypeWarning, common.DuplicatePVForDevice,
+			"PV uses %s like the older PV %s, only one of them should be bound. The PV is left in place with the %s annotation",
+			duplicate.device, duplicate.original, common.PVDuplicateOfAnnotation)
+	}
+	return nil
+}
+
+// findDuplicatePVs returns the PVs that use the device of an older PV, by name. PVs use the same device
+// when their local paths resolve to the same device file, or when they were created for the same serial number.
+func findDuplicatePVs(pvs []corev1.PersistentVolume) map[string]duplicatePV {
+	sorted := make([]corev1.PersistentVolume, len(pvs))
+	copy(sorted, pvs)
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].CreationTimestamp.Equal(&sorted[j].CreationTimestamp) {
+			return sorted[i].CreationTimestamp.Before(&sorted[j].CreationTimestamp)
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	// the oldest PV of each device, by resolved device path and by serial number
+	owners := map[string]string{}
+	duplicates := map[string]duplicatePV{}
+	for _, pv := range sorted {
+		devices := []string{}
+		devicePath, err := internal.FilePathEvalSymLinks(pv.Spec.Local.Path)
+		// bind-mounted devices and directories don't resolve to a device file
+		if err == nil && strings.HasPrefix(devicePath, "/dev/") {
+			devices = append(devices, devicePath)
+		}
+		if identity := pv.Annotations[common.PVDeviceIdentityAnnotation]; identity != "" {
+			devices = append(devices, fmt.Sprintf("the device with serial %s", identity))
+		}
+
+		for _, device := range devices {
+			if original, found := owners[device]; found {
+				duplicates[pv.Name] = duplicatePV{original: original, device: device}
+				break
+			}
+		}
+		if _, found := duplicates[pv.Name]; found {
+			continue
+		}
+		for _, device := range devices {
+			owners[device] = pv.Name
+		}
+	}
+	return duplicates
+}
+
+// setDuplicateOf sets the PVDuplicateOfAnnotation of the PV to original, or removes it if original is empty
+func (r *ReconcileDeleter) setDuplicateOf(pvName, original string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pv := &corev1.PersistentVolume{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: pvName}, pv)
+		if kerrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if original == "" {
+			delete(pv.Annotations, common.PVDuplicateOfAnnotation)
+		} else {
+			if pv.Annotations == nil {
+				pv.Annotations = map[string]string{}
+			}
+			pv.Annotations[common.PVDuplicateOfAnnotation] = original
+		}
+		return r.client.Update(context.TODO(), pv)
+	})
+}
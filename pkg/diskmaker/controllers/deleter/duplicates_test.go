@@ -0,0 +1,112 @@
+package deleter
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	crFake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	provCommon "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/common"
+)
+
+func TestFlagDuplicatePVs(t *testing.T) {
+	symlinks := map[string]string{
+		"/mnt/local-storage/sc/disk-a": "/dev/sdb",
+		"/mnt/local-storage/sc/disk-b": "/dev/sdb",
+		"/mnt/local-storage/sc/disk-c": "/dev/sdc",
+		"/mnt/local-storage/sc/disk-d": "/dev/sdd",
+		"/mnt/local-storage/sc/disk-e": "/dev/sde",
+		"/mnt/local-storage/sc/dir-f":  "/mnt/local-storage/sc/dir-f",
+		"/mnt/local-storage/sc/dir-g":  "/mnt/local-storage/sc/dir-g",
+	}
+	internal.FilePathEvalSymLinks = func(path string) (string, error) {
+		resolved, found := symlinks[path]
+		if !found {
+			return "", fmt.Errorf("%s not found", path)
+		}
+		return resolved, nil
+	}
+	defer func() {
+		internal.FilePathEvalSymLinks = filepath.EvalSymlinks
+	}()
+
+	now := time.Now()
+	newPV := func(name, path, provisionedBy string, age time.Duration, annotations map[string]string) *corev1.PersistentVolume {
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+				Annotations:       map[string]string{provCommon.AnnProvisionedBy: provisionedBy},
+			},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{Local: &corev1.LocalVolumeSource{Path: path}},
+			},
+		}
+		for key, value := range annotations {
+			pv.Annotations[key] = value
+		}
+		return pv
+	}
+	objects := []runtime.Object{
+		// the same device file
+		newPV("pv-a", "/mnt/local-storage/sc/disk-a", "node-a", time.Hour, nil),
+		newPV("pv-b", "/mnt/local-storage/sc/disk-b", "node-a", time.Minute, nil),
+		// the same serial number
+		newPV("pv-c", "/mnt/local-storage/sc/disk-c", "node-a", time.Hour, map[string]string{common.PVDeviceIdentityAnnotation: "serial-1"}),
+		newPV("pv-d", "/mnt/local-storage/sc/disk-d", "node-a", time.Minute, map[string]string{common.PVDeviceIdentityAnnotation: "serial-1"}),
+		// no longer a duplicate
+		newPV("pv-e", "/mnt/local-storage/sc/disk-e", "node-a", time.Minute, map[string]string{common.PVDuplicateOfAnnotation: "pv-a"}),
+		// directories are never duplicates of each other
+		newPV("pv-f", "/mnt/local-storage/sc/dir-f", "node-a", time.Hour, nil),
+		newPV("pv-g", "/mnt/local-storage/sc/dir-g", "node-a", time.Minute, nil),
+		// the PVs of other nodes are left alone
+		newPV("pv-h", "/mnt/local-storage/sc/disk-a", "node-b", time.Minute, nil),
+	}
+
+	scheme, err := localv1alpha1.SchemeBuilder.Build()
+	assert.NoError(t, err)
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	fakeClient := crFake.NewFakeClientWithScheme(scheme, objects...)
+	recorder := record.NewFakeRecorder(10)
+	runtimeConfig := &provCommon.RuntimeConfig{
+		UserConfig: &provCommon.UserConfig{Node: &corev1.Node{}},
+		Recorder:   recorder,
+	}
+	runtimeConfig.Name = "node-a"
+	r := &ReconcileDeleter{
+		client:        fakeClient,
+		scheme:        scheme,
+		runtimeConfig: runtimeConfig,
+	}
+
+	err = r.flagDuplicatePVs(logf.Log)
+	assert.NoError(t, err)
+	expected := map[string]string{"pv-b": "pv-a", "pv-d": "pv-c"}
+	for _, name := range []string{"pv-a", "pv-b", "pv-c", "pv-d", "pv-e", "pv-f", "pv-g", "pv-h"} {
+		pv := &corev1.PersistentVolume{}
+		err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: name}, pv)
+		assert.NoError(t, err)
+		original, flagged := pv.Annotations[common.PVDuplicateOfAnnotation]
+		assert.Equalf(t, expected[name], original, "%s annotation of %s", common.PVDuplicateOfAnnotation, name)
+		_, expectFlagged := expected[name]
+		assert.Equalf(t, expectFlagged, flagged, "%s flagged", name)
+	}
+	assert.Len(t, recorder.Events, 2, "an event for each duplicate")
+
+	// the PVs are only flagged once
+	err = r.flagDuplicatePVs(logf.Log)
+	assert.NoError(t, err)
+	assert.Len(t, recorder.Events, 2)
+}
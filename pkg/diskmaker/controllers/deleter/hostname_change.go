@@ -0,0 +1,142 @@
+package deleter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/diskmaker"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// listBlockDevices lists the devices of the node, to find the PVs whose device moved to the node
+var listBlockDevices = diskmaker.Devices.ListBlockDevices
+
+// movedPVs returns the local PVs created on a node with another hostname for a device that is now on this node,
+// matched by the serial number or partition UUID recorded on the PV. The PVs of a hostname that a node still has
+// are left alone: their device is shared by the nodes, like a SAN LUN, or more than one device reports the serial.
+func movedPVs(pvs []corev1.PersistentVolume, identities sets.String, hostname string, nodeHostnames sets.String) []corev1.PersistentVolume {
+	moved := []corev1.PersistentVolume{}
+	for _, pv := range pvs {
+		identity := pv.Annotations[common.PVDeviceIdentityAnnotation]
+		pvHostname, found := pv.Labels[corev1.LabelHostname]
+		if pv.Spec.Local == nil || identity == "" || !found || !pv.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if pvHostname == hostname || nodeHostnames.Has(pvHostname) || !identities.Has(identity) {
+			continue
+		}
+		moved = append(moved, pv)
+	}
+	return moved
+}
+
+// relabelMovedPVs handles the PVs whose device is now on this node under a new hostname, like after the node
+// was re-imaged. The node affinity of a PV can't be changed, so the unbound PVs are deleted, and the LocalVolume
+// and LocalVolumeSet controllers create them again with the node affinity of the new hostname.
+// The bound PVs are left in place and flagged with PVHostnameChangedAnnotation,
+// the annotation is removed once the PV no longer moved, like when the node got its hostname back.
+func (r *ReconcileDeleter) relabelMovedPVs(reqLogger logr.Logger) error {
+	hostname, found := r.runtimeConfig.Node.Labels[corev1.LabelHostname]
+	if !found {
+		return nil
+	}
+	devices, _, err := listBlockDevices()
+	if err != nil {
+		return fmt.Errorf("could not list the devices of the node: %w", err)
+	}
+	identities := sets.NewString()
+	for _, device := range devices {
+		if identity := device.StableIdentity(); identity != "" {
+			identities.Insert(identity)
+		}
+	}
+
+	nodes := &corev1.NodeList{}
+	err = r.client.List(context.TODO(), nodes)
+	if err != nil {
+		return fmt.Errorf("could not list nodes: %w", err)
+	}
+	nodeHostnames := sets.NewString()
+	for _, node := range nodes.Items {
+		if nodeHostname, found := node.Labels[corev1.LabelHostname]; found {
+			nodeHostnames.Insert(nodeHostname)
+		}
+	}
+
+	pvList := &corev1.PersistentVolumeList{}
+	err = r.client.List(context.TODO(), pvList)
+	if err != nil {
+		return fmt.Errorf("could not list PVs: %w", err)
+	}
+
+	moved := sets.NewString()
+	for _, pv := range movedPVs(pvList.Items, identities, hostname, nodeHostnames) {
+		moved.Insert(pv.Name)
+		pvLogger := reqLogger.WithValues("pvName", pv.Name, "oldHostname", pv.Labels[corev1.LabelHostname], "hostname", hostname)
+		if pv.Spec.ClaimRef == nil && pv.Status.Phase == corev1.VolumeAvailable {
+			pvLogger.Info("deleting the unbound PV of a device that is now on the node under a new hostname")
+			// the precondition fails if the PV was bound meanwhile
+			err = r.client.Delete(context.TODO(), &pv, client.Preconditions{ResourceVersion: &pv.ResourceVersion})
+			if err != nil && !kerrors.IsNotFound(err) {
+				return fmt.Errorf("could not delete PV %q of a device that moved to the node: %w", pv.Name, err)
+			}
+			r.runtimeConfig.Recorder.Eventf(&pv, corev1.EventTypeNormal, common.PVHostnameChanged,
+				"The device of the PV is now on the node with hostname %s, the PV is deleted to be created again with the new node affinity", hostname)
+			continue
+		}
+		if pv.Annotations[common.PVHostnameChangedAnnotation] == hostname {
+			continue
+		}
+		err = r.setHostnameChanged(pv.Name, hostname)
+		if err != nil {
+			return fmt.Errorf("could not update the %s annotation of PV %q: %w", common.PVHostnameChangedAnnotation, pv.Name, err)
+		}
+		pvLogger.Info("the device of the bound PV is now on the node under a new hostname")
+		r.runtimeConfig.Recorder.Eventf(&pv, corev1.EventTypeWarning, common.PVHostnameChanged,
+			"The device of the %s PV is now on the node with hostname %s, the PV keeps its node affinity and is flagged with the %s annotation",
+			pv.Status.Phase, hostname, common.PVHostnameChangedAnnotation)
+	}
+
+	// only the PVs of the devices of this node are unflagged, the other nodes handle theirs
+	for _, pv := range pvList.Items {
+		if _, flagged := pv.Annotations[common.PVHostnameChangedAnnotation]; !flagged || moved.Has(pv.Name) ||
+			!identities.Has(pv.Annotations[common.PVDeviceIdentityAnnotation]) {
+			continue
+		}
+		err = r.setHostnameChanged(pv.Name, "")
+		if err != nil {
+			return fmt.Errorf("could not remove the %s annotation of PV %q: %w", common.PVHostnameChangedAnnotation, pv.Name, err)
+		}
+		reqLogger.Info("PV no longer moved to a new hostname", "pvName", pv.Name)
+	}
+	return nil
+}
+
+// setHostnameChanged sets the PVHostnameChangedAnnotation of the PV to hostname, or removes it if hostname is empty
+func (r *ReconcileDeleter) setHostnameChanged(pvName, hostname string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pv := &corev1.PersistentVolume{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: pvName}, pv)
+		if kerrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if hostname == "" {
+			delete(pv.Annotations, common.PVHostnameChangedAnnotation)
+		} else {
+			if pv.Annotations == nil {
+				pv.Annotations = map[string]string{}
+			}
+			pv.Annotations[common.PVHostnameChangedAnnotation] = hostname
+		}
+		return r.client.Update(context.TODO(), pv)
+	})
+}
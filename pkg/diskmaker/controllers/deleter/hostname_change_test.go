@@ -0,0 +1,111 @@
+package deleter
+
+import (
+	"context"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/diskmaker"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	crFake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	provCommon "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/common"
+)
+
+func TestRelabelMovedPVs(t *testing.T) {
+	listBlockDevices = func() ([]internal.BlockDevice, []string, error) {
+		return []internal.BlockDevice{
+			{KName: "sdb", Serial: "serial-1"},
+			{KName: "sdc", Serial: "serial-2"},
+			{KName: "sdd", Serial: "serial-3"},
+			{KName: "sde", Serial: "serial-4"},
+			{KName: "sdf", Serial: "serial-5"},
+		}, nil, nil
+	}
+	defer func() { listBlockDevices = diskmaker.Devices.ListBlockDevices }()
+
+	newNode := func(name string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelHostname: name}}}
+	}
+	newPV := func(name, hostname, serial string, claimed bool, annotations map[string]string) *corev1.PersistentVolume {
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      map[string]string{corev1.LabelHostname: hostname},
+				Annotations: map[string]string{provCommon.AnnProvisionedBy: hostname, common.PVDeviceIdentityAnnotation: serial},
+			},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{Local: &corev1.LocalVolumeSource{Path: "/mnt/local-storage/sc/" + serial}},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeAvailable},
+		}
+		if claimed {
+			pv.Spec.ClaimRef = &corev1.ObjectReference{Namespace: "default", Name: "claim-" + name}
+			pv.Status.Phase = corev1.VolumeBound
+		}
+		for key, value := range annotations {
+			pv.Annotations[key] = value
+		}
+		return pv
+	}
+	node := newNode("new-host")
+	objects := []runtime.Object{
+		node,
+		newNode("other-host"),
+		// the unbound PV of a device of the node under its old hostname is recreated
+		newPV("pv-a", "old-host", "serial-1", false, nil),
+		// the bound one is flagged
+		newPV("pv-b", "old-host", "serial-2", true, nil),
+		// a hostname that a node still has is a shared device
+		newPV("pv-c", "other-host", "serial-3", false, nil),
+		// the PVs of the node are left alone
+		newPV("pv-d", "new-host", "serial-4", false, nil),
+		// a device that isn't on the node
+		newPV("pv-e", "old-host", "serial-6", false, nil),
+		// a PV that was flagged for a hostname that is back
+		newPV("pv-f", "other-host", "serial-5", true, map[string]string{common.PVHostnameChangedAnnotation: "new-host"}),
+	}
+
+	scheme, err := localv1alpha1.SchemeBuilder.Build()
+	assert.NoError(t, err)
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	fakeClient := crFake.NewFakeClientWithScheme(scheme, objects...)
+	recorder := record.NewFakeRecorder(10)
+	runtimeConfig := &provCommon.RuntimeConfig{
+		UserConfig: &provCommon.UserConfig{Node: node},
+		Recorder:   recorder,
+	}
+	r := &ReconcileDeleter{
+		client:        fakeClient,
+		scheme:        scheme,
+		runtimeConfig: runtimeConfig,
+	}
+
+	err = r.relabelMovedPVs(logf.Log)
+	assert.NoError(t, err)
+
+	pv := &corev1.PersistentVolume{}
+	err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "pv-a"}, pv)
+	assert.True(t, kerrors.IsNotFound(err), "the unbound PV should be deleted to be created again")
+	expected := map[string]string{"pv-b": "new-host"}
+	for _, name := range []string{"pv-b", "pv-c", "pv-d", "pv-e", "pv-f"} {
+		pv := &corev1.PersistentVolume{}
+		err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: name}, pv)
+		assert.NoError(t, err)
+		assert.Equalf(t, expected[name], pv.Annotations[common.PVHostnameChangedAnnotation], "%s annotation of %s", common.PVHostnameChangedAnnotation, name)
+	}
+	assert.Len(t, recorder.Events, 2, "an event for the deleted and the flagged PV")
+
+	// the PVs are only flagged once
+	err = r.relabelMovedPVs(logf.Log)
+	assert.NoError(t, err)
+	assert.Len(t, recorder.Events, 2)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
 	"github.com/openshift/local-storage-operator/pkg/common"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -19,6 +20,7 @@ import (
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileDeleter) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	defer common.ObserveReconcileDuration(ComponentName)()
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Looking for released PVs to clean up")
 	// enqueue if cache is not initialized
@@ -48,6 +50,10 @@ func (r *ReconcileDeleter) Reconcile(request reconcile.Request) (reconcile.Resul
 	r.runtimeConfig.UseAlphaAPI = provisionerConfig.UseAlphaAPI
 	r.runtimeConfig.LabelsForPV = provisionerConfig.LabelsForPV
 
+	// deletionBackoff isn't a setting of the static provisioner, the operator validated it
+	deletionBackoff, _ := time.ParseDuration(cm.Data[localv1.ProvisionerConfigDeletionBackoff])
+	r.procTable.setDeletionBackoff(deletionBackoff)
+
 	// initialize the pv cache
 	// initialize the deleter's pv cache on the first run
 	if !r.firstRunOver {
@@ -76,6 +82,28 @@ func (r *ReconcileDeleter) Reconcile(request reconcile.Request) (reconcile.Resul
 		r.firstRunOver = true
 	}
 
+	err = r.quarantineTimedOutCleanups(reqLogger)
+	if err != nil {
+		reqLogger.Error(err, "failed to quarantine PVs whose cleanup timed out")
+		return reconcile.Result{}, err
+	}
+
+	err = r.flagDuplicatePVs(reqLogger)
+	if err != nil {
+		reqLogger.Error(err, "failed to flag PVs that use the same device")
+		return reconcile.Result{}, err
+	}
+
+	err = r.relabelMovedPVs(reqLogger)
+	if err != nil {
+		reqLogger.Error(err, "failed to handle the PVs whose device moved to a new hostname")
+		return reconcile.Result{}, err
+	}
+
 	r.deleter.DeletePVs()
-	return reconcile.Result{RequeueAfter: time.Second * 30}, nil
+	requeueAfter := r.options.ResyncPeriodOrDefault(time.Second * 30)
+	if provisionerConfig.MinResyncPeriod.Duration > 0 {
+		requeueAfter = provisionerConfig.MinResyncPeriod.Duration
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
 }
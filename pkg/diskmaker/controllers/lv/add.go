@@ -1,9 +1,12 @@
 package lv
 
 import (
+	"context"
+
 	"github.com/openshift/local-storage-operator/pkg/apis"
 	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
 	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/diskmaker"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -36,7 +39,7 @@ const (
 var log = logf.Log.WithName(ComponentName)
 
 // Add adds a new nodeside lv controller to mgr
-func Add(mgr manager.Manager, cleanupTracker *provDeleter.CleanupStatusTracker, pvCache *provCache.VolumeCache) error {
+func Add(mgr manager.Manager, cleanupTracker *provDeleter.CleanupStatusTracker, pvCache *provCache.VolumeCache, options common.Options) error {
 	apis.AddToScheme(mgr.GetScheme())
 	// populate the pv cache
 	clientSet := provCommon.SetupClient()
@@ -62,6 +65,9 @@ func Add(mgr manager.Manager, cleanupTracker *provDeleter.CleanupStatusTracker,
 		cleanupTracker:  cleanupTracker,
 		runtimeConfig:   runtimeConfig,
 		deleter:         provDeleter.NewDeleter(runtimeConfig, cleanupTracker),
+		options:         options,
+
+		deviceSettleTracker: newDeviceSettleTracker(),
 	}
 	// Create a new controller
 	c, err := controller.New(ComponentName, mgr, controller.Options{Reconciler: r})
@@ -78,6 +84,14 @@ func Add(mgr manager.Manager, cleanupTracker *provDeleter.CleanupStatusTracker,
 		return err
 	}
 
+	// reconcile all LocalVolumes when block devices are hot-plugged on the node
+	err = c.Watch(&source.Channel{Source: diskmaker.SubscribeDeviceEvents()}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: allLocalVolumes(mgr.GetClient()),
+	})
+	if err != nil {
+		return err
+	}
+
 	// TODO enqueue for the PV based on labels
 
 	// update owned-pv cache used by provisioner/deleter libs and enequeue owning lvset
@@ -114,6 +128,23 @@ func Add(mgr manager.Manager, cleanupTracker *provDeleter.CleanupStatusTracker,
 	return nil
 }
 
+// allLocalVolumes maps any object to the LocalVolumes in the watched namespace
+func allLocalVolumes(c client.Client) handler.ToRequestsFunc {
+	return func(handler.MapObject) []reconcile.Request {
+		lvs := &localv1.LocalVolumeList{}
+		err := c.List(context.TODO(), lvs, client.InNamespace(common.GetWatchNameSpaceEnfVar()))
+		if err != nil {
+			log.Error(err, "failed to list LocalVolumes")
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(lvs.Items))
+		for _, lv := range lvs.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: lv.Name, Namespace: lv.Namespace}})
+		}
+		return requests
+	}
+}
+
 func handlePVChange(runtimeConfig *provCommon.RuntimeConfig, pv *corev1.PersistentVolume, q workqueue.RateLimitingInterface, isDelete bool) {
 	// skip non-owned PVs
 	name, found := pv.Annotations[provCommon.AnnProvisionedBy]
@@ -150,6 +181,9 @@ type ReconcileLocalVolume struct {
 	runtimeConfig  *provCommon.RuntimeConfig
 	deleter        *provDeleter.Deleter
 	firstRunOver   bool
+	options        common.Options
+
+	deviceSettleTracker *deviceSettleTracker
 }
 
 var _ reconcile.Reconciler = &ReconcileLocalVolume{}
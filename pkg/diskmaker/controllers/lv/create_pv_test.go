@@ -207,19 +207,18 @@ func TestCreatePV(t *testing.T) {
 		}
 		testConfig.fakeVolUtil.AddNewDirEntries("/mnt/local-storage/", dirFiles)
 
-		err := common.CreateLocalPV(
-			&tc.lv,
-			r.runtimeConfig,
-			r.cleanupTracker,
-			log.WithName("testLogger"),
-			tc.sc,
-			tc.mountPoints,
-			r.client,
-			tc.symlinkpath,
-			tc.deviceName,
-			true,
-			map[string]string{},
-		)
+		err := common.CreateLocalPV(common.CreateLocalPVArgs{
+			LocalVolumeLikeObject: &tc.lv,
+			RuntimeConfig:         r.runtimeConfig,
+			CleanupTracker:        r.cleanupTracker,
+			StorageClass:          tc.sc,
+			MountPointMap:         tc.mountPoints,
+			Client:                r.client,
+			SymLinkPath:           tc.symlinkpath,
+			DeviceName:            tc.deviceName,
+			IDExists:              true,
+			ExtraLabelsForPV:      map[string]string{},
+		}, log.WithName("testLogger"))
 		if tc.shouldErr {
 			assert.NotNil(t, err)
 		} else {
@@ -257,20 +256,24 @@ func TestCreatePV(t *testing.T) {
 		// reclaimPolicy accurate,
 		assert.Equal(t, *tc.sc.ReclaimPolicy, pv.Spec.PersistentVolumeReclaimPolicy)
 
+		// owner labels accurate
+		assert.Equal(t, tc.lv.Kind, pv.Labels[common.OwnerKindLabel])
+		assert.Equal(t, tc.lv.Name, pv.Labels[common.OwnerNameLabel])
+		assert.Equal(t, tc.lv.Namespace, pv.Labels[common.OwnerNamespaceLabel])
+
 		// test idempotency by running again
-		err = common.CreateLocalPV(
-			&tc.lv,
-			r.runtimeConfig,
-			r.cleanupTracker,
-			log.WithName("testLogger"),
-			tc.sc,
-			tc.mountPoints,
-			r.client,
-			tc.symlinkpath,
-			tc.deviceName,
-			true,
-			map[string]string{},
-		)
+		err = common.CreateLocalPV(common.CreateLocalPVArgs{
+			LocalVolumeLikeObject: &tc.lv,
+			RuntimeConfig:         r.runtimeConfig,
+			CleanupTracker:        r.cleanupTracker,
+			StorageClass:          tc.sc,
+			MountPointMap:         tc.mountPoints,
+			Client:                r.client,
+			SymLinkPath:           tc.symlinkpath,
+			DeviceName:            tc.deviceName,
+			IDExists:              true,
+			ExtraLabelsForPV:      map[string]string{},
+		}, log.WithName("testLogger"))
 		assert.Nil(t, err)
 
 	}
@@ -0,0 +1,72 @@
+package lv
+
+import (
+	"sync"
+	"time"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+)
+
+// defaultDeviceSettleGracePeriod is how long a new device is left alone when spec.deviceSettleGracePeriod is unset
+const defaultDeviceSettleGracePeriod = 5 * time.Second
+
+// deviceSettleGracePeriod returns how long the devices of the LocalVolume are left alone after they are first seen
+func deviceSettleGracePeriod(lv *localv1.LocalVolume) time.Duration {
+	if lv.Spec.DeviceSettleGracePeriod == nil {
+		return defaultDeviceSettleGracePeriod
+	}
+	if lv.Spec.DeviceSettleGracePeriod.Duration < 0 {
+		return 0
+	}
+	return lv.Spec.DeviceSettleGracePeriod.Duration
+}
+
+// deviceSettleTracker records when the diskmaker first saw the block devices of the node, by kernel name,
+// so that hot-plugged devices are symlinked once udev has created their links
+type deviceSettleTracker struct {
+	firstSeen map[string]time.Time
+	mux       sync.Mutex
+	now       func() time.Time
+}
+
+func newDeviceSettleTracker() *deviceSettleTracker {
+	return &deviceSettleTracker{
+		firstSeen: map[string]time.Time{},
+		now:       time.Now,
+	}
+}
+
+// observe records the devices seen for the first time and forgets the devices that are gone,
+// a kernel name reused by another device is then seen as new
+func (t *deviceSettleTracker) observe(blockDevices []internal.BlockDevice) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	now := t.now()
+	seen := map[string]time.Time{}
+	for _, blockDevice := range blockDevices {
+		firstSeen, found := t.firstSeen[blockDevice.KName]
+		if !found {
+			firstSeen = now
+		}
+		seen[blockDevice.KName] = firstSeen
+	}
+	t.firstSeen = seen
+}
+
+// unsettledFor returns how long the device still has to settle after the grace period, 0 once it has settled
+func (t *deviceSettleTracker) unsettledFor(kname string, gracePeriod time.Duration) time.Duration {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	firstSeen, found := t.firstSeen[kname]
+	if !found {
+		return gracePeriod
+	}
+	remaining := gracePeriod - t.now().Sub(firstSeen)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
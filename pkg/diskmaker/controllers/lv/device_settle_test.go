@@ -0,0 +1,46 @@
+package lv
+
+import (
+	"testing"
+	"time"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeviceSettleGracePeriod(t *testing.T) {
+	lv := &localv1.LocalVolume{}
+	assert.Equal(t, defaultDeviceSettleGracePeriod, deviceSettleGracePeriod(lv))
+	lv.Spec.DeviceSettleGracePeriod = &metav1.Duration{Duration: 30 * time.Second}
+	assert.Equal(t, 30*time.Second, deviceSettleGracePeriod(lv))
+	lv.Spec.DeviceSettleGracePeriod = &metav1.Duration{}
+	assert.Zero(t, deviceSettleGracePeriod(lv), "0s disables the wait")
+}
+
+func TestDeviceSettleTracker(t *testing.T) {
+	now := time.Now()
+	tracker := newDeviceSettleTracker()
+	tracker.now = func() time.Time { return now }
+	gracePeriod := 5 * time.Second
+
+	assert.Equal(t, gracePeriod, tracker.unsettledFor("sdb", gracePeriod), "a device that was never seen has to settle")
+	tracker.observe([]internal.BlockDevice{{KName: "sdb"}})
+	assert.Equal(t, gracePeriod, tracker.unsettledFor("sdb", gracePeriod))
+
+	now = now.Add(2 * time.Second)
+	tracker.observe([]internal.BlockDevice{{KName: "sdb"}, {KName: "sdc"}})
+	assert.Equal(t, 3*time.Second, tracker.unsettledFor("sdb", gracePeriod), "the first observation is kept")
+	assert.Equal(t, gracePeriod, tracker.unsettledFor("sdc", gracePeriod))
+	assert.Zero(t, tracker.unsettledFor("sdc", 0))
+
+	now = now.Add(4 * time.Second)
+	assert.Zero(t, tracker.unsettledFor("sdb", gracePeriod), "the device has settled")
+
+	// a device that is gone is forgotten, the next device with its name has to settle
+	tracker.observe([]internal.BlockDevice{{KName: "sdc"}})
+	tracker.observe([]internal.BlockDevice{{KName: "sdb"}, {KName: "sdc"}})
+	assert.Equal(t, gracePeriod, tracker.unsettledFor("sdb", gracePeriod))
+	assert.Equal(t, time.Second, tracker.unsettledFor("sdc", gracePeriod))
+}
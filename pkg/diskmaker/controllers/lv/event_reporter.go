@@ -13,11 +13,12 @@ import (
 
 const (
 	// LocalVolume events
-	ErrorRunningBlockList    = "ErrorRunningBlockList"
-	ErrorReadingBlockList    = "ErrorReadingBlockList"
-	ErrorListingDeviceID     = "ErrorListingDeviceID"
-	ErrorFindingMatchingDisk = "ErrorFindingMatchingDisk"
-	ErrorCreatingSymLink     = "ErrorCreatingSymLink"
+	ErrorRunningBlockList      = "ErrorRunningBlockList"
+	ErrorReadingBlockList      = "ErrorReadingBlockList"
+	ErrorListingDeviceID       = "ErrorListingDeviceID"
+	ErrorFindingMatchingDisk   = "ErrorFindingMatchingDisk"
+	ErrorCreatingSymLink       = "ErrorCreatingSymLink"
+	ErrorProvisioningDirectory = "ErrorProvisioningDirectory"
 
 	FoundMatchingDisk     = "FoundMatchingDisk"
 	DeviceSymlinkExists   = "DeviceSymlinkExists"
@@ -1,11 +1,9 @@
 package lv
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"strings"
@@ -13,6 +11,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/diskmaker"
 	"github.com/openshift/local-storage-operator/pkg/internal"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -24,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog"
 )
@@ -55,6 +55,7 @@ func (r *ReconcileLocalVolume) createSymlink(
 	symLinkTarget string,
 	devLogger logr.Logger,
 	idExists bool,
+	useBindMount bool,
 ) bool {
 	// get PV creation lock which checks for existing symlinks to this device
 	pvLock, pvLocked, existingSymlinks, err := internal.GetPVCreationLock(
@@ -74,7 +75,7 @@ func (r *ReconcileLocalVolume) createSymlink(
 	if len(existingSymlinks) > 0 { // already claimed, fail silently
 		for _, path := range existingSymlinks {
 			if path == symLinkTarget { // symlinked in this folder, ensure the PV exists
-				return true
+				return !useBindMount || r.remountBindMount(symLinkSource, symLinkTarget)
 			}
 		}
 		return false
@@ -95,10 +96,14 @@ func (r *ReconcileLocalVolume) createSymlink(
 
 	if fileExists(symLinkTarget) {
 		klog.V(4).Infof("symlink %s already exists", symLinkTarget)
-		return true
+		return !useBindMount || r.remountBindMount(symLinkSource, symLinkTarget)
 	}
 
-	err = os.Symlink(symLinkSource, symLinkTarget)
+	if useBindMount {
+		err = internal.BindMountDevice(symLinkSource, symLinkTarget)
+	} else {
+		err = os.Symlink(symLinkSource, symLinkTarget)
+	}
 	if err != nil {
 		msg := fmt.Sprintf("error creating symlink %s: %v", symLinkTarget, err)
 		r.eventSync.Report(r.localVolume, newDiskEvent(ErrorFindingMatchingDisk, msg, symLinkSource, corev1.EventTypeWarning))
@@ -116,6 +121,27 @@ func (r *ReconcileLocalVolume) createSymlink(
 	return true
 
 }
+
+// remountBindMount bind-mounts the device again on an existing target that a reboot left unmounted,
+// so that the PV doesn't point to an empty file
+func (r *ReconcileLocalVolume) remountBindMount(symLinkSource, symLinkTarget string) bool {
+	mounted, err := internal.IsMountPoint(symLinkTarget)
+	if err == nil && mounted {
+		return true
+	}
+	if err == nil {
+		err = internal.BindMountDevice(symLinkSource, symLinkTarget)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("error bind-mounting %s again: %v", symLinkTarget, err)
+		r.eventSync.Report(r.localVolume, newDiskEvent(ErrorFindingMatchingDisk, msg, symLinkSource, corev1.EventTypeWarning))
+		klog.Errorf(msg)
+		return false
+	}
+	klog.Infof("bind-mounted %s again on %s", symLinkSource, symLinkTarget)
+	return true
+}
+
 func diskMakerLabels(crName string) map[string]string {
 	return map[string]string{
 		"app": fmt.Sprintf("local-volume-diskmaker-%s", crName),
@@ -133,6 +159,10 @@ func (r *ReconcileLocalVolume) generateConfig() *DiskConfig {
 
 	storageClassDevices := r.localVolume.Spec.StorageClassDevices
 	for _, storageClassDevice := range storageClassDevices {
+		// directories are provisioned by provisionSourceDirs
+		if storageClassDevice.SourceDir != nil {
+			continue
+		}
 		disks := new(Disks)
 		if len(storageClassDevice.DevicePaths) > 0 {
 			disks.DevicePaths = storageClassDevice.DevicePaths
@@ -174,6 +204,8 @@ func addOwnerLabels(meta *metav1.ObjectMeta, cr *localv1.LocalVolume) bool {
 func (r *ReconcileLocalVolume) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("request.namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling LocalVolume")
+	defer diskmaker.TrackReconcile()()
+	defer common.ObserveReconcileDuration(ComponentName)()
 
 	lv := &localv1.LocalVolume{}
 	err := r.client.Get(context.TODO(), request.NamespacedName, lv)
@@ -189,9 +221,19 @@ func (r *ReconcileLocalVolume) Reconcile(request reconcile.Request) (reconcile.R
 
 	r.localVolume = lv
 
-	// don't provision for deleted lvs
+	// don't provision for deleted lvs, only remove the symlinks of the lvs that leave their PVs to another tool
 	if !lv.DeletionTimestamp.IsZero() {
-		return reconcile.Result{}, nil
+		err = r.removeSymlinks(lv, os.Getenv("MY_NODE_NAME"), reqLogger)
+		if err != nil {
+			reqLogger.Error(err, "failed to remove the symlinks of the deleted LocalVolume")
+		}
+		return reconcile.Result{}, err
+	}
+
+	// don't provision for paused lvs, check again later in case they are resumed
+	if common.IsPaused(lv) {
+		reqLogger.Info("reconciliation is paused", "annotation", common.PausedAnnotation)
+		return reconcile.Result{Requeue: true, RequeueAfter: r.options.ResyncPeriodOrDefault(checkDuration)}, nil
 	}
 
 	// ignore LocalVolumes whose LabelSelector doesn't match this node
@@ -213,6 +255,22 @@ func (r *ReconcileLocalVolume) Reconcile(request reconcile.Request) (reconcile.R
 		return reconcile.Result{}, nil
 	}
 
+	// wait for the host paths instead of failing every device, e.g. on nodes that mount /dev read-only
+	err = diskmaker.CheckHostPaths(r.options.DiscoveryOnly)
+	if err != nil {
+		reqLogger.Error(err, "host paths are unavailable, not provisioning", "retryAfter", diskmaker.HostPathRetryPeriod)
+		r.eventSync.Report(lv, newDiskEvent(diskmaker.HostPathUnavailable, err.Error(), "", corev1.EventTypeWarning))
+		return reconcile.Result{RequeueAfter: diskmaker.HostPathRetryPeriod}, nil
+	}
+
+	if !r.options.DiscoveryOnly {
+		err = r.syncSymlinkedNode(lv, r.runtimeConfig.Node.Name)
+		if err != nil {
+			reqLogger.Error(err, "failed to record the node in status.symlinkedNodes")
+			return reconcile.Result{}, err
+		}
+	}
+
 	// get associated provisioner config
 	cm := &corev1.ConfigMap{}
 	err = r.client.Get(context.TODO(), types.NamespacedName{Name: common.ProvisionerConfigMapName, Namespace: request.Namespace}, cm)
@@ -246,23 +304,30 @@ func (r *ReconcileLocalVolume) Reconcile(request reconcile.Request) (reconcile.R
 		klog.Errorf("error creating local-storage directory %s: %v", r.symlinkLocation, err)
 		os.Exit(-1)
 	}
-	diskConfig := r.generateConfig()
-	// run command lsblk --all --noheadings --pairs --output "KNAME,PKNAME,TYPE,MOUNTPOINT"
-	// the reason we are using KNAME instead of NAME is because for lvm disks(and may be others)
-	// the NAME and device file in /dev directory do not match.
-	cmd := exec.Command("lsblk", "--all", "--noheadings", "--pairs", "--output", "KNAME,PKNAME,TYPE,MOUNTPOINT")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	err = cmd.Run()
-	if err != nil {
-		msg := fmt.Sprintf("error running lsblk: %v", err)
-		r.eventSync.Report(r.localVolume, newDiskEvent(ErrorRunningBlockList, msg, "", corev1.EventTypeWarning))
-		klog.Errorf(msg)
-		return reconcile.Result{}, err
+
+	if hasSourceDirs(lv) && !r.options.DiscoveryOnly {
+		mountPointMap, err := common.GenerateMountMap(r.runtimeConfig)
+		if err != nil {
+			reqLogger.Error(err, "failed to generate mountPointMap")
+			return reconcile.Result{}, err
+		}
+		err = r.provisionSourceDirs(lv, mountPointMap, reqLogger)
+		if err != nil {
+			reqLogger.Error(err, "failed to provision source directories")
+		}
 	}
 
-	// list block devices
-	blockDevices, badRows, err := internal.ListBlockDevices()
+	if !r.options.DiscoveryOnly {
+		err = r.trimFilesystems(lv, reqLogger)
+		if err != nil {
+			// trimming is best effort, it doesn't keep the devices from being provisioned
+			reqLogger.Error(err, "failed to trim the filesystems of some PVs")
+		}
+	}
+
+	diskConfig := r.generateConfig()
+	// list block devices, the listing is cached until a udev event or the cache's TTL
+	blockDevices, badRows, err := diskmaker.Devices.ListBlockDevices()
 	if err != nil {
 		msg := fmt.Sprintf("failed to list block devices: %v", err)
 		r.eventSync.Report(r.localVolume, newDiskEvent(ErrorRunningBlockList, msg, "", corev1.EventTypeWarning))
@@ -273,6 +338,13 @@ func (r *ReconcileLocalVolume) Reconcile(request reconcile.Request) (reconcile.R
 		r.eventSync.Report(r.localVolume, newDiskEvent(ErrorRunningBlockList, msg, "", corev1.EventTypeWarning))
 		klog.Errorf(msg, "could not parse all the lsblk rows", "lsblk.BadRows", badRows)
 	}
+	diskmaker.MarkDiscoveryComplete()
+	r.deviceSettleTracker.observe(blockDevices)
+
+	// only directories are provisioned, recheck them in case their PVs are released
+	if len(diskConfig.Disks) == 0 {
+		return reconcile.Result{Requeue: true, RequeueAfter: r.options.ResyncPeriodOrDefault(checkDuration)}, nil
+	}
 
 	validBlockDevices := make([]internal.BlockDevice, 0)
 	for _, blockDevice := range blockDevices {
@@ -333,54 +405,172 @@ func (r *ReconcileLocalVolume) Reconcile(request reconcile.Request) (reconcile.R
 	}
 
 	var errors []error
+	requeueAfter := r.options.ResyncPeriodOrDefault(checkDuration)
+	settleGracePeriod := deviceSettleGracePeriod(lv)
 
 	for storageClassName, deviceArray := range deviceMap {
 		for _, deviceNameLocation := range deviceArray {
 			devLogger := reqLogger.WithValues("Device.Name", deviceNameLocation.diskNamePath)
 			symLinkDirPath := path.Join(r.symlinkLocation, storageClassName)
-			source, target, idExists, err := common.GetSymLinkSourceAndTarget(deviceNameLocation.blockDevice, symLinkDirPath)
+			source, target, idExists, err := common.GetSymLinkSourceAndTarget(deviceNameLocation.blockDevice, symLinkDirPath, nil)
 			if err != nil {
 				reqLogger.Error(err, "failed to get symlink source and target")
 				errors = append(errors, err)
 				break
 			}
-			shouldCreatePV := r.createSymlink(deviceNameLocation, source, target, devLogger, idExists)
-			if shouldCreatePV {
-				storageClass := &storagev1.StorageClass{}
-				err := r.client.Get(context.TODO(), types.NamespacedName{Name: storageClassName}, storageClass)
-				if err != nil {
-					devLogger.Error(err, "failed to fetch storageClass")
-					errors = append(errors, err)
-					break
-				}
-				lvOwnerLabels := map[string]string{
-					common.LocalVolumeOwnerNameForPV:      r.localVolume.Name,
-					common.LocalVolumeOwnerNamespaceForPV: r.localVolume.Namespace,
-				}
-
-				err = common.CreateLocalPV(
-					lv,
-					r.runtimeConfig,
-					r.cleanupTracker,
-					devLogger,
-					*storageClass,
-					mountPointMap,
-					r.client,
-					target,
-					filepath.Base(deviceNameLocation.diskNamePath),
-					idExists,
-					lvOwnerLabels,
-				)
-				if err != nil {
-					devLogger.Error(err, "could not create local PV")
-					errors = append(errors, err)
-					break
+			if r.options.DiscoveryOnly {
+				devLogger.Info("discovery-only mode, not provisioning matching device", "symlink", target)
+				r.eventSync.Report(r.localVolume, newDiskEvent(diskmaker.FoundMatchingDisk, "found matching disk, not provisioning it in discovery-only mode", deviceNameLocation.blockDevice.KName, corev1.EventTypeNormal))
+				continue
+			}
+			// give udev time to create the links of a new device before it is symlinked by an unstable path
+			if unsettledFor := r.deviceSettleTracker.unsettledFor(deviceNameLocation.blockDevice.KName, settleGracePeriod); unsettledFor > 0 && !fileExists(target) {
+				devLogger.Info("waiting for the new device to settle", "remaining", unsettledFor)
+				if unsettledFor < requeueAfter {
+					requeueAfter = unsettledFor
 				}
+				continue
+			}
+			// don't start symlinking a device while the diskmaker is terminating,
+			// an operation that was started is allowed to finish before the process exits
+			if !diskmaker.BeginDeviceOperation() {
+				devLogger.Info("diskmaker is shutting down, not provisioning")
+				return reconcile.Result{}, nil
+			}
+			err = r.provisionDevice(lv, deviceNameLocation, storageClassName, mountPointMap, source, target, devLogger, idExists)
+			diskmaker.EndDeviceOperation()
+			if err != nil {
+				// keep provisioning the other devices
+				devLogger.Error(err, "failed to provision device")
+				errors = append(errors, err)
 			}
 		}
 	}
+	if len(errors) > 0 {
+		reqLogger.Error(utilerrors.NewAggregate(errors), "failed to provision some devices")
+	}
+
+	return reconcile.Result{Requeue: true, RequeueAfter: requeueAfter}, nil
+}
+
+// provisionDevice symlinks the device and creates the PV for it
+func (r *ReconcileLocalVolume) provisionDevice(
+	lv *localv1.LocalVolume,
+	deviceNameLocation DiskLocation,
+	storageClassName string,
+	mountPointMap sets.String,
+	source string,
+	target string,
+	devLogger logr.Logger,
+	idExists bool,
+) error {
+	shouldCreatePV := r.createSymlink(deviceNameLocation, source, target, devLogger, idExists, useBindMount(lv, storageClassName))
+	if !shouldCreatePV {
+		return nil
+	}
+	storageClass := &storagev1.StorageClass{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: storageClassName}, storageClass)
+	if err != nil {
+		devLogger.Error(err, "failed to fetch storageClass")
+		return err
+	}
+	lvOwnerLabels := map[string]string{
+		common.LocalVolumeOwnerNameForPV:      r.localVolume.Name,
+		common.LocalVolumeOwnerNamespaceForPV: r.localVolume.Namespace,
+	}
+	deviceByID, deviceByPath := common.DeviceLinks(deviceNameLocation.blockDevice, source, idExists, devLogger)
+
+	var prepareDevice func() error
+	if disableLazyInit(lv, storageClassName) {
+		prepareDevice = func() error {
+			return formatWithoutLazyInit(source, devLogger)
+		}
+	}
+
+	err = common.CreateLocalPV(common.CreateLocalPVArgs{
+		LocalVolumeLikeObject: lv,
+		RuntimeConfig:         r.runtimeConfig,
+		CleanupTracker:        r.cleanupTracker,
+		NodeAffinityKey:       r.options.PVNodeAffinityKey,
+		BackupLabels:          r.options.PVBackupLabels,
+		BackupAnnotations:     r.options.PVBackupAnnotations,
+		StorageClass:          *storageClass,
+		MountPointMap:         mountPointMap,
+		Client:                r.client,
+		SymLinkPath:           target,
+		DeviceName:            filepath.Base(deviceNameLocation.diskNamePath),
+		DeviceIdentity:        deviceNameLocation.blockDevice.StableIdentity(),
+		DeviceByID:            deviceByID,
+		DeviceByPath:          deviceByPath,
+		IDExists:              idExists,
+		ExtraLabelsForPV:      lvOwnerLabels,
+		AccessModes:           accessModes(lv, storageClassName),
+		PVNamePrefix:          lv.Spec.PVNamePrefix,
+		PVLabels:              lv.Spec.PVLabels,
+		PVAnnotations:         lv.Spec.PVAnnotations,
+		RecreationLimiter:     diskmaker.PVRecreations,
+		PrepareDevice:         prepareDevice,
+		UnmanagedPV:           !lv.ManagesPersistentVolumes(),
+	}, devLogger)
+	if err != nil {
+		devLogger.Error(err, "could not create local PV")
+		return err
+	}
+	return nil
+}
+
+// useBindMount returns true if the devices of the storageClass should be bind-mounted instead of symlinked
+func useBindMount(lv *localv1.LocalVolume, storageClassName string) bool {
+	for _, devices := range lv.Spec.StorageClassDevices {
+		if devices.StorageClassName == storageClassName {
+			return devices.UseBindMount
+		}
+	}
+	return false
+}
 
-	return reconcile.Result{Requeue: true, RequeueAfter: checkDuration}, nil
+// accessModes returns the access modes of the PVs of the storageClass, nil for the default ReadWriteOnce
+// or when the access modes are not valid
+func accessModes(lv *localv1.LocalVolume, storageClassName string) []corev1.PersistentVolumeAccessMode {
+	for _, devices := range lv.Spec.StorageClassDevices {
+		if devices.StorageClassName == storageClassName && devices.ValidateAccessModes() == nil {
+			return devices.AccessModes
+		}
+	}
+	return nil
+}
+
+// disableLazyInit returns true if the devices of the storageClass should be formatted by the diskmaker
+// with the lazy initialization of ext4 disabled
+func disableLazyInit(lv *localv1.LocalVolume, storageClassName string) bool {
+	for _, devices := range lv.Spec.StorageClassDevices {
+		if devices.StorageClassName == storageClassName {
+			return devices.DisableLazyInit && devices.ValidateDisableLazyInit() == nil
+		}
+	}
+	return false
+}
+
+// formatWithoutLazyInit formats a blank device with ext4, initializing its inode tables and journal up front.
+// kubelet then mounts the existing filesystem instead of formatting the device with lazy initialization.
+// Devices with a filesystem or a partition table are left as they are.
+func formatWithoutLazyInit(device string, devLogger logr.Logger) error {
+	signature, err := internal.GetDeviceSignature(device)
+	if err != nil {
+		return err
+	}
+	if signature != "" {
+		devLogger.Info("device is not blank, not formatting it", "signature", signature)
+		return nil
+	}
+	devLogger.Info("formatting device with ext4 lazy initialization disabled")
+	start := time.Now()
+	err = internal.MakeExt4FS(device, internal.Ext4NoLazyInitOptions)
+	if err != nil {
+		return err
+	}
+	devLogger.Info("formatted device", "duration", time.Since(start).Round(time.Second))
+	return nil
 }
 
 func ignoreDevices(dev internal.BlockDevice) bool {
@@ -394,6 +584,16 @@ func ignoreDevices(dev internal.BlockDevice) bool {
 		return true
 	}
 
+	if isRAIDMember, err := dev.IsRAIDMember(); err != nil || isRAIDMember {
+		klog.Infof("ignoring software RAID member device %q", dev.Name)
+		return true
+	}
+
+	if diskmaker.IsHostExcluded(dev) {
+		klog.Infof("ignoring device %q listed in the host exclude file", dev.Name)
+		return true
+	}
+
 	return false
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"github.com/openshift/local-storage-operator/pkg/internal"
 
 	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	"github.com/openshift/local-storage-operator/pkg/common"
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
@@ -19,6 +21,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -115,6 +118,46 @@ func TestLoadConfig(t *testing.T) {
 	}
 }
 
+func TestGenerateConfigSkipsSourceDirs(t *testing.T) {
+	lv := &localv1.LocalVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foobar",
+			Namespace: "default",
+		},
+		Spec: localv1.LocalVolumeSpec{
+			StorageClassDevices: []localv1.StorageClassDevice{
+				{StorageClassName: "devices", DevicePaths: []string{"/dev/sdb"}},
+				{StorageClassName: "dirs", SourceDir: &localv1.SourceDir{Path: "/var/lib/dirs", Count: 2}},
+			},
+		},
+	}
+	d, _ := getFakeDiskMaker(t, "/mnt/local-storage", lv)
+	d.localVolume = lv
+	assert.True(t, hasSourceDirs(lv))
+
+	diskConfig := d.generateConfig()
+	assert.Contains(t, diskConfig.Disks, "devices")
+	assert.NotContains(t, diskConfig.Disks, "dirs")
+}
+
+func TestCheckSourceDirSize(t *testing.T) {
+	sourceDir := createTmpDir(t, "", "dirs")
+	defer os.RemoveAll(sourceDir)
+	lv := &localv1.LocalVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foobar",
+			Namespace: "default",
+		},
+	}
+	d, _ := getFakeDiskMaker(t, "/mnt/local-storage", lv)
+
+	err := d.checkSourceDirSize(lv, &localv1.SourceDir{Path: sourceDir, Count: 2, Capacity: resource.MustParse("1Mi")})
+	assert.NoError(t, err, "the capacity fits in the filesystem")
+
+	err = d.checkSourceDirSize(lv, &localv1.SourceDir{Path: sourceDir, Count: 1000, Capacity: resource.MustParse("1Ei")})
+	assert.Error(t, err, "the capacity exceeds the filesystem")
+}
+
 func TestCreateSymLinkByDeviceID(t *testing.T) {
 	tmpSymLinkTargetDir := createTmpDir(t, "", "target")
 	fakeDisk := createTmpFile(t, "", "diskName")
@@ -156,7 +199,7 @@ func TestCreateSymLinkByDeviceID(t *testing.T) {
 			},
 		},
 	}
-	d.createSymlink(diskLocation, fakeDiskByID.Name(), path.Join(tmpSymLinkTargetDir, "diskID"), log, true)
+	d.createSymlink(diskLocation, fakeDiskByID.Name(), path.Join(tmpSymLinkTargetDir, "diskID"), log, true, false)
 
 	// assert that target symlink is created for disk ID when both disk name and disk by-id are available
 	assert.Truef(t, hasFile(t, tmpSymLinkTargetDir, "diskID"), "failed to find symlink with disk ID in %s directory", tmpSymLinkTargetDir)
@@ -186,12 +229,55 @@ func TestCreateSymLinkByDeviceName(t *testing.T) {
 
 	d, _ := getFakeDiskMaker(t, tmpSymLinkTargetDir, lv, sc)
 	diskLocation := DiskLocation{fakeDisk.Name(), "", internal.BlockDevice{}}
-	d.createSymlink(diskLocation, fakeDisk.Name(), path.Join(tmpSymLinkTargetDir, "diskName"), log, false)
+	d.createSymlink(diskLocation, fakeDisk.Name(), path.Join(tmpSymLinkTargetDir, "diskName"), log, false, false)
 
 	// assert that target symlink is created for disk name when no disk ID is available
 	assert.Truef(t, hasFile(t, tmpSymLinkTargetDir, "diskName"), "failed to find symlink with disk name in %s directory", tmpSymLinkTargetDir)
 }
 
+func TestCreateSymLinkRemountsBindMount(t *testing.T) {
+	tmpSymLinkTargetDir := createTmpDir(t, "", "target")
+	fakeDisk := createTmpFile(t, "", "diskName")
+	fakeDiskByID := createTmpFile(t, "", "diskID")
+	defer os.RemoveAll(tmpSymLinkTargetDir)
+	defer os.Remove(fakeDisk.Name())
+	defer os.Remove(fakeDiskByID.Name())
+
+	lv := &localv1.LocalVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foobar",
+			Namespace: "default",
+		},
+	}
+	sc := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foobar",
+		},
+	}
+	d, _ := getFakeDiskMaker(t, tmpSymLinkTargetDir, lv, sc)
+	d.localVolume = lv
+	diskLocation := DiskLocation{fakeDisk.Name(), fakeDiskByID.Name(), internal.BlockDevice{}}
+
+	// the empty target file that a reboot left unmounted
+	target := path.Join(tmpSymLinkTargetDir, sc.Name, filepath.Base(fakeDiskByID.Name()))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(target), 0755))
+	assert.NoError(t, ioutil.WriteFile(target, []byte{}, 0600))
+
+	mounts := [][]string{}
+	internal.ExecCommand = func(name string, args ...string) *exec.Cmd {
+		if name != "mount" {
+			return exec.Command(name, args...)
+		}
+		mounts = append(mounts, append([]string{name}, args...))
+		return exec.Command("true")
+	}
+	defer func() { internal.ExecCommand = exec.Command }()
+
+	shouldCreatePV := d.createSymlink(diskLocation, fakeDiskByID.Name(), target, log, true, true)
+	assert.True(t, shouldCreatePV)
+	assert.Equal(t, [][]string{{"mount", "--bind", fakeDiskByID.Name(), target}}, mounts, "the device should be bind-mounted again")
+}
+
 func getFakeDiskMaker(t *testing.T, symlinkLocation string, objs ...runtime.Object) (*ReconcileLocalVolume, *testContext) {
 	scheme, err := localv1.SchemeBuilder.Build()
 	assert.NoErrorf(t, err, "creating scheme")
@@ -239,6 +325,9 @@ func getFakeDiskMaker(t *testing.T, symlinkLocation string, objs ...runtime.Obje
 		cleanupTracker:  cleanupTracker,
 		runtimeConfig:   runtimeConfig,
 		deleter:         provDeleter.NewDeleter(runtimeConfig, cleanupTracker),
+		options:         common.DefaultOptions(),
+
+		deviceSettleTracker: newDeviceSettleTracker(),
 	}, tc
 
 }
@@ -0,0 +1,180 @@
+package lv
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path"
+
+	"github.com/go-logr/logr"
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/diskmaker"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// sourceDirVolumeName returns the name of the i-th subdirectory of a sourceDir,
+// which is also the name of its bind mount in the storageclass directory
+func sourceDirVolumeName(i int32) string {
+	return fmt.Sprintf("vol%d", i)
+}
+
+// hasSourceDirs returns true if any storageClassDevices of the LocalVolume provision directories
+func hasSourceDirs(lv *localv1.LocalVolume) bool {
+	for _, storageClassDevice := range lv.Spec.StorageClassDevices {
+		if storageClassDevice.SourceDir != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// provisionSourceDirs creates the subdirectories of every sourceDir of the LocalVolume,
+// bind-mounts them into the storageclass directories and creates their PVs.
+// Directories are provisioned separately from devices, they never go through device matching.
+func (r *ReconcileLocalVolume) provisionSourceDirs(lv *localv1.LocalVolume, mountPointMap sets.String, reqLogger logr.Logger) error {
+	var errs []error
+	for _, storageClassDevice := range lv.Spec.StorageClassDevices {
+		if storageClassDevice.SourceDir == nil {
+			continue
+		}
+		err := r.provisionSourceDir(lv, storageClassDevice.StorageClassName, storageClassDevice.SourceDir, mountPointMap, reqLogger)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to provision source directories: %v", errs)
+	}
+	return nil
+}
+
+func (r *ReconcileLocalVolume) provisionSourceDir(
+	lv *localv1.LocalVolume,
+	storageClassName string,
+	sourceDir *localv1.SourceDir,
+	mountPointMap sets.String,
+	reqLogger logr.Logger,
+) error {
+	storageClass := &storagev1.StorageClass{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: storageClassName}, storageClass)
+	if err != nil {
+		reqLogger.Error(err, "failed to fetch storageClass", "storageClass", storageClassName)
+		return err
+	}
+	lvOwnerLabels := map[string]string{
+		common.LocalVolumeOwnerNameForPV:      lv.Name,
+		common.LocalVolumeOwnerNamespaceForPV: lv.Namespace,
+	}
+
+	err = r.checkSourceDirSize(lv, sourceDir)
+	if err != nil {
+		reqLogger.Error(err, "not provisioning the source directory", "storageClass", storageClassName)
+		return err
+	}
+
+	for i := int32(0); i < sourceDir.Count; i++ {
+		source := path.Join(sourceDir.Path, sourceDirVolumeName(i))
+		target := path.Join(r.symlinkLocation, storageClassName, sourceDirVolumeName(i))
+		dirLogger := reqLogger.WithValues("Directory.Path", source)
+
+		// don't start mounting a directory while the diskmaker is terminating
+		if !diskmaker.BeginDeviceOperation() {
+			dirLogger.Info("diskmaker is shutting down, not provisioning")
+			return nil
+		}
+		err = r.provisionDirectory(lv, *storageClass, sourceDir, mountPointMap, source, target, lvOwnerLabels, dirLogger)
+		diskmaker.EndDeviceOperation()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// provisionDirectory bind-mounts the directory and creates the PV for it
+func (r *ReconcileLocalVolume) provisionDirectory(
+	lv *localv1.LocalVolume,
+	storageClass storagev1.StorageClass,
+	sourceDir *localv1.SourceDir,
+	mountPointMap sets.String,
+	source string,
+	target string,
+	lvOwnerLabels map[string]string,
+	dirLogger logr.Logger,
+) error {
+	if !mountPointMap.Has(target) {
+		err := internal.BindMountDirectory(source, target)
+		if err != nil {
+			msg := fmt.Sprintf("error bind-mounting directory %s: %v", source, err)
+			r.eventSync.Report(lv, newDiskEvent(ErrorProvisioningDirectory, msg, source, corev1.EventTypeWarning))
+			dirLogger.Error(err, "could not bind-mount directory")
+			return err
+		}
+		mountPointMap.Insert(target)
+	}
+
+	err := common.CreateLocalPV(common.CreateLocalPVArgs{
+		LocalVolumeLikeObject: lv,
+		RuntimeConfig:         r.runtimeConfig,
+		CleanupTracker:        r.cleanupTracker,
+		NodeAffinityKey:       r.options.PVNodeAffinityKey,
+		BackupLabels:          r.options.PVBackupLabels,
+		BackupAnnotations:     r.options.PVBackupAnnotations,
+		StorageClass:          storageClass,
+		MountPointMap:         mountPointMap,
+		Client:                r.client,
+		SymLinkPath:           target,
+		DeviceName:            source,
+		ExtraLabelsForPV:      lvOwnerLabels,
+		AccessModes:           accessModes(lv, storageClass.Name),
+		PVNamePrefix:          lv.Spec.PVNamePrefix,
+		PVLabels:              lv.Spec.PVLabels,
+		PVAnnotations:         lv.Spec.PVAnnotations,
+		RecreationLimiter:     diskmaker.PVRecreations,
+		CapacityBytes:         sourceDir.Capacity.Value(),
+		UnmanagedPV:           !lv.ManagesPersistentVolumes(),
+	}, dirLogger)
+	if err != nil {
+		dirLogger.Error(err, "could not create local PV")
+		return err
+	}
+	return nil
+}
+
+// checkSourceDirSize returns an error, and reports it, if the subdirectories of the sourceDir advertise more capacity
+// than the size of the filesystem of its path. The capacity isn't enforced on each subdirectory, so the PVs can
+// still fill the filesystem together, but it can't be promised more than once.
+func (r *ReconcileLocalVolume) checkSourceDirSize(lv *localv1.LocalVolume, sourceDir *localv1.SourceDir) error {
+	err := os.MkdirAll(sourceDir.Path, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory %q: %w", sourceDir.Path, err)
+	}
+	size, err := internal.FilesystemSize(sourceDir.Path)
+	if err != nil {
+		return err
+	}
+	requested := sourceDirCapacity(sourceDir)
+	if requested <= size {
+		return nil
+	}
+	err = fmt.Errorf("the %d subdirectories of %s advertise %s, more than the %s of its filesystem",
+		sourceDir.Count, sourceDir.Path, resource.NewQuantity(requested, resource.BinarySI), resource.NewQuantity(size, resource.BinarySI))
+	r.eventSync.Report(lv, newDiskEvent(ErrorProvisioningDirectory, err.Error(), sourceDir.Path, corev1.EventTypeWarning))
+	return err
+}
+
+// sourceDirCapacity returns the capacity advertised by all the subdirectories of the sourceDir
+func sourceDirCapacity(sourceDir *localv1.SourceDir) int64 {
+	capacity := sourceDir.Capacity.Value()
+	if capacity > 0 && int64(sourceDir.Count) > math.MaxInt64/capacity {
+		return math.MaxInt64
+	}
+	return int64(sourceDir.Count) * capacity
+}
@@ -0,0 +1,167 @@
+package lv
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+)
+
+// syncSymlinkedNode records the node in status.symlinkedNodes before the devices of a LocalVolume that leaves its PVs
+// to another tool are symlinked, or that bind-mounts devices, so that the operator keeps the deleted LocalVolume until
+// the symlinks and the bind mounts are removed. The node is removed from the list once the LocalVolume manages its PVs
+// and symlinks its devices.
+func (r *ReconcileLocalVolume) syncSymlinkedNode(lv *localv1.LocalVolume, nodeName string) error {
+	if _, changed := common.SyncSymlinkedNode(lv.Status.SymlinkedNodes, nodeName, !removesLinksOnDeletion(lv)); !changed {
+		return nil
+	}
+	key := types.NamespacedName{Name: lv.Name, Namespace: lv.Namespace}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &localv1.LocalVolume{}
+		err := r.client.Get(context.TODO(), key, current)
+		if err != nil {
+			return err
+		}
+		symlinkedNodes, changed := common.SyncSymlinkedNode(current.Status.SymlinkedNodes, nodeName, !removesLinksOnDeletion(current))
+		if !changed {
+			return nil
+		}
+		current.Status.SymlinkedNodes = symlinkedNodes
+		return r.client.Status().Update(context.TODO(), current)
+	})
+}
+
+// removesLinksOnDeletion returns true if the diskmaker removes the links of the deleted LocalVolume from its nodes:
+// the symlinks of a LocalVolume that leaves its PVs to another tool, and the bind mounts of its devices.
+func removesLinksOnDeletion(lv *localv1.LocalVolume) bool {
+	if !lv.ManagesPersistentVolumes() {
+		return true
+	}
+	for _, devices := range lv.Spec.StorageClassDevices {
+		if devices.UseBindMount {
+			return true
+		}
+	}
+	return false
+}
+
+// removeSymlinks removes the symlinks of a deleted LocalVolume listed in status.symlinkedNodes from the node,
+// then the node from the list. Only the symlinks that PVs with the owner labels of the LocalVolume point to are
+// removed, the symlinks of other objects with the same StorageClass are left alone.
+// The bind mounts of its devices are removed once no PV points to them, see removeBindMounts.
+func (r *ReconcileLocalVolume) removeSymlinks(lv *localv1.LocalVolume, nodeName string, reqLogger logr.Logger) error {
+	if !sets.NewString(lv.Status.SymlinkedNodes...).Has(nodeName) {
+		return nil
+	}
+	node := &corev1.Node{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: nodeName}, node)
+	if err != nil {
+		return fmt.Errorf("could not get node %q: %w", nodeName, err)
+	}
+	pvs, err := common.ListOwnedPVs(context.TODO(), r.client, lv)
+	if err != nil {
+		return fmt.Errorf("could not list the persistent volumes of the localvolume: %w", err)
+	}
+	allPVs := &corev1.PersistentVolumeList{}
+	err = r.client.List(context.TODO(), allPVs)
+	if err != nil {
+		return fmt.Errorf("could not list persistent volumes: %w", err)
+	}
+	for _, devices := range lv.Spec.StorageClassDevices {
+		symLinkDir := path.Join(r.symlinkLocation, devices.StorageClassName)
+		removed, err := common.RemoveSymlinksOfPVs(symLinkDir, node.Labels[corev1.LabelHostname], pvs.Items)
+		if len(removed) > 0 {
+			reqLogger.Info("removed the symlinks of the deleted LocalVolume", "directory", symLinkDir, "symlinks", removed)
+		}
+		if err != nil {
+			return err
+		}
+		if devices.UseBindMount {
+			err = removeBindMounts(lv, symLinkDir, node.Labels[corev1.LabelHostname], pvs.Items, allPVs.Items, reqLogger)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	key := types.NamespacedName{Name: lv.Name, Namespace: lv.Namespace}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &localv1.LocalVolume{}
+		err := r.client.Get(context.TODO(), key, current)
+		if errors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		symlinkedNodes := sets.NewString(current.Status.SymlinkedNodes...)
+		if !symlinkedNodes.Has(nodeName) {
+			return nil
+		}
+		current.Status.SymlinkedNodes = symlinkedNodes.Delete(nodeName).List()
+		return r.client.Status().Update(context.TODO(), current)
+	})
+}
+
+// removeBindMounts unmounts and removes the bind mount targets of devices in symLinkDir that no PV points to.
+// The unbound PVs of a deleted LocalVolume that manages its PVs are deleted by the operator, it waits for them.
+// Released PVs keep their targets, the deleter removes them once it wiped the devices and deleted the PVs.
+// The targets that the PVs of another tool point to are removed like its symlinks, those of the PVs of other
+// objects with the same StorageClass are left alone.
+func removeBindMounts(lv *localv1.LocalVolume, symLinkDir, hostname string, ownedPVs, allPVs []corev1.PersistentVolume, reqLogger logr.Logger) error {
+	owned := sets.NewString()
+	waiting := []string{}
+	for _, pv := range ownedPVs {
+		if pv.Spec.Local == nil || pv.Labels[corev1.LabelHostname] != hostname || filepath.Dir(pv.Spec.Local.Path) != filepath.Clean(symLinkDir) {
+			continue
+		}
+		owned.Insert(pv.Name)
+		if lv.ManagesPersistentVolumes() && pv.Status.Phase != corev1.VolumeReleased {
+			waiting = append(waiting, pv.Name)
+		}
+	}
+	inUse := sets.NewString()
+	for _, pv := range allPVs {
+		if pv.Spec.Local == nil || pv.Labels[corev1.LabelHostname] != hostname {
+			continue
+		}
+		if !lv.ManagesPersistentVolumes() && owned.Has(pv.Name) {
+			continue
+		}
+		inUse.Insert(pv.Spec.Local.Path)
+	}
+	if len(waiting) > 0 {
+		return fmt.Errorf("waiting for the persistent volumes %v to be deleted before removing the bind mounts in %q", waiting, symLinkDir)
+	}
+
+	targets, err := internal.FilePathGlob(filepath.Join(symLinkDir, "*"))
+	if err != nil {
+		return fmt.Errorf("could not list the bind mount targets in %q: %w", symLinkDir, err)
+	}
+	removed := []string{}
+	for _, target := range targets {
+		if inUse.Has(target) {
+			continue
+		}
+		isTarget, err := internal.RemoveBindMountTarget(target)
+		if err != nil {
+			return err
+		}
+		if isTarget {
+			removed = append(removed, filepath.Base(target))
+		}
+	}
+	if len(removed) > 0 {
+		reqLogger.Info("removed the bind mounts of the deleted LocalVolume", "directory", symLinkDir, "targets", removed)
+	}
+	return nil
+}
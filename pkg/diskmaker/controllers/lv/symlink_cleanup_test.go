@@ -0,0 +1,80 @@
+package lv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRemoveBindMounts(t *testing.T) {
+	newPV := func(name, path string, phase corev1.PersistentVolumePhase) corev1.PersistentVolume {
+		return corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelHostname: "node-a"}},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{Local: &corev1.LocalVolumeSource{Path: path}},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: phase},
+		}
+	}
+
+	testTable := []struct {
+		label           string
+		managePVs       bool
+		ownedPhase      corev1.PersistentVolumePhase
+		expectErr       bool
+		expectedTargets []string
+	}{
+		{
+			label:           "unbound PV not deleted yet",
+			managePVs:       true,
+			ownedPhase:      corev1.VolumeAvailable,
+			expectErr:       true,
+			expectedTargets: []string{"dev-a", "dev-b", "dev-c"},
+		},
+		{
+			label:           "released PV left to the deleter",
+			managePVs:       true,
+			ownedPhase:      corev1.VolumeReleased,
+			expectedTargets: []string{"dev-a", "dev-b"},
+		},
+		{
+			label:           "PV of another tool",
+			managePVs:       false,
+			ownedPhase:      corev1.VolumeBound,
+			expectedTargets: []string{"dev-b"},
+		},
+	}
+	for _, tc := range testTable {
+		symLinkDir, err := ioutil.TempDir("", "local-sc")
+		assert.NoError(t, err)
+		defer os.RemoveAll(symLinkDir)
+		// dev-a is the target of the PV of the LocalVolume, dev-b of the PV of another object
+		// with the same StorageClass and dev-c of no PV
+		for _, name := range []string{"dev-a", "dev-b", "dev-c"} {
+			assert.NoError(t, ioutil.WriteFile(filepath.Join(symLinkDir, name), []byte{}, 0600))
+		}
+		owned := newPV("owned", filepath.Join(symLinkDir, "dev-a"), tc.ownedPhase)
+		other := newPV("other", filepath.Join(symLinkDir, "dev-b"), corev1.VolumeBound)
+
+		lv := &localv1.LocalVolume{Spec: localv1.LocalVolumeSpec{ManagePersistentVolumes: &tc.managePVs}}
+		err = removeBindMounts(lv, symLinkDir, "node-a", []corev1.PersistentVolume{owned}, []corev1.PersistentVolume{owned, other}, log)
+		if tc.expectErr {
+			assert.Errorf(t, err, "[%s]", tc.label)
+		} else {
+			assert.NoErrorf(t, err, "[%s]", tc.label)
+		}
+		files, err := ioutil.ReadDir(symLinkDir)
+		assert.NoError(t, err)
+		targets := []string{}
+		for _, file := range files {
+			targets = append(targets, file.Name())
+		}
+		assert.Equalf(t, tc.expectedTargets, targets, "[%s]", tc.label)
+	}
+}
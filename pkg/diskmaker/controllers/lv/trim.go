@@ -0,0 +1,137 @@
+package lv
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/retry"
+	provCommon "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/common"
+)
+
+// the host operations of periodicTrim, overridden in tests
+var (
+	trimNow         = time.Now
+	deviceNumber    = internal.DeviceNumber
+	mountPoints     = internal.MountPoints
+	supportsDiscard = internal.SupportsDiscard
+	trimFilesystem  = internal.TrimFilesystem
+)
+
+// periodicTrimIntervals returns the periodicTrim interval of the storageClassDevices with Filesystem volumes on devices,
+// by StorageClass name
+func periodicTrimIntervals(lv *localv1.LocalVolume) map[string]time.Duration {
+	intervals := map[string]time.Duration{}
+	for _, device := range lv.Spec.StorageClassDevices {
+		if device.PeriodicTrim == nil || device.VolumeMode == localv1.PersistentVolumeBlock || device.SourceDir != nil {
+			continue
+		}
+		intervals[device.StorageClassName] = device.PeriodicTrim.Duration
+	}
+	return intervals
+}
+
+// trimFilesystems runs fstrim on the mounted filesystems of the PVs of the node whose periodicTrim interval
+// has passed since their last trim, recorded in the PVLastTrimAnnotation. PVs that are not mounted by a pod,
+// and devices that don't support discard, are skipped.
+func (r *ReconcileLocalVolume) trimFilesystems(lv *localv1.LocalVolume, reqLogger logr.Logger) error {
+	intervals := periodicTrimIntervals(lv)
+	if len(intervals) == 0 {
+		return nil
+	}
+	pvs, err := common.ListOwnedPVs(context.TODO(), r.client, lv)
+	if err != nil {
+		return fmt.Errorf("could not list the PVs of the LocalVolume: %w", err)
+	}
+
+	var errs []error
+	for _, pv := range pvs.Items {
+		interval, found := intervals[pv.Spec.StorageClassName]
+		if !found || pv.Spec.Local == nil || pv.Annotations[provCommon.AnnProvisionedBy] != r.runtimeConfig.Name {
+			continue
+		}
+		if pv.Spec.VolumeMode != nil && *pv.Spec.VolumeMode == corev1.PersistentVolumeBlock {
+			continue
+		}
+		if lastTrim, err := time.Parse(time.RFC3339, pv.Annotations[common.PVLastTrimAnnotation]); err == nil && trimNow().Sub(lastTrim) < interval {
+			continue
+		}
+		pvLogger := reqLogger.WithValues("pvName", pv.Name)
+		trimmed, err := r.trimPV(pv, pvLogger)
+		if err != nil {
+			pvLogger.Error(err, "failed to trim the filesystem of the PV")
+			r.eventSync.Report(lv, newDiskEvent(common.TrimFailed, fmt.Sprintf("failed to trim the filesystem of PV %s: %v", pv.Name, err), pv.Name, corev1.EventTypeWarning))
+			errs = append(errs, err)
+			continue
+		}
+		if !trimmed {
+			continue
+		}
+		err = r.setLastTrim(pv.Name, trimNow())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not update the %s annotation of PV %q: %w", common.PVLastTrimAnnotation, pv.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// trimPV runs fstrim on the filesystem of the PV, trimmed is false if it isn't mounted or its device doesn't support discard
+func (r *ReconcileLocalVolume) trimPV(pv corev1.PersistentVolume, pvLogger logr.Logger) (trimmed bool, err error) {
+	device, err := internal.FilePathEvalSymLinks(pv.Spec.Local.Path)
+	if err != nil {
+		return false, fmt.Errorf("could not resolve the path %q of the PV: %w", pv.Spec.Local.Path, err)
+	}
+	number, err := deviceNumber(device)
+	if err != nil {
+		return false, err
+	}
+	mounted, err := mountPoints(number)
+	if err != nil {
+		return false, err
+	}
+	if len(mounted) == 0 {
+		pvLogger.V(4).Info("not trimming the PV, its filesystem isn't mounted", "device", device)
+		return false, nil
+	}
+	discard, err := supportsDiscard(device)
+	if err != nil {
+		return false, err
+	}
+	if !discard {
+		pvLogger.V(4).Info("not trimming the PV, its device doesn't support discard", "device", device)
+		return false, nil
+	}
+	// the mount points of a device all share its filesystem, trimming one of them is enough
+	output, err := trimFilesystem(mounted[0])
+	if err != nil {
+		return false, err
+	}
+	pvLogger.Info("trimmed the filesystem of the PV", "device", device, "mountPoint", mounted[0], "output", output)
+	return true, nil
+}
+
+// setLastTrim records the time of the last trim in the PVLastTrimAnnotation of the PV
+func (r *ReconcileLocalVolume) setLastTrim(pvName string, lastTrim time.Time) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pv := &corev1.PersistentVolume{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: pvName}, pv)
+		if errors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if pv.Annotations == nil {
+			pv.Annotations = map[string]string{}
+		}
+		pv.Annotations[common.PVLastTrimAnnotation] = lastTrim.UTC().Format(time.RFC3339)
+		return r.client.Update(context.TODO(), pv)
+	})
+}
@@ -0,0 +1,144 @@
+package lv
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	provCommon "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/common"
+)
+
+func TestTrimFilesystems(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	devices := map[string]string{
+		"/mnt/local-storage/fs/disk-a":    "/dev/sdb",
+		"/mnt/local-storage/fs/disk-b":    "/dev/sdc",
+		"/mnt/local-storage/fs/disk-c":    "/dev/sdd",
+		"/mnt/local-storage/fs/disk-d":    "/dev/sde",
+		"/mnt/local-storage/fs/disk-e":    "/dev/sdf",
+		"/mnt/local-storage/block/disk-f": "/dev/sdg",
+	}
+	mounted := map[string][]string{
+		"/dev/sdb": {"/var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/pv-a", "/var/lib/kubelet/pods/a/volumes/pv-a"},
+		"/dev/sdc": {"/var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/pv-b"},
+		"/dev/sde": {"/var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/pv-d"},
+		"/dev/sdf": {"/var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/pv-e"},
+		"/dev/sdg": {"/var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/pv-f"},
+	}
+	trimmed := []string{}
+	internal.FilePathEvalSymLinks = func(path string) (string, error) {
+		return devices[path], nil
+	}
+	trimNow = func() time.Time { return now }
+	deviceNumber = func(device string) (string, error) { return device, nil }
+	mountPoints = func(device string) ([]string, error) { return mounted[device], nil }
+	supportsDiscard = func(device string) (bool, error) { return device != "/dev/sde", nil }
+	trimFilesystem = func(mountPoint string) (string, error) {
+		if mountPoint == "/var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/pv-e" {
+			return "", fmt.Errorf("the discard operation is not supported")
+		}
+		trimmed = append(trimmed, mountPoint)
+		return mountPoint + ": 1 GiB (1073741824 bytes) trimmed", nil
+	}
+	defer func() {
+		internal.FilePathEvalSymLinks = filepath.EvalSymlinks
+		trimNow = time.Now
+		deviceNumber = internal.DeviceNumber
+		mountPoints = internal.MountPoints
+		supportsDiscard = internal.SupportsDiscard
+		trimFilesystem = internal.TrimFilesystem
+	}()
+
+	lv := &localv1.LocalVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "local-disks", Namespace: "local-storage"},
+		Spec: localv1.LocalVolumeSpec{StorageClassDevices: []localv1.StorageClassDevice{
+			{StorageClassName: "fs", VolumeMode: localv1.PersistentVolumeFilesystem, PeriodicTrim: &metav1.Duration{Duration: 24 * time.Hour}},
+			{StorageClassName: "block", VolumeMode: localv1.PersistentVolumeBlock, PeriodicTrim: &metav1.Duration{Duration: 24 * time.Hour}},
+		}},
+	}
+	block := corev1.PersistentVolumeBlock
+	newPV := func(name, storageClassName, path string, lastTrim *time.Time) *corev1.PersistentVolume {
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					common.LocalVolumeOwnerNameForPV:      lv.Name,
+					common.LocalVolumeOwnerNamespaceForPV: lv.Namespace,
+				},
+				Annotations: map[string]string{provCommon.AnnProvisionedBy: "node-a"},
+			},
+			Spec: corev1.PersistentVolumeSpec{
+				StorageClassName:       storageClassName,
+				PersistentVolumeSource: corev1.PersistentVolumeSource{Local: &corev1.LocalVolumeSource{Path: path}},
+			},
+		}
+		if lastTrim != nil {
+			pv.Annotations[common.PVLastTrimAnnotation] = lastTrim.Format(time.RFC3339)
+		}
+		if storageClassName == "block" {
+			pv.Spec.VolumeMode = &block
+		}
+		return pv
+	}
+	recent := now.Add(-time.Hour)
+	old := now.Add(-48 * time.Hour)
+	objects := []runtime.Object{
+		lv,
+		// never trimmed
+		newPV("pv-a", "fs", "/mnt/local-storage/fs/disk-a", nil),
+		// trimmed before the interval
+		newPV("pv-b", "fs", "/mnt/local-storage/fs/disk-b", &recent),
+		// not mounted
+		newPV("pv-c", "fs", "/mnt/local-storage/fs/disk-c", &old),
+		// doesn't support discard
+		newPV("pv-d", "fs", "/mnt/local-storage/fs/disk-d", nil),
+		// fstrim fails
+		newPV("pv-e", "fs", "/mnt/local-storage/fs/disk-e", nil),
+		// Block volumes are not trimmed
+		newPV("pv-f", "block", "/mnt/local-storage/block/disk-f", nil),
+	}
+	r, tc := getFakeDiskMaker(t, "/mnt/local-storage", objects...)
+	r.runtimeConfig.Name = "node-a"
+
+	err := r.trimFilesystems(lv, logf.Log)
+	assert.Error(t, err, "the error of pv-e is returned")
+	assert.Equal(t, []string{"/var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/pv-a"}, trimmed)
+	expected := map[string]string{
+		"pv-a": now.Format(time.RFC3339),
+		"pv-b": recent.Format(time.RFC3339),
+		"pv-c": old.Format(time.RFC3339),
+		"pv-d": "",
+		"pv-e": "",
+		"pv-f": "",
+	}
+	for name, lastTrim := range expected {
+		pv := &corev1.PersistentVolume{}
+		err := tc.fakeClient.Get(context.TODO(), types.NamespacedName{Name: name}, pv)
+		assert.NoError(t, err)
+		assert.Equalf(t, lastTrim, pv.Annotations[common.PVLastTrimAnnotation], "last trim of %s", name)
+	}
+	assert.Len(t, tc.fakeRecorder.Events, 1, "a TrimFailed event for pv-e")
+
+	// pv-a isn't trimmed again until the interval has passed
+	trimmed = []string{}
+	now = now.Add(time.Hour)
+	_ = r.trimFilesystems(lv, logf.Log)
+	assert.Empty(t, trimmed)
+	now = now.Add(24 * time.Hour)
+	_ = r.trimFilesystems(lv, logf.Log)
+	assert.Equal(t, []string{
+		"/var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/pv-a",
+		"/var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/pv-b",
+	}, trimmed)
+}
@@ -1,11 +1,13 @@
 package lvset
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
 	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/diskmaker"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -17,7 +19,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
-	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
@@ -45,7 +46,7 @@ func init() {
 }
 
 // Add adds a new nodeside lvset controller to mgr
-func Add(mgr manager.Manager, cleanupTracker *provDeleter.CleanupStatusTracker, pvCache *provCache.VolumeCache) error {
+func Add(mgr manager.Manager, cleanupTracker *provDeleter.CleanupStatusTracker, pvCache *provCache.VolumeCache, options common.Options) error {
 
 	clientSet := provCommon.SetupClient()
 
@@ -65,14 +66,17 @@ func Add(mgr manager.Manager, cleanupTracker *provDeleter.CleanupStatusTracker,
 	clock := &wallTime{}
 	crClient := mgr.GetClient()
 	r := &ReconcileLocalVolumeSet{
-		client:         crClient,
-		scheme:         mgr.GetScheme(),
-		nodeName:       nodeName,
-		eventReporter:  newEventReporter(mgr.GetEventRecorderFor(ComponentName)),
-		deviceAgeMap:   newAgeMap(clock),
-		cleanupTracker: cleanupTracker,
-		runtimeConfig:  runtimeConfig,
-		deleter:        provDeleter.NewDeleter(runtimeConfig, cleanupTracker),
+		client:          crClient,
+		scheme:          mgr.GetScheme(),
+		nodeName:        nodeName,
+		eventReporter:   newEventReporter(mgr.GetEventRecorderFor(ComponentName)),
+		deviceAgeMap:    newAgeMap(clock),
+		deviceFailures:  newFailureMap(),
+		deviceStability: newStabilityMap(clock),
+		cleanupTracker:  cleanupTracker,
+		runtimeConfig:   runtimeConfig,
+		deleter:         provDeleter.NewDeleter(runtimeConfig, cleanupTracker),
+		options:         options,
 	}
 	// Create a new controller
 	c, err := controller.New(ComponentName, mgr, controller.Options{
@@ -84,7 +88,7 @@ func Add(mgr manager.Manager, cleanupTracker *provDeleter.CleanupStatusTracker,
 		return err
 	}
 
-	err = c.Watch(&source.Kind{Type: &localv1alpha1.LocalVolumeSet{}}, &handler.EnqueueRequestForObject{}, predicate.GenerationChangedPredicate{})
+	err = c.Watch(&source.Kind{Type: &localv1alpha1.LocalVolumeSet{}}, &handler.EnqueueRequestForObject{}, common.GenerationOrRescanChanged())
 	if err != nil {
 		return err
 	}
@@ -98,6 +102,14 @@ func Add(mgr manager.Manager, cleanupTracker *provDeleter.CleanupStatusTracker,
 		return err
 	}
 
+	// reconcile all LocalVolumeSets when block devices are hot-plugged on the node
+	err = c.Watch(&source.Channel{Source: diskmaker.SubscribeDeviceEvents()}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: allLocalVolumeSets(crClient),
+	})
+	if err != nil {
+		return err
+	}
+
 	// update owned-pv cache used by provisioner/deleter libs and enequeue owning lvset
 	// only the cache is touched by
 	err = c.Watch(&source.Kind{Type: &corev1.PersistentVolume{}}, &handler.Funcs{
@@ -133,6 +145,23 @@ func Add(mgr manager.Manager, cleanupTracker *provDeleter.CleanupStatusTracker,
 	return err
 }
 
+// allLocalVolumeSets maps any object to the LocalVolumeSets in the watched namespace
+func allLocalVolumeSets(c client.Client) handler.ToRequestsFunc {
+	return func(handler.MapObject) []reconcile.Request {
+		lvSets := &localv1alpha1.LocalVolumeSetList{}
+		err := c.List(context.TODO(), lvSets, client.InNamespace(watchNamespace))
+		if err != nil {
+			log.Error(err, "failed to list LocalVolumeSets")
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(lvSets.Items))
+		for _, lvSet := range lvSets.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: lvSet.Name, Namespace: lvSet.Namespace}})
+		}
+		return requests
+	}
+}
+
 func handlePVChange(runtimeConfig *provCommon.RuntimeConfig, pv *corev1.PersistentVolume, q workqueue.RateLimitingInterface, isDelete bool) {
 
 	// skip non-owned PVs unless provisioner name is not yet known
@@ -177,11 +206,17 @@ type ReconcileLocalVolumeSet struct {
 	eventReporter *eventReporter
 	// map from KNAME of device to time when the device was first observed since the process started
 	deviceAgeMap *ageMap
+	// provisioning failures of devices, to quarantine the devices that fail repeatedly
+	deviceFailures *failureMap
+	// since when the devices have been seen stable, for deviceInclusionSpec.minStableDuration
+	deviceStability *stabilityMap
 
 	// static-provisioner stuff
 	cleanupTracker *provDeleter.CleanupStatusTracker
 	runtimeConfig  *provCommon.RuntimeConfig
 	deleter        *provDeleter.Deleter
+
+	options common.Options
 }
 
 var _ reconcile.Reconciler = &ReconcileLocalVolumeSet{}
@@ -0,0 +1,102 @@
+package lvset
+
+import (
+	"context"
+	"path/filepath"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// symlinkPaths returns the paths of the entries of the symlink directories of the storageclasses of a LocalVolumeSet
+func symlinkPaths(symLinkDirs []string) ([]string, error) {
+	paths := []string{}
+	for _, symLinkDir := range symLinkDirs {
+		dirPaths, err := filepath.Glob(filepath.Join(symLinkDir, "/*"))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, dirPaths...)
+	}
+	return paths, nil
+}
+
+// provisionedCapacity returns the total size of the devices that are symlinked in symLinkDirs
+func provisionedCapacity(symLinkDirs []string, devices []internal.BlockDevice) (resource.Quantity, error) {
+	total := resource.Quantity{Format: resource.BinarySI}
+	paths, err := symlinkPaths(symLinkDirs)
+	if err != nil {
+		return total, err
+	}
+	for _, device := range devices {
+		for _, path := range paths {
+			isMatch, err := internal.PathEvalsToDiskLabel(path, device.KName)
+			if err != nil {
+				return total, err
+			}
+			if isMatch {
+				total.Add(deviceSize(device))
+				break
+			}
+		}
+	}
+	return total, nil
+}
+
+// fitsCapacity returns true if the device can be provisioned without the provisioned capacity exceeding maxCapacity.
+// A device whose size can't be parsed doesn't fit when the capacity is limited.
+func fitsCapacity(maxCapacity *resource.Quantity, provisioned resource.Quantity, dev internal.BlockDevice) bool {
+	if maxCapacity == nil {
+		return true
+	}
+	size, err := resource.ParseQuantity(dev.Size)
+	if err != nil {
+		return false
+	}
+	total := provisioned.DeepCopy()
+	total.Add(size)
+	return total.Cmp(*maxCapacity) <= 0
+}
+
+// syncNodeCapacity records the capacity provisioned on this node in status.nodeCapacities
+func (r *ReconcileLocalVolumeSet) syncNodeCapacity(lvset *localv1alpha1.LocalVolumeSet, capacity resource.Quantity) error {
+	key := types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}
+	nodeName := r.runtimeConfig.Node.Name
+	nodeCapacity := localv1alpha1.NodeCapacity{NodeName: nodeName, ProvisionedCapacity: capacity}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &localv1alpha1.LocalVolumeSet{}
+		err := r.client.Get(context.TODO(), key, current)
+		if err != nil {
+			return err
+		}
+		capacities := []localv1alpha1.NodeCapacity{}
+		for _, existing := range current.Status.NodeCapacities {
+			if existing.NodeName != nodeName {
+				capacities = append(capacities, existing)
+				continue
+			}
+			if existing.ProvisionedCapacity.Cmp(capacity) == 0 {
+				return nil
+			}
+		}
+		current.Status.NodeCapacities = append(capacities, nodeCapacity)
+		return r.client.Status().Update(context.TODO(), current)
+	})
+}
+
+// capacityRoundingFunc returns how the capacity of new PVs is rounded for capacityRounding
+func capacityRoundingFunc(rounding localv1alpha1.CapacityRounding) func(int64) int64 {
+	switch rounding {
+	case localv1alpha1.CapacityRoundingExact:
+		return func(capacityBytes int64) int64 { return capacityBytes }
+	case localv1alpha1.CapacityRoundingFloorGiB:
+		return common.RoundDownCapacityGiB
+	default:
+		return common.RoundDownCapacityPretty
+	}
+}
@@ -0,0 +1,91 @@
+package lvset
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestFitsCapacity(t *testing.T) {
+	maxCapacity := resource.MustParse("100Gi")
+	provisioned := resource.MustParse("60Gi")
+	testcases := []struct {
+		label       string
+		maxCapacity *resource.Quantity
+		size        string
+		expectFit   bool
+	}{
+		{label: "not limited", size: "1Ti", expectFit: true},
+		{label: "fits", maxCapacity: &maxCapacity, size: "40Gi", expectFit: true},
+		{label: "exceeds", maxCapacity: &maxCapacity, size: "41Gi", expectFit: false},
+		{label: "unparseable size", maxCapacity: &maxCapacity, size: "unknown", expectFit: false},
+	}
+	for _, tc := range testcases {
+		fits := fitsCapacity(tc.maxCapacity, provisioned, internal.BlockDevice{KName: "sdb", Size: tc.size})
+		assert.Equalf(t, tc.expectFit, fits, "[%s]", tc.label)
+	}
+}
+
+func TestProvisionedCapacity(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "capacity")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	devDir := filepath.Join(tmpDir, "dev")
+	symLinkDir := filepath.Join(tmpDir, "sc")
+	assert.NoError(t, os.MkdirAll(devDir, 0755))
+	assert.NoError(t, os.MkdirAll(symLinkDir, 0755))
+
+	devices := []internal.BlockDevice{
+		{KName: "sdb", Size: "10737418240"},
+		{KName: "sdc", Size: "21474836480"},
+		{KName: "sdd", Size: "42949672960"},
+	}
+	for _, device := range devices {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(devDir, device.KName), []byte{}, 0644))
+	}
+	// sdb and sdd are provisioned
+	assert.NoError(t, os.Symlink(filepath.Join(devDir, "sdb"), filepath.Join(symLinkDir, "by-id-b")))
+	assert.NoError(t, os.Symlink(filepath.Join(devDir, "sdd"), filepath.Join(symLinkDir, "by-id-d")))
+
+	capacity, err := provisionedCapacity([]string{symLinkDir}, devices)
+	assert.NoError(t, err)
+	expected := resource.MustParse("50Gi")
+	assert.Zerof(t, expected.Cmp(capacity), "expected %s, got %s", expected.String(), capacity.String())
+}
+
+func TestSyncNodeCapacity(t *testing.T) {
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "sc"},
+		Status: localv1alpha1.LocalVolumeSetStatus{
+			NodeCapacities: []localv1alpha1.NodeCapacity{
+				{NodeName: "node-a", ProvisionedCapacity: resource.MustParse("10Gi")},
+				{NodeName: "node-b", ProvisionedCapacity: resource.MustParse("20Gi")},
+			},
+		},
+	}
+	r, tc := newFakeLocalVolumeSetReconciler(t, lvset)
+	r.runtimeConfig.Node.Name = "node-a"
+	key := types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}
+
+	err := r.syncNodeCapacity(lvset, resource.MustParse("30Gi"))
+	assert.NoError(t, err)
+
+	updated := &localv1alpha1.LocalVolumeSet{}
+	err = tc.fakeClient.Get(context.TODO(), key, updated)
+	assert.NoError(t, err)
+	capacities := map[string]string{}
+	for _, capacity := range updated.Status.NodeCapacities {
+		capacities[capacity.NodeName] = capacity.ProvisionedCapacity.String()
+	}
+	assert.Equal(t, map[string]string{"node-a": "30Gi", "node-b": "20Gi"}, capacities, "only the entry of this node is replaced")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -13,10 +14,13 @@ import (
 	"github.com/openshift/local-storage-operator/pkg/common"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	provCommon "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/common"
 	provUtil "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/util"
 )
@@ -225,19 +229,18 @@ func TestCreatePV(t *testing.T) {
 		}
 		testConfig.fakeVolUtil.AddNewDirEntries("/mnt/local-storage/", dirFiles)
 
-		err := common.CreateLocalPV(
-			&tc.lvset,
-			r.runtimeConfig,
-			r.cleanupTracker,
-			log.WithName("testLogger"),
-			tc.sc,
-			tc.mountPoints,
-			r.client,
-			tc.symlinkpath,
-			tc.deviceName,
-			true,
-			map[string]string{},
-		)
+		err := common.CreateLocalPV(common.CreateLocalPVArgs{
+			LocalVolumeLikeObject: &tc.lvset,
+			RuntimeConfig:         r.runtimeConfig,
+			CleanupTracker:        r.cleanupTracker,
+			StorageClass:          tc.sc,
+			MountPointMap:         tc.mountPoints,
+			Client:                r.client,
+			SymLinkPath:           tc.symlinkpath,
+			DeviceName:            tc.deviceName,
+			IDExists:              true,
+			ExtraLabelsForPV:      map[string]string{},
+		}, log.WithName("testLogger"))
 		if tc.shouldErr {
 			assert.NotNil(t, err)
 		} else {
@@ -275,22 +278,714 @@ func TestCreatePV(t *testing.T) {
 		// reclaimPolicy accurate,
 		assert.Equal(t, *tc.sc.ReclaimPolicy, pv.Spec.PersistentVolumeReclaimPolicy)
 
+		// owner labels accurate
+		assert.Equal(t, localv1alpha1.LocalVolumeSetKind, pv.Labels[common.OwnerKindLabel])
+		assert.Equal(t, tc.lvset.Name, pv.Labels[common.OwnerNameLabel])
+		assert.Equal(t, tc.lvset.Namespace, pv.Labels[common.OwnerNamespaceLabel])
+
 		// test idempotency by running again
-		err = common.CreateLocalPV(
-			&tc.lvset,
-			r.runtimeConfig,
-			r.cleanupTracker,
-			log.WithName("testLogger"),
-			tc.sc,
-			tc.mountPoints,
-			r.client,
-			tc.symlinkpath,
-			tc.deviceName,
-			true,
-			map[string]string{},
-		)
+		err = common.CreateLocalPV(common.CreateLocalPVArgs{
+			LocalVolumeLikeObject: &tc.lvset,
+			RuntimeConfig:         r.runtimeConfig,
+			CleanupTracker:        r.cleanupTracker,
+			StorageClass:          tc.sc,
+			MountPointMap:         tc.mountPoints,
+			Client:                r.client,
+			SymLinkPath:           tc.symlinkpath,
+			DeviceName:            tc.deviceName,
+			IDExists:              true,
+			ExtraLabelsForPV:      map[string]string{},
+		}, log.WithName("testLogger"))
 		assert.Nil(t, err)
 
 	}
 
 }
+
+func TestCreatePVDeviceIdentityMismatch(t *testing.T) {
+	reclaimPolicyDelete := corev1.PersistentVolumeReclaimDelete
+	lvset := &localv1alpha1.LocalVolumeSet{
+		TypeMeta:   metav1.TypeMeta{Kind: localv1alpha1.LocalVolumeSetKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset-a", Namespace: "default"},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "storageclass-a"},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "nodename-a",
+			Labels: map[string]string{corev1.LabelHostname: "node-hostname-a"},
+		},
+	}
+	sc := &storagev1.StorageClass{
+		ObjectMeta:    metav1.ObjectMeta{Name: "storageclass-a"},
+		ReclaimPolicy: &reclaimPolicyDelete,
+	}
+	symlinkPath := "/mnt/local-storage/storageclass-a/device-a"
+
+	r, testConfig := newFakeLocalVolumeSetReconciler(t, lvset, node, sc)
+	r.nodeName = node.Name
+	testConfig.runtimeConfig.Node = node
+	testConfig.runtimeConfig.Name = common.GetProvisionedByValue(*node)
+	testConfig.runtimeConfig.DiscoveryMap[sc.Name] = provCommon.MountConfig{VolumeMode: string(localv1.PersistentVolumeBlock)}
+	testConfig.fakeVolUtil.AddNewDirEntries("/mnt/local-storage/", map[string][]*provUtil.FakeDirEntry{
+		sc.Name: {{Name: "device-a", Capacity: 10 * common.GiB, VolumeType: provUtil.FakeEntryBlock}},
+	})
+
+	args := common.CreateLocalPVArgs{
+		LocalVolumeLikeObject: lvset,
+		RuntimeConfig:         r.runtimeConfig,
+		CleanupTracker:        r.cleanupTracker,
+		StorageClass:          *sc,
+		MountPointMap:         sets.NewString(),
+		Client:                r.client,
+		SymLinkPath:           symlinkPath,
+		DeviceName:            "sdb",
+		DeviceIdentity:        "serial-a",
+		IDExists:              true,
+	}
+	err := common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Nil(t, err)
+
+	pvName := common.GeneratePVName(filepath.Base(symlinkPath), node.Name, sc.Name)
+	pv := &corev1.PersistentVolume{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: pvName}, pv)
+	assert.Nil(t, err)
+	assert.Equal(t, "serial-a", pv.Annotations[common.PVDeviceIdentityAnnotation])
+
+	// the same device is accepted
+	err = common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Nil(t, err)
+
+	// a different device behind the same symlink is refused
+	args.DeviceName = "sdc"
+	args.DeviceIdentity = "serial-b"
+	err = common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Error(t, err)
+	assert.IsType(t, common.DeviceIdentityMismatchError{}, err)
+
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: pvName}, pv)
+	assert.Nil(t, err)
+	assert.Equal(t, "serial-a", pv.Annotations[common.PVDeviceIdentityAnnotation])
+	assert.Equal(t, "sdb", pv.Annotations[common.PVDeviceNameLabel])
+}
+
+func TestCreatePVNamePrefix(t *testing.T) {
+	reclaimPolicyDelete := corev1.PersistentVolumeReclaimDelete
+	lvset := &localv1alpha1.LocalVolumeSet{
+		TypeMeta:   metav1.TypeMeta{Kind: localv1alpha1.LocalVolumeSetKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset-a", Namespace: "default"},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "storageclass-a"},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "nodename-a",
+			Labels: map[string]string{corev1.LabelHostname: "node-hostname-a"},
+		},
+	}
+	sc := &storagev1.StorageClass{
+		ObjectMeta:    metav1.ObjectMeta{Name: "storageclass-a"},
+		ReclaimPolicy: &reclaimPolicyDelete,
+	}
+	symlinkPath := "/mnt/local-storage/storageclass-a/device-a"
+
+	r, testConfig := newFakeLocalVolumeSetReconciler(t, lvset, node, sc)
+	r.nodeName = node.Name
+	testConfig.runtimeConfig.Node = node
+	testConfig.runtimeConfig.Name = common.GetProvisionedByValue(*node)
+	testConfig.runtimeConfig.DiscoveryMap[sc.Name] = provCommon.MountConfig{VolumeMode: string(localv1.PersistentVolumeBlock)}
+	testConfig.fakeVolUtil.AddNewDirEntries("/mnt/local-storage/", map[string][]*provUtil.FakeDirEntry{
+		sc.Name: {{Name: "device-a", Capacity: 10 * common.GiB, VolumeType: provUtil.FakeEntryBlock}},
+	})
+
+	args := common.CreateLocalPVArgs{
+		LocalVolumeLikeObject: lvset,
+		RuntimeConfig:         r.runtimeConfig,
+		CleanupTracker:        r.cleanupTracker,
+		StorageClass:          *sc,
+		MountPointMap:         sets.NewString(),
+		Client:                r.client,
+		SymLinkPath:           symlinkPath,
+		DeviceName:            "sdb",
+		IDExists:              true,
+		PVNamePrefix:          "fast-",
+	}
+	err := common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Nil(t, err)
+
+	expectedName := common.GeneratePVNameWithPrefix("fast-", filepath.Base(symlinkPath), node.Name, sc.Name)
+	assert.Equal(t, strings.Replace(common.GeneratePVName(filepath.Base(symlinkPath), node.Name, sc.Name), common.DefaultPVNamePrefix, "fast-", 1), expectedName)
+	pv := &corev1.PersistentVolume{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: expectedName}, pv)
+	assert.Nil(t, err)
+
+	// changing the prefix doesn't create a second PV for the device
+	args.PVNamePrefix = "other-"
+	err = common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Nil(t, err)
+	pvs := &corev1.PersistentVolumeList{}
+	err = r.client.List(context.TODO(), pvs)
+	assert.Nil(t, err)
+	assert.Len(t, pvs.Items, 1)
+	assert.Equal(t, expectedName, pvs.Items[0].Name)
+}
+
+func TestCreatePVCapacityRounding(t *testing.T) {
+	deviceCapacity := 12*common.GiB + 345*common.MiB + 6789
+	testTable := []struct {
+		desc             string
+		rounding         localv1alpha1.CapacityRounding
+		deviceCapacity   int64
+		expectedCapacity int64
+		shouldErr        bool
+	}{
+		{desc: "default", deviceCapacity: deviceCapacity, expectedCapacity: 12 * common.GiB},
+		{desc: "default small device", deviceCapacity: 5*common.GiB + 6789, expectedCapacity: 5 * 1024 * common.MiB},
+		{desc: "exact", rounding: localv1alpha1.CapacityRoundingExact, deviceCapacity: deviceCapacity, expectedCapacity: deviceCapacity},
+		{desc: "floorGiB", rounding: localv1alpha1.CapacityRoundingFloorGiB, deviceCapacity: deviceCapacity, expectedCapacity: 12 * common.GiB},
+		{desc: "floorGiB small device", rounding: localv1alpha1.CapacityRoundingFloorGiB, deviceCapacity: 5*common.GiB + 6789, expectedCapacity: 5 * common.GiB},
+		{desc: "floorGiB device below 1GiB", rounding: localv1alpha1.CapacityRoundingFloorGiB, deviceCapacity: 500 * common.MiB, shouldErr: true},
+	}
+	for _, tc := range testTable {
+		t.Run(tc.desc, func(t *testing.T) {
+			reclaimPolicyDelete := corev1.PersistentVolumeReclaimDelete
+			lvset := &localv1alpha1.LocalVolumeSet{
+				TypeMeta:   metav1.TypeMeta{Kind: localv1alpha1.LocalVolumeSetKind},
+				ObjectMeta: metav1.ObjectMeta{Name: "lvset-a", Namespace: "default"},
+				Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "storageclass-a", CapacityRounding: tc.rounding},
+			}
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "nodename-a",
+					Labels: map[string]string{corev1.LabelHostname: "node-hostname-a"},
+				},
+			}
+			sc := &storagev1.StorageClass{
+				ObjectMeta:    metav1.ObjectMeta{Name: "storageclass-a"},
+				ReclaimPolicy: &reclaimPolicyDelete,
+			}
+			symlinkPath := "/mnt/local-storage/storageclass-a/device-a"
+
+			r, testConfig := newFakeLocalVolumeSetReconciler(t, lvset, node, sc)
+			r.nodeName = node.Name
+			testConfig.runtimeConfig.Node = node
+			testConfig.runtimeConfig.Name = common.GetProvisionedByValue(*node)
+			testConfig.runtimeConfig.DiscoveryMap[sc.Name] = provCommon.MountConfig{VolumeMode: string(localv1.PersistentVolumeBlock)}
+			testConfig.fakeVolUtil.AddNewDirEntries("/mnt/local-storage/", map[string][]*provUtil.FakeDirEntry{
+				sc.Name: {{Name: "device-a", Capacity: tc.deviceCapacity, VolumeType: provUtil.FakeEntryBlock}},
+			})
+
+			err := common.CreateLocalPV(common.CreateLocalPVArgs{
+				LocalVolumeLikeObject: lvset,
+				RuntimeConfig:         r.runtimeConfig,
+				CleanupTracker:        r.cleanupTracker,
+				StorageClass:          *sc,
+				MountPointMap:         sets.NewString(),
+				Client:                r.client,
+				SymLinkPath:           symlinkPath,
+				DeviceName:            "sdb",
+				IDExists:              true,
+				RoundCapacity:         capacityRoundingFunc(lvset.Spec.CapacityRounding),
+			}, log.WithName("testLogger"))
+			if tc.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			pv := &corev1.PersistentVolume{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: common.GeneratePVName(filepath.Base(symlinkPath), node.Name, sc.Name)}, pv)
+			assert.NoError(t, err)
+			pvCapacity := pv.Spec.Capacity[corev1.ResourceStorage]
+			assert.Equal(t, tc.expectedCapacity, pvCapacity.Value())
+		})
+	}
+}
+
+func TestCreatePVNodeAffinityKey(t *testing.T) {
+	affinityKey := "topology.local.csi.example.com/node"
+
+	reclaimPolicyDelete := corev1.PersistentVolumeReclaimDelete
+	lvset := &localv1alpha1.LocalVolumeSet{
+		TypeMeta:   metav1.TypeMeta{Kind: localv1alpha1.LocalVolumeSetKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset-a", Namespace: "default"},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "storageclass-a"},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "nodename-a",
+			Labels: map[string]string{corev1.LabelHostname: "node-hostname-a"},
+		},
+	}
+	sc := &storagev1.StorageClass{
+		ObjectMeta:    metav1.ObjectMeta{Name: "storageclass-a"},
+		ReclaimPolicy: &reclaimPolicyDelete,
+	}
+	symlinkPath := "/mnt/local-storage/storageclass-a/device-a"
+
+	r, testConfig := newFakeLocalVolumeSetReconciler(t, lvset, node, sc)
+	r.nodeName = node.Name
+	testConfig.runtimeConfig.Node = node
+	testConfig.runtimeConfig.Name = common.GetProvisionedByValue(*node)
+	testConfig.runtimeConfig.DiscoveryMap[sc.Name] = provCommon.MountConfig{VolumeMode: string(localv1.PersistentVolumeBlock)}
+	testConfig.fakeVolUtil.AddNewDirEntries("/mnt/local-storage/", map[string][]*provUtil.FakeDirEntry{
+		sc.Name: {{Name: "device-a", Capacity: 10 * common.GiB, VolumeType: provUtil.FakeEntryBlock}},
+	})
+
+	args := common.CreateLocalPVArgs{
+		LocalVolumeLikeObject: lvset,
+		RuntimeConfig:         r.runtimeConfig,
+		CleanupTracker:        r.cleanupTracker,
+		StorageClass:          *sc,
+		MountPointMap:         sets.NewString(),
+		Client:                r.client,
+		SymLinkPath:           symlinkPath,
+		DeviceName:            "sdb",
+		IDExists:              true,
+		NodeAffinityKey:       affinityKey,
+	}
+	// the node doesn't have the label of the node affinity
+	err := common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Error(t, err)
+
+	node.Labels[affinityKey] = "csi-node-a"
+	err = common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Nil(t, err)
+
+	pv := &corev1.PersistentVolume{}
+	pvName := common.GeneratePVName(filepath.Base(symlinkPath), node.Name, sc.Name)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: pvName}, pv)
+	assert.Nil(t, err)
+	assert.Equal(t, []corev1.NodeSelectorRequirement{
+		{Key: affinityKey, Operator: corev1.NodeSelectorOpIn, Values: []string{"csi-node-a"}},
+	}, pv.Spec.NodeAffinity.Required.NodeSelectorTerms[0].MatchExpressions)
+	assert.Equal(t, "node-hostname-a", pv.Labels[corev1.LabelHostname], "PVs are still labeled with the hostname")
+}
+
+func TestCreatePVDeviceLinks(t *testing.T) {
+	reclaimPolicyDelete := corev1.PersistentVolumeReclaimDelete
+	lvset := &localv1alpha1.LocalVolumeSet{
+		TypeMeta:   metav1.TypeMeta{Kind: localv1alpha1.LocalVolumeSetKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset-a", Namespace: "default"},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "storageclass-a"},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "nodename-a",
+			Labels: map[string]string{corev1.LabelHostname: "node-hostname-a"},
+		},
+	}
+	sc := &storagev1.StorageClass{
+		ObjectMeta:    metav1.ObjectMeta{Name: "storageclass-a"},
+		ReclaimPolicy: &reclaimPolicyDelete,
+	}
+	symlinkPath := "/mnt/local-storage/storageclass-a/wwn-0x5000c500a0b1c2d3"
+
+	r, testConfig := newFakeLocalVolumeSetReconciler(t, lvset, node, sc)
+	testConfig.runtimeConfig.Node = node
+	testConfig.runtimeConfig.Name = common.GetProvisionedByValue(*node)
+	testConfig.runtimeConfig.DiscoveryMap[sc.Name] = provCommon.MountConfig{VolumeMode: string(localv1.PersistentVolumeBlock)}
+	testConfig.fakeVolUtil.AddNewDirEntries("/mnt/local-storage/", map[string][]*provUtil.FakeDirEntry{
+		sc.Name: {{Name: filepath.Base(symlinkPath), Capacity: 10 * common.GiB, VolumeType: provUtil.FakeEntryBlock}},
+	})
+	countingClient := &writeCountingClient{Client: r.client}
+
+	args := common.CreateLocalPVArgs{
+		LocalVolumeLikeObject: lvset,
+		RuntimeConfig:         r.runtimeConfig,
+		CleanupTracker:        r.cleanupTracker,
+		StorageClass:          *sc,
+		MountPointMap:         sets.NewString(),
+		Client:                countingClient,
+		SymLinkPath:           symlinkPath,
+		DeviceName:            "sdb",
+		DeviceByID:            "/dev/disk/by-id/wwn-0x5000c500a0b1c2d3",
+		DeviceByPath:          "/dev/disk/by-path/pci-0000:00:1f.2-ata-2",
+		DeviceAlias:           "/dev/disk/by-id/wwn-0x5000c500a0b1c2d3",
+		IDExists:              true,
+	}
+	err := common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, countingClient.creates)
+	assert.Equal(t, 0, countingClient.updates, "the links are set by the create")
+
+	pvName := common.GeneratePVName(filepath.Base(symlinkPath), node.Name, sc.Name)
+	pv := &corev1.PersistentVolume{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: pvName}, pv)
+	assert.Nil(t, err)
+	assert.Equal(t, "/dev/disk/by-id/wwn-0x5000c500a0b1c2d3", pv.Annotations[common.PVDeviceByIDAnnotation])
+	assert.Equal(t, "/dev/disk/by-path/pci-0000:00:1f.2-ata-2", pv.Annotations[common.PVDeviceByPathAnnotation])
+	assert.Equal(t, "/dev/disk/by-id/wwn-0x5000c500a0b1c2d3", pv.Annotations[common.PVDeviceAliasAnnotation])
+
+	// the links recorded at creation are kept when the device moves
+	args.DeviceByPath = "/dev/disk/by-path/pci-0000:00:1f.2-ata-3"
+	err = common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Nil(t, err)
+	pv = &corev1.PersistentVolume{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: pvName}, pv)
+	assert.Nil(t, err)
+	assert.Equal(t, "/dev/disk/by-path/pci-0000:00:1f.2-ata-2", pv.Annotations[common.PVDeviceByPathAnnotation])
+}
+
+func TestCreatePVUserLabelsAndAnnotations(t *testing.T) {
+	reclaimPolicyDelete := corev1.PersistentVolumeReclaimDelete
+	lvset := &localv1alpha1.LocalVolumeSet{
+		TypeMeta:   metav1.TypeMeta{Kind: localv1alpha1.LocalVolumeSetKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset-a", Namespace: "default"},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "storageclass-a"},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "nodename-a",
+			Labels: map[string]string{corev1.LabelHostname: "node-hostname-a"},
+		},
+	}
+	sc := &storagev1.StorageClass{
+		ObjectMeta:    metav1.ObjectMeta{Name: "storageclass-a"},
+		ReclaimPolicy: &reclaimPolicyDelete,
+	}
+	symlinkPath := "/mnt/local-storage/storageclass-a/wwn-0x5000c500a0b1c2d3"
+
+	r, testConfig := newFakeLocalVolumeSetReconciler(t, lvset, node, sc)
+	testConfig.runtimeConfig.Node = node
+	testConfig.runtimeConfig.Name = common.GetProvisionedByValue(*node)
+	testConfig.runtimeConfig.DiscoveryMap[sc.Name] = provCommon.MountConfig{VolumeMode: string(localv1.PersistentVolumeBlock)}
+	testConfig.fakeVolUtil.AddNewDirEntries("/mnt/local-storage/", map[string][]*provUtil.FakeDirEntry{
+		sc.Name: {{Name: filepath.Base(symlinkPath), Capacity: 10 * common.GiB, VolumeType: provUtil.FakeEntryBlock}},
+	})
+	// sets the creationTimestamp, so that the second call updates the PV
+	countingClient := &writeCountingClient{Client: r.client}
+
+	args := common.CreateLocalPVArgs{
+		LocalVolumeLikeObject: lvset,
+		RuntimeConfig:         r.runtimeConfig,
+		CleanupTracker:        r.cleanupTracker,
+		StorageClass:          *sc,
+		MountPointMap:         sets.NewString(),
+		Client:                countingClient,
+		SymLinkPath:           symlinkPath,
+		DeviceName:            "sdb",
+		IDExists:              true,
+		PVLabels:              map[string]string{"backup": "daily", common.PVOwnerNameLabel: "other"},
+		PVAnnotations:         map[string]string{"backup.example.com/policy": "keep-7", provCommon.AnnProvisionedBy: "other"},
+	}
+	err := common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Nil(t, err)
+
+	pvName := common.GeneratePVName(filepath.Base(symlinkPath), node.Name, sc.Name)
+	pv := &corev1.PersistentVolume{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: pvName}, pv)
+	assert.Nil(t, err)
+	assert.Equal(t, "daily", pv.Labels["backup"])
+	assert.Equal(t, "keep-7", pv.Annotations["backup.example.com/policy"])
+	assert.Equal(t, lvset.Name, pv.Labels[common.PVOwnerNameLabel], "the owner labels are not replaced")
+	assert.Equal(t, testConfig.runtimeConfig.Name, pv.Annotations[provCommon.AnnProvisionedBy], "the provisioned-by annotation is not replaced")
+
+	// new keys are added to existing PVs, existing keys are kept
+	args.PVLabels = map[string]string{"backup": "weekly", "team": "storage"}
+	err = common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Nil(t, err)
+	pv = &corev1.PersistentVolume{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: pvName}, pv)
+	assert.Nil(t, err)
+	assert.Equal(t, "daily", pv.Labels["backup"])
+	assert.Equal(t, "storage", pv.Labels["team"])
+}
+
+func TestCreatePVBackupAnnotations(t *testing.T) {
+	backupAnnotations, _, err := common.ParsePVBackupMetadata("backup.example.com/policy=skip,backup.example.com/reason=node-local")
+	assert.NoError(t, err)
+
+	reclaimPolicyDelete := corev1.PersistentVolumeReclaimDelete
+	lvset := &localv1alpha1.LocalVolumeSet{
+		TypeMeta:   metav1.TypeMeta{Kind: localv1alpha1.LocalVolumeSetKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset-a", Namespace: "default"},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "storageclass-a"},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "nodename-a",
+			Labels: map[string]string{corev1.LabelHostname: "node-hostname-a"},
+		},
+	}
+	sc := &storagev1.StorageClass{
+		ObjectMeta:    metav1.ObjectMeta{Name: "storageclass-a"},
+		ReclaimPolicy: &reclaimPolicyDelete,
+	}
+	symlinkPath := "/mnt/local-storage/storageclass-a/wwn-0x5000c500a0b1c2d3"
+
+	r, testConfig := newFakeLocalVolumeSetReconciler(t, lvset, node, sc)
+	testConfig.runtimeConfig.Node = node
+	testConfig.runtimeConfig.Name = common.GetProvisionedByValue(*node)
+	testConfig.runtimeConfig.DiscoveryMap[sc.Name] = provCommon.MountConfig{VolumeMode: string(localv1.PersistentVolumeBlock)}
+	testConfig.fakeVolUtil.AddNewDirEntries("/mnt/local-storage/", map[string][]*provUtil.FakeDirEntry{
+		sc.Name: {{Name: filepath.Base(symlinkPath), Capacity: 10 * common.GiB, VolumeType: provUtil.FakeEntryBlock}},
+	})
+
+	args := common.CreateLocalPVArgs{
+		LocalVolumeLikeObject: lvset,
+		RuntimeConfig:         r.runtimeConfig,
+		CleanupTracker:        r.cleanupTracker,
+		StorageClass:          *sc,
+		MountPointMap:         sets.NewString(),
+		Client:                r.client,
+		SymLinkPath:           symlinkPath,
+		DeviceName:            "sdb",
+		IDExists:              true,
+		PVAnnotations:         map[string]string{"backup.example.com/policy": "keep-7"},
+		BackupAnnotations:     backupAnnotations,
+	}
+	err = common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Nil(t, err)
+
+	pvName := common.GeneratePVName(filepath.Base(symlinkPath), node.Name, sc.Name)
+	pv := &corev1.PersistentVolume{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: pvName}, pv)
+	assert.Nil(t, err)
+	assert.Equal(t, "node-local", pv.Annotations["backup.example.com/reason"])
+	assert.Equal(t, "keep-7", pv.Annotations["backup.example.com/policy"], "spec.pvAnnotations replace the backup annotations")
+	assert.Equal(t, testConfig.runtimeConfig.Name, pv.Annotations[provCommon.AnnProvisionedBy])
+
+	args.BackupAnnotations, args.BackupLabels, err = common.ParsePVBackupMetadata(common.PVBackupAnnotationsVelero)
+	assert.NoError(t, err)
+	err = common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Nil(t, err)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: pvName}, pv)
+	assert.Nil(t, err)
+	assert.Equal(t, "true", pv.Labels["velero.io/exclude-from-backup"], "the velero preset is added as a label to existing PVs")
+	assert.NotContains(t, pv.Annotations, "velero.io/exclude-from-backup")
+}
+
+func TestCreatePVAccessModes(t *testing.T) {
+	reclaimPolicyDelete := corev1.PersistentVolumeReclaimDelete
+	lvset := &localv1alpha1.LocalVolumeSet{
+		TypeMeta:   metav1.TypeMeta{Kind: localv1alpha1.LocalVolumeSetKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset-a", Namespace: "default"},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "storageclass-a"},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "nodename-a",
+			Labels: map[string]string{corev1.LabelHostname: "node-hostname-a"},
+		},
+	}
+	sc := &storagev1.StorageClass{
+		ObjectMeta:    metav1.ObjectMeta{Name: "storageclass-a"},
+		ReclaimPolicy: &reclaimPolicyDelete,
+	}
+	symlinkPaths := []string{"/mnt/local-storage/storageclass-a/wwn-0x5000c500a0b1c2d3", "/mnt/local-storage/storageclass-a/wwn-0x5000c500a0b1c2d4"}
+
+	r, testConfig := newFakeLocalVolumeSetReconciler(t, lvset, node, sc)
+	testConfig.runtimeConfig.Node = node
+	testConfig.runtimeConfig.Name = common.GetProvisionedByValue(*node)
+	testConfig.runtimeConfig.DiscoveryMap[sc.Name] = provCommon.MountConfig{VolumeMode: string(localv1.PersistentVolumeBlock)}
+	testConfig.fakeVolUtil.AddNewDirEntries("/mnt/local-storage/", map[string][]*provUtil.FakeDirEntry{
+		sc.Name: {
+			{Name: filepath.Base(symlinkPaths[0]), Capacity: 10 * common.GiB, VolumeType: provUtil.FakeEntryBlock},
+			{Name: filepath.Base(symlinkPaths[1]), Capacity: 10 * common.GiB, VolumeType: provUtil.FakeEntryBlock},
+		},
+	})
+
+	testcases := []struct {
+		accessModes []corev1.PersistentVolumeAccessMode
+		expected    []corev1.PersistentVolumeAccessMode
+	}{
+		{accessModes: nil, expected: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}},
+		{
+			accessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce, corev1.ReadOnlyMany},
+			expected:    []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce, corev1.ReadOnlyMany},
+		},
+	}
+	for i, tc := range testcases {
+		err := common.CreateLocalPV(common.CreateLocalPVArgs{
+			LocalVolumeLikeObject: lvset,
+			RuntimeConfig:         r.runtimeConfig,
+			CleanupTracker:        r.cleanupTracker,
+			StorageClass:          *sc,
+			MountPointMap:         sets.NewString(),
+			Client:                r.client,
+			SymLinkPath:           symlinkPaths[i],
+			DeviceName:            "sdb",
+			IDExists:              true,
+			AccessModes:           tc.accessModes,
+		}, log.WithName("testLogger"))
+		assert.Nil(t, err)
+
+		pv := &corev1.PersistentVolume{}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: common.GeneratePVName(filepath.Base(symlinkPaths[i]), node.Name, sc.Name)}, pv)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.expected, pv.Spec.AccessModes)
+	}
+}
+
+func TestCreatePVPrepareDevice(t *testing.T) {
+	reclaimPolicyDelete := corev1.PersistentVolumeReclaimDelete
+	lvset := &localv1alpha1.LocalVolumeSet{
+		TypeMeta:   metav1.TypeMeta{Kind: localv1alpha1.LocalVolumeSetKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset-a", Namespace: "default"},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "storageclass-a"},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "nodename-a",
+			Labels: map[string]string{corev1.LabelHostname: "node-hostname-a"},
+		},
+	}
+	sc := &storagev1.StorageClass{
+		ObjectMeta:    metav1.ObjectMeta{Name: "storageclass-a"},
+		ReclaimPolicy: &reclaimPolicyDelete,
+	}
+	symlinkPath := "/mnt/local-storage/storageclass-a/wwn-0x5000c500a0b1c2d3"
+
+	r, testConfig := newFakeLocalVolumeSetReconciler(t, lvset, node, sc)
+	testConfig.runtimeConfig.Node = node
+	testConfig.runtimeConfig.Name = common.GetProvisionedByValue(*node)
+	testConfig.runtimeConfig.DiscoveryMap[sc.Name] = provCommon.MountConfig{VolumeMode: string(localv1.PersistentVolumeFilesystem)}
+	testConfig.fakeVolUtil.AddNewDirEntries("/mnt/local-storage/", map[string][]*provUtil.FakeDirEntry{
+		sc.Name: {{Name: filepath.Base(symlinkPath), Capacity: 10 * common.GiB, VolumeType: provUtil.FakeEntryBlock}},
+	})
+
+	prepared := 0
+	prepareErr := fmt.Errorf("mkfs.ext4 failed")
+	args := common.CreateLocalPVArgs{
+		LocalVolumeLikeObject: lvset,
+		RuntimeConfig:         r.runtimeConfig,
+		CleanupTracker:        r.cleanupTracker,
+		StorageClass:          *sc,
+		MountPointMap:         sets.NewString(),
+		Client:                r.client,
+		SymLinkPath:           symlinkPath,
+		DeviceName:            "sdb",
+		IDExists:              true,
+		PrepareDevice: func() error {
+			prepared++
+			return prepareErr
+		},
+	}
+	pvName := common.GeneratePVName(filepath.Base(symlinkPath), node.Name, sc.Name)
+
+	// no PV is created for a device that failed to be prepared
+	err := common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Error(t, err)
+	assert.Equal(t, 1, prepared)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: pvName}, &corev1.PersistentVolume{})
+	assert.True(t, kerrors.IsNotFound(err), "expected no PV, got %v", err)
+
+	prepareErr = nil
+	err = common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, prepared)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: pvName}, &corev1.PersistentVolume{})
+	assert.Nil(t, err)
+
+	// the device is only prepared before its PV is created
+	err = common.CreateLocalPV(args, log.WithName("testLogger"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, prepared)
+}
+
+// writeCountingClient counts the requests that write to the apiserver
+type writeCountingClient struct {
+	client.Client
+	creates int
+	updates int
+	// staleCache makes reads miss all PVs, like a cache that didn't see the latest PVs yet
+	staleCache bool
+}
+
+func (c *writeCountingClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if _, ok := obj.(*corev1.PersistentVolume); ok && c.staleCache {
+		return kerrors.NewNotFound(corev1.Resource("persistentvolumes"), key.Name)
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *writeCountingClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if _, ok := list.(*corev1.PersistentVolumeList); ok && c.staleCache {
+		return nil
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *writeCountingClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	c.creates++
+	// the fake client doesn't set the creationTimestamp like the apiserver does
+	if pv, ok := obj.(*corev1.PersistentVolume); ok {
+		pv.CreationTimestamp = metav1.Now()
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *writeCountingClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	c.updates++
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func TestCreatePVWritesPerDevice(t *testing.T) {
+	const deviceCount = 50
+	reclaimPolicyDelete := corev1.PersistentVolumeReclaimDelete
+	lvset := &localv1alpha1.LocalVolumeSet{
+		TypeMeta:   metav1.TypeMeta{Kind: localv1alpha1.LocalVolumeSetKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset-a", Namespace: "default"},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "storageclass-a"},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "nodename-a",
+			Labels: map[string]string{corev1.LabelHostname: "node-hostname-a"},
+		},
+	}
+	sc := &storagev1.StorageClass{
+		ObjectMeta:    metav1.ObjectMeta{Name: "storageclass-a"},
+		ReclaimPolicy: &reclaimPolicyDelete,
+	}
+
+	r, testConfig := newFakeLocalVolumeSetReconciler(t, lvset, node, sc)
+	testConfig.runtimeConfig.Node = node
+	testConfig.runtimeConfig.Name = common.GetProvisionedByValue(*node)
+	testConfig.runtimeConfig.DiscoveryMap[sc.Name] = provCommon.MountConfig{VolumeMode: string(localv1.PersistentVolumeBlock)}
+	entries := make([]*provUtil.FakeDirEntry, 0, deviceCount)
+	for i := 0; i < deviceCount; i++ {
+		entries = append(entries, &provUtil.FakeDirEntry{Name: fmt.Sprintf("device-%d", i), Capacity: 10 * common.GiB, VolumeType: provUtil.FakeEntryBlock})
+	}
+	testConfig.fakeVolUtil.AddNewDirEntries("/mnt/local-storage/", map[string][]*provUtil.FakeDirEntry{sc.Name: entries})
+
+	countingClient := &writeCountingClient{Client: r.client}
+	provisionAll := func() []error {
+		errs := []error{}
+		for _, entry := range entries {
+			err := common.CreateLocalPV(common.CreateLocalPVArgs{
+				LocalVolumeLikeObject: lvset,
+				RuntimeConfig:         r.runtimeConfig,
+				CleanupTracker:        r.cleanupTracker,
+				StorageClass:          *sc,
+				MountPointMap:         sets.NewString(),
+				Client:                countingClient,
+				SymLinkPath:           filepath.Join("/mnt/local-storage", sc.Name, entry.Name),
+				DeviceName:            entry.Name,
+				IDExists:              true,
+			}, log.WithName("testLogger"))
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errs
+	}
+
+	// one request per new PV
+	assert.Empty(t, provisionAll())
+	assert.Equal(t, deviceCount, countingClient.creates, "creates for new PVs")
+	assert.Equal(t, 0, countingClient.updates, "updates for new PVs")
+
+	// no requests for PVs that are up to date
+	countingClient.creates = 0
+	assert.Empty(t, provisionAll())
+	assert.Equal(t, 0, countingClient.creates, "creates for existing PVs")
+	assert.Equal(t, 0, countingClient.updates, "updates for existing PVs")
+
+	// PVs missing from a stale cache don't fail provisioning
+	countingClient.staleCache = true
+	assert.Empty(t, provisionAll())
+	pvs := &corev1.PersistentVolumeList{}
+	err := r.client.List(context.TODO(), pvs)
+	assert.Nil(t, err)
+	assert.Len(t, pvs.Items, deviceCount)
+}
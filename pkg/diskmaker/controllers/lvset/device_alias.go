@@ -0,0 +1,38 @@
+package lvset
+
+import (
+	"path"
+	"path/filepath"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// symlinkSourceAndTarget returns the source and target of the symlink of the device like common.GetSymLinkSourceAndTarget.
+// A device that already has a PV of the storageclass on this node is symlinked from the alias recorded on the PV,
+// as long as the alias still points to the device, so that the path of the PV doesn't change when the symlink
+// is created again and the device has several links.
+func (r *ReconcileLocalVolumeSet) symlinkSourceAndTarget(
+	lvset *localv1alpha1.LocalVolumeSet,
+	dev internal.BlockDevice,
+	storageClassName string,
+	symLinkDir string,
+) (string, string, bool, error) {
+	hostname := r.runtimeConfig.Node.GetLabels()[corev1.LabelHostname]
+	alias, err := common.FindPVDeviceAlias(r.client, hostname, storageClassName, dev.StableIdentity())
+	if err != nil {
+		return "", "", false, err
+	}
+	if alias != "" {
+		isMatch, err := internal.PathEvalsToDiskLabel(alias, dev.KName)
+		if err != nil {
+			return "", "", false, err
+		}
+		if isMatch {
+			return alias, path.Join(symLinkDir, filepath.Base(alias)), true, nil
+		}
+	}
+	return common.GetSymLinkSourceAndTarget(dev, symLinkDir, lvset.Spec.SymlinkTargetPreference)
+}
@@ -0,0 +1,104 @@
+package lvset
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSymlinkSourceAndTarget(t *testing.T) {
+	defer func() {
+		internal.FilePathGlob = filepath.Glob
+		internal.FilePathEvalSymLinks = filepath.EvalSymlinks
+	}()
+	// the device has three aliases in /dev/disk/by-id/, listed in no particular order
+	internal.FilePathGlob = func(pattern string) ([]string, error) {
+		if pattern != "/dev/disk/by-id/*" {
+			return []string{}, nil
+		}
+		return []string{
+			"/dev/disk/by-id/wwn-0x5000c500a0b1c2d3",
+			"/dev/disk/by-id/scsi-35000c500a0b1c2d3",
+			"/dev/disk/by-id/ata-ST4000NM0035_ZC1B2C3D",
+		}, nil
+	}
+	internal.FilePathEvalSymLinks = func(path string) (string, error) {
+		if strings.HasPrefix(path, "/dev/disk/by-id/") {
+			return "/dev/sdb", nil
+		}
+		return path, nil
+	}
+
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "sc"},
+	}
+	dev := internal.BlockDevice{Name: "sdb", KName: "sdb", Serial: "ZC1B2C3D"}
+	newPV := func(name, storageClassName, identity, alias string) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{corev1.LabelHostname: "node-a"},
+				Annotations: map[string]string{
+					common.PVDeviceIdentityAnnotation: identity,
+					common.PVDeviceAliasAnnotation:    alias,
+				},
+			},
+			Spec: corev1.PersistentVolumeSpec{
+				StorageClassName: storageClassName,
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					Local: &corev1.LocalVolumeSource{Path: "/mnt/local-storage/" + storageClassName + "/" + filepath.Base(alias)},
+				},
+			},
+		}
+	}
+	testcases := []struct {
+		label          string
+		pvs            []*corev1.PersistentVolume
+		expectedSource string
+	}{
+		{
+			label:          "no PV, the lexicographically first alias",
+			expectedSource: "/dev/disk/by-id/ata-ST4000NM0035_ZC1B2C3D",
+		},
+		{
+			label:          "the alias recorded on the PV of the device",
+			pvs:            []*corev1.PersistentVolume{newPV("pv-a", "sc", "ZC1B2C3D", "/dev/disk/by-id/wwn-0x5000c500a0b1c2d3")},
+			expectedSource: "/dev/disk/by-id/wwn-0x5000c500a0b1c2d3",
+		},
+		{
+			label: "the PVs of other devices and storageclasses",
+			pvs: []*corev1.PersistentVolume{
+				newPV("pv-a", "sc", "ZC1B2C3E", "/dev/disk/by-id/wwn-0x5000c500a0b1c2d3"),
+				newPV("pv-b", "other-sc", "ZC1B2C3D", "/dev/disk/by-id/scsi-35000c500a0b1c2d3"),
+			},
+			expectedSource: "/dev/disk/by-id/ata-ST4000NM0035_ZC1B2C3D",
+		},
+		{
+			label:          "an alias that no longer points to the device",
+			pvs:            []*corev1.PersistentVolume{newPV("pv-a", "sc", "ZC1B2C3D", "/dev/disk/by-path/pci-0000:00:1f.2-ata-2")},
+			expectedSource: "/dev/disk/by-id/ata-ST4000NM0035_ZC1B2C3D",
+		},
+	}
+
+	for _, tc := range testcases {
+		r, _ := newFakeLocalVolumeSetReconciler(t, lvset)
+		r.runtimeConfig.Node = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{corev1.LabelHostname: "node-a"}}}
+		for _, pv := range tc.pvs {
+			assert.NoError(t, r.client.Create(context.TODO(), pv))
+		}
+		source, target, idExists, err := r.symlinkSourceAndTarget(lvset, dev, "sc", "/mnt/local-storage/sc")
+		assert.NoErrorf(t, err, "[%s]", tc.label)
+		assert.Truef(t, idExists, "[%s]", tc.label)
+		assert.Equalf(t, tc.expectedSource, source, "[%s] unexpected symlink source", tc.label)
+		assert.Equalf(t, "/mnt/local-storage/sc/"+filepath.Base(tc.expectedSource), target, "[%s] unexpected symlink target", tc.label)
+	}
+}
@@ -0,0 +1,38 @@
+package lvset
+
+import (
+	"sort"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// sortDevicesBySelectionStrategy orders the devices according to the strategy,
+// so that the devices at the front of the list are provisioned first when maxDeviceCount or maxCapacityPerNode is set.
+// Ties (equal size, or unparseable sizes) are broken by kernel name so the order is stable across reconciles.
+func sortDevicesBySelectionStrategy(devices []internal.BlockDevice, strategy localv1alpha1.DeviceSelectionStrategy) {
+	sort.SliceStable(devices, func(i, j int) bool {
+		switch strategy {
+		case localv1alpha1.SmallestFirst, localv1alpha1.LargestFirst:
+			sizeI, sizeJ := deviceSize(devices[i]), deviceSize(devices[j])
+			cmp := sizeI.Cmp(sizeJ)
+			if cmp != 0 {
+				if strategy == localv1alpha1.SmallestFirst {
+					return cmp < 0
+				}
+				return cmp > 0
+			}
+		}
+		return devices[i].KName < devices[j].KName
+	})
+}
+
+// deviceSize returns the size of the device, or zero if lsblk reported a size that can't be parsed
+func deviceSize(dev internal.BlockDevice) resource.Quantity {
+	quantity, err := resource.ParseQuantity(dev.Size)
+	if err != nil {
+		return resource.Quantity{}
+	}
+	return quantity
+}
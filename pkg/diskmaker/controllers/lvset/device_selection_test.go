@@ -0,0 +1,39 @@
+package lvset
+
+import (
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortDevicesBySelectionStrategy(t *testing.T) {
+	devices := []internal.BlockDevice{
+		{KName: "sdc", Size: "2147483648"},
+		{KName: "sdb", Size: "1073741824"},
+		{KName: "sdd", Size: "1073741824"},
+		{KName: "sda", Size: "4294967296"},
+	}
+
+	testcases := []struct {
+		strategy localv1alpha1.DeviceSelectionStrategy
+		expected []string
+	}{
+		{strategy: "", expected: []string{"sda", "sdb", "sdc", "sdd"}},
+		{strategy: localv1alpha1.PathOrder, expected: []string{"sda", "sdb", "sdc", "sdd"}},
+		{strategy: localv1alpha1.SmallestFirst, expected: []string{"sdb", "sdd", "sdc", "sda"}},
+		{strategy: localv1alpha1.LargestFirst, expected: []string{"sda", "sdc", "sdb", "sdd"}},
+	}
+
+	for _, tc := range testcases {
+		sorted := make([]internal.BlockDevice, len(devices))
+		copy(sorted, devices)
+		sortDevicesBySelectionStrategy(sorted, tc.strategy)
+		names := make([]string, 0)
+		for _, dev := range sorted {
+			names = append(names, dev.KName)
+		}
+		assert.Equalf(t, tc.expected, names, "strategy: %q", tc.strategy)
+	}
+}
@@ -0,0 +1,77 @@
+package lvset
+
+import (
+	"sync"
+	"time"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+)
+
+// minStableDuration returns how long a device must have been seen stable before it is provisioned, 0 if it isn't required
+func minStableDuration(spec *localv1alpha1.DeviceInclusionSpec) time.Duration {
+	if spec == nil || spec.MinStableDuration == nil || spec.MinStableDuration.Duration < 0 {
+		return 0
+	}
+	return spec.MinStableDuration.Duration
+}
+
+// stableDevice is a device that has been seen with the same serial number and not read-only since stableSince
+type stableDevice struct {
+	serial      string
+	stableSince time.Time
+}
+
+// stabilityMap records since when the diskmaker has seen the block devices of the node stable, by kernel name.
+// It is shared by the LocalVolumeSets and only lives as long as the process, so that the devices start over
+// when the diskmaker restarts.
+type stabilityMap struct {
+	devices map[string]stableDevice
+	mux     sync.Mutex
+	clock   timeInterface
+}
+
+func newStabilityMap(clock timeInterface) *stabilityMap {
+	return &stabilityMap{
+		devices: map[string]stableDevice{},
+		clock:   clock,
+	}
+}
+
+// observe records the block devices of the node: a device seen for the first time, with another serial number
+// or that was read-only starts over, and the devices that are gone or read-only are forgotten
+func (s *stabilityMap) observe(blockDevices []internal.BlockDevice) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	now := s.clock.getCurrentTime()
+	seen := map[string]stableDevice{}
+	for _, blockDevice := range blockDevices {
+		readOnly, err := blockDevice.GetReadOnly()
+		if err != nil || readOnly {
+			continue
+		}
+		device, found := s.devices[blockDevice.KName]
+		if !found || device.serial != blockDevice.Serial {
+			device = stableDevice{serial: blockDevice.Serial, stableSince: now}
+		}
+		seen[blockDevice.KName] = device
+	}
+	s.devices = seen
+}
+
+// unstableFor returns how long the device still has to be seen stable to reach minDuration, 0 once it has
+func (s *stabilityMap) unstableFor(blockDevice internal.BlockDevice, minDuration time.Duration) time.Duration {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	device, found := s.devices[blockDevice.KName]
+	if !found || device.serial != blockDevice.Serial {
+		return minDuration
+	}
+	remaining := minDuration - s.clock.getCurrentTime().Sub(device.stableSince)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
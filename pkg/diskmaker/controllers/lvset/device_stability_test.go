@@ -0,0 +1,58 @@
+package lvset
+
+import (
+	"testing"
+	"time"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMinStableDuration(t *testing.T) {
+	assert.Zero(t, minStableDuration(nil))
+	spec := &localv1alpha1.DeviceInclusionSpec{}
+	assert.Zero(t, minStableDuration(spec))
+	spec.MinStableDuration = &metav1.Duration{Duration: 10 * time.Minute}
+	assert.Equal(t, 10*time.Minute, minStableDuration(spec))
+	spec.MinStableDuration = &metav1.Duration{Duration: -time.Minute}
+	assert.Zero(t, minStableDuration(spec), "a negative duration is not required")
+}
+
+func TestStabilityMap(t *testing.T) {
+	clock := &fakeClock{ftime: time.Unix(0, 0)}
+	stability := newStabilityMap(clock)
+	minDuration := 10 * time.Minute
+	sdb := internal.BlockDevice{KName: "sdb", Serial: "serial-1", ReadOnly: "0"}
+	sdc := internal.BlockDevice{KName: "sdc", Serial: "serial-2", ReadOnly: "0"}
+
+	assert.Equal(t, minDuration, stability.unstableFor(sdb, minDuration), "a device that was never seen is not stable")
+	stability.observe([]internal.BlockDevice{sdb, sdc})
+	assert.Equal(t, minDuration, stability.unstableFor(sdb, minDuration))
+	assert.Zero(t, stability.unstableFor(sdb, 0))
+
+	clock.ftime = clock.ftime.Add(4 * time.Minute)
+	stability.observe([]internal.BlockDevice{sdb, sdc})
+	assert.Equal(t, 6*time.Minute, stability.unstableFor(sdb, minDuration), "the first observation is kept")
+
+	// the controller resets: sdb disappears, sdc turns read-only
+	stability.observe([]internal.BlockDevice{{KName: "sdc", Serial: "serial-2", ReadOnly: "1"}})
+	clock.ftime = clock.ftime.Add(time.Minute)
+	stability.observe([]internal.BlockDevice{sdb, sdc})
+	assert.Equal(t, minDuration, stability.unstableFor(sdb, minDuration), "a device that disappeared starts over")
+	assert.Equal(t, minDuration, stability.unstableFor(sdc, minDuration), "a device that was read-only starts over")
+
+	// another device under the same kernel name
+	clock.ftime = clock.ftime.Add(5 * time.Minute)
+	replaced := internal.BlockDevice{KName: "sdb", Serial: "serial-3", ReadOnly: "0"}
+	assert.Equal(t, minDuration, stability.unstableFor(replaced, minDuration), "a device with another serial was never seen")
+	stability.observe([]internal.BlockDevice{replaced, sdc})
+	assert.Equal(t, minDuration, stability.unstableFor(replaced, minDuration))
+	assert.Equal(t, 5*time.Minute, stability.unstableFor(sdc, minDuration))
+
+	clock.ftime = clock.ftime.Add(10 * time.Minute)
+	stability.observe([]internal.BlockDevice{replaced, sdc})
+	assert.Zero(t, stability.unstableFor(replaced, minDuration), "the device is stable")
+	assert.Zero(t, stability.unstableFor(sdc, minDuration))
+}
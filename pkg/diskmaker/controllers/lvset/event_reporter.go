@@ -8,6 +8,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
 	"github.com/openshift/local-storage-operator/pkg/diskmaker"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
@@ -17,6 +18,30 @@ const (
 	ErrorListingExistingSymlinks = "ErrorListingExistingSymlinks"
 	// DiscoveredNewDevice is an event reason string
 	DiscoveredNewDevice = "DiscoveredNewDevice"
+	// ReleasedExcludedDevice is an event reason string
+	ReleasedExcludedDevice = "ReleasedExcludedDevice"
+	// ExcludedDeviceInUse is an event reason string
+	ExcludedDeviceInUse = "ExcludedDeviceInUse"
+	// ReleasedRemovedNodeDevice is an event reason string
+	ReleasedRemovedNodeDevice = "ReleasedRemovedNodeDevice"
+	// DeviceQuarantined is an event reason string
+	DeviceQuarantined = string(localv1alpha1.RejectedDeviceQuarantined)
+	// DeviceInUse is an event reason string
+	DeviceInUse = string(localv1alpha1.RejectedDeviceInUse)
+	// DeviceTooSmallForFilesystem is an event reason string
+	DeviceTooSmallForFilesystem = string(localv1alpha1.RejectedDeviceTooSmallForFilesystem)
+	// DeviceIOPSUnknown is an event reason string
+	DeviceIOPSUnknown = "DeviceIOPSUnknown"
+	// DeviceWriteProbeFailed is an event reason string
+	DeviceWriteProbeFailed = "DeviceWriteProbeFailed"
+	// DeviceReserved is an event reason string
+	DeviceReserved = "DeviceReserved"
+	// DeviceNotStable is an event reason string
+	DeviceNotStable = "DeviceNotStable"
+	// RootDeviceExcluded is an event reason string
+	RootDeviceExcluded = "RootDeviceExcluded"
+	// ErrorFindingRootDevices is an event reason string
+	ErrorFindingRootDevices = "ErrorFindingRootDevices"
 )
 
 func newDiskEvent(eventReason, message, disk, eventType string) diskmaker.DiskEvent {
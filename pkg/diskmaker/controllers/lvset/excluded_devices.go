@@ -0,0 +1,75 @@
+package lvset
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-logr/logr"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// releaseExcludedDevices removes the symlinks and unbound PVs of the devices listed in spec.excludeBySerial.
+// PVs that are bound are left in place, the operator reports them in the ExcludedDevicesInUse condition.
+func (r *ReconcileLocalVolumeSet) releaseExcludedDevices(
+	reqLogger logr.Logger,
+	lvset *localv1alpha1.LocalVolumeSet,
+	inclusionSpec *localv1alpha1.DeviceInclusionSpec,
+	blockDevices []internal.BlockDevice,
+	storageClassName string,
+	symLinkDir string,
+) error {
+	hostname := r.runtimeConfig.Node.GetLabels()[corev1.LabelHostname]
+	for _, blockDevice := range blockDevices {
+		if !isExcludedBySerial(blockDevice, inclusionSpec) {
+			continue
+		}
+		devLogger := reqLogger.WithValues("Device.Name", blockDevice.Name, "Device.Serial", blockDevice.Serial)
+
+		_, symlinkPath, _, err := r.symlinkSourceAndTarget(lvset, blockDevice, storageClassName, symLinkDir)
+		if err != nil {
+			devLogger.Error(err, "error while discovering symlink target")
+			continue
+		}
+		if _, err := os.Lstat(symlinkPath); os.IsNotExist(err) {
+			continue
+		}
+
+		pv, err := common.FindPVForSymLink(r.client, hostname, storageClassName, symlinkPath)
+		if err != nil {
+			return err
+		}
+		// the PVs of a LocalVolumeSet that doesn't manage them belong to another tool
+		if pv != nil && !lvset.ManagesPersistentVolumes() {
+			devLogger.Info("device is excluded by serial, but its PV is not managed by the LocalVolumeSet and is left in place", "pv", pv.Name)
+			continue
+		}
+		if pv != nil && (pv.Spec.ClaimRef != nil || pv.Status.Phase != corev1.VolumeAvailable) {
+			r.eventReporter.Report(lvset, newDiskEvent(ExcludedDeviceInUse,
+				fmt.Sprintf("device is excluded by serial, but its PV %s is %s and is left in place", pv.Name, pv.Status.Phase),
+				blockDevice.KName, corev1.EventTypeWarning))
+			continue
+		}
+
+		// remove the symlink first, so that the PV isn't recreated for it
+		devLogger.Info("releasing excluded device", "symlink", symlinkPath)
+		err = os.Remove(symlinkPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not remove symlink %q of excluded device: %w", symlinkPath, err)
+		}
+		if pv != nil {
+			// the precondition fails if the PV was bound meanwhile
+			err = r.client.Delete(context.TODO(), pv, client.Preconditions{ResourceVersion: &pv.ResourceVersion})
+			if err != nil && !kerrors.IsNotFound(err) {
+				return fmt.Errorf("could not delete PV %q of excluded device: %w", pv.Name, err)
+			}
+		}
+		r.eventReporter.Report(lvset, newDiskEvent(ReleasedExcludedDevice, "released device that is excluded by serial", blockDevice.KName, corev1.EventTypeNormal))
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package lvset
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDevicesTooSmallForFilesystem(t *testing.T) {
+	// only run the size matcher, with minSize: 0
+	oldFilterMap := FilterMap
+	FilterMap = make(map[string]func(internal.BlockDevice, *localv1alpha1.DeviceInclusionSpec) (bool, error), 0)
+	oldMatcherMap := matcherMap
+	matcherMap = map[string]func(internal.BlockDevice, *localv1alpha1.DeviceInclusionSpec) (bool, error){
+		inSizeRange: oldMatcherMap[inSizeRange],
+	}
+	defer func() {
+		FilterMap = oldFilterMap
+		matcherMap = oldMatcherMap
+	}()
+
+	lvset := &localv1alpha1.LocalVolumeSet{ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace}}
+	minSize := resource.MustParse("0")
+	inclusionSpec := &localv1alpha1.DeviceInclusionSpec{MinSize: &minSize}
+	r, tc := newFakeLocalVolumeSetReconciler(t, lvset)
+	// 1MiB, 10MiB and 1GiB
+	blockDevices := []internal.BlockDevice{{KName: "sdb", Size: "1048576"}, {KName: "sdc", Size: "10485760"}, {KName: "sdd", Size: "1073741824"}}
+	// let the devices reach deviceMinAge
+	r.getValidDevices(log, lvset, inclusionSpec, blockDevices)
+	tc.fakeClock.ftime = tc.fakeClock.ftime.Add(deviceMinAge + time.Second)
+
+	validDevices, _ := r.getValidDevices(log, lvset, inclusionSpec, blockDevices)
+	assert.Equal(t, blockDevices[1:], validDevices, "the device below 10Mi is skipped")
+	found := false
+	for len(tc.eventStream) > 0 {
+		event := <-tc.eventStream
+		if strings.Contains(event, DeviceTooSmallForFilesystem) {
+			assert.Contains(t, event, "1048576")
+			found = true
+		}
+	}
+	assert.True(t, found, "expected a %s event", DeviceTooSmallForFilesystem)
+
+	r.options.MinFilesystemDeviceSize = resource.MustParse("100Mi")
+	validDevices, _ = r.getValidDevices(log, lvset, inclusionSpec, blockDevices)
+	assert.Equal(t, blockDevices[2:], validDevices, "the minimum is configurable")
+
+	lvset.Spec.VolumeMode = localv1.PersistentVolumeBlock
+	validDevices, _ = r.getValidDevices(log, lvset, inclusionSpec, blockDevices)
+	assert.Equal(t, blockDevices, validDevices, "block devices of any size are provisioned")
+}
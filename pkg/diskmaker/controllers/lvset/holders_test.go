@@ -0,0 +1,58 @@
+package lvset
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDevicesWithHolders(t *testing.T) {
+	// only run the holders filter
+	oldFilterMap := FilterMap
+	FilterMap = map[string]func(internal.BlockDevice, *localv1alpha1.DeviceInclusionSpec) (bool, error){
+		noHolders: oldFilterMap[noHolders],
+	}
+	oldMatcherMap := matcherMap
+	matcherMap = make(map[string]func(internal.BlockDevice, *localv1alpha1.DeviceInclusionSpec) (bool, error), 0)
+	defer func() {
+		FilterMap = oldFilterMap
+		matcherMap = oldMatcherMap
+		internal.FilePathGlob = filepath.Glob
+	}()
+	// sdb is an LVM physical volume
+	internal.FilePathGlob = func(pattern string) ([]string, error) {
+		if strings.HasPrefix(pattern, "/sys/class/block/sdb/holders") {
+			return []string{"/sys/class/block/sdb/holders/dm-0"}, nil
+		}
+		return []string{}, nil
+	}
+
+	lvset := &localv1alpha1.LocalVolumeSet{ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace}}
+	r, tc := newFakeLocalVolumeSetReconciler(t, lvset)
+	blockDevices := []internal.BlockDevice{{KName: "sdb", Size: "10737418240"}, {KName: "sdc", Size: "10737418240"}}
+	// let the devices reach deviceMinAge
+	r.getValidDevices(log, lvset, nil, blockDevices)
+	tc.fakeClock.ftime = tc.fakeClock.ftime.Add(deviceMinAge + time.Second)
+
+	validDevices, _ := r.getValidDevices(log, lvset, nil, blockDevices)
+	assert.Equal(t, []internal.BlockDevice{{KName: "sdc", Size: "10737418240"}}, validDevices, "the device with holders is skipped")
+	found := false
+	for len(tc.eventStream) > 0 {
+		event := <-tc.eventStream
+		if strings.Contains(event, DeviceInUse) {
+			assert.Contains(t, event, "dm-0")
+			found = true
+		}
+	}
+	assert.True(t, found, "expected a %s event", DeviceInUse)
+
+	lvset.Spec.AllowDevicesWithHolders = true
+	validDevices, _ = r.getValidDevices(log, lvset, nil, blockDevices)
+	assert.Len(t, validDevices, 2, "allowDevicesWithHolders provisions devices with holders")
+}
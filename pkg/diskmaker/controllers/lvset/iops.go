@@ -0,0 +1,71 @@
+package lvset
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// nodeDeviceIOPS parses the common.NodeDeviceIOPSAnnotation of the node into the IOPS of its devices by serial number.
+// found is false if the node doesn't have the annotation.
+func nodeDeviceIOPS(node *corev1.Node) (deviceIOPS map[string]int64, found bool, err error) {
+	if node == nil {
+		return nil, false, nil
+	}
+	value, found := node.Annotations[common.NodeDeviceIOPSAnnotation]
+	if !found {
+		return nil, false, nil
+	}
+	deviceIOPS = map[string]int64{}
+	err = json.Unmarshal([]byte(value), &deviceIOPS)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to parse annotation %s of node %s: %w", common.NodeDeviceIOPSAnnotation, node.Name, err)
+	}
+	return deviceIOPS, true, nil
+}
+
+// minIOPSDeviceIOPS returns the IOPS of the devices of the node when the inclusionSpec sets minIOPS.
+// It returns nil, so that minIOPS is ignored, when the node has no valid IOPS metadata,
+// which is reported in a warning event.
+func (r *ReconcileLocalVolumeSet) minIOPSDeviceIOPS(
+	reqLogger logr.Logger,
+	lvset *localv1alpha1.LocalVolumeSet,
+	inclusionSpec *localv1alpha1.DeviceInclusionSpec,
+) map[string]int64 {
+	if inclusionSpec == nil || inclusionSpec.MinIOPS == nil {
+		return nil
+	}
+	var node *corev1.Node
+	if r.runtimeConfig != nil {
+		node = r.runtimeConfig.Node
+	}
+	deviceIOPS, found, err := nodeDeviceIOPS(node)
+	message := ""
+	if err != nil {
+		message = fmt.Sprintf("ignoring minIOPS: %v", err)
+	} else if !found {
+		message = fmt.Sprintf("ignoring minIOPS: the node has no %s annotation with the IOPS of its devices", common.NodeDeviceIOPSAnnotation)
+	}
+	if message != "" {
+		reqLogger.Info(message)
+		if lvset != nil {
+			r.eventReporter.Report(lvset, newDiskEvent(DeviceIOPSUnknown, message, "", corev1.EventTypeWarning))
+		}
+		return nil
+	}
+	return deviceIOPS
+}
+
+// hasMinIOPS returns true if the device is listed in deviceIOPS by serial number with at least minIOPS
+func hasMinIOPS(dev internal.BlockDevice, minIOPS int64, deviceIOPS map[string]int64) bool {
+	if dev.Serial == "" {
+		return false
+	}
+	iops, found := deviceIOPS[dev.Serial]
+	return found && iops >= minIOPS
+}
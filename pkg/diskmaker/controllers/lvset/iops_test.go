@@ -0,0 +1,75 @@
+package lvset
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMinIOPS(t *testing.T) {
+	// only run the minIOPS check
+	oldFilterMap := FilterMap
+	FilterMap = make(map[string]func(internal.BlockDevice, *localv1alpha1.DeviceInclusionSpec) (bool, error), 0)
+	oldMatcherMap := matcherMap
+	matcherMap = make(map[string]func(internal.BlockDevice, *localv1alpha1.DeviceInclusionSpec) (bool, error), 0)
+	defer func() {
+		FilterMap = oldFilterMap
+		matcherMap = oldMatcherMap
+	}()
+
+	lvset := &localv1alpha1.LocalVolumeSet{ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace}}
+	r, tc := newFakeLocalVolumeSetReconciler(t, lvset)
+	blockDevices := []internal.BlockDevice{
+		{KName: "nvme0n1", Serial: "vol-fast", Size: "10737418240"},
+		{KName: "nvme1n1", Serial: "vol-slow", Size: "10737418240"},
+		{KName: "nvme2n1", Serial: "vol-unknown", Size: "10737418240"},
+		{KName: "nvme3n1", Size: "10737418240"},
+	}
+	minIOPS := int64(10000)
+	inclusionSpec := &localv1alpha1.DeviceInclusionSpec{MinIOPS: &minIOPS}
+	// let the devices reach deviceMinAge
+	r.getValidDevices(log, lvset, inclusionSpec, blockDevices)
+	tc.fakeClock.ftime = tc.fakeClock.ftime.Add(deviceMinAge + time.Second)
+	drainEvents := func() []string {
+		events := []string{}
+		for len(tc.eventStream) > 0 {
+			events = append(events, <-tc.eventStream)
+		}
+		return events
+	}
+	drainEvents()
+
+	validDevices, _ := r.getValidDevices(log, lvset, nil, blockDevices)
+	assert.Len(t, validDevices, 4, "all devices match without minIOPS")
+
+	r.runtimeConfig.Node.Annotations = map[string]string{common.NodeDeviceIOPSAnnotation: `{"vol-fast": 16000, "vol-slow": 3000}`}
+	validDevices, _ = r.getValidDevices(log, lvset, inclusionSpec, blockDevices)
+	assert.Equal(t, []internal.BlockDevice{{KName: "nvme0n1", Serial: "vol-fast", Size: "10737418240"}}, validDevices,
+		"devices below minIOPS or without IOPS metadata are skipped")
+	assert.Empty(t, drainEvents())
+
+	for _, annotations := range []map[string]string{
+		nil,
+		{common.NodeDeviceIOPSAnnotation: "16000"},
+	} {
+		// the event reporter reports an event once
+		r.eventReporter = newEventReporter(tc.fakeRecorder)
+		r.runtimeConfig.Node.Annotations = annotations
+		validDevices, _ = r.getValidDevices(log, lvset, inclusionSpec, blockDevices)
+		assert.Len(t, validDevices, 4, "minIOPS is ignored without valid IOPS metadata: %v", annotations)
+		found := false
+		for _, event := range drainEvents() {
+			if strings.Contains(event, DeviceIOPSUnknown) {
+				assert.Contains(t, event, "Warning")
+				found = true
+			}
+		}
+		assert.True(t, found, "expected a %s event for annotations %v", DeviceIOPSUnknown, annotations)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/diskmaker"
 	"github.com/openshift/local-storage-operator/pkg/internal"
 	"golang.org/x/sys/unix"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -18,6 +19,8 @@ const (
 	noBiosBootInPartLabel = "noBiosBootInPartLabel"
 	noFilesystemSignature = "noFilesystemSignature"
 	noBindMounts          = "noBindMounts"
+	notRAIDMember         = "notRAIDMember"
+	noHolders             = "noHolders"
 	// file access , can't mock test
 	noChildren = "noChildren"
 	// file access , can't mock test
@@ -27,12 +30,25 @@ const (
 	inSizeRange              = "inSizeRange"
 	inTypeList               = "inTypeList"
 	inMechanicalPropertyList = "inMechanicalPropertyList"
+	notExcludedBySerial      = "notExcludedBySerial"
 	inVendorList             = "inVendorList"
 	inModelList              = "inModelList"
+	inPartLabelList          = "inPartLabelList"
+	inTransportList          = "inTransportList"
+	inFSLabelList            = "inFSLabelList"
 )
 
 var defaultMinSize = resource.MustParse("1Gi")
 
+// filesystemLabel returns the label of the filesystem of the device, it can be replaced in tests
+var filesystemLabel = func(dev internal.BlockDevice) (string, error) {
+	pathname, err := dev.GetDevPath()
+	if err != nil {
+		return "", err
+	}
+	return internal.GetFilesystemLabel(pathname)
+}
+
 // maps of function identifier (for logs) to filter function.
 // These are passed the localv1alpha1.DeviceInclusionSpec to make testing easier,
 // but they aren't expected to use it
@@ -67,6 +83,18 @@ var FilterMap = map[string]func(internal.BlockDevice, *localv1alpha1.DeviceInclu
 		hasBindMounts, _, err := dev.HasBindMounts()
 		return !hasBindMounts, err
 	},
+	// claiming a member of an mdadm array (e.g. the root filesystem mirror) would destroy the array
+	notRAIDMember: func(dev internal.BlockDevice, spec *localv1alpha1.DeviceInclusionSpec) (bool, error) {
+		isRAIDMember, err := dev.IsRAIDMember()
+		return !isRAIDMember, err
+	},
+
+	// a device with holders is in use by the kernel, e.g. as an LVM physical volume,
+	// even when no process has it open
+	noHolders: func(dev internal.BlockDevice, spec *localv1alpha1.DeviceInclusionSpec) (bool, error) {
+		holders, err := dev.GetHolders()
+		return len(holders) == 0, err
+	},
 
 	noChildren: func(dev internal.BlockDevice, spec *localv1alpha1.DeviceInclusionSpec) (bool, error) {
 		hasChildren, err := dev.HasChildren()
@@ -97,6 +125,8 @@ var FilterMap = map[string]func(internal.BlockDevice, *localv1alpha1.DeviceInclu
 // functions that match device by *localv1alpha1.DeviceInclusionSpec
 var matcherMap = map[string]func(internal.BlockDevice, *localv1alpha1.DeviceInclusionSpec) (bool, error){
 
+	// the device size is the exact byte count from lsblk, and resource.Quantity compares without rounding,
+	// so fractional limits like 1.5Ti include a device of exactly that size but not one a byte smaller
 	inSizeRange: func(dev internal.BlockDevice, spec *localv1alpha1.DeviceInclusionSpec) (bool, error) {
 		if spec == nil {
 			return true, nil
@@ -106,13 +136,14 @@ var matcherMap = map[string]func(internal.BlockDevice, *localv1alpha1.DeviceIncl
 		if err != nil {
 			return false, fmt.Errorf("could not parse device size: %w", err)
 		}
-		greaterThanOrEqualToMin := true
-		if spec.MinSize == nil {
-			spec.MinSize = &defaultMinSize
+		// don't default the spec in place, it is shared with other devices and the cache
+		minSize := defaultMinSize
+		if spec.MinSize != nil {
+			minSize = *spec.MinSize
 		}
 		// quantity greater than min: -1
 		// quantity equal to min: 0
-		greaterThanOrEqualToMin = spec.MinSize.Cmp(quantity) <= 0
+		greaterThanOrEqualToMin := minSize.Cmp(quantity) <= 0
 
 		lessThanOrEqualToMax := true
 		if spec.MaxSize != nil {
@@ -180,6 +211,10 @@ var matcherMap = map[string]func(internal.BlockDevice, *localv1alpha1.DeviceIncl
 		return matched, nil
 	},
 
+	notExcludedBySerial: func(dev internal.BlockDevice, spec *localv1alpha1.DeviceInclusionSpec) (bool, error) {
+		return !isExcludedBySerial(dev, spec), nil
+	},
+
 	inModelList: func(dev internal.BlockDevice, spec *localv1alpha1.DeviceInclusionSpec) (bool, error) {
 		if spec == nil {
 			return true, nil
@@ -196,4 +231,83 @@ var matcherMap = map[string]func(internal.BlockDevice, *localv1alpha1.DeviceIncl
 		}
 		return matched, nil
 	},
+
+	// partition labels are matched exactly, they are chosen to identify the partitions to consume
+	inPartLabelList: func(dev internal.BlockDevice, spec *localv1alpha1.DeviceInclusionSpec) (bool, error) {
+		if spec == nil {
+			return true, nil
+		}
+		if len(spec.PartLabels) == 0 {
+			return true, nil
+		}
+		if dev.PartLabel == "" {
+			return false, nil
+		}
+		matched := false
+		for _, partLabel := range spec.PartLabels {
+			if dev.PartLabel == partLabel {
+				matched = true
+				break
+			}
+		}
+		return matched, nil
+	},
+
+	inTransportList: func(dev internal.BlockDevice, spec *localv1alpha1.DeviceInclusionSpec) (bool, error) {
+		if spec == nil {
+			return true, nil
+		}
+		if len(spec.Transports) == 0 {
+			return true, nil
+		}
+		matched := false
+		for _, transport := range spec.Transports {
+			if strings.EqualFold(dev.Transport, transport) {
+				matched = true
+				break
+			}
+		}
+		return matched, nil
+	},
+
+	// filesystem labels are matched exactly, like partition labels. The label is only probed for devices
+	// with a filesystem, which are only reattached with reattachExisting.
+	inFSLabelList: func(dev internal.BlockDevice, spec *localv1alpha1.DeviceInclusionSpec) (bool, error) {
+		if spec == nil {
+			return true, nil
+		}
+		if len(spec.FSLabels) == 0 {
+			return true, nil
+		}
+		if dev.FSType == "" {
+			return false, nil
+		}
+		label, err := filesystemLabel(dev)
+		if err != nil {
+			return false, err
+		}
+		for _, fsLabel := range spec.FSLabels {
+			if label == fsLabel {
+				return true, nil
+			}
+		}
+		return false, nil
+	},
+}
+
+// isExcludedBySerial returns true if the device's serial is listed in spec.excludeBySerial
+// or if the device is listed in the host exclude file of the node
+func isExcludedBySerial(dev internal.BlockDevice, spec *localv1alpha1.DeviceInclusionSpec) bool {
+	if diskmaker.IsHostExcluded(dev) {
+		return true
+	}
+	if spec == nil || dev.Serial == "" {
+		return false
+	}
+	for _, serial := range spec.ExcludeBySerial {
+		if serial == dev.Serial {
+			return true
+		}
+	}
+	return false
 }
@@ -339,6 +339,72 @@ func TestInSizeRange(t *testing.T) {
 	assertAll(t, results)
 }
 
+func TestInSizeRangeBoundaries(t *testing.T) {
+	onePointFiveTi := resource.MustParse("1.5Ti")
+	onePointOneGi := resource.MustParse("1.1Gi")
+	twoTi := resource.MustParse("2Ti")
+
+	matcherMap := matcherMap
+	matcher := inSizeRange
+	results := []knownMatcherResult{
+		// exactly minSize
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Size: "1649267441664"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{MinSize: &onePointFiveTi},
+			expectMatch: true,
+		},
+		// one byte under minSize
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Size: "1649267441663"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{MinSize: &onePointFiveTi},
+			expectMatch: false,
+		},
+		// exactly maxSize
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Size: "1649267441664"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{MaxSize: &onePointFiveTi},
+			expectMatch: true,
+		},
+		// one byte over maxSize
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Size: "1649267441665"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{MaxSize: &onePointFiveTi},
+			expectMatch: false,
+		},
+		// 1.1Gi is 1181116006.4 bytes, which isn't rounded down to a whole byte
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Size: "1181116006"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{MinSize: &onePointOneGi, MaxSize: &twoTi},
+			expectMatch: false,
+		},
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Size: "1181116007"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{MinSize: &onePointOneGi, MaxSize: &twoTi},
+			expectMatch: true,
+		},
+		// minSize and maxSize are equal
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Size: "1649267441664"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{MinSize: &onePointFiveTi, MaxSize: &onePointFiveTi},
+			expectMatch: true,
+		},
+	}
+	assertAll(t, results)
+
+	// the default minSize is not written to the spec
+	spec := &localv1alpha1.DeviceInclusionSpec{}
+	_, err := matcherMap[matcher](internal.BlockDevice{Size: fmt.Sprintf("%d", 10*Gi)}, spec)
+	assert.NoError(t, err)
+	assert.Nil(t, spec.MinSize)
+}
+
 // func Testin size range(t *testing.T) {
 // 	match, err := in size range()
 // }
@@ -434,6 +500,19 @@ func TestInMechanicalPropertyList(t *testing.T) {
 	matcherMap := matcherMap
 	matcher := inMechanicalPropertyList
 	results := []knownMatcherResult{
+		// unset, matches both
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Rotational: "0"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{},
+			expectMatch: true, expectErr: false,
+		},
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Rotational: "1"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{},
+			expectMatch: true, expectErr: false,
+		},
 		// exact match
 		{
 			matcherMap: matcherMap, matcher: matcher,
@@ -606,7 +685,189 @@ func TestInModelList(t *testing.T) {
 	assertAll(t, results)
 }
 
+func TestInPartLabelList(t *testing.T) {
+	matcherMap := matcherMap
+	matcher := inPartLabelList
+	results := []knownMatcherResult{
+		// no labels
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Type: "disk"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{},
+			expectMatch: true, expectErr: false,
+		},
+		// exact match
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Type: "part", PartLabel: "k8s-local-data"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{PartLabels: []string{"k8s-local-data"}},
+			expectMatch: true, expectErr: false,
+		},
+		// subset match
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Type: "part", PartLabel: "k8s-local-data"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{PartLabels: []string{"k8s-local-logs", "k8s-local-data"}},
+			expectMatch: true, expectErr: false,
+		},
+		// substring mismatch
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Type: "part", PartLabel: "k8s-local-data-old"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{PartLabels: []string{"k8s-local-data"}},
+			expectMatch: false, expectErr: false,
+		},
+		// disks have no label
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Type: "disk"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{PartLabels: []string{"k8s-local-data"}},
+			expectMatch: false, expectErr: false,
+		},
+	}
+	assertAll(t, results)
+}
+
+func TestInTransportList(t *testing.T) {
+	matcherMap := matcherMap
+	matcher := inTransportList
+	results := []knownMatcherResult{
+		// no transports
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Transport: "sata"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{},
+			expectMatch: true, expectErr: false,
+		},
+		// match
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Transport: "nvme"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{Transports: []string{"nvme"}},
+			expectMatch: true, expectErr: false,
+		},
+		// case insensitive match
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Transport: "iscsi"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{Transports: []string{"fc", "iSCSI"}},
+			expectMatch: true, expectErr: false,
+		},
+		// other transport
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Transport: "sata"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{Transports: []string{"nvme"}},
+			expectMatch: false, expectErr: false,
+		},
+		// no transport reported
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Type: "loop"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{Transports: []string{"nvme"}},
+			expectMatch: false, expectErr: false,
+		},
+	}
+	assertAll(t, results)
+}
+
+func TestInFSLabelList(t *testing.T) {
+	labels := map[string]string{"sdb": "pg-data-01", "sdc": ""}
+	defer func(probe func(internal.BlockDevice) (string, error)) { filesystemLabel = probe }(filesystemLabel)
+	filesystemLabel = func(dev internal.BlockDevice) (string, error) {
+		label, found := labels[dev.KName]
+		if !found {
+			return "", fmt.Errorf("failed to probe %q", dev.KName)
+		}
+		return label, nil
+	}
+
+	matcherMap := matcherMap
+	matcher := inFSLabelList
+	results := []knownMatcherResult{
+		// no labels
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{KName: "sdd"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{},
+			expectMatch: true, expectErr: false,
+		},
+		// match
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{KName: "sdb", FSType: "xfs"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{FSLabels: []string{"pg-data-00", "pg-data-01"}},
+			expectMatch: true, expectErr: false,
+		},
+		// other label
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{KName: "sdb", FSType: "xfs"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{FSLabels: []string{"pg-data"}},
+			expectMatch: false, expectErr: false,
+		},
+		// filesystem without a label
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{KName: "sdc", FSType: "ext4"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{FSLabels: []string{"pg-data-01"}},
+			expectMatch: false, expectErr: false,
+		},
+		// blank device, not probed
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{KName: "sdd"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{FSLabels: []string{"pg-data-01"}},
+			expectMatch: false, expectErr: false,
+		},
+		// probe failure
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{KName: "sdd", FSType: "ext4"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{FSLabels: []string{"pg-data-01"}},
+			expectMatch: false, expectErr: true,
+		},
+	}
+	assertAll(t, results)
+}
+
 // a known result for a particular filter that can be asserted
+func TestNotExcludedBySerial(t *testing.T) {
+	matcherMap := matcherMap
+	matcher := notExcludedBySerial
+	results := []knownMatcherResult{
+		// nothing excluded
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Serial: "S123"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{},
+			expectMatch: true, expectErr: false,
+		},
+		// excluded
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Serial: "S123"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{ExcludeBySerial: []string{"S000", "S123"}},
+			expectMatch: false, expectErr: false,
+		},
+		// serials must match exactly
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{Serial: "S1234"},
+			spec:        &localv1alpha1.DeviceInclusionSpec{ExcludeBySerial: []string{"S123"}},
+			expectMatch: true, expectErr: false,
+		},
+		// devices without a serial are never excluded
+		{
+			matcherMap: matcherMap, matcher: matcher,
+			dev:         internal.BlockDevice{},
+			spec:        &localv1alpha1.DeviceInclusionSpec{ExcludeBySerial: []string{""}},
+			expectMatch: true, expectErr: false,
+		},
+	}
+	assertAll(t, results)
+}
+
 type knownMatcherResult struct {
 	// should pass one of filterMap or matcherMap
 	matcherMap  map[string]func(internal.BlockDevice, *localv1alpha1.DeviceInclusionSpec) (bool, error)
@@ -0,0 +1,79 @@
+package lvset
+
+import (
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// effectiveDeviceInclusionSpec returns the deviceInclusionSpec for the node, with the
+// matching spec.nodeOverrides merged over the base spec in list order.
+// An override selects a node if its nodeName equals the node's name or its nodeSelector matches the node's labels.
+func effectiveDeviceInclusionSpec(lvset *localv1alpha1.LocalVolumeSet, node *corev1.Node) (*localv1alpha1.DeviceInclusionSpec, error) {
+	var spec *localv1alpha1.DeviceInclusionSpec
+	if lvset.Spec.DeviceInclusionSpec != nil {
+		spec = lvset.Spec.DeviceInclusionSpec.DeepCopy()
+	}
+
+	for _, override := range lvset.Spec.NodeOverrides {
+		matches := override.NodeName != "" && override.NodeName == node.Name
+		if !matches && override.NodeSelector != nil {
+			var err error
+			matches, err = common.NodeSelectorMatchesNodeLabels(node, override.NodeSelector)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !matches {
+			continue
+		}
+		if spec == nil {
+			spec = &localv1alpha1.DeviceInclusionSpec{}
+		}
+		mergeDeviceInclusionSpec(spec, override.DeviceInclusionSpec.DeepCopy())
+	}
+	return spec, nil
+}
+
+// mergeDeviceInclusionSpec replaces the fields of base with the fields that are set in override
+func mergeDeviceInclusionSpec(base, override *localv1alpha1.DeviceInclusionSpec) {
+	if len(override.DeviceTypes) > 0 {
+		base.DeviceTypes = override.DeviceTypes
+	}
+	if len(override.DeviceMechanicalProperties) > 0 {
+		base.DeviceMechanicalProperties = override.DeviceMechanicalProperties
+	}
+	if override.MinSize != nil {
+		base.MinSize = override.MinSize
+	}
+	if override.MaxSize != nil {
+		base.MaxSize = override.MaxSize
+	}
+	if len(override.Models) > 0 {
+		base.Models = override.Models
+	}
+	if len(override.Vendors) > 0 {
+		base.Vendors = override.Vendors
+	}
+	if len(override.ExcludeBySerial) > 0 {
+		base.ExcludeBySerial = override.ExcludeBySerial
+	}
+	if len(override.ReserveBySerial) > 0 {
+		base.ReserveBySerial = override.ReserveBySerial
+	}
+	if len(override.PartLabels) > 0 {
+		base.PartLabels = override.PartLabels
+	}
+	if len(override.FSLabels) > 0 {
+		base.FSLabels = override.FSLabels
+	}
+	if len(override.Transports) > 0 {
+		base.Transports = override.Transports
+	}
+	if override.MinIOPS != nil {
+		base.MinIOPS = override.MinIOPS
+	}
+	if override.MinStableDuration != nil {
+		base.MinStableDuration = override.MinStableDuration
+	}
+}
@@ -0,0 +1,77 @@
+package lvset
+
+import (
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEffectiveDeviceInclusionSpec(t *testing.T) {
+	baseMin := resource.MustParse("10Gi")
+	rackMin := resource.MustParse("100Gi")
+	nodeMax := resource.MustParse("200Gi")
+	nodeMin := resource.MustParse("50Gi")
+
+	lvset := &localv1alpha1.LocalVolumeSet{
+		Spec: localv1alpha1.LocalVolumeSetSpec{
+			DeviceInclusionSpec: &localv1alpha1.DeviceInclusionSpec{
+				MinSize:     &baseMin,
+				DeviceTypes: []localv1alpha1.DeviceType{localv1alpha1.RawDisk},
+			},
+			NodeOverrides: []localv1alpha1.NodeOverride{
+				{
+					NodeSelector: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{
+							{MatchExpressions: []corev1.NodeSelectorRequirement{
+								{Key: "rack", Operator: corev1.NodeSelectorOpIn, Values: []string{"r1"}},
+							}},
+						},
+					},
+					DeviceInclusionSpec: localv1alpha1.DeviceInclusionSpec{MinSize: &rackMin},
+				},
+				{
+					NodeName:            "node-b",
+					DeviceInclusionSpec: localv1alpha1.DeviceInclusionSpec{MinSize: &nodeMin, MaxSize: &nodeMax},
+				},
+			},
+		},
+	}
+
+	testcases := []struct {
+		label       string
+		node        *corev1.Node
+		expectedMin resource.Quantity
+		expectedMax *resource.Quantity
+	}{
+		{
+			label:       "no matching override",
+			node:        &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}},
+			expectedMin: baseMin,
+		},
+		{
+			label:       "override by label",
+			node:        &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"rack": "r1"}}},
+			expectedMin: rackMin,
+		},
+		{
+			label:       "later override by name wins",
+			node:        &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{"rack": "r1"}}},
+			expectedMin: nodeMin,
+			expectedMax: &nodeMax,
+		},
+	}
+
+	for _, tc := range testcases {
+		spec, err := effectiveDeviceInclusionSpec(lvset, tc.node)
+		assert.NoErrorf(t, err, "[%s]", tc.label)
+		assert.Equalf(t, tc.expectedMin.String(), spec.MinSize.String(), "[%s] minSize", tc.label)
+		assert.Equalf(t, tc.expectedMax, spec.MaxSize, "[%s] maxSize", tc.label)
+		assert.Equalf(t, []localv1alpha1.DeviceType{localv1alpha1.RawDisk}, spec.DeviceTypes, "[%s] deviceTypes", tc.label)
+	}
+	// the base spec is not modified
+	assert.Equal(t, baseMin.String(), lvset.Spec.DeviceInclusionSpec.MinSize.String())
+}
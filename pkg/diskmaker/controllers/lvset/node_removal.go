@@ -0,0 +1,88 @@
+package lvset
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	staticProvisioner "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/common"
+)
+
+// releaseRemovedNode removes the symlinks and unbound PVs that the LocalVolumeSet provisioned on this node
+// before the node stopped matching its nodeSelector. Only the PVs with the owner labels of the LocalVolumeSet
+// and the symlinks they point to are released, symlinks of other objects in the same directories are left alone.
+// PVs that are bound are left in place, the operator reports them in the BoundVolumesOnRemovedNodes condition.
+func (r *ReconcileLocalVolumeSet) releaseRemovedNode(reqLogger logr.Logger, lvset *localv1alpha1.LocalVolumeSet) error {
+	if !lvset.ManagesPersistentVolumes() {
+		return nil
+	}
+	cm := &corev1.ConfigMap{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: common.ProvisionerConfigMapName, Namespace: lvset.Namespace}, cm)
+	if err != nil {
+		return fmt.Errorf("could not get provisioner configmap: %w", err)
+	}
+	provisionerConfig := staticProvisioner.ProvisionerConfiguration{}
+	staticProvisioner.ConfigMapDataToVolumeConfig(cm.Data, &provisionerConfig)
+
+	pvs := &corev1.PersistentVolumeList{}
+	err = r.client.List(context.TODO(), pvs, client.MatchingLabels{
+		common.PVOwnerKindLabel:      localv1alpha1.LocalVolumeSetKind,
+		common.PVOwnerNameLabel:      lvset.Name,
+		common.PVOwnerNamespaceLabel: lvset.Namespace,
+		corev1.LabelHostname:         r.runtimeConfig.Node.GetLabels()[corev1.LabelHostname],
+	})
+	if err != nil {
+		return fmt.Errorf("could not list the persistent volumes of the localvolumeset: %w", err)
+	}
+	for _, storageClassName := range lvset.StorageClassNames() {
+		symLinkConfig, ok := provisionerConfig.StorageClassConfig[storageClassName]
+		if !ok {
+			continue
+		}
+		err = r.releaseRemovedNodePVs(reqLogger, lvset, pvs.Items, storageClassName, symLinkConfig.HostDir)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseRemovedNodePVs releases the unbound PVs of one StorageClass of the LocalVolumeSet and their symlinks in symLinkDir
+func (r *ReconcileLocalVolumeSet) releaseRemovedNodePVs(reqLogger logr.Logger, lvset *localv1alpha1.LocalVolumeSet, pvs []corev1.PersistentVolume, storageClassName, symLinkDir string) error {
+	for i := range pvs {
+		pv := &pvs[i]
+		if pv.Spec.StorageClassName != storageClassName || pv.Spec.Local == nil || filepath.Dir(pv.Spec.Local.Path) != filepath.Clean(symLinkDir) {
+			continue
+		}
+		if pv.Spec.ClaimRef != nil || pv.Status.Phase != corev1.VolumeAvailable {
+			continue
+		}
+
+		// remove the symlink first, so that the PV isn't recreated for it
+		symlinkPath := pv.Spec.Local.Path
+		reqLogger.Info("releasing device of a node that no longer matches the nodeSelector", "symlink", symlinkPath, "pv", pv.Name)
+		info, err := os.Lstat(symlinkPath)
+		if err == nil && info.Mode()&os.ModeSymlink != 0 {
+			err = os.Remove(symlinkPath)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not remove symlink %q: %w", symlinkPath, err)
+		}
+		// the precondition fails if the PV was bound meanwhile
+		err = r.client.Delete(context.TODO(), pv, client.Preconditions{ResourceVersion: &pv.ResourceVersion})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("could not delete PV %q: %w", pv.Name, err)
+		}
+		r.eventReporter.Report(lvset, newDiskEvent(ReleasedRemovedNodeDevice,
+			"released device of a node that no longer matches the nodeSelector", filepath.Base(symlinkPath), corev1.EventTypeNormal))
+	}
+	return nil
+}
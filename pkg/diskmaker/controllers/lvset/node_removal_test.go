@@ -0,0 +1,88 @@
+package lvset
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	staticProvisioner "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/common"
+)
+
+func TestReleaseRemovedNode(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "node-removal")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	storageClassName := "removed-sc"
+	symLinkDir := filepath.Join(tmpDir, storageClassName)
+	assert.NoError(t, os.MkdirAll(symLinkDir, 0755))
+	for _, name := range []string{"available", "bound", "other-owner", "orphan"} {
+		assert.NoError(t, os.Symlink("/dev/null", filepath.Join(symLinkDir, name)))
+	}
+	// regular files are not symlinks created by the diskmaker
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(symLinkDir, "file"), nil, 0644))
+
+	data, err := staticProvisioner.VolumeConfigToConfigMapData(&staticProvisioner.ProvisionerConfiguration{
+		StorageClassConfig: map[string]staticProvisioner.MountConfig{storageClassName: {HostDir: symLinkDir}},
+	})
+	assert.NoError(t, err)
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: common.ProvisionerConfigMapName, Namespace: testNamespace},
+		Data:       data,
+	}
+	newPV := func(name, owner string, claimRef *corev1.ObjectReference, phase corev1.PersistentVolumePhase) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{
+				corev1.LabelHostname:         "node-a",
+				common.PVOwnerKindLabel:      localv1alpha1.LocalVolumeSetKind,
+				common.PVOwnerNameLabel:      owner,
+				common.PVOwnerNamespaceLabel: testNamespace,
+			}},
+			Spec: corev1.PersistentVolumeSpec{
+				StorageClassName:       storageClassName,
+				ClaimRef:               claimRef,
+				PersistentVolumeSource: corev1.PersistentVolumeSource{Local: &corev1.LocalVolumeSource{Path: filepath.Join(symLinkDir, name)}},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: phase},
+		}
+	}
+	availablePV := newPV("available", "removed", nil, corev1.VolumeAvailable)
+	boundPV := newPV("bound", "removed", &corev1.ObjectReference{Name: "claim"}, corev1.VolumeBound)
+	// another LocalVolumeSet with the same StorageClass that still selects the node
+	otherPV := newPV("other-owner", "other", nil, corev1.VolumeAvailable)
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "removed", Namespace: testNamespace},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: storageClassName, CleanupOnNodeRemoval: true},
+	}
+
+	r, tc := newFakeLocalVolumeSetReconciler(t, lvset, cm, availablePV, boundPV, otherPV)
+	tc.runtimeConfig.Node = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{corev1.LabelHostname: "node-a"}}}
+
+	err = r.releaseRemovedNode(logf.Log, lvset)
+	assert.NoError(t, err)
+
+	remaining, err := ioutil.ReadDir(symLinkDir)
+	assert.NoError(t, err)
+	names := []string{}
+	for _, entry := range remaining {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"bound", "other-owner", "orphan", "file"}, names, "only the symlink of the unbound PV of the LocalVolumeSet should be removed")
+
+	err = tc.fakeClient.Get(context.TODO(), types.NamespacedName{Name: availablePV.Name}, &corev1.PersistentVolume{})
+	assert.True(t, kerrors.IsNotFound(err), "the unbound PV should be deleted")
+	err = tc.fakeClient.Get(context.TODO(), types.NamespacedName{Name: boundPV.Name}, &corev1.PersistentVolume{})
+	assert.NoError(t, err, "the bound PV should be left in place")
+	err = tc.fakeClient.Get(context.TODO(), types.NamespacedName{Name: otherPV.Name}, &corev1.PersistentVolume{})
+	assert.NoError(t, err, "the PV of another LocalVolumeSet should be left in place")
+}
@@ -0,0 +1,151 @@
+package lvset
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// defaultQuarantineThreshold is the number of consecutive provisioning failures
+// after which a device is quarantined when the LocalVolumeSet doesn't set quarantineThreshold
+const defaultQuarantineThreshold int32 = 5
+
+type deviceFailure struct {
+	identity string
+	count    int32
+	message  string
+}
+
+// failureMap counts the consecutive provisioning failures of the devices of each LocalVolumeSet.
+// The count of a device is reset when it is provisioned, or when another device takes its place.
+type failureMap struct {
+	failures map[types.NamespacedName]map[string]*deviceFailure
+	mux      sync.Mutex
+}
+
+func newFailureMap() *failureMap {
+	return &failureMap{
+		failures: map[types.NamespacedName]map[string]*deviceFailure{},
+	}
+}
+
+// quarantineThreshold returns the effective quarantineThreshold of the LocalVolumeSet, 0 if quarantining is disabled
+func quarantineThreshold(lvset *localv1alpha1.LocalVolumeSet) int32 {
+	if lvset.Spec.QuarantineThreshold == nil {
+		return defaultQuarantineThreshold
+	}
+	return *lvset.Spec.QuarantineThreshold
+}
+
+// recordFailure counts a provisioning failure of the device and returns the number of consecutive failures
+func (f *failureMap) recordFailure(key types.NamespacedName, dev internal.BlockDevice, err error) int32 {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	devices, found := f.failures[key]
+	if !found {
+		devices = map[string]*deviceFailure{}
+		f.failures[key] = devices
+	}
+	failure, found := devices[dev.KName]
+	if !found || failure.identity != dev.StableIdentity() {
+		failure = &deviceFailure{identity: dev.StableIdentity()}
+		devices[dev.KName] = failure
+	}
+	failure.count++
+	failure.message = err.Error()
+	return failure.count
+}
+
+// recordSuccess resets the failure count of the device
+func (f *failureMap) recordSuccess(key types.NamespacedName, dev internal.BlockDevice) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	delete(f.failures[key], dev.KName)
+}
+
+// isQuarantined returns true if the device failed at least threshold times in a row, a threshold of 0 never quarantines
+func (f *failureMap) isQuarantined(key types.NamespacedName, dev internal.BlockDevice, threshold int32) bool {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	failure, found := f.failures[key][dev.KName]
+	return threshold > 0 && found && failure.identity == dev.StableIdentity() && failure.count >= threshold
+}
+
+// prune forgets the failures of devices that were removed or replaced by a device with another identity
+func (f *failureMap) prune(key types.NamespacedName, blockDevices []internal.BlockDevice) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	identities := map[string]string{}
+	for _, dev := range blockDevices {
+		identities[dev.KName] = dev.StableIdentity()
+	}
+	for kname, failure := range f.failures[key] {
+		identity, found := identities[kname]
+		if !found || identity != failure.identity {
+			delete(f.failures[key], kname)
+		}
+	}
+}
+
+// quarantined returns the quarantined devices of the LocalVolumeSet ordered by their path
+func (f *failureMap) quarantined(key types.NamespacedName, threshold int32, nodeName string) []localv1alpha1.QuarantinedDevice {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	devices := []localv1alpha1.QuarantinedDevice{}
+	if threshold <= 0 {
+		return devices
+	}
+	for kname, failure := range f.failures[key] {
+		if failure.count < threshold {
+			continue
+		}
+		devices = append(devices, localv1alpha1.QuarantinedDevice{
+			NodeName:     nodeName,
+			DevicePath:   "/dev/" + kname,
+			Serial:       failure.identity,
+			FailureCount: failure.count,
+			Message:      failure.message,
+		})
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].DevicePath < devices[j].DevicePath })
+	return devices
+}
+
+// syncQuarantinedDevices updates the entries of this node in status.quarantinedDevices
+func (r *ReconcileLocalVolumeSet) syncQuarantinedDevices(lvset *localv1alpha1.LocalVolumeSet) error {
+	key := types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}
+	nodeName := r.runtimeConfig.Node.Name
+	devices := r.deviceFailures.quarantined(key, quarantineThreshold(lvset), nodeName)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &localv1alpha1.LocalVolumeSet{}
+		err := r.client.Get(context.TODO(), key, current)
+		if err != nil {
+			return err
+		}
+		existing := []localv1alpha1.QuarantinedDevice{}
+		others := []localv1alpha1.QuarantinedDevice{}
+		for _, device := range current.Status.QuarantinedDevices {
+			if device.NodeName == nodeName {
+				existing = append(existing, device)
+			} else {
+				others = append(others, device)
+			}
+		}
+		if reflect.DeepEqual(existing, devices) {
+			return nil
+		}
+		current.Status.QuarantinedDevices = append(others, devices...)
+		if len(current.Status.QuarantinedDevices) == 0 {
+			current.Status.QuarantinedDevices = nil
+		}
+		return r.client.Status().Update(context.TODO(), current)
+	})
+}
@@ -0,0 +1,84 @@
+package lvset
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestFailureMapQuarantine(t *testing.T) {
+	key := types.NamespacedName{Name: "lvset", Namespace: testNamespace}
+	dev := internal.BlockDevice{KName: "sdb", Serial: "S1"}
+	failures := newFailureMap()
+
+	for i := int32(1); i < 3; i++ {
+		assert.Equal(t, i, failures.recordFailure(key, dev, fmt.Errorf("mkfs failed")))
+		assert.False(t, failures.isQuarantined(key, dev, 3), "quarantined before reaching the threshold")
+	}
+	assert.Equal(t, int32(3), failures.recordFailure(key, dev, fmt.Errorf("mkfs failed")))
+	assert.True(t, failures.isQuarantined(key, dev, 3), "quarantined after reaching the threshold")
+	assert.False(t, failures.isQuarantined(key, dev, 0), "a threshold of 0 disables quarantining")
+
+	quarantined := failures.quarantined(key, 3, "node-a")
+	if assert.Len(t, quarantined, 1) {
+		assert.Equal(t, localv1alpha1.QuarantinedDevice{
+			NodeName:     "node-a",
+			DevicePath:   "/dev/sdb",
+			Serial:       "S1",
+			FailureCount: 3,
+			Message:      "mkfs failed",
+		}, quarantined[0])
+	}
+
+	// another LocalVolumeSet is not affected
+	otherKey := types.NamespacedName{Name: "other", Namespace: testNamespace}
+	assert.False(t, failures.isQuarantined(otherKey, dev, 3))
+
+	// a replaced device isn't quarantined, and its failures are counted from scratch
+	replaced := internal.BlockDevice{KName: "sdb", Serial: "S2"}
+	assert.False(t, failures.isQuarantined(key, replaced, 3), "replaced device is quarantined")
+	failures.prune(key, []internal.BlockDevice{replaced})
+	assert.Empty(t, failures.quarantined(key, 3, "node-a"))
+	assert.Equal(t, int32(1), failures.recordFailure(key, replaced, fmt.Errorf("mkfs failed")))
+
+	// a successful provisioning resets the count
+	failures.recordSuccess(key, replaced)
+	assert.Equal(t, int32(1), failures.recordFailure(key, replaced, fmt.Errorf("mkfs failed")))
+}
+
+func TestSyncQuarantinedDevices(t *testing.T) {
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "sc"},
+		Status: localv1alpha1.LocalVolumeSetStatus{
+			QuarantinedDevices: []localv1alpha1.QuarantinedDevice{
+				{NodeName: "node-b", DevicePath: "/dev/sdc", FailureCount: 5},
+				{NodeName: "node-a", DevicePath: "/dev/sdd", FailureCount: 5},
+			},
+		},
+	}
+	r, tc := newFakeLocalVolumeSetReconciler(t, lvset)
+	r.runtimeConfig.Node.Name = "node-a"
+	key := types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}
+
+	dev := internal.BlockDevice{KName: "sdb", Serial: "S1"}
+	for i := int32(0); i < defaultQuarantineThreshold; i++ {
+		r.deviceFailures.recordFailure(key, dev, fmt.Errorf("mkfs failed"))
+	}
+	err := r.syncQuarantinedDevices(lvset)
+	assert.NoError(t, err)
+
+	updated := &localv1alpha1.LocalVolumeSet{}
+	err = tc.fakeClient.Get(context.TODO(), key, updated)
+	assert.NoError(t, err)
+	assert.Equal(t, []localv1alpha1.QuarantinedDevice{
+		{NodeName: "node-b", DevicePath: "/dev/sdc", FailureCount: 5},
+		{NodeName: "node-a", DevicePath: "/dev/sdb", Serial: "S1", FailureCount: defaultQuarantineThreshold, Message: "mkfs failed"},
+	}, updated.Status.QuarantinedDevices, "only the entries of this node are replaced")
+}
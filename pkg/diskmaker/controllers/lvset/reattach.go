@@ -0,0 +1,31 @@
+package lvset
+
+import (
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// filesystem used by kubelet to mount a local PV without fsType
+const defaultFSType = "ext4"
+
+// reattachFilesystems are the filesystems spec.reattachExisting provisions with their data in place
+var reattachFilesystems = sets.NewString("ext4", "xfs")
+
+// canReattach returns true if the device already has a filesystem that the LocalVolumeSet
+// allows to be provisioned as-is with spec.reattachExisting.
+// The filesystem must match the PV's fsType, otherwise kubelet would fail to mount it.
+func canReattach(lvset *localv1alpha1.LocalVolumeSet, dev internal.BlockDevice) bool {
+	if lvset == nil || !lvset.Spec.ReattachExisting || dev.FSType == "" {
+		return false
+	}
+	if lvset.Spec.VolumeMode != "" && lvset.Spec.VolumeMode != localv1.PersistentVolumeFilesystem {
+		return false
+	}
+	fsType := lvset.Spec.FSType
+	if fsType == "" {
+		fsType = defaultFSType
+	}
+	return reattachFilesystems.Has(dev.FSType) && dev.FSType == fsType
+}
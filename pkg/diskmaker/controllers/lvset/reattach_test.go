@@ -0,0 +1,67 @@
+package lvset
+
+import (
+	"testing"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanReattach(t *testing.T) {
+	testTable := []struct {
+		label    string
+		spec     localv1alpha1.LocalVolumeSetSpec
+		fsType   string
+		expected bool
+	}{
+		{
+			label:    "reattach disabled",
+			spec:     localv1alpha1.LocalVolumeSetSpec{},
+			fsType:   "ext4",
+			expected: false,
+		},
+		{
+			label:    "no filesystem",
+			spec:     localv1alpha1.LocalVolumeSetSpec{ReattachExisting: true},
+			fsType:   "",
+			expected: false,
+		},
+		{
+			label:    "ext4 with default fsType",
+			spec:     localv1alpha1.LocalVolumeSetSpec{ReattachExisting: true},
+			fsType:   "ext4",
+			expected: true,
+		},
+		{
+			label:    "xfs with matching fsType",
+			spec:     localv1alpha1.LocalVolumeSetSpec{ReattachExisting: true, FSType: "xfs"},
+			fsType:   "xfs",
+			expected: true,
+		},
+		{
+			label:    "xfs with default fsType",
+			spec:     localv1alpha1.LocalVolumeSetSpec{ReattachExisting: true},
+			fsType:   "xfs",
+			expected: false,
+		},
+		{
+			label:    "filesystem not in allowed set",
+			spec:     localv1alpha1.LocalVolumeSetSpec{ReattachExisting: true, FSType: "btrfs"},
+			fsType:   "btrfs",
+			expected: false,
+		},
+		{
+			label:    "block volumeMode",
+			spec:     localv1alpha1.LocalVolumeSetSpec{ReattachExisting: true, VolumeMode: localv1.PersistentVolumeBlock},
+			fsType:   "ext4",
+			expected: false,
+		},
+	}
+	for _, tc := range testTable {
+		lvset := &localv1alpha1.LocalVolumeSet{Spec: tc.spec}
+		dev := internal.BlockDevice{Name: "sdb", KName: "sdb", FSType: tc.fsType}
+		assert.Equalf(t, tc.expected, canReattach(lvset, dev), "[%s]", tc.label)
+	}
+}
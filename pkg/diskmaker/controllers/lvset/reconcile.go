@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
 	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
 	"github.com/openshift/local-storage-operator/pkg/common"
 	"github.com/openshift/local-storage-operator/pkg/diskmaker"
@@ -15,7 +17,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	staticProvisioner "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/common"
@@ -29,6 +33,8 @@ import (
 func (r *ReconcileLocalVolumeSet) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling LocalVolumeSet")
+	defer diskmaker.TrackReconcile()()
+	defer common.ObserveReconcileDuration(ComponentName)()
 
 	// Fetch the LocalVolumeSet instance
 	lvset := &localv1alpha1.LocalVolumeSet{}
@@ -43,9 +49,19 @@ func (r *ReconcileLocalVolumeSet) Reconcile(request reconcile.Request) (reconcil
 		return reconcile.Result{}, err
 	}
 
-	// don't provision for deleted lvsets
+	// don't provision for deleted lvsets, only remove the symlinks of the lvsets that leave their PVs to another tool
 	if !lvset.DeletionTimestamp.IsZero() {
-		return reconcile.Result{}, nil
+		err = r.removeSymlinks(reqLogger, lvset)
+		if err != nil {
+			reqLogger.Error(err, "failed to remove the symlinks of the deleted LocalVolumeSet")
+		}
+		return reconcile.Result{}, err
+	}
+
+	// don't provision for paused lvsets, annotation changes are not watched so check again later
+	if common.IsPaused(lvset) {
+		reqLogger.Info("reconciliation is paused", "annotation", common.PausedAnnotation)
+		return reconcile.Result{Requeue: true, RequeueAfter: r.options.ResyncPeriodOrDefault(time.Minute)}, nil
 	}
 
 	// get the node and determine if the localvolumeset selects this node
@@ -64,17 +80,52 @@ func (r *ReconcileLocalVolumeSet) Reconcile(request reconcile.Request) (reconcil
 	}
 
 	if !matches {
+		// release the devices this node provisioned before it left the selector
+		if lvset.Spec.CleanupOnNodeRemoval && !r.options.DiscoveryOnly {
+			err = r.releaseRemovedNode(reqLogger, lvset)
+			if err != nil {
+				reqLogger.Error(err, "failed to release the devices of the node")
+				return reconcile.Result{}, err
+			}
+		}
 		return reconcile.Result{}, nil
 	}
 
-	storageClassName := lvset.Spec.StorageClassName
+	// with maxNodeCount, only the nodes selected by the operator provision devices.
+	// status changes don't trigger a reconcile, check again for this node to be selected later.
+	if lvset.Spec.MaxNodeCount != nil && !sets.NewString(lvset.Status.SelectedNodes...).Has(r.nodeName) {
+		reqLogger.Info("node is not selected by maxNodeCount, not provisioning")
+		return reconcile.Result{Requeue: true, RequeueAfter: r.options.ResyncPeriodOrDefault(time.Minute)}, nil
+	}
 
-	// get associated storageclass
-	storageClass := &storagev1.StorageClass{}
-	err = r.client.Get(context.TODO(), types.NamespacedName{Name: storageClassName}, storageClass)
+	// wait for the host paths instead of failing every device, e.g. on nodes that mount /dev read-only
+	err = diskmaker.CheckHostPaths(r.options.DiscoveryOnly)
 	if err != nil {
-		reqLogger.Error(err, "could not get storageclass")
-		return reconcile.Result{}, err
+		reqLogger.Error(err, "host paths are unavailable, not provisioning", "retryAfter", diskmaker.HostPathRetryPeriod)
+		r.eventReporter.Report(lvset, newDiskEvent(diskmaker.HostPathUnavailable, err.Error(), "", corev1.EventTypeWarning))
+		return reconcile.Result{RequeueAfter: diskmaker.HostPathRetryPeriod}, nil
+	}
+
+	if !r.options.DiscoveryOnly {
+		err = r.syncSymlinkedNode(lvset)
+		if err != nil {
+			reqLogger.Error(err, "failed to record the node in status.symlinkedNodes")
+			return reconcile.Result{}, err
+		}
+	}
+
+	storageClassNames := lvset.StorageClassNames()
+
+	// get associated storageclasses, spec.storageClassName and those of spec.deviceRouting
+	storageClasses := map[string]storagev1.StorageClass{}
+	for _, storageClassName := range storageClassNames {
+		storageClass := &storagev1.StorageClass{}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: storageClassName}, storageClass)
+		if err != nil {
+			reqLogger.Error(err, "could not get storageclass", "storageClass.Name", storageClassName)
+			return reconcile.Result{}, err
+		}
+		storageClasses[storageClassName] = *storageClass
 	}
 
 	// get associated provisioner config
@@ -105,15 +156,20 @@ func (r *ReconcileLocalVolumeSet) Reconcile(request reconcile.Request) (reconcil
 	// unsupported
 	r.runtimeConfig.UseJobForCleaning = false
 
-	// get symlinkdir
-	symLinkConfig, ok := provisionerConfig.StorageClassConfig[storageClassName]
-	if !ok {
-		return reconcile.Result{}, fmt.Errorf("could not find storageclass entry %q in provisioner config: %+v", storageClassName, provisionerConfig)
+	// get the symlinkdir of each storageclass
+	symLinkDirs := map[string]string{}
+	allSymLinkDirs := []string{}
+	for _, storageClassName := range storageClassNames {
+		symLinkConfig, ok := provisionerConfig.StorageClassConfig[storageClassName]
+		if !ok {
+			return reconcile.Result{}, fmt.Errorf("could not find storageclass entry %q in provisioner config: %+v", storageClassName, provisionerConfig)
+		}
+		symLinkDirs[storageClassName] = symLinkConfig.HostDir
+		allSymLinkDirs = append(allSymLinkDirs, symLinkConfig.HostDir)
 	}
-	symLinkDir := symLinkConfig.HostDir
 
 	// list block devices
-	blockDevices, badRows, err := internal.ListBlockDevices()
+	blockDevices, badRows, err := diskmaker.Devices.ListBlockDevices()
 	if err != nil {
 		r.eventReporter.Report(lvset, newDiskEvent(diskmaker.ErrorRunningBlockList, "failed to list block devices", "", corev1.EventTypeWarning))
 		reqLogger.Error(err, "could not list block devices", "lsblk.BadRows", badRows)
@@ -122,16 +178,94 @@ func (r *ReconcileLocalVolumeSet) Reconcile(request reconcile.Request) (reconcil
 		r.eventReporter.Report(lvset, newDiskEvent(diskmaker.ErrorRunningBlockList, fmt.Sprintf("error parsing rows: %+v", badRows), "", corev1.EventTypeWarning))
 		reqLogger.Error(fmt.Errorf("bad rows"), "could not parse all the lsblk rows", "lsblk.BadRows", badRows)
 	}
+	diskmaker.MarkDiscoveryComplete()
+	r.deviceStability.observe(blockDevices)
+
+	// apply the nodeOverrides that select this node
+	inclusionSpec, err := effectiveDeviceInclusionSpec(lvset, r.runtimeConfig.Node)
+	if err != nil {
+		reqLogger.Error(err, "failed to match nodeOverrides to node labels")
+		return reconcile.Result{}, err
+	}
+
+	// release the devices that were excluded by serial after they were provisioned
+	if !r.options.DiscoveryOnly {
+		for _, storageClassName := range storageClassNames {
+			err = r.releaseExcludedDevices(reqLogger, lvset, inclusionSpec, blockDevices, storageClassName, symLinkDirs[storageClassName])
+			if err != nil {
+				reqLogger.Error(err, "failed to release excluded devices")
+				return reconcile.Result{}, err
+			}
+		}
+	}
+
+	// the devices of the host's operating system are excluded before the filters, so that no LocalVolumeSet can claim them
+	rootDeviceNames, err := rootDevices()
+	if err != nil {
+		r.eventReporter.Report(lvset, newDiskEvent(ErrorFindingRootDevices, fmt.Sprintf("failed to find the devices of the root filesystem: %v", err), "", corev1.EventTypeWarning))
+		reqLogger.Error(err, "could not find the devices of the root filesystem")
+		return reconcile.Result{}, err
+	}
 
 	// find disks that match lvset filters and matchers
-	validDevices, delayedDevices := r.getValidDevices(reqLogger, lvset, blockDevices)
+	validDevices, delayedDevices := r.getValidDevices(reqLogger, lvset, inclusionSpec, r.withoutRootDevices(reqLogger, lvset, blockDevices, sets.NewString(rootDeviceNames...)))
+
+	// order the devices so that maxDeviceCount picks them according to the selection strategy
+	sortDevicesBySelectionStrategy(validDevices, lvset.Spec.DeviceSelectionStrategy)
+
+	// forget the failures of devices that were removed or replaced
+	lvsetKey := types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}
+	threshold := quarantineThreshold(lvset)
+	r.deviceFailures.prune(lvsetKey, blockDevices)
+
+	mountPointMap, err := common.GenerateMountMap(r.runtimeConfig)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// the devices provisioned on this node count towards maxCapacityPerNode
+	usedCapacity, err := provisionedCapacity(allSymLinkDirs, blockDevices)
+	if err != nil && lvset.Spec.MaxCapacityPerNode != nil {
+		r.eventReporter.Report(lvset, newDiskEvent(ErrorListingExistingSymlinks, "error determining already provisioned capacity", "", corev1.EventTypeWarning))
+		return reconcile.Result{}, fmt.Errorf("could not determine the capacity that is already provisioned: %w", err)
+	}
+
+	// outside of the provisioningWindow, only the devices that are already symlinked are provisioned
+	now := time.Now()
+	windowOpen, windowOpens, err := common.ProvisioningWindowState(lvset.Spec.ProvisioningWindow, now)
+	if err != nil {
+		r.eventReporter.Report(lvset, newDiskEvent(common.ProvisioningDeferred, fmt.Sprintf("new devices are not provisioned: %v", err), "", corev1.EventTypeWarning))
+		reqLogger.Error(err, "could not determine if the provisioning window is open, not provisioning new devices")
+	}
 
-	// process valid devices
+	stableDuration := minStableDuration(inclusionSpec)
+	// how long until the next device that is not stable yet is, 0 if there is none
+	var untilStable time.Duration
+
+	// process valid devices, a device that fails doesn't keep the others from being provisioned
 	var noMatch []string
+	var provisionErrors []error
+	reservedDevices := []localv1alpha1.ReservedDevice{}
 	for _, blockDevice := range validDevices {
 		devLogger := reqLogger.WithValues("Device.Name", blockDevice.Name)
 
-		symlinkSourcePath, symlinkPath, idExists, err := common.GetSymLinkSourceAndTarget(blockDevice, symLinkDir)
+		if r.deviceFailures.isQuarantined(lvsetKey, blockDevice, threshold) {
+			devLogger.V(4).Info("device is quarantined, not provisioning")
+			continue
+		}
+
+		// route the device to the storageclass of its tag
+		storageClassName, err := routeDevice(lvset, blockDevice)
+		if err != nil {
+			devLogger.Error(err, "could not read the tags of the device")
+			continue
+		} else if storageClassName == "" {
+			devLogger.V(4).Info("device matches no route of deviceRouting, not provisioning", "partLabel", blockDevice.PartLabel)
+			continue
+		}
+		devLogger = devLogger.WithValues("storageClass.Name", storageClassName)
+
+		symlinkSourcePath, symlinkPath, idExists, err := r.symlinkSourceAndTarget(lvset, blockDevice, storageClassName, symLinkDirs[storageClassName])
 		if err != nil {
 			devLogger.Error(err, "error while discovering symlink source and target")
 			continue
@@ -144,7 +278,7 @@ func (r *ReconcileLocalVolumeSet) Reconcile(request reconcile.Request) (reconcil
 		// validate MaxDeviceCount
 		var alreadyProvisionedCount int
 		var currentDeviceSymlinked bool
-		alreadyProvisionedCount, currentDeviceSymlinked, noMatch, err = getAlreadySymlinked(symLinkDir, blockDevice, blockDevices)
+		alreadyProvisionedCount, currentDeviceSymlinked, noMatch, err = getAlreadySymlinked(allSymLinkDirs, blockDevice, blockDevices)
 		_ = currentDeviceSymlinked
 		if err != nil && lvset.Spec.MaxDeviceCount != nil {
 			r.eventReporter.Report(lvset, newDiskEvent(ErrorListingExistingSymlinks, "error determining already provisioned disks", "", corev1.EventTypeWarning))
@@ -154,50 +288,138 @@ func (r *ReconcileLocalVolumeSet) Reconcile(request reconcile.Request) (reconcil
 		if lvset.Spec.MaxDeviceCount != nil {
 			withinMax = int32(alreadyProvisionedCount) < *lvset.Spec.MaxDeviceCount
 		}
+		// reserved devices are only reported until their serial is removed from reserveBySerial
+		if !currentDeviceSymlinked && isReservedBySerial(blockDevice, inclusionSpec) {
+			devLogger.V(4).Info("device is reserved by serial, not provisioning", "serial", blockDevice.Serial)
+			r.eventReporter.Report(lvset, newDiskEvent(DeviceReserved, "device is reserved by serial and is not provisioned", blockDevice.KName, corev1.EventTypeNormal))
+			reservedDevices = append(reservedDevices, newReservedDevice(blockDevice, r.runtimeConfig.Node.Name))
+			continue
+		}
 		// skip this device if this device is not already symlinked and provisioning it would exceed the maxDeviceCount
 		if !(withinMax || currentDeviceSymlinked) {
-			break
+			continue
+		}
+		// skip this device if it is not already symlinked and it doesn't fit under the maxCapacityPerNode,
+		// the next devices can still fit
+		if !currentDeviceSymlinked && !fitsCapacity(lvset.Spec.MaxCapacityPerNode, usedCapacity, blockDevice) {
+			devLogger.Info("device exceeds maxCapacityPerNode, not provisioning", "size", blockDevice.Size)
+			continue
 		}
 
-		mountPointMap, err := common.GenerateMountMap(r.runtimeConfig)
-		if err != nil {
-			return reconcile.Result{}, err
+		if !currentDeviceSymlinked && !windowOpen {
+			devLogger.V(4).Info("provisioning window is closed, deferring the device", "opens", windowOpens)
+			r.eventReporter.Report(lvset, newDiskEvent(common.ProvisioningDeferred, "the provisioning window is closed, the device is provisioned once it opens", blockDevice.KName, corev1.EventTypeNormal))
+			continue
+		}
+
+		// wait for a new device to be seen stable for minStableDuration, e.g. through controller firmware updates
+		if unstableFor := r.deviceStability.unstableFor(blockDevice, stableDuration); !currentDeviceSymlinked && unstableFor > 0 {
+			devLogger.V(4).Info("device is not stable for minStableDuration yet, not provisioning", "remaining", unstableFor)
+			r.eventReporter.Report(lvset, newDiskEvent(DeviceNotStable,
+				fmt.Sprintf("device is provisioned once it is seen stable for %v", stableDuration), blockDevice.KName, corev1.EventTypeNormal))
+			if untilStable == 0 || unstableFor < untilStable {
+				untilStable = unstableFor
+			}
+			continue
+		}
+
+		if r.options.DiscoveryOnly {
+			devLogger.Info("discovery-only mode, not provisioning matching device", "symlink", symlinkPath)
+			r.eventReporter.Report(lvset, newDiskEvent(diskmaker.FoundMatchingDisk, "found matching disk, not provisioning it in discovery-only mode", blockDevice.KName, corev1.EventTypeNormal))
+			continue
 		}
 
+		// don't start symlinking a device while the diskmaker is terminating,
+		// an operation that was started is allowed to finish before the process exits
+		if !diskmaker.BeginDeviceOperation() {
+			devLogger.Info("diskmaker is shutting down, not provisioning")
+			return reconcile.Result{}, nil
+		}
 		devLogger.Info("provisioning PV")
 		r.eventReporter.Report(lvset, newDiskEvent(diskmaker.FoundMatchingDisk, "provisioning matching disk", blockDevice.KName, corev1.EventTypeNormal))
-		err = r.provisionPV(lvset, devLogger, blockDevice, *storageClass, mountPointMap, symlinkSourcePath, symlinkPath, idExists)
+		err = r.provisionPV(lvset, devLogger, blockDevice, storageClasses[storageClassName], mountPointMap, symlinkSourcePath, symlinkPath, idExists)
+		diskmaker.EndDeviceOperation()
 		if err != nil {
 			r.eventReporter.Report(lvset, newDiskEvent(diskmaker.ErrorProvisioningDisk, "provisioning failed", blockDevice.KName, corev1.EventTypeWarning))
-			return reconcile.Result{}, fmt.Errorf("could not provision disk: %w", err)
+			failureCount := r.deviceFailures.recordFailure(lvsetKey, blockDevice, err)
+			if threshold > 0 && failureCount >= threshold {
+				// stop retrying the device, the other devices are provisioned
+				devLogger.Error(err, "quarantining device after repeated provisioning failures", "failureCount", failureCount)
+				r.eventReporter.Report(lvset, newDiskEvent(DeviceQuarantined,
+					fmt.Sprintf("device failed provisioning %d times in a row and is quarantined until it is replaced: %v", failureCount, err),
+					blockDevice.KName, corev1.EventTypeWarning))
+				continue
+			}
+			devLogger.Error(err, "provisioning failed", "failureCount", failureCount)
+			provisionErrors = append(provisionErrors, fmt.Errorf("could not provision disk %q: %w", blockDevice.KName, err))
+			continue
+		}
+		r.deviceFailures.recordSuccess(lvsetKey, blockDevice)
+		if !currentDeviceSymlinked {
+			usedCapacity.Add(deviceSize(blockDevice))
 		}
 		devLogger.Info("provisioning succeeded")
 
 	}
+	err = r.syncQuarantinedDevices(lvset)
+	if err != nil {
+		reqLogger.Error(err, "failed to update quarantined devices")
+		return reconcile.Result{}, err
+	}
+	err = r.syncReservedDevices(lvset, reservedDevices)
+	if err != nil {
+		reqLogger.Error(err, "failed to update reserved devices")
+		return reconcile.Result{}, err
+	}
+	usedCapacity, err = provisionedCapacity(allSymLinkDirs, blockDevices)
+	if err != nil {
+		reqLogger.Error(err, "could not determine the provisioned capacity")
+	} else if err = r.syncNodeCapacity(lvset, usedCapacity); err != nil {
+		reqLogger.Error(err, "failed to update provisioned capacity")
+		return reconcile.Result{}, err
+	}
+	if len(provisionErrors) > 0 {
+		return reconcile.Result{}, utilerrors.NewAggregate(provisionErrors)
+	}
+
 	if len(noMatch) > 0 {
-		reqLogger.Info("found stale symLink Entries", "storageClass.Name", storageClassName, "paths.List", noMatch, "directory", symLinkDir)
+		reqLogger.Info("found stale symLink Entries", "storageClass.Names", storageClassNames, "paths.List", noMatch, "directories", allSymLinkDirs)
 	}
 
 	// shorten the requeueTime if there are delayed devices
-	requeueTime := time.Minute
+	requeueTime := r.options.ResyncPeriodOrDefault(time.Minute)
 	if len(delayedDevices) > 1 {
 		requeueTime = deviceMinAge / 2
 	}
+	// and to provision the deferred devices once the provisioning window opens
+	if !windowOpen && !windowOpens.IsZero() {
+		if untilOpen := common.ProvisioningWindowRequeueAfter(windowOpens, now); untilOpen < requeueTime {
+			requeueTime = untilOpen
+		}
+	}
+
+	// and to provision the devices once they are stable
+	if untilStable > 0 && untilStable < requeueTime {
+		requeueTime = untilStable
+	}
 
 	return reconcile.Result{Requeue: true, RequeueAfter: requeueTime}, nil
 }
 
-// runs filters and matchers on the blockDeviceList and returns valid devices
+// runs filters and matchers (using inclusionSpec) on the blockDeviceList and returns valid devices
 // and devices that are not considered old enough to be valid yet
 // i.e. if the device is younger than deviceMinAge
 // if the waitingDevices list is nonempty, the operator should requeueue
 func (r *ReconcileLocalVolumeSet) getValidDevices(
 	reqLogger logr.Logger,
 	lvset *localv1alpha1.LocalVolumeSet,
+	inclusionSpec *localv1alpha1.DeviceInclusionSpec,
 	blockDevices []internal.BlockDevice,
 ) ([]internal.BlockDevice, []internal.BlockDevice) {
 	validDevices := make([]internal.BlockDevice, 0)
 	delayedDevices := make([]internal.BlockDevice, 0)
+	// nil when minIOPS is not set or can't be checked on this node
+	deviceIOPS := r.minIOPSDeviceIOPS(reqLogger, lvset, inclusionSpec)
 	// get valid devices
 DeviceLoop:
 	for _, blockDevice := range blockDevices {
@@ -206,10 +428,18 @@ DeviceLoop:
 		r.deviceAgeMap.storeDeviceAge(blockDevice.KName)
 
 		devLogger := reqLogger.WithValues("Device.Name", blockDevice.Name)
+		reattach := canReattach(lvset, blockDevice)
 		for name, filter := range FilterMap {
 			var valid bool
 			var err error
 			filterLogger := devLogger.WithValues("filter.Name", name)
+			if name == noFilesystemSignature && reattach {
+				filterLogger.Info("reattaching existing filesystem", "fsType", blockDevice.FSType)
+				continue
+			}
+			if name == noHolders && lvset != nil && lvset.Spec.AllowDevicesWithHolders {
+				continue
+			}
 			valid, err = filter(blockDevice, nil)
 			if err != nil {
 				filterLogger.Error(err, "filter error")
@@ -217,6 +447,9 @@ DeviceLoop:
 				continue DeviceLoop
 			} else if !valid {
 				filterLogger.Info("filter negative")
+				if name == noHolders && lvset != nil {
+					r.reportDeviceInUse(lvset, blockDevice)
+				}
 				continue DeviceLoop
 			}
 		}
@@ -243,7 +476,7 @@ DeviceLoop:
 
 		for name, matcher := range matcherMap {
 			matcherLogger := devLogger.WithValues("matcher.Name", name)
-			valid, err := matcher(blockDevice, lvset.Spec.DeviceInclusionSpec)
+			valid, err := matcher(blockDevice, inclusionSpec)
 			if err != nil {
 				matcherLogger.Error(err, "match error")
 				valid = false
@@ -253,6 +486,18 @@ DeviceLoop:
 				continue DeviceLoop
 			}
 		}
+		if deviceIOPS != nil && !hasMinIOPS(blockDevice, *inclusionSpec.MinIOPS, deviceIOPS) {
+			devLogger.Info("device is below minIOPS", "serial", blockDevice.Serial, "minIOPS", *inclusionSpec.MinIOPS)
+			continue DeviceLoop
+		}
+		// the minSize of the CR may be lower than what mkfs needs
+		if minSize := r.options.MinFilesystemDeviceSize; lvset != nil && tooSmallForFilesystem(lvset, blockDevice, minSize) {
+			devLogger.Info("device is too small for a filesystem", "size", blockDevice.Size, "minimum", minSize.String())
+			r.eventReporter.Report(lvset, newDiskEvent(DeviceTooSmallForFilesystem,
+				fmt.Sprintf("skipping device of %s bytes, volumeMode Filesystem needs at least %s", blockDevice.Size, minSize.String()),
+				blockDevice.KName, corev1.EventTypeWarning))
+			continue DeviceLoop
+		}
 		devLogger.Info("matched disk")
 		// handle valid disk
 		validDevices = append(validDevices, blockDevice)
@@ -261,16 +506,37 @@ DeviceLoop:
 	return validDevices, delayedDevices
 }
 
+// reportDeviceInUse records a DeviceInUse event listing the devices that hold the skipped device
+func (r *ReconcileLocalVolumeSet) reportDeviceInUse(lvset *localv1alpha1.LocalVolumeSet, dev internal.BlockDevice) {
+	holders, err := dev.GetHolders()
+	if err != nil {
+		return
+	}
+	r.eventReporter.Report(lvset, newDiskEvent(DeviceInUse,
+		fmt.Sprintf("skipping device held by %s, set allowDevicesWithHolders to provision it", strings.Join(holders, ", ")),
+		dev.KName, corev1.EventTypeWarning))
+}
+
+// tooSmallForFilesystem returns true if the device is below minSize, the size mkfs needs, and the LocalVolumeSet formats
+// its devices. Devices of any size can be provisioned with volumeMode Block.
+func tooSmallForFilesystem(lvset *localv1alpha1.LocalVolumeSet, dev internal.BlockDevice, minSize resource.Quantity) bool {
+	if lvset.Spec.VolumeMode == localv1.PersistentVolumeBlock {
+		return false
+	}
+	size := deviceSize(dev)
+	return size.Cmp(minSize) < 0
+}
+
 // returns:
 // count of already symlinked from validDevices
 // if the currentDevice is alreadysymlinks
 // list of symlinks that don't match validDevices
 // err
-func getAlreadySymlinked(symLinkDir string, currentDevice internal.BlockDevice, validDevices []internal.BlockDevice) (int, bool, []string, error) {
+func getAlreadySymlinked(symLinkDirs []string, currentDevice internal.BlockDevice, validDevices []internal.BlockDevice) (int, bool, []string, error) {
 	count := 0
 	noMatch := make([]string, 0)
 	currentDeviceSymlinked := false
-	paths, err := filepath.Glob(filepath.Join(symLinkDir, "/*"))
+	paths, err := symlinkPaths(symLinkDirs)
 	if err != nil {
 		return 0, currentDeviceSymlinked, []string{}, err
 	}
@@ -311,6 +577,11 @@ func (r *ReconcileLocalVolumeSet) provisionPV(
 	if err != nil {
 		return err
 	}
+	deviceByID, deviceByPath := common.DeviceLinks(dev, symlinkSourcePath, idExists, devLogger)
+	deviceAlias := ""
+	if idExists {
+		deviceAlias = symlinkSourcePath
+	}
 
 	symLinkDir := filepath.Dir(symlinkPath)
 
@@ -334,20 +605,35 @@ func (r *ReconcileLocalVolumeSet) provisionPV(
 	defer unlockFunc()
 	if len(existingSymlinks) > 0 { // already claimed
 		for _, path := range existingSymlinks {
-			if path == symlinkPath { // symlinked in this folder, ensure the PV exists
-				return common.CreateLocalPV(
-					obj,
-					r.runtimeConfig,
-					r.cleanupTracker,
-					devLogger,
-					storageClass,
-					mountPointMap,
-					r.client,
-					symlinkPath,
-					dev.KName,
-					idExists,
-					map[string]string{},
-				)
+			// symlinked in this folder, ensure the PV exists.
+			// the symlink is kept if it was named after another link of the device, e.g. before symlinkTargetPreference changed
+			if filepath.Dir(path) == symLinkDir {
+				symlinkPath = path
+				return common.CreateLocalPV(common.CreateLocalPVArgs{
+					LocalVolumeLikeObject: obj,
+					RuntimeConfig:         r.runtimeConfig,
+					CleanupTracker:        r.cleanupTracker,
+					NodeAffinityKey:       r.options.PVNodeAffinityKey,
+					BackupLabels:          r.options.PVBackupLabels,
+					BackupAnnotations:     r.options.PVBackupAnnotations,
+					StorageClass:          storageClass,
+					MountPointMap:         mountPointMap,
+					Client:                r.client,
+					SymLinkPath:           symlinkPath,
+					DeviceName:            dev.KName,
+					DeviceIdentity:        dev.StableIdentity(),
+					DeviceByID:            deviceByID,
+					DeviceByPath:          deviceByPath,
+					DeviceAlias:           deviceAlias,
+					IDExists:              idExists,
+					ExtraLabelsForPV:      map[string]string{},
+					PVNamePrefix:          obj.Spec.PVNamePrefix,
+					PVLabels:              obj.Spec.PVLabels,
+					PVAnnotations:         obj.Spec.PVAnnotations,
+					RecreationLimiter:     diskmaker.PVRecreations,
+					RoundCapacity:         capacityRoundingFunc(obj.Spec.CapacityRounding),
+					UnmanagedPV:           !obj.ManagesPersistentVolumes(),
+				}, devLogger)
 			}
 		}
 		return nil
@@ -356,6 +642,12 @@ func (r *ReconcileLocalVolumeSet) provisionPV(
 		return err
 	}
 
+	// test the device before it is symlinked, while no other LocalVolumeSet can claim it
+	err = r.runDeviceWriteProbe(obj, devLogger, dev, devLabelPath)
+	if err != nil {
+		return err
+	}
+
 	devLogger.Info("symlinking", "sourcePath", symlinkSourcePath, "targetPath", symlinkPath)
 	// create symlink
 	err = os.Symlink(symlinkSourcePath, symlinkPath)
@@ -372,35 +664,59 @@ func (r *ReconcileLocalVolumeSet) provisionPV(
 				// existing file evals to disk
 			} else if valid {
 				// if file exists and is accurate symlink, create pv
-				return common.CreateLocalPV(
-					obj,
-					r.runtimeConfig,
-					r.cleanupTracker,
-					devLogger,
-					storageClass,
-					mountPointMap,
-					r.client,
-					symlinkPath,
-					dev.KName,
-					idExists,
-					map[string]string{},
-				)
+				return common.CreateLocalPV(common.CreateLocalPVArgs{
+					LocalVolumeLikeObject: obj,
+					RuntimeConfig:         r.runtimeConfig,
+					CleanupTracker:        r.cleanupTracker,
+					NodeAffinityKey:       r.options.PVNodeAffinityKey,
+					BackupLabels:          r.options.PVBackupLabels,
+					BackupAnnotations:     r.options.PVBackupAnnotations,
+					StorageClass:          storageClass,
+					MountPointMap:         mountPointMap,
+					Client:                r.client,
+					SymLinkPath:           symlinkPath,
+					DeviceName:            dev.KName,
+					DeviceIdentity:        dev.StableIdentity(),
+					DeviceByID:            deviceByID,
+					DeviceByPath:          deviceByPath,
+					DeviceAlias:           deviceAlias,
+					IDExists:              idExists,
+					ExtraLabelsForPV:      map[string]string{},
+					PVNamePrefix:          obj.Spec.PVNamePrefix,
+					PVLabels:              obj.Spec.PVLabels,
+					PVAnnotations:         obj.Spec.PVAnnotations,
+					RecreationLimiter:     diskmaker.PVRecreations,
+					RoundCapacity:         capacityRoundingFunc(obj.Spec.CapacityRounding),
+					UnmanagedPV:           !obj.ManagesPersistentVolumes(),
+				}, devLogger)
 			}
 		}
 	} else if err != nil {
 		return err
 	}
-	return common.CreateLocalPV(
-		obj,
-		r.runtimeConfig,
-		r.cleanupTracker,
-		devLogger,
-		storageClass,
-		mountPointMap,
-		r.client,
-		symlinkPath,
-		dev.KName,
-		idExists,
-		map[string]string{},
-	)
+	return common.CreateLocalPV(common.CreateLocalPVArgs{
+		LocalVolumeLikeObject: obj,
+		RuntimeConfig:         r.runtimeConfig,
+		CleanupTracker:        r.cleanupTracker,
+		NodeAffinityKey:       r.options.PVNodeAffinityKey,
+		BackupLabels:          r.options.PVBackupLabels,
+		BackupAnnotations:     r.options.PVBackupAnnotations,
+		StorageClass:          storageClass,
+		MountPointMap:         mountPointMap,
+		Client:                r.client,
+		SymLinkPath:           symlinkPath,
+		DeviceName:            dev.KName,
+		DeviceIdentity:        dev.StableIdentity(),
+		DeviceByID:            deviceByID,
+		DeviceByPath:          deviceByPath,
+		DeviceAlias:           deviceAlias,
+		IDExists:              idExists,
+		ExtraLabelsForPV:      map[string]string{},
+		PVNamePrefix:          obj.Spec.PVNamePrefix,
+		PVLabels:              obj.Spec.PVLabels,
+		PVAnnotations:         obj.Spec.PVAnnotations,
+		RecreationLimiter:     diskmaker.PVRecreations,
+		RoundCapacity:         capacityRoundingFunc(obj.Spec.CapacityRounding),
+		UnmanagedPV:           !obj.ManagesPersistentVolumes(),
+	}, devLogger)
 }
@@ -6,6 +6,8 @@ import (
 
 	"github.com/openshift/client-go/security/clientset/versioned/scheme"
 	"github.com/openshift/local-storage-operator/pkg/apis"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
@@ -13,12 +15,15 @@ import (
 	v1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/util/mount"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	crFake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	provCache "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/cache"
 	provCommon "sigs.k8s.io/sig-storage-local-static-provisioner/pkg/common"
 	"sigs.k8s.io/sig-storage-local-static-provisioner/pkg/deleter"
@@ -91,13 +96,16 @@ func newFakeLocalVolumeSetReconciler(t *testing.T, objs ...runtime.Object) (*Rec
 
 	cleanupTracker := &provDeleter.CleanupStatusTracker{ProcTable: provDeleter.NewProcTable()}
 	return &ReconcileLocalVolumeSet{
-		client:         fakeClient,
-		scheme:         scheme,
-		eventReporter:  newEventReporter(fakeRecorder),
-		deviceAgeMap:   newAgeMap(fakeClock),
-		cleanupTracker: &provDeleter.CleanupStatusTracker{ProcTable: deleter.NewProcTable()},
-		runtimeConfig:  runtimeConfig,
-		deleter:        provDeleter.NewDeleter(runtimeConfig, cleanupTracker),
+		client:          fakeClient,
+		scheme:          scheme,
+		eventReporter:   newEventReporter(fakeRecorder),
+		deviceAgeMap:    newAgeMap(fakeClock),
+		deviceFailures:  newFailureMap(),
+		deviceStability: newStabilityMap(fakeClock),
+		cleanupTracker:  &provDeleter.CleanupStatusTracker{ProcTable: deleter.NewProcTable()},
+		runtimeConfig:   runtimeConfig,
+		deleter:         provDeleter.NewDeleter(runtimeConfig, cleanupTracker),
+		options:         common.DefaultOptions(),
 	}, tc
 }
 
@@ -140,3 +148,54 @@ func (a apiUtil) DeleteJob(jobName string, namespace string) error {
 	err = a.client.Delete(context.TODO(), job)
 	return err
 }
+
+func TestReconcilePausedLocalVolumeSet(t *testing.T) {
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "paused",
+			Namespace:   testNamespace,
+			Annotations: map[string]string{common.PausedAnnotation: "true"},
+		},
+		Spec: localv1alpha1.LocalVolumeSetSpec{StorageClassName: "paused-sc"},
+	}
+	r, _ := newFakeLocalVolumeSetReconciler(t, lvset)
+
+	// the node and storageclass don't exist, so reconcile fails unless it skips the paused lvset
+	result, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}})
+	assert.NoError(t, err)
+	assert.True(t, result.Requeue, "paused lvsets should be checked again later")
+}
+
+func TestDeviceEventsEnqueueAllLocalVolumeSets(t *testing.T) {
+	lvsets := []runtime.Object{
+		&localv1alpha1.LocalVolumeSet{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: testNamespace}},
+		&localv1alpha1.LocalVolumeSet{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: testNamespace}},
+	}
+	r, _ := newFakeLocalVolumeSetReconciler(t, lvsets...)
+
+	requests := allLocalVolumeSets(r.client)(handler.MapObject{})
+	assert.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "a", Namespace: testNamespace}},
+		{NamespacedName: types.NamespacedName{Name: "b", Namespace: testNamespace}},
+	}, requests)
+}
+
+func TestReconcileUnselectedNode(t *testing.T) {
+	maxNodeCount := int32(1)
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "limited", Namespace: testNamespace},
+		Spec: localv1alpha1.LocalVolumeSetSpec{
+			StorageClassName: "limited-sc",
+			MaxNodeCount:     &maxNodeCount,
+		},
+		Status: localv1alpha1.LocalVolumeSetStatus{SelectedNodes: []string{"node-b"}},
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}}
+	r, _ := newFakeLocalVolumeSetReconciler(t, lvset, node)
+	r.nodeName = node.Name
+
+	// the storageclass doesn't exist, so reconcile fails unless it skips the unselected node
+	result, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}})
+	assert.NoError(t, err)
+	assert.True(t, result.Requeue, "the node should be checked again for being selected later")
+}
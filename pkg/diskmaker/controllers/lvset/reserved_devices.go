@@ -0,0 +1,67 @@
+package lvset
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// isReservedBySerial returns true if the device's serial is listed in spec.reserveBySerial
+func isReservedBySerial(dev internal.BlockDevice, spec *localv1alpha1.DeviceInclusionSpec) bool {
+	if spec == nil || dev.Serial == "" {
+		return false
+	}
+	for _, serial := range spec.ReserveBySerial {
+		if serial == dev.Serial {
+			return true
+		}
+	}
+	return false
+}
+
+// newReservedDevice returns the status.reservedDevices entry of the device
+func newReservedDevice(dev internal.BlockDevice, nodeName string) localv1alpha1.ReservedDevice {
+	return localv1alpha1.ReservedDevice{
+		NodeName:   nodeName,
+		DevicePath: "/dev/" + dev.KName,
+		Serial:     dev.Serial,
+		Size:       dev.Size,
+	}
+}
+
+// syncReservedDevices replaces the entries of this node in status.reservedDevices with devices
+func (r *ReconcileLocalVolumeSet) syncReservedDevices(lvset *localv1alpha1.LocalVolumeSet, devices []localv1alpha1.ReservedDevice) error {
+	key := types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}
+	nodeName := r.runtimeConfig.Node.Name
+	sort.Slice(devices, func(i, j int) bool { return devices[i].DevicePath < devices[j].DevicePath })
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &localv1alpha1.LocalVolumeSet{}
+		err := r.client.Get(context.TODO(), key, current)
+		if err != nil {
+			return err
+		}
+		existing := []localv1alpha1.ReservedDevice{}
+		others := []localv1alpha1.ReservedDevice{}
+		for _, device := range current.Status.ReservedDevices {
+			if device.NodeName == nodeName {
+				existing = append(existing, device)
+			} else {
+				others = append(others, device)
+			}
+		}
+		if reflect.DeepEqual(existing, devices) {
+			return nil
+		}
+		current.Status.ReservedDevices = append(others, devices...)
+		if len(current.Status.ReservedDevices) == 0 {
+			current.Status.ReservedDevices = nil
+		}
+		return r.client.Status().Update(context.TODO(), current)
+	})
+}
@@ -0,0 +1,61 @@
+package lvset
+
+import (
+	"context"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestIsReservedBySerial(t *testing.T) {
+	spec := &localv1alpha1.DeviceInclusionSpec{ReserveBySerial: []string{"S1", ""}}
+	assert.True(t, isReservedBySerial(internal.BlockDevice{KName: "sdb", Serial: "S1"}, spec))
+	assert.False(t, isReservedBySerial(internal.BlockDevice{KName: "sdc", Serial: "S2"}, spec))
+	assert.False(t, isReservedBySerial(internal.BlockDevice{KName: "sdd"}, spec), "devices without a serial are never reserved")
+	assert.False(t, isReservedBySerial(internal.BlockDevice{KName: "sdb", Serial: "S1"}, nil))
+}
+
+func TestSyncReservedDevices(t *testing.T) {
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec:       localv1alpha1.LocalVolumeSetSpec{StorageClassName: "sc"},
+		Status: localv1alpha1.LocalVolumeSetStatus{
+			ReservedDevices: []localv1alpha1.ReservedDevice{
+				{NodeName: "node-b", DevicePath: "/dev/sdc", Serial: "S3"},
+				{NodeName: "node-a", DevicePath: "/dev/sdd", Serial: "S4"},
+			},
+		},
+	}
+	r, tc := newFakeLocalVolumeSetReconciler(t, lvset)
+	r.runtimeConfig.Node.Name = "node-a"
+	key := types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}
+
+	devices := []localv1alpha1.ReservedDevice{
+		newReservedDevice(internal.BlockDevice{KName: "sdf", Serial: "S6", Size: "1073741824"}, "node-a"),
+		newReservedDevice(internal.BlockDevice{KName: "sde", Serial: "S5", Size: "1073741824"}, "node-a"),
+	}
+	err := r.syncReservedDevices(lvset, devices)
+	assert.NoError(t, err)
+	updated := &localv1alpha1.LocalVolumeSet{}
+	err = tc.fakeClient.Get(context.TODO(), key, updated)
+	assert.NoError(t, err)
+	assert.Equal(t, []localv1alpha1.ReservedDevice{
+		{NodeName: "node-b", DevicePath: "/dev/sdc", Serial: "S3"},
+		{NodeName: "node-a", DevicePath: "/dev/sde", Serial: "S5", Size: "1073741824"},
+		{NodeName: "node-a", DevicePath: "/dev/sdf", Serial: "S6", Size: "1073741824"},
+	}, updated.Status.ReservedDevices, "only the entries of this node are replaced")
+
+	// the devices of this node are removed once their serials are no longer reserved
+	err = r.syncReservedDevices(lvset, []localv1alpha1.ReservedDevice{})
+	assert.NoError(t, err)
+	updated = &localv1alpha1.LocalVolumeSet{}
+	err = tc.fakeClient.Get(context.TODO(), key, updated)
+	assert.NoError(t, err)
+	assert.Equal(t, []localv1alpha1.ReservedDevice{
+		{NodeName: "node-b", DevicePath: "/dev/sdc", Serial: "S3"},
+	}, updated.Status.ReservedDevices)
+}
@@ -0,0 +1,33 @@
+package lvset
+
+import (
+	"github.com/go-logr/logr"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// rootDevices returns the devices of the root and boot filesystems of the host
+var rootDevices = internal.RootDevices
+
+// withoutRootDevices returns the block devices that neither back the root or boot filesystem of the host
+// nor are partitions of a disk that does. These are never provisioned, whatever the filters of the LocalVolumeSet.
+func (r *ReconcileLocalVolumeSet) withoutRootDevices(
+	reqLogger logr.Logger,
+	lvset *localv1alpha1.LocalVolumeSet,
+	blockDevices []internal.BlockDevice,
+	rootDeviceNames sets.String,
+) []internal.BlockDevice {
+	devices := make([]internal.BlockDevice, 0, len(blockDevices))
+	for _, blockDevice := range blockDevices {
+		if rootDeviceNames.Has(blockDevice.KName) || (blockDevice.PKName != "" && rootDeviceNames.Has(blockDevice.PKName)) {
+			reqLogger.Info("skipping device of the host's root or boot filesystem", "Device.Name", blockDevice.Name)
+			r.eventReporter.Report(lvset, newDiskEvent(RootDeviceExcluded,
+				"device backs the root or boot filesystem of the node and is never provisioned", blockDevice.KName, corev1.EventTypeNormal))
+			continue
+		}
+		devices = append(devices, blockDevice)
+	}
+	return devices
+}
@@ -0,0 +1,37 @@
+package lvset
+
+import (
+	"strings"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestWithoutRootDevices(t *testing.T) {
+	lvset := &localv1alpha1.LocalVolumeSet{ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace}}
+	r, tc := newFakeLocalVolumeSetReconciler(t, lvset)
+	blockDevices := []internal.BlockDevice{
+		{Name: "sda", KName: "sda", Type: "disk"},
+		{Name: "sda1", KName: "sda1", Type: "part", PKName: "sda"},
+		{Name: "sda3", KName: "sda3", Type: "part", PKName: "sda"},
+		{Name: "rhel-root", KName: "dm-0", Type: "lvm", PKName: "sda2"},
+		{Name: "sdb", KName: "sdb", Type: "disk"},
+		{Name: "sdc1", KName: "sdc1", Type: "part", PKName: "sdc"},
+	}
+
+	devices := r.withoutRootDevices(log, lvset, blockDevices, sets.NewString("dm-0", "sda", "sda1", "sda2"))
+	assert.Equal(t, []internal.BlockDevice{blockDevices[4], blockDevices[5]}, devices,
+		"the root disk and all its partitions are excluded")
+	assert.Len(t, tc.eventStream, 4)
+	for len(tc.eventStream) > 0 {
+		event := <-tc.eventStream
+		assert.True(t, strings.HasPrefix(event, "Normal "+RootDeviceExcluded), event)
+	}
+
+	devices = r.withoutRootDevices(log, lvset, blockDevices, sets.NewString())
+	assert.Equal(t, blockDevices, devices, "no device is excluded when the root is not backed by a device")
+}
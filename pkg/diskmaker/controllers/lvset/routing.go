@@ -0,0 +1,51 @@
+package lvset
+
+import (
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// the host operations of deviceRouting, overridden in tests
+var (
+	deviceNumber = internal.DeviceNumber
+	udevTags     = internal.UdevTags
+)
+
+// routeDevice returns the StorageClass the device is provisioned in: the StorageClass of the first route of
+// spec.deviceRouting whose tag the device carries, else spec.storageClassName.
+// It returns "" if the device carries no routed tag and unroutedDevicePolicy is Reject.
+// The udev tags are only read if a route uses them.
+func routeDevice(lvset *localv1alpha1.LocalVolumeSet, dev internal.BlockDevice) (string, error) {
+	var tags sets.String
+	for _, route := range lvset.Spec.DeviceRouting {
+		if route.PartLabel != "" && route.PartLabel == dev.PartLabel {
+			return route.StorageClassName, nil
+		}
+		if route.UdevTag == "" {
+			continue
+		}
+		if tags == nil {
+			devPath, err := dev.GetDevPath()
+			if err != nil {
+				return "", err
+			}
+			number, err := deviceNumber(devPath)
+			if err != nil {
+				return "", err
+			}
+			deviceTags, err := udevTags(number)
+			if err != nil {
+				return "", err
+			}
+			tags = sets.NewString(deviceTags...)
+		}
+		if tags.Has(route.UdevTag) {
+			return route.StorageClassName, nil
+		}
+	}
+	if len(lvset.Spec.DeviceRouting) > 0 && lvset.Spec.UnroutedDevicePolicy == localv1alpha1.UnroutedDevicesReject {
+		return "", nil
+	}
+	return lvset.Spec.StorageClassName, nil
+}
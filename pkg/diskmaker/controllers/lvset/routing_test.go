@@ -0,0 +1,71 @@
+package lvset
+
+import (
+	"fmt"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteDevice(t *testing.T) {
+	tags := map[string][]string{
+		"/dev/sdb": {"systemd", "archive"},
+		"/dev/sdc": {"systemd"},
+	}
+	readTags := 0
+	deviceNumber = func(device string) (string, error) { return device, nil }
+	udevTags = func(device string) ([]string, error) {
+		readTags++
+		if device == "/dev/sdz" {
+			return nil, fmt.Errorf("failed to read the udev database")
+		}
+		return tags[device], nil
+	}
+	defer func() {
+		deviceNumber = internal.DeviceNumber
+		udevTags = internal.UdevTags
+	}()
+
+	lvset := &localv1alpha1.LocalVolumeSet{Spec: localv1alpha1.LocalVolumeSetSpec{StorageClassName: "local"}}
+	storageClassName, err := routeDevice(lvset, internal.BlockDevice{KName: "sdb"})
+	assert.NoError(t, err)
+	assert.Equal(t, "local", storageClassName, "every device goes to storageClassName without routes")
+	assert.Equal(t, 0, readTags)
+
+	lvset.Spec.DeviceRouting = []localv1alpha1.DeviceRoute{
+		{PartLabel: "fast", StorageClassName: "local-fast"},
+		{UdevTag: "archive", StorageClassName: "local-archive"},
+		{PartLabel: "archive", StorageClassName: "local-archive-partitions"},
+	}
+	testCases := []struct {
+		label            string
+		device           internal.BlockDevice
+		storageClassName string
+	}{
+		{label: "partition label", device: internal.BlockDevice{KName: "sdc1", PartLabel: "fast"}, storageClassName: "local-fast"},
+		{label: "udev tag", device: internal.BlockDevice{KName: "sdb"}, storageClassName: "local-archive"},
+		{label: "the first matching route wins", device: internal.BlockDevice{KName: "sdb", PartLabel: "archive"}, storageClassName: "local-archive"},
+		{label: "unrouted device", device: internal.BlockDevice{KName: "sdc", PartLabel: "slow"}, storageClassName: "local"},
+	}
+	for _, tc := range testCases {
+		storageClassName, err := routeDevice(lvset, tc.device)
+		assert.NoErrorf(t, err, "[%s]", tc.label)
+		assert.Equalf(t, tc.storageClassName, storageClassName, "[%s]", tc.label)
+	}
+
+	readTags = 0
+	storageClassName, err = routeDevice(lvset, internal.BlockDevice{KName: "sdc1", PartLabel: "fast"})
+	assert.NoError(t, err)
+	assert.Equal(t, "local-fast", storageClassName)
+	assert.Equal(t, 0, readTags, "the udev tags are only read when a udevTag route is reached")
+
+	lvset.Spec.UnroutedDevicePolicy = localv1alpha1.UnroutedDevicesReject
+	storageClassName, err = routeDevice(lvset, internal.BlockDevice{KName: "sdc"})
+	assert.NoError(t, err)
+	assert.Empty(t, storageClassName, "unrouted devices are rejected")
+
+	_, err = routeDevice(lvset, internal.BlockDevice{KName: "sdz"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package lvset
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/go-logr/logr"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// syncSymlinkedNode records the node in status.symlinkedNodes before the devices of a LocalVolumeSet that leaves its PVs
+// to another tool are symlinked, so that the operator keeps the deleted LocalVolumeSet until the symlinks are removed.
+// The node is removed from the list once the LocalVolumeSet manages its PVs.
+func (r *ReconcileLocalVolumeSet) syncSymlinkedNode(lvset *localv1alpha1.LocalVolumeSet) error {
+	if _, changed := common.SyncSymlinkedNode(lvset.Status.SymlinkedNodes, r.nodeName, lvset.ManagesPersistentVolumes()); !changed {
+		return nil
+	}
+	key := types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &localv1alpha1.LocalVolumeSet{}
+		err := r.client.Get(context.TODO(), key, current)
+		if err != nil {
+			return err
+		}
+		symlinkedNodes, changed := common.SyncSymlinkedNode(current.Status.SymlinkedNodes, r.nodeName, current.ManagesPersistentVolumes())
+		if !changed {
+			return nil
+		}
+		current.Status.SymlinkedNodes = symlinkedNodes
+		return r.client.Status().Update(context.TODO(), current)
+	})
+}
+
+// removeSymlinks removes the symlinks of a deleted LocalVolumeSet listed in status.symlinkedNodes from the node,
+// then the node from the list. Only the symlinks that PVs with the owner labels of the LocalVolumeSet point to are
+// removed, the symlinks of other objects with the same StorageClass are left alone.
+func (r *ReconcileLocalVolumeSet) removeSymlinks(reqLogger logr.Logger, lvset *localv1alpha1.LocalVolumeSet) error {
+	if !sets.NewString(lvset.Status.SymlinkedNodes...).Has(r.nodeName) {
+		return nil
+	}
+	node := &corev1.Node{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: r.nodeName}, node)
+	if err != nil {
+		return fmt.Errorf("could not get node %q: %w", r.nodeName, err)
+	}
+	hostname := node.Labels[corev1.LabelHostname]
+	pvs := &corev1.PersistentVolumeList{}
+	err = r.client.List(context.TODO(), pvs, client.MatchingLabels{
+		common.PVOwnerKindLabel:      localv1alpha1.LocalVolumeSetKind,
+		common.PVOwnerNameLabel:      lvset.Name,
+		common.PVOwnerNamespaceLabel: lvset.Namespace,
+		corev1.LabelHostname:         hostname,
+	})
+	if err != nil {
+		return fmt.Errorf("could not list the persistent volumes of the localvolumeset: %w", err)
+	}
+	for _, storageClassName := range lvset.StorageClassNames() {
+		symLinkDir := path.Join(common.GetLocalDiskLocationPath(), storageClassName)
+		removed, err := common.RemoveSymlinksOfPVs(symLinkDir, hostname, pvs.Items)
+		if len(removed) > 0 {
+			reqLogger.Info("removed the symlinks of the deleted LocalVolumeSet", "directory", symLinkDir, "symlinks", removed)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	key := types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &localv1alpha1.LocalVolumeSet{}
+		err := r.client.Get(context.TODO(), key, current)
+		if kerrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		symlinkedNodes := sets.NewString(current.Status.SymlinkedNodes...)
+		if !symlinkedNodes.Has(r.nodeName) {
+			return nil
+		}
+		current.Status.SymlinkedNodes = symlinkedNodes.Delete(r.nodeName).List()
+		return r.client.Status().Update(context.TODO(), current)
+	})
+}
@@ -0,0 +1,82 @@
+package lvset
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestSymlinkCleanup(t *testing.T) {
+	localDiskLocation, err := ioutil.TempDir("", "local-storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(localDiskLocation)
+	oldLocation, found := os.LookupEnv(common.LocalDiskLocationEnv)
+	os.Setenv(common.LocalDiskLocationEnv, localDiskLocation)
+	defer func() {
+		if found {
+			os.Setenv(common.LocalDiskLocationEnv, oldLocation)
+		} else {
+			os.Unsetenv(common.LocalDiskLocationEnv)
+		}
+	}()
+
+	managePersistentVolumes := false
+	lvset := &localv1alpha1.LocalVolumeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec: localv1alpha1.LocalVolumeSetSpec{
+			StorageClassName:        "sc",
+			ManagePersistentVolumes: &managePersistentVolumes,
+		},
+		Status: localv1alpha1.LocalVolumeSetStatus{SymlinkedNodes: []string{"node-a"}},
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{corev1.LabelHostname: "node-b"}}}
+	symlinkDir := filepath.Join(localDiskLocation, "sc")
+	// the other tool labels its PVs with the owner of the symlinks
+	ownedPV := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "owned", Labels: map[string]string{
+			corev1.LabelHostname:         "node-b",
+			common.PVOwnerKindLabel:      localv1alpha1.LocalVolumeSetKind,
+			common.PVOwnerNameLabel:      lvset.Name,
+			common.PVOwnerNamespaceLabel: lvset.Namespace,
+		}},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{Local: &corev1.LocalVolumeSource{Path: filepath.Join(symlinkDir, "disk-a")}},
+		},
+	}
+	r, _ := newFakeLocalVolumeSetReconciler(t, lvset, node, ownedPV)
+	r.nodeName = "node-b"
+	key := types.NamespacedName{Name: lvset.Name, Namespace: lvset.Namespace}
+	getLVSet := func() *localv1alpha1.LocalVolumeSet {
+		lvset := &localv1alpha1.LocalVolumeSet{}
+		err := r.client.Get(context.TODO(), key, lvset)
+		assert.NoError(t, err)
+		return lvset
+	}
+
+	err = r.syncSymlinkedNode(lvset)
+	assert.NoError(t, err)
+	lvset = getLVSet()
+	assert.Equal(t, []string{"node-a", "node-b"}, lvset.Status.SymlinkedNodes)
+
+	assert.NoError(t, os.MkdirAll(symlinkDir, 0755))
+	assert.NoError(t, os.Symlink("/dev/sdb", filepath.Join(symlinkDir, "disk-a")))
+	// a symlink of another object with the same StorageClass
+	assert.NoError(t, os.Symlink("/dev/sdc", filepath.Join(symlinkDir, "disk-b")))
+	err = r.removeSymlinks(log, lvset)
+	assert.NoError(t, err)
+	entries, err := ioutil.ReadDir(symlinkDir)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "disk-b", entries[0].Name(), "only the symlink of the owned PV is removed")
+	}
+	assert.Equal(t, []string{"node-a"}, getLVSet().Status.SymlinkedNodes, "only the node of the diskmaker is removed")
+}
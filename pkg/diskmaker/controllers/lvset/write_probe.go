@@ -0,0 +1,48 @@
+package lvset
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/diskmaker"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// probeDeviceWrites is the write probe of spec.deviceWriteProbe, overridden in tests
+var probeDeviceWrites = internal.ProbeDeviceWrites
+
+// runDeviceWriteProbe runs the spec.deviceWriteProbe of the LocalVolumeSet on a device that is not provisioned yet,
+// and reports a DeviceWriteProbeFailed event if the device fails it. Devices with a filesystem signature, like those
+// kept with reattachExisting or matched by fsLabels, are never probed. It runs within the device operation of the
+// provisioning and stops between two regions when the diskmaker shuts down, before the ShutdownGracePeriod expires.
+func (r *ReconcileLocalVolumeSet) runDeviceWriteProbe(lvset *localv1alpha1.LocalVolumeSet, devLogger logr.Logger, dev internal.BlockDevice, devPath string) error {
+	probe := lvset.Spec.DeviceWriteProbe
+	if probe == nil {
+		return nil
+	}
+	if dev.FSType != "" {
+		devLogger.Info("not probing device writes, the device has a filesystem signature", "fsType", dev.FSType)
+		return nil
+	}
+	mode := probe.Mode
+	if mode == "" {
+		mode = localv1alpha1.DeviceWriteProbeSample
+	}
+	devLogger.Info("probing device writes", "mode", mode)
+	probed, err := probeDeviceWrites(devPath, mode == localv1alpha1.DeviceWriteProbeFull, diskmaker.ShuttingDown)
+	if errors.Is(err, internal.ErrWriteProbeStopped) {
+		devLogger.Info("diskmaker is shutting down, stopped probing device writes")
+		return err
+	} else if err != nil {
+		r.eventReporter.Report(lvset, newDiskEvent(DeviceWriteProbeFailed,
+			fmt.Sprintf("device failed the %s write probe and is not provisioned: %v", mode, err), dev.KName, corev1.EventTypeWarning))
+		return err
+	}
+	if probed == 0 {
+		devLogger.Info("not probing device writes, the sampled regions hold data")
+	}
+	return nil
+}
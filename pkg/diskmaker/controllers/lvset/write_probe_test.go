@@ -0,0 +1,50 @@
+package lvset
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRunDeviceWriteProbe(t *testing.T) {
+	probed := map[string]bool{}
+	probeDeviceWrites = func(device string, full bool, stop func() bool) (int, error) {
+		probed[device] = full
+		if device == "/dev/sdc" {
+			return 0, fmt.Errorf("the 1048576 bytes read back at offset 0 differ from the written test pattern")
+		}
+		return 3, nil
+	}
+	defer func() { probeDeviceWrites = internal.ProbeDeviceWrites }()
+
+	lvset := &localv1alpha1.LocalVolumeSet{ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace}}
+	r, tc := newFakeLocalVolumeSetReconciler(t, lvset)
+
+	err := r.runDeviceWriteProbe(lvset, log, internal.BlockDevice{KName: "sdb"}, "/dev/sdb")
+	assert.NoError(t, err)
+	assert.Empty(t, probed, "devices are not probed without deviceWriteProbe")
+
+	lvset.Spec.DeviceWriteProbe = &localv1alpha1.DeviceWriteProbe{}
+	err = r.runDeviceWriteProbe(lvset, log, internal.BlockDevice{KName: "sdb"}, "/dev/sdb")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"/dev/sdb": false}, probed, "the Sample mode is the default")
+	assert.Empty(t, tc.eventStream)
+
+	err = r.runDeviceWriteProbe(lvset, log, internal.BlockDevice{KName: "sdd", FSType: "ext4"}, "/dev/sdd")
+	assert.NoError(t, err)
+	assert.NotContains(t, probed, "/dev/sdd", "devices with a filesystem signature are not probed")
+
+	lvset.Spec.DeviceWriteProbe.Mode = localv1alpha1.DeviceWriteProbeFull
+	err = r.runDeviceWriteProbe(lvset, log, internal.BlockDevice{KName: "sdc"}, "/dev/sdc")
+	assert.Error(t, err)
+	assert.True(t, probed["/dev/sdc"])
+	if assert.Len(t, tc.eventStream, 1) {
+		event := <-tc.eventStream
+		assert.True(t, strings.HasPrefix(event, "Warning "+DeviceWriteProbeFailed), event)
+	}
+}
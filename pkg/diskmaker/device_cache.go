@@ -0,0 +1,82 @@
+package diskmaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/openshift/local-storage-operator/pkg/internal"
+)
+
+const (
+	// DefaultDeviceCacheTTL is how long the diskmaker reuses a device listing by default
+	DefaultDeviceCacheTTL = time.Minute
+)
+
+// DeviceCache caches the node's block devices as listed by lsblk, so that reconciles
+// that don't involve hardware changes don't rescan the devices.
+// The cache expires after its TTL and is invalidated by udev events, see WatchDevices.
+type DeviceCache struct {
+	lock       sync.Mutex
+	ttl        time.Duration
+	devices    []internal.BlockDevice
+	listedAt   time.Time
+	generation uint64
+	now        func() time.Time
+	list       func() ([]internal.BlockDevice, []string, error)
+}
+
+// NewDeviceCache returns an empty DeviceCache, a ttl of 0 disables caching
+func NewDeviceCache(ttl time.Duration) *DeviceCache {
+	return &DeviceCache{
+		ttl:  ttl,
+		now:  time.Now,
+		list: internal.ListBlockDevices,
+	}
+}
+
+// Devices is the device cache shared by the diskmaker controllers
+var Devices = NewDeviceCache(DefaultDeviceCacheTTL)
+
+// SetTTL changes how long device listings are reused, a ttl of 0 disables caching
+func (c *DeviceCache) SetTTL(ttl time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.ttl = ttl
+}
+
+// ListBlockDevices returns the cached block devices, or lists them with internal.ListBlockDevices
+// if the cache expired or was invalidated. Listings that have bad rows or fail are not cached.
+func (c *DeviceCache) ListBlockDevices() ([]internal.BlockDevice, []string, error) {
+	c.lock.Lock()
+	if c.devices != nil && c.now().Sub(c.listedAt) < c.ttl {
+		devices := append([]internal.BlockDevice{}, c.devices...)
+		c.lock.Unlock()
+		return devices, nil, nil
+	}
+	generation := c.generation
+	c.lock.Unlock()
+
+	start := time.Now()
+	devices, badRows, err := c.list()
+	observeDiscovery(start)
+	if err != nil || len(badRows) > 0 {
+		return devices, badRows, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// don't cache a listing that an invalidation raced with, it may miss the change
+	if generation == c.generation {
+		c.devices = append([]internal.BlockDevice{}, devices...)
+		c.listedAt = c.now()
+	}
+	return devices, badRows, err
+}
+
+// Invalidate drops the cached devices, the next ListBlockDevices lists them again
+func (c *DeviceCache) Invalidate() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.devices = nil
+	c.generation++
+}
@@ -0,0 +1,78 @@
+package diskmaker
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDeviceCache(ttl time.Duration) (*DeviceCache, *int, *time.Time) {
+	listings := 0
+	now := time.Now()
+	cache := NewDeviceCache(ttl)
+	cache.now = func() time.Time { return now }
+	cache.list = func() ([]internal.BlockDevice, []string, error) {
+		listings++
+		return []internal.BlockDevice{{Name: fmt.Sprintf("sd%d", listings)}}, nil, nil
+	}
+	return cache, &listings, &now
+}
+
+func TestDeviceCacheTTL(t *testing.T) {
+	cache, listings, now := newTestDeviceCache(time.Minute)
+
+	devices, _, err := cache.ListBlockDevices()
+	assert.NoError(t, err)
+	assert.Equal(t, "sd1", devices[0].Name)
+
+	*now = now.Add(30 * time.Second)
+	devices, _, err = cache.ListBlockDevices()
+	assert.NoError(t, err)
+	assert.Equal(t, "sd1", devices[0].Name, "listing should be reused within the TTL")
+	assert.Equal(t, 1, *listings)
+
+	*now = now.Add(31 * time.Second)
+	devices, _, err = cache.ListBlockDevices()
+	assert.NoError(t, err)
+	assert.Equal(t, "sd2", devices[0].Name, "devices should be listed again after the TTL")
+}
+
+func TestDeviceCacheInvalidate(t *testing.T) {
+	cache, listings, _ := newTestDeviceCache(time.Minute)
+
+	_, _, err := cache.ListBlockDevices()
+	assert.NoError(t, err)
+	cache.Invalidate()
+	devices, _, err := cache.ListBlockDevices()
+	assert.NoError(t, err)
+	assert.Equal(t, "sd2", devices[0].Name, "devices should be listed again after an invalidation")
+	assert.Equal(t, 2, *listings)
+}
+
+func TestDeviceCacheDisabled(t *testing.T) {
+	cache, listings, _ := newTestDeviceCache(0)
+
+	for i := 0; i < 3; i++ {
+		_, _, err := cache.ListBlockDevices()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, *listings)
+}
+
+func TestDeviceCacheSkipsFailedListings(t *testing.T) {
+	cache, listings, _ := newTestDeviceCache(time.Minute)
+	cache.list = func() ([]internal.BlockDevice, []string, error) {
+		*listings++
+		return nil, []string{"bad row"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		_, badRows, err := cache.ListBlockDevices()
+		assert.NoError(t, err)
+		assert.Len(t, badRows, 1)
+	}
+	assert.Equal(t, 2, *listings)
+}
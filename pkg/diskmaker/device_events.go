@@ -0,0 +1,130 @@
+package diskmaker
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const (
+	// udevEventPeriod collapses bursts of udev events into one device change
+	udevEventPeriod = time.Second
+	// udevRestartBackoff is how long the udev monitor is down before it is restarted the first time,
+	// every consecutive failure doubles it up to maxUdevRestartBackoff
+	udevRestartBackoff    = 5 * time.Second
+	maxUdevRestartBackoff = 5 * time.Minute
+	// udevFallbackPollInterval is how often a device change is assumed while the udev monitor is down
+	udevFallbackPollInterval = 30 * time.Second
+)
+
+// udevDeviceEventMatch also matches change events, which udev emits when a device
+// gets a filesystem or partition table, since they change the lsblk output
+var udevDeviceEventMatch = []string{"(?i)add", "(?i)remove", "(?i)change"}
+
+// deviceEventBroadcaster notifies the subscribed controllers of device changes
+type deviceEventBroadcaster struct {
+	lock        sync.Mutex
+	subscribers []chan event.GenericEvent
+}
+
+var deviceEvents = &deviceEventBroadcaster{}
+
+// SubscribeDeviceEvents returns a channel that receives an event when block devices were added,
+// removed or changed on the node, to be watched by a controller with a source.Channel.
+// The events carry no object, notifications that are not consumed yet are coalesced.
+func SubscribeDeviceEvents() <-chan event.GenericEvent {
+	return deviceEvents.subscribe()
+}
+
+func (b *deviceEventBroadcaster) subscribe() <-chan event.GenericEvent {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	c := make(chan event.GenericEvent, 1)
+	b.subscribers = append(b.subscribers, c)
+	return c
+}
+
+func (b *deviceEventBroadcaster) notify() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for _, c := range b.subscribers {
+		select {
+		case c <- event.GenericEvent{}:
+		default:
+			// a notification is already pending
+		}
+	}
+}
+
+// devicesChanged invalidates the device cache and triggers the subscribed controllers
+func devicesChanged() {
+	Devices.Invalidate()
+	deviceEvents.notify()
+}
+
+// WatchDevices monitors udev for block devices being added, removed or changed until stop is closed,
+// so that hot-plugged devices are provisioned within seconds instead of on the next periodic reconcile.
+// When the udev monitor exits it is restarted with an exponential backoff,
+// and device changes are polled for until then.
+func WatchDevices(stop <-chan struct{}) {
+	backoff := udevRestartBackoff
+	for {
+		started := time.Now()
+		if !watchUdevEvents(stop) {
+			return
+		}
+		// the monitor ran fine for a while, it didn't fail on a persistent problem
+		if time.Since(started) > maxUdevRestartBackoff {
+			backoff = udevRestartBackoff
+		}
+		klog.Warningf("udev monitor exited, polling for device changes every %v until it is restarted in %v", udevFallbackPollInterval, backoff)
+		if !pollDevices(stop, backoff) {
+			return
+		}
+		backoff *= 2
+		if backoff > maxUdevRestartBackoff {
+			backoff = maxUdevRestartBackoff
+		}
+	}
+}
+
+// watchUdevEvents runs the udev monitor until it exits, it returns false if stop was closed instead
+func watchUdevEvents(stop <-chan struct{}) bool {
+	events := make(chan string)
+	go udevBlockMonitor(events, udevEventPeriod, udevDeviceEventMatch)
+	for {
+		select {
+		case <-stop:
+			return false
+		case text, ok := <-events:
+			if !ok {
+				return true
+			}
+			klog.V(4).Infof("devices changed after udev event: %s", text)
+			devicesChanged()
+		}
+	}
+}
+
+// pollDevices reports a device change every udevFallbackPollInterval for the given duration,
+// it returns false if stop was closed
+func pollDevices(stop <-chan struct{}, duration time.Duration) bool {
+	ticker := time.NewTicker(udevFallbackPollInterval)
+	defer ticker.Stop()
+	done := time.NewTimer(duration)
+	defer done.Stop()
+	for {
+		select {
+		case <-stop:
+			return false
+		case <-done.C:
+			// rescan once before the monitor restarts, changes may have been missed meanwhile
+			devicesChanged()
+			return true
+		case <-ticker.C:
+			devicesChanged()
+		}
+	}
+}
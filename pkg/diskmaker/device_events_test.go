@@ -0,0 +1,45 @@
+package diskmaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceEventsCoalesce(t *testing.T) {
+	broadcaster := &deviceEventBroadcaster{}
+	first := broadcaster.subscribe()
+	second := broadcaster.subscribe()
+
+	broadcaster.notify()
+	broadcaster.notify()
+
+	assert.Len(t, first, 1, "pending notifications should be coalesced")
+	assert.Len(t, second, 1, "every subscriber should be notified")
+	<-first
+	broadcaster.notify()
+	assert.Len(t, first, 1)
+}
+
+func TestPollDevicesInvalidatesCache(t *testing.T) {
+	listings := 0
+	Devices.list = func() ([]internal.BlockDevice, []string, error) {
+		listings++
+		return []internal.BlockDevice{}, nil, nil
+	}
+	defer func() { Devices.list = internal.ListBlockDevices }()
+
+	_, _, err := Devices.ListBlockDevices()
+	assert.NoError(t, err)
+
+	stop := make(chan struct{})
+	assert.True(t, pollDevices(stop, 10*time.Millisecond), "polling should end after its duration")
+	_, _, err = Devices.ListBlockDevices()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, listings, "the cache should be invalidated after polling")
+
+	close(stop)
+	assert.False(t, pollDevices(stop, time.Minute), "polling should end when stopped")
+}
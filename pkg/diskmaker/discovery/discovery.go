@@ -11,6 +11,7 @@ import (
 
 	"github.com/openshift/local-storage-operator/pkg/apis"
 	"github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
 	"github.com/openshift/local-storage-operator/pkg/diskmaker"
 	"github.com/openshift/local-storage-operator/pkg/diskmaker/controllers/lvset"
 	"github.com/openshift/local-storage-operator/pkg/internal"
@@ -34,6 +35,7 @@ type DeviceDiscovery struct {
 	apiClient            diskmaker.ApiUpdater
 	eventSync            *diskmaker.EventReporter
 	disks                []v1alpha1.DiscoveredDevice
+	rejectedDisks        []v1alpha1.RejectedDevice
 	localVolumeDiscovery *v1alpha1.LocalVolumeDiscovery
 }
 
@@ -70,6 +72,13 @@ func (discovery *DeviceDiscovery) Start() error {
 		return errors.Wrapf(err, message)
 	}
 
+	if os.Getenv(common.DiscoverySelfTestEnv) == "true" {
+		err = discovery.selfTest()
+		if err != nil {
+			klog.Errorf("failed to record the self-test result. %v", err)
+		}
+	}
+
 	err = discovery.discoverDevices()
 	if err != nil {
 		errors.Wrapf(err, "failed to discover devices")
@@ -80,7 +89,7 @@ func (discovery *DeviceDiscovery) Start() error {
 	signal.Notify(sigc, syscall.SIGTERM)
 
 	udevEvents := make(chan string)
-	go udevBlockMonitor(udevEvents, udevEventPeriod)
+	go diskmaker.UdevBlockMonitor(udevEvents, udevEventPeriod)
 	for {
 		select {
 		case <-sigc:
@@ -107,7 +116,7 @@ func (discovery *DeviceDiscovery) Start() error {
 // discoverDevices identifies the list of usable disks on the current node
 func (discovery *DeviceDiscovery) discoverDevices() error {
 	// List all the valid block devices on the node
-	validDevices, err := getValidBlockDevices()
+	validDevices, ignoredDevices, err := getValidBlockDevices()
 	if err != nil {
 		message := "failed to discover devices"
 		e := diskmaker.NewEvent(diskmaker.ErrorListingBlockDevices, fmt.Sprintf("%s. Error: %+v", message, err), "")
@@ -117,13 +126,15 @@ func (discovery *DeviceDiscovery) discoverDevices() error {
 
 	klog.Infof("valid block devices: %+v", validDevices)
 
-	discoveredDisks := getDiscoverdDevices(validDevices)
+	discoveredDisks, unavailableDisks := getDiscoverdDevices(validDevices)
 	klog.Infof("discovered devices: %+v", discoveredDisks)
+	rejectedDisks := append(ignoredDevices, unavailableDisks...)
 
 	// Update discovered devices in the  LocalVolumeDiscoveryResult resource
-	if !reflect.DeepEqual(discovery.disks, discoveredDisks) {
+	if !reflect.DeepEqual(discovery.disks, discoveredDisks) || !reflect.DeepEqual(discovery.rejectedDisks, rejectedDisks) {
 		klog.Info("device list updated. Updating LocalVolumeDiscoveryResult status...")
 		discovery.disks = discoveredDisks
+		discovery.rejectedDisks = rejectedDisks
 		err = discovery.updateStatus()
 		if err != nil {
 			message := "failed to update LocalVolumeDiscoveryResult status"
@@ -139,31 +150,50 @@ func (discovery *DeviceDiscovery) discoverDevices() error {
 	return nil
 }
 
-// getValidBlockDevices fetchs all the block devices sutitable for discovery
-func getValidBlockDevices() ([]internal.BlockDevice, error) {
+// getValidBlockDevices fetchs all the block devices sutitable for discovery,
+// and the devices that are ignored with the reason why
+func getValidBlockDevices() ([]internal.BlockDevice, []v1alpha1.RejectedDevice, error) {
 	blockDevices, badRows, err := internal.ListBlockDevices()
 	if err != nil {
 
-		return blockDevices, errors.Wrapf(err, "failed to list all the block devices in the node.")
+		return blockDevices, nil, errors.Wrapf(err, "failed to list all the block devices in the node.")
 	} else if len(badRows) > 0 {
 		klog.Warningf("failed to parse all the lsblk rows. Bad rows: %+v", badRows)
 	}
 
 	// Get valid list of devices
 	validDevices := make([]internal.BlockDevice, 0)
+	ignoredDevices := make([]v1alpha1.RejectedDevice, 0)
 	for _, blockDevice := range blockDevices {
-		if ignoreDevices(blockDevice) {
+		if reason, message := ignoreReason(blockDevice); reason != "" {
+			ignoredDevices = append(ignoredDevices, rejectedDevice(blockDevice, reason, message))
 			continue
 		}
 		validDevices = append(validDevices, blockDevice)
 	}
 
-	return validDevices, nil
+	return validDevices, ignoredDevices, nil
+}
+
+// rejectedDevice creates a v1alpha1.RejectedDevice from an internal.BlockDevice
+func rejectedDevice(dev internal.BlockDevice, reason v1alpha1.DeviceRejectionReason, message string) v1alpha1.RejectedDevice {
+	deviceID, err := dev.GetPathByID()
+	if err != nil {
+		deviceID = ""
+	}
+	return v1alpha1.RejectedDevice{
+		Path:     fmt.Sprintf("/dev/%s", dev.Name),
+		DeviceID: deviceID,
+		Reason:   reason,
+		Message:  message,
+	}
 }
 
-// getDiscoverdDevices creates v1alpha1.DiscoveredDevice from internal.BlockDevices
-func getDiscoverdDevices(blockDevices []internal.BlockDevice) []v1alpha1.DiscoveredDevice {
+// getDiscoverdDevices creates v1alpha1.DiscoveredDevice from internal.BlockDevices,
+// and a v1alpha1.RejectedDevice for each device that is not available
+func getDiscoverdDevices(blockDevices []internal.BlockDevice) ([]v1alpha1.DiscoveredDevice, []v1alpha1.RejectedDevice) {
 	discoveredDevices := make([]v1alpha1.DiscoveredDevice, 0)
+	unavailableDevices := make([]v1alpha1.RejectedDevice, 0)
 	for _, blockDevice := range blockDevices {
 		deviceID, err := blockDevice.GetPathByID()
 		if err != nil {
@@ -186,85 +216,99 @@ func getDiscoverdDevices(blockDevices []internal.BlockDevice) []v1alpha1.Discove
 			DeviceID: deviceID,
 			Size:     size,
 			Property: parseDeviceProperty(blockDevice.Rotational),
-			Status:   getDeviceStatus(blockDevice),
 		}
+		status, reason, message := getDeviceStatus(blockDevice)
+		discoveredDevice.Status = status
 		discoveredDevices = append(discoveredDevices, discoveredDevice)
+		if reason != "" {
+			unavailableDevices = append(unavailableDevices, v1alpha1.RejectedDevice{
+				Path:     discoveredDevice.Path,
+				DeviceID: deviceID,
+				Reason:   reason,
+				Message:  message,
+			})
+		}
 	}
 
-	return discoveredDevices
+	return discoveredDevices, unavailableDevices
 }
 
-// ignoreDevices checks if a device should be ignored during discovery
-func ignoreDevices(dev internal.BlockDevice) bool {
-	if readOnly, err := dev.GetReadOnly(); err != nil || readOnly {
+// ignoreReason returns why a device is ignored during discovery, or an empty reason if it isn't
+func ignoreReason(dev internal.BlockDevice) (v1alpha1.DeviceRejectionReason, string) {
+	if readOnly, err := dev.GetReadOnly(); err != nil {
+		return v1alpha1.RejectedCheckFailed, fmt.Sprintf("failed to check if the device is read-only: %v", err)
+	} else if readOnly {
 		klog.Infof("ignoring read only device %q", dev.Name)
-		return true
+		return v1alpha1.RejectedReadOnly, "the device is read-only"
 	}
 
-	if hasChildren, err := dev.HasChildren(); err != nil || hasChildren {
+	if hasChildren, err := dev.HasChildren(); err != nil {
+		return v1alpha1.RejectedCheckFailed, fmt.Sprintf("failed to check if the device has children: %v", err)
+	} else if hasChildren {
 		klog.Infof("ignoring root device %q", dev.Name)
-		return true
+		return v1alpha1.RejectedHasChildren, "the device has partitions or other child devices"
+	}
+
+	if isRAIDMember, err := dev.IsRAIDMember(); err != nil {
+		return v1alpha1.RejectedCheckFailed, fmt.Sprintf("failed to check if the device is a RAID member: %v", err)
+	} else if isRAIDMember {
+		klog.Infof("ignoring software RAID member device %q", dev.Name)
+		return v1alpha1.RejectedRAIDMember, "the device is a member of a software RAID array"
 	}
 
 	if dev.State == internal.StateSuspended {
 		klog.Infof("ignoring device %q with invalid state %q", dev.Name, dev.State)
-		return true
+		return v1alpha1.RejectedSuspended, fmt.Sprintf("the device is in state %q", dev.State)
 	}
 
 	if !supportedDeviceTypes.Has(dev.Type) {
 		klog.Infof("ignoring device %q with invalid type %q", dev.Name, dev.Type)
-		return true
+		return v1alpha1.RejectedUnsupportedType, fmt.Sprintf("devices of type %q are not supported", dev.Type)
 	}
 
-	return false
+	return "", ""
 }
 
-// getDeviceStatus returns device status as "Available", "NotAvailable" or "Unkown"
-func getDeviceStatus(dev internal.BlockDevice) v1alpha1.DeviceStatus {
-	status := v1alpha1.DeviceStatus{}
+// getDeviceStatus returns device status as "Available", "NotAvailable" or "Unkown",
+// and the reason why the device is not available
+func getDeviceStatus(dev internal.BlockDevice) (v1alpha1.DeviceStatus, v1alpha1.DeviceRejectionReason, string) {
+	notAvailable := v1alpha1.DeviceStatus{State: v1alpha1.NotAvailable}
+	unknown := v1alpha1.DeviceStatus{State: v1alpha1.Unknown}
 	if dev.FSType != "" {
 		klog.Infof("device %q with filesystem %q is not available", dev.Name, dev.FSType)
-		status.State = v1alpha1.NotAvailable
-		return status
+		return notAvailable, v1alpha1.RejectedHasFilesystem, fmt.Sprintf("the device has a %q filesystem", dev.FSType)
 	}
 
 	noBiosBootInPartLabel, err := lvset.FilterMap["noBiosBootInPartLabel"](dev, nil)
 	if err != nil {
-		status.State = v1alpha1.Unknown
-		return status
+		return unknown, v1alpha1.RejectedCheckFailed, fmt.Sprintf("failed to check the partition label: %v", err)
 	}
 	if !noBiosBootInPartLabel {
 		klog.Infof("device %q with part label %q is not available", dev.Name, dev.PartLabel)
-		status.State = v1alpha1.NotAvailable
-		return status
+		return notAvailable, v1alpha1.RejectedBiosBootPartLabel, fmt.Sprintf("the device has the partition label %q", dev.PartLabel)
 	}
 
 	canOpen, err := lvset.FilterMap["canOpenExclusively"](dev, nil)
 	if err != nil {
-		status.State = v1alpha1.Unknown
-		return status
+		return unknown, v1alpha1.RejectedCheckFailed, fmt.Sprintf("failed to open the device exclusively: %v", err)
 	}
 	if !canOpen {
 		klog.Infof("device %q is not available as it can't be opened exclusively", dev.Name)
-		status.State = v1alpha1.NotAvailable
-		return status
+		return notAvailable, v1alpha1.RejectedDeviceInUse, "the device can't be opened exclusively"
 	}
 
 	hasBindMounts, mountPoint, err := dev.HasBindMounts()
 	if err != nil {
-		status.State = v1alpha1.Unknown
-		return status
+		return unknown, v1alpha1.RejectedCheckFailed, fmt.Sprintf("failed to check the mount points: %v", err)
 	}
 
 	if hasBindMounts {
 		klog.Infof("device %q with mount point %q is not available", dev.Name, mountPoint)
-		status.State = v1alpha1.NotAvailable
-		return status
+		return notAvailable, v1alpha1.RejectedBindMounted, fmt.Sprintf("the device is mounted at %q", mountPoint)
 	}
 
 	klog.Infof("device %q is available", dev.Name)
-	status.State = v1alpha1.Available
-	return status
+	return v1alpha1.DeviceStatus{State: v1alpha1.Available}, "", ""
 }
 
 func parseDeviceProperty(property string) v1alpha1.DeviceMechanicalProperty {
@@ -16,13 +16,16 @@ import (
 var lsblkOut string
 var blkidOut string
 
+// failCommand is the command that exits with an error in the helper process
+var failCommand string
+
 // helperCommand returns a fake exec.Cmd for unit tests
 func helperCommand(command string, args ...string) *exec.Cmd {
 	cs := []string{"-test.run=TestHelperProcess", "--", command}
 	cs = append(cs, args...)
 	cmd := exec.Command(os.Args[0], cs...)
 	cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1", fmt.Sprintf("COMMAND=%s", command),
-		fmt.Sprintf("LSBLKOUT=%s", lsblkOut), fmt.Sprintf("BLKIDOUT=%s", blkidOut)}
+		fmt.Sprintf("LSBLKOUT=%s", lsblkOut), fmt.Sprintf("BLKIDOUT=%s", blkidOut), fmt.Sprintf("FAILCOMMAND=%s", failCommand)}
 	return cmd
 }
 
@@ -31,12 +34,18 @@ func TestHelperProcess(t *testing.T) {
 		return
 	}
 
+	if os.Getenv("COMMAND") == os.Getenv("FAILCOMMAND") {
+		fmt.Fprintf(os.Stderr, "%s failed", os.Getenv("COMMAND"))
+		os.Exit(1)
+	}
 	defer os.Exit(0)
 	switch os.Getenv("COMMAND") {
 	case "lsblk":
 		fmt.Fprintf(os.Stdout, os.Getenv("LSBLKOUT"))
 	case "blkid":
 		fmt.Fprintf(os.Stdout, os.Getenv("BLKIDOUT"))
+	case "losetup":
+		fmt.Fprintf(os.Stdout, "/dev/loop7\n")
 	}
 }
 
@@ -203,7 +212,8 @@ func TestIgnoreDevices(t *testing.T) {
 			internal.FilePathGlob = filepath.Glob
 		}()
 
-		actual := ignoreDevices(tc.blockDevice)
+		reason, _ := ignoreReason(tc.blockDevice)
+		actual := reason != ""
 		assert.Equalf(t, tc.expected, actual, "[%s]: %s", tc.label, tc.errMessage)
 	}
 }
@@ -273,7 +283,7 @@ func TestValidBlockDevices(t *testing.T) {
 			internal.FilePathGlob = filepath.Glob
 			internal.ExecCommand = exec.Command
 		}()
-		actual, err := getValidBlockDevices()
+		actual, _, err := getValidBlockDevices()
 		assert.NoError(t, err)
 		assert.Equalf(t, tc.expectedDiscoveredDeviceSize, len(actual), "[%s]: %s", tc.label, tc.errMessage)
 	}
@@ -456,7 +466,7 @@ func TestGetDiscoveredDevices(t *testing.T) {
 			internal.FilePathEvalSymLinks = filepath.EvalSymlinks
 		}()
 
-		actual := getDiscoverdDevices(tc.blockDevices)
+		actual, _ := getDiscoverdDevices(tc.blockDevices)
 		for i := 0; i < len(tc.expected); i++ {
 			assert.Equalf(t, tc.expected[i].DeviceID, actual[i].DeviceID, "[%s: Discovered Device: %d]: invalid device ID", tc.label, i+1)
 			assert.Equalf(t, tc.expected[i].Path, actual[i].Path, "[%s: Discovered Device: %d]: invalid device path", tc.label, i+1)
@@ -558,3 +568,32 @@ func unsetEnv() {
 	os.Unsetenv("DISCOVERY_OBJECT_UID")
 	os.Unsetenv("DISCOVERY_OBJECT_NAME")
 }
+
+func TestRejectedDevices(t *testing.T) {
+	internal.FilePathGlob = func(name string) ([]string, error) {
+		return []string{"/dev/disk/by-id/sdb"}, nil
+	}
+	internal.FilePathEvalSymLinks = func(path string) (string, error) {
+		return "/dev/sdb", nil
+	}
+	defer func() {
+		internal.FilePathGlob = filepath.Glob
+		internal.FilePathEvalSymLinks = filepath.EvalSymlinks
+	}()
+
+	reason, message := ignoreReason(internal.BlockDevice{Name: "sdb", KName: "sdb", ReadOnly: "1", State: "running", Type: "disk"})
+	assert.Equal(t, v1alpha1.RejectedReadOnly, reason)
+	assert.NotEmpty(t, message)
+	reason, _ = ignoreReason(internal.BlockDevice{Name: "sr0", KName: "sr0", ReadOnly: "0", State: "running", Type: "rom"})
+	assert.Equal(t, v1alpha1.RejectedUnsupportedType, reason)
+
+	discovered, rejected := getDiscoverdDevices([]internal.BlockDevice{
+		{Name: "sdb", KName: "sdb", FSType: "xfs", Type: "disk", Size: "62914560000", ReadOnly: "0", State: "running"},
+		{Name: "sdb", KName: "sdb", PartLabel: "BIOS-BOOT", Type: "part", Size: "1048576", ReadOnly: "0", State: "running"},
+	})
+	assert.Len(t, discovered, 2, "unavailable devices are still discovered")
+	assert.Equal(t, []v1alpha1.RejectedDevice{
+		{Path: "/dev/sdb", DeviceID: "/dev/disk/by-id/sdb", Reason: v1alpha1.RejectedHasFilesystem, Message: `the device has a "xfs" filesystem`},
+		{Path: "/dev/sdb", DeviceID: "/dev/disk/by-id/sdb", Reason: v1alpha1.RejectedBiosBootPartLabel, Message: `the device has the partition label "BIOS-BOOT"`},
+	}, rejected)
+}
@@ -0,0 +1,161 @@
+package discovery
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/diskmaker"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/pkg/errors"
+	"k8s.io/klog"
+)
+
+const (
+	// selfTestDeviceSize is the size of the sparse scratch file behind the loop device of the self-test
+	selfTestDeviceSize = 64 * 1024 * 1024
+	// selfTestDataSize is the size of the file written to the scratch device and read back
+	selfTestDataSize = 1024 * 1024
+)
+
+// selfTest runs the provisioning self-test of the node and records its result
+// in the SelfTestPassed condition of the LocalVolumeDiscoveryResult of the node
+func (discovery *DeviceDiscovery) selfTest() error {
+	nodeName := os.Getenv("MY_NODE_NAME")
+	klog.Info("running the provisioning self-test")
+	condition := operatorv1.OperatorCondition{
+		Type:    v1alpha1.SelfTestPassedCondition,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  diskmaker.SelfTestPassed,
+		Message: "a scratch device was formatted, mounted, written and read back",
+	}
+	e := diskmaker.NewSuccessEvent(diskmaker.SelfTestPassed, fmt.Sprintf("provisioning self-test passed on node %s", nodeName), "")
+	err := runSelfTest(os.TempDir())
+	if err != nil {
+		klog.Errorf("provisioning self-test failed: %v", err)
+		condition.Status = operatorv1.ConditionFalse
+		condition.Reason = diskmaker.SelfTestFailed
+		condition.Message = err.Error()
+		e = diskmaker.NewEvent(diskmaker.SelfTestFailed, fmt.Sprintf("provisioning self-test failed on node %s: %v", nodeName, err), "")
+	}
+	discovery.eventSync.Report(e, discovery.localVolumeDiscovery)
+
+	resultCR, err := discovery.apiClient.GetDiscoveryResult(truncateNodeName(resultCRName, nodeName), os.Getenv("WATCH_NAMESPACE"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve LocalVolumeDiscoveryResult resource to record the self-test result")
+	}
+	v1helpers.SetOperatorCondition(&resultCR.Status.Conditions, condition)
+	err = discovery.apiClient.UpdateDiscoveryResultStatus(resultCR)
+	if err != nil {
+		return errors.Wrapf(err, "failed to record the self-test result in the LocalVolumeDiscoveryResult resource")
+	}
+	return nil
+}
+
+// runSelfTest checks that the node can provision a local volume without touching its disks:
+// a loop device backed by a sparse scratch file in dir is formatted with ext4 and mounted,
+// then a file is written to it and read back, like the e2e tests do with the PVs they consume.
+// Everything is removed afterwards, a failed cleanup also fails the self-test.
+func runSelfTest(dir string) (err error) {
+	scratchDir, err := ioutil.TempDir(dir, "local-storage-self-test-")
+	if err != nil {
+		return fmt.Errorf("failed to create the scratch directory: %w", err)
+	}
+	defer func() {
+		removeErr := os.RemoveAll(scratchDir)
+		if removeErr != nil && err == nil {
+			err = fmt.Errorf("failed to remove the scratch directory: %w", removeErr)
+		}
+	}()
+
+	scratchFile := filepath.Join(scratchDir, "device")
+	err = createSparseFile(scratchFile, selfTestDeviceSize)
+	if err != nil {
+		return err
+	}
+	device, err := internal.AttachLoopDevice(scratchFile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		detachErr := internal.DetachLoopDevice(device)
+		if detachErr != nil && err == nil {
+			err = detachErr
+		}
+	}()
+
+	err = internal.MakeExt4FS(device, "")
+	if err != nil {
+		return err
+	}
+	mountDir := filepath.Join(scratchDir, "mount")
+	err = os.Mkdir(mountDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create the mount directory: %w", err)
+	}
+	err = internal.MountDevice(device, mountDir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		unmountErr := internal.Unmount(mountDir)
+		if unmountErr != nil && err == nil {
+			err = unmountErr
+		}
+	}()
+
+	return writeAndReadBack(filepath.Join(mountDir, "data"))
+}
+
+func createSparseFile(path string, size int64) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create the scratch file: %w", err)
+	}
+	defer file.Close()
+	err = file.Truncate(size)
+	if err != nil {
+		return fmt.Errorf("failed to size the scratch file: %w", err)
+	}
+	return nil
+}
+
+// writeAndReadBack writes random data to the file, syncs it and compares the md5 sum of the data read back
+func writeAndReadBack(path string) error {
+	data := make([]byte, selfTestDataSize)
+	_, err := rand.Read(data)
+	if err != nil {
+		return fmt.Errorf("failed to generate the test data: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create the test file: %w", err)
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write the test file: %w", err)
+	}
+
+	read, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the test file: %w", err)
+	}
+	written, readBack := md5.Sum(data), md5.Sum(read)
+	if written != readBack {
+		return fmt.Errorf("the test file read back has md5 %x instead of %x", readBack, written)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package discovery
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/diskmaker"
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSelfTest(t *testing.T) {
+	internal.ExecCommand = helperCommand
+	defer func() {
+		internal.ExecCommand = exec.Command
+		failCommand = ""
+	}()
+
+	testcases := []struct {
+		label       string
+		failCommand string
+		errMessage  string
+	}{
+		{label: "self-test passes"},
+		{label: "loop device is not attached", failCommand: "losetup", errMessage: "failed to attach"},
+		{label: "device is not formatted", failCommand: "mkfs.ext4", errMessage: "failed to format"},
+		{label: "device is not mounted", failCommand: "mount", errMessage: "failed to mount"},
+		{label: "device is not unmounted", failCommand: "umount", errMessage: "failed to unmount"},
+	}
+	for _, tc := range testcases {
+		dir, err := ioutil.TempDir("", "selftest")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+		failCommand = tc.failCommand
+
+		err = runSelfTest(dir)
+		if tc.errMessage == "" {
+			assert.NoError(t, err, tc.label)
+		} else {
+			assert.Error(t, err, tc.label)
+			assert.Contains(t, err.Error(), tc.errMessage, tc.label)
+		}
+		entries, err := ioutil.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Empty(t, entries, "%s: the scratch directory is removed", tc.label)
+	}
+}
+
+func TestSelfTestCondition(t *testing.T) {
+	internal.ExecCommand = helperCommand
+	defer func() {
+		internal.ExecCommand = exec.Command
+		failCommand = ""
+	}()
+	setEnv()
+	defer unsetEnv()
+
+	var conditions []operatorv1.OperatorCondition
+	dd := getFakeDeviceDiscovery()
+	dd.apiClient = &diskmaker.MockAPIUpdater{
+		MockGetDiscoveryResult: func(name, namespace string) (*v1alpha1.LocalVolumeDiscoveryResult, error) {
+			result := &v1alpha1.LocalVolumeDiscoveryResult{}
+			result.Status.Conditions = conditions
+			return result, nil
+		},
+		MockUpdateDiscoveryResultStatus: func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error {
+			conditions = lvdr.Status.Conditions
+			return nil
+		},
+	}
+	dd.eventSync = diskmaker.NewEventReporter(dd.apiClient)
+
+	err := dd.selfTest()
+	assert.NoError(t, err)
+	condition := v1helpers.FindOperatorCondition(conditions, v1alpha1.SelfTestPassedCondition)
+	assert.NotNil(t, condition)
+	assert.Equal(t, operatorv1.ConditionTrue, condition.Status)
+	assert.Equal(t, diskmaker.SelfTestPassed, condition.Reason)
+
+	failCommand = "mount"
+	err = dd.selfTest()
+	assert.NoError(t, err, "a failed self-test is recorded, not returned")
+	condition = v1helpers.FindOperatorCondition(conditions, v1alpha1.SelfTestPassedCondition)
+	assert.NotNil(t, condition)
+	assert.Equal(t, operatorv1.ConditionFalse, condition.Status)
+	assert.Equal(t, diskmaker.SelfTestFailed, condition.Reason)
+	assert.Contains(t, condition.Message, "failed to mount")
+}
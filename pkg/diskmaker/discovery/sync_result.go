@@ -86,6 +86,7 @@ func (discovery *DeviceDiscovery) updateStatus() error {
 
 	// Update discovered devce list and discovery time
 	resultCR.Status.DiscoveredDevices = discovery.disks
+	resultCR.Status.RejectedDevices = discovery.rejectedDisks
 	resultCR.Status.DiscoveredTimeStamp = time.Now().UTC().Format(time.RFC3339)
 
 	err = discovery.apiClient.UpdateDiscoveryResultStatus(resultCR)
@@ -28,6 +28,8 @@ const (
 
 	CreatedDiscoveryResultObject = "CreatedDiscoveryResultObject"
 	UpdatedDiscoveredDeviceList  = "UpdatedDiscoveredDeviceList"
+	SelfTestPassed               = "SelfTestPassed"
+	SelfTestFailed               = "SelfTestFailed"
 )
 
 // DiskEvent is instance of a single event
@@ -0,0 +1,95 @@
+package diskmaker
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+)
+
+const (
+	// ReconcileStuckThreshold is how long a single reconcile may run before the
+	// liveness probe reports the diskmaker as stuck
+	ReconcileStuckThreshold = 10 * time.Minute
+	// initialDiscoveryRetryPeriod is how often the first device listing is retried until it succeeds
+	initialDiscoveryRetryPeriod = 10 * time.Second
+)
+
+// healthTracker records the progress of the diskmaker reconcile loops for the health probes
+type healthTracker struct {
+	lock              sync.Mutex
+	discoveryComplete bool
+	// hostPathErr is the last error of CheckHostPaths
+	hostPathErr error
+	nextID      uint64
+	inProgress  map[uint64]time.Time
+}
+
+var health = &healthTracker{inProgress: map[uint64]time.Time{}}
+
+// MarkDiscoveryComplete records that the diskmaker listed the node's block devices successfully,
+// after which the readiness probe succeeds.
+func MarkDiscoveryComplete() {
+	health.lock.Lock()
+	defer health.lock.Unlock()
+	health.discoveryComplete = true
+}
+
+// RunInitialDiscovery lists the node's block devices once at startup, retrying until the listing succeeds,
+// and then marks the discovery complete. The readiness probe doesn't depend on a LocalVolume or LocalVolumeSet
+// selecting the node, or on their reconciles getting as far as listing the devices.
+func RunInitialDiscovery(devices *DeviceCache, stop <-chan struct{}) {
+	wait.PollImmediateUntil(initialDiscoveryRetryPeriod, func() (bool, error) {
+		_, _, err := devices.ListBlockDevices()
+		if err != nil {
+			klog.Errorf("failed to list block devices: %v", err)
+			return false, nil
+		}
+		MarkDiscoveryComplete()
+		return true, nil
+	}, stop)
+}
+
+// TrackReconcile registers a running reconcile for the liveness probe.
+// The returned function must be called when the reconcile returns.
+func TrackReconcile() func() {
+	health.lock.Lock()
+	defer health.lock.Unlock()
+	id := health.nextID
+	health.nextID++
+	health.inProgress[id] = time.Now()
+	return func() {
+		health.lock.Lock()
+		defer health.lock.Unlock()
+		delete(health.inProgress, id)
+	}
+}
+
+// ReadyzCheck is a healthz.Checker that fails until the first successful device discovery,
+// and while the host paths of the diskmaker are unavailable.
+func ReadyzCheck(_ *http.Request) error {
+	health.lock.Lock()
+	defer health.lock.Unlock()
+	if health.hostPathErr != nil {
+		return fmt.Errorf("%s: %v", HostPathUnavailable, health.hostPathErr)
+	}
+	if !health.discoveryComplete {
+		return fmt.Errorf("no device discovery has completed yet")
+	}
+	return nil
+}
+
+// HealthzCheck is a healthz.Checker that fails if a reconcile has been running for longer than ReconcileStuckThreshold.
+func HealthzCheck(_ *http.Request) error {
+	health.lock.Lock()
+	defer health.lock.Unlock()
+	for _, started := range health.inProgress {
+		if running := time.Since(started); running > ReconcileStuckThreshold {
+			return fmt.Errorf("a reconcile has been running for %v", running.Round(time.Second))
+		}
+	}
+	return nil
+}
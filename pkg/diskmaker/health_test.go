@@ -0,0 +1,47 @@
+package diskmaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadyzCheck(t *testing.T) {
+	health = &healthTracker{inProgress: map[uint64]time.Time{}}
+	assert.Error(t, ReadyzCheck(nil))
+	MarkDiscoveryComplete()
+	assert.NoError(t, ReadyzCheck(nil))
+}
+
+func TestRunInitialDiscovery(t *testing.T) {
+	health = &healthTracker{inProgress: map[uint64]time.Time{}}
+	devices := NewDeviceCache(0)
+	devices.list = func() ([]internal.BlockDevice, []string, error) {
+		return []internal.BlockDevice{{Name: "sdb", KName: "sdb"}}, nil, nil
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	RunInitialDiscovery(devices, stop)
+	assert.NoError(t, ReadyzCheck(nil))
+}
+
+func TestHealthzCheck(t *testing.T) {
+	health = &healthTracker{inProgress: map[uint64]time.Time{}}
+	assert.NoError(t, HealthzCheck(nil))
+
+	done := TrackReconcile()
+	assert.NoError(t, HealthzCheck(nil))
+
+	// pretend the reconcile started too long ago
+	health.lock.Lock()
+	for id := range health.inProgress {
+		health.inProgress[id] = time.Now().Add(-2 * ReconcileStuckThreshold)
+	}
+	health.lock.Unlock()
+	assert.Error(t, HealthzCheck(nil))
+
+	done()
+	assert.NoError(t, HealthzCheck(nil))
+}
@@ -0,0 +1,117 @@
+package diskmaker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+)
+
+// hostExcludesPollInterval is how often the host exclude file is checked for changes
+const hostExcludesPollInterval = 10 * time.Second
+
+// hostExcludeList is the content of the host exclude file
+type hostExcludeList struct {
+	lock    sync.Mutex
+	serials sets.String
+	// devices are the kernel names of the listed device paths, which may be symlinks like /dev/disk/by-id paths
+	devices sets.String
+}
+
+var hostExcludes = &hostExcludeList{serials: sets.NewString(), devices: sets.NewString()}
+
+// parseHostExcludes returns the serial numbers and the device kernel names listed in the host exclude file:
+// one entry per line, a device path if it starts with a / and a serial number otherwise.
+// Empty lines and lines starting with # are ignored. A path that doesn't resolve to a device yet is skipped.
+func parseHostExcludes(data string) (sets.String, sets.String) {
+	serials := sets.NewString()
+	devices := sets.NewString()
+	for _, line := range strings.Split(data, "\n") {
+		entry := strings.TrimSpace(line)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if !strings.HasPrefix(entry, "/") {
+			serials.Insert(entry)
+			continue
+		}
+		path, err := filepath.EvalSymlinks(entry)
+		if err != nil {
+			klog.V(4).Infof("skipping host exclude %s: %v", entry, err)
+			continue
+		}
+		devices.Insert(filepath.Base(path))
+	}
+	return serials, devices
+}
+
+// LoadHostExcludes reads the host exclude file at path. A disabled file, with an empty path, or a missing file
+// excludes nothing.
+func LoadHostExcludes(path string) error {
+	_, err := loadHostExcludes(path)
+	return err
+}
+
+// loadHostExcludes reads the host exclude file and returns true if its entries changed
+func loadHostExcludes(path string) (bool, error) {
+	data := []byte{}
+	if path != "" {
+		var err error
+		data, err = ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return false, fmt.Errorf("failed to read the host exclude file %s: %w", path, err)
+		}
+	}
+	serials, devices := parseHostExcludes(string(data))
+
+	hostExcludes.lock.Lock()
+	defer hostExcludes.lock.Unlock()
+	if reflect.DeepEqual(serials, hostExcludes.serials) && reflect.DeepEqual(devices, hostExcludes.devices) {
+		return false, nil
+	}
+	klog.Infof("host excludes changed, serials: %v, devices: %v", serials.List(), devices.List())
+	hostExcludes.serials = serials
+	hostExcludes.devices = devices
+	return true, nil
+}
+
+// IsHostExcluded returns true if the host exclude file lists the serial number or a path of the device
+func IsHostExcluded(dev internal.BlockDevice) bool {
+	hostExcludes.lock.Lock()
+	defer hostExcludes.lock.Unlock()
+	return (dev.Serial != "" && hostExcludes.serials.Has(dev.Serial)) || hostExcludes.devices.Has(dev.KName)
+}
+
+// WatchHostExcludes checks the host exclude file at path for changes until stop is closed,
+// and triggers the controllers subscribed to device events when its entries change.
+// The paths are resolved again on every check, for devices that appear after the file was written.
+func WatchHostExcludes(path string, stop <-chan struct{}) {
+	if path == "" {
+		return
+	}
+	ticker := time.NewTicker(hostExcludesPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			changed, err := loadHostExcludes(path)
+			if err != nil {
+				klog.Errorf("failed to load the host exclude file: %v", err)
+				continue
+			}
+			if changed {
+				deviceEvents.notify()
+			}
+		}
+	}
+}
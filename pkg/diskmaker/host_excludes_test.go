@@ -0,0 +1,50 @@
+package diskmaker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/local-storage-operator/pkg/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostExcludes(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "host-excludes")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	defer LoadHostExcludes("")
+
+	// a fake device and a by-id symlink to it
+	devicePath := filepath.Join(tempDir, "sdc")
+	assert.NoError(t, ioutil.WriteFile(devicePath, nil, 0644))
+	byIDPath := filepath.Join(tempDir, "wwn-0x5000c500a0b1c2d3")
+	assert.NoError(t, os.Symlink(devicePath, byIDPath))
+
+	excludeFile := filepath.Join(tempDir, "exclude")
+	changed, err := loadHostExcludes(excludeFile)
+	assert.NoError(t, err, "a missing file should exclude nothing")
+	assert.False(t, changed)
+	assert.False(t, IsHostExcluded(internal.BlockDevice{KName: "sdc", Serial: "PHLJ9043015V1P0FGN"}))
+
+	content := "# scratch disks\n\nPHLJ9043015V1P0FGN\n  " + byIDPath + "\n" + filepath.Join(tempDir, "missing") + "\n"
+	assert.NoError(t, ioutil.WriteFile(excludeFile, []byte(content), 0644))
+	changed, err = loadHostExcludes(excludeFile)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, IsHostExcluded(internal.BlockDevice{KName: "sdb", Serial: "PHLJ9043015V1P0FGN"}), "a listed serial should be excluded")
+	assert.True(t, IsHostExcluded(internal.BlockDevice{KName: "sdc"}), "the device of a listed symlink should be excluded")
+	assert.False(t, IsHostExcluded(internal.BlockDevice{KName: "sdd", Serial: "OTHER"}))
+	assert.False(t, IsHostExcluded(internal.BlockDevice{KName: "sdd", Serial: "# scratch disks"}), "comments should be ignored")
+
+	changed, err = loadHostExcludes(excludeFile)
+	assert.NoError(t, err)
+	assert.False(t, changed, "an unchanged file should not trigger a reconcile")
+
+	assert.NoError(t, os.Remove(excludeFile))
+	changed, err = loadHostExcludes(excludeFile)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.False(t, IsHostExcluded(internal.BlockDevice{KName: "sdc", Serial: "PHLJ9043015V1P0FGN"}))
+}
@@ -0,0 +1,61 @@
+package diskmaker
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// HostPathUnavailable is the reason of the events of a diskmaker whose host paths are missing or read-only
+	HostPathUnavailable = "HostPathUnavailable"
+	// HostPathRetryPeriod is how long the reconciles wait before checking unavailable host paths again
+	HostPathRetryPeriod = time.Minute
+)
+
+// hostPaths returns the host directories the diskmaker needs: /dev to list and format the devices,
+// and the symlink directory to symlink them. Overridden in tests.
+var hostPaths = func() []string {
+	return []string{"/dev", common.GetLocalDiskLocationPath()}
+}
+
+// CheckHostPaths returns an error if a host path of the diskmaker is missing, not a directory or read-only,
+// as on hardened nodes that mount them read-only or where the hostPath mount failed.
+// The result is reported by the readiness probe: the diskmaker is not ready while it fails.
+// Discovery-only diskmakers don't need the host paths to be writable.
+func CheckHostPaths(discoveryOnly bool) error {
+	var err error
+	for _, path := range hostPaths() {
+		err = checkHostPath(path, discoveryOnly)
+		if err != nil {
+			break
+		}
+	}
+	health.lock.Lock()
+	defer health.lock.Unlock()
+	health.hostPathErr = err
+	return err
+}
+
+func checkHostPath(path string, discoveryOnly bool) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("host path %s is unavailable: %w", path, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("host path %s is not a directory", path)
+	}
+	// discovery-only diskmakers never write to the devices nor symlink them
+	if discoveryOnly {
+		return nil
+	}
+	// access reports EROFS for read-only mounts
+	err = unix.Access(path, unix.W_OK)
+	if err != nil {
+		return fmt.Errorf("host path %s is not writable: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package diskmaker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckHostPaths(t *testing.T) {
+	health = &healthTracker{inProgress: map[uint64]time.Time{}}
+	tempDir, err := ioutil.TempDir("", "host-paths")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	paths := []string{tempDir}
+	defaultHostPaths := hostPaths
+	hostPaths = func() []string { return paths }
+	defer func() { hostPaths = defaultHostPaths }()
+	MarkDiscoveryComplete()
+
+	assert.NoError(t, CheckHostPaths(false))
+	assert.NoError(t, ReadyzCheck(nil))
+
+	paths = []string{tempDir, filepath.Join(tempDir, "missing")}
+	assert.Error(t, CheckHostPaths(false))
+	err = ReadyzCheck(nil)
+	if assert.Error(t, err, "the diskmaker is not ready while a host path is unavailable") {
+		assert.Contains(t, err.Error(), HostPathUnavailable)
+	}
+
+	file := filepath.Join(tempDir, "file")
+	assert.NoError(t, ioutil.WriteFile(file, []byte{}, 0644))
+	paths = []string{file}
+	assert.Error(t, CheckHostPaths(false), "a host path must be a directory")
+
+	paths = []string{tempDir}
+	assert.NoError(t, CheckHostPaths(false))
+	assert.NoError(t, ReadyzCheck(nil), "the diskmaker is ready again once the host paths are available")
+}
@@ -0,0 +1,26 @@
+package diskmaker
+
+import (
+	"time"
+
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// discoveryDuration has no device labels, like all histograms of the diskmaker,
+// to keep the cardinality bounded on nodes with many disks
+var discoveryDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "lso_discovery_duration_seconds",
+	Help:    "Time taken to list the block devices of the node",
+	Buckets: common.DurationBuckets,
+})
+
+func init() {
+	metrics.Registry.MustRegister(discoveryDuration, common.PVCreateDuration)
+}
+
+// observeDiscovery records the time taken to list the block devices since start
+func observeDiscovery(start time.Time) {
+	discoveryDuration.Observe(time.Since(start).Seconds())
+}
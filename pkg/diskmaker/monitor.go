@@ -1,4 +1,4 @@
-package discovery
+package diskmaker
 
 import (
 	"bufio"
@@ -15,19 +15,23 @@ var (
 	udevEventMatch      = []string{"(?i)add", "(?i)remove"}
 )
 
-// Monitors udev for block device changes, and collapses these events such that
+// UdevBlockMonitor monitors udev for block device changes, and collapses these events such that
 // only one event is emitted per period in order to deal with flapping.
-func udevBlockMonitor(c chan string, period time.Duration) {
-	defer close(c)
-
+func UdevBlockMonitor(c chan string, period time.Duration) {
 	// return any add or remove events, but none that match device mapper
 	// events. string matching is case-insensitive
+	udevBlockMonitor(c, period, udevEventMatch)
+}
+
+func udevBlockMonitor(c chan string, period time.Duration, matches []string) {
+	defer close(c)
+
 	events := make(chan string)
 
-	klog.Infof("regex for matching udev events - %q", udevEventMatch)
+	klog.Infof("regex for matching udev events - %q", matches)
 	klog.Infof("regex for list of devices to be ignored for udev events - %q", udevExclusionFilter)
 
-	go rawUdevBlockMonitor(events, udevEventMatch, udevExclusionFilter)
+	go rawUdevBlockMonitor(events, matches, udevExclusionFilter)
 
 	for {
 		event, ok := <-events
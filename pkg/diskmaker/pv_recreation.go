@@ -0,0 +1,7 @@
+package diskmaker
+
+import "github.com/openshift/local-storage-operator/pkg/common"
+
+// PVRecreations limits how often the LocalVolume and LocalVolumeSet controllers
+// recreate the PV of a device after it was released and cleaned up
+var PVRecreations = common.NewPVRecreationLimiter()
@@ -0,0 +1,68 @@
+package diskmaker
+
+import (
+	"sync"
+	"time"
+)
+
+// ShutdownGracePeriod is how long the diskmaker waits for in-flight device
+// operations after receiving SIGTERM. It is kept below the default pod
+// terminationGracePeriodSeconds (30s) so the wait finishes before SIGKILL.
+const ShutdownGracePeriod = 20 * time.Second
+
+// operationTracker tracks device operations (symlink and PV creation) that must not
+// be interrupted halfway, so that a terminating diskmaker can let them finish.
+type operationTracker struct {
+	lock         sync.Mutex
+	shuttingDown bool
+	inFlight     sync.WaitGroup
+}
+
+var operations = &operationTracker{}
+
+// BeginDeviceOperation registers the start of a device operation.
+// It returns false if the diskmaker is shutting down, in which case the operation must not be started.
+// Every successful call must be paired with EndDeviceOperation.
+func BeginDeviceOperation() bool {
+	operations.lock.Lock()
+	defer operations.lock.Unlock()
+	if operations.shuttingDown {
+		return false
+	}
+	operations.inFlight.Add(1)
+	return true
+}
+
+// ShuttingDown returns true once the diskmaker stopped starting new device operations,
+// long device operations check it to stop early.
+func ShuttingDown() bool {
+	operations.lock.Lock()
+	defer operations.lock.Unlock()
+	return operations.shuttingDown
+}
+
+// EndDeviceOperation marks a device operation started with BeginDeviceOperation as finished.
+func EndDeviceOperation() {
+	operations.inFlight.Done()
+}
+
+// WaitForDeviceOperations stops new device operations from starting and waits up to
+// timeout for the in-flight ones to finish. It returns false if the timeout expired.
+func WaitForDeviceOperations(timeout time.Duration) bool {
+	operations.lock.Lock()
+	operations.shuttingDown = true
+	operations.lock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		operations.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
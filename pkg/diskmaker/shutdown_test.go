@@ -0,0 +1,31 @@
+package diskmaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForDeviceOperations(t *testing.T) {
+	operations = &operationTracker{}
+
+	assert.True(t, BeginDeviceOperation())
+	assert.False(t, ShuttingDown())
+	finished := make(chan struct{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		EndDeviceOperation()
+		close(finished)
+	}()
+
+	assert.True(t, WaitForDeviceOperations(time.Second))
+	<-finished
+	assert.False(t, BeginDeviceOperation(), "no operations should start after shutdown")
+	assert.True(t, ShuttingDown())
+
+	operations = &operationTracker{}
+	assert.True(t, BeginDeviceOperation())
+	assert.False(t, WaitForDeviceOperations(10*time.Millisecond), "expected timeout with an unfinished operation")
+	EndDeviceOperation()
+}
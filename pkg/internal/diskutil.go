@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -19,13 +20,24 @@ var (
 	FilePathGlob         = filepath.Glob
 	FilePathEvalSymLinks = filepath.EvalSymlinks
 	mountFile            = "/proc/1/mountinfo"
+	mdstatFile           = "/proc/mdstat"
+	udevDataDir          = "/run/udev/data"
 )
 
 const (
 	// StateSuspended is a possible value of BlockDevice.State
 	StateSuspended = "suspended"
+	// Ext4NoLazyInitOptions are the mkfs.ext4 extended options that initialize the inode tables
+	// and the journal at format time instead of after the first mount
+	Ext4NoLazyInitOptions = "lazy_itable_init=0,lazy_journal_init=0"
 	// DiskByIDDir is the path for symlinks to the device by id.
 	DiskByIDDir = "/dev/disk/by-id/"
+	// DiskByPathDir is the path for symlinks to the device by its hardware path.
+	DiskByPathDir = "/dev/disk/by-path/"
+	// DiskByUUIDDir is the path for symlinks to the device by the UUID of its filesystem.
+	DiskByUUIDDir = "/dev/disk/by-uuid/"
+	// RAIDMemberFSType is the signature blkid reports for members of software RAID arrays
+	RAIDMemberFSType = "linux_raid_member"
 )
 
 // IDPathNotFoundError indicates that a symlink to the device was not found in /dev/disk/by-id/
@@ -55,10 +67,26 @@ type BlockDevice struct {
 	PathByID   string `json:"pathByID,omitempty"`
 	Serial     string `json:"serial,omitempty"`
 	PartLabel  string `json:"partLabel,omitempty"`
+	PartUUID   string `json:"partuuid,omitempty"`
+	// Transport is the transport of the device, like nvme, sata or iscsi.
+	// Devices that lsblk reports without one, like partitions, have the transport of their parent.
+	Transport string `json:"tran,omitempty"`
+	// PKName is the KNAME of the parent device
+	PKName string `json:"pkname,omitempty"`
 }
 
 // IDPathNotFoundError indicates that a symlink to the device was not found in /dev/disk/by-id/
 
+// StableIdentity returns a value that identifies the physical device across reboots and renames:
+// the partition UUID for partitions and the serial number otherwise.
+// It is empty if the device reports neither.
+func (b BlockDevice) StableIdentity() string {
+	if b.Type == "part" && b.PartUUID != "" {
+		return b.PartUUID
+	}
+	return b.Serial
+}
+
 // GetRotational as bool
 func (b BlockDevice) GetRotational() (bool, error) {
 	v, err := parseBitBool(b.Rotational)
@@ -120,6 +148,51 @@ func (b BlockDevice) HasChildren() (bool, error) {
 	return false, nil
 }
 
+// GetHolders returns the names of the devices built on the device, such as device-mapper or md devices,
+// as listed in /sys/class/block/<kname>/holders
+func (b BlockDevice) GetHolders() ([]string, error) {
+	holdersDir := filepath.Join("/sys/class/block/", b.KName, "holders", "/*")
+	paths, err := FilePathGlob(holdersDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the holders of device %q", b.KName)
+	}
+	holders := make([]string, 0, len(paths))
+	for _, path := range paths {
+		holders = append(holders, filepath.Base(path))
+	}
+	return holders, nil
+}
+
+// IsRAIDMember checks if the device is a member of a software (mdadm) RAID array,
+// either by its blkid signature or by its listing in an active array in `/proc/mdstat`.
+func (b BlockDevice) IsRAIDMember() (bool, error) {
+	if b.FSType == RAIDMemberFSType {
+		return true, nil
+	}
+	data, err := ioutil.ReadFile(mdstatFile)
+	if os.IsNotExist(err) {
+		// md driver not loaded, there are no arrays
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to read file %s: %v", mdstatFile, err)
+	}
+
+	// array lines look like: "md127 : active raid1 sdb1[1] sda1[0](F)"
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[0], "md") || fields[1] != ":" {
+			continue
+		}
+		for _, field := range fields[2:] {
+			i := strings.Index(field, "[")
+			if i > 0 && field[:i] == b.KName {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
 // HasBindMounts checks for bind mounts and returns mount point for a device by parsing `proc/1/mountinfo`.
 // HostPID should be set to true inside the POD spec to get details of host's mount points inside `proc/1/mountinfo`.
 func (b BlockDevice) HasBindMounts() (bool, string, error) {
@@ -153,6 +226,8 @@ func (b BlockDevice) GetDevPath() (string, error) {
 }
 
 // GetPathByID check on BlockDevice
+// A device with several links in /dev/disk/by-id/ is always found by the lexicographically first one,
+// unless PathByID is already set to one of them, like the link recorded on the PV of the device.
 func (b BlockDevice) GetPathByID() (string, error) {
 
 	// return if previously populated value is valid
@@ -162,28 +237,52 @@ func (b BlockDevice) GetPathByID() (string, error) {
 			return b.PathByID, nil
 		}
 	}
-	b.PathByID = ""
-	diskByIDDir := filepath.Join(DiskByIDDir, "/*")
-	paths, err := FilePathGlob(diskByIDDir)
+	path, err := b.GetPathInDir(DiskByIDDir)
+	if err != nil {
+		return "", err
+	}
+	if path != "" {
+		return path, nil
+	}
+	devPath, err := b.GetDevPath()
 	if err != nil {
-		return "", fmt.Errorf("could not list files in %q: %w", DiskByIDDir, err)
+		return "", err
 	}
+	// return path by label and error
+	return devPath, IDPathNotFoundError{DeviceName: b.KName}
+}
+
+// GetPathByPath returns the symlink to the device in /dev/disk/by-path/,
+// or an empty string if there is none
+func (b BlockDevice) GetPathByPath() (string, error) {
+	return b.GetPathInDir(DiskByPathDir)
+}
+
+// GetPathByUUID returns the symlink to the device in /dev/disk/by-uuid/,
+// or an empty string if there is none
+func (b BlockDevice) GetPathByUUID() (string, error) {
+	return b.GetPathInDir(DiskByUUIDDir)
+}
+
+// GetPathInDir returns the lexicographically first symlink to the device in dir, such as /dev/disk/by-path/,
+// or an empty string if there is none. Devices often have several links in a directory, e.g. the wwn- and scsi-
+// links in /dev/disk/by-id/, sorting them picks the same one whatever order they are listed in.
+func (b BlockDevice) GetPathInDir(dir string) (string, error) {
+	paths, err := FilePathGlob(filepath.Join(dir, "/*"))
+	if err != nil {
+		return "", fmt.Errorf("could not list files in %q: %w", dir, err)
+	}
+	sort.Strings(paths)
 	for _, path := range paths {
 		isMatch, err := PathEvalsToDiskLabel(path, b.KName)
 		if err != nil {
 			return "", err
 		}
 		if isMatch {
-			b.PathByID = path
 			return path, nil
 		}
 	}
-	devPath, err := b.GetDevPath()
-	if err != nil {
-		return "", err
-	}
-	// return path by label and error
-	return devPath, IDPathNotFoundError{DeviceName: b.KName}
+	return "", nil
 }
 
 // PathEvalsToDiskLabel checks if the path is a symplink to a file devName
@@ -211,7 +310,7 @@ func ListBlockDevices() ([]BlockDevice, []string, error) {
 		return []BlockDevice{}, []string{}, errors.Wrap(err, "failed to list block devices")
 	}
 
-	columns := "NAME,ROTA,TYPE,SIZE,MODEL,VENDOR,RO,RM,STATE,KNAME,SERIAL,PARTLABEL"
+	columns := "NAME,ROTA,TYPE,SIZE,MODEL,VENDOR,RO,RM,STATE,KNAME,SERIAL,PARTLABEL,PARTUUID,TRAN,PKNAME"
 	args := []string{"--pairs", "-b", "-o", columns}
 	cmd := ExecCommand("lsblk", args...)
 	output, err := executeCmdWithCombinedOutput(cmd)
@@ -273,6 +372,15 @@ func ListBlockDevices() ([]BlockDevice, []string, error) {
 		return []BlockDevice{}, badRows, err
 	}
 
+	// lsblk lists parents before their children
+	transports := map[string]string{}
+	for i := range blockDevices {
+		if blockDevices[i].Transport == "" {
+			blockDevices[i].Transport = transports[blockDevices[i].PKName]
+		}
+		transports[blockDevices[i].KName] = blockDevices[i].Transport
+	}
+
 	return blockDevices, badRows, nil
 }
 
@@ -311,11 +419,166 @@ func GetDeviceFSMap() (map[string]string, error) {
 	return m, nil
 }
 
+// probeDevice probes the device with blkid and returns the tags of the signature found on it,
+// empty if the device is blank. It reads the device itself rather than blkid's cache.
+func probeDevice(device string) (map[string]string, error) {
+	cmd := ExecCommand("blkid", "-p", "-o", "export", device)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		// blkid exits with 2 when it finds no signature on the device
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 2 {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to probe %q: %v: %s", device, err, string(output))
+	}
+	values := map[string]string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(parts) == 2 {
+			values[parts[0]] = unescapeBlkidValue(parts[1])
+		}
+	}
+	return values, nil
+}
+
+// unescapeBlkidValue removes the backslashes blkid -o export escapes the spaces and special characters of the values with
+func unescapeBlkidValue(value string) string {
+	var unescaped strings.Builder
+	escaped := false
+	for _, c := range value {
+		if c == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		unescaped.WriteRune(c)
+	}
+	return unescaped.String()
+}
+
+// GetDeviceSignature probes the device with blkid and returns the type of the filesystem
+// or of the partition table found on it, empty if the device is blank.
+// Unlike GetDeviceFSMap it reads the device itself rather than blkid's cache.
+func GetDeviceSignature(device string) (string, error) {
+	values, err := probeDevice(device)
+	if err != nil {
+		return "", err
+	}
+	if values["TYPE"] != "" {
+		return values["TYPE"], nil
+	}
+	return values["PTTYPE"], nil
+}
+
+// GetFilesystemLabel probes the device with blkid and returns the label of its filesystem,
+// empty if the device has no filesystem or a filesystem without a label
+func GetFilesystemLabel(device string) (string, error) {
+	values, err := probeDevice(device)
+	if err != nil {
+		return "", err
+	}
+	if values["USAGE"] != "filesystem" {
+		return "", nil
+	}
+	return values["LABEL"], nil
+}
+
+// DeviceNumber returns the major:minor number of the device file, as listed in /proc/1/mountinfo
+func DeviceNumber(device string) (string, error) {
+	stat := unix.Stat_t{}
+	err := unix.Stat(device, &stat)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat %q: %w", device, err)
+	}
+	if stat.Mode&unix.S_IFMT != unix.S_IFBLK {
+		return "", fmt.Errorf("%q is not a block device", device)
+	}
+	return fmt.Sprintf("%d:%d", unix.Major(uint64(stat.Rdev)), unix.Minor(uint64(stat.Rdev))), nil
+}
+
+// MountPoints returns the mount points of the filesystem of the device with the major:minor number in the host's
+// mount namespace, by parsing /proc/1/mountinfo. HostPID needs to be set in the pod spec.
+func MountPoints(deviceNumber string) ([]string, error) {
+	data, err := ioutil.ReadFile(mountFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %v", mountFile, err)
+	}
+	mountPoints := []string{}
+	for _, mountInfo := range strings.Split(string(data), "\n") {
+		// the device number is the 3rd field and the mount point the 5th
+		fields := strings.Split(mountInfo, " ")
+		if len(fields) >= 5 && fields[2] == deviceNumber {
+			mountPoints = append(mountPoints, fields[4])
+		}
+	}
+	return mountPoints, nil
+}
+
+// UdevTags returns the udev tags of the block device with the major:minor number, from the udev database
+// of the host. A device that udev didn't record has no tags.
+func UdevTags(deviceNumber string) ([]string, error) {
+	filename := filepath.Join(udevDataDir, "b"+deviceNumber)
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %v", filename, err)
+	}
+	tags := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		// tags are recorded as G:<tag>, the other lines are properties and links
+		if strings.HasPrefix(line, "G:") {
+			tags = append(tags, strings.TrimPrefix(line, "G:"))
+		}
+	}
+	return tags, nil
+}
+
+// SupportsDiscard returns true if the device discards blocks, which fstrim needs
+func SupportsDiscard(device string) (bool, error) {
+	cmd := ExecCommand("lsblk", "--bytes", "--nodeps", "--noheadings", "--output", "DISC-MAX", device)
+	output, err := executeCmdWithCombinedOutput(cmd)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the discard limit of %q: %w", device, err)
+	}
+	discardMax, err := strconv.ParseInt(output, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse the discard limit %q of %q: %w", output, device, err)
+	}
+	return discardMax > 0, nil
+}
+
+// TrimFilesystem runs fstrim on the mount point in the host's mount namespace,
+// where kubelet mounts the filesystems of the PVs. It returns the output of fstrim, with the trimmed bytes.
+func TrimFilesystem(mountPoint string) (string, error) {
+	cmd := ExecCommand("nsenter", "--target", "1", "--mount", "--", "fstrim", "--verbose", mountPoint)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to trim %q: %v: %s", mountPoint, err, string(output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// MakeExt4FS formats the device with ext4 like kubelet does, with the extended options of mkfs.ext4 if set
+func MakeExt4FS(device string, extendedOptions string) error {
+	args := []string{"-F", "-m0"}
+	if extendedOptions != "" {
+		args = append(args, "-E", extendedOptions)
+	}
+	args = append(args, device)
+	cmd := ExecCommand("mkfs.ext4", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to format %q: %v: %s", device, err, string(output))
+	}
+	return nil
+}
+
 // GetPVCreationLock checks whether a PV can be created based on this device
 // and Locks the device so that no PVs can be created on it while the lock is held.
 // the PV lock will fail if:
 // - another process holds an exclusive file lock on the device (using the syscall flock)
-// - a symlink to this device exists in symlinkDirs
+// - a symlink to this device, or a bind mount target of it, exists in symlinkDirs
 // returns:
 // ExclusiveFileLock, must be unlocked regardless of success
 // bool determines if flock was placed on device.
@@ -329,6 +592,20 @@ func GetPVCreationLock(device string, symlinkDirs ...string) (ExclusiveFileLock,
 		return lock, locked, []string{}, err
 	}
 	existingLinkPaths, symErr := GetMatchingSymlinksInDirs(device, symlinkDirs...)
+	if symErr == nil {
+		// bind mount targets don't resolve to the device once a reboot unmounted them
+		var targets []string
+		targets, symErr = GetMatchingBindMountTargetsInDirs(device, symlinkDirs...)
+		found := map[string]bool{}
+		for _, link := range existingLinkPaths {
+			found[link] = true
+		}
+		for _, target := range targets {
+			if !found[target] {
+				existingLinkPaths = append(existingLinkPaths, target)
+			}
+		}
+	}
 	// If symErr is not nil, there was an error fetching the symlinks
 	if symErr != nil {
 		return lock, locked, existingLinkPaths, symErr
@@ -342,9 +619,12 @@ func GetPVCreationLock(device string, symlinkDirs ...string) (ExclusiveFileLock,
 }
 
 // GetMatchingSymlinksInDirs returns all the files in dir that are the same file as path after evaluating symlinks
-// it works using `find -L dir1 dir2 dirn -samefile path`
+// it works using `find -L dir1 dir2 dirn -samefile path`, each path is passed as its own argument
+// so that paths with spaces or other special characters are not split
 func GetMatchingSymlinksInDirs(path string, dirs ...string) ([]string, error) {
-	cmd := exec.Command("find", "-L", strings.Join(dirs, " "), "-samefile", path)
+	args := append([]string{"-L"}, dirs...)
+	args = append(args, "-samefile", path)
+	cmd := ExecCommand("find", args...)
 	output, err := executeCmdWithCombinedOutput(cmd)
 	if err != nil {
 		return []string{}, fmt.Errorf("failed to get symlinks in directories: %q for device path %q. %v", dirs, path, err)
@@ -364,6 +644,213 @@ func GetMatchingSymlinksInDirs(path string, dirs ...string) ([]string, error) {
 	return links, nil
 }
 
+// GetMatchingBindMountTargetsInDirs returns the files in the subdirectories of dirs that the device at path is
+// bind-mounted on, and the empty files named after the device or one of its links, the targets that a reboot
+// left unmounted.
+func GetMatchingBindMountTargetsInDirs(path string, dirs ...string) ([]string, error) {
+	devicePath, err := FilePathEvalSymLinks(path)
+	if err != nil {
+		return []string{}, fmt.Errorf("could not eval symlink %q: %w", path, err)
+	}
+	kname := filepath.Base(devicePath)
+	names := map[string]bool{kname: true, filepath.Base(path): true}
+	for _, linkDir := range []string{DiskByIDDir, DiskByPathDir, DiskByUUIDDir} {
+		links, err := FilePathGlob(filepath.Join(linkDir, "*"))
+		if err != nil {
+			return []string{}, fmt.Errorf("could not list files in %q: %w", linkDir, err)
+		}
+		for _, link := range links {
+			if isMatch, err := PathEvalsToDiskLabel(link, kname); err == nil && isMatch {
+				names[filepath.Base(link)] = true
+			}
+		}
+	}
+
+	data, err := ioutil.ReadFile(mountFile)
+	if err != nil {
+		return []string{}, fmt.Errorf("failed to read file %s: %v", mountFile, err)
+	}
+	bindMounted := map[string]bool{}
+	for _, mountInfo := range strings.Split(string(data), "\n") {
+		// the root of a bind mount of the device is the 4th field and the mount point the 5th
+		fields := strings.Split(mountInfo, " ")
+		if len(fields) >= 5 && fields[3] == fmt.Sprintf("/%s", kname) {
+			bindMounted[fields[4]] = true
+		}
+	}
+
+	targets := []string{}
+	for _, dir := range dirs {
+		files, err := FilePathGlob(filepath.Join(dir, "*", "*"))
+		if err != nil {
+			return []string{}, fmt.Errorf("could not list files in %q: %w", dir, err)
+		}
+		for _, file := range files {
+			if bindMounted[file] {
+				targets = append(targets, file)
+				continue
+			}
+			if !names[filepath.Base(file)] {
+				continue
+			}
+			info, err := os.Lstat(file)
+			if err == nil && info.Mode().IsRegular() && info.Size() == 0 {
+				targets = append(targets, file)
+			}
+		}
+	}
+	return targets, nil
+}
+
+// IsMountPoint returns true if path is a mount point in the host's mount namespace, by parsing /proc/1/mountinfo.
+func IsMountPoint(path string) (bool, error) {
+	data, err := ioutil.ReadFile(mountFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read file %s: %v", mountFile, err)
+	}
+	for _, mountInfo := range strings.Split(string(data), "\n") {
+		// the mount point is the 5th field
+		fields := strings.Split(mountInfo, " ")
+		if len(fields) >= 5 && fields[4] == path {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// BindMountDevice bind-mounts the device at source onto target, creating an empty target file if needed.
+// It is used instead of a symlink for container runtimes that can't resolve symlinked volume paths.
+// An existing target, left unmounted by a reboot, is mounted again.
+func BindMountDevice(source, target string) error {
+	created := false
+	file, err := os.OpenFile(target, os.O_RDONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		info, err := os.Lstat(target)
+		if err != nil {
+			return fmt.Errorf("failed to check bind mount target %q: %w", target, err)
+		} else if !info.Mode().IsRegular() {
+			return fmt.Errorf("bind mount target %q is not a regular file", target)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to create bind mount target %q: %w", target, err)
+	} else {
+		file.Close()
+		created = true
+	}
+
+	cmd := ExecCommand("mount", "--bind", source, target)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if created {
+			os.Remove(target)
+		}
+		return fmt.Errorf("failed to bind mount %q to %q: %v: %s", source, target, err, string(output))
+	}
+	return nil
+}
+
+// RemoveBindMountTarget unmounts the device that BindMountDevice bind-mounted on target, if it is still mounted,
+// and removes the target file. It returns false and leaves target in place if it is a symlink, a directory
+// or a file that isn't a bind mount target.
+func RemoveBindMountTarget(target string) (bool, error) {
+	info, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to check bind mount target %q: %w", target, err)
+	}
+	if info.Mode()&(os.ModeSymlink|os.ModeDir) != 0 {
+		return false, nil
+	}
+	mounted, err := IsMountPoint(target)
+	if err != nil {
+		return false, err
+	}
+	if mounted {
+		err = Unmount(target)
+		if err != nil {
+			return false, err
+		}
+	} else if !info.Mode().IsRegular() || info.Size() != 0 {
+		return false, nil
+	}
+	err = os.Remove(target)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to remove bind mount target %q: %w", target, err)
+	}
+	return true, nil
+}
+
+// BindMountDirectory bind-mounts the directory at source onto target, creating both directories if needed.
+func BindMountDirectory(source, target string) error {
+	for _, dir := range []string{source, target} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory %q: %w", dir, err)
+		}
+	}
+
+	cmd := ExecCommand("mount", "--bind", source, target)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to bind mount %q to %q: %v: %s", source, target, err, string(output))
+	}
+	return nil
+}
+
+// FilesystemSize returns the size in bytes of the filesystem that path is on
+func FilesystemSize(path string) (int64, error) {
+	stat := unix.Statfs_t{}
+	err := unix.Statfs(path, &stat)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the filesystem size of %q: %w", path, err)
+	}
+	return int64(stat.Blocks) * int64(stat.Bsize), nil
+}
+
+// AttachLoopDevice attaches the file to a free loop device and returns the path of the device
+func AttachLoopDevice(file string) (string, error) {
+	cmd := ExecCommand("losetup", "--find", "--show", file)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to attach %q to a loop device: %v: %s", file, err, string(output))
+	}
+	device := strings.TrimSpace(string(output))
+	if device == "" {
+		return "", fmt.Errorf("losetup did not return the loop device of %q", file)
+	}
+	return device, nil
+}
+
+// DetachLoopDevice detaches the file of the loop device
+func DetachLoopDevice(device string) error {
+	cmd := ExecCommand("losetup", "--detach", device)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to detach loop device %q: %v: %s", device, err, string(output))
+	}
+	return nil
+}
+
+// MountDevice mounts the filesystem of the device on the target directory
+func MountDevice(device, target string) error {
+	cmd := ExecCommand("mount", device, target)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to mount %q on %q: %v: %s", device, target, err, string(output))
+	}
+	return nil
+}
+
+// Unmount unmounts the target
+func Unmount(target string) error {
+	cmd := ExecCommand("umount", target)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to unmount %q: %v: %s", target, err, string(output))
+	}
+	return nil
+}
+
 type ExclusiveFileLock struct {
 	Path   string
 	locked bool
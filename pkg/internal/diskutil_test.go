@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -155,6 +156,26 @@ func TestListBlockDevices(t *testing.T) {
 				},
 			},
 		},
+		{
+			label: "Case 5: devices with different transports",
+			lsblkOutput: `NAME="sda" KNAME="sda" TYPE="disk" SIZE="62914560000" MODEL="SSD" TRAN="sata" PKNAME=""
+NAME="sda1" KNAME="sda1" TYPE="part" SIZE="62913494528" MODEL="" TRAN="" PKNAME="sda"
+NAME="nvme0n1" KNAME="nvme0n1" TYPE="disk" SIZE="1000204886016" MODEL="NVMe Fabric" TRAN="nvme" PKNAME=""
+NAME="sdb" KNAME="sdb" TYPE="disk" SIZE="107374182400" MODEL="LIO-ORG" TRAN="iscsi" PKNAME=""
+NAME="vg-lv" KNAME="dm-0" TYPE="lvm" SIZE="107374182400" MODEL="" TRAN="" PKNAME="sdb"
+NAME="loop0" KNAME="loop0" TYPE="loop" SIZE="1073741824" MODEL="" TRAN="" PKNAME=""
+`,
+			totalBlockDevices: 6,
+			totalBadRows:      0,
+			expected: []BlockDevice{
+				{Name: "sda", Type: "disk", Size: "62914560000", Model: "SSD", Transport: "sata"},
+				{Name: "sda1", Type: "part", Size: "62913494528", Transport: "sata"},
+				{Name: "nvme0n1", Type: "disk", Size: "1000204886016", Model: "NVMe Fabric", Transport: "nvme"},
+				{Name: "sdb", Type: "disk", Size: "107374182400", Model: "LIO-ORG", Transport: "iscsi"},
+				{Name: "vg-lv", Type: "lvm", Size: "107374182400", Transport: "iscsi"},
+				{Name: "loop0", Type: "loop", Size: "1073741824"},
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -177,6 +198,7 @@ func TestListBlockDevices(t *testing.T) {
 			assert.Equalf(t, tc.expected[i].Rotational, blockDevices[i].Rotational, "[%q: Device: %d]: invalid block device rotational property", tc.label, i+1)
 			assert.Equalf(t, tc.expected[i].ReadOnly, blockDevices[i].ReadOnly, "[%q: Device: %d]: invalid block device read only value", tc.label, i+1)
 			assert.Equalf(t, tc.expected[i].PartLabel, blockDevices[i].PartLabel, "[%q: Device: %d]: invalid block device PartLabel value", tc.label, i+1)
+			assert.Equalf(t, tc.expected[i].Transport, blockDevices[i].Transport, "[%q: Device: %d]: invalid block device transport", tc.label, i+1)
 		}
 	}
 
@@ -314,6 +336,95 @@ func TestHasBindMounts(t *testing.T) {
 	}
 }
 
+func TestIsRAIDMember(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "discovery")
+	if err != nil {
+		t.Fatalf("error creating temp directory : %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	mdstat := `Personalities : [raid1]
+md127 : active raid1 sdb1[1] sda1[0](F)
+      1046528 blocks super 1.2 [2/2] [UU]
+
+unused devices: <none>
+`
+	testcases := []struct {
+		label       string
+		blockDevice BlockDevice
+		mdstat      string
+		expected    bool
+	}{
+		{
+			label:       "Case 1: partition listed in an array",
+			blockDevice: BlockDevice{KName: "sdb1"},
+			mdstat:      mdstat,
+			expected:    true,
+		},
+		{
+			label:       "Case 2: failed member listed in an array",
+			blockDevice: BlockDevice{KName: "sda1"},
+			mdstat:      mdstat,
+			expected:    true,
+		},
+		{
+			label:       "Case 3: parent disk of a member",
+			blockDevice: BlockDevice{KName: "sdb"},
+			mdstat:      mdstat,
+			expected:    false,
+		},
+		{
+			label:       "Case 4: raid member signature",
+			blockDevice: BlockDevice{KName: "sdc", FSType: RAIDMemberFSType},
+			mdstat:      "",
+			expected:    true,
+		},
+		{
+			label:       "Case 5: no arrays",
+			blockDevice: BlockDevice{KName: "sdc"},
+			mdstat:      "Personalities :\nunused devices: <none>\n",
+			expected:    false,
+		},
+	}
+
+	for _, tc := range testcases {
+		filename := filepath.Join(tempDir, "mdstat")
+		err = ioutil.WriteFile(filename, []byte(tc.mdstat), 0755)
+		if err != nil {
+			t.Fatalf("error writing mdstat to file : %v", err)
+		}
+		mdstatFile = filename
+		actual, err := tc.blockDevice.IsRAIDMember()
+		assert.NoError(t, err)
+		assert.Equalf(t, tc.expected, actual, "[%s]: failed to check raid membership", tc.label)
+	}
+
+	// missing /proc/mdstat means the md driver is not loaded
+	mdstatFile = filepath.Join(tempDir, "missing")
+	actual, err := BlockDevice{KName: "sdc"}.IsRAIDMember()
+	assert.NoError(t, err)
+	assert.False(t, actual)
+	mdstatFile = "/proc/mdstat"
+}
+
+func TestGetHolders(t *testing.T) {
+	defer func() { FilePathGlob = filepath.Glob }()
+	FilePathGlob = func(pattern string) ([]string, error) {
+		assert.Equal(t, "/sys/class/block/sdb/holders/*", pattern)
+		return []string{"/sys/class/block/sdb/holders/dm-0", "/sys/class/block/sdb/holders/dm-1"}, nil
+	}
+	holders, err := BlockDevice{Name: "sdb", KName: "sdb"}.GetHolders()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dm-0", "dm-1"}, holders)
+
+	FilePathGlob = func(pattern string) ([]string, error) {
+		return []string{}, nil
+	}
+	holders, err = BlockDevice{Name: "sdc", KName: "sdc"}.GetHolders()
+	assert.NoError(t, err)
+	assert.Empty(t, holders)
+}
+
 func TestHasChildrenFail(t *testing.T) {
 	testcases := []struct {
 		label        string
@@ -388,6 +499,85 @@ func TestGetPathByID(t *testing.T) {
 	}
 }
 
+func TestGetPathByIDSeveralAliases(t *testing.T) {
+	defer func() {
+		FilePathGlob = filepath.Glob
+		FilePathEvalSymLinks = filepath.EvalSymlinks
+	}()
+	FilePathGlob = func(name string) ([]string, error) {
+		return []string{
+			"/dev/disk/by-id/wwn-0x5000c500a0b1c2d3",
+			"/dev/disk/by-id/scsi-35000c500a0b1c2d3",
+			"/dev/disk/by-id/ata-ST4000NM0035_ZC1B2C3D",
+			"/dev/disk/by-id/ata-ST4000NM0035_ZC1B2C3E",
+		}, nil
+	}
+	FilePathEvalSymLinks = func(path string) (string, error) {
+		if strings.HasSuffix(path, "ZC1B2C3E") {
+			return "/dev/sdc", nil
+		}
+		return "/dev/sdb", nil
+	}
+
+	actual, err := BlockDevice{Name: "sdb", KName: "sdb"}.GetPathByID()
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/disk/by-id/ata-ST4000NM0035_ZC1B2C3D", actual, "the lexicographically first alias of the device")
+
+	actual, err = BlockDevice{Name: "sdb", KName: "sdb", PathByID: "/dev/disk/by-id/wwn-0x5000c500a0b1c2d3"}.GetPathByID()
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/disk/by-id/wwn-0x5000c500a0b1c2d3", actual, "an alias that was already chosen is kept")
+
+	actual, err = BlockDevice{Name: "sdb", KName: "sdb", PathByID: "/dev/disk/by-id/ata-ST4000NM0035_ZC1B2C3E"}.GetPathByID()
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/disk/by-id/ata-ST4000NM0035_ZC1B2C3D", actual, "an alias of another device is replaced")
+}
+
+func TestGetPathByPath(t *testing.T) {
+	defer func() {
+		FilePathGlob = filepath.Glob
+		FilePathEvalSymLinks = filepath.EvalSymlinks
+	}()
+	FilePathGlob = func(name string) ([]string, error) {
+		return []string{"/dev/disk/by-path/pci-0000:00:1f.2-ata-1", "/dev/disk/by-path/pci-0000:00:1f.2-ata-2"}, nil
+	}
+	FilePathEvalSymLinks = func(path string) (string, error) {
+		if strings.HasSuffix(path, "ata-2") {
+			return "/dev/sdb", nil
+		}
+		return "/dev/sda", nil
+	}
+
+	actual, err := BlockDevice{Name: "sdb", KName: "sdb"}.GetPathByPath()
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/disk/by-path/pci-0000:00:1f.2-ata-2", actual)
+
+	actual, err = BlockDevice{Name: "sdc", KName: "sdc"}.GetPathByPath()
+	assert.NoError(t, err)
+	assert.Empty(t, actual, "a device without a by-path link")
+}
+
+func TestGetPathByUUID(t *testing.T) {
+	defer func() {
+		FilePathGlob = filepath.Glob
+		FilePathEvalSymLinks = filepath.EvalSymlinks
+	}()
+	FilePathGlob = func(name string) ([]string, error) {
+		assert.Equal(t, "/dev/disk/by-uuid/*", name)
+		return []string{"/dev/disk/by-uuid/0b4cbd2b-3b4e-4f4c-9d0a-7c1e6f1f2a3b"}, nil
+	}
+	FilePathEvalSymLinks = func(path string) (string, error) {
+		return "/dev/sdb1", nil
+	}
+
+	actual, err := BlockDevice{Name: "sdb1", KName: "sdb1"}.GetPathByUUID()
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/disk/by-uuid/0b4cbd2b-3b4e-4f4c-9d0a-7c1e6f1f2a3b", actual)
+
+	actual, err = BlockDevice{Name: "sdc", KName: "sdc"}.GetPathByUUID()
+	assert.NoError(t, err)
+	assert.Empty(t, actual, "a device without a filesystem")
+}
+
 func TestGetPathByIDFail(t *testing.T) {
 	testcases := []struct {
 		label               string
@@ -626,3 +816,264 @@ func TestGetRotationalFail(t *testing.T) {
 	}
 
 }
+
+func TestGetDeviceSignature(t *testing.T) {
+	testcases := []struct {
+		label       string
+		blkidOutput string
+		expected    string
+	}{
+		{
+			label:       "filesystem",
+			blkidOutput: "DEVNAME=/dev/sdb\nUUID=4f2b8a5e\nVERSION=1.0\nTYPE=ext4\nUSAGE=filesystem\n",
+			expected:    "ext4",
+		},
+		{
+			label:       "partition table",
+			blkidOutput: "DEVNAME=/dev/sdb\nPTUUID=1b2c3d4e\nPTTYPE=gpt\n",
+			expected:    "gpt",
+		},
+		{
+			label:       "no signature",
+			blkidOutput: "",
+			expected:    "",
+		},
+	}
+
+	ExecCommand = helperCommand
+	defer func() {
+		ExecCommand = exec.Command
+	}()
+	for _, tc := range testcases {
+		blkidOut = tc.blkidOutput
+		signature, err := GetDeviceSignature("/dev/sdb")
+		assert.NoErrorf(t, err, "[%s]", tc.label)
+		assert.Equalf(t, tc.expected, signature, "[%s]", tc.label)
+	}
+}
+
+func TestGetFilesystemLabel(t *testing.T) {
+	testcases := []struct {
+		label       string
+		blkidOutput string
+		expected    string
+	}{
+		{
+			label:       "filesystem with a label",
+			blkidOutput: "DEVNAME=/dev/sdb\nLABEL=pg-data-01\nUUID=4f2b8a5e\nTYPE=xfs\nUSAGE=filesystem\n",
+			expected:    "pg-data-01",
+		},
+		{
+			label:       "escaped label",
+			blkidOutput: "DEVNAME=/dev/sdb\nLABEL=pg\\ data\nUUID=4f2b8a5e\nTYPE=ext4\nUSAGE=filesystem\n",
+			expected:    "pg data",
+		},
+		{
+			label:       "filesystem without a label",
+			blkidOutput: "DEVNAME=/dev/sdb\nUUID=4f2b8a5e\nTYPE=ext4\nUSAGE=filesystem\n",
+			expected:    "",
+		},
+		{
+			label:       "not a filesystem",
+			blkidOutput: "DEVNAME=/dev/sdb\nLABEL=host:0\nUUID=1b2c3d4e\nTYPE=linux_raid_member\nUSAGE=raid\n",
+			expected:    "",
+		},
+		{
+			label:       "no signature",
+			blkidOutput: "",
+			expected:    "",
+		},
+	}
+
+	ExecCommand = helperCommand
+	defer func() {
+		ExecCommand = exec.Command
+	}()
+	for _, tc := range testcases {
+		blkidOut = tc.blkidOutput
+		label, err := GetFilesystemLabel("/dev/sdb")
+		assert.NoErrorf(t, err, "[%s]", tc.label)
+		assert.Equalf(t, tc.expected, label, "[%s]", tc.label)
+	}
+}
+
+func TestGetMatchingSymlinksInDirsWithSpaces(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "symlinks")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	// a device path and symlink dirs with spaces and colons, like some by-id links
+	device := filepath.Join(tmpDir, "by-id", "usb-Generic Flash Disk:0")
+	dirA := filepath.Join(tmpDir, "local storage", "sc a")
+	dirB := filepath.Join(tmpDir, "local storage", "sc b")
+	for _, dir := range []string{filepath.Dir(device), dirA, dirB} {
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	assert.NoError(t, ioutil.WriteFile(device, []byte{}, 0644))
+	link := filepath.Join(dirB, "usb-Generic Flash Disk:0")
+	assert.NoError(t, os.Symlink(device, link))
+
+	links, err := GetMatchingSymlinksInDirs(device, dirA, dirB)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{link}, links)
+}
+
+func TestGetMatchingBindMountTargetsInDirs(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "bind-mounts")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	symlinkDir := filepath.Join(tmpDir, "local-storage")
+	mounted := filepath.Join(symlinkDir, "sc-a", "wwn-0x5000c500a0b1c2d3")
+	unmounted := filepath.Join(symlinkDir, "sc-b", "pci-0000:00:1f.2-ata-1")
+	otherDevice := filepath.Join(symlinkDir, "sc-b", "sdd")
+	notEmpty := filepath.Join(symlinkDir, "sc-c", "sdc")
+	for _, file := range []string{mounted, unmounted, otherDevice, notEmpty} {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		assert.NoError(t, ioutil.WriteFile(file, []byte{}, 0600))
+	}
+	assert.NoError(t, ioutil.WriteFile(notEmpty, []byte("data"), 0600))
+
+	mountInfo := fmt.Sprintf("5595 121 0:6 /sdc %s rw shared:23 - devtmpfs devtmpfs rw,seclabel", mounted)
+	filename := filepath.Join(tmpDir, "mountfile")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(mountInfo), 0755))
+	oldMountFile := mountFile
+	mountFile = filename
+	defer func() { mountFile = oldMountFile }()
+
+	FilePathGlob = func(pattern string) ([]string, error) {
+		switch pattern {
+		case filepath.Join(DiskByIDDir, "*"):
+			return []string{DiskByIDDir + "wwn-0x5000c500a0b1c2d3"}, nil
+		case filepath.Join(DiskByPathDir, "*"):
+			return []string{DiskByPathDir + "pci-0000:00:1f.2-ata-1", DiskByPathDir + "pci-0000:00:1f.2-ata-2"}, nil
+		case filepath.Join(DiskByUUIDDir, "*"):
+			return []string{}, nil
+		}
+		return filepath.Glob(pattern)
+	}
+	FilePathEvalSymLinks = func(path string) (string, error) {
+		if path == DiskByPathDir+"pci-0000:00:1f.2-ata-2" {
+			return "/dev/sdd", nil
+		}
+		return "/dev/sdc", nil
+	}
+	defer func() {
+		FilePathGlob = filepath.Glob
+		FilePathEvalSymLinks = filepath.EvalSymlinks
+	}()
+
+	targets, err := GetMatchingBindMountTargetsInDirs(DiskByIDDir+"wwn-0x5000c500a0b1c2d3", symlinkDir)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{mounted, unmounted}, targets)
+}
+
+func TestRemoveBindMountTarget(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "bind-mounts")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	mounted := filepath.Join(tmpDir, "mounted")
+	unmounted := filepath.Join(tmpDir, "unmounted")
+	notEmpty := filepath.Join(tmpDir, "not-empty")
+	symlink := filepath.Join(tmpDir, "symlink")
+	directory := filepath.Join(tmpDir, "vol0")
+	for _, file := range []string{mounted, unmounted} {
+		assert.NoError(t, ioutil.WriteFile(file, []byte{}, 0600))
+	}
+	assert.NoError(t, ioutil.WriteFile(notEmpty, []byte("data"), 0600))
+	assert.NoError(t, os.Symlink("/dev/sdc", symlink))
+	assert.NoError(t, os.Mkdir(directory, 0755))
+
+	mountInfo := fmt.Sprintf("5595 121 0:6 /sdc %s rw shared:23 - devtmpfs devtmpfs rw,seclabel", mounted)
+	filename := filepath.Join(tmpDir, "mountfile")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(mountInfo), 0755))
+	oldMountFile := mountFile
+	mountFile = filename
+	defer func() { mountFile = oldMountFile }()
+	ExecCommand = helperCommand
+	defer func() { ExecCommand = exec.Command }()
+
+	for _, tc := range []struct {
+		target          string
+		expectedRemoved bool
+	}{
+		{target: mounted, expectedRemoved: true},
+		{target: unmounted, expectedRemoved: true},
+		{target: notEmpty},
+		{target: symlink},
+		{target: directory},
+	} {
+		removed, err := RemoveBindMountTarget(tc.target)
+		assert.NoErrorf(t, err, "[%s]", tc.target)
+		assert.Equalf(t, tc.expectedRemoved, removed, "[%s]", tc.target)
+		_, err = os.Lstat(tc.target)
+		assert.Equalf(t, tc.expectedRemoved, os.IsNotExist(err), "[%s] the target should only be removed if it is a bind mount target", tc.target)
+	}
+	removed, err := RemoveBindMountTarget(filepath.Join(tmpDir, "missing"))
+	assert.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestMountPoints(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "discovery")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	mountInfo := `121 98 8:16 / /var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/local-pv-343bdd9 rw,relatime shared:65 - ext4 /dev/sdb rw,seclabel
+122 98 8:16 / /var/lib/kubelet/pods/6d9d33ae/volumes/kubernetes.io~local-volume/local-pv-343bdd9 rw,relatime shared:65 - ext4 /dev/sdb rw,seclabel
+123 98 8:32 / /var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/local-pv-8a1c2e4 rw,relatime shared:66 - xfs /dev/sdc rw,seclabel`
+	filename := filepath.Join(tempDir, "mountfile")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(mountInfo), 0755))
+	oldMountFile := mountFile
+	mountFile = filename
+	defer func() { mountFile = oldMountFile }()
+
+	mountPoints, err := MountPoints("8:16")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"/var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/local-pv-343bdd9",
+		"/var/lib/kubelet/pods/6d9d33ae/volumes/kubernetes.io~local-volume/local-pv-343bdd9",
+	}, mountPoints)
+	mountPoints, err = MountPoints("8:48")
+	assert.NoError(t, err)
+	assert.Empty(t, mountPoints, "the device isn't mounted")
+}
+
+func TestUdevTags(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "udev")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	udevData := `S:disk/by-id/wwn-0x5000c500a0b1c2d3
+I:1534568
+E:ID_SERIAL=ST4000NM0035
+G:systemd
+G:fast-disks
+`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "b8:16"), []byte(udevData), 0644))
+	oldUdevDataDir := udevDataDir
+	udevDataDir = tempDir
+	defer func() { udevDataDir = oldUdevDataDir }()
+
+	tags, err := UdevTags("8:16")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"systemd", "fast-disks"}, tags)
+	tags, err = UdevTags("8:32")
+	assert.NoError(t, err)
+	assert.Empty(t, tags, "udev didn't record the device")
+}
+
+func TestSupportsDiscard(t *testing.T) {
+	ExecCommand = helperCommand
+	defer func() {
+		ExecCommand = exec.Command
+	}()
+	for output, expected := range map[string]bool{"2147450880\n": true, "0\n": false} {
+		lsblkOut = output
+		supported, err := SupportsDiscard("/dev/sdb")
+		assert.NoError(t, err)
+		assert.Equalf(t, expected, supported, "DISC-MAX %q", output)
+	}
+	lsblkOut = "unknown"
+	_, err := SupportsDiscard("/dev/sdb")
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	sysDevBlockDir   = "/sys/dev/block"
+	sysClassBlockDir = "/sys/class/block"
+)
+
+// isRootMountPoint returns true for the mount points of the operating system of the host:
+// the root and boot filesystems, and /sysroot where RHCOS mounts the physical root
+func isRootMountPoint(mountPoint string) bool {
+	return mountPoint == "/" || mountPoint == "/sysroot" || mountPoint == "/boot" || strings.HasPrefix(mountPoint, "/boot/")
+}
+
+// RootDevices returns the kernel names of the devices that back the root and boot filesystems of the host:
+// the devices of the filesystems, the devices these are built on, like the partitions of an LVM volume or
+// a RAID array, and the disks of all these partitions. It parses /proc/1/mountinfo, so HostPID needs to be
+// set in the pod spec.
+func RootDevices() ([]string, error) {
+	data, err := ioutil.ReadFile(mountFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %v", mountFile, err)
+	}
+	devices := map[string]bool{}
+	for _, mountInfo := range strings.Split(string(data), "\n") {
+		// the device number is the 3rd field and the mount point the 5th
+		fields := strings.Split(mountInfo, " ")
+		if len(fields) < 5 || !isRootMountPoint(fields[4]) {
+			continue
+		}
+		deviceNumber := fields[2]
+		// filesystems like btrfs have an anonymous device number, their device is the mount source
+		if strings.HasPrefix(deviceNumber, "0:") {
+			source := mountSource(fields)
+			if !strings.HasPrefix(source, "/dev/") {
+				// not backed by a device, like an overlay or a tmpfs
+				continue
+			}
+			deviceNumber, err = DeviceNumber(source)
+			if err != nil {
+				return nil, err
+			}
+		}
+		devicePath, err := filepath.EvalSymlinks(filepath.Join(sysDevBlockDir, deviceNumber))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the device of the filesystem mounted on %s: %w", fields[4], err)
+		}
+		err = addBackingDevices(filepath.Base(devicePath), devices)
+		if err != nil {
+			return nil, err
+		}
+	}
+	names := make([]string, 0, len(devices))
+	for name := range devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// mountSource returns the mount source of a line of mountinfo, the field after the filesystem type
+// that follows the "-" separator of the optional fields
+func mountSource(fields []string) string {
+	for i, field := range fields {
+		if field == "-" && i+2 < len(fields) {
+			return fields[i+2]
+		}
+	}
+	return ""
+}
+
+// addBackingDevices adds the device to devices, with the disk of a partition and the devices that the device is
+// built on, as listed in its slaves directory
+func addBackingDevices(kname string, devices map[string]bool) error {
+	if devices[kname] {
+		return nil
+	}
+	devices[kname] = true
+	deviceDir := filepath.Join(sysClassBlockDir, kname)
+	// the sysfs directory of a partition is in the directory of its disk
+	_, err := os.Stat(filepath.Join(deviceDir, "partition"))
+	if err == nil {
+		partitionDir, err := filepath.EvalSymlinks(deviceDir)
+		if err != nil {
+			return fmt.Errorf("failed to find the disk of partition %s: %w", kname, err)
+		}
+		err = addBackingDevices(filepath.Base(filepath.Dir(partitionDir)), devices)
+		if err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to check if %s is a partition: %w", kname, err)
+	}
+	slaves, err := filepath.Glob(filepath.Join(deviceDir, "slaves", "*"))
+	if err != nil {
+		return fmt.Errorf("failed to list the devices under %s: %w", kname, err)
+	}
+	for _, slave := range slaves {
+		err = addBackingDevices(filepath.Base(slave), devices)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRootDevices(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "root-devices")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	// a fake sysfs: sda1 is /boot, / is the LVM volume dm-0 on sda2, and sdb1 holds a PV
+	devicesDir := filepath.Join(tempDir, "devices")
+	for _, dir := range []string{"sda/sda1", "sda/sda2", "sdb/sdb1", "dm-0/slaves"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(devicesDir, dir), 0755))
+	}
+	for _, partition := range []string{"sda/sda1", "sda/sda2", "sdb/sdb1"} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(devicesDir, partition, "partition"), []byte("1"), 0644))
+	}
+	assert.NoError(t, os.Symlink(filepath.Join(devicesDir, "sda/sda2"), filepath.Join(devicesDir, "dm-0/slaves/sda2")))
+	oldSysDevBlockDir, oldSysClassBlockDir := sysDevBlockDir, sysClassBlockDir
+	sysDevBlockDir, sysClassBlockDir = filepath.Join(tempDir, "dev-block"), filepath.Join(tempDir, "class-block")
+	defer func() { sysDevBlockDir, sysClassBlockDir = oldSysDevBlockDir, oldSysClassBlockDir }()
+	assert.NoError(t, os.MkdirAll(sysDevBlockDir, 0755))
+	assert.NoError(t, os.MkdirAll(sysClassBlockDir, 0755))
+	for number, dir := range map[string]string{"8:1": "sda/sda1", "8:2": "sda/sda2", "8:17": "sdb/sdb1", "253:0": "dm-0"} {
+		assert.NoError(t, os.Symlink(filepath.Join(devicesDir, dir), filepath.Join(sysDevBlockDir, number)))
+		assert.NoError(t, os.Symlink(filepath.Join(devicesDir, dir), filepath.Join(sysClassBlockDir, filepath.Base(dir))))
+	}
+
+	mountInfo := `28 1 253:0 / / rw,relatime shared:1 - xfs /dev/mapper/rhel-root rw,seclabel
+45 28 8:1 / /boot rw,relatime shared:2 - ext4 /dev/sda1 rw,seclabel
+46 28 0:45 / /tmp rw,nosuid shared:3 - tmpfs tmpfs rw,seclabel
+121 28 8:17 / /var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/local-pv-343bdd9 rw,relatime shared:65 - ext4 /dev/sdb1 rw,seclabel`
+	filename := filepath.Join(tempDir, "mountfile")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(mountInfo), 0755))
+	oldMountFile := mountFile
+	mountFile = filename
+	defer func() { mountFile = oldMountFile }()
+
+	devices, err := RootDevices()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dm-0", "sda", "sda1", "sda2"}, devices)
+
+	// a root that is not backed by a device, as in a live image, has no root devices
+	mountInfo = `28 1 0:31 / / rw,relatime shared:1 - overlay overlay rw,lowerdir=/run/rootfsbase`
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(mountInfo), 0755))
+	devices, err = RootDevices()
+	assert.NoError(t, err)
+	assert.Empty(t, devices)
+}
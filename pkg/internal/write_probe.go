@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// writeProbeChunkSize is the size of the regions written by ProbeDeviceWrites
+const writeProbeChunkSize = 1 << 20
+
+// ErrWriteProbeStopped is returned by ProbeDeviceWrites when it was stopped before it tested all the regions
+var ErrWriteProbeStopped = errors.New("write probe stopped")
+
+// ProbeDeviceWrites writes a test pattern to the device, reads it back from the device and returns an error if it differs.
+// Without full, the pattern is only written to the unused regions among the first, middle and last MiB of the device,
+// the regions that only hold zeros, and they are zeroed again. It returns the number of regions it tested, none if
+// all of them hold data. With full, the pattern is written to the whole device, which destroys its content.
+// stop is checked before each region, the probe returns ErrWriteProbeStopped once it returns true.
+func ProbeDeviceWrites(device string, full bool, stop func() bool) (int, error) {
+	f, err := os.OpenFile(device, os.O_RDWR|unix.O_SYNC, 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %q: %w", device, err)
+	}
+	defer f.Close()
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the size of %q: %w", device, err)
+	}
+
+	var offsets []int64
+	if full {
+		for offset := int64(0); offset < size; offset += writeProbeChunkSize {
+			offsets = append(offsets, offset)
+		}
+	} else {
+		seen := map[int64]bool{}
+		for _, offset := range []int64{0, size / 2 / writeProbeChunkSize * writeProbeChunkSize, size - writeProbeChunkSize} {
+			if offset < 0 {
+				offset = 0
+			}
+			if !seen[offset] {
+				seen[offset] = true
+				offsets = append(offsets, offset)
+			}
+		}
+	}
+	probed := 0
+	for _, offset := range offsets {
+		if stop != nil && stop() {
+			return probed, ErrWriteProbeStopped
+		}
+		length := int64(writeProbeChunkSize)
+		if offset+length > size {
+			length = size - offset
+		}
+		if !full {
+			unused, err := regionUnused(f, offset, length)
+			if err != nil {
+				return probed, fmt.Errorf("write probe of %q failed: %w", device, err)
+			}
+			if !unused {
+				continue
+			}
+		}
+		err = probeRegion(f, offset, length, !full)
+		if err != nil {
+			return probed, fmt.Errorf("write probe of %q failed: %w", device, err)
+		}
+		probed++
+	}
+	return probed, nil
+}
+
+// regionUnused returns true if the region of the file only holds zeros
+func regionUnused(f *os.File, offset, length int64) (bool, error) {
+	content := make([]byte, length)
+	_, err := f.ReadAt(content, offset)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %d bytes at offset %d: %w", length, offset, err)
+	}
+	for _, b := range content {
+		if b != 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// probeRegion writes the test pattern to a region of the file and reads it back, zeroing the region again if zero is true
+func probeRegion(f *os.File, offset, length int64, zero bool) error {
+	pattern := writeProbePattern(offset, length)
+	_, err := f.WriteAt(pattern, offset)
+	if err == nil {
+		err = readBack(f, offset, pattern)
+	}
+	if zero {
+		_, zeroErr := f.WriteAt(make([]byte, length), offset)
+		if zeroErr != nil && err == nil {
+			err = fmt.Errorf("failed to zero %d bytes at offset %d: %w", length, offset, zeroErr)
+		}
+	}
+	return err
+}
+
+// readBack reads the region of the pattern from the device rather than the page cache and compares it to the pattern
+func readBack(f *os.File, offset int64, pattern []byte) error {
+	err := unix.Fadvise(int(f.Fd()), offset, int64(len(pattern)), unix.FADV_DONTNEED)
+	if err != nil {
+		return fmt.Errorf("failed to drop the cached pages at offset %d: %w", offset, err)
+	}
+	read := make([]byte, len(pattern))
+	_, err = f.ReadAt(read, offset)
+	if err != nil {
+		return fmt.Errorf("failed to read back %d bytes at offset %d: %w", len(pattern), offset, err)
+	}
+	if !bytes.Equal(read, pattern) {
+		return fmt.Errorf("the %d bytes read back at offset %d differ from the written test pattern", len(pattern), offset)
+	}
+	return nil
+}
+
+// writeProbePattern returns the test pattern of the region at offset: the offset of every 8 bytes,
+// so that writes that land at the wrong offset are caught too
+func writeProbePattern(offset, length int64) []byte {
+	pattern := make([]byte, length)
+	word := make([]byte, 8)
+	for i := int64(0); i < length; i += 8 {
+		binary.LittleEndian.PutUint64(word, uint64(offset+i)^0xa5a5a5a5a5a5a5a5)
+		copy(pattern[i:], word)
+	}
+	return pattern
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeDeviceWrites(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "write-probe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	device := filepath.Join(tempDir, "disk")
+	// 3.5 MiB, so that the last region overlaps the middle one
+	content := bytes.Repeat([]byte("data"), 7*writeProbeChunkSize/8)
+	assert.NoError(t, ioutil.WriteFile(device, content, 0644))
+
+	probedRegions, err := ProbeDeviceWrites(device, false, nil)
+	assert.NoError(t, err)
+	assert.Zero(t, probedRegions, "regions that hold data are not probed")
+	probed, err := ioutil.ReadFile(device)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(content, probed), "the regions that hold data are left alone")
+
+	// the first MiB and the second half are unused
+	copy(content, make([]byte, writeProbeChunkSize))
+	copy(content[len(content)/2:], make([]byte, len(content)/2))
+	assert.NoError(t, ioutil.WriteFile(device, content, 0644))
+	probedRegions, err = ProbeDeviceWrites(device, false, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, probedRegions, "the first and last MiB are unused, the middle one holds data")
+	probed, err = ioutil.ReadFile(device)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(content, probed), "the probed regions are zeroed again")
+
+	checks := 0
+	stopAfterOneRegion := func() bool {
+		checks++
+		return checks > 1
+	}
+	probedRegions, err = ProbeDeviceWrites(device, true, stopAfterOneRegion)
+	assert.Equal(t, ErrWriteProbeStopped, err)
+	assert.Equal(t, 1, probedRegions, "the probe stops between two regions")
+
+	probedRegions, err = ProbeDeviceWrites(device, true, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, probedRegions)
+	probed, err = ioutil.ReadFile(device)
+	assert.NoError(t, err)
+	assert.Len(t, probed, len(content))
+	assert.Equal(t, writeProbePattern(writeProbeChunkSize, writeProbeChunkSize), probed[writeProbeChunkSize:2*writeProbeChunkSize],
+		"the whole device holds the test pattern")
+
+	_, err = ProbeDeviceWrites(filepath.Join(tempDir, "missing"), false, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,25 @@
+package webhook
+
+import (
+	"github.com/openshift/local-storage-operator/pkg/common"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var log = logf.Log.WithName("webhook")
+
+// AddToManagerFuncs is a list of functions to register all admission webhooks with the Manager
+var AddToManagerFuncs = []func(manager.Manager, common.Options) error{
+	addLocalVolumeWebhooks,
+	addLocalVolumeSetWebhooks,
+}
+
+// AddToManager registers all admission webhooks with the Manager's webhook server
+func AddToManager(m manager.Manager, options common.Options) error {
+	for _, f := range AddToManagerFuncs {
+		if err := f(m, options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
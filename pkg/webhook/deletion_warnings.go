@@ -0,0 +1,29 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// boundPVsWarning returns a warning with the number of PVs of the object that are bound and the namespaces of
+// their claims, empty if none of them is bound. It doesn't block the deletion of the object, the bound PVs are
+// never deleted, but shows the admin which namespaces are affected at delete time.
+func boundPVsWarning(kind, namespace, name string, pvs []corev1.PersistentVolume) string {
+	bound := 0
+	namespaces := sets.NewString()
+	for _, pv := range pvs {
+		if pv.Status.Phase != corev1.VolumeBound || pv.Spec.ClaimRef == nil {
+			continue
+		}
+		bound++
+		namespaces.Insert(pv.Spec.ClaimRef.Namespace)
+	}
+	if bound == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s %s/%s has %d bound PV(s) claimed in %d namespace(s): %s",
+		kind, namespace, name, bound, namespaces.Len(), strings.Join(namespaces.List(), ", "))
+}
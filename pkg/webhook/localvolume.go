@@ -0,0 +1,183 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// LocalVolumeMutatePath is the path the LocalVolume defaulting webhook is served at
+	LocalVolumeMutatePath = "/mutate-local-storage-openshift-io-v1-localvolume"
+	// LocalVolumeValidatePath is the path the LocalVolume validating webhook is served at
+	LocalVolumeValidatePath = "/validate-local-storage-openshift-io-v1-localvolume"
+)
+
+// kernelDevicePath matches the kernel names of disks and their partitions, which can change across reboots
+var kernelDevicePath = regexp.MustCompile(`^/dev/((sd|vd|xvd|hd)[a-z]+[0-9]*|nvme[0-9]+n[0-9]+(p[0-9]+)?)$`)
+
+func addLocalVolumeWebhooks(mgr manager.Manager, options common.Options) error {
+	server := mgr.GetWebhookServer()
+	server.Register(LocalVolumeMutatePath, admission.DefaultingWebhookFor(&localv1.LocalVolume{}))
+	server.Register(LocalVolumeValidatePath, newLocalVolumeValidatingWebhook(options.StorageClassNamePattern))
+	return nil
+}
+
+// newLocalVolumeValidatingWebhook returns the LocalVolume validating webhook. The webhook
+// server injects the scheme, client and logger into it when it starts.
+func newLocalVolumeValidatingWebhook(storageClassNamePattern *regexp.Regexp) *warningsWebhook {
+	return withWarnings(&admission.Webhook{Handler: &localVolumeValidator{storageClassNamePattern: storageClassNamePattern}})
+}
+
+// localVolumeValidator validates LocalVolumes on create and update, and warns about their bound PVs on delete
+type localVolumeValidator struct {
+	client  client.Client
+	decoder *admission.Decoder
+	// storageClassNamePattern is the --storage-class-name-pattern of the operator
+	storageClassNamePattern *regexp.Regexp
+}
+
+var _ admission.Handler = &localVolumeValidator{}
+
+// InjectClient injects the client into the validator
+func (v *localVolumeValidator) InjectClient(c client.Client) error {
+	v.client = c
+	return nil
+}
+
+// InjectDecoder injects the decoder into the validator
+func (v *localVolumeValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle validates the LocalVolume in the request with its ValidateCreate and ValidateUpdate methods,
+// and warns about devicePaths that are unstable when the spec changes
+func (v *localVolumeValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation == v1beta1.Delete {
+		return v.handleDelete(ctx, req)
+	}
+	if req.Operation != v1beta1.Create && req.Operation != v1beta1.Update {
+		return admission.Allowed("")
+	}
+
+	lv := &localv1.LocalVolume{}
+	err := v.decoder.Decode(req, lv)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if req.Operation == v1beta1.Update {
+		oldLV := &localv1.LocalVolume{}
+		err = v.decoder.DecodeRaw(req.OldObject, oldLV)
+		if err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		err = lv.ValidateUpdate(oldLV)
+		if err != nil {
+			return admission.Denied(err.Error())
+		}
+		err = validateStorageClassNames(v.storageClassNamePattern, storageClassNames(lv), storageClassNames(oldLV))
+		if err != nil {
+			return admission.Denied(err.Error())
+		}
+		// updates of the finalizer and the metadata are not warned about again
+		if equality.Semantic.DeepEqual(oldLV.Spec, lv.Spec) {
+			return admission.Allowed("")
+		}
+	} else {
+		err = lv.ValidateCreate()
+		if err != nil {
+			return admission.Denied(err.Error())
+		}
+		err = validateStorageClassNames(v.storageClassNamePattern, storageClassNames(lv), nil)
+		if err != nil {
+			return admission.Denied(err.Error())
+		}
+	}
+
+	for _, warning := range v.devicePathWarnings(ctx, lv) {
+		addWarning(ctx, warning)
+	}
+	return admission.Allowed("")
+}
+
+// handleDelete allows the deletion of the LocalVolume, with a warning about its bound PVs
+func (v *localVolumeValidator) handleDelete(ctx context.Context, req admission.Request) admission.Response {
+	lv := &localv1.LocalVolume{ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}}
+	pvs, err := common.ListOwnedPVs(ctx, v.client, lv)
+	if err != nil {
+		log.Error(err, "could not list the PVs of the LocalVolume, skipping the bound PV warning")
+		return admission.Allowed("")
+	}
+	if warning := boundPVsWarning(localv1.LocalVolumeKind, req.Namespace, req.Name, pvs.Items); warning != "" {
+		addWarning(ctx, warning)
+	}
+	return admission.Allowed("")
+}
+
+// storageClassNames returns the StorageClass names of the storageClassDevices of the LocalVolume
+func storageClassNames(lv *localv1.LocalVolume) []string {
+	names := make([]string, 0, len(lv.Spec.StorageClassDevices))
+	for _, device := range lv.Spec.StorageClassDevices {
+		names = append(names, device.StorageClassName)
+	}
+	return names
+}
+
+// devicePathWarnings warns about devicePaths that are kernel names like /dev/sdb, which may name another disk
+// after a reboot. The /dev/disk/by-id/ links of the devices are suggested when LocalVolumeDiscoveryResults list them.
+func (v *localVolumeValidator) devicePathWarnings(ctx context.Context, lv *localv1.LocalVolume) []string {
+	var unstable []string
+	for _, device := range lv.Spec.StorageClassDevices {
+		for _, path := range device.DevicePaths {
+			if kernelDevicePath.MatchString(path) {
+				unstable = append(unstable, path)
+			}
+		}
+	}
+	if len(unstable) == 0 {
+		return nil
+	}
+
+	// the stable links of the devices, by path and node
+	links := map[string][]string{}
+	if v.client != nil {
+		results := &localv1alpha1.LocalVolumeDiscoveryResultList{}
+		err := v.client.List(ctx, results, client.InNamespace(lv.Namespace))
+		if err != nil {
+			log.Error(err, "could not list discovery results, not suggesting stable device paths")
+		}
+		for _, result := range results.Items {
+			for _, device := range result.Status.DiscoveredDevices {
+				if device.DeviceID != "" {
+					links[device.Path] = append(links[device.Path], fmt.Sprintf("%s on node %s", device.DeviceID, result.Spec.NodeName))
+				}
+			}
+		}
+	}
+
+	warnings := make([]string, 0, len(unstable))
+	for _, path := range unstable {
+		warning := fmt.Sprintf("devicePath %s is a kernel name that can name another disk after a reboot, use a stable link under /dev/disk/by-id/ or /dev/disk/by-path/ instead", path)
+		if suggestions := links[path]; len(suggestions) > 0 {
+			sort.Strings(suggestions)
+			warning += fmt.Sprintf(", for example %s", strings.Join(suggestions, ", "))
+		}
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
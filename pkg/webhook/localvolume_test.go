@@ -0,0 +1,254 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	scheme, err := localv1.SchemeBuilder.Build()
+	assert.NoErrorf(t, err, "creating scheme")
+	return scheme
+}
+
+func TestLocalVolumeDefaultVolumeMode(t *testing.T) {
+	lv := &localv1.LocalVolume{
+		TypeMeta:   metav1.TypeMeta{APIVersion: localv1.SchemeGroupVersion.String(), Kind: "LocalVolume"},
+		ObjectMeta: metav1.ObjectMeta{Name: "local-disks", Namespace: "local-storage"},
+		Spec: localv1.LocalVolumeSpec{
+			StorageClassDevices: []localv1.StorageClassDevice{
+				{StorageClassName: "fs", DevicePaths: []string{"/dev/sdb"}},
+				{StorageClassName: "block", VolumeMode: localv1.PersistentVolumeBlock, DevicePaths: []string{"/dev/sdc"}},
+			},
+		},
+	}
+	raw, err := json.Marshal(lv)
+	assert.NoError(t, err)
+
+	hook := admission.DefaultingWebhookFor(&localv1.LocalVolume{})
+	err = hook.InjectScheme(newTestScheme(t))
+	assert.NoError(t, err)
+
+	resp := hook.Handle(context.TODO(), admission.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	assert.True(t, resp.Allowed)
+
+	patched := map[string]string{}
+	for _, patch := range resp.Patches {
+		if value, ok := patch.Value.(string); ok {
+			patched[patch.Path] = value
+		}
+	}
+	assert.Equal(t, string(localv1.PersistentVolumeFilesystem), patched["/spec/storageClassDevices/0/volumeMode"])
+	assert.NotContains(t, patched, "/spec/storageClassDevices/1/volumeMode")
+}
+
+func TestLocalVolumeValidation(t *testing.T) {
+	newLocalVolume := func(devices ...localv1.StorageClassDevice) []byte {
+		lv := &localv1.LocalVolume{
+			TypeMeta:   metav1.TypeMeta{APIVersion: localv1.SchemeGroupVersion.String(), Kind: "LocalVolume"},
+			ObjectMeta: metav1.ObjectMeta{Name: "local-disks", Namespace: "local-storage"},
+			Spec:       localv1.LocalVolumeSpec{StorageClassDevices: devices},
+		}
+		raw, err := json.Marshal(lv)
+		assert.NoError(t, err)
+		return raw
+	}
+	valid := newLocalVolume(
+		localv1.StorageClassDevice{StorageClassName: "fs", VolumeMode: localv1.PersistentVolumeFilesystem, FSType: "xfs", DevicePaths: []string{"/dev/sdb"}},
+		localv1.StorageClassDevice{StorageClassName: "block", VolumeMode: localv1.PersistentVolumeBlock, DevicePaths: []string{"/dev/sdc"}},
+	)
+	blockWithFSType := newLocalVolume(
+		localv1.StorageClassDevice{StorageClassName: "block", VolumeMode: localv1.PersistentVolumeBlock, FSType: "xfs", DevicePaths: []string{"/dev/sdc"}},
+	)
+	blockWithLazyInit := newLocalVolume(
+		localv1.StorageClassDevice{StorageClassName: "block", VolumeMode: localv1.PersistentVolumeBlock, DisableLazyInit: true, DevicePaths: []string{"/dev/sdc"}},
+	)
+	withAccessModes := func(modes ...corev1.PersistentVolumeAccessMode) []byte {
+		return newLocalVolume(
+			localv1.StorageClassDevice{StorageClassName: "fs", AccessModes: modes, DevicePaths: []string{"/dev/sdb"}},
+		)
+	}
+	withPeriodicTrim := func(interval time.Duration) []byte {
+		return newLocalVolume(
+			localv1.StorageClassDevice{StorageClassName: "fs", PeriodicTrim: &metav1.Duration{Duration: interval}, DevicePaths: []string{"/dev/sdb"}},
+		)
+	}
+	withPVLabels := func(pvLabels map[string]string) []byte {
+		lv := &localv1.LocalVolume{}
+		assert.NoError(t, json.Unmarshal(valid, lv))
+		lv.Spec.PVLabels = pvLabels
+		raw, err := json.Marshal(lv)
+		assert.NoError(t, err)
+		return raw
+	}
+	withProvisionerConfig := func(provisionerConfig map[string]string) []byte {
+		lv := &localv1.LocalVolume{}
+		assert.NoError(t, json.Unmarshal(valid, lv))
+		lv.Spec.ProvisionerConfig = provisionerConfig
+		raw, err := json.Marshal(lv)
+		assert.NoError(t, err)
+		return raw
+	}
+
+	hook := &admission.Webhook{Handler: &localVolumeValidator{}}
+	assert.NoError(t, hook.InjectScheme(newTestScheme(t)))
+
+	testcases := []struct {
+		label     string
+		operation admissionv1beta1.Operation
+		object    []byte
+		oldObject []byte
+		allowed   bool
+	}{
+		{label: "valid", operation: admissionv1beta1.Create, object: valid, allowed: true},
+		{label: "block with fsType", operation: admissionv1beta1.Create, object: blockWithFSType, allowed: false},
+		{label: "block with disableLazyInit", operation: admissionv1beta1.Create, object: blockWithLazyInit, allowed: false},
+		{label: "ReadWriteOnce and ReadOnlyMany", operation: admissionv1beta1.Create, object: withAccessModes(corev1.ReadWriteOnce, corev1.ReadOnlyMany), allowed: true},
+		{label: "ReadWriteMany", operation: admissionv1beta1.Create, object: withAccessModes(corev1.ReadWriteOnce, corev1.ReadWriteMany), allowed: false},
+		{label: "unknown access mode", operation: admissionv1beta1.Create, object: withAccessModes("ReadWriteSometimes"), allowed: false},
+		{label: "update to block with fsType", operation: admissionv1beta1.Update, object: blockWithFSType, oldObject: valid, allowed: false},
+		{label: "update with unchanged spec", operation: admissionv1beta1.Update, object: blockWithFSType, oldObject: blockWithFSType, allowed: true},
+		{label: "weekly periodicTrim", operation: admissionv1beta1.Create, object: withPeriodicTrim(7 * 24 * time.Hour), allowed: true},
+		{label: "periodicTrim below 1h", operation: admissionv1beta1.Create, object: withPeriodicTrim(time.Minute), allowed: false},
+		{label: "pvLabels", operation: admissionv1beta1.Create, object: withPVLabels(map[string]string{"backup.example.com/policy": "daily"}), allowed: true},
+		{label: "invalid pvLabels key", operation: admissionv1beta1.Create, object: withPVLabels(map[string]string{"backup/policy/daily": "true"}), allowed: false},
+		{label: "invalid pvLabels value", operation: admissionv1beta1.Create, object: withPVLabels(map[string]string{"backup": "every day"}), allowed: false},
+		{label: "provisionerConfig", operation: admissionv1beta1.Create, object: withProvisionerConfig(map[string]string{"minResyncPeriod": "1m", "deletionBackoff": "5m"}), allowed: true},
+		{label: "unknown provisionerConfig key", operation: admissionv1beta1.Create, object: withProvisionerConfig(map[string]string{"useJobForCleaning": "true"}), allowed: false},
+		{label: "minResyncPeriod below 5s", operation: admissionv1beta1.Create, object: withProvisionerConfig(map[string]string{"minResyncPeriod": "1s"}), allowed: false},
+		{label: "deletionBackoff not a duration", operation: admissionv1beta1.Create, object: withProvisionerConfig(map[string]string{"deletionBackoff": "300"}), allowed: false},
+	}
+	for _, tc := range testcases {
+		resp := hook.Handle(context.TODO(), admission.Request{
+			AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: tc.operation,
+				Object:    runtime.RawExtension{Raw: tc.object},
+				OldObject: runtime.RawExtension{Raw: tc.oldObject},
+			},
+		})
+		assert.Equalf(t, tc.allowed, resp.Allowed, "[%s] %v", tc.label, resp.Result)
+	}
+}
+
+func TestLocalVolumeDevicePathWarning(t *testing.T) {
+	scheme := newTestScheme(t)
+	assert.NoError(t, localv1alpha1.SchemeBuilder.AddToScheme(scheme))
+	discoveryResult := &localv1alpha1.LocalVolumeDiscoveryResult{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery-result-node1", Namespace: testNamespace},
+		Spec:       localv1alpha1.LocalVolumeDiscoveryResultSpec{NodeName: "node1"},
+		Status: localv1alpha1.LocalVolumeDiscoveryResultStatus{
+			DiscoveredDevices: []localv1alpha1.DiscoveredDevice{
+				{DeviceID: "/dev/disk/by-id/wwn-0x5000c500a0b1c2d3", Path: "/dev/sdb"},
+			},
+		},
+	}
+	validator := &localVolumeValidator{client: fake.NewFakeClientWithScheme(scheme, discoveryResult)}
+	hook := &admission.Webhook{Handler: validator}
+	assert.NoError(t, hook.InjectScheme(scheme))
+	assert.NoError(t, hook.InjectLogger(log))
+	handler := withWarnings(hook)
+
+	newLocalVolume := func(devicePaths ...string) *localv1.LocalVolume {
+		return &localv1.LocalVolume{
+			TypeMeta:   metav1.TypeMeta{APIVersion: localv1.SchemeGroupVersion.String(), Kind: "LocalVolume"},
+			ObjectMeta: metav1.ObjectMeta{Name: "local-disks", Namespace: testNamespace},
+			Spec: localv1.LocalVolumeSpec{StorageClassDevices: []localv1.StorageClassDevice{
+				{StorageClassName: "fs", DevicePaths: devicePaths},
+			}},
+		}
+	}
+
+	resp := serveAdmission(t, handler, admissionv1beta1.Create, newLocalVolume("/dev/disk/by-id/wwn-0x5000c500a0b1c2d3", "/dev/disk/by-path/pci-0000:00:1f.2-ata-2"))
+	assert.True(t, resp.Response.Allowed)
+	assert.Empty(t, resp.Response.Warnings, "stable links")
+
+	resp = serveAdmission(t, handler, admissionv1beta1.Create, newLocalVolume("/dev/sdb", "/dev/nvme0n1p2", "/dev/disk/by-id/nvme-eui.1"))
+	assert.True(t, resp.Response.Allowed, "kernel names are allowed")
+	if assert.Len(t, resp.Response.Warnings, 2) {
+		assert.Contains(t, resp.Response.Warnings[0], "/dev/sdb is a kernel name")
+		assert.Contains(t, resp.Response.Warnings[0], "/dev/disk/by-id/wwn-0x5000c500a0b1c2d3 on node node1", "the discovered link is suggested")
+		assert.Contains(t, resp.Response.Warnings[1], "/dev/nvme0n1p2 is a kernel name")
+	}
+
+	// updates that leave the spec unchanged are not warned about again
+	resp = serveAdmission(t, handler, admissionv1beta1.Update, newLocalVolume("/dev/sdb"))
+	assert.True(t, resp.Response.Allowed)
+	assert.Empty(t, resp.Response.Warnings)
+}
+
+func TestLocalVolumeDeleteBoundPVsWarning(t *testing.T) {
+	scheme := newTestScheme(t)
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "local-pv-a",
+			Labels: map[string]string{
+				common.LocalVolumeOwnerNameForPV:      "local-disks",
+				common.LocalVolumeOwnerNamespaceForPV: testNamespace,
+			},
+		},
+		Spec: corev1.PersistentVolumeSpec{
+			ClaimRef: &corev1.ObjectReference{Kind: "PersistentVolumeClaim", Namespace: "team-a", Name: "data"},
+		},
+		Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound},
+	}
+	validator := &localVolumeValidator{client: fake.NewFakeClientWithScheme(scheme, pv)}
+	hook := &admission.Webhook{Handler: validator}
+	assert.NoError(t, hook.InjectScheme(scheme))
+	assert.NoError(t, hook.InjectLogger(log))
+
+	lv := &localv1.LocalVolume{
+		TypeMeta:   metav1.TypeMeta{APIVersion: localv1.SchemeGroupVersion.String(), Kind: "LocalVolume"},
+		ObjectMeta: metav1.ObjectMeta{Name: "local-disks", Namespace: testNamespace},
+	}
+	resp := serveAdmission(t, withWarnings(hook), admissionv1beta1.Delete, lv)
+	assert.True(t, resp.Response.Allowed, "the deletion is not blocked")
+	assert.Equal(t, []string{
+		"LocalVolume local-storage/local-disks has 1 bound PV(s) claimed in 1 namespace(s): team-a",
+	}, resp.Response.Warnings)
+}
+
+// convert sends obj through the conversion webhook and returns the converted object
+func TestLocalVolumeStorageClassNamePattern(t *testing.T) {
+	pattern := regexp.MustCompile("^local-")
+	scheme := newTestScheme(t)
+	hook := &admission.Webhook{Handler: &localVolumeValidator{storageClassNamePattern: pattern}}
+	assert.NoError(t, hook.InjectScheme(scheme))
+	assert.NoError(t, hook.InjectLogger(log))
+	handler := withWarnings(hook)
+
+	lv := &localv1.LocalVolume{
+		TypeMeta:   metav1.TypeMeta{APIVersion: localv1.SchemeGroupVersion.String(), Kind: "LocalVolume"},
+		ObjectMeta: metav1.ObjectMeta{Name: "local-disks", Namespace: testNamespace},
+		Spec: localv1.LocalVolumeSpec{StorageClassDevices: []localv1.StorageClassDevice{
+			{StorageClassName: "local-fs", DevicePaths: []string{"/dev/disk/by-id/a"}},
+			{StorageClassName: "block", DevicePaths: []string{"/dev/disk/by-id/b"}},
+		}},
+	}
+	resp := serveAdmission(t, handler, admissionv1beta1.Create, lv)
+	assert.False(t, resp.Response.Allowed, "every storageClassName must match")
+	resp = serveAdmission(t, handler, admissionv1beta1.Update, lv)
+	assert.True(t, resp.Response.Allowed, "existing names are allowed on update")
+
+	err := validateStorageClassNames(pattern, []string{"local-fs", "block", "fs"}, []string{"block"})
+	assert.EqualError(t, err, `storageClassName "fs" doesn't match the required pattern "^local-"`, "only added names are checked")
+}
@@ -0,0 +1,257 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// LocalVolumeSetValidatePath is the path the LocalVolumeSet validating webhook is served at
+	LocalVolumeSetValidatePath = "/validate-local-storage-openshift-io-v1alpha1-localvolumeset"
+)
+
+func addLocalVolumeSetWebhooks(mgr manager.Manager, options common.Options) error {
+	server := mgr.GetWebhookServer()
+	server.Register(LocalVolumeSetValidatePath, newLocalVolumeSetValidatingWebhook(options.StorageClassNamePattern))
+	return nil
+}
+
+// newLocalVolumeSetValidatingWebhook returns the LocalVolumeSet validating webhook. The webhook
+// server injects the scheme, client and logger into it when it starts.
+func newLocalVolumeSetValidatingWebhook(storageClassNamePattern *regexp.Regexp) *warningsWebhook {
+	return withWarnings(&admission.Webhook{Handler: &localVolumeSetValidator{storageClassNamePattern: storageClassNamePattern}})
+}
+
+// localVolumeSetValidator validates LocalVolumeSets on create and update, and warns about their bound PVs on delete
+type localVolumeSetValidator struct {
+	client  client.Client
+	decoder *admission.Decoder
+	// storageClassNamePattern is the --storage-class-name-pattern of the operator
+	storageClassNamePattern *regexp.Regexp
+}
+
+var _ admission.Handler = &localVolumeSetValidator{}
+
+// InjectClient injects the client into the validator
+func (v *localVolumeSetValidator) InjectClient(c client.Client) error {
+	v.client = c
+	return nil
+}
+
+// InjectDecoder injects the decoder into the validator
+func (v *localVolumeSetValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle validates the LocalVolumeSet in the request
+func (v *localVolumeSetValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation == v1beta1.Delete {
+		return v.handleDelete(ctx, req)
+	}
+	if req.Operation != v1beta1.Create && req.Operation != v1beta1.Update {
+		return admission.Allowed("")
+	}
+
+	lvset := &localv1alpha1.LocalVolumeSet{}
+	err := v.decoder.Decode(req, lvset)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	var oldLVSet *localv1alpha1.LocalVolumeSet
+	if req.Operation == v1beta1.Update {
+		oldLVSet = &localv1alpha1.LocalVolumeSet{}
+		err = v.decoder.DecodeRaw(req.OldObject, oldLVSet)
+		if err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		// updates of the finalizers, the metadata and the status are neither validated nor warned about again,
+		// so that objects created before a validation rule existed can still be updated and deleted
+		if equality.Semantic.DeepEqual(oldLVSet.Spec, lvset.Spec) {
+			return admission.Allowed("")
+		}
+	}
+
+	err = localv1.ValidatePVMetadata(lvset.Spec.PVLabels, lvset.Spec.PVAnnotations)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	err = localv1.ValidateProvisionerConfig(lvset.Spec.ProvisionerConfig)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	err = validateDeviceRouting(lvset.Spec.DeviceRouting)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	err = common.ValidateProvisioningWindow(lvset.Spec.ProvisioningWindow)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	err = validateFSLabels(lvset)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	var oldNames []string
+	if oldLVSet != nil {
+		oldNames = oldLVSet.StorageClassNames()
+
+		err = validateLockedFields(oldLVSet, lvset)
+		if err != nil {
+			return admission.Denied(err.Error())
+		}
+	}
+	err = validateStorageClassNames(v.storageClassNamePattern, lvset.StorageClassNames(), oldNames)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	for _, warning := range v.minSizeWarnings(ctx, lvset) {
+		addWarning(ctx, warning)
+	}
+
+	return admission.Allowed("")
+}
+
+// handleDelete allows the deletion of the LocalVolumeSet, with a warning about its bound PVs
+func (v *localVolumeSetValidator) handleDelete(ctx context.Context, req admission.Request) admission.Response {
+	pvs := &corev1.PersistentVolumeList{}
+	err := v.client.List(ctx, pvs, client.MatchingLabels{
+		common.PVOwnerKindLabel:      localv1alpha1.LocalVolumeSetKind,
+		common.PVOwnerNameLabel:      req.Name,
+		common.PVOwnerNamespaceLabel: req.Namespace,
+	})
+	if err != nil {
+		log.Error(err, "could not list the PVs of the LocalVolumeSet, skipping the bound PV warning")
+		return admission.Allowed("")
+	}
+	if warning := boundPVsWarning(localv1alpha1.LocalVolumeSetKind, req.Namespace, req.Name, pvs.Items); warning != "" {
+		addWarning(ctx, warning)
+	}
+	return admission.Allowed("")
+}
+
+// validateLockedFields rejects the changes to the fields that select the devices of a LocalVolumeSet
+// that was locked with the LockedAnnotation. The annotation of the old object counts, so that unlocking it
+// has to be a change of its own.
+func validateLockedFields(oldLVSet, lvset *localv1alpha1.LocalVolumeSet) error {
+	if !common.IsLocked(oldLVSet) {
+		return nil
+	}
+	lockedFields := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"spec.deviceInclusionSpec", oldLVSet.Spec.DeviceInclusionSpec, lvset.Spec.DeviceInclusionSpec},
+		{"spec.nodeOverrides", oldLVSet.Spec.NodeOverrides, lvset.Spec.NodeOverrides},
+		{"spec.nodeSelector", oldLVSet.Spec.NodeSelector, lvset.Spec.NodeSelector},
+		{"spec.maxDeviceCount", oldLVSet.Spec.MaxDeviceCount, lvset.Spec.MaxDeviceCount},
+		{"spec.maxCapacityPerNode", oldLVSet.Spec.MaxCapacityPerNode, lvset.Spec.MaxCapacityPerNode},
+		{"spec.maxNodeCount", oldLVSet.Spec.MaxNodeCount, lvset.Spec.MaxNodeCount},
+		{"spec.allowDevicesWithHolders", oldLVSet.Spec.AllowDevicesWithHolders, lvset.Spec.AllowDevicesWithHolders},
+		{"spec.reattachExisting", oldLVSet.Spec.ReattachExisting, lvset.Spec.ReattachExisting},
+	}
+	changed := []string{}
+	for _, field := range lockedFields {
+		if !equality.Semantic.DeepEqual(field.old, field.new) {
+			changed = append(changed, field.name)
+		}
+	}
+	if len(changed) > 0 {
+		return fmt.Errorf("the LocalVolumeSet is locked by the %s annotation, remove it first to change %s",
+			common.LockedAnnotation, strings.Join(changed, ", "))
+	}
+	return nil
+}
+
+// validateFSLabels checks that fsLabels are only used to reattach filesystems: devices with a label
+// always have a filesystem, which is only kept with reattachExisting and volumeMode Filesystem
+func validateFSLabels(lvset *localv1alpha1.LocalVolumeSet) error {
+	paths := []string{}
+	if lvset.Spec.DeviceInclusionSpec != nil && len(lvset.Spec.DeviceInclusionSpec.FSLabels) > 0 {
+		paths = append(paths, "spec.deviceInclusionSpec.fsLabels")
+	}
+	for i, override := range lvset.Spec.NodeOverrides {
+		if len(override.DeviceInclusionSpec.FSLabels) > 0 {
+			paths = append(paths, fmt.Sprintf("spec.nodeOverrides[%d].deviceInclusionSpec.fsLabels", i))
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	if !lvset.Spec.ReattachExisting {
+		return fmt.Errorf("%s requires spec.reattachExisting", strings.Join(paths, ", "))
+	}
+	if lvset.Spec.VolumeMode != "" && lvset.Spec.VolumeMode != localv1.PersistentVolumeFilesystem {
+		return fmt.Errorf("%s requires spec.volumeMode %s", strings.Join(paths, ", "), localv1.PersistentVolumeFilesystem)
+	}
+	return nil
+}
+
+// validateDeviceRouting checks that every route of spec.deviceRouting has a StorageClass and exactly one tag
+func validateDeviceRouting(routes []localv1alpha1.DeviceRoute) error {
+	for i, route := range routes {
+		if route.StorageClassName == "" {
+			return fmt.Errorf("spec.deviceRouting[%d].storageClassName is required", i)
+		}
+		if (route.PartLabel == "") == (route.UdevTag == "") {
+			return fmt.Errorf("spec.deviceRouting[%d] must set exactly one of partLabel and udevTag", i)
+		}
+	}
+	return nil
+}
+
+// minSizeWarnings warns when the requested minSize is larger than every device in the
+// LocalVolumeDiscoveryResults, which usually means a unit mistake (e.g. Ti instead of Gi).
+// Discovery results may be missing or stale, so this is only ever a warning.
+func (v *localVolumeSetValidator) minSizeWarnings(ctx context.Context, lvset *localv1alpha1.LocalVolumeSet) []string {
+	if lvset.Spec.DeviceInclusionSpec == nil || lvset.Spec.DeviceInclusionSpec.MinSize == nil {
+		return nil
+	}
+	minSize := lvset.Spec.DeviceInclusionSpec.MinSize
+
+	results := &localv1alpha1.LocalVolumeDiscoveryResultList{}
+	err := v.client.List(ctx, results, client.InNamespace(lvset.Namespace))
+	if err != nil {
+		log.Error(err, "could not list discovery results, skipping minSize check")
+		return nil
+	}
+
+	var largest *resource.Quantity
+	for _, result := range results.Items {
+		for _, device := range result.Status.DiscoveredDevices {
+			size := resource.NewQuantity(device.Size, resource.BinarySI)
+			if largest == nil || size.Cmp(*largest) > 0 {
+				largest = size
+			}
+		}
+	}
+	if largest == nil || minSize.Cmp(*largest) <= 0 {
+		return nil
+	}
+
+	return []string{fmt.Sprintf(
+		"spec.deviceInclusionSpec.minSize %s is larger than the largest discovered device (%s), no device may match",
+		minSize.String(), largest.String(),
+	)}
+}
@@ -0,0 +1,397 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const testNamespace = "local-storage"
+
+// admissionResponse mirrors the parts of the AdmissionReview response the tests look at
+type admissionResponse struct {
+	Response struct {
+		Allowed  bool     `json:"allowed"`
+		Warnings []string `json:"warnings"`
+	} `json:"response"`
+}
+
+// serveAdmission sends obj to the webhook handler and returns the decoded response
+func serveAdmission(t *testing.T, handler http.Handler, operation admissionv1beta1.Operation, obj runtime.Object) admissionResponse {
+	return serveAdmissionUpdate(t, handler, operation, obj, obj)
+}
+
+// serveAdmissionUpdate sends obj and the old object of an update to the webhook handler and returns the decoded response
+func serveAdmissionUpdate(t *testing.T, handler http.Handler, operation admissionv1beta1.Operation, obj, oldObj runtime.Object) admissionResponse {
+	raw, err := json.Marshal(obj)
+	assert.NoError(t, err)
+	oldRaw, err := json.Marshal(oldObj)
+	assert.NoError(t, err)
+	accessor, err := meta.Accessor(oldObj)
+	assert.NoError(t, err)
+	review := admissionv1beta1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1beta1", Kind: "AdmissionReview"},
+		Request: &admissionv1beta1.AdmissionRequest{
+			Operation: operation,
+			Name:      accessor.GetName(),
+			Namespace: testNamespace,
+			Object:    runtime.RawExtension{Raw: raw},
+			OldObject: runtime.RawExtension{Raw: oldRaw},
+		},
+	}
+	body, err := json.Marshal(review)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	resp := admissionResponse{}
+	err = json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoErrorf(t, err, "decoding response %q", rec.Body.String())
+	return resp
+}
+
+func newTestLocalVolumeSetWebhook(t *testing.T, objs ...runtime.Object) http.Handler {
+	scheme, err := localv1alpha1.SchemeBuilder.Build()
+	assert.NoErrorf(t, err, "creating scheme")
+
+	validator := &localVolumeSetValidator{client: fake.NewFakeClientWithScheme(scheme, objs...)}
+	return serveLocalVolumeSetValidator(t, scheme, validator)
+}
+
+// serveLocalVolumeSetValidator wraps the validator in the LocalVolumeSet validating webhook
+func serveLocalVolumeSetValidator(t *testing.T, scheme *runtime.Scheme, validator *localVolumeSetValidator) http.Handler {
+	hook := &admission.Webhook{Handler: validator}
+	err := hook.InjectScheme(scheme)
+	assert.NoError(t, err)
+	err = hook.InjectLogger(log)
+	assert.NoError(t, err)
+	return withWarnings(hook)
+}
+
+// newTestLocalVolumeSetWebhookWithPattern returns the LocalVolumeSet validating webhook with a --storage-class-name-pattern
+func newTestLocalVolumeSetWebhookWithPattern(t *testing.T, pattern string) http.Handler {
+	scheme, err := localv1alpha1.SchemeBuilder.Build()
+	assert.NoErrorf(t, err, "creating scheme")
+
+	validator := &localVolumeSetValidator{
+		client:                  fake.NewFakeClientWithScheme(scheme),
+		storageClassNamePattern: regexp.MustCompile(pattern),
+	}
+	return serveLocalVolumeSetValidator(t, scheme, validator)
+}
+
+func newTestLocalVolumeSet(inclusionSpec *localv1alpha1.DeviceInclusionSpec) *localv1alpha1.LocalVolumeSet {
+	return &localv1alpha1.LocalVolumeSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: localv1alpha1.SchemeGroupVersion.String(), Kind: "LocalVolumeSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "lvset", Namespace: testNamespace},
+		Spec: localv1alpha1.LocalVolumeSetSpec{
+			StorageClassName:    "local-sc",
+			DeviceInclusionSpec: inclusionSpec,
+		},
+	}
+}
+
+func TestLocalVolumeSetMinSizeWarning(t *testing.T) {
+	discoveryResult := &localv1alpha1.LocalVolumeDiscoveryResult{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery-result-node1", Namespace: testNamespace},
+		Status: localv1alpha1.LocalVolumeDiscoveryResultStatus{
+			DiscoveredDevices: []localv1alpha1.DiscoveredDevice{
+				{DeviceID: "/dev/disk/by-id/a", Size: 10 * 1024 * 1024 * 1024},
+				{DeviceID: "/dev/disk/by-id/b", Size: 100 * 1024 * 1024 * 1024},
+			},
+		},
+	}
+	tenTi := resource.MustParse("10Ti")
+	tenGi := resource.MustParse("10Gi")
+
+	testcases := []struct {
+		label         string
+		objs          []runtime.Object
+		inclusionSpec *localv1alpha1.DeviceInclusionSpec
+		expectWarning bool
+	}{
+		{label: "minSize larger than any device", objs: []runtime.Object{discoveryResult}, inclusionSpec: &localv1alpha1.DeviceInclusionSpec{MinSize: &tenTi}, expectWarning: true},
+		{label: "minSize matches a device", objs: []runtime.Object{discoveryResult}, inclusionSpec: &localv1alpha1.DeviceInclusionSpec{MinSize: &tenGi}},
+		{label: "no minSize", objs: []runtime.Object{discoveryResult}},
+		{label: "no discovery results", inclusionSpec: &localv1alpha1.DeviceInclusionSpec{MinSize: &tenTi}},
+	}
+
+	for _, tc := range testcases {
+		handler := newTestLocalVolumeSetWebhook(t, tc.objs...)
+		resp := serveAdmission(t, handler, admissionv1beta1.Create, newTestLocalVolumeSet(tc.inclusionSpec))
+		assert.Truef(t, resp.Response.Allowed, "[%s] expected the request to be allowed", tc.label)
+		assert.Equalf(t, tc.expectWarning, len(resp.Response.Warnings) > 0, "[%s] unexpected warnings: %v", tc.label, resp.Response.Warnings)
+	}
+}
+
+func TestLocalVolumeSetUnchangedSpec(t *testing.T) {
+	discoveryResult := &localv1alpha1.LocalVolumeDiscoveryResult{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery-result-node1", Namespace: testNamespace},
+		Status: localv1alpha1.LocalVolumeDiscoveryResultStatus{
+			DiscoveredDevices: []localv1alpha1.DiscoveredDevice{{DeviceID: "/dev/disk/by-id/a", Size: 10 * 1024 * 1024 * 1024}},
+		},
+	}
+	tenTi := resource.MustParse("10Ti")
+	// an object created before pvLabels were validated, with a minSize that no device matches
+	oldLVSet := newTestLocalVolumeSet(&localv1alpha1.DeviceInclusionSpec{MinSize: &tenTi})
+	oldLVSet.Spec.PVLabels = map[string]string{"storage.openshift.com/owner-name": "other"}
+	oldLVSet.Finalizers = []string{"storage.openshift.com/local-volume-protection"}
+
+	handler := newTestLocalVolumeSetWebhook(t, discoveryResult)
+	lvset := oldLVSet.DeepCopy()
+	lvset.Finalizers = nil
+	resp := serveAdmissionUpdate(t, handler, admissionv1beta1.Update, lvset, oldLVSet)
+	assert.True(t, resp.Response.Allowed, "updates that leave the spec unchanged should be allowed")
+	assert.Empty(t, resp.Response.Warnings, "updates that leave the spec unchanged should not be warned about")
+
+	lvset = oldLVSet.DeepCopy()
+	lvset.Spec.PVAnnotations = map[string]string{"backup.example.com/policy": "daily"}
+	resp = serveAdmissionUpdate(t, handler, admissionv1beta1.Update, lvset, oldLVSet)
+	assert.False(t, resp.Response.Allowed, "spec updates should be validated")
+}
+
+func TestLocalVolumeSetPVMetadataValidation(t *testing.T) {
+	testcases := []struct {
+		label         string
+		pvLabels      map[string]string
+		pvAnnotations map[string]string
+		allowed       bool
+	}{
+		{label: "no pv metadata", allowed: true},
+		{label: "legal keys", pvLabels: map[string]string{"backup": "daily"}, pvAnnotations: map[string]string{"backup.example.com/policy": "every day"}, allowed: true},
+		{label: "invalid pvLabels key", pvLabels: map[string]string{"-backup": "daily"}},
+		{label: "invalid pvLabels value", pvLabels: map[string]string{"backup": "every day"}},
+		{label: "invalid pvAnnotations key", pvAnnotations: map[string]string{"backup.example.com/policy/daily": "true"}},
+		{label: "operator label", pvLabels: map[string]string{"storage.openshift.com/owner-name": "other"}},
+		{label: "kubernetes annotation", pvAnnotations: map[string]string{"pv.kubernetes.io/provisioned-by": "other"}},
+	}
+
+	for _, tc := range testcases {
+		handler := newTestLocalVolumeSetWebhook(t)
+		lvset := newTestLocalVolumeSet(nil)
+		lvset.Spec.PVLabels = tc.pvLabels
+		lvset.Spec.PVAnnotations = tc.pvAnnotations
+		resp := serveAdmission(t, handler, admissionv1beta1.Create, lvset)
+		assert.Equalf(t, tc.allowed, resp.Response.Allowed, "[%s] unexpected admission", tc.label)
+	}
+}
+
+func TestLocalVolumeSetProvisionerConfigValidation(t *testing.T) {
+	testcases := []struct {
+		label             string
+		provisionerConfig map[string]string
+		allowed           bool
+	}{
+		{label: "no provisionerConfig", allowed: true},
+		{label: "supported keys", provisionerConfig: map[string]string{"minResyncPeriod": "30s", "deletionBackoff": "0s"}, allowed: true},
+		{label: "unknown key", provisionerConfig: map[string]string{"labelsForPV": "team=storage"}},
+		{label: "minResyncPeriod above 1h", provisionerConfig: map[string]string{"minResyncPeriod": "2h"}},
+		{label: "negative deletionBackoff", provisionerConfig: map[string]string{"deletionBackoff": "-1m"}},
+	}
+
+	for _, tc := range testcases {
+		handler := newTestLocalVolumeSetWebhook(t)
+		lvset := newTestLocalVolumeSet(nil)
+		lvset.Spec.ProvisionerConfig = tc.provisionerConfig
+		resp := serveAdmission(t, handler, admissionv1beta1.Create, lvset)
+		assert.Equalf(t, tc.allowed, resp.Response.Allowed, "[%s] unexpected admission", tc.label)
+	}
+}
+
+func TestLocalVolumeSetStorageClassNamePattern(t *testing.T) {
+	lvset := newTestLocalVolumeSet(nil)
+	lvset.Spec.StorageClassName = "fast-disks"
+
+	resp := serveAdmission(t, newTestLocalVolumeSetWebhook(t), admissionv1beta1.Create, lvset)
+	assert.True(t, resp.Response.Allowed, "every name is allowed by default")
+
+	handler := newTestLocalVolumeSetWebhookWithPattern(t, "^local-")
+	resp = serveAdmission(t, handler, admissionv1beta1.Create, lvset)
+	assert.False(t, resp.Response.Allowed, "a name without the local- prefix is rejected")
+	resp = serveAdmission(t, handler, admissionv1beta1.Update, lvset)
+	assert.True(t, resp.Response.Allowed, "an existing name is allowed on update")
+	resp = serveAdmission(t, handler, admissionv1beta1.Create, newTestLocalVolumeSet(nil))
+	assert.True(t, resp.Response.Allowed)
+}
+
+func TestLocalVolumeSetDeviceRoutingValidation(t *testing.T) {
+	testcases := []struct {
+		label   string
+		routes  []localv1alpha1.DeviceRoute
+		allowed bool
+	}{
+		{label: "no routes", allowed: true},
+		{label: "partLabel and udevTag routes", routes: []localv1alpha1.DeviceRoute{{PartLabel: "fast", StorageClassName: "local-fast"}, {UdevTag: "archive", StorageClassName: "local-archive"}}, allowed: true},
+		{label: "route without storageClassName", routes: []localv1alpha1.DeviceRoute{{PartLabel: "fast"}}},
+		{label: "route without tag", routes: []localv1alpha1.DeviceRoute{{StorageClassName: "local-fast"}}},
+		{label: "route with both tags", routes: []localv1alpha1.DeviceRoute{{PartLabel: "fast", UdevTag: "fast", StorageClassName: "local-fast"}}},
+	}
+
+	for _, tc := range testcases {
+		handler := newTestLocalVolumeSetWebhook(t)
+		lvset := newTestLocalVolumeSet(nil)
+		lvset.Spec.DeviceRouting = tc.routes
+		resp := serveAdmission(t, handler, admissionv1beta1.Create, lvset)
+		assert.Equalf(t, tc.allowed, resp.Response.Allowed, "[%s] unexpected admission", tc.label)
+	}
+
+	lvset := newTestLocalVolumeSet(nil)
+	lvset.Spec.DeviceRouting = []localv1alpha1.DeviceRoute{{PartLabel: "fast", StorageClassName: "fast-disks"}}
+	resp := serveAdmission(t, newTestLocalVolumeSetWebhookWithPattern(t, "^local-"), admissionv1beta1.Create, lvset)
+	assert.False(t, resp.Response.Allowed, "the StorageClasses of the routes must match the pattern")
+}
+
+func TestLocalVolumeSetProvisioningWindowValidation(t *testing.T) {
+	testcases := []struct {
+		label   string
+		window  *localv1alpha1.ProvisioningWindow
+		allowed bool
+	}{
+		{label: "no window", allowed: true},
+		{label: "valid window", window: &localv1alpha1.ProvisioningWindow{Start: "22:00", End: "06:00", Days: []string{"Sat", "Sun"}, TimeZone: "Europe/Paris"}, allowed: true},
+		{label: "invalid day", window: &localv1alpha1.ProvisioningWindow{Start: "22:00", End: "06:00", Days: []string{"Weekend"}}},
+		{label: "invalid time zone", window: &localv1alpha1.ProvisioningWindow{Start: "22:00", End: "06:00", TimeZone: "Nowhere"}},
+	}
+
+	for _, tc := range testcases {
+		handler := newTestLocalVolumeSetWebhook(t)
+		lvset := newTestLocalVolumeSet(nil)
+		lvset.Spec.ProvisioningWindow = tc.window
+		resp := serveAdmission(t, handler, admissionv1beta1.Create, lvset)
+		assert.Equalf(t, tc.allowed, resp.Response.Allowed, "[%s] unexpected admission", tc.label)
+	}
+}
+
+func TestLocalVolumeSetFSLabelsValidation(t *testing.T) {
+	labels := &localv1alpha1.DeviceInclusionSpec{FSLabels: []string{"pg-data"}}
+	testcases := []struct {
+		label      string
+		spec       *localv1alpha1.DeviceInclusionSpec
+		overrides  []localv1alpha1.NodeOverride
+		reattach   bool
+		volumeMode localv1.PersistentVolumeMode
+		allowed    bool
+	}{
+		{label: "no fsLabels", allowed: true},
+		{label: "fsLabels with reattachExisting", spec: labels, reattach: true, allowed: true},
+		{label: "fsLabels with reattachExisting and Filesystem", spec: labels, reattach: true, volumeMode: localv1.PersistentVolumeFilesystem, allowed: true},
+		{label: "fsLabels without reattachExisting", spec: labels},
+		{label: "fsLabels of a node override without reattachExisting", overrides: []localv1alpha1.NodeOverride{{NodeName: "node1", DeviceInclusionSpec: *labels}}},
+		{label: "fsLabels with Block", spec: labels, reattach: true, volumeMode: localv1.PersistentVolumeBlock},
+	}
+
+	for _, tc := range testcases {
+		handler := newTestLocalVolumeSetWebhook(t)
+		lvset := newTestLocalVolumeSet(tc.spec)
+		lvset.Spec.NodeOverrides = tc.overrides
+		lvset.Spec.ReattachExisting = tc.reattach
+		lvset.Spec.VolumeMode = tc.volumeMode
+		resp := serveAdmission(t, handler, admissionv1beta1.Create, lvset)
+		assert.Equalf(t, tc.allowed, resp.Response.Allowed, "[%s] unexpected admission", tc.label)
+	}
+}
+
+func TestLocalVolumeSetDeleteBoundPVsWarning(t *testing.T) {
+	newPV := func(name, owner string, phase corev1.PersistentVolumePhase, claimNamespace string) *corev1.PersistentVolume {
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					common.PVOwnerKindLabel:      localv1alpha1.LocalVolumeSetKind,
+					common.PVOwnerNameLabel:      owner,
+					common.PVOwnerNamespaceLabel: testNamespace,
+				},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: phase},
+		}
+		if claimNamespace != "" {
+			pv.Spec.ClaimRef = &corev1.ObjectReference{Kind: "PersistentVolumeClaim", Namespace: claimNamespace, Name: "data"}
+		}
+		return pv
+	}
+	scheme, err := localv1alpha1.SchemeBuilder.Build()
+	assert.NoError(t, err)
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	validator := &localVolumeSetValidator{client: fake.NewFakeClientWithScheme(scheme,
+		newPV("pv-a", "lvset", corev1.VolumeBound, "team-b"),
+		newPV("pv-b", "lvset", corev1.VolumeBound, "team-a"),
+		newPV("pv-c", "lvset", corev1.VolumeBound, "team-a"),
+		newPV("pv-d", "lvset", corev1.VolumeAvailable, ""),
+		newPV("pv-e", "lvset", corev1.VolumeReleased, "team-c"),
+		newPV("pv-f", "other-lvset", corev1.VolumeBound, "team-d"),
+	)}
+	hook := &admission.Webhook{Handler: validator}
+	assert.NoError(t, hook.InjectScheme(scheme))
+	assert.NoError(t, hook.InjectLogger(log))
+	handler := withWarnings(hook)
+
+	resp := serveAdmission(t, handler, admissionv1beta1.Delete, newTestLocalVolumeSet(nil))
+	assert.True(t, resp.Response.Allowed, "the deletion is not blocked")
+	assert.Equal(t, []string{
+		"LocalVolumeSet local-storage/lvset has 3 bound PV(s) claimed in 2 namespace(s): team-a, team-b",
+	}, resp.Response.Warnings)
+
+	lvset := newTestLocalVolumeSet(nil)
+	lvset.Name = "unused-lvset"
+	resp = serveAdmission(t, handler, admissionv1beta1.Delete, lvset)
+	assert.True(t, resp.Response.Allowed)
+	assert.Empty(t, resp.Response.Warnings, "no bound PV")
+}
+
+func TestLocalVolumeSetLockedFields(t *testing.T) {
+	minSize := resource.MustParse("100Gi")
+	newLVSet := func(locked bool, models ...string) *localv1alpha1.LocalVolumeSet {
+		lvset := newTestLocalVolumeSet(&localv1alpha1.DeviceInclusionSpec{MinSize: &minSize, Models: models})
+		if locked {
+			lvset.Annotations = map[string]string{common.LockedAnnotation: "true"}
+		}
+		return lvset
+	}
+	testcases := []struct {
+		label   string
+		oldObj  *localv1alpha1.LocalVolumeSet
+		obj     *localv1alpha1.LocalVolumeSet
+		allowed bool
+	}{
+		{label: "unlocked filter change", oldObj: newLVSet(false, "SSD"), obj: newLVSet(false, "SSD", "HDD"), allowed: true},
+		{label: "locked filter change", oldObj: newLVSet(true, "SSD"), obj: newLVSet(true, "SSD", "HDD")},
+		{label: "locking with a filter change", oldObj: newLVSet(false, "SSD"), obj: newLVSet(true, "SSD", "HDD"), allowed: true},
+		{label: "unlocking with a filter change", oldObj: newLVSet(true, "SSD"), obj: newLVSet(false, "SSD", "HDD")},
+		{label: "unlocking", oldObj: newLVSet(true, "SSD"), obj: newLVSet(false, "SSD"), allowed: true},
+		{label: "locked metadata change", oldObj: newLVSet(true, "SSD"), obj: func() *localv1alpha1.LocalVolumeSet {
+			lvset := newLVSet(true, "SSD")
+			lvset.Labels = map[string]string{"team": "storage"}
+			lvset.Spec.PVLabels = map[string]string{"tier": "fast"}
+			return lvset
+		}(), allowed: true},
+		{label: "locked nodeSelector change", oldObj: newLVSet(true, "SSD"), obj: func() *localv1alpha1.LocalVolumeSet {
+			lvset := newLVSet(true, "SSD")
+			lvset.Spec.NodeSelector = &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{}}}
+			return lvset
+		}()},
+	}
+
+	for _, tc := range testcases {
+		resp := serveAdmissionUpdate(t, newTestLocalVolumeSetWebhook(t), admissionv1beta1.Update, tc.obj, tc.oldObj)
+		assert.Equalf(t, tc.allowed, resp.Response.Allowed, "[%s] unexpected admission", tc.label)
+	}
+}
@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// ReconcilePath is the path of the endpoint that enqueues all the objects of the operator for an immediate reconcile
+const ReconcilePath = "/reconcile"
+
+// reconcileResources are the resources a client must be allowed to update to hit the reconcile endpoint
+var reconcileResources = []string{"localvolumes", "localvolumesets"}
+
+// AddReconcileEndpoint registers the reconcile endpoint with the Manager's webhook server.
+// Only the clients whose bearer token can update the LocalVolumes and LocalVolumeSets of namespace are served,
+// a namespace list or an empty namespace requires the permission in all namespaces.
+func AddReconcileEndpoint(mgr manager.Manager, namespace string) error {
+	if strings.Contains(namespace, ",") {
+		namespace = ""
+	}
+	mgr.GetWebhookServer().Register(ReconcilePath, &reconcileHandler{client: mgr.GetClient(), namespace: namespace})
+	return nil
+}
+
+// reconcileHandler serves the reconcile endpoint
+type reconcileHandler struct {
+	client    client.Client
+	namespace string
+}
+
+var _ http.Handler = &reconcileHandler{}
+
+func (h *reconcileHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == req.Header.Get("Authorization") {
+		http.Error(w, "a bearer token is required", http.StatusUnauthorized)
+		return
+	}
+
+	review := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	err := h.client.Create(context.TODO(), review)
+	if err != nil {
+		log.Error(err, "failed to review the token of a reconcile request")
+		http.Error(w, "failed to review the token", http.StatusInternalServerError)
+		return
+	}
+	if !review.Status.Authenticated {
+		http.Error(w, "the bearer token is not valid", http.StatusUnauthorized)
+		return
+	}
+	user := review.Status.User
+
+	for _, resource := range reconcileResources {
+		allowed, err := h.allowed(user, resource)
+		if err != nil {
+			log.Error(err, "failed to review the access of a reconcile request", "user", user.Username)
+			http.Error(w, "failed to review the access", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			http.Error(w, "update of "+resource+" is not allowed", http.StatusForbidden)
+			return
+		}
+	}
+
+	log.Info("reconciling all objects", "user", user.Username)
+	common.ReconcileAll()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// allowed returns true if user can update the resource in the namespace of the handler
+func (h *reconcileHandler) allowed(user authenticationv1.UserInfo, resource string) (bool, error) {
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: h.namespace,
+				Verb:      "update",
+				Group:     localv1.SchemeGroupVersion.Group,
+				Resource:  resource,
+			},
+		},
+	}
+	err := h.client.Create(context.TODO(), review)
+	if err != nil {
+		return false, err
+	}
+	return review.Status.Allowed, nil
+}
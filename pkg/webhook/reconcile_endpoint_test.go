@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// reviewClient answers the TokenReviews and SubjectAccessReviews like the API server would
+type reviewClient struct {
+	client.Client
+	// users are the usernames of the valid tokens
+	users map[string]string
+	// allowed are the resources each user can update
+	allowed map[string][]string
+}
+
+func (c *reviewClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	switch review := obj.(type) {
+	case *authenticationv1.TokenReview:
+		username, found := c.users[review.Spec.Token]
+		review.Status.Authenticated = found
+		review.Status.User.Username = username
+	case *authorizationv1.SubjectAccessReview:
+		for _, resource := range c.allowed[review.Spec.User] {
+			if resource == review.Spec.ResourceAttributes.Resource && review.Spec.ResourceAttributes.Verb == "update" {
+				review.Status.Allowed = true
+			}
+		}
+	}
+	return nil
+}
+
+func TestReconcileEndpoint(t *testing.T) {
+	handler := &reconcileHandler{
+		client: &reviewClient{
+			Client: fake.NewFakeClient(),
+			users:  map[string]string{"admin-token": "admin", "viewer-token": "viewer"},
+			allowed: map[string][]string{
+				"admin":  {"localvolumes", "localvolumesets"},
+				"viewer": {"localvolumes"},
+			},
+		},
+		namespace: testNamespace,
+	}
+	reconcileAll := common.SubscribeReconcileAll()
+
+	testCases := []struct {
+		name           string
+		method         string
+		authorization  string
+		expectedStatus int
+	}{
+		{name: "GET is not allowed", method: http.MethodGet, authorization: "Bearer admin-token", expectedStatus: http.StatusMethodNotAllowed},
+		{name: "no token", method: http.MethodPost, expectedStatus: http.StatusUnauthorized},
+		{name: "not a bearer token", method: http.MethodPost, authorization: "Basic YWRtaW46YWRtaW4=", expectedStatus: http.StatusUnauthorized},
+		{name: "invalid token", method: http.MethodPost, authorization: "Bearer other-token", expectedStatus: http.StatusUnauthorized},
+		{name: "user that can't update LocalVolumeSets", method: http.MethodPost, authorization: "Bearer viewer-token", expectedStatus: http.StatusForbidden},
+		{name: "allowed user", method: http.MethodPost, authorization: "Bearer admin-token", expectedStatus: http.StatusAccepted},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, ReconcilePath, nil)
+		if tc.authorization != "" {
+			req.Header.Set("Authorization", tc.authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equalf(t, tc.expectedStatus, rec.Code, "%s: %s", tc.name, rec.Body.String())
+
+		select {
+		case <-reconcileAll:
+			assert.Equalf(t, http.StatusAccepted, tc.expectedStatus, "%s: reconciled all objects", tc.name)
+		case <-time.After(10 * time.Millisecond):
+			assert.NotEqualf(t, http.StatusAccepted, tc.expectedStatus, "%s: did not reconcile all objects", tc.name)
+		}
+	}
+}
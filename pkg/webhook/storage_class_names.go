@@ -0,0 +1,24 @@
+package webhook
+
+import (
+	"regexp"
+
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// validateStorageClassNames checks the StorageClass names against the pattern, the --storage-class-name-pattern of the operator.
+// The names of oldNames are not checked again, so that objects created before the pattern was set
+// can still be updated and deleted.
+func validateStorageClassNames(pattern *regexp.Regexp, names, oldNames []string) error {
+	existing := sets.NewString(oldNames...)
+	for _, name := range names {
+		if existing.Has(name) {
+			continue
+		}
+		if err := common.ValidateStorageClassName(pattern, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
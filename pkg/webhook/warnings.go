@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// The vendored admission API predates AdmissionResponse.Warnings, which the API server
+// (1.19+) shows to the client, e.g. as "Warning: ..." lines in kubectl output.
+// Handlers add warnings to the request context with addWarning and withWarnings writes
+// them into the encoded AdmissionReview response.
+
+type warningsKey struct{}
+
+type warningRecorder struct {
+	lock     sync.Mutex
+	warnings []string
+}
+
+// addWarning records a warning to be returned to the client with the admission response.
+// It is a no-op when the handler is not wrapped by withWarnings.
+func addWarning(ctx context.Context, warning string) {
+	recorder, ok := ctx.Value(warningsKey{}).(*warningRecorder)
+	if !ok {
+		return
+	}
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	recorder.warnings = append(recorder.warnings, warning)
+}
+
+// getWarnings returns the warnings recorded in ctx.
+func getWarnings(ctx context.Context) []string {
+	recorder, ok := ctx.Value(warningsKey{}).(*warningRecorder)
+	if !ok {
+		return nil
+	}
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	return recorder.warnings
+}
+
+type bufferedResponseWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return b.body.Write(data)
+}
+
+func (b *bufferedResponseWriter) WriteHeader(statusCode int) {
+	b.statusCode = statusCode
+}
+
+// warningsWebhook is an admission webhook whose AdmissionReview responses carry the
+// warnings recorded by its handler. It embeds the webhook so that the scheme, logger and
+// inject func the webhook server sets on registered handlers still reach the webhook
+// and its handler.
+type warningsWebhook struct {
+	*admission.Webhook
+}
+
+// withWarnings wraps an admission webhook so that warnings recorded by its handler
+// are added to the "warnings" field of the AdmissionReview response.
+func withWarnings(hook *admission.Webhook) *warningsWebhook {
+	return &warningsWebhook{Webhook: hook}
+}
+
+// ServeHTTP serves the wrapped webhook and adds the recorded warnings to its response.
+func (wh *warningsWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := context.WithValue(r.Context(), warningsKey{}, &warningRecorder{})
+	buffered := &bufferedResponseWriter{header: w.Header(), statusCode: http.StatusOK}
+	wh.Webhook.ServeHTTP(buffered, r.WithContext(ctx))
+
+	body := buffered.body.Bytes()
+	if warnings := getWarnings(ctx); len(warnings) > 0 {
+		review := map[string]interface{}{}
+		if err := json.Unmarshal(body, &review); err == nil {
+			if response, ok := review["response"].(map[string]interface{}); ok {
+				response["warnings"] = warnings
+				if encoded, err := json.Marshal(review); err == nil {
+					body = encoded
+				}
+			}
+		}
+	}
+
+	w.WriteHeader(buffered.statusCode)
+	w.Write(body)
+}
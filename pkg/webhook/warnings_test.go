@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"net/http"
+	"testing"
+
+	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
+	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// registerTestWebhook registers hook with a webhook server and injects its fields the way
+// the manager and the webhook server do, so the test exercises the same injection as a
+// running operator. It returns a handler that serves requests at path through the server's mux.
+func registerTestWebhook(t *testing.T, path string, hook http.Handler, scheme *runtime.Scheme, c client.Client) http.Handler {
+	server := &webhook.Server{}
+	server.Register(path, hook)
+
+	var setFields inject.Func
+	setFields = func(i interface{}) error {
+		if _, err := inject.ClientInto(c, i); err != nil {
+			return err
+		}
+		if _, err := inject.SchemeInto(scheme, i); err != nil {
+			return err
+		}
+		if _, err := inject.InjectorInto(setFields, i); err != nil {
+			return err
+		}
+		return nil
+	}
+	assert.NoError(t, setFields(hook))
+	_, err := inject.LoggerInto(log, hook)
+	assert.NoError(t, err)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Path = path
+		server.WebhookMux.ServeHTTP(w, r)
+	})
+}
+
+func TestRegisteredValidatingWebhooks(t *testing.T) {
+	scheme := newTestScheme(t)
+	assert.NoError(t, localv1alpha1.SchemeBuilder.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewFakeClientWithScheme(scheme)
+
+	lvHandler := registerTestWebhook(t, LocalVolumeValidatePath, newLocalVolumeValidatingWebhook(nil), scheme, c)
+	lv := &localv1.LocalVolume{
+		TypeMeta:   metav1.TypeMeta{APIVersion: localv1.SchemeGroupVersion.String(), Kind: "LocalVolume"},
+		ObjectMeta: metav1.ObjectMeta{Name: "local-disks", Namespace: testNamespace},
+		Spec: localv1.LocalVolumeSpec{StorageClassDevices: []localv1.StorageClassDevice{
+			{StorageClassName: "fs", DevicePaths: []string{"/dev/sdb"}},
+		}},
+	}
+	resp := serveAdmission(t, lvHandler, admissionv1beta1.Create, lv)
+	assert.True(t, resp.Response.Allowed)
+	assert.Len(t, resp.Response.Warnings, 1, "kernel device name warning")
+	resp = serveAdmission(t, lvHandler, admissionv1beta1.Delete, lv)
+	assert.True(t, resp.Response.Allowed)
+
+	lvsetHandler := registerTestWebhook(t, LocalVolumeSetValidatePath, newLocalVolumeSetValidatingWebhook(nil), scheme, c)
+	lvset := newTestLocalVolumeSet(nil)
+	resp = serveAdmission(t, lvsetHandler, admissionv1beta1.Create, lvset)
+	assert.True(t, resp.Response.Allowed)
+	resp = serveAdmission(t, lvsetHandler, admissionv1beta1.Delete, lvset)
+	assert.True(t, resp.Response.Allowed)
+
+	lvset.Spec.PVLabels = map[string]string{"-backup": "daily"}
+	resp = serveAdmission(t, lvsetHandler, admissionv1beta1.Create, lvset)
+	assert.False(t, resp.Response.Allowed, "invalid LocalVolumeSets are rejected")
+}
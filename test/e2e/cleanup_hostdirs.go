@@ -131,7 +131,7 @@ set +x
 	privileged := true
 	containers := []corev1.Container{
 		{
-			Image: common.GetDiskMakerImage(),
+			Image: getDiskMakerImage(),
 			Command: []string{
 				"/bin/bash",
 				"-c",
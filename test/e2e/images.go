@@ -0,0 +1,33 @@
+package e2e
+
+import (
+	"os"
+
+	"github.com/openshift/local-storage-operator/pkg/common"
+)
+
+// defaultConsumerImage runs the jobs that write to and read from the PVs under test
+const defaultConsumerImage = "gcr.io/google_containers/busybox"
+
+// env var config helpers
+
+func getOperatorImage() string {
+	return os.Getenv("IMAGE_LOCAL_DISKMAKER")
+}
+
+// getDiskMakerImage returns the diskmaker image deployed by the tests, which also runs their cleanup jobs
+func getDiskMakerImage() string {
+	if image := os.Getenv("IMAGE_LOCAL_DISKMAKER"); image != "" {
+		return image
+	}
+	return common.GetDiskMakerImage()
+}
+
+// getConsumerImage returns the image of the jobs that consume the PVs,
+// TEST_CONSUMER_IMAGE points it to a mirror in disconnected clusters
+func getConsumerImage() string {
+	if image := os.Getenv("TEST_CONSUMER_IMAGE"); image != "" {
+		return image
+	}
+	return defaultConsumerImage
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -30,7 +31,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
-	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -113,10 +113,21 @@ func LocalVolumeTest(ctx *framework.Context, cleanupFuncs *[]cleanupFn) func(*te
 		// get the device paths and IDs
 		nodeEnv = populateDeviceInfo(t, ctx, nodeEnv)
 
-		selectedDisk := nodeEnv[0].disks[0]
-		matcher.Expect(selectedDisk.path).ShouldNot(gomega.BeZero(), "device path should not be empty")
+		// every disk of the selected node is provisioned, so that a PV pointing to the wrong disk is caught
+		selectedDisks := nodeEnv[0].disks
+		devicePaths := make([]string, 0, len(selectedDisks))
+		expectedPaths := make([]string, 0, len(selectedDisks))
+		for _, selectedDisk := range selectedDisks {
+			matcher.Expect(selectedDisk.path).ShouldNot(gomega.BeZero(), "device path should not be empty")
+			devicePaths = append(devicePaths, selectedDisk.path)
+			if selectedDisk.id != "" {
+				expectedPaths = append(expectedPaths, selectedDisk.id)
+			} else {
+				expectedPaths = append(expectedPaths, selectedDisk.name)
+			}
+		}
 
-		localVolume := getFakeLocalVolume(selectedNode, selectedDisk.path, namespace)
+		localVolume := getFakeLocalVolume(selectedNode, devicePaths, namespace)
 
 		matcher.Eventually(func() error {
 			t.Log("creating localvolume")
@@ -131,7 +142,7 @@ func LocalVolumeTest(ctx *framework.Context, cleanupFuncs *[]cleanupFn) func(*te
 				return cleanupLVResources(t, f, localVolume)
 			},
 		)
-		err = waitForDaemonSet(t, f.KubeClient, namespace, nodedaemon.DiskMakerName, retryInterval, timeout)
+		err = waitForDaemonSet(t, f.KubeClient, namespace, nodedaemon.DiskMakerName, 1, retryInterval, timeout)
 		if err != nil {
 			t.Fatalf("error waiting for diskmaker daemonset : %v", err)
 		}
@@ -141,48 +152,51 @@ func LocalVolumeTest(ctx *framework.Context, cleanupFuncs *[]cleanupFn) func(*te
 			t.Fatalf("error verifying localvolume cr: %v", err)
 		}
 
-		err = checkLocalVolumeStatus(t, localVolume)
-		if err != nil {
-			t.Fatalf("error checking localvolume condition: %v", err)
-		}
+		pvs := eventuallyFindPVs(t, f, localVolume.Spec.StorageClassDevices[0].StorageClassName, len(selectedDisks))
 
-		pvs := eventuallyFindPVs(t, f, localVolume.Spec.StorageClassDevices[0].StorageClassName, 1)
-		var expectedPath string
-		if len(pvs) > 0 {
-			if selectedDisk.id != "" {
-				expectedPath = selectedDisk.id
-			} else {
-				expectedPath = selectedDisk.name
+		// the localvolume is Available once its first PV exists
+		matcher.Eventually(func() error {
+			err := f.Client.Get(goctx.TODO(), dynclient.ObjectKey{Namespace: localVolume.Namespace, Name: localVolume.Name}, localVolume)
+			if err != nil {
+				return err
 			}
-		} else {
-			t.Fatalf("no pvs returned by eventuallyFindPVs: %+v", pvs)
+			return checkLocalVolumeStatus(t, localVolume)
+		}, time.Minute*2, time.Second*5).ShouldNot(gomega.HaveOccurred(), "checking localvolume condition")
+		// each disk is provisioned once, by its own symlink
+		pvPaths := make([]string, 0, len(pvs))
+		for _, pv := range pvs {
+			pvPaths = append(pvPaths, filepath.Base(pv.Spec.Local.Path))
+		}
+		matcher.Expect(pvPaths).To(gomega.ConsistOf(expectedPaths), "expected one PV for each disk")
+
+		// the PVs advertise the access modes of the spec
+		for _, pv := range pvs {
+			matcher.Expect(pv.Spec.AccessModes).To(gomega.ConsistOf(localVolume.Spec.StorageClassDevices[0].AccessModes), "access modes of PV %q", pv.Name)
 		}
-		matcher.Expect(filepath.Base(pvs[0].Spec.Local.Path)).To(gomega.Equal(expectedPath))
 
 		// verify pv annotation
 		t.Logf("looking for %q annotation on pvs", provCommon.AnnProvisionedBy)
 		verifyProvisionerAnnotation(t, pvs, nodeList.Items)
 
+		t.Log("verifying the provisioner configmap keeps the keys added by other tools")
+		verifyProvisionerConfigMapKeepsForeignKeys(t, f.KubeClient, namespace, localVolume.Spec.StorageClassDevices[0].StorageClassName)
+
 		// verify deletion
 		for _, pv := range pvs {
 			eventuallyDelete(t, &pv)
 		}
 		// verify that PVs come back after deletion
-		pvs = eventuallyFindPVs(t, f, localVolume.Spec.StorageClassDevices[0].StorageClassName, 1)
+		pvs = eventuallyFindPVs(t, f, localVolume.Spec.StorageClassDevices[0].StorageClassName, len(selectedDisks))
 
-		// consume pvs
-		consumingObjectList := make([]runtime.Object, 0)
-		for _, pv := range pvs {
-			pvc, job, pod := consumePV(t, ctx, pv)
-			consumingObjectList = append(consumingObjectList, job, pvc, pod)
-		}
+		// consume all pvs at once, checking the data written to every disk
+		consumingObjectList := consumePVs(t, ctx, pvs)
 		// release pvs
 		eventuallyDelete(t, consumingObjectList...)
 
 		// verify that PVs eventually come back
 		matcher.Eventually(func() bool {
 
-			newPVs := eventuallyFindPVs(t, f, localVolume.Spec.StorageClassDevices[0].StorageClassName, 1)
+			newPVs := eventuallyFindPVs(t, f, localVolume.Spec.StorageClassDevices[0].StorageClassName, len(selectedDisks))
 			for _, pv := range pvs {
 				pvFound := false
 				for _, newPV := range newPVs {
@@ -214,6 +228,15 @@ func LocalVolumeTest(ctx *framework.Context, cleanupFuncs *[]cleanupFn) func(*te
 			pvc, job, pod := consumePV(t, ctx, pv)
 			consumingObjectList = append(consumingObjectList, job, pvc, pod)
 		}
+		// deprecate the storageclass while the bound PV is released
+		matcher.Eventually(func() error {
+			err := f.Client.Get(goctx.TODO(), types.NamespacedName{Name: localVolume.Name, Namespace: f.Namespace}, localVolume)
+			if err != nil {
+				return err
+			}
+			localVolume.Spec.DeletionPolicy = localv1.DeletionPolicyDrainThenDelete
+			return f.Client.Update(goctx.TODO(), localVolume)
+		}, time.Minute, time.Second*5).ShouldNot(gomega.HaveOccurred(), "setting the deletionPolicy of LocalVolume %q", localVolume.Name)
 		// attempt localVolume deletion
 		matcher.Eventually(func() error {
 			t.Logf("deleting LocalVolume %q", localVolume.Name)
@@ -233,6 +256,16 @@ func LocalVolumeTest(ctx *framework.Context, cleanupFuncs *[]cleanupFn) func(*te
 			}
 			return len(localVolume.ObjectMeta.Finalizers) > 0
 		}, time.Second*30, time.Second*5).Should(gomega.BeTrue(), "checking finalizer exists with bound PVs")
+		matcher.Eventually(func() bool {
+			t.Logf("verifying the storageclass is deprecated")
+			sc, err := f.KubeClient.StorageV1().StorageClasses().Get(localVolume.Spec.StorageClassDevices[0].StorageClassName, metav1.GetOptions{})
+			if err != nil {
+				t.Logf("error getting StorageClass: %+v", err)
+				return false
+			}
+			_, found := sc.Annotations["local.storage.openshift.io/deprecated"]
+			return found
+		}, time.Minute, time.Second*5).Should(gomega.BeTrue(), "checking the storageclass is deprecated with bound PVs")
 		// release PV
 		t.Logf("releasing pvs")
 		eventuallyDelete(t, consumingObjectList...)
@@ -254,7 +287,35 @@ func LocalVolumeTest(ctx *framework.Context, cleanupFuncs *[]cleanupFn) func(*te
 
 }
 
+// consumePV runs a job that writes to the PV and checks the md5 of the data read back,
+// and returns the objects to delete to release the PV
 func consumePV(t *testing.T, ctx *framework.Context, pv corev1.PersistentVolume) (*corev1.PersistentVolumeClaim, *batchv1.Job, *corev1.Pod) {
+	pvc, job, timeStarted := startPVConsumer(t, ctx, pv)
+	pod := waitForPVConsumer(t, pv, job, timeStarted)
+	return pvc, job, pod
+}
+
+// consumePVs runs the consuming jobs of all PVs in parallel, like consumePV,
+// and returns the objects to delete to release the PVs
+func consumePVs(t *testing.T, ctx *framework.Context, pvs []corev1.PersistentVolume) []runtime.Object {
+	consumingObjectList := make([]runtime.Object, 0)
+	jobs := make([]*batchv1.Job, 0, len(pvs))
+	timesStarted := make([]time.Time, 0, len(pvs))
+	for _, pv := range pvs {
+		pvc, job, timeStarted := startPVConsumer(t, ctx, pv)
+		consumingObjectList = append(consumingObjectList, job, pvc)
+		jobs = append(jobs, job)
+		timesStarted = append(timesStarted, timeStarted)
+	}
+	for i, pv := range pvs {
+		pod := waitForPVConsumer(t, pv, jobs[i], timesStarted[i])
+		consumingObjectList = append(consumingObjectList, pod)
+	}
+	return consumingObjectList
+}
+
+// startPVConsumer creates the PVC and the consuming job of the PV, and returns the time the job was created
+func startPVConsumer(t *testing.T, ctx *framework.Context, pv corev1.PersistentVolume) (*corev1.PersistentVolumeClaim, *batchv1.Job, time.Time) {
 	matcher := gomega.NewWithT(t)
 	f := framework.Global
 	name := fmt.Sprintf("%s-consumer", pv.ObjectMeta.Name)
@@ -296,7 +357,7 @@ func consumePV(t *testing.T, ctx *framework.Context, pv corev1.PersistentVolume)
 					Containers: []corev1.Container{
 						{
 							Name:  "busybox",
-							Image: "gcr.io/google_containers/busybox",
+							Image: getConsumerImage(),
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									MountPath: "/data",
@@ -304,13 +365,16 @@ func consumePV(t *testing.T, ctx *framework.Context, pv corev1.PersistentVolume)
 								},
 							},
 							Command: []string{"/bin/sh", "-c"},
-							Args: []string{
+							// sh -c only runs its first argument, the steps are joined into one script
+							Args: []string{strings.Join([]string{
+								"set -e",
 								"dd if=/dev/random of=/tmp/random.img bs=512 count=1",     // create a new file named random.img
 								"md5VAR1=$(md5sum /tmp/random.img | awk '{ print $1 }')",  // calculate md5sum of random.img
 								"cp /tmp/random.img /data/random.img",                     // copy random.img file to pvc mountpoint
+								"sync",                                                    // flush random.img to the disk
 								"md5VAR2=$(md5sum /data/random.img | awk '{ print $1 }')", // recalculate md5sum of file random.img stored in pvc
 								"if [[ \"$md5VAR1\" != \"$md5VAR2\" ]];then exit 1; fi",   // verifies that the md5sum hasn't changed
-							},
+							}, "\n")},
 						},
 					},
 					Volumes: []corev1.Volume{
@@ -344,6 +408,13 @@ func consumePV(t *testing.T, ctx *framework.Context, pv corev1.PersistentVolume)
 		t.Logf("creating job: %q", job.Name)
 		return f.Client.Create(goctx.TODO(), job, &framework.CleanupOptions{TestContext: ctx})
 	}, time.Minute, time.Second*2).ShouldNot(gomega.HaveOccurred(), "creating job")
+	return pvc, job, timeStarted
+}
+
+// waitForPVConsumer waits for the consuming job of the PV to complete and returns its pod
+func waitForPVConsumer(t *testing.T, pv corev1.PersistentVolume, job *batchv1.Job, timeStarted time.Time) *corev1.Pod {
+	matcher := gomega.NewWithT(t)
+	f := framework.Global
 
 	// wait for job to complete
 	matcher.Eventually(func() int32 {
@@ -394,7 +465,7 @@ func consumePV(t *testing.T, ctx *framework.Context, pv corev1.PersistentVolume)
 	}).ShouldNot(gomega.HaveOccurred(), "fetching consuming pod")
 
 	matchingPod.TypeMeta.Kind = "Pod"
-	return pvc, job, &matchingPod
+	return &matchingPod
 }
 
 func verifyProvisionerAnnotation(t *testing.T, pvs []corev1.PersistentVolume, nodeList []corev1.Node) {
@@ -497,6 +568,44 @@ func verifyDaemonSetTolerations(kubeclient kubernetes.Interface, daemonSetName,
 	return nil
 }
 
+// verifyProvisionerConfigMapKeepsForeignKeys adds a key to the provisioner configmap, which triggers its reconcile,
+// and verifies that the operator keeps both the key and its own storageClass config
+func verifyProvisionerConfigMapKeepsForeignKeys(t *testing.T, kubeclient kubernetes.Interface, namespace, scName string) {
+	matcher := gomega.NewWithT(t)
+	foreignKey := "e2e-foreign-key"
+	matcher.Eventually(func() error {
+		configMap, err := kubeclient.CoreV1().ConfigMaps(namespace).Get(common.ProvisionerConfigMapName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[foreignKey] = "kept"
+		_, err = kubeclient.CoreV1().ConfigMaps(namespace).Update(configMap)
+		return err
+	}, time.Minute, time.Second*2).ShouldNot(gomega.HaveOccurred(), "adding a key to the provisioner configmap")
+
+	matcher.Consistently(func() error {
+		configMap, err := kubeclient.CoreV1().ConfigMaps(namespace).Get(common.ProvisionerConfigMapName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if configMap.Data[foreignKey] != "kept" {
+			return fmt.Errorf("key %q was removed from the provisioner configmap", foreignKey)
+		}
+		config := provCommon.ProvisionerConfiguration{}
+		err = provCommon.ConfigMapDataToVolumeConfig(configMap.Data, &config)
+		if err != nil {
+			return err
+		}
+		if _, found := config.StorageClassConfig[scName]; !found {
+			return fmt.Errorf("storageclass %q is missing from the provisioner configmap", scName)
+		}
+		return nil
+	}, time.Second*30, time.Second*5).ShouldNot(gomega.HaveOccurred(), "checking the provisioner configmap")
+}
+
 func verifyStorageClassDeletion(scName string, kubeclient kubernetes.Interface) error {
 	waitError := wait.Poll(retryInterval, timeout, func() (done bool, err error) {
 		_, err = kubeclient.StorageV1().StorageClasses().Get(scName, metav1.GetOptions{})
@@ -541,7 +650,7 @@ func waitForCreatedPV(kubeClient kubernetes.Interface, lv *localv1.LocalVolume)
 	waitErr := wait.PollImmediate(retryInterval, timeout, func() (bool, error) {
 		pvs, err := kubeClient.CoreV1().PersistentVolumes().List(metav1.ListOptions{LabelSelector: commontypes.GetPVOwnerSelector(lv).String()})
 		if err != nil {
-			if isRetryableAPIError(err) {
+			if common.IsRetryableAPIError(err) {
 				return false, nil
 			}
 			return false, err
@@ -597,19 +706,6 @@ func getNitroDisk(kubeClient kubernetes.Interface, node v1.Node) (string, error)
 	return "", fmt.Errorf("unimplemented")
 }
 
-func isRetryableAPIError(err error) bool {
-	// These errors may indicate a transient error that we can retry in tests.
-	if apierrors.IsInternalError(err) || apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err) ||
-		apierrors.IsTooManyRequests(err) || utilnet.IsProbableEOF(err) || utilnet.IsConnectionReset(err) {
-		return true
-	}
-	// If the error sends the Retry-After header, we respect it as an explicit confirmation we should retry.
-	if _, shouldRetry := apierrors.SuggestsClientDelay(err); shouldRetry {
-		return true
-	}
-	return false
-}
-
 // waitListSchedulableNodes is a wrapper around listing nodes supporting retries.
 func waitListSchedulableNodes(c kubernetes.Interface) (*v1.NodeList, error) {
 	var nodes *v1.NodeList
@@ -619,7 +715,7 @@ func waitListSchedulableNodes(c kubernetes.Interface) (*v1.NodeList, error) {
 			"spec.unschedulable": "false",
 		}.AsSelector().String()})
 		if err != nil {
-			if isRetryableAPIError(err) {
+			if common.IsRetryableAPIError(err) {
 				return false, nil
 			}
 			return false, err
@@ -631,8 +727,7 @@ func waitListSchedulableNodes(c kubernetes.Interface) (*v1.NodeList, error) {
 	return nodes, nil
 }
 
-func waitForDaemonSet(t *testing.T, kubeclient kubernetes.Interface, namespace, name string, retryInterval, timeout time.Duration) error {
-	nodeCount := 1
+func waitForDaemonSet(t *testing.T, kubeclient kubernetes.Interface, namespace, name string, nodeCount int, retryInterval, timeout time.Duration) error {
 	var err error
 	err = wait.Poll(retryInterval, timeout, func() (done bool, err error) {
 		daemonset, err := kubeclient.AppsV1().DaemonSets(namespace).Get(name, metav1.GetOptions{})
@@ -677,7 +772,7 @@ func waitForNodeTaintUpdate(t *testing.T, kubeclient kubernetes.Interface, node
 	return *newNode, nil
 }
 
-func getFakeLocalVolume(selectedNode v1.Node, selectedDisk, namespace string) *localv1.LocalVolume {
+func getFakeLocalVolume(selectedNode v1.Node, devicePaths []string, namespace string) *localv1.LocalVolume {
 	localVolume := &localv1.LocalVolume{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "LocalVolume",
@@ -707,7 +802,8 @@ func getFakeLocalVolume(selectedNode v1.Node, selectedDisk, namespace string) *l
 			StorageClassDevices: []localv1.StorageClassDevice{
 				{
 					StorageClassName: "test-local-sc",
-					DevicePaths:      []string{selectedDisk},
+					AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce, v1.ReadOnlyMany},
+					DevicePaths:      devicePaths,
 				},
 			},
 		},
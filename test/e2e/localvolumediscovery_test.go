@@ -62,7 +62,7 @@ func LocalVolumeDiscoveryTest(ctx *framework.Context, cleanupFuncs *[]cleanupFn)
 		defer deleteResource(localVolumeDiscovery, localVolumeDiscovery.Name, localVolumeDiscovery.Namespace, f.Client)
 
 		discoveryDSName := "diskmaker-discovery"
-		err = waitForDaemonSet(t, f.KubeClient, namespace, discoveryDSName, retryInterval, timeout)
+		err = waitForDaemonSet(t, f.KubeClient, namespace, discoveryDSName, 1, retryInterval, timeout)
 		if err != nil {
 			t.Fatalf("error waiting for diskmaker daemonset : %v", err)
 		}
@@ -16,6 +16,7 @@ import (
 
 	localv1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1"
 	localv1alpha1 "github.com/openshift/local-storage-operator/pkg/apis/local/v1alpha1"
+	"github.com/openshift/local-storage-operator/pkg/controller/nodedaemon"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -321,6 +322,24 @@ func LocalVolumeSetTest(ctx *framework.Context, cleanupFuncs *[]cleanupFn) func(
 		}, time.Minute, time.Second*2).ShouldNot(gomega.HaveOccurred(), "updating lvset")
 		eventuallyFindPVs(t, f, twentyToFiftyFilesystem.Spec.StorageClassName, 9)
 
+		// the PVs must come back pointing at the same disks after the diskmakers rediscover the devices,
+		// and after a reboot, which can rename the devices
+		expectedPVs := map[string]int{
+			twentyToFifty.Spec.StorageClassName:           3,
+			tenToThirty.Spec.StorageClassName:             3,
+			twentyToFiftyFilesystem.Spec.StorageClassName: 9,
+		}
+		devices := map[string]map[string]string{}
+		for storageClassName, count := range expectedPVs {
+			devices[storageClassName] = pvDevices(eventuallyFindPVs(t, f, storageClassName, count))
+		}
+		restartDiskMaker(t, f, namespace, nodeEnv[1].node)
+		rebootAWSNode(t, f, ec2Client, nodeEnv[2].node)
+		err = waitForDaemonSet(t, f.KubeClient, namespace, nodedaemon.DiskMakerName, len(nodeEnv), retryInterval, timeout)
+		matcher.Expect(err).NotTo(gomega.HaveOccurred(), "waiting for diskmaker daemonset")
+		for storageClassName, expected := range devices {
+			verifyPVDevices(t, f, storageClassName, expected)
+		}
 	}
 
 }
@@ -173,13 +173,3 @@ func waitForOperatorToBeReady(t *testing.T, ctx *framework.TestCtx) error {
 	}
 	return nil
 }
-
-// env var config helpers
-
-func getOperatorImage() string {
-	return os.Getenv("IMAGE_LOCAL_DISKMAKER")
-}
-
-func getDiskMakerImage() string {
-	return os.Getenv("IMAGE_LOCAL_DISKMAKER")
-}
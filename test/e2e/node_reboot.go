@@ -0,0 +1,88 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/onsi/gomega"
+	"github.com/openshift/local-storage-operator/pkg/common"
+	"github.com/openshift/local-storage-operator/pkg/controller/nodedaemon"
+	framework "github.com/operator-framework/operator-sdk/pkg/test"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// pvDevices maps the names of the PVs to the physical device behind each of them:
+// its serial number or partition UUID, or its by-id link for devices that have neither
+func pvDevices(pvs []corev1.PersistentVolume) map[string]string {
+	devices := make(map[string]string, len(pvs))
+	for _, pv := range pvs {
+		device := pv.Annotations[common.PVDeviceIdentityAnnotation]
+		if device == "" {
+			device = pv.Annotations[common.PVDeviceByIDAnnotation]
+		}
+		devices[pv.Name] = device
+	}
+	return devices
+}
+
+// rebootAWSNode reboots the instance of the node and waits for the node to be ready again after booting.
+// the /dev/sdX names of the node's devices can change across the reboot.
+func rebootAWSNode(t *testing.T, f *framework.Framework, ec2Client *ec2.EC2, node corev1.Node) {
+	matcher := gomega.NewWithT(t)
+	instanceID, _, _, err := getAWSNodeInfo(node)
+	matcher.Expect(err).NotTo(gomega.HaveOccurred(), "getAWSNodeInfo")
+
+	current := &corev1.Node{}
+	err = f.Client.Get(context.TODO(), types.NamespacedName{Name: node.Name}, current)
+	matcher.Expect(err).NotTo(gomega.HaveOccurred(), "getting node %q", node.Name)
+	bootID := current.Status.NodeInfo.BootID
+
+	t.Logf("rebooting node %q (instance %q)", node.Name, instanceID)
+	_, err = ec2Client.RebootInstances(&ec2.RebootInstancesInput{InstanceIds: []*string{aws.String(instanceID)}})
+	matcher.Expect(err).NotTo(gomega.HaveOccurred(), "rebooting instance %q", instanceID)
+
+	matcher.Eventually(func() error {
+		err := f.Client.Get(context.TODO(), types.NamespacedName{Name: node.Name}, current)
+		if err != nil {
+			return err
+		}
+		if current.Status.NodeInfo.BootID == bootID {
+			return fmt.Errorf("node %q did not reboot yet", node.Name)
+		}
+		for _, condition := range current.Status.Conditions {
+			if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
+				return nil
+			}
+		}
+		return fmt.Errorf("node %q is not ready yet", node.Name)
+	}, time.Minute*15, time.Second*10).ShouldNot(gomega.HaveOccurred(), "waiting for node %q to reboot", node.Name)
+}
+
+// restartDiskMaker deletes the diskmaker pod of the node, so that it discovers the node's devices from scratch
+func restartDiskMaker(t *testing.T, f *framework.Framework, namespace string, node corev1.Node) {
+	matcher := gomega.NewWithT(t)
+	pods := &corev1.PodList{}
+	err := f.Client.List(context.TODO(), pods, client.InNamespace(namespace), client.MatchingLabels{"app": nodedaemon.DiskMakerName})
+	matcher.Expect(err).NotTo(gomega.HaveOccurred(), "listing diskmaker pods")
+	for i := range pods.Items {
+		if pods.Items[i].Spec.NodeName == node.Name {
+			t.Logf("restarting diskmaker pod %q on node %q", pods.Items[i].Name, node.Name)
+			eventuallyDelete(t, &pods.Items[i])
+		}
+	}
+}
+
+// verifyPVDevices checks that the PVs of the StorageClass are the PVs in expected,
+// and that each of them still points at the same physical device
+func verifyPVDevices(t *testing.T, f *framework.Framework, storageClassName string, expected map[string]string) {
+	matcher := gomega.NewWithT(t)
+	matcher.Eventually(func() map[string]string {
+		return pvDevices(eventuallyFindPVs(t, f, storageClassName, len(expected)))
+	}, time.Minute*5, time.Second*10).Should(gomega.Equal(expected), "checking the devices of the PVs of StorageClass %q", storageClassName)
+}
@@ -1,5 +1,6 @@
 package version
 
 var (
+	// Version of the operator, set at build time with -ldflags
 	Version = "0.0.1"
 )